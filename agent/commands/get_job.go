@@ -5,6 +5,7 @@ import (
 	"log"
 
 	agentgrpc "github.com/open-scheduler/agent/grpc"
+	"github.com/open-scheduler/agent/instancefiles"
 	"github.com/open-scheduler/agent/service/job"
 	"github.com/open-scheduler/agent/service/instance"
 )
@@ -13,8 +14,8 @@ type GetDeploymentCommand struct {
 	service *job.GetDeploymentService
 }
 
-func NewGetDeploymentCommand(grpcClient *agentgrpc.GrpcClient, instanceService *instance.SetInstanceDataService) *GetDeploymentCommand {
-	service, err := job.NewGetDeploymentService(grpcClient, instanceService)
+func NewGetDeploymentCommand(grpcClient *agentgrpc.GrpcClient, instanceService *instance.SetInstanceDataService, files *instancefiles.Store) *GetDeploymentCommand {
+	service, err := job.NewGetDeploymentService(grpcClient, instanceService, files)
 	if err != nil {
 		log.Fatalf("[GetDeploymentCommand] Failed to create service: %v", err)
 	}
@@ -2,23 +2,29 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/open-scheduler/agent/redact"
 	pb "github.com/open-scheduler/proto"
 )
 
 type GrpcClient struct {
 	serverAddr string
-	conn       *grpc.ClientConn
-	client     pb.CentroSchedulerServiceClient
-	mu         sync.RWMutex
+	// tlsConfig is nil for a connection without TLS
+	tlsConfig *tls.Config
+	conn      *grpc.ClientConn
+	client    pb.CentroSchedulerServiceClient
+	mu        sync.RWMutex
 }
 
 func NewGrpcClient(serverAddr string) (*GrpcClient, error) {
@@ -31,6 +37,13 @@ func NewGrpcClient(serverAddr string) (*GrpcClient, error) {
 	}, nil
 }
 
+// UseTLS makes Connect use TLS with config instead of a plain connection
+func (c *GrpcClient) UseTLS(config *tls.Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tlsConfig = config
+}
+
 func (c *GrpcClient) Connect(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -40,26 +53,73 @@ func (c *GrpcClient) Connect(ctx context.Context) error {
 		return nil
 	}
 
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.conn = conn
+	c.client = pb.NewCentroSchedulerServiceClient(conn)
+	log.Printf("[GrpcClient] Successfully connected to server")
+
+	return nil
+}
+
+// Reconnect replaces the connection, so a new client certificate is used
+func (c *GrpcClient) Reconnect(ctx context.Context) error {
+	c.mu.RLock()
+	old := c.conn
+	c.mu.RUnlock()
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.client = pb.NewCentroSchedulerServiceClient(conn)
+	c.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	log.Printf("[GrpcClient] Reconnected to server")
+	return nil
+}
+
+func (c *GrpcClient) dial(ctx context.Context) (*grpc.ClientConn, error) {
 	log.Printf("[GrpcClient] Connecting to server at %s", c.serverAddr)
 
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
+	transportCredentials := insecure.NewCredentials()
+	if c.tlsConfig != nil {
+		transportCredentials = credentials.NewTLS(c.tlsConfig)
+	}
 	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(transportCredentials),
 		grpc.WithBlock(),
 	}
 
 	conn, err := grpc.DialContext(ctx, c.serverAddr, opts...)
 	if err != nil {
-		return fmt.Errorf("failed to connect to gRPC server: %w", err)
+		return nil, fmt.Errorf("failed to connect to gRPC server: %w", err)
 	}
+	return conn, nil
+}
 
-	c.conn = conn
-	c.client = pb.NewCentroSchedulerServiceClient(conn)
-	log.Printf("[GrpcClient] Successfully connected to server")
-
-	return nil
+// withToken adds the node credential to the metadata of a call. Agents with
+// a client certificate need none.
+func withToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	md := metadata.New(map[string]string{
+		"authorization": fmt.Sprintf("Bearer %s", token),
+	})
+	return metadata.NewOutgoingContext(ctx, md)
 }
 
 func (c *GrpcClient) SendHeartbeat(ctx context.Context, nodeID string, token string, ramMB, cpuCores, diskMB float32, clusterName string, meta map[string]string) (*pb.HeartbeatResponse, error) {
@@ -71,10 +131,7 @@ func (c *GrpcClient) SendHeartbeat(ctx context.Context, nodeID string, token str
 		return nil, fmt.Errorf("gRPC client is not connected")
 	}
 
-	md := metadata.New(map[string]string{
-		"authorization": fmt.Sprintf("Bearer %s", token),
-	})
-	ctx = metadata.NewOutgoingContext(ctx, md)
+	ctx = withToken(ctx, token)
 
 	req := &pb.HeartbeatRequest{
 		NodeId:            nodeID,
@@ -107,10 +164,7 @@ func (c *GrpcClient) GetDeployment(ctx context.Context, nodeID string, token str
 		return nil, fmt.Errorf("gRPC client is not connected")
 	}
 
-	md := metadata.New(map[string]string{
-		"authorization": fmt.Sprintf("Bearer %s", token),
-	})
-	ctx = metadata.NewOutgoingContext(ctx, md)
+	ctx = withToken(ctx, token)
 
 	req := &pb.GetDeploymentRequest{
 		NodeId: nodeID,
@@ -137,16 +191,13 @@ func (c *GrpcClient) UpdateStatus(ctx context.Context, nodeID string, token stri
 		return nil, fmt.Errorf("gRPC client is not connected")
 	}
 
-	md := metadata.New(map[string]string{
-		"authorization": fmt.Sprintf("Bearer %s", token),
-	})
-	ctx = metadata.NewOutgoingContext(ctx, md)
+	ctx = withToken(ctx, token)
 
 	req := &pb.UpdateStatusRequest{
 		NodeId:           nodeID,
 		DeploymentId:     deploymentID,
 		DeploymentStatus: status,
-		StatusMessage:    detail,
+		StatusMessage:    redact.String(detail),
 		Timestamp:        timestamp,
 	}
 
@@ -172,10 +223,12 @@ func (c *GrpcClient) SetInstanceData(ctx context.Context, nodeID string, token s
 		return nil, fmt.Errorf("gRPC client is not connected")
 	}
 
-	md := metadata.New(map[string]string{
-		"authorization": fmt.Sprintf("Bearer %s", token),
-	})
-	ctx = metadata.NewOutgoingContext(ctx, md)
+	ctx = withToken(ctx, token)
+
+	// Secrets may be passed as command arguments
+	instanceData = proto.Clone(instanceData).(*pb.InstanceData)
+	instanceData.Command = redact.Strings(instanceData.Command)
+	instanceData.Args = redact.Strings(instanceData.Args)
 
 	req := &pb.SetInstanceDataRequest{
 		NodeId:       nodeID,
@@ -197,6 +250,118 @@ func (c *GrpcClient) SetInstanceData(ctx context.Context, nodeID string, token s
 	return resp, nil
 }
 
+// GetDeploymentSecrets gets the values of the secrets of a deployment assigned to the node
+func (c *GrpcClient) GetDeploymentSecrets(ctx context.Context, nodeID string, token string, deploymentID string) (*pb.GetDeploymentSecretsResponse, error) {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return nil, fmt.Errorf("gRPC client is not connected")
+	}
+
+	ctx = withToken(ctx, token)
+
+	req := &pb.GetDeploymentSecretsRequest{
+		NodeId:       nodeID,
+		DeploymentId: deploymentID,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := client.GetDeploymentSecrets(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("GetDeploymentSecrets RPC failed: %w", err)
+	}
+	return resp, nil
+}
+
+// GetDeploymentConfigs gets the rendered config files of a deployment assigned to the node
+func (c *GrpcClient) GetDeploymentConfigs(ctx context.Context, nodeID string, token string, deploymentID string) (*pb.GetDeploymentConfigsResponse, error) {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return nil, fmt.Errorf("gRPC client is not connected")
+	}
+
+	ctx = withToken(ctx, token)
+
+	req := &pb.GetDeploymentConfigsRequest{
+		NodeId:       nodeID,
+		DeploymentId: deploymentID,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := client.GetDeploymentConfigs(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("GetDeploymentConfigs RPC failed: %w", err)
+	}
+	return resp, nil
+}
+
+// JoinNode exchanges a join token and a CSR for the first client certificate
+// of the node. token is the node credential, if the agent has one; nodes
+// with credentials can only join when they present one.
+func (c *GrpcClient) JoinNode(ctx context.Context, nodeID string, token string, joinToken string, csrPEM []byte) (*pb.JoinNodeResponse, error) {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return nil, fmt.Errorf("gRPC client is not connected")
+	}
+
+	ctx = withToken(ctx, token)
+
+	req := &pb.JoinNodeRequest{
+		NodeId:    nodeID,
+		JoinToken: joinToken,
+		CsrPem:    csrPEM,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	resp, err := client.JoinNode(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("JoinNode RPC failed: %w", err)
+	}
+	return resp, nil
+}
+
+// RenewCertificate exchanges a CSR for a new client certificate, the call is
+// authenticated with the current one
+func (c *GrpcClient) RenewCertificate(ctx context.Context, nodeID string, token string, csrPEM []byte) (*pb.RenewCertificateResponse, error) {
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+
+	if client == nil {
+		return nil, fmt.Errorf("gRPC client is not connected")
+	}
+
+	ctx = withToken(ctx, token)
+
+	req := &pb.RenewCertificateRequest{
+		NodeId: nodeID,
+		CsrPem: csrPEM,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	resp, err := client.RenewCertificate(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("RenewCertificate RPC failed: %w", err)
+	}
+	return resp, nil
+}
+
 func (c *GrpcClient) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -0,0 +1,255 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Files of the node identity in its directory
+const (
+	identityKeyFile  = "node.key"
+	identityCertFile = "node.crt"
+	identityCAFile   = "ca.crt"
+)
+
+// renewCheckInterval is how often the certificate is checked for renewal
+const renewCheckInterval = time.Minute
+
+// NodeIdentity is the client certificate an agent authenticates with. It is
+// kept in a directory and renewed after two thirds of its lifetime.
+type NodeIdentity struct {
+	dir    string
+	nodeID string
+	caPool *x509.CertPool
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// LoadNodeIdentity reads the certificate of a node from dir if it has one.
+// Centro is verified with the CA in caFile, or with the CA saved in dir when
+// the node joined if caFile is empty. It returns nil if there is neither,
+// then the agent connects without TLS.
+func LoadNodeIdentity(dir, nodeID, caFile string) (*NodeIdentity, error) {
+	if caFile == "" {
+		caFile = filepath.Join(dir, identityCAFile)
+		if _, err := os.Stat(caFile); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificate found in %s", caFile)
+	}
+
+	identity := &NodeIdentity{
+		dir:    dir,
+		nodeID: nodeID,
+		caPool: caPool,
+	}
+
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, identityCertFile), filepath.Join(dir, identityKeyFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[NodeIdentity] Ignoring the certificate in %s: %v", dir, err)
+		}
+		return identity, nil
+	}
+	if cert.Leaf.Subject.CommonName != nodeID {
+		log.Printf("[NodeIdentity] Ignoring the certificate in %s, it belongs to node %s", dir, cert.Leaf.Subject.CommonName)
+		return identity, nil
+	}
+	identity.cert = &cert
+	return identity, nil
+}
+
+// HasCertificate reports whether the node has a certificate that has not expired
+func (i *NodeIdentity) HasCertificate() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.cert != nil && time.Now().Before(i.cert.Leaf.NotAfter)
+}
+
+// TLSConfig verifies Centro with the CA and presents the current certificate
+// of the node, or none before it joined
+func (i *NodeIdentity) TLSConfig() *tls.Config {
+	return &tls.Config{
+		RootCAs:    i.caPool,
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			i.mu.RLock()
+			defer i.mu.RUnlock()
+			if i.cert == nil {
+				return &tls.Certificate{}, nil
+			}
+			return i.cert, nil
+		},
+	}
+}
+
+// Join gets the first certificate of the node with a join token. The client
+// has to reconnect afterwards to present it. token is the node credential, if
+// the agent has one.
+func (i *NodeIdentity) Join(ctx context.Context, client *GrpcClient, token string, joinToken string) error {
+	key, csrPEM, err := i.newKeyAndCSR()
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.JoinNode(ctx, i.nodeID, token, joinToken, csrPEM)
+	if err != nil {
+		return err
+	}
+	if !resp.Accepted {
+		return fmt.Errorf("centro refused to join: %s", resp.ResponseMessage)
+	}
+
+	if err := i.save(key, resp.CertificatePem, resp.CaCertificatePem); err != nil {
+		return err
+	}
+	log.Printf("[NodeIdentity] Joined as node %s, certificate valid until %s", i.nodeID, i.notAfter().Format(time.RFC3339))
+	return nil
+}
+
+// StartRenewal renews the certificate in the background until ctx is done.
+// token is the node credential, if the agent has one.
+func (i *NodeIdentity) StartRenewal(ctx context.Context, client *GrpcClient, token string) {
+	go func() {
+		ticker := time.NewTicker(renewCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			if time.Now().After(i.renewAt()) {
+				if err := i.renew(ctx, client, token); err != nil {
+					log.Printf("[NodeIdentity] Failed to renew the certificate, valid until %s: %v", i.notAfter().Format(time.RFC3339), err)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (i *NodeIdentity) renew(ctx context.Context, client *GrpcClient, token string) error {
+	key, csrPEM, err := i.newKeyAndCSR()
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.RenewCertificate(ctx, i.nodeID, token, csrPEM)
+	if err != nil {
+		return err
+	}
+	if !resp.Accepted {
+		return fmt.Errorf("centro refused to renew: %s", resp.ResponseMessage)
+	}
+
+	if err := i.save(key, resp.CertificatePem, resp.CaCertificatePem); err != nil {
+		return err
+	}
+	log.Printf("[NodeIdentity] Certificate renewed, valid until %s", i.notAfter().Format(time.RFC3339))
+
+	// The open connection keeps presenting the old certificate
+	return client.Reconnect(ctx)
+}
+
+// renewAt is when two thirds of the lifetime of the certificate have passed
+func (i *NodeIdentity) renewAt() time.Time {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.cert == nil {
+		return time.Now()
+	}
+	leaf := i.cert.Leaf
+	return leaf.NotBefore.Add(leaf.NotAfter.Sub(leaf.NotBefore) * 2 / 3)
+}
+
+func (i *NodeIdentity) notAfter() time.Time {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	if i.cert == nil {
+		return time.Time{}
+	}
+	return i.cert.Leaf.NotAfter
+}
+
+func (i *NodeIdentity) newKeyAndCSR() (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: i.nodeID},
+	}, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// save writes the key, certificate and CA to the directory and starts using them
+func (i *NodeIdentity) save(key *ecdsa.PrivateKey, certPEM, caPEM []byte) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key: %w", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid certificate from centro: %w", err)
+	}
+
+	if err := os.MkdirAll(i.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", i.dir, err)
+	}
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{identityKeyFile, keyPEM},
+		{identityCertFile, certPEM},
+		{identityCAFile, caPEM},
+	}
+	for _, file := range files {
+		if err := writeFileAtomic(filepath.Join(i.dir, file.name), file.data); err != nil {
+			return err
+		}
+	}
+
+	i.mu.Lock()
+	i.cert = &cert
+	i.mu.Unlock()
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
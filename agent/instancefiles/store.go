@@ -0,0 +1,136 @@
+// Package instancefiles keeps the files the agent mounts into instances, such
+// as secrets and configs, in a directory per deployment. The directory is
+// removed with the instance.
+package instancefiles
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store keeps the files of every deployment in a directory named after the
+// deployment ID below its root
+type Store struct {
+	root string
+
+	mu sync.Mutex
+	// busy deployments are being started, Prune leaves their files alone
+	// although no instance exists yet
+	busy map[string]bool
+}
+
+func NewStore(root string) (*Store, error) {
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create instance directory: %w", err)
+	}
+	return &Store{root: root, busy: make(map[string]bool)}, nil
+}
+
+func (s *Store) dir(deploymentID string) (string, error) {
+	if deploymentID == "" || deploymentID == "." || deploymentID == ".." || filepath.Base(deploymentID) != deploymentID {
+		return "", fmt.Errorf("invalid deployment ID %q", deploymentID)
+	}
+	return filepath.Join(s.root, deploymentID), nil
+}
+
+// Acquire marks a deployment as busy while its instance is being created,
+// until Release is called
+func (s *Store) Acquire(deploymentID string) {
+	s.mu.Lock()
+	s.busy[deploymentID] = true
+	s.mu.Unlock()
+}
+
+// Release ends the start of a deployment, its files are pruned once it has no instance
+func (s *Store) Release(deploymentID string) {
+	s.mu.Lock()
+	delete(s.busy, deploymentID)
+	s.mu.Unlock()
+}
+
+// Busy reports whether the instance of a deployment is being created
+func (s *Store) Busy(deploymentID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.busy[deploymentID]
+}
+
+// WriteFile writes a file of a deployment below kind, e.g. secrets, and
+// returns its path on the host. Files are readable by everyone, since the
+// user inside the instance is not known; the directories above them are only
+// accessible to the agent.
+func (s *Store) WriteFile(deploymentID, kind, name string, data []byte) (string, error) {
+	dir, err := s.dir(deploymentID)
+	if err != nil {
+		return "", err
+	}
+	if filepath.Base(name) != name || filepath.Base(kind) != kind {
+		return "", fmt.Errorf("invalid file name %s/%s", kind, name)
+	}
+
+	dir = filepath.Join(dir, kind)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create directory of deployment %s: %w", deploymentID, err)
+	}
+	path := filepath.Join(dir, name)
+	// Write a new file, so that an instance that still has the old one
+	// mounted keeps its content
+	tmp, err := os.CreateTemp(dir, "."+name+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o444)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// Remove deletes the files of a deployment
+func (s *Store) Remove(deploymentID string) error {
+	dir, err := s.dir(deploymentID)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove files of deployment %s: %w", deploymentID, err)
+	}
+	return nil
+}
+
+// Prune deletes the files of deployments that are neither in keep nor busy
+// and returns their IDs
+func (s *Store) Prune(keep map[string]bool) ([]string, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance directory: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removed []string
+	for _, entry := range entries {
+		deploymentID := entry.Name()
+		if !entry.IsDir() || keep[deploymentID] || s.busy[deploymentID] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.root, deploymentID)); err != nil {
+			return removed, fmt.Errorf("failed to remove files of deployment %s: %w", deploymentID, err)
+		}
+		removed = append(removed, deploymentID)
+	}
+	return removed, nil
+}
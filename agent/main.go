@@ -6,10 +6,13 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	"github.com/open-scheduler/agent/commands"
 	agentgrpc "github.com/open-scheduler/agent/grpc"
+	"github.com/open-scheduler/agent/instancefiles"
+	"github.com/open-scheduler/agent/redact"
 	cleanupservice "github.com/open-scheduler/agent/service/cleanup"
 	instanceservice "github.com/open-scheduler/agent/service/instance"
 	statusservice "github.com/open-scheduler/agent/service/status"
@@ -18,9 +21,15 @@ import (
 
 func main() {
 	serverFlag := flag.String("server", "", "Centro server address (overrides CENTRO_SERVER_ADDR env var)")
-	tokenFlag := flag.String("token", "", "Authentication token (overrides TOKEN env var)")
+	tokenFlag := flag.String("token", "", "Node credential from 'osctl node credential create' (overrides TOKEN env var)")
+	caFileFlag := flag.String("ca-file", "", "CA certificate from 'osctl node ca' to connect with TLS (overrides CENTRO_CA_FILE env var)")
+	joinTokenFlag := flag.String("join-token", "", "Join token from 'osctl node join-token create' to join without approval and get a client certificate (overrides JOIN_TOKEN env var)")
+	certDirFlag := flag.String("cert-dir", "", "Directory the client certificate is kept in (overrides CERT_DIR env var)")
+	instanceDirFlag := flag.String("instance-dir", "", "Directory the files mounted into instances, like secrets, are kept in (overrides INSTANCE_DIR env var)")
 	flag.Parse()
 
+	// Secret values of deployments never reach the log
+	log.SetOutput(redact.NewWriter(os.Stderr))
 	log.Println("Starting NodeAgent...")
 
 	serverAddr := *serverFlag
@@ -35,9 +44,6 @@ func main() {
 	if token == "" {
 		token = os.Getenv("TOKEN")
 	}
-	if token == "" {
-		log.Fatalf("Token not provided. Use --token flag or set TOKEN environment variable")
-	}
 
 	nodeID := os.Getenv("NODE_ID")
 	if nodeID == "" {
@@ -48,10 +54,61 @@ func main() {
 		nodeID = hostname
 	}
 
+	caFile := *caFileFlag
+	if caFile == "" {
+		caFile = os.Getenv("CENTRO_CA_FILE")
+	}
+	joinToken := *joinTokenFlag
+	if joinToken == "" {
+		joinToken = os.Getenv("JOIN_TOKEN")
+	}
+	certDir := *certDirFlag
+	if certDir == "" {
+		certDir = os.Getenv("CERT_DIR")
+	}
+	if certDir == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			log.Fatalf("Failed to get config directory, use --cert-dir flag or set CERT_DIR environment variable: %v", err)
+		}
+		certDir = filepath.Join(configDir, "osagent")
+	}
+	instanceDir := *instanceDirFlag
+	if instanceDir == "" {
+		instanceDir = os.Getenv("INSTANCE_DIR")
+	}
+	if instanceDir == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			log.Fatalf("Failed to get config directory, use --instance-dir flag or set INSTANCE_DIR environment variable: %v", err)
+		}
+		instanceDir = filepath.Join(configDir, "osagent", "instances")
+	}
+	files, err := instancefiles.NewStore(instanceDir)
+	if err != nil {
+		log.Fatalf("Failed to open instance directory: %v", err)
+	}
+
+	// Without a CA the agent connects without TLS, which only works with
+	// a Centro started with -grpc-insecure
+	identity, err := agentgrpc.LoadNodeIdentity(certDir, nodeID, caFile)
+	if err != nil {
+		log.Fatalf("Failed to load node certificate: %v", err)
+	}
+	if identity == nil && token == "" {
+		log.Fatalf("Token not provided. Use --token flag or set TOKEN environment variable, or --ca-file and --join-token to join with a certificate")
+	}
+	if identity != nil && !identity.HasCertificate() && joinToken == "" && token == "" {
+		log.Fatalf("No valid certificate in %s. Use --join-token flag or set JOIN_TOKEN environment variable", certDir)
+	}
+
 	grpcClient, err := agentgrpc.NewGrpcClient(serverAddr)
 	if err != nil {
 		log.Fatalf("Failed to create gRPC client: %v", err)
 	}
+	if identity != nil {
+		grpcClient.UseTLS(identity.TLSConfig())
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -65,6 +122,33 @@ func main() {
 		}
 	}()
 
+	// Without TLS the join token only gets the node approved
+	if identity == nil && joinToken != "" {
+		resp, err := grpcClient.JoinNode(ctx, nodeID, token, joinToken, nil)
+		if err != nil {
+			log.Fatalf("Failed to join Centro: %v", err)
+		}
+		if !resp.Accepted {
+			log.Fatalf("Centro refused to join: %s", resp.ResponseMessage)
+		}
+		log.Printf("Joined Centro: %s", resp.ResponseMessage)
+	}
+
+	if identity != nil {
+		if !identity.HasCertificate() && joinToken != "" {
+			if err := identity.Join(ctx, grpcClient, token, joinToken); err != nil {
+				log.Fatalf("Failed to join Centro: %v", err)
+			}
+			// The connection was opened without the new certificate
+			if err := grpcClient.Reconnect(ctx); err != nil {
+				log.Fatalf("Failed to connect to gRPC server: %v", err)
+			}
+		}
+		if identity.HasCertificate() {
+			identity.StartRenewal(ctx, grpcClient, token)
+		}
+	}
+
 	log.Println("Successfully connected to Centro server")
 
 	sigChan := make(chan os.Signal, 1)
@@ -101,13 +185,13 @@ func main() {
 		log.Fatalf("Failed to create SetInstanceDataService: %v", err)
 	}
 
-	cleanupService, err := cleanupservice.NewCleanupService(driver, nodeID)
+	cleanupService, err := cleanupservice.NewCleanupService(driver, nodeID, files)
 	if err != nil {
 		log.Fatalf("Failed to create CleanupService: %v", err)
 	}
 
 	executor.Register(commands.NewHeartbeatCommand(grpcClient))
-	executor.Register(commands.NewGetDeploymentCommand(grpcClient, instanceService))
+	executor.Register(commands.NewGetDeploymentCommand(grpcClient, instanceService, files))
 	executor.Register(commands.NewUpdateStatusCommand(statusService))
 	executor.Register(commands.NewSetInstanceDataCommand(instanceService))
 	executor.Register(commands.NewCleanUpInstancesCommand(cleanupService))
@@ -0,0 +1,126 @@
+// Package redact keeps the values of secrets out of what the agent logs and
+// reports to Centro. The values of every deployment are registered when its
+// secrets are resolved and replaced wherever they appear.
+package redact
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secret values
+const Redacted = "[REDACTED]"
+
+// minLength is the length of the shortest value that is redacted, shorter
+// values would mangle unrelated text
+const minLength = 4
+
+var (
+	mu     sync.RWMutex
+	values = make(map[string][]string)
+	// sorted holds every value, longest first, so that a value containing
+	// another is replaced as a whole
+	sorted []string
+)
+
+// Register adds the secret values of a deployment. Multi-line values, like
+// keys, are also redacted line by line.
+func Register(deploymentID string, secrets [][]byte) {
+	var list []string
+	for _, secret := range secrets {
+		value := string(secret)
+		list = appendValue(list, value)
+		if strings.Contains(value, "\n") {
+			for _, line := range strings.Split(value, "\n") {
+				list = appendValue(list, strings.TrimSpace(line))
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	values[deploymentID] = list
+	rebuild()
+}
+
+func appendValue(list []string, value string) []string {
+	if len(value) < minLength {
+		return list
+	}
+	return append(list, value)
+}
+
+// Forget removes the values of a deployment once its instance is gone
+func Forget(deploymentID string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := values[deploymentID]; ok {
+		delete(values, deploymentID)
+		rebuild()
+	}
+}
+
+// Deployments returns the IDs of the deployments with registered values
+func Deployments() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	ids := make([]string, 0, len(values))
+	for deploymentID := range values {
+		ids = append(ids, deploymentID)
+	}
+	return ids
+}
+
+func rebuild() {
+	sorted = sorted[:0]
+	for _, list := range values {
+		sorted = append(sorted, list...)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+}
+
+// String replaces every registered value in s
+func String(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, value := range sorted {
+		if strings.Contains(s, value) {
+			s = strings.ReplaceAll(s, value, Redacted)
+		}
+	}
+	return s
+}
+
+// Strings replaces every registered value in a copy of list
+func Strings(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	redacted := make([]string, len(list))
+	for i, s := range list {
+		redacted[i] = String(s)
+	}
+	return redacted
+}
+
+// writer redacts everything written to it
+type writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a writer that redacts before writing to w, for the log
+// output of the agent. The log package writes each entry with a single call.
+func NewWriter(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,93 @@
+package redact
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	Register("web", [][]byte{
+		[]byte("hunter22"),
+		[]byte("abc"),
+		[]byte("-----BEGIN KEY-----\nMIIBOgIBAAJB\n-----END KEY-----"),
+	})
+	Register("db", [][]byte{[]byte("hunter22-long")})
+	defer Forget("web")
+	defer Forget("db")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "value", in: "password=hunter22", want: "password=[REDACTED]"},
+		{name: "every occurrence", in: "hunter22 hunter22", want: "[REDACTED] [REDACTED]"},
+		{name: "longer value first", in: "token hunter22-long", want: "token [REDACTED]"},
+		{name: "short values are kept", in: "abc", want: "abc"},
+		{name: "whole multi-line value", in: "key: -----BEGIN KEY-----\nMIIBOgIBAAJB\n-----END KEY-----", want: "key: [REDACTED]"},
+		{name: "line of a multi-line value", in: "line MIIBOgIBAAJB", want: "line [REDACTED]"},
+		{name: "other text", in: "nothing to hide", want: "nothing to hide"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := String(tt.in); got != tt.want {
+				t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForget(t *testing.T) {
+	Register("web", [][]byte{[]byte("hunter22")})
+	Register("db", [][]byte{[]byte("swordfish")})
+	Forget("web")
+	defer Forget("db")
+
+	if got := String("hunter22 swordfish"); got != "hunter22 [REDACTED]" {
+		t.Errorf("got %q", got)
+	}
+	if got := Deployments(); !reflect.DeepEqual(got, []string{"db"}) {
+		t.Errorf("deployments %v, want [db]", got)
+	}
+}
+
+func TestRegisterReplaces(t *testing.T) {
+	Register("web", [][]byte{[]byte("old-secret")})
+	Register("web", [][]byte{[]byte("new-secret")})
+	defer Forget("web")
+
+	if got := String("old-secret new-secret"); got != "old-secret [REDACTED]" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	Register("web", [][]byte{[]byte("hunter22")})
+	defer Forget("web")
+
+	list := []string{"PASSWORD=hunter22", "USER=admin"}
+	got := Strings(list)
+	if want := []string{"PASSWORD=[REDACTED]", "USER=admin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if list[0] != "PASSWORD=hunter22" {
+		t.Error("the list was changed")
+	}
+	if Strings(nil) != nil {
+		t.Error("nil list became non-nil")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	Register("web", [][]byte{[]byte("hunter22")})
+	defer Forget("web")
+
+	var buf bytes.Buffer
+	logger := log.New(NewWriter(&buf), "", 0)
+	logger.Printf("connecting with password %s", "hunter22")
+	if got := buf.String(); got != "connecting with password [REDACTED]\n" {
+		t.Errorf("logged %q", got)
+	}
+}
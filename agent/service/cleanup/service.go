@@ -5,15 +5,18 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/open-scheduler/agent/instancefiles"
+	"github.com/open-scheduler/agent/redact"
 	"github.com/open-scheduler/agent/taskdriver"
 )
 
 type CleanupService struct {
 	driver taskdriver.Driver
 	nodeID string
+	files  *instancefiles.Store
 }
 
-func NewCleanupService(driver taskdriver.Driver, nodeID string) (*CleanupService, error) {
+func NewCleanupService(driver taskdriver.Driver, nodeID string, files *instancefiles.Store) (*CleanupService, error) {
 	if driver == nil {
 		return nil, fmt.Errorf("driver cannot be nil")
 	}
@@ -21,6 +24,7 @@ func NewCleanupService(driver taskdriver.Driver, nodeID string) (*CleanupService
 	return &CleanupService{
 		driver: driver,
 		nodeID: nodeID,
+		files:  files,
 	}, nil
 }
 
@@ -43,6 +47,8 @@ func (s *CleanupService) Execute(ctx context.Context, nodeID string, token strin
 
 	stoppedCount := 0
 	cleanedCount := 0
+	// Deployments whose instances are left keep their files
+	remaining := make(map[string]bool)
 
 	// Filter for stopped instances and stop them
 	for _, instance := range instances {
@@ -55,14 +61,33 @@ func (s *CleanupService) Execute(ctx context.Context, nodeID string, token strin
 			err := s.driver.StopInstance(ctx, instance.InstanceId)
 			if err != nil {
 				log.Printf("[CleanupService] Failed to stop instance %s: %v", instance.InstanceId, err)
+				remaining[instance.Labels["open-scheduler.deployment-id"]] = true
 				continue
 			}
 
 			cleanedCount++
 			log.Printf("[CleanupService] Successfully cleaned up instance: %s", instance.InstanceId)
+			continue
 		}
+		remaining[instance.Labels["open-scheduler.deployment-id"]] = true
 	}
 
 	log.Printf("[CleanupService] Cleanup complete - Found: %d stopped, Cleaned: %d", stoppedCount, cleanedCount)
+
+	if s.files != nil {
+		removed, err := s.files.Prune(remaining)
+		for _, deploymentID := range removed {
+			log.Printf("[CleanupService] Removed files of deployment %s", deploymentID)
+		}
+		if err != nil {
+			log.Printf("[CleanupService] Failed to remove instance files: %v", err)
+		}
+		// Secrets of deployments without an instance are no longer redacted
+		for _, deploymentID := range redact.Deployments() {
+			if !remaining[deploymentID] && !s.files.Busy(deploymentID) {
+				redact.Forget(deploymentID)
+			}
+		}
+	}
 	return nil
 }
@@ -97,6 +97,19 @@ func getAvailableDiskMB() float64 {
 	return availableMB
 }
 
+// nodeLabels parses NODE_LABELS ("zone=us-east,disk=ssd") into node metadata
+// that placement constraints can match with node.meta.<key>
+func nodeLabels() map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("NODE_LABELS"), ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); ok && key != "" {
+			labels[key] = strings.TrimSpace(value)
+		}
+	}
+	return labels
+}
+
 func (h *HeartbeatService) Execute(ctx context.Context, nodeID string, token string) error {
 	// Get cluster name from environment variable, default to "default"
 	clusterName := os.Getenv("CLUSTER_NAME")
@@ -107,6 +120,11 @@ func (h *HeartbeatService) Execute(ctx context.Context, nodeID string, token str
 	metadata := map[string]string{
 		"version": "1.0.0",
 		"region":  "us-west-1",
+		"os":      runtime.GOOS,
+		"arch":    runtime.GOARCH,
+	}
+	for key, value := range nodeLabels() {
+		metadata[key] = value
 	}
 
 	ramAvailable := getAvailableMemoryMB()
@@ -7,24 +7,32 @@ import (
 	"time"
 
 	agentgrpc "github.com/open-scheduler/agent/grpc"
+	"github.com/open-scheduler/agent/instancefiles"
+	"github.com/open-scheduler/agent/redact"
 	"github.com/open-scheduler/agent/service/instance"
 	"github.com/open-scheduler/agent/taskdriver"
 	pb "github.com/open-scheduler/proto"
+	"google.golang.org/protobuf/proto"
 )
 
 type GetDeploymentService struct {
 	grpcClient *agentgrpc.GrpcClient
 	instanceService *instance.SetInstanceDataService
+	files *instancefiles.Store
 }
 
-func NewGetDeploymentService(grpcClient *agentgrpc.GrpcClient, instanceService *instance.SetInstanceDataService) (*GetDeploymentService, error) {
+func NewGetDeploymentService(grpcClient *agentgrpc.GrpcClient, instanceService *instance.SetInstanceDataService, files *instancefiles.Store) (*GetDeploymentService, error) {
 	if grpcClient == nil {
 		return nil, fmt.Errorf("gRPC client cannot be nil")
 	}
+	if files == nil {
+		return nil, fmt.Errorf("instance file store cannot be nil")
+	}
 
 	return &GetDeploymentService{
 		grpcClient: grpcClient,
 		instanceService: instanceService,
+		files: files,
 	}, nil
 }
 
@@ -59,17 +67,121 @@ func (s *GetDeploymentService) handleDeployment(ctx context.Context, deployment
 	log.Printf("[GetDeploymentService] Running deployment: %s (%s) with driver: %s", deployment.DeploymentName, deployment.DeploymentId, deployment.DriverType)
 
 	s.updateDeploymentStatus(ctx, deployment.DeploymentId, nodeID, token, "provisioning", fmt.Sprintf("Provisioning deployment: %s", deployment.DeploymentName))
-	
-	id, err := driver.Run(ctx, deployment)
+
+	s.files.Acquire(deployment.DeploymentId)
+	defer s.files.Release(deployment.DeploymentId)
+	prepared, err := s.withSecrets(ctx, deployment, nodeID, token)
+	if err == nil {
+		prepared, err = s.withConfigs(ctx, prepared, nodeID, token)
+	}
+	if err == nil {
+		var id string
+		id, err = driver.Run(ctx, prepared)
+		if err == nil {
+			return s.started(ctx, deployment, nodeID, token, id)
+		}
+	}
+
+	// Report failure to Centro
+	errMsg := fmt.Sprintf("Deployment execution failed: %v", err)
+	log.Printf("[GetDeploymentService] Deployment %s (%s) failed: %s", deployment.DeploymentName, deployment.DeploymentId, errMsg)
+	s.updateDeploymentStatus(ctx, deployment.DeploymentId, nodeID, token, "failed", errMsg)
+	if err := s.files.Remove(deployment.DeploymentId); err != nil {
+		log.Printf("[GetDeploymentService] %v", err)
+	}
+	redact.Forget(deployment.DeploymentId)
+	return fmt.Errorf("failed to run deployment %s: %w", deployment.DeploymentName, err)
+}
+
+// withSecrets returns a copy of a deployment with the values of its secrets
+// in its environment and in files mounted into the instance. The values are
+// only kept by the instance and the redactor.
+func (s *GetDeploymentService) withSecrets(ctx context.Context, deployment *pb.Deployment, nodeID string, token string) (*pb.Deployment, error) {
+	if len(deployment.Secrets) == 0 {
+		return deployment, nil
+	}
+
+	resp, err := s.grpcClient.GetDeploymentSecrets(ctx, nodeID, token, deployment.DeploymentId)
 	if err != nil {
-		// Report failure to Centro
-		errMsg := fmt.Sprintf("Deployment execution failed: %v", err)
-		log.Printf("[GetDeploymentService] Deployment %s (%s) failed: %s", deployment.DeploymentName, deployment.DeploymentId, errMsg)
-		s.updateDeploymentStatus(ctx, deployment.DeploymentId, nodeID, token, "failed", errMsg)
-		return fmt.Errorf("failed to run deployment %s: %w", deployment.DeploymentName, err)
+		return nil, fmt.Errorf("failed to get secrets: %w", err)
+	}
+	if !resp.Accepted {
+		return nil, fmt.Errorf("failed to get secrets: %s", resp.ResponseMessage)
+	}
+
+	values := make([][]byte, 0, len(resp.Secrets))
+	for _, secret := range resp.Secrets {
+		values = append(values, secret.Value)
+	}
+	redact.Register(deployment.DeploymentId, values)
+
+	prepared := proto.Clone(deployment).(*pb.Deployment)
+	if prepared.EnvironmentVariables == nil {
+		prepared.EnvironmentVariables = make(map[string]string)
 	}
+	for i, secret := range resp.Secrets {
+		if secret.Env != "" {
+			prepared.EnvironmentVariables[secret.Env] = string(secret.Value)
+			continue
+		}
+		if prepared.DriverType == "process" {
+			return nil, fmt.Errorf("secret %s: the process driver cannot mount files", secret.Secret)
+		}
+		hostPath, err := s.files.WriteFile(deployment.DeploymentId, "secrets", fmt.Sprintf("%d-%s", i, secret.Secret), secret.Value)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %w", secret.Secret, err)
+		}
+		prepared.VolumeMounts = append(prepared.VolumeMounts, &pb.Volume{
+			SourcePath: hostPath,
+			TargetPath: secret.File,
+			ReadOnly:   true,
+			Type:       "bind",
+		})
+	}
+
+	log.Printf("[GetDeploymentService] Resolved %d secrets of deployment %s", len(resp.Secrets), deployment.DeploymentId)
+	return prepared, nil
+}
+
+// withConfigs returns a copy of a deployment with the files of its configs,
+// rendered by Centro, mounted into the instance
+func (s *GetDeploymentService) withConfigs(ctx context.Context, deployment *pb.Deployment, nodeID string, token string) (*pb.Deployment, error) {
+	if len(deployment.Configs) == 0 {
+		return deployment, nil
+	}
+	if deployment.DriverType == "process" {
+		return nil, fmt.Errorf("the process driver cannot mount config files")
+	}
+
+	resp, err := s.grpcClient.GetDeploymentConfigs(ctx, nodeID, token, deployment.DeploymentId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configs: %w", err)
+	}
+	if !resp.Accepted {
+		return nil, fmt.Errorf("failed to get configs: %s", resp.ResponseMessage)
+	}
+
+	prepared := proto.Clone(deployment).(*pb.Deployment)
+	for i, config := range resp.Configs {
+		hostPath, err := s.files.WriteFile(deployment.DeploymentId, "configs", fmt.Sprintf("%d-%s", i, config.Config), config.Content)
+		if err != nil {
+			return nil, fmt.Errorf("config %s: %w", config.Config, err)
+		}
+		prepared.VolumeMounts = append(prepared.VolumeMounts, &pb.Volume{
+			SourcePath: hostPath,
+			TargetPath: config.File,
+			ReadOnly:   true,
+			Type:       "bind",
+		})
+	}
+
+	log.Printf("[GetDeploymentService] Wrote %d config files of deployment %s", len(resp.Configs), deployment.DeploymentId)
+	return prepared, nil
+}
 
-	err = s.instanceService.SetInstanceData(ctx, nodeID, token, deployment.DeploymentId, id)
+// started reports a deployment whose instance was created as running
+func (s *GetDeploymentService) started(ctx context.Context, deployment *pb.Deployment, nodeID string, token string, id string) error {
+	err := s.instanceService.SetInstanceData(ctx, nodeID, token, deployment.DeploymentId, id)
 	if err != nil {
 		return fmt.Errorf("failed to set instance data: %w", err)
 	}
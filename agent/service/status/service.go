@@ -47,9 +47,13 @@ func (s *UpdateStatusService) Execute(ctx context.Context, nodeID string, token
 	log.Printf("[UpdateStatusService] Found %d instances to update", len(instances))
 
 	for _, instance := range instances {
-		jobID, hasJobID := instance.Labels["open-scheduler.job-id"]
-		if !hasJobID || jobID == "" {
-			log.Printf("[UpdateStatusService] Instance %s has no job-id label, skipping", instance.InstanceId)
+		jobID := instance.Labels["open-scheduler.deployment-id"]
+		if jobID == "" {
+			// Instances started by older agents only carry the job-id label
+			jobID = instance.Labels["open-scheduler.job-id"]
+		}
+		if jobID == "" {
+			log.Printf("[UpdateStatusService] Instance %s has no deployment-id label, skipping", instance.InstanceId)
 			continue
 		}
 
@@ -83,11 +87,38 @@ func (s *UpdateStatusService) Execute(ctx context.Context, nodeID string, token
 		}
 
 		log.Printf("[UpdateStatusService] Status update successful for job %s: %s", jobID, resp.ResponseMessage)
+
+		if resp.StopInstance {
+			s.stopInstance(ctx, jobID, instance.InstanceId)
+		}
 	}
 
 	return nil
 }
 
+// stopInstance stops an instance centro no longer wants running and reports it as stopped
+func (s *UpdateStatusService) stopInstance(ctx context.Context, jobID string, instanceID string) {
+	log.Printf("[UpdateStatusService] Stopping instance %s of job %s as requested by centro", instanceID, jobID)
+
+	if err := s.driver.StopInstance(ctx, instanceID); err != nil {
+		log.Printf("[UpdateStatusService] Failed to stop instance %s: %v", instanceID, err)
+		return
+	}
+
+	_, err := s.grpcClient.UpdateStatus(
+		ctx,
+		s.nodeID,
+		s.token,
+		jobID,
+		"stopped",
+		fmt.Sprintf("Instance %s stopped by centro", instanceID),
+		time.Now().Unix(),
+	)
+	if err != nil {
+		log.Printf("[UpdateStatusService] Failed to report stopped job %s: %v", jobID, err)
+	}
+}
+
 func mapInstanceStatusToJobStatus(instanceStatus string) string {
 	switch instanceStatus {
 	case "running":
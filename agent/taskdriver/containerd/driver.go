@@ -75,6 +75,10 @@ func NewContainerdDriver() *ContainerdDriver {
 }
 
 func (d *ContainerdDriver) Run(ctx context.Context, deployment *pb.Deployment) (string, error) {
+	if deployment.InstanceConfig == nil {
+		return "", fmt.Errorf("deployment %s has no instance_config", deployment.DeploymentId)
+	}
+
 	err := d.pullImage(ctx, deployment.InstanceConfig.ImageName)
 	if err != nil {
 		return "", fmt.Errorf("failed to pull image %s: %w", deployment.InstanceConfig.ImageName, err)
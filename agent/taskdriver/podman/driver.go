@@ -7,7 +7,9 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"time"
 
+	"github.com/containers/image/v5/manifest"
 	"github.com/containers/podman/v4/libpod/define"
 	"github.com/containers/podman/v4/pkg/bindings"
 	"github.com/containers/podman/v4/pkg/bindings/containers"
@@ -106,6 +108,10 @@ func NewPodmanDriver() *PodmanDriver {
 }
 
 func (d *PodmanDriver) Run(ctx context.Context, deployment *pb.Deployment) (string, error) {
+	if deployment.InstanceConfig == nil {
+		return "", fmt.Errorf("deployment %s has no instance_config", deployment.DeploymentId)
+	}
+
 	err := d.pullImage(ctx, deployment.InstanceConfig.ImageName)
 	if err != nil {
 		return "", fmt.Errorf("failed to pull image %s: %w", deployment.InstanceConfig.ImageName, err)
@@ -233,6 +239,11 @@ func (d *PodmanDriver) createInstance(ctx context.Context, deployment *pb.Deploy
 		}
 	}
 
+	// Set health check
+	if deployment.HealthCheck != nil && len(deployment.HealthCheck.Test) > 0 {
+		s.HealthConfig = healthConfig(deployment.HealthCheck)
+	}
+
 	log.Printf("[PodmanDriver] Creating instance for deployment %s with image: %s", deployment.DeploymentId, deployment.InstanceConfig.ImageName)
 	r, err := containers.CreateWithSpec(d.ctx, s, &containers.CreateOptions{})
 	if err != nil {
@@ -258,6 +269,22 @@ func (d *PodmanDriver) createInstance(ctx context.Context, deployment *pb.Deploy
 	return r.ID, nil
 }
 
+// healthConfig converts a deployment health check into the podman format.
+// Invalid durations were rejected by centro, so they are treated as unset here.
+func healthConfig(healthCheck *pb.HealthCheck) *manifest.Schema2HealthConfig {
+	duration := func(value string) time.Duration {
+		d, _ := time.ParseDuration(value)
+		return d
+	}
+	return &manifest.Schema2HealthConfig{
+		Test:        healthCheck.Test,
+		Interval:    duration(healthCheck.Interval),
+		Timeout:     duration(healthCheck.Timeout),
+		StartPeriod: duration(healthCheck.StartPeriod),
+		Retries:     int(healthCheck.Retries),
+	}
+}
+
 func (d *PodmanDriver) StopInstance(ctx context.Context, instanceID string) error {
 	log.Printf("[PodmanDriver] Stopping instance: %s", instanceID)
 	force := true
@@ -351,6 +378,7 @@ func (d *PodmanDriver) InspectInstance(ctx context.Context, instanceID string) (
 		Labels:       labels,
 		Ports:        ports,
 		Volumes:      volumes,
+		Health:       inspectData.State.Health.Status,
 	}, nil
 }
 
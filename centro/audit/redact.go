@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxBodySize is the largest request body that is recorded, larger bodies are
+// only noted with their size
+const MaxBodySize = 64 << 10
+
+// Redacted replaces the values of sensitive fields
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are parts of field names whose values are never recorded.
+// They are matched case-insensitively anywhere in the name, ignoring '_' and
+// '-', so environment variables like DB_PASSWORD and headers like X-Api-Key
+// are caught as well.
+var sensitiveKeys = []string{
+	"password", "secret", "token", "credential", "privatekey", "apikey",
+	"accesskey", "authorization",
+}
+
+var separators = strings.NewReplacer("_", "", "-", "")
+
+// IsSensitive reports whether the value of a field with this name is redacted
+func IsSensitive(name string) bool {
+	name = separators.Replace(strings.ToLower(name))
+	for _, key := range sensitiveKeys {
+		if strings.Contains(name, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a JSON request body with the values of sensitive fields and
+// of the fields named in extra replaced, at any depth. Bodies that are not JSON
+// or larger than MaxBodySize are replaced by a note with their size, since
+// they cannot be redacted.
+func Redact(body []byte, extra ...string) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if len(body) > MaxBodySize {
+		return note(fmt.Sprintf("more than %d bytes, too large to record", MaxBodySize))
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return note(fmt.Sprintf("%d bytes, not JSON", len(body)))
+	}
+	redacted, err := json.Marshal(redactValue(value, extra))
+	if err != nil {
+		return note(fmt.Sprintf("%d bytes", len(body)))
+	}
+	return redacted
+}
+
+func redactValue(value interface{}, extra []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if IsSensitive(key) || isExtra(key, extra) {
+				if field != nil && field != "" {
+					v[key] = Redacted
+				}
+				continue
+			}
+			v[key] = redactValue(field, extra)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item, extra)
+		}
+	}
+	return value
+}
+
+func isExtra(key string, extra []string) bool {
+	for _, name := range extra {
+		if key == name {
+			return true
+		}
+	}
+	return false
+}
+
+func note(message string) json.RawMessage {
+	data, _ := json.Marshal(map[string]string{"_note": message})
+	return data
+}
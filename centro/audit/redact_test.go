@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		extra []string
+		want  string
+	}{
+		{
+			name: "sensitive fields",
+			body: `{"username": "alice", "password": "hunter22", "role": "admin"}`,
+			want: `{"password": "[REDACTED]", "role": "admin", "username": "alice"}`,
+		},
+		{
+			name: "case and position in the name",
+			body: `{"DB_PASSWORD": "a", "refreshToken": "b", "X-Api-Key": "c", "aws_access_key_id": "d", "name": "e"}`,
+			want: `{"DB_PASSWORD": "[REDACTED]", "refreshToken": "[REDACTED]", "X-Api-Key": "[REDACTED]", "aws_access_key_id": "[REDACTED]", "name": "e"}`,
+		},
+		{
+			name: "nested objects and arrays",
+			body: `{"env": {"API_SECRET": "a", "MODE": "prod"}, "users": [{"name": "bob", "password": "b"}]}`,
+			want: `{"env": {"API_SECRET": "[REDACTED]", "MODE": "prod"}, "users": [{"name": "bob", "password": "[REDACTED]"}]}`,
+		},
+		{
+			name: "sensitive objects as a whole",
+			body: `{"credentials": {"user": "a", "pass": "b"}}`,
+			want: `{"credentials": "[REDACTED]"}`,
+		},
+		{
+			name: "empty values are kept",
+			body: `{"password": "", "token": null}`,
+			want: `{"password": "", "token": null}`,
+		},
+		{
+			name:  "extra fields",
+			body:  `{"name": "db", "value": "s3cr3t", "nested": {"value": "s3cr3t"}}`,
+			extra: []string{"value"},
+			want:  `{"name": "db", "value": "[REDACTED]", "nested": {"value": "[REDACTED]"}}`,
+		},
+		{
+			name:  "extra fields are matched exactly",
+			body:  `{"values": "kept", "Value": "kept"}`,
+			extra: []string{"value"},
+			want:  `{"values": "kept", "Value": "kept"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, want interface{}
+			if err := json.Unmarshal(Redact([]byte(tt.body), tt.extra...), &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestRedactUnredactableBodies(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+		note string
+	}{
+		{name: "not JSON", body: []byte("password=hunter22"), note: "not JSON"},
+		{name: "too large", body: append([]byte(`{"password": "`), bytes.Repeat([]byte("x"), MaxBodySize)...), note: "too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Redact(tt.body)
+			if strings.Contains(string(got), "hunter22") || bytes.Contains(got, []byte("xxxx")) {
+				t.Fatalf("the body was recorded: %s", got)
+			}
+			var note map[string]string
+			if err := json.Unmarshal(got, &note); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(note["_note"], tt.note) {
+				t.Errorf("note %q, want it to mention %q", note["_note"], tt.note)
+			}
+		})
+	}
+
+	if Redact(nil) != nil {
+		t.Error("an empty body was recorded")
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "password", want: true},
+		{name: "DB_PASSWORD", want: true},
+		{name: "client_secret", want: true},
+		{name: "refresh_token", want: true},
+		{name: "private_key", want: true},
+		{name: "private-key", want: true},
+		{name: "PrivateKey", want: true},
+		{name: "X-Api-Key", want: true},
+		{name: "AWS_ACCESS_KEY_ID", want: true},
+		{name: "Authorization", want: true},
+		{name: "username", want: false},
+		{name: "public_key", want: false},
+		{name: "key", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsSensitive(tt.name); got != tt.want {
+			t.Errorf("IsSensitive(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package configs renders config objects into the content of the files
+// deployments mount. Configs referenced as templates are Go templates,
+// rendered by Centro when the agent starts the instance.
+package configs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+	"text/template/parse"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// MaxSize is the largest config, etcd is not made for large values
+const MaxSize = 256 << 10
+
+// MaxRangeIterations limits how often templates may loop over integers like
+// {{ range 3 }}, nested loops multiplied. Loops over data are bounded by the
+// size of the deployment.
+const MaxRangeIterations = 10000
+
+// errTooLarge stops rendering once the output is larger than MaxSize
+var errTooLarge = fmt.Errorf("rendered template is larger than %d bytes", MaxSize)
+
+// limitedBuffer fails writes beyond MaxSize, so a template cannot make Centro
+// allocate more than that while rendering
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > MaxSize {
+		return 0, errTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+// Data is what templates are rendered with, e.g. {{ .Deployment.Name }} or
+// {{ .Meta.domain }}
+type Data struct {
+	Deployment Deployment
+	// Meta is the metadata of the deployment
+	Meta map[string]string
+	// Env holds the environment variables of the deployment, without secrets
+	Env  map[string]string
+	Node Node
+}
+
+type Deployment struct {
+	ID   string
+	Name string
+	Type string
+	// ParentID is the deployment a replica belongs to, empty for deployments
+	// submitted directly
+	ParentID string
+	Clusters []string
+}
+
+type Node struct {
+	ID      string
+	Cluster string
+	Labels  map[string]string
+}
+
+// NewData returns the template data of a deployment running on node, which
+// may be nil
+func NewData(deployment *pb.Deployment, node *etcdstorage.NodeInfo) Data {
+	data := Data{
+		Deployment: Deployment{
+			ID:       deployment.DeploymentId,
+			Name:     deployment.DeploymentName,
+			Type:     deployment.DeploymentType,
+			ParentID: deployment.ParentDeploymentId,
+			Clusters: deployment.SelectedClusters,
+		},
+		Meta: deployment.DeploymentMetadata,
+		Env:  deployment.EnvironmentVariables,
+	}
+	if node != nil {
+		data.Node = Node{
+			ID:      node.NodeID,
+			Cluster: node.ClusterName,
+			Labels:  node.Labels,
+		}
+	}
+	return data
+}
+
+// Parse checks the syntax of a template
+func Parse(config *etcdstorage.Config) (*template.Template, error) {
+	// A missing key is an error instead of "<no value>" in the file
+	tmpl, err := template.New(config.Name).Option("missingkey=error").Parse(config.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	if err := checkRanges(tmpl, tmpl.Tree.Root, 1, map[string]bool{}); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// rangeCount returns n for {{ range n }} with an integer constant n
+func rangeCount(pipe *parse.PipeNode) (int64, bool) {
+	for len(pipe.Cmds) == 1 && len(pipe.Cmds[0].Args) == 1 {
+		switch arg := pipe.Cmds[0].Args[0].(type) {
+		case *parse.NumberNode:
+			return arg.Int64, arg.IsInt
+		case *parse.PipeNode:
+			pipe = arg
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// checkRanges rejects loops over integers that would run more than
+// MaxRangeIterations times, counting loops they are nested in and templates
+// they call. iterations is how often node runs.
+func checkRanges(tmpl *template.Template, node parse.Node, iterations int64, called map[string]bool) error {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return nil
+		}
+		for _, child := range node.Nodes {
+			if err := checkRanges(tmpl, child, iterations, called); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return checkBranch(tmpl, &node.BranchNode, iterations, iterations, called)
+	case *parse.WithNode:
+		return checkBranch(tmpl, &node.BranchNode, iterations, iterations, called)
+	case *parse.RangeNode:
+		body := iterations
+		if count, ok := rangeCount(node.Pipe); ok && count > 0 {
+			if count > MaxRangeIterations || body*count > MaxRangeIterations {
+				return fmt.Errorf("range over integers loops more than %d times", MaxRangeIterations)
+			}
+			body *= count
+		}
+		return checkBranch(tmpl, &node.BranchNode, body, iterations, called)
+	case *parse.TemplateNode:
+		// Recursion is stopped by the depth limit of text/template
+		if called[node.Name] {
+			return nil
+		}
+		if t := tmpl.Lookup(node.Name); t != nil && t.Tree != nil {
+			return checkRanges(tmpl, t.Tree.Root, iterations, withCalled(called, node.Name))
+		}
+	}
+	return nil
+}
+
+func checkBranch(tmpl *template.Template, branch *parse.BranchNode, body, elseIterations int64, called map[string]bool) error {
+	if err := checkRanges(tmpl, branch.List, body, called); err != nil {
+		return err
+	}
+	return checkRanges(tmpl, branch.ElseList, elseIterations, called)
+}
+
+// withCalled returns the templates on the call path including name
+func withCalled(called map[string]bool, name string) map[string]bool {
+	copied := make(map[string]bool, len(called)+1)
+	for n := range called {
+		copied[n] = true
+	}
+	copied[name] = true
+	return copied
+}
+
+// Render returns the content of the file of a reference
+func Render(config *etcdstorage.Config, ref *pb.ConfigReference, data Data) ([]byte, error) {
+	if !ref.Template {
+		return []byte(config.Data), nil
+	}
+
+	tmpl, err := Parse(config)
+	if err != nil {
+		return nil, err
+	}
+	var buf limitedBuffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		if errors.Is(err, errTooLarge) {
+			return nil, errTooLarge
+		}
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
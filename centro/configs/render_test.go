@@ -0,0 +1,75 @@
+package configs
+
+import (
+	"strings"
+	"testing"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+func TestParseLimitsRangeOverIntegers(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "small range", data: `{{range 3}}x{{end}}`},
+		{name: "range over data", data: `{{range $key, $value := .Meta}}{{$key}}={{$value}}{{end}}`},
+		{name: "nested within limit", data: `{{range 100}}{{range 100}}x{{end}}{{end}}`},
+		{name: "large range", data: `{{range 2000000000}}{{end}}`, wantErr: true},
+		{name: "parenthesized", data: `{{range (20000)}}{{end}}`, wantErr: true},
+		{name: "nested over limit", data: `{{range 100}}{{range 100}}{{range 2}}{{end}}{{end}}{{end}}`, wantErr: true},
+		{name: "nested in else", data: `{{range .Meta}}{{else}}{{range 100}}{{range 200}}{{end}}{{end}}{{end}}`, wantErr: true},
+		{name: "through template", data: `{{define "a"}}{{range 1000}}{{end}}{{end}}{{range 100}}{{template "a"}}{{end}}`, wantErr: true},
+		{name: "recursive template", data: `{{define "a"}}{{range 10}}{{template "a"}}{{end}}{{end}}{{template "a"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(&etcdstorage.Config{Name: "app.conf", Data: tt.data})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderStopsAtMaxSize(t *testing.T) {
+	// 100 * 100 lines of 64 bytes render to more than MaxSize
+	line := strings.Repeat("x", 63) + "\n"
+	config := &etcdstorage.Config{Name: "app.conf", Data: `{{range 100}}{{range 100}}` + line + `{{end}}{{end}}`}
+
+	_, err := Render(config, &pb.ConfigReference{Config: "app.conf", Template: true}, Data{})
+	if err != errTooLarge {
+		t.Fatalf("Render() error = %v, want %v", err, errTooLarge)
+	}
+}
+
+func TestRender(t *testing.T) {
+	config := &etcdstorage.Config{Name: "app.conf", Data: "id={{ .Deployment.ID }} domain={{ .Meta.domain }}"}
+	data := NewData(&pb.Deployment{
+		DeploymentId:       "web",
+		DeploymentMetadata: map[string]string{"domain": "example.com"},
+	}, nil)
+
+	content, err := Render(config, &pb.ConfigReference{Config: "app.conf", Template: true}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "id=web domain=example.com"; string(content) != want {
+		t.Errorf("Render() = %q, want %q", content, want)
+	}
+
+	content, err = Render(config, &pb.ConfigReference{Config: "app.conf"}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != config.Data {
+		t.Errorf("Render() of a plain config = %q, want the data unchanged", content)
+	}
+
+	if _, err := Render(&etcdstorage.Config{Name: "app.conf", Data: "{{ .Meta.missing }}"}, &pb.ConfigReference{Template: true}, data); err == nil {
+		t.Error("Render() with a missing key succeeded")
+	}
+}
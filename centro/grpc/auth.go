@@ -0,0 +1,219 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/open-scheduler/centro/pki"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// NodeCredentialPrefix starts node credentials, which look like
+// osn_<id>_<secret>. Agents send them as "authorization: Bearer <credential>".
+const NodeCredentialPrefix = "osn_"
+
+// credentialUsedInterval limits how often last_used_at is written
+const credentialUsedInterval = time.Minute
+
+type nodeIDKey struct{}
+
+// NodeIDFromContext returns the node an RPC was authenticated as. It is empty
+// for agents admitted without a credential in dev mode.
+func NodeIDFromContext(ctx context.Context) string {
+	nodeID, _ := ctx.Value(nodeIDKey{}).(string)
+	return nodeID
+}
+
+// HashToken returns the hash node credentials and join tokens are stored with
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// nodeRequest is implemented by every request of an agent
+type nodeRequest interface {
+	GetNodeId() string
+}
+
+// NodeAuth authenticates agents by their client certificate or node
+// credential and binds every RPC to the node it was issued for
+type NodeAuth struct {
+	storage *etcdstorage.Storage
+	// allowUnauthenticated admits agents without a valid credential as the
+	// node they claim to be, for -dev
+	allowUnauthenticated bool
+}
+
+func NewNodeAuth(storage *etcdstorage.Storage, allowUnauthenticated bool) *NodeAuth {
+	return &NodeAuth{
+		storage:              storage,
+		allowUnauthenticated: allowUnauthenticated,
+	}
+}
+
+// authenticate returns the node of the client certificate of the connection,
+// or else of the credential in the request metadata
+func (a *NodeAuth) authenticate(ctx context.Context) (string, error) {
+	if cert := clientCertificate(ctx); cert != nil {
+		return a.nodeForCertificate(ctx, cert)
+	}
+
+	nodeID, err := a.nodeForCredential(ctx)
+	if status.Code(err) == codes.Unauthenticated && a.allowUnauthenticated {
+		return "", nil
+	}
+	return nodeID, err
+}
+
+// clientCertificate returns the client certificate of the connection if the
+// TLS handshake verified it against the CA
+func clientCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 {
+		return nil
+	}
+	return tlsInfo.State.VerifiedChains[0][0]
+}
+
+func (a *NodeAuth) nodeForCertificate(ctx context.Context, cert *x509.Certificate) (string, error) {
+	// Connections outlive the certificate they were opened with
+	if time.Now().After(cert.NotAfter) {
+		return "", status.Error(codes.Unauthenticated, "client certificate expired")
+	}
+
+	record, err := a.storage.GetNodeCertificate(ctx, pki.Serial(cert))
+	if err != nil {
+		log.Printf("[Centro] Failed to get node certificate: %v", err)
+		return "", status.Error(codes.Internal, "failed to check client certificate")
+	}
+	if record == nil || record.NodeID != cert.Subject.CommonName {
+		return "", status.Error(codes.Unauthenticated, "unknown client certificate")
+	}
+	if record.RevokedAt != nil {
+		return "", status.Error(codes.Unauthenticated, "client certificate was revoked")
+	}
+	return record.NodeID, nil
+}
+
+func (a *NodeAuth) nodeForCredential(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", status.Error(codes.Unauthenticated, "node credential is required")
+	}
+	raw, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "authorization must be \"Bearer <node credential>\"")
+	}
+
+	credentialID, secret, ok := strings.Cut(strings.TrimPrefix(raw, NodeCredentialPrefix), "_")
+	if !strings.HasPrefix(raw, NodeCredentialPrefix) || !ok || credentialID == "" || secret == "" {
+		return "", status.Error(codes.Unauthenticated, "invalid node credential")
+	}
+
+	credential, err := a.storage.GetNodeCredential(ctx, credentialID)
+	if err != nil {
+		log.Printf("[Centro] Failed to get node credential: %v", err)
+		return "", status.Error(codes.Internal, "failed to check node credential")
+	}
+	if credential == nil || subtle.ConstantTimeCompare([]byte(HashToken(raw)), []byte(credential.SecretHash)) != 1 {
+		return "", status.Error(codes.Unauthenticated, "invalid node credential")
+	}
+
+	now := time.Now()
+	if credential.LastUsedAt == nil || now.Sub(*credential.LastUsedAt) > credentialUsedInterval {
+		if err := a.storage.TouchNodeCredential(ctx, credential.ID, now); err != nil {
+			log.Printf("[Centro] Failed to record use of node credential %s: %v", credential.ID, err)
+		}
+	}
+	return credential.NodeID, nil
+}
+
+// checkNode rejects requests made for another node than the authenticated one
+func checkNode(nodeID string, req interface{}) error {
+	r, ok := req.(nodeRequest)
+	if !ok || nodeID == "" || r.GetNodeId() == nodeID {
+		return nil
+	}
+	return status.Error(codes.PermissionDenied, fmt.Sprintf("node %s cannot make calls for node %s", nodeID, r.GetNodeId()))
+}
+
+// UnaryInterceptor authenticates unary RPCs
+func (a *NodeAuth) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		// New nodes have neither, they authenticate with the join token in the
+		// request. Nodes that have a credential already present it as well.
+		if info.FullMethod == pb.CentroSchedulerService_JoinNode_FullMethodName {
+			nodeID, err := a.authenticate(ctx)
+			if err != nil || nodeID == "" {
+				return handler(ctx, req)
+			}
+			if err := checkNode(nodeID, req); err != nil {
+				log.Printf("[Centro] Rejected %s: %v", info.FullMethod, err)
+				return nil, err
+			}
+			return handler(context.WithValue(ctx, nodeIDKey{}, nodeID), req)
+		}
+
+		nodeID, err := a.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkNode(nodeID, req); err != nil {
+			log.Printf("[Centro] Rejected %s: %v", info.FullMethod, err)
+			return nil, err
+		}
+		return handler(context.WithValue(ctx, nodeIDKey{}, nodeID), req)
+	}
+}
+
+// StreamInterceptor authenticates streaming RPCs and checks every message
+// the agent sends on the stream
+func (a *NodeAuth) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		nodeID, err := a.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &nodeStream{
+			ServerStream: ss,
+			ctx:          context.WithValue(ss.Context(), nodeIDKey{}, nodeID),
+			nodeID:       nodeID,
+		})
+	}
+}
+
+type nodeStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	nodeID string
+}
+
+func (s *nodeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *nodeStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkNode(s.nodeID, m)
+}
@@ -0,0 +1,181 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+	pb "github.com/open-scheduler/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// saveNodeCredential stores a credential of a node and returns the raw credential
+func saveNodeCredential(t *testing.T, storage *etcdstorage.Storage, id, nodeID string) string {
+	t.Helper()
+	raw := NodeCredentialPrefix + id + "_secret-" + id
+	err := storage.SaveNodeCredential(context.Background(), &etcdstorage.NodeCredential{
+		ID:         id,
+		NodeID:     nodeID,
+		SecretHash: HashToken(raw),
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func withAuthorization(value string) context.Context {
+	if value == "" {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", value))
+}
+
+func TestCheckNode(t *testing.T) {
+	tests := []struct {
+		name   string
+		nodeID string
+		req    interface{}
+		want   codes.Code
+	}{
+		{name: "same node", nodeID: "node-1", req: &pb.HeartbeatRequest{NodeId: "node-1"}, want: codes.OK},
+		{name: "other node", nodeID: "node-1", req: &pb.HeartbeatRequest{NodeId: "node-2"}, want: codes.PermissionDenied},
+		{name: "empty node ID in request", nodeID: "node-1", req: &pb.UpdateStatusRequest{}, want: codes.PermissionDenied},
+		{name: "unauthenticated in dev mode", nodeID: "", req: &pb.HeartbeatRequest{NodeId: "node-2"}, want: codes.OK},
+		{name: "request without node ID", nodeID: "node-1", req: &pb.Deployment{}, want: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.Code(checkNode(tt.nodeID, tt.req)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	storage, _ := etcdtest.NewStorage()
+	raw := saveNodeCredential(t, storage, "c1", "node-1")
+
+	tests := []struct {
+		name          string
+		devMode       bool
+		authorization string
+		method        string
+		req           interface{}
+		want          codes.Code
+		wantNode      string
+	}{
+		{name: "valid credential", authorization: "Bearer " + raw, req: &pb.HeartbeatRequest{NodeId: "node-1"}, want: codes.OK, wantNode: "node-1"},
+		{name: "credential of another node", authorization: "Bearer " + raw, req: &pb.HeartbeatRequest{NodeId: "node-2"}, want: codes.PermissionDenied},
+		{name: "missing credential", req: &pb.HeartbeatRequest{NodeId: "node-1"}, want: codes.Unauthenticated},
+		{name: "not a bearer token", authorization: raw, req: &pb.HeartbeatRequest{NodeId: "node-1"}, want: codes.Unauthenticated},
+		{name: "wrong secret", authorization: "Bearer " + NodeCredentialPrefix + "c1_other", req: &pb.HeartbeatRequest{NodeId: "node-1"}, want: codes.Unauthenticated},
+		{name: "unknown credential", authorization: "Bearer " + NodeCredentialPrefix + "c2_secret-c2", req: &pb.HeartbeatRequest{NodeId: "node-1"}, want: codes.Unauthenticated},
+		{name: "join token instead of credential", authorization: "Bearer " + JoinTokenPrefix + "c1_secret-c1", req: &pb.HeartbeatRequest{NodeId: "node-1"}, want: codes.Unauthenticated},
+		{name: "dev mode without credential", devMode: true, req: &pb.HeartbeatRequest{NodeId: "node-2"}, want: codes.OK},
+		{name: "dev mode with invalid credential", devMode: true, authorization: "Bearer " + NodeCredentialPrefix + "c1_other", req: &pb.HeartbeatRequest{NodeId: "node-2"}, want: codes.OK},
+		{name: "dev mode checks valid credentials", devMode: true, authorization: "Bearer " + raw, req: &pb.HeartbeatRequest{NodeId: "node-2"}, want: codes.PermissionDenied},
+		{name: "join without credential", method: pb.CentroSchedulerService_JoinNode_FullMethodName, req: &pb.JoinNodeRequest{NodeId: "node-2"}, want: codes.OK},
+		{name: "join with own credential", method: pb.CentroSchedulerService_JoinNode_FullMethodName, authorization: "Bearer " + raw, req: &pb.JoinNodeRequest{NodeId: "node-1"}, want: codes.OK, wantNode: "node-1"},
+		{name: "join with credential of another node", method: pb.CentroSchedulerService_JoinNode_FullMethodName, authorization: "Bearer " + raw, req: &pb.JoinNodeRequest{NodeId: "node-2"}, want: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = pb.CentroSchedulerService_Heartbeat_FullMethodName
+			}
+			var gotNode string
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				gotNode = NodeIDFromContext(ctx)
+				return nil, nil
+			}
+
+			interceptor := NewNodeAuth(storage, tt.devMode).UnaryInterceptor()
+			_, err := interceptor(withAuthorization(tt.authorization), tt.req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("got %s, want %s: %v", got, tt.want, err)
+			}
+			if called != (tt.want == codes.OK) {
+				t.Fatalf("handler called: %v", called)
+			}
+			if gotNode != tt.wantNode {
+				t.Errorf("authenticated as %q, want %q", gotNode, tt.wantNode)
+			}
+		})
+	}
+}
+
+// recvStream is a server stream that receives a single heartbeat
+type recvStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	nodeID string
+}
+
+func (s *recvStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *recvStream) RecvMsg(m interface{}) error {
+	m.(*pb.HeartbeatRequest).NodeId = s.nodeID
+	return nil
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	storage, _ := etcdtest.NewStorage()
+	raw := saveNodeCredential(t, storage, "c1", "node-1")
+
+	tests := []struct {
+		name          string
+		authorization string
+		msgNodeID     string
+		want          codes.Code
+	}{
+		{name: "messages of the node", authorization: "Bearer " + raw, msgNodeID: "node-1", want: codes.OK},
+		{name: "message for another node", authorization: "Bearer " + raw, msgNodeID: "node-2", want: codes.PermissionDenied},
+		{name: "missing credential", msgNodeID: "node-1", want: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &recvStream{ctx: withAuthorization(tt.authorization), nodeID: tt.msgNodeID}
+			handler := func(srv interface{}, ss grpc.ServerStream) error {
+				if NodeIDFromContext(ss.Context()) != "node-1" {
+					t.Errorf("authenticated as %q", NodeIDFromContext(ss.Context()))
+				}
+				return ss.RecvMsg(&pb.HeartbeatRequest{})
+			}
+
+			interceptor := NewNodeAuth(storage, false).StreamInterceptor()
+			err := interceptor(nil, stream, &grpc.StreamServerInfo{}, handler)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("got %s, want %s: %v", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCredentialLastUsed(t *testing.T) {
+	storage, _ := etcdtest.NewStorage()
+	raw := saveNodeCredential(t, storage, "c1", "node-1")
+
+	if _, err := NewNodeAuth(storage, false).authenticate(withAuthorization("Bearer " + raw)); err != nil {
+		t.Fatal(err)
+	}
+	credential, err := storage.GetNodeCredential(context.Background(), "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential.LastUsedAt == nil {
+		t.Error("last_used_at was not recorded")
+	}
+}
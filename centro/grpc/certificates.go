@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/open-scheduler/centro/pki"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// EnableCertificates lets nodes join with a join token and authenticate with
+// client certificates signed by ca, valid for validity
+func (s *CentroServer) EnableCertificates(ca *pki.CA, validity time.Duration) {
+	s.ca = ca
+	s.certValidity = validity
+}
+
+// hasActiveCertificate reports whether a node holds a certificate that is
+// neither expired nor revoked
+func (s *CentroServer) hasActiveCertificate(ctx context.Context, nodeID string) (bool, error) {
+	certs, err := s.storage.GetNodeCertificates(ctx, nodeID)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	for _, cert := range certs {
+		if cert.RevokedAt == nil && now.Before(cert.NotAfter) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordNodeCertificate keeps an issued certificate, so it can be revoked and
+// certificates Centro does not know are rejected
+func (s *CentroServer) recordNodeCertificate(ctx context.Context, nodeID string, cert *x509.Certificate) error {
+	return s.storage.SaveNodeCertificate(ctx, &etcdstorage.NodeCertificate{
+		Serial:   pki.Serial(cert),
+		NodeID:   nodeID,
+		IssuedAt: time.Now(),
+		NotAfter: cert.NotAfter,
+	})
+}
+
+// renewalRefusal returns why a node may not renew its certificate, or ""
+func renewalRefusal(node *etcdstorage.NodeInfo) string {
+	switch {
+	case node == nil:
+		return "Node not registered, join with a join token"
+	case node.Decommissioned:
+		return "Node is decommissioned, recommission it and join again with a new join token"
+	case node.Approval == etcdstorage.NodeApprovalRejected:
+		return "Node was rejected"
+	}
+	return ""
+}
+
+func (s *CentroServer) RenewCertificate(ctx context.Context, req *pb.RenewCertificateRequest) (*pb.RenewCertificateResponse, error) {
+	if s.ca == nil {
+		return &pb.RenewCertificateResponse{
+			Accepted:        false,
+			ResponseMessage: "Centro serves gRPC without TLS, authenticate with a node credential instead",
+		}, nil
+	}
+	// Agents admitted without a credential in dev mode have no identity to renew
+	if NodeIDFromContext(ctx) == "" {
+		return &pb.RenewCertificateResponse{
+			Accepted:        false,
+			ResponseMessage: "A certificate or node credential is required, new nodes join with a join token",
+		}, nil
+	}
+
+	// Certificates of decommissioned nodes are revoked, they must not be
+	// able to get new ones with a certificate that is still connected
+	node, err := s.storage.GetNode(ctx, req.NodeId)
+	if err != nil {
+		log.Printf("[Centro] Failed to get node %s: %v", req.NodeId, err)
+		return &pb.RenewCertificateResponse{
+			Accepted:        false,
+			ResponseMessage: "Failed to get node info",
+		}, nil
+	}
+	if reason := renewalRefusal(node); reason != "" {
+		log.Printf("[Centro] Refused to renew certificate of node %s: %s", req.NodeId, reason)
+		return &pb.RenewCertificateResponse{
+			Accepted:        false,
+			ResponseMessage: reason,
+		}, nil
+	}
+
+	certPEM, cert, err := s.ca.SignNodeCSR(req.CsrPem, req.NodeId, s.certValidity)
+	if errors.Is(err, pki.ErrInvalidCSR) {
+		return &pb.RenewCertificateResponse{
+			Accepted:        false,
+			ResponseMessage: err.Error(),
+		}, nil
+	}
+	if err == nil {
+		err = s.recordNodeCertificate(ctx, req.NodeId, cert)
+	}
+	if err != nil {
+		log.Printf("[Centro] Failed to renew certificate of node %s: %v", req.NodeId, err)
+		return &pb.RenewCertificateResponse{
+			Accepted:        false,
+			ResponseMessage: "Failed to issue certificate",
+		}, nil
+	}
+
+	log.Printf("[Centro] Certificate of node %s renewed, valid until %s", req.NodeId, cert.NotAfter.Format(time.RFC3339))
+	return &pb.RenewCertificateResponse{
+		Accepted:         true,
+		ResponseMessage:  "Certificate renewed",
+		CertificatePem:   certPEM,
+		CaCertificatePem: s.ca.CertificatePEM(),
+	}, nil
+}
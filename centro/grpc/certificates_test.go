@@ -0,0 +1,191 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/open-scheduler/centro/pki"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+	pb "github.com/open-scheduler/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func newCSR(t *testing.T, commonName string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+// newTLSServer returns a server that issues node certificates
+func newTLSServer(t *testing.T) (*CentroServer, *etcdstorage.Storage) {
+	t.Helper()
+	storage, _ := etcdtest.NewStorage()
+	ca, err := pki.LoadOrCreate(context.Background(), storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &CentroServer{storage: storage}
+	server.EnableCertificates(ca, time.Hour)
+	return server, storage
+}
+
+// withClientCertificate returns a context of a connection that presented cert
+func withClientCertificate(cert *x509.Certificate) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}},
+		}},
+	})
+}
+
+func TestCertificateAuthentication(t *testing.T) {
+	server, storage := newTLSServer(t)
+	ctx := context.Background()
+	auth := NewNodeAuth(storage, false)
+
+	issue := func(nodeID string, validity time.Duration, record bool) *x509.Certificate {
+		_, cert, err := server.ca.SignNodeCSR(newCSR(t, nodeID), nodeID, validity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record {
+			if err := server.recordNodeCertificate(ctx, nodeID, cert); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return cert
+	}
+
+	valid := issue("node-1", time.Hour, true)
+	unknown := issue("node-1", time.Hour, false)
+	expired := issue("node-1", -time.Minute, true)
+	revoked := issue("node-2", time.Hour, true)
+	if _, err := storage.RevokeNodeCertificates(ctx, "node-2"); err != nil {
+		t.Fatal(err)
+	}
+	renamed := issue("node-3", time.Hour, false)
+	if err := storage.SaveNodeCertificate(ctx, &etcdstorage.NodeCertificate{
+		Serial:   pki.Serial(renamed),
+		NodeID:   "node-1",
+		NotAfter: renamed.NotAfter,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		cert     *x509.Certificate
+		want     codes.Code
+		wantNode string
+	}{
+		{name: "recorded certificate", cert: valid, want: codes.OK, wantNode: "node-1"},
+		{name: "certificate Centro did not record", cert: unknown, want: codes.Unauthenticated},
+		{name: "expired certificate", cert: expired, want: codes.Unauthenticated},
+		{name: "revoked certificate", cert: revoked, want: codes.Unauthenticated},
+		{name: "recorded for another node", cert: renamed, want: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeID, err := auth.authenticate(withClientCertificate(tt.cert))
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("got %s, want %s: %v", got, tt.want, err)
+			}
+			if nodeID != tt.wantNode {
+				t.Errorf("authenticated as %q, want %q", nodeID, tt.wantNode)
+			}
+		})
+	}
+}
+
+func TestRenewCertificate(t *testing.T) {
+	server, storage := newTLSServer(t)
+	ctx := context.Background()
+	nodes := []*etcdstorage.NodeInfo{
+		{NodeID: "approved", Approval: etcdstorage.NodeApprovalApproved},
+		{NodeID: "decommissioned", Approval: etcdstorage.NodeApprovalApproved, Decommissioned: true},
+		{NodeID: "rejected", Approval: etcdstorage.NodeApprovalRejected},
+	}
+	for _, node := range nodes {
+		if err := storage.SaveNode(ctx, node); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		nodeID   string
+		identity string
+		csr      []byte
+		accepted bool
+	}{
+		{name: "approved node", nodeID: "approved", identity: "approved", csr: newCSR(t, "approved"), accepted: true},
+		{name: "without identity", nodeID: "approved", csr: newCSR(t, "approved")},
+		{name: "decommissioned node", nodeID: "decommissioned", identity: "decommissioned", csr: newCSR(t, "decommissioned")},
+		{name: "rejected node", nodeID: "rejected", identity: "rejected", csr: newCSR(t, "rejected")},
+		{name: "unregistered node", nodeID: "unknown", identity: "unknown", csr: newCSR(t, "unknown")},
+		{name: "invalid request", nodeID: "approved", identity: "approved", csr: []byte("not a csr")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(ctx, nodeIDKey{}, tt.identity)
+			resp, err := server.RenewCertificate(ctx, &pb.RenewCertificateRequest{NodeId: tt.nodeID, CsrPem: tt.csr})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Accepted != tt.accepted {
+				t.Fatalf("accepted: %v, want %v (%s)", resp.Accepted, tt.accepted, resp.ResponseMessage)
+			}
+			if !tt.accepted {
+				return
+			}
+
+			block, _ := pem.Decode(resp.CertificatePem)
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			record, err := storage.GetNodeCertificate(ctx, pki.Serial(cert))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record == nil || record.NodeID != tt.nodeID {
+				t.Errorf("renewed certificate was not recorded for %s: %+v", tt.nodeID, record)
+			}
+		})
+	}
+}
+
+func TestRenewCertificateWithoutTLS(t *testing.T) {
+	storage, _ := etcdtest.NewStorage()
+	server := &CentroServer{storage: storage}
+
+	ctx := context.WithValue(context.Background(), nodeIDKey{}, "node-1")
+	resp, err := server.RenewCertificate(ctx, &pb.RenewCertificateRequest{NodeId: "node-1", CsrPem: newCSR(t, "node-1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Accepted {
+		t.Error("issued a certificate without a CA")
+	}
+}
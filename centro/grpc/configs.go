@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/open-scheduler/centro/configs"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// GetDeploymentConfigs returns the files of the configs a deployment
+// references, with templates rendered for the node. Only the node the
+// deployment is assigned to gets them, and only while the deployment is active.
+func (s *CentroServer) GetDeploymentConfigs(ctx context.Context, req *pb.GetDeploymentConfigsRequest) (*pb.GetDeploymentConfigsResponse, error) {
+	if req.NodeId == "" || req.DeploymentId == "" {
+		return &pb.GetDeploymentConfigsResponse{
+			Accepted:        false,
+			ResponseMessage: "node_id and deployment_id are required",
+		}, nil
+	}
+
+	active, err := s.storage.GetDeploymentActive(ctx, req.DeploymentId)
+	if err != nil {
+		log.Printf("[Centro] Failed to get deployment %s: %v", req.DeploymentId, err)
+		return &pb.GetDeploymentConfigsResponse{
+			Accepted:        false,
+			ResponseMessage: "Failed to get deployment status",
+		}, nil
+	}
+	if active == nil || active.NodeID != req.NodeId || active.Deployment == nil {
+		log.Printf("[Centro] Node %s asked for the configs of deployment %s, which is not assigned to it", req.NodeId, req.DeploymentId)
+		return &pb.GetDeploymentConfigsResponse{
+			Accepted:        false,
+			ResponseMessage: "Deployment is not assigned to this node",
+		}, nil
+	}
+
+	refs := active.Deployment.Configs
+	if len(refs) == 0 {
+		return &pb.GetDeploymentConfigsResponse{
+			Accepted:        true,
+			ResponseMessage: "Deployment has no configs",
+		}, nil
+	}
+
+	node, err := s.storage.GetNode(ctx, req.NodeId)
+	if err != nil {
+		log.Printf("[Centro] Failed to get node %s: %v", req.NodeId, err)
+		return &pb.GetDeploymentConfigsResponse{
+			Accepted:        false,
+			ResponseMessage: "Failed to get node",
+		}, nil
+	}
+	data := configs.NewData(active.Deployment, node)
+
+	rendered := make([]*pb.RenderedConfig, 0, len(refs))
+	stored := make(map[string]*etcdstorage.Config)
+	for _, ref := range refs {
+		config, ok := stored[ref.Config]
+		if !ok {
+			config, err = s.storage.GetConfig(ctx, ref.Config)
+			if err != nil {
+				log.Printf("[Centro] Failed to get config %s: %v", ref.Config, err)
+				return &pb.GetDeploymentConfigsResponse{
+					Accepted:        false,
+					ResponseMessage: "Failed to get configs",
+				}, nil
+			}
+			if config == nil {
+				return &pb.GetDeploymentConfigsResponse{
+					Accepted:        false,
+					ResponseMessage: fmt.Sprintf("Config %s does not exist", ref.Config),
+				}, nil
+			}
+			stored[ref.Config] = config
+		}
+
+		content, err := configs.Render(config, ref, data)
+		if err != nil {
+			return &pb.GetDeploymentConfigsResponse{
+				Accepted:        false,
+				ResponseMessage: fmt.Sprintf("Config %s: %v", ref.Config, err),
+			}, nil
+		}
+		rendered = append(rendered, &pb.RenderedConfig{
+			Config:  ref.Config,
+			File:    ref.File,
+			Content: content,
+		})
+	}
+
+	log.Printf("[Centro] Sent %d config files of deployment %s to node %s", len(rendered), req.DeploymentId, req.NodeId)
+	return &pb.GetDeploymentConfigsResponse{
+		Accepted:        true,
+		ResponseMessage: "Configs rendered",
+		Configs:         rendered,
+	}, nil
+}
@@ -0,0 +1,245 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/open-scheduler/centro/pki"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// JoinTokenPrefix starts join tokens, which look like osj_<id>_<secret>
+const JoinTokenPrefix = "osj_"
+
+// AutoApproveNodes approves nodes that register without a join token instead
+// of leaving them pending
+func (s *CentroServer) AutoApproveNodes() {
+	s.autoApproveNodes = true
+}
+
+// checkJoinToken returns the stored join token if raw is valid, unexpired and
+// not used up
+func (s *CentroServer) checkJoinToken(ctx context.Context, raw string) (*etcdstorage.JoinToken, error) {
+	tokenID, secret, ok := strings.Cut(strings.TrimPrefix(raw, JoinTokenPrefix), "_")
+	if !strings.HasPrefix(raw, JoinTokenPrefix) || !ok || tokenID == "" || secret == "" {
+		return nil, nil
+	}
+
+	token, err := s.storage.GetJoinToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || subtle.ConstantTimeCompare([]byte(HashToken(raw)), []byte(token.SecretHash)) != 1 {
+		return nil, nil
+	}
+	if token.Exhausted() || !time.Now().Before(token.ExpiresAt) {
+		return nil, nil
+	}
+	return token, nil
+}
+
+// joinRefusal returns why a node ID cannot be taken with a join token, or ""
+// if it is new, pending or was decommissioned with its identity revoked. A
+// node that authenticated with one of its credentials may join again.
+func (s *CentroServer) joinRefusal(ctx context.Context, nodeID string) (string, error) {
+	active, err := s.hasActiveCertificate(ctx, nodeID)
+	if err != nil {
+		return "", err
+	}
+	if active {
+		return "already has a valid certificate", nil
+	}
+
+	// The node proved its identity with a credential
+	if NodeIDFromContext(ctx) == nodeID {
+		return "", nil
+	}
+
+	// Credentials may be created before the node first connects
+	credentials, err := s.storage.GetNodeCredentials(ctx, nodeID)
+	if err != nil {
+		return "", err
+	}
+	if len(credentials) > 0 {
+		return "already has node credentials", nil
+	}
+
+	node, err := s.storage.GetNode(ctx, nodeID)
+	if err != nil {
+		return "", err
+	}
+	if node != nil && node.IsApproved() && node.IdentityRevokedAt.IsZero() {
+		return "is approved already", nil
+	}
+	return "", nil
+}
+
+// admitNode approves a node that joined with a join token and gives it the
+// cluster and labels of the token. The node is registered if it has not sent
+// a heartbeat yet.
+func (s *CentroServer) admitNode(ctx context.Context, nodeID string, token *etcdstorage.JoinToken) error {
+	now := time.Now()
+	_, err := s.storage.UpdateNode(ctx, nodeID, func(node *etcdstorage.NodeInfo) (*etcdstorage.NodeInfo, bool) {
+		if node == nil {
+			node = &etcdstorage.NodeInfo{NodeID: nodeID}
+		}
+		node.Approval = etcdstorage.NodeApprovalApproved
+		node.ApprovedBy = token.CreatedBy
+		node.ApprovedAt = now
+		node.JoinTokenID = token.ID
+		node.IdentityRevokedAt = time.Time{}
+		node.AssignedCluster = token.Cluster
+		node.Labels = token.Labels
+		if token.Cluster != "" {
+			node.ClusterName = token.Cluster
+		}
+		return node, true
+	})
+	return err
+}
+
+// JoinNode admits a node with a join token. With TLS the node sends a
+// certificate signing request and gets its client certificate, without TLS
+// it is only approved and authenticates with a node credential.
+func (s *CentroServer) JoinNode(ctx context.Context, req *pb.JoinNodeRequest) (*pb.JoinNodeResponse, error) {
+	if req.NodeId == "" {
+		return &pb.JoinNodeResponse{
+			Accepted:        false,
+			ResponseMessage: "node_id is required",
+		}, nil
+	}
+	if s.ca == nil && len(req.CsrPem) > 0 {
+		return &pb.JoinNodeResponse{
+			Accepted:        false,
+			ResponseMessage: "Centro serves gRPC without TLS, join without a certificate request and authenticate with a node credential",
+		}, nil
+	}
+	if s.ca != nil && len(req.CsrPem) == 0 {
+		return &pb.JoinNodeResponse{
+			Accepted:        false,
+			ResponseMessage: "csr_pem is required",
+		}, nil
+	}
+
+	// Without TLS agents join at every start, which must not use up the token
+	if s.ca == nil {
+		node, err := s.storage.GetNode(ctx, req.NodeId)
+		if err != nil {
+			log.Printf("[Centro] Failed to get node: %v", err)
+			return &pb.JoinNodeResponse{
+				Accepted:        false,
+				ResponseMessage: "Failed to get node info",
+			}, nil
+		}
+		if node != nil && node.Approval == etcdstorage.NodeApprovalApproved {
+			return &pb.JoinNodeResponse{
+				Accepted:        true,
+				ResponseMessage: "Node is approved already",
+			}, nil
+		}
+	}
+
+	token, err := s.checkJoinToken(ctx, req.JoinToken)
+	if err != nil {
+		log.Printf("[Centro] Failed to check join token: %v", err)
+		return &pb.JoinNodeResponse{
+			Accepted:        false,
+			ResponseMessage: "Failed to check join token",
+		}, nil
+	}
+	if token == nil {
+		log.Printf("[Centro] Node %s tried to join with an invalid, used up or expired join token", req.NodeId)
+		return &pb.JoinNodeResponse{
+			Accepted:        false,
+			ResponseMessage: "Invalid, used up or expired join token",
+		}, nil
+	}
+
+	// A join token must not let one node take over the identity of another
+	refusal, err := s.joinRefusal(ctx, req.NodeId)
+	if err != nil {
+		log.Printf("[Centro] Failed to check identity of node %s: %v", req.NodeId, err)
+		return &pb.JoinNodeResponse{
+			Accepted:        false,
+			ResponseMessage: "Failed to check node identity",
+		}, nil
+	}
+	if refusal != "" {
+		log.Printf("[Centro] Node %s tried to join with join token %s: %s", req.NodeId, token.ID, refusal)
+		return &pb.JoinNodeResponse{
+			Accepted:        false,
+			ResponseMessage: fmt.Sprintf("Node %s %s, decommission it first to join again", req.NodeId, refusal),
+		}, nil
+	}
+
+	var certPEM []byte
+	var cert *x509.Certificate
+	if s.ca != nil {
+		certPEM, cert, err = s.ca.SignNodeCSR(req.CsrPem, req.NodeId, s.certValidity)
+		if errors.Is(err, pki.ErrInvalidCSR) {
+			return &pb.JoinNodeResponse{
+				Accepted:        false,
+				ResponseMessage: err.Error(),
+			}, nil
+		}
+		if err != nil {
+			log.Printf("[Centro] Failed to issue certificate for node %s: %v", req.NodeId, err)
+			return &pb.JoinNodeResponse{
+				Accepted:        false,
+				ResponseMessage: "Failed to issue certificate",
+			}, nil
+		}
+	}
+
+	// Nodes may present the same token at once, only as many as it allows join
+	_, ok, err := s.storage.UseJoinToken(ctx, token.ID, req.NodeId, time.Now())
+	if err != nil || !ok {
+		if err != nil {
+			log.Printf("[Centro] Failed to use join token %s: %v", token.ID, err)
+		}
+		return &pb.JoinNodeResponse{
+			Accepted:        false,
+			ResponseMessage: "Invalid, used up or expired join token",
+		}, nil
+	}
+	if cert != nil {
+		if err := s.recordNodeCertificate(ctx, req.NodeId, cert); err != nil {
+			log.Printf("[Centro] Failed to record certificate of node %s: %v", req.NodeId, err)
+			return &pb.JoinNodeResponse{
+				Accepted:        false,
+				ResponseMessage: "Failed to issue certificate",
+			}, nil
+		}
+	}
+	if err := s.admitNode(ctx, req.NodeId, token); err != nil {
+		log.Printf("[Centro] Failed to approve node %s: %v", req.NodeId, err)
+		return &pb.JoinNodeResponse{
+			Accepted:        false,
+			ResponseMessage: "Failed to register node",
+		}, nil
+	}
+
+	if cert == nil {
+		log.Printf("[Centro] Node %s joined with join token %s (cluster: %s)", req.NodeId, token.ID, token.Cluster)
+		return &pb.JoinNodeResponse{
+			Accepted:        true,
+			ResponseMessage: "Node approved",
+		}, nil
+	}
+
+	log.Printf("[Centro] Node %s joined with join token %s (cluster: %s), certificate valid until %s",
+		req.NodeId, token.ID, token.Cluster, cert.NotAfter.Format(time.RFC3339))
+	return &pb.JoinNodeResponse{
+		Accepted:         true,
+		ResponseMessage:  "Node approved, certificate issued",
+		CertificatePem:   certPEM,
+		CaCertificatePem: s.ca.CertificatePEM(),
+	}, nil
+}
@@ -0,0 +1,257 @@
+package grpc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+	pb "github.com/open-scheduler/proto"
+)
+
+// saveJoinToken stores a join token and returns the raw token
+func saveJoinToken(t *testing.T, storage *etcdstorage.Storage, token *etcdstorage.JoinToken) string {
+	t.Helper()
+	raw := JoinTokenPrefix + token.ID + "_secret-" + token.ID
+	token.SecretHash = HashToken(raw)
+	if token.MaxUses == 0 {
+		token.MaxUses = 1
+	}
+	if token.ExpiresAt.IsZero() {
+		token.ExpiresAt = time.Now().Add(time.Hour)
+	}
+	if token.CreatedBy == "" {
+		token.CreatedBy = "admin"
+	}
+	if err := storage.SaveJoinToken(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestCheckJoinToken(t *testing.T) {
+	server, storage := newTLSServer(t)
+	valid := saveJoinToken(t, storage, &etcdstorage.JoinToken{ID: "valid"})
+	expired := saveJoinToken(t, storage, &etcdstorage.JoinToken{ID: "expired", ExpiresAt: time.Now().Add(-time.Second)})
+	usedUp := saveJoinToken(t, storage, &etcdstorage.JoinToken{ID: "used-up", MaxUses: 2, UsedBy: []string{"node-1", "node-2"}})
+
+	tests := []struct {
+		name  string
+		raw   string
+		valid bool
+	}{
+		{name: "valid token", raw: valid, valid: true},
+		{name: "expired token", raw: expired},
+		{name: "used up token", raw: usedUp},
+		{name: "wrong secret", raw: JoinTokenPrefix + "valid_other"},
+		{name: "unknown token", raw: JoinTokenPrefix + "unknown_secret-unknown"},
+		{name: "node credential prefix", raw: NodeCredentialPrefix + "valid_secret-valid"},
+		{name: "without secret", raw: JoinTokenPrefix + "valid"},
+		{name: "empty", raw: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := server.checkJoinToken(context.Background(), tt.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (token != nil) != tt.valid {
+				t.Errorf("got token %v, want valid: %v", token, tt.valid)
+			}
+		})
+	}
+}
+
+func TestJoinTokenUses(t *testing.T) {
+	server, storage := newTLSServer(t)
+	raw := saveJoinToken(t, storage, &etcdstorage.JoinToken{ID: "twice", MaxUses: 2})
+
+	for i, nodeID := range []string{"node-1", "node-2", "node-3"} {
+		resp, err := server.JoinNode(context.Background(), &pb.JoinNodeRequest{NodeId: nodeID, JoinToken: raw, CsrPem: newCSR(t, nodeID)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := i < 2; resp.Accepted != want {
+			t.Errorf("%s accepted: %v, want %v (%s)", nodeID, resp.Accepted, want, resp.ResponseMessage)
+		}
+	}
+
+	token, err := storage.GetJoinToken(context.Background(), "twice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(token.UsedBy, []string{"node-1", "node-2"}) {
+		t.Errorf("used by %v", token.UsedBy)
+	}
+}
+
+func TestJoinBindsClusterAndLabels(t *testing.T) {
+	server, storage := newTLSServer(t)
+	ctx := context.Background()
+	raw := saveJoinToken(t, storage, &etcdstorage.JoinToken{
+		ID:        "eu",
+		Cluster:   "eu",
+		Labels:    map[string]string{"zone": "eu-1"},
+		CreatedBy: "ops",
+	})
+	// The node registered itself with another cluster and labels before
+	if err := storage.SaveNode(ctx, &etcdstorage.NodeInfo{
+		NodeID:      "node-1",
+		ClusterName: "us",
+		Labels:      map[string]string{"zone": "us-1"},
+		Approval:    etcdstorage.NodeApprovalPending,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := server.JoinNode(ctx, &pb.JoinNodeRequest{NodeId: "node-1", JoinToken: raw, CsrPem: newCSR(t, "node-1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Accepted || len(resp.CertificatePem) == 0 {
+		t.Fatalf("join refused: %s", resp.ResponseMessage)
+	}
+
+	node, err := storage.GetNode(ctx, "node-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Approval != etcdstorage.NodeApprovalApproved || node.ApprovedBy != "ops" || node.JoinTokenID != "eu" {
+		t.Errorf("node not approved by the token: %+v", node)
+	}
+	if node.ClusterName != "eu" || node.AssignedCluster != "eu" {
+		t.Errorf("cluster %q (assigned %q), want eu", node.ClusterName, node.AssignedCluster)
+	}
+	if !reflect.DeepEqual(node.Labels, map[string]string{"zone": "eu-1"}) {
+		t.Errorf("labels %v", node.Labels)
+	}
+}
+
+func TestJoinRefusesTakeover(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     *etcdstorage.NodeInfo
+		cert     bool
+		revoked  bool
+		accepted bool
+	}{
+		{name: "new node", accepted: true},
+		{name: "pending node", node: &etcdstorage.NodeInfo{Approval: etcdstorage.NodeApprovalPending}, accepted: true},
+		{name: "approved node", node: &etcdstorage.NodeInfo{Approval: etcdstorage.NodeApprovalApproved}},
+		{name: "node with a valid certificate", node: &etcdstorage.NodeInfo{Approval: etcdstorage.NodeApprovalPending}, cert: true},
+		{name: "node with a revoked certificate", node: &etcdstorage.NodeInfo{Approval: etcdstorage.NodeApprovalPending}, cert: true, revoked: true, accepted: true},
+		{name: "decommissioned node", node: &etcdstorage.NodeInfo{Approval: etcdstorage.NodeApprovalApproved, Decommissioned: true}},
+		{name: "decommissioned node with revoked identity", node: &etcdstorage.NodeInfo{Approval: etcdstorage.NodeApprovalApproved, Decommissioned: true, IdentityRevokedAt: time.Now()}, accepted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, storage := newTLSServer(t)
+			ctx := context.Background()
+			raw := saveJoinToken(t, storage, &etcdstorage.JoinToken{ID: "token"})
+			if tt.node != nil {
+				tt.node.NodeID = "node-1"
+				if err := storage.SaveNode(ctx, tt.node); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.cert {
+				_, cert, err := server.ca.SignNodeCSR(newCSR(t, "node-1"), "node-1", time.Hour)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := server.recordNodeCertificate(ctx, "node-1", cert); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.revoked {
+				if _, err := storage.RevokeNodeCertificates(ctx, "node-1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			resp, err := server.JoinNode(ctx, &pb.JoinNodeRequest{NodeId: "node-1", JoinToken: raw, CsrPem: newCSR(t, "node-1")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Accepted != tt.accepted {
+				t.Errorf("accepted: %v, want %v (%s)", resp.Accepted, tt.accepted, resp.ResponseMessage)
+			}
+
+			// A refused join must not use up the token
+			token, err := storage.GetJoinToken(ctx, "token")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if used := len(token.UsedBy) > 0; used != tt.accepted {
+				t.Errorf("token used: %v", used)
+			}
+		})
+	}
+}
+
+func TestJoinWithNodeCredential(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity string
+		accepted bool
+	}{
+		{name: "without the credential", accepted: false},
+		{name: "authenticated with the credential", identity: "node-1", accepted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, _ := etcdtest.NewStorage()
+			server := &CentroServer{storage: storage}
+			raw := saveJoinToken(t, storage, &etcdstorage.JoinToken{ID: "token"})
+			saveNodeCredential(t, storage, "c1", "node-1")
+
+			ctx := context.Background()
+			if tt.identity != "" {
+				ctx = context.WithValue(ctx, nodeIDKey{}, tt.identity)
+			}
+			resp, err := server.JoinNode(ctx, &pb.JoinNodeRequest{NodeId: "node-1", JoinToken: raw})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Accepted != tt.accepted {
+				t.Errorf("accepted: %v, want %v (%s)", resp.Accepted, tt.accepted, resp.ResponseMessage)
+			}
+		})
+	}
+}
+
+func TestJoinWithoutTLS(t *testing.T) {
+	storage, _ := etcdtest.NewStorage()
+	server := &CentroServer{storage: storage}
+	ctx := context.Background()
+	raw := saveJoinToken(t, storage, &etcdstorage.JoinToken{ID: "token"})
+
+	for i := 0; i < 3; i++ {
+		resp, err := server.JoinNode(ctx, &pb.JoinNodeRequest{NodeId: "node-1", JoinToken: raw})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Accepted {
+			t.Fatalf("join %d refused: %s", i+1, resp.ResponseMessage)
+		}
+	}
+
+	// Agents join again at every start, which must not use up the token
+	token, err := storage.GetJoinToken(ctx, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token.UsedBy) != 1 {
+		t.Errorf("token used %d times, want 1", len(token.UsedBy))
+	}
+
+	resp, err := server.JoinNode(ctx, &pb.JoinNodeRequest{NodeId: "node-2", JoinToken: raw, CsrPem: newCSR(t, "node-2")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Accepted {
+		t.Error("accepted a certificate request without a CA")
+	}
+}
@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/open-scheduler/centro/secrets"
+	pb "github.com/open-scheduler/proto"
+)
+
+// EnableSecrets lets agents get the decrypted secrets of their deployments
+func (s *CentroServer) EnableSecrets(keyring *secrets.Keyring) {
+	s.keyring = keyring
+}
+
+// GetDeploymentSecrets returns the values of the secrets a deployment
+// references. Only the node the deployment is assigned to gets them, and only
+// while the deployment is active.
+func (s *CentroServer) GetDeploymentSecrets(ctx context.Context, req *pb.GetDeploymentSecretsRequest) (*pb.GetDeploymentSecretsResponse, error) {
+	if req.NodeId == "" || req.DeploymentId == "" {
+		return &pb.GetDeploymentSecretsResponse{
+			Accepted:        false,
+			ResponseMessage: "node_id and deployment_id are required",
+		}, nil
+	}
+
+	active, err := s.storage.GetDeploymentActive(ctx, req.DeploymentId)
+	if err != nil {
+		log.Printf("[Centro] Failed to get deployment %s: %v", req.DeploymentId, err)
+		return &pb.GetDeploymentSecretsResponse{
+			Accepted:        false,
+			ResponseMessage: "Failed to get deployment status",
+		}, nil
+	}
+	if active == nil || active.NodeID != req.NodeId || active.Deployment == nil {
+		log.Printf("[Centro] Node %s asked for the secrets of deployment %s, which is not assigned to it", req.NodeId, req.DeploymentId)
+		return &pb.GetDeploymentSecretsResponse{
+			Accepted:        false,
+			ResponseMessage: "Deployment is not assigned to this node",
+		}, nil
+	}
+
+	refs := active.Deployment.Secrets
+	if len(refs) == 0 {
+		return &pb.GetDeploymentSecretsResponse{
+			Accepted:        true,
+			ResponseMessage: "Deployment has no secrets",
+		}, nil
+	}
+	if s.keyring == nil {
+		return &pb.GetDeploymentSecretsResponse{
+			Accepted:        false,
+			ResponseMessage: "Secrets are not enabled on Centro",
+		}, nil
+	}
+
+	resolved := make([]*pb.ResolvedSecret, 0, len(refs))
+	values := make(map[string][]byte)
+	for _, ref := range refs {
+		value, ok := values[ref.Secret]
+		if !ok {
+			secret, err := s.storage.GetSecret(ctx, ref.Secret)
+			if err != nil {
+				log.Printf("[Centro] Failed to get secret %s: %v", ref.Secret, err)
+				return &pb.GetDeploymentSecretsResponse{
+					Accepted:        false,
+					ResponseMessage: "Failed to get secrets",
+				}, nil
+			}
+			if secret == nil {
+				return &pb.GetDeploymentSecretsResponse{
+					Accepted:        false,
+					ResponseMessage: fmt.Sprintf("Secret %s does not exist", ref.Secret),
+				}, nil
+			}
+			value, err = s.keyring.Open(secret)
+			if err != nil {
+				log.Printf("[Centro] Failed to decrypt secret %s: %v", ref.Secret, err)
+				return &pb.GetDeploymentSecretsResponse{
+					Accepted:        false,
+					ResponseMessage: fmt.Sprintf("Failed to decrypt secret %s", ref.Secret),
+				}, nil
+			}
+			values[ref.Secret] = value
+		}
+		resolved = append(resolved, &pb.ResolvedSecret{
+			Secret: ref.Secret,
+			Env:    ref.Env,
+			File:   ref.File,
+			Value:  value,
+		})
+	}
+
+	log.Printf("[Centro] Sent %d secrets of deployment %s to node %s", len(resolved), req.DeploymentId, req.NodeId)
+	return &pb.GetDeploymentSecretsResponse{
+		Accepted:        true,
+		ResponseMessage: "Secrets resolved",
+		Secrets:         resolved,
+	}, nil
+}
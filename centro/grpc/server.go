@@ -7,6 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/open-scheduler/centro/pki"
+	"github.com/open-scheduler/centro/placement"
+	"github.com/open-scheduler/centro/secrets"
 	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
 	pb "github.com/open-scheduler/proto"
 )
@@ -14,6 +17,13 @@ import (
 type CentroServer struct {
 	pb.UnimplementedCentroSchedulerServiceServer
 	storage *etcdstorage.Storage
+	// ca signs node certificates, nil when gRPC is served without TLS
+	ca           *pki.CA
+	certValidity time.Duration
+	// autoApproveNodes approves nodes that register without a join token
+	autoApproveNodes bool
+	// keyring decrypts secrets for agents, nil unless secrets are enabled
+	keyring *secrets.Keyring
 }
 
 func NewCentroServer(storage *etcdstorage.Storage) *CentroServer {
@@ -132,20 +142,20 @@ func (s *CentroServer) handleDeploymentRejection(ctx context.Context, deployment
 			continue
 		}
 
-		// Check cluster match
-		if len(deployment.SelectedClusters) > 0 {
-			clusterMatches := false
-			for _, cluster := range deployment.SelectedClusters {
-				if cluster == node.ClusterName {
-					clusterMatches = true
-					break
-				}
-			}
-			if !clusterMatches {
-				rejectionReasons[nodeID] = fmt.Sprintf("Cluster mismatch: deployment requires %v, node is in '%s'",
-					deployment.SelectedClusters, node.ClusterName)
-				continue
-			}
+		if node.Decommissioned {
+			rejectionReasons[nodeID] = "Node is decommissioned"
+			continue
+		}
+
+		if !node.IsApproved() {
+			rejectionReasons[nodeID] = fmt.Sprintf("Node is not approved (%s)", node.Approval)
+			continue
+		}
+
+		// Check cluster and constraint match
+		if reason := placement.Check(node, deployment); reason != "" {
+			rejectionReasons[nodeID] = reason
+			continue
 		}
 
 		// Check resources
@@ -163,8 +173,7 @@ func (s *CentroServer) handleDeploymentRejection(ctx context.Context, deployment
 	// If no nodes can take this deployment, save a detailed event
 	if !hasHealthyMatchingNode {
 		var eventMessage strings.Builder
-		eventMessage.WriteString(fmt.Sprintf("[%s] No matching nodes available for deployment %s\n",
-			time.Now().Format(time.RFC3339), deployment.DeploymentId))
+		eventMessage.WriteString(fmt.Sprintf("No matching nodes available for deployment %s\n", deployment.DeploymentId))
 		eventMessage.WriteString(fmt.Sprintf("Deployment requirements: CPU=%.2f cores, RAM=%.2fMB, Disk=%.2fMB",
 			requiredCPU, requiredRAM, requiredDisk))
 		if len(deployment.SelectedClusters) > 0 {
@@ -176,7 +185,14 @@ func (s *CentroServer) handleDeploymentRejection(ctx context.Context, deployment
 			eventMessage.WriteString(fmt.Sprintf("  - Node '%s': %s\n", nodeID, reason))
 		}
 
-		if err := s.storage.SaveDeploymentEvent(ctx, deployment.DeploymentId, eventMessage.String()); err != nil {
+		event := &etcdstorage.DeploymentEvent{
+			Type:    etcdstorage.EventTypeWarning,
+			Reason:  "NoMatchingNodes",
+			Message: eventMessage.String(),
+			Source:  etcdstorage.EventSourceCentro,
+			Attempt: deployment.RetryCount,
+		}
+		if err := s.storage.SaveDeploymentEvent(ctx, deployment.DeploymentId, event); err != nil {
 			log.Printf("[Centro] Failed to save 'no matching nodes' event: %v", err)
 		}
 
@@ -196,6 +212,14 @@ func (s *CentroServer) handleDeploymentRejection(ctx context.Context, deployment
 	}
 }
 
+// statusEventReason turns an agent-reported status such as "running" into an event reason such as "Running"
+func statusEventReason(status string) string {
+	if status == "" {
+		return "StatusUpdate"
+	}
+	return strings.ToUpper(status[:1]) + status[1:]
+}
+
 func (s *CentroServer) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
 	if req.NodeId == "" {
 		return &pb.HeartbeatResponse{
@@ -204,41 +228,61 @@ func (s *CentroServer) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest)
 		}, nil
 	}
 
-	node, err := s.storage.GetNode(ctx, req.NodeId)
+	now := time.Now()
+	registered := false
+	node, err := s.storage.UpdateNode(ctx, req.NodeId, func(node *etcdstorage.NodeInfo) (*etcdstorage.NodeInfo, bool) {
+		registered = node == nil
+		if node == nil {
+			node = &etcdstorage.NodeInfo{
+				NodeID:   req.NodeId,
+				Approval: etcdstorage.NodeApprovalPending,
+			}
+			if s.autoApproveNodes {
+				node.Approval = etcdstorage.NodeApprovalApproved
+				node.ApprovedAt = now
+			}
+		}
+		if node.Approval == etcdstorage.NodeApprovalRejected {
+			return node, false
+		}
+
+		node.LastHeartbeat = now
+		node.ClusterName = req.ClusterName
+		if node.AssignedCluster != "" {
+			node.ClusterName = node.AssignedCluster
+		}
+		node.RamMB = req.AvailableMemoryMb
+		node.CPUCores = req.AvailableCpuCores
+		node.DiskMB = req.AvailableDiskMb
+		node.Metadata = req.NodeMetadata
+		return node, true
+	})
 	if err != nil {
-		log.Printf("[Centro] Failed to get node: %v", err)
+		log.Printf("[Centro] Failed to save node: %v", err)
 		return &pb.HeartbeatResponse{
 			Acknowledged:    false,
-			ResponseMessage: "Failed to get node info",
+			ResponseMessage: "Failed to save node info",
 		}, nil
 	}
 
-	if node == nil {
-		log.Printf("[Centro] New node registered: %s (cluster: %s)", req.NodeId, req.ClusterName)
-		node = &etcdstorage.NodeInfo{
-			NodeID:      req.NodeId,
-			ClusterName: req.ClusterName,
-		}
+	if registered {
+		log.Printf("[Centro] New node registered: %s (cluster: %s, approval: %s)", req.NodeId, node.ClusterName, node.Approval)
 	}
-
-	node.LastHeartbeat = time.Now()
-	node.ClusterName = req.ClusterName
-	node.RamMB = req.AvailableMemoryMb
-	node.CPUCores = req.AvailableCpuCores
-	node.DiskMB = req.AvailableDiskMb
-	node.Metadata = req.NodeMetadata
-
-	if err := s.storage.SaveNode(ctx, node); err != nil {
-		log.Printf("[Centro] Failed to save node: %v", err)
+	if node.Approval == etcdstorage.NodeApprovalRejected {
 		return &pb.HeartbeatResponse{
 			Acknowledged:    false,
-			ResponseMessage: "Failed to save node info",
+			ResponseMessage: "Node was rejected",
 		}, nil
 	}
-
 	log.Printf("[Centro] Heartbeat from node %s - CPU: %.2f cores, RAM: %.2fMB, Disk: %.2fMB",
 		req.NodeId, req.AvailableCpuCores, req.AvailableMemoryMb, req.AvailableDiskMb)
 
+	if !node.IsApproved() {
+		return &pb.HeartbeatResponse{
+			Acknowledged:    true,
+			ResponseMessage: "Heartbeat received, node is pending approval",
+		}, nil
+	}
 	return &pb.HeartbeatResponse{
 		Acknowledged:    true,
 		ResponseMessage: "Heartbeat received",
@@ -279,7 +323,21 @@ func (s *CentroServer) GetDeployment(ctx context.Context, req *pb.GetDeploymentR
 		}, nil
 	}
 
-	deployment, err := s.storage.DequeueDeployment(ctx)
+	if node.Decommissioned {
+		return &pb.GetDeploymentResponse{
+			DeploymentAvailable:    false,
+			ResponseMessage: "Node is decommissioned",
+		}, nil
+	}
+
+	if !node.IsApproved() {
+		return &pb.GetDeploymentResponse{
+			DeploymentAvailable:    false,
+			ResponseMessage: fmt.Sprintf("Node is not approved (%s)", node.Approval),
+		}, nil
+	}
+
+	deployment, err := s.storage.DequeueDeploymentForNode(ctx, req.NodeId)
 	if err != nil {
 		log.Printf("[Centro] Failed to dequeue deployment: %v", err)
 		return &pb.GetDeploymentResponse{
@@ -298,31 +356,18 @@ func (s *CentroServer) GetDeployment(ctx context.Context, req *pb.GetDeploymentR
 	// Calculate deployment resource requirements
 	requiredCPU, requiredRAM, requiredDisk := calculateDeploymentResourceRequirements(deployment)
 
-	// Check if deployment has cluster requirements and if node matches
-	var rejectionReason string
-	if len(deployment.SelectedClusters) > 0 {
-		log.Printf("[Centro] Deployment %s has cluster requirements: %v", deployment.DeploymentId, deployment.SelectedClusters)
-		clusterMatches := false
-		for _, cluster := range deployment.SelectedClusters {
-			if cluster == node.ClusterName {
-				clusterMatches = true
-				break
-			}
-		}
-		// log.Printf("[Centro] Cluster matches: %v, node cluster: %s", clusterMatches, node.ClusterName)
-		if !clusterMatches {
-			rejectionReason = fmt.Sprintf("Cluster mismatch: deployment requires %v, node is in '%s'",
-				deployment.SelectedClusters, node.ClusterName)
-			log.Printf("[Centro] Deployment %s rejected by node %s: %s", deployment.DeploymentId, req.NodeId, rejectionReason)
-
-			// Check if any other nodes could potentially take this deployment
-			s.handleDeploymentRejection(ctx, deployment, req.NodeId, rejectionReason, requiredCPU, requiredRAM, requiredDisk)
-
-			return &pb.GetDeploymentResponse{
-				DeploymentAvailable:    false,
-				ResponseMessage: fmt.Sprintf("No matching deployments for cluster: %s", node.ClusterName),
-			}, nil
-		}
+	// Check if the node matches the clusters and constraints of the deployment
+	rejectionReason := placement.Check(node, deployment)
+	if rejectionReason != "" {
+		log.Printf("[Centro] Deployment %s rejected by node %s: %s", deployment.DeploymentId, req.NodeId, rejectionReason)
+
+		// Check if any other nodes could potentially take this deployment
+		s.handleDeploymentRejection(ctx, deployment, req.NodeId, rejectionReason, requiredCPU, requiredRAM, requiredDisk)
+
+		return &pb.GetDeploymentResponse{
+			DeploymentAvailable:    false,
+			ResponseMessage: fmt.Sprintf("No matching deployments for node %s (cluster: %s)", req.NodeId, node.ClusterName),
+		}, nil
 	}
 
 	// Check if node has sufficient resources for the deployment
@@ -355,7 +400,15 @@ func (s *CentroServer) GetDeployment(ctx context.Context, req *pb.GetDeploymentR
 		log.Printf("[Centro] Failed to save deployment assignment: %v", err)
 	}
 
-	if err := s.storage.SaveDeploymentEvent(ctx, deployment.DeploymentId, fmt.Sprintf("[%s] Deployment assigned to node %s", time.Now().Format(time.RFC3339), req.NodeId)); err != nil {
+	assignedEvent := &etcdstorage.DeploymentEvent{
+		Type:    etcdstorage.EventTypeNormal,
+		Reason:  "Assigned",
+		Message: fmt.Sprintf("Deployment assigned to node %s", req.NodeId),
+		NodeID:  req.NodeId,
+		Source:  etcdstorage.EventSourceCentro,
+		Attempt: deployment.RetryCount,
+	}
+	if err := s.storage.SaveDeploymentEvent(ctx, deployment.DeploymentId, assignedEvent); err != nil {
 		log.Printf("[Centro] Failed to save deployment event: %v", err)
 	}
 
@@ -391,24 +444,101 @@ func (s *CentroServer) UpdateStatus(ctx context.Context, req *pb.UpdateStatusReq
 	}
 
 	if deploymentStatus == nil {
-		deploymentStatus = &etcdstorage.DeploymentStatus{
-			NodeID:    req.NodeId,
-			ClaimedAt: time.Now(),
+		// Agents keep reporting finished instances until they are cleaned up,
+		// those reports must not overwrite the final status
+		history, err := s.storage.GetDeploymentHistory(ctx, req.DeploymentId)
+		if err != nil {
+			log.Printf("[Centro] Failed to get deployment history: %v", err)
 		}
+		if history != nil && history.NodeID != req.NodeId {
+			log.Printf("[Centro] Node %s reported status of deployment %s, which ran on node %s", req.NodeId, req.DeploymentId, history.NodeID)
+			return &pb.UpdateStatusResponse{
+				Acknowledged:    false,
+				ResponseMessage: "Deployment is not assigned to this node",
+			}, nil
+		}
+		if history != nil {
+			// The replica was replaced after its node was lost, the node must not keep running it
+			if history.Status == "lost" {
+				return &pb.UpdateStatusResponse{
+					Acknowledged:    true,
+					ResponseMessage: "Deployment was replaced after its node was lost, instance should be stopped",
+					StopInstance:    true,
+				}, nil
+			}
+			return &pb.UpdateStatusResponse{
+				Acknowledged:    true,
+				ResponseMessage: fmt.Sprintf("Deployment already finished with status %s", history.Status),
+			}, nil
+		}
+
+		log.Printf("[Centro] Node %s reported status of unknown deployment %s", req.NodeId, req.DeploymentId)
+		return &pb.UpdateStatusResponse{
+			Acknowledged:    false,
+			ResponseMessage: "Deployment is not assigned to this node",
+		}, nil
+	}
+
+	if deploymentStatus.NodeID != req.NodeId {
+		log.Printf("[Centro] Node %s reported status of deployment %s, which is assigned to node %s", req.NodeId, req.DeploymentId, deploymentStatus.NodeID)
+		return &pb.UpdateStatusResponse{
+			Acknowledged:    false,
+			ResponseMessage: "Deployment is not assigned to this node",
+		}, nil
 	}
 
 	deploymentStatus.Status = req.DeploymentStatus
 	deploymentStatus.Detail = req.StatusMessage
 	deploymentStatus.UpdatedAt = time.Now()
+	if req.DeploymentStatus == "running" && deploymentStatus.StartedAt.IsZero() {
+		deploymentStatus.StartedAt = deploymentStatus.UpdatedAt
+	}
 
-	if err := s.storage.SaveDeploymentEvent(ctx, req.DeploymentId, fmt.Sprintf("[%s] Status: %s - %s", time.Now().Format(time.RFC3339), req.DeploymentStatus, req.StatusMessage)); err != nil {
+	statusEvent := &etcdstorage.DeploymentEvent{
+		Type:    etcdstorage.EventTypeNormal,
+		Reason:  statusEventReason(req.DeploymentStatus),
+		Message: req.StatusMessage,
+		NodeID:  req.NodeId,
+		Source:  etcdstorage.EventSourceAgent,
+	}
+	if statusEvent.Message == "" {
+		statusEvent.Message = fmt.Sprintf("Status changed to %s", req.DeploymentStatus)
+	}
+	if req.DeploymentStatus == "failed" {
+		statusEvent.Type = etcdstorage.EventTypeWarning
+	}
+	if deploymentStatus.Deployment != nil {
+		statusEvent.Attempt = deploymentStatus.Deployment.RetryCount
+	}
+	if err := s.storage.SaveDeploymentEvent(ctx, req.DeploymentId, statusEvent); err != nil {
 		log.Printf("[Centro] Failed to save deployment event: %v", err)
 	}
 
 	log.Printf("[Centro] Deployment %s status update from node %s: %s - %s",
 		req.DeploymentId, req.NodeId, req.DeploymentStatus, req.StatusMessage)
 
-	if req.DeploymentStatus == "completed" || req.DeploymentStatus == "failed" {
+	finished := req.DeploymentStatus == "completed" || req.DeploymentStatus == "failed" || req.DeploymentStatus == "stopped"
+
+	// Failed batch deployments are retried by their retry policy, service and
+	// system replicas are replaced by the reconciler instead
+	retry := req.DeploymentStatus == "failed" && deploymentStatus.Deployment != nil &&
+		deploymentStatus.Deployment.DeploymentType != "service" && deploymentStatus.Deployment.DeploymentType != "system" &&
+		deploymentStatus.DesiredStatus != etcdstorage.DesiredStatusStopped
+	if retry {
+		if err := s.storage.EnqueueFailedDeployment(ctx, deploymentStatus.Deployment); err != nil {
+			log.Printf("[Centro] Failed to enqueue failed deployment: %v", err)
+			return &pb.UpdateStatusResponse{
+				Acknowledged:    false,
+				ResponseMessage: "Failed to enqueue failed deployment",
+			}, nil
+		}
+
+		if err := s.storage.DeleteDeploymentActive(ctx, req.DeploymentId); err != nil {
+			log.Printf("[Centro] Failed to delete active deployment: %v", err)
+		}
+
+		log.Printf("[Centro] Deployment %s failed on node %s, moved to failed queue for retry", req.DeploymentId, req.NodeId)
+	} else if finished {
 		if err := s.storage.SaveDeploymentHistory(ctx, req.DeploymentId, deploymentStatus); err != nil {
 			log.Printf("[Centro] Failed to save deployment history: %v", err)
 			return &pb.UpdateStatusResponse{
@@ -422,6 +552,20 @@ func (s *CentroServer) UpdateStatus(ctx context.Context, req *pb.UpdateStatusReq
 		}
 
 		log.Printf("[Centro] Deployment %s finished with status: %s", req.DeploymentId, req.DeploymentStatus)
+
+		// Services and system deployments are kept running, the reconciler starts a replacement
+		if etcdstorage.IsLongRunningUnit(deploymentStatus.Deployment) && deploymentStatus.DesiredStatus != etcdstorage.DesiredStatusStopped {
+			exitEvent := &etcdstorage.DeploymentEvent{
+				Type:    etcdstorage.EventTypeWarning,
+				Reason:  "ReplicaExited",
+				Message: fmt.Sprintf("Replica %s exited with status %s on node %s, a replacement will be started", req.DeploymentId, req.DeploymentStatus, req.NodeId),
+				NodeID:  req.NodeId,
+				Source:  etcdstorage.EventSourceCentro,
+			}
+			if err := s.storage.SaveDeploymentEvent(ctx, deploymentStatus.Deployment.ParentDeploymentId, exitEvent); err != nil {
+				log.Printf("[Centro] Failed to save deployment event: %v", err)
+			}
+		}
 	} else {
 		if err := s.storage.SaveDeploymentActive(ctx, req.DeploymentId, deploymentStatus); err != nil {
 			log.Printf("[Centro] Failed to save deployment status: %v", err)
@@ -432,6 +576,15 @@ func (s *CentroServer) UpdateStatus(ctx context.Context, req *pb.UpdateStatusReq
 		}
 	}
 
+	// Centro asks for the instance to be stopped, e.g. when a rolling update replaces it
+	if !finished && deploymentStatus.DesiredStatus == etcdstorage.DesiredStatusStopped {
+		return &pb.UpdateStatusResponse{
+			Acknowledged:    true,
+			ResponseMessage: "Status updated, instance should be stopped",
+			StopInstance:    true,
+		}, nil
+	}
+
 	return &pb.UpdateStatusResponse{
 		Acknowledged:    true,
 		ResponseMessage: "Status updated successfully",
@@ -460,6 +613,22 @@ func (s *CentroServer) SetInstanceData(ctx context.Context, req *pb.SetInstanceD
 		}, nil
 	}
 
+	assignedNode, err := s.deploymentNode(ctx, req.DeploymentId)
+	if err != nil {
+		log.Printf("[Centro] Failed to get deployment %s: %v", req.DeploymentId, err)
+		return &pb.SetInstanceDataResponse{
+			Acknowledged:    false,
+			ResponseMessage: "Failed to get deployment status",
+		}, nil
+	}
+	if assignedNode != req.NodeId {
+		log.Printf("[Centro] Node %s sent instance data of deployment %s, which is not assigned to it", req.NodeId, req.DeploymentId)
+		return &pb.SetInstanceDataResponse{
+			Acknowledged:    false,
+			ResponseMessage: "Deployment is not assigned to this node",
+		}, nil
+	}
+
 	// Log instance data for monitoring
 	log.Printf("[Centro] Received instance data for deployment %s from node %s: instance=%s, status=%s, pid=%d",
 		req.DeploymentId, req.NodeId, req.InstanceData.InstanceId, req.InstanceData.Status, req.InstanceData.Pid)
@@ -479,6 +648,27 @@ func (s *CentroServer) SetInstanceData(ctx context.Context, req *pb.SetInstanceD
 	}, nil
 }
 
+// deploymentNode returns the node a running or finished deployment was
+// assigned to, or "" if the deployment is unknown
+func (s *CentroServer) deploymentNode(ctx context.Context, deploymentID string) (string, error) {
+	active, err := s.storage.GetDeploymentActive(ctx, deploymentID)
+	if err != nil {
+		return "", err
+	}
+	if active != nil {
+		return active.NodeID, nil
+	}
+
+	history, err := s.storage.GetDeploymentHistory(ctx, deploymentID)
+	if err != nil {
+		return "", err
+	}
+	if history != nil {
+		return history.NodeID, nil
+	}
+	return "", nil
+}
+
 func (s *CentroServer) AddDeployment(deployment *pb.Deployment) {
 	ctx := context.Background()
 	if err := s.storage.EnqueueDeployment(ctx, deployment); err != nil {
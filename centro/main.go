@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"log"
@@ -13,13 +14,19 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/open-scheduler/centro/audit"
 	centrogrpc "github.com/open-scheduler/centro/grpc"
 	"github.com/open-scheduler/centro/migration"
+	"github.com/open-scheduler/centro/oidc"
+	"github.com/open-scheduler/centro/pki"
+	"github.com/open-scheduler/centro/retention"
 	"github.com/open-scheduler/centro/scheduler"
 	"github.com/open-scheduler/centro/rest"
+	"github.com/open-scheduler/centro/secrets"
 	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
 	pb "github.com/open-scheduler/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 
 	_ "github.com/open-scheduler/docs" // This line is needed for Swagger
@@ -50,8 +57,42 @@ func main() {
 	port := flag.String("port", "50051", "The gRPC server port")
 	httpPort := flag.String("http-port", "8080", "The REST API server port")
 	etcdEndpoints := flag.String("etcd-endpoints", "localhost:2379", "Comma-separated list of etcd endpoints")
+	retentionMaxAge := flag.Duration("retention-max-age", 0, "Remove finished deployments and events older than this (0 = keep forever)")
+	retentionMaxCount := flag.Int("retention-max-count", 0, "Maximum number of finished deployments kept per deployment type (0 = unlimited)")
+	retentionMaxEvents := flag.Int("retention-max-events", 0, "Maximum number of events kept per deployment (0 = unlimited)")
+	retentionTypePolicies := flag.String("retention-type-policies", "", "Per deployment type retention overrides, e.g. \"batch:max-age=24h,max-count=500;service:max-events=200\"")
+	retentionArchiveDir := flag.String("retention-archive-dir", "", "Directory where pruned records are archived as JSONL before deletion (empty = no archive)")
+	retentionInterval := flag.Duration("retention-interval", 10*time.Minute, "Interval between retention garbage collection runs")
+	jwtSecretFile := flag.String("jwt-secret-file", "", "File containing the secret API tokens are signed with (default: $CENTRO_JWT_SECRET)")
+	devMode := flag.Bool("dev", false, "Development mode: allow the default JWT secret, create the first admin user with the password admin123, accept agents without a node credential and approve new nodes")
+	oidcIssuer := flag.String("oidc-issuer", "", "Issuer URL of the OpenID Connect provider for single sign-on (empty = disabled)")
+	oidcClientID := flag.String("oidc-client-id", "", "Client ID of Centro at the OpenID Connect provider, the secret is read from $CENTRO_OIDC_CLIENT_SECRET")
+	oidcRedirectURL := flag.String("oidc-redirect-url", "", "Callback URL registered with the provider, e.g. https://centro.example.com/api/v1/auth/oidc/callback")
+	oidcScopes := flag.String("oidc-scopes", "profile,email", "Comma-separated scopes requested in addition to openid")
+	oidcUsernameClaim := flag.String("oidc-username-claim", "", "ID token claim used as username (default: preferred_username, then email, then sub)")
+	oidcGroupsClaim := flag.String("oidc-groups-claim", "groups", "ID token claim listing the groups of the user")
+	oidcGroupRoles := flag.String("oidc-group-roles", "", "Roles of identity provider groups, first match wins, e.g. \"platform-admins=admin,developers=operator\"")
+	grpcInsecure := flag.Bool("grpc-insecure", false, "Serve gRPC without TLS, agents then authenticate with node credentials only")
+	grpcTLSHosts := flag.String("grpc-tls-hosts", "localhost,127.0.0.1", "Comma-separated host names and IP addresses agents reach the gRPC server at, for its certificate")
+	nodeCertValidity := flag.Duration("node-cert-validity", 24*time.Hour, "How long node client certificates are valid, agents renew them after two thirds of it")
+	autoApproveNodes := flag.Bool("auto-approve-nodes", false, "Approve nodes that register without a join token instead of leaving them pending (implied by -dev)")
+	auditLogFile := flag.String("audit-log-file", "", "File the audit log of API changes is also appended to as JSONL (empty = etcd only)")
+	secretsKeyFile := flag.String("secrets-key-file", "", "File with the 32 byte key secrets are encrypted with, raw or as hex or base64 (empty = secrets disabled)")
+	oidcDefaultRole := flag.String("oidc-default-role", "", "Role of SSO users in none of the mapped groups (empty = refuse them)")
+	oidcSessionTTL := flag.Duration("oidc-session-ttl", rest.DefaultSSOSessionTTL, "How long SSO logins can be refreshed before users log in at the identity provider again, which maps their groups to a role again")
 	flag.Parse()
 
+	jwtSecret, err := loadJWTSecret(*jwtSecretFile, *devMode)
+	if err != nil {
+		log.Fatalf("Invalid JWT secret: %v", err)
+	}
+	rest.SetJWTSecret(jwtSecret)
+
+	typePolicies, err := retention.ParseTypePolicies(*retentionTypePolicies)
+	if err != nil {
+		log.Fatalf("Invalid -retention-type-policies: %v", err)
+	}
+
 	endpoints := strings.Split(*etcdEndpoints, ",")
 	for i := range endpoints {
 		endpoints[i] = strings.TrimSpace(endpoints[i])
@@ -65,6 +106,7 @@ func main() {
 	defer storage.Close()
 
 	log.Printf("[Centro] Successfully connected to etcd")
+	rest.SetRevocationList(storage)
 
 	address := fmt.Sprintf(":%s", *port)
 	lis, err := net.Listen("tcp", address)
@@ -74,14 +116,116 @@ func main() {
 
 	log.Printf("[Centro] Starting gRPC server on %s", address)
 
-	grpcServer := grpc.NewServer()
+	nodeAuth := centrogrpc.NewNodeAuth(storage, *devMode)
+	if *devMode {
+		log.Printf("[Centro] WARNING: agents without a node credential are accepted. Do not use -dev in production.")
+	}
+	serverOptions := []grpc.ServerOption{
+		grpc.UnaryInterceptor(nodeAuth.UnaryInterceptor()),
+		grpc.StreamInterceptor(nodeAuth.StreamInterceptor()),
+	}
+
+	var ca *pki.CA
+	if *grpcInsecure {
+		log.Printf("[Centro] WARNING: serving gRPC without TLS, node credentials are sent in clear text")
+	} else {
+		ca, err = pki.LoadOrCreate(context.Background(), storage)
+		if err != nil {
+			log.Fatalf("Failed to load the CA: %v", err)
+		}
+		var hosts []string
+		for _, host := range strings.Split(*grpcTLSHosts, ",") {
+			if host = strings.TrimSpace(host); host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+		serverCert, err := ca.IssueServerCertificate(hosts)
+		if err != nil {
+			log.Fatalf("Failed to issue the gRPC server certificate: %v", err)
+		}
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    ca.Pool(),
+			// New nodes connect without a certificate to join, the
+			// interceptors authenticate every other call
+			ClientAuth: tls.VerifyClientCertIfGiven,
+			MinVersion: tls.VersionTLS12,
+		})))
+		log.Printf("[Centro] gRPC uses mTLS, server certificate valid for %v", hosts)
+	}
+	grpcServer := grpc.NewServer(serverOptions...)
 
 	centroServer := centrogrpc.NewCentroServer(storage)
+	if ca != nil {
+		centroServer.EnableCertificates(ca, *nodeCertValidity)
+	}
+	if *autoApproveNodes || *devMode {
+		centroServer.AutoApproveNodes()
+		log.Printf("[Centro] WARNING: nodes that register without a join token are approved")
+	}
+
+	var keyring *secrets.Keyring
+	if *secretsKeyFile != "" {
+		keyring, err = secrets.LoadKeyFile(*secretsKeyFile)
+		if err != nil {
+			log.Fatalf("Failed to load the secrets key: %v", err)
+		}
+		centroServer.EnableSecrets(keyring)
+		log.Printf("[Centro] Secrets are enabled, key ID %s", keyring.KeyID())
+	}
 	pb.RegisterCentroSchedulerServiceServer(grpcServer, centroServer)
 
 	reflection.Register(grpcServer)
 
 	apiServer := rest.NewAPIServer(storage)
+	if err := apiServer.BootstrapAdmin(context.Background(), os.Getenv("CENTRO_ADMIN_PASSWORD"), *devMode); err != nil {
+		log.Fatalf("Failed to create the admin user: %v", err)
+	}
+	if ca != nil {
+		apiServer.EnableNodeCertificates(ca)
+	}
+	if keyring != nil {
+		apiServer.EnableSecrets(keyring)
+	}
+	if *auditLogFile != "" {
+		sink, err := audit.NewFileSink(*auditLogFile)
+		if err != nil {
+			log.Fatalf("Failed to open the audit log: %v", err)
+		}
+		apiServer.EnableAuditFile(sink)
+		log.Printf("[Centro] Writing the audit log to %s", *auditLogFile)
+	}
+	if *oidcIssuer != "" {
+		groupRoles, err := oidc.ParseGroupRoles(*oidcGroupRoles)
+		if err != nil {
+			log.Fatalf("Invalid -oidc-group-roles: %v", err)
+		}
+		var scopes []string
+		for _, scope := range strings.Split(*oidcScopes, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				scopes = append(scopes, scope)
+			}
+		}
+		provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+			IssuerURL:     *oidcIssuer,
+			ClientID:      *oidcClientID,
+			ClientSecret:  os.Getenv("CENTRO_OIDC_CLIENT_SECRET"),
+			RedirectURL:   *oidcRedirectURL,
+			Scopes:        scopes,
+			UsernameClaim: *oidcUsernameClaim,
+			GroupsClaim:   *oidcGroupsClaim,
+			GroupRoles:    groupRoles,
+			DefaultRole:   *oidcDefaultRole,
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up single sign-on: %v", err)
+		}
+		if *oidcSessionTTL <= 0 {
+			log.Fatalf("-oidc-session-ttl must be positive")
+		}
+		apiServer.EnableOIDC(provider, *oidcSessionTTL)
+		log.Printf("[Centro] Single sign-on enabled with %s (device flow: %v)", *oidcIssuer, provider.SupportsDeviceFlow())
+	}
 	httpAddress := fmt.Sprintf(":%s", *httpPort)
 	httpServer := &http.Server{
 		Addr:    httpAddress,
@@ -91,6 +235,29 @@ func main() {
 	queue := scheduler.NewQueue(storage)
 	go queue.StartScheduler(context.Background())
 
+	collector, err := retention.NewCollector(storage, retention.Config{
+		Default: retention.Policy{
+			MaxAge:    *retentionMaxAge,
+			MaxCount:  *retentionMaxCount,
+			MaxEvents: *retentionMaxEvents,
+		},
+		PerType:    typePolicies,
+		ArchiveDir: *retentionArchiveDir,
+		Interval:   *retentionInterval,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up retention: %v", err)
+	}
+
+	// Work that must not run on more than one centro instance at a time
+	// only starts on the elected leader.
+	leaderCtx, stopLeader := context.WithCancel(context.Background())
+	defer stopLeader()
+	go storage.RunAsLeader(leaderCtx, candidateID(*port), func(ctx context.Context) {
+		go collector.Run(ctx)
+		go scheduler.NewReconciler(storage).Run(ctx)
+	})
+
 	go func() {
 		time.Sleep(5 * time.Second)
 		migration.SeedTestData(centroServer)
@@ -138,6 +305,45 @@ func main() {
 		log.Printf("[Centro] HTTP server shutdown error: %v", err)
 	}
 
+	stopLeader()
 	grpcServer.GracefulStop()
 	log.Println("[Centro] Servers stopped")
 }
+
+// candidateID identifies this centro instance in leader elections
+func candidateID(port string) string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "centro"
+	}
+	return fmt.Sprintf("%s:%s", hostname, port)
+}
+
+// minJWTSecretLength is the shortest secret accepted outside of dev mode
+const minJWTSecretLength = 32
+
+// loadJWTSecret reads the secret API tokens are signed with from a file or the
+// CENTRO_JWT_SECRET environment variable. The built-in default secret and short
+// secrets are only accepted in dev mode.
+func loadJWTSecret(file string, devMode bool) ([]byte, error) {
+	secret := os.Getenv("CENTRO_JWT_SECRET")
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		secret = strings.TrimSpace(string(data))
+	}
+
+	if secret == "" || secret == rest.DefaultJWTSecret {
+		if !devMode {
+			return nil, fmt.Errorf("refusing to start with the default secret, set -jwt-secret-file or CENTRO_JWT_SECRET, or run with -dev")
+		}
+		log.Printf("[Centro] WARNING: using the default JWT secret, anyone can forge API tokens. Do not use -dev in production.")
+		return []byte(rest.DefaultJWTSecret), nil
+	}
+	if len(secret) < minJWTSecretLength && !devMode {
+		return nil, fmt.Errorf("the secret must be at least %d bytes long", minJWTSecretLength)
+	}
+	return []byte(secret), nil
+}
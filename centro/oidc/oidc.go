@@ -0,0 +1,350 @@
+// Package oidc logs users in through an OpenID Connect identity provider.
+// Browsers use the authorization code flow with PKCE, osctl uses the device
+// authorization flow. Either way the ID token is verified against the keys
+// the provider publishes, and the groups of the user are mapped to a role.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+var (
+	// ErrAuthorizationPending means the user has not finished the device login yet
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	// ErrSlowDown means osctl polls too often and must wait 5 seconds longer
+	ErrSlowDown = errors.New("slow_down")
+	// ErrNoRole means none of the groups of the user is mapped to a role
+	ErrNoRole = errors.New("no role is mapped to the groups of the user")
+)
+
+// DeviceError is a final error of the device flow, like access_denied or expired_token
+type DeviceError struct {
+	Code        string
+	Description string
+}
+
+func (e *DeviceError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Description)
+	}
+	return e.Code
+}
+
+// GroupRole maps an identity provider group to a role
+type GroupRole struct {
+	Group string
+	Role  string
+}
+
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback of Centro registered with the provider,
+	// e.g. https://centro.example.com/api/v1/auth/oidc/callback
+	RedirectURL string
+	// Scopes are requested in addition to openid
+	Scopes []string
+	// UsernameClaim names the user, the default is preferred_username with
+	// email and sub as fallbacks
+	UsernameClaim string
+	// GroupsClaim lists the groups of the user, the default is groups
+	GroupsClaim string
+	// GroupRoles are checked in order, the first group the user is in
+	// decides the role
+	GroupRoles []GroupRole
+	// DefaultRole is the role of users in none of the groups, empty to
+	// refuse them
+	DefaultRole string
+}
+
+// Identity is a verified user of the identity provider
+type Identity struct {
+	Subject  string
+	Username string
+	Email    string
+	Groups   []string
+	Role     string
+}
+
+// DeviceLogin is a started device login. The user opens VerificationURI and
+// enters UserCode while osctl polls with DeviceCode.
+type DeviceLogin struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type Provider struct {
+	config   Config
+	oauth2   oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+	client   *http.Client
+}
+
+// NewProvider discovers the endpoints and keys of the identity provider
+func NewProvider(ctx context.Context, config Config) (*Provider, error) {
+	if config.IssuerURL == "" || config.ClientID == "" {
+		return nil, fmt.Errorf("OIDC needs an issuer URL and a client ID")
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	provider, err := gooidc.NewProvider(gooidc.ClientContext(ctx, client), config.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider %s: %w", config.IssuerURL, err)
+	}
+
+	scopes := append([]string{gooidc.ScopeOpenID}, config.Scopes...)
+	return &Provider{
+		config: config,
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: provider.Verifier(&gooidc.Config{ClientID: config.ClientID}),
+		client:   client,
+	}, nil
+}
+
+func (p *Provider) Issuer() string {
+	return p.config.IssuerURL
+}
+
+// SupportsDeviceFlow reports whether the provider has a device authorization endpoint
+func (p *Provider) SupportsDeviceFlow() bool {
+	return p.oauth2.Endpoint.DeviceAuthURL != ""
+}
+
+func (p *Provider) context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, p.client)
+}
+
+// AuthCodeURL returns the URL of the provider a browser login starts at.
+// The code verifier and nonce must be kept for Exchange.
+func (p *Provider) AuthCodeURL(state, codeVerifier, nonce string) string {
+	return p.oauth2.AuthCodeURL(state, oauth2.S256ChallengeOption(codeVerifier), gooidc.Nonce(nonce))
+}
+
+// Exchange finishes a browser login with the code the provider redirected back with
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	token, err := p.oauth2.Exchange(p.context(ctx), code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	idToken, err := p.verifier.Verify(p.context(ctx), rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify ID token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, fmt.Errorf("ID token nonce does not match the login")
+	}
+	return p.identity(idToken)
+}
+
+// StartDeviceLogin asks the provider for a device code and a user code
+func (p *Provider) StartDeviceLogin(ctx context.Context) (*DeviceLogin, error) {
+	if !p.SupportsDeviceFlow() {
+		return nil, fmt.Errorf("OIDC provider %s does not support the device flow", p.config.IssuerURL)
+	}
+
+	// Confidential clients authenticate at the device endpoint too, which
+	// oauth2 does not do by itself
+	var opts []oauth2.AuthCodeOption
+	if p.config.ClientSecret != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("client_secret", p.config.ClientSecret))
+	}
+	resp, err := p.oauth2.DeviceAuth(p.context(ctx), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start device login: %w", err)
+	}
+
+	login := &DeviceLogin{
+		DeviceCode:              resp.DeviceCode,
+		UserCode:                resp.UserCode,
+		VerificationURI:         resp.VerificationURI,
+		VerificationURIComplete: resp.VerificationURIComplete,
+		Interval:                int(resp.Interval),
+	}
+	if !resp.Expiry.IsZero() {
+		login.ExpiresIn = int(time.Until(resp.Expiry).Seconds())
+	}
+	if login.Interval == 0 {
+		login.Interval = 5
+	}
+	return login, nil
+}
+
+// PollDeviceLogin asks the provider once whether the user finished a device
+// login. It returns ErrAuthorizationPending or ErrSlowDown while osctl should
+// keep polling, and a *DeviceError when the login failed.
+func (p *Provider) PollDeviceLogin(ctx context.Context, deviceCode string) (*Identity, error) {
+	form := url.Values{
+		"grant_type":  {deviceCodeGrantType},
+		"device_code": {deviceCode},
+		"client_id":   {p.config.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.oauth2.Endpoint.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to poll device login: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response (status %d): %w", resp.StatusCode, err)
+	}
+
+	switch token.Error {
+	case "":
+	case ErrAuthorizationPending.Error():
+		return nil, ErrAuthorizationPending
+	case ErrSlowDown.Error():
+		return nil, ErrSlowDown
+	default:
+		return nil, &DeviceError{Code: token.Error, Description: token.ErrorDescription}
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	return p.VerifyIDToken(ctx, token.IDToken)
+}
+
+// VerifyIDToken checks the signature, issuer, audience and expiry of an ID
+// token and returns the identity in it
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken string) (*Identity, error) {
+	idToken, err := p.verifier.Verify(p.context(ctx), rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify ID token: %w", err)
+	}
+	return p.identity(idToken)
+}
+
+func (p *Provider) identity(idToken *gooidc.IDToken) (*Identity, error) {
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode ID token claims: %w", err)
+	}
+
+	identity := &Identity{
+		Subject: idToken.Subject,
+		Email:   stringClaim(claims, "email"),
+		Groups:  stringsClaim(claims, p.config.GroupsClaim),
+	}
+	if p.config.UsernameClaim != "" {
+		identity.Username = stringClaim(claims, p.config.UsernameClaim)
+	} else {
+		identity.Username = stringClaim(claims, "preferred_username")
+		if identity.Username == "" {
+			identity.Username = identity.Email
+		}
+		if identity.Username == "" {
+			identity.Username = idToken.Subject
+		}
+	}
+	if identity.Username == "" {
+		return nil, fmt.Errorf("ID token has no %s claim", p.config.UsernameClaim)
+	}
+
+	identity.Role = p.RoleFor(identity.Groups)
+	if identity.Role == "" {
+		return identity, ErrNoRole
+	}
+	return identity, nil
+}
+
+// RoleFor returns the role of a user in groups, or "" if none is mapped
+func (p *Provider) RoleFor(groups []string) string {
+	for _, mapping := range p.config.GroupRoles {
+		for _, group := range groups {
+			if group == mapping.Group {
+				return mapping.Role
+			}
+		}
+	}
+	return p.config.DefaultRole
+}
+
+// ParseGroupRoles parses a mapping like "platform-admins=admin,developers=operator"
+func ParseGroupRoles(value string) ([]GroupRole, error) {
+	var mappings []GroupRole
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(item, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" || role == "" {
+			return nil, fmt.Errorf("invalid group mapping %q, use group=role", item)
+		}
+		mappings = append(mappings, GroupRole{Group: group, Role: role})
+	}
+	return mappings, nil
+}
+
+func stringClaim(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// stringsClaim reads a claim that is a list of strings, or a single string
+func stringsClaim(claims map[string]interface{}, name string) []string {
+	switch value := claims[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
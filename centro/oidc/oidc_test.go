@@ -0,0 +1,277 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/open-scheduler/centro/oidc/oidctest"
+	"golang.org/x/oauth2"
+)
+
+const (
+	testClientID    = "centro"
+	testRedirectURL = "https://centro.example.com/api/v1/auth/oidc/callback"
+)
+
+var testGroupRoles = []GroupRole{
+	{Group: "platform-admins", Role: "admin"},
+	{Group: "developers", Role: "operator"},
+}
+
+func newTestServer(t *testing.T, clientSecret string) *oidctest.Server {
+	t.Helper()
+	server, err := oidctest.NewServer(testClientID, clientSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestProvider(t *testing.T, server *oidctest.Server, defaultRole string) *Provider {
+	t.Helper()
+	provider, err := NewProvider(context.Background(), Config{
+		IssuerURL:    server.Issuer(),
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+		RedirectURL:  testRedirectURL,
+		GroupRoles:   testGroupRoles,
+		DefaultRole:  defaultRole,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+// authorize follows a browser to the provider and returns the code and state
+// it redirects back to the callback with
+func authorize(t *testing.T, provider *Provider, state, codeVerifier, nonce string) (code, returnedState string) {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(provider.AuthCodeURL(state, codeVerifier, nonce))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned status %d, want 302", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != testRedirectURL {
+		t.Fatalf("redirected to %s, want %s", got, testRedirectURL)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestAuthCodeFlowWithPKCE(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, "")
+	server.SetUser(oidctest.User{Subject: "user-1", Username: "alice", Email: "alice@example.com", Groups: []string{"developers"}})
+	provider := newTestProvider(t, server, "")
+
+	verifier := oauth2.GenerateVerifier()
+	code, state := authorize(t, provider, "state-1", verifier, "nonce-1")
+	if state != "state-1" {
+		t.Errorf("provider returned state %q, want state-1", state)
+	}
+
+	identity, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Username != "alice" || identity.Subject != "user-1" || identity.Email != "alice@example.com" {
+		t.Errorf("identity = %+v, want alice (user-1)", identity)
+	}
+	if identity.Role != "operator" {
+		t.Errorf("role = %q, want operator", identity.Role)
+	}
+
+	// A code can only be exchanged once
+	if _, err := provider.Exchange(ctx, code, verifier, "nonce-1"); err == nil {
+		t.Error("exchanging a code twice succeeded")
+	}
+}
+
+func TestAuthCodeFlowRejectsWrongVerifier(t *testing.T) {
+	server := newTestServer(t, "")
+	provider := newTestProvider(t, server, "viewer")
+
+	code, _ := authorize(t, provider, "state-1", oauth2.GenerateVerifier(), "nonce-1")
+	_, err := provider.Exchange(context.Background(), code, oauth2.GenerateVerifier(), "nonce-1")
+	if err == nil || !strings.Contains(err.Error(), "code_verifier") {
+		t.Fatalf("exchange with a different code verifier returned %v, want a code_verifier error", err)
+	}
+}
+
+func TestAuthCodeFlowRejectsWrongNonce(t *testing.T) {
+	server := newTestServer(t, "")
+	provider := newTestProvider(t, server, "viewer")
+
+	verifier := oauth2.GenerateVerifier()
+	code, _ := authorize(t, provider, "state-1", verifier, "nonce-1")
+	_, err := provider.Exchange(context.Background(), code, verifier, "nonce-2")
+	if err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Fatalf("exchange with the nonce of another login returned %v, want a nonce error", err)
+	}
+}
+
+func TestDeviceFlow(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, "client-secret")
+	server.SetUser(oidctest.User{Subject: "user-2", Username: "bob", Groups: []string{"platform-admins"}})
+	provider := newTestProvider(t, server, "")
+
+	if !provider.SupportsDeviceFlow() {
+		t.Fatal("device flow not discovered")
+	}
+	login, err := provider.StartDeviceLogin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login.DeviceCode == "" || login.UserCode == "" || login.VerificationURI == "" {
+		t.Fatalf("incomplete device login %+v", login)
+	}
+
+	if _, err := provider.PollDeviceLogin(ctx, login.DeviceCode); !errors.Is(err, ErrAuthorizationPending) {
+		t.Fatalf("poll before approval returned %v, want ErrAuthorizationPending", err)
+	}
+
+	if !server.ApproveDevice(login.UserCode) {
+		t.Fatal("user code is not pending")
+	}
+	identity, err := provider.PollDeviceLogin(ctx, login.DeviceCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Username != "bob" || identity.Role != "admin" {
+		t.Errorf("identity = %+v, want bob with role admin", identity)
+	}
+}
+
+func TestDeviceFlowDenied(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, "client-secret")
+	provider := newTestProvider(t, server, "viewer")
+
+	login, err := provider.StartDeviceLogin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.DenyDevice(login.UserCode)
+
+	_, err = provider.PollDeviceLogin(ctx, login.DeviceCode)
+	var deviceErr *DeviceError
+	if !errors.As(err, &deviceErr) || deviceErr.Code != "access_denied" {
+		t.Fatalf("poll after denial returned %v, want access_denied", err)
+	}
+}
+
+func TestVerifyIDTokenRejectsUnknownKey(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, "")
+	provider := newTestProvider(t, server, "viewer")
+
+	// Another provider signs with a key that is not in the JWKS of server,
+	// claiming to be server
+	other := newTestServer(t, "")
+	forged, err := other.IDToken("", map[string]interface{}{"iss": server.Issuer()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.VerifyIDToken(ctx, forged); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Fatalf("ID token signed with an unknown key returned %v, want a signature error", err)
+	}
+
+	genuine, err := server.IDToken("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.VerifyIDToken(ctx, genuine); err != nil {
+		t.Fatalf("ID token of the provider was rejected: %v", err)
+	}
+}
+
+func TestVerifyIDTokenRejectsOtherAudience(t *testing.T) {
+	server := newTestServer(t, "")
+	provider := newTestProvider(t, server, "viewer")
+
+	token, err := server.IDToken("", map[string]interface{}{"aud": "another-client"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.VerifyIDToken(context.Background(), token); err == nil {
+		t.Fatal("ID token for another client was accepted")
+	}
+}
+
+func TestGroupRoleMapping(t *testing.T) {
+	tests := []struct {
+		name        string
+		groups      interface{}
+		defaultRole string
+		wantRole    string
+		wantNoRole  bool
+	}{
+		{name: "first mapping wins", groups: []string{"developers", "platform-admins"}, wantRole: "admin"},
+		{name: "mapped group", groups: []string{"developers"}, wantRole: "operator"},
+		{name: "single group as string", groups: "developers", wantRole: "operator"},
+		{name: "default role", groups: []string{"sales"}, defaultRole: "viewer", wantRole: "viewer"},
+		{name: "no groups with default role", groups: nil, defaultRole: "viewer", wantRole: "viewer"},
+		{name: "no mapping and no default role", groups: []string{"sales"}, wantNoRole: true},
+	}
+
+	server := newTestServer(t, "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestProvider(t, server, tt.defaultRole)
+			token, err := server.IDToken("", map[string]interface{}{"groups": tt.groups})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			identity, err := provider.VerifyIDToken(context.Background(), token)
+			if tt.wantNoRole {
+				if !errors.Is(err, ErrNoRole) {
+					t.Fatalf("err = %v, want ErrNoRole", err)
+				}
+				if identity == nil || identity.Username != "alice" {
+					t.Errorf("identity = %+v, want alice without role", identity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity.Role != tt.wantRole {
+				t.Errorf("role = %q, want %q", identity.Role, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestParseGroupRoles(t *testing.T) {
+	mappings, err := ParseGroupRoles(" platform-admins=admin, developers = operator ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mappings) != 2 || mappings[0] != testGroupRoles[0] || mappings[1] != testGroupRoles[1] {
+		t.Errorf("mappings = %+v, want %+v", mappings, testGroupRoles)
+	}
+
+	for _, invalid := range []string{"admins", "=admin", "admins="} {
+		if _, err := ParseGroupRoles(invalid); err == nil {
+			t.Errorf("ParseGroupRoles(%q) succeeded", invalid)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+// Package oidctest runs a local OpenID Connect provider for tests. It
+// supports discovery, JWKS, the authorization code flow with PKCE and the
+// device authorization flow, and logs everyone in as the configured User
+// without asking.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v3"
+)
+
+// User is who the server logs in
+type User struct {
+	Subject  string
+	Username string
+	Email    string
+	Groups   []string
+}
+
+type authRequest struct {
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+}
+
+type deviceRequest struct {
+	userCode string
+	approved bool
+	denied   bool
+	expires  time.Time
+}
+
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+	// TokenLifetime is the lifetime of issued ID tokens
+	TokenLifetime time.Duration
+
+	key   *rsa.PrivateKey
+	keyID string
+
+	mu      sync.Mutex
+	user    User
+	codes   map[string]*authRequest
+	devices map[string]*deviceRequest
+}
+
+// NewServer starts a provider for a client. With an empty clientSecret the
+// client is public and does not authenticate at the token endpoint.
+func NewServer(clientID, clientSecret string) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	s := &Server{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		TokenLifetime: time.Hour,
+		key:           key,
+		keyID:         randomString(8),
+		user:          User{Subject: "user-1", Username: "alice", Email: "alice@example.com"},
+		codes:         make(map[string]*authRequest),
+		devices:       make(map[string]*deviceRequest),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("/keys", s.handleKeys)
+	mux.HandleFunc("/authorize", s.handleAuthorize)
+	mux.HandleFunc("/device/code", s.handleDeviceCode)
+	mux.HandleFunc("/device", s.handleDevice)
+	mux.HandleFunc("/token", s.handleToken)
+	s.Server = httptest.NewServer(mux)
+	return s, nil
+}
+
+// Issuer is the issuer URL to configure Centro with
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// SetUser changes who is logged in by later logins
+func (s *Server) SetUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = user
+}
+
+// ApproveDevice finishes the device login with userCode as if the user
+// entered it in a browser. It reports whether the code was pending.
+func (s *Server) ApproveDevice(userCode string) bool {
+	return s.decideDevice(userCode, true)
+}
+
+// DenyDevice rejects the device login with userCode
+func (s *Server) DenyDevice(userCode string) bool {
+	return s.decideDevice(userCode, false)
+}
+
+func (s *Server) decideDevice(userCode string, approve bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, device := range s.devices {
+		if device.userCode == userCode && !device.approved && !device.denied {
+			device.approved = approve
+			device.denied = !approve
+			return true
+		}
+	}
+	return false
+}
+
+// IDToken signs an ID token for the client with the claims of the current
+// user, overridden by extra claims
+func (s *Server) IDToken(nonce string, extra map[string]interface{}) (string, error) {
+	s.mu.Lock()
+	user := s.user
+	s.mu.Unlock()
+
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":                s.URL,
+		"sub":                user.Subject,
+		"aud":                s.ClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(s.TokenLifetime).Unix(),
+		"preferred_username": user.Username,
+		"email":              user.Email,
+		"groups":             user.Groups,
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	for name, value := range extra {
+		claims[name] = value
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal claims: %w", err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.RS256,
+		Key:       jose.JSONWebKey{Key: s.key, KeyID: s.keyID, Algorithm: string(jose.RS256)},
+	}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", fmt.Errorf("failed to create signer: %w", err)
+	}
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign ID token: %w", err)
+	}
+	return signed.CompactSerialize()
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"device_authorization_endpoint":         s.URL + "/device/code",
+		"jwks_uri":                              s.URL + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"grant_types_supported":                 []string{"authorization_code", "urn:ietf:params:oauth:grant-type:device_code"},
+	})
+}
+
+func (s *Server) handleKeys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+		Key:       &s.key.PublicKey,
+		KeyID:     s.keyID,
+		Algorithm: string(jose.RS256),
+		Use:       "sig",
+	}}})
+}
+
+// handleAuthorize logs the user in right away and redirects back with a code
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != s.ClientID || query.Get("response_type") != "code" {
+		http.Error(w, "unknown client or unsupported response type", http.StatusBadRequest)
+		return
+	}
+	if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || !redirectURI.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString(16)
+	s.mu.Lock()
+	s.codes[code] = &authRequest{
+		redirectURI:   query.Get("redirect_uri"),
+		codeChallenge: query.Get("code_challenge"),
+		nonce:         query.Get("nonce"),
+	}
+	s.mu.Unlock()
+
+	values := redirectURI.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirectURI.RawQuery = values.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (s *Server) handleDeviceCode(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticateClient(w, r) {
+		return
+	}
+
+	deviceCode := randomString(16)
+	userCode := randomString(4)
+	s.mu.Lock()
+	s.devices[deviceCode] = &deviceRequest{userCode: userCode, expires: time.Now().Add(10 * time.Minute)}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"device_code":               deviceCode,
+		"user_code":                 userCode,
+		"verification_uri":          s.URL + "/device",
+		"verification_uri_complete": s.URL + "/device?user_code=" + userCode,
+		"expires_in":                600,
+		"interval":                  1,
+	})
+}
+
+// handleDevice approves a device login like a user in a browser would
+func (s *Server) handleDevice(w http.ResponseWriter, r *http.Request) {
+	if !s.ApproveDevice(r.URL.Query().Get("user_code")) {
+		http.Error(w, "unknown user code", http.StatusNotFound)
+		return
+	}
+	fmt.Fprintln(w, "Device approved, you can close this window.")
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticateClient(w, r) {
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		// The code is only used up by a successful exchange, so the retry of
+		// oauth2 with another client auth style reports the same error
+		s.mu.Lock()
+		request, ok := s.codes[r.PostForm.Get("code")]
+		s.mu.Unlock()
+		if !ok || request.redirectURI != r.PostForm.Get("redirect_uri") {
+			tokenError(w, "invalid_grant", "unknown code or redirect_uri")
+			return
+		}
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != request.codeChallenge {
+			tokenError(w, "invalid_grant", "code_verifier does not match code_challenge")
+			return
+		}
+		s.mu.Lock()
+		delete(s.codes, r.PostForm.Get("code"))
+		s.mu.Unlock()
+		s.issueTokens(w, request.nonce)
+
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		s.mu.Lock()
+		device, ok := s.devices[r.PostForm.Get("device_code")]
+		if ok && (device.approved || device.denied || time.Now().After(device.expires)) {
+			delete(s.devices, r.PostForm.Get("device_code"))
+		}
+		s.mu.Unlock()
+		switch {
+		case !ok:
+			tokenError(w, "invalid_grant", "unknown device_code")
+		case device.denied:
+			tokenError(w, "access_denied", "the user denied the login")
+		case device.approved:
+			s.issueTokens(w, "")
+		case time.Now().After(device.expires):
+			tokenError(w, "expired_token", "the device code expired")
+		default:
+			tokenError(w, "authorization_pending", "")
+		}
+
+	default:
+		tokenError(w, "unsupported_grant_type", "")
+	}
+}
+
+func (s *Server) issueTokens(w http.ResponseWriter, nonce string) {
+	idToken, err := s.IDToken(nonce, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(16),
+		"token_type":   "Bearer",
+		"expires_in":   int(s.TokenLifetime.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+// authenticateClient checks client_id, and the secret of confidential
+// clients, sent with basic auth or in the form
+func (s *Server) authenticateClient(w http.ResponseWriter, r *http.Request) bool {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request", "invalid form")
+		return false
+	}
+
+	clientID, secret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != s.ClientID || (s.ClientSecret != "" && secret != s.ClientSecret) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return false
+	}
+	return true
+}
+
+func tokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func randomString(bytes int) string {
+	buf := make([]byte, bytes)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,49 @@
+// Package periodic parses the cron schedule of periodic deployments. The
+// expressions use the same syntax as the cron scheduler of the agent
+// (robfig/cron): five fields (minute, hour, day of month, month, day of week)
+// or a descriptor such as @hourly, @daily or @every 30m.
+package periodic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/open-scheduler/proto"
+	"github.com/robfig/cron/v3"
+)
+
+// ParseSchedule returns the schedule of a periodic deployment, evaluated in its
+// time zone or UTC if it has none
+func ParseSchedule(periodic *pb.Periodic) (cron.Schedule, error) {
+	expression := strings.TrimSpace(periodic.Cron)
+	if expression == "" {
+		return nil, fmt.Errorf("cron expression is empty")
+	}
+	if strings.HasPrefix(expression, "CRON_TZ=") || strings.HasPrefix(expression, "TZ=") {
+		return nil, fmt.Errorf("set the time zone with time_zone instead of a TZ prefix")
+	}
+
+	location, err := Location(periodic)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := cron.ParseStandard(fmt.Sprintf("CRON_TZ=%s %s", location, expression))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %v", expression, err)
+	}
+	return schedule, nil
+}
+
+// Location returns the time zone a periodic deployment is scheduled in
+func Location(periodic *pb.Periodic) (*time.Location, error) {
+	if periodic.TimeZone == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(periodic.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", periodic.TimeZone)
+	}
+	return location, nil
+}
@@ -0,0 +1,226 @@
+// Package pki runs the internal certificate authority of Centro. It signs the
+// gRPC server certificate of Centro and the short-lived client certificates
+// agents authenticate with. The subject common name of a client certificate is
+// the node ID.
+package pki
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// caValidity is how long the CA certificate is valid
+const caValidity = 10 * 365 * 24 * time.Hour
+
+// ServerCertValidity is how long the certificate Centro serves gRPC with is
+// valid. It is issued again at every start.
+const ServerCertValidity = 365 * 24 * time.Hour
+
+// clockSkew backdates certificates so nodes with a slightly wrong clock accept them
+const clockSkew = 5 * time.Minute
+
+// ErrInvalidCSR is returned for certificate signing requests that cannot be parsed or verified
+var ErrInvalidCSR = errors.New("invalid certificate signing request")
+
+// CA signs certificates with a key that is shared by all Centro instances
+type CA struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     crypto.Signer
+}
+
+// LoadOrCreate returns the CA stored in etcd, and creates it on the first start
+func LoadOrCreate(ctx context.Context, storage *etcdstorage.Storage) (*CA, error) {
+	stored, err := storage.GetCA(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		created, err := newCAKeyPair()
+		if err != nil {
+			return nil, err
+		}
+		// Another Centro instance may have been faster, its CA is used then
+		if stored, err = storage.CreateCA(ctx, created); err != nil {
+			return nil, err
+		}
+	}
+	return parseCA(stored)
+}
+
+func newCAKeyPair() (*etcdstorage.CAKeyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Open Scheduler Centro CA"},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CA key: %w", err)
+	}
+
+	return &etcdstorage.CAKeyPair{
+		CertificatePEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		KeyPEM:         string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+		CreatedAt:      now,
+	}, nil
+}
+
+func parseCA(stored *etcdstorage.CAKeyPair) (*CA, error) {
+	certBlock, _ := pem.Decode([]byte(stored.CertificatePEM))
+	keyBlock, _ := pem.Decode([]byte(stored.KeyPEM))
+	if certBlock == nil || keyBlock == nil {
+		return nil, fmt.Errorf("failed to decode CA")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("CA key cannot sign")
+	}
+	return &CA{cert: cert, certPEM: []byte(stored.CertificatePEM), key: signer}, nil
+}
+
+// CertificatePEM returns the CA certificate agents verify Centro with
+func (ca *CA) CertificatePEM() []byte {
+	return ca.certPEM
+}
+
+// Pool returns a pool holding only the CA certificate
+func (ca *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// IssueServerCertificate creates a key and a certificate for the gRPC server
+// of Centro, valid for the given host names and IP addresses
+func (ca *CA) IssueServerCertificate(hosts []string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to generate server key: %w", err)
+	}
+	serial, err := newSerial()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "centro"},
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     now.Add(ServerCertValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create server certificate: %w", err)
+	}
+	return tls.Certificate{
+		Certificate: [][]byte{der, ca.cert.Raw},
+		PrivateKey:  key,
+	}, nil
+}
+
+// SignNodeCSR issues a client certificate for a node. Only the public key of
+// the request is used, the subject is always the node ID.
+func (ca *CA) SignNodeCSR(csrPEM []byte, nodeID string, validity time.Duration) ([]byte, *x509.Certificate, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, nil, ErrInvalidCSR
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidCSR, err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidCSR, err)
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: nodeID},
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     now.Add(validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, csr.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create node certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse node certificate: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), cert, nil
+}
+
+// Serial returns the serial number a certificate is recorded with
+func Serial(cert *x509.Certificate) string {
+	return hex.EncodeToString(cert.SerialNumber.Bytes())
+}
+
+func newSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
@@ -0,0 +1,132 @@
+package pki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+)
+
+func newCSR(t *testing.T, commonName string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestLoadOrCreate(t *testing.T) {
+	storage, _ := etcdtest.NewStorage()
+	ctx := context.Background()
+
+	created, err := LoadOrCreate(ctx, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadOrCreate(ctx, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.cert.Equal(loaded.cert) {
+		t.Error("a second start created another CA")
+	}
+	if !created.cert.IsCA {
+		t.Error("CA certificate is not a CA")
+	}
+}
+
+func TestSignNodeCSR(t *testing.T) {
+	storage, _ := etcdtest.NewStorage()
+	ca, err := LoadOrCreate(context.Background(), storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validCSR := newCSR(t, "node-1")
+	block, _ := pem.Decode(validCSR)
+	tampered := append([]byte(nil), block.Bytes...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	tests := []struct {
+		name    string
+		csr     []byte
+		invalid bool
+	}{
+		{name: "node ID as subject", csr: newCSR(t, "node-1")},
+		{name: "subject of another node", csr: newCSR(t, "node-2")},
+		{name: "not PEM", csr: []byte("not a csr"), invalid: true},
+		{name: "certificate instead of request", csr: ca.CertificatePEM(), invalid: true},
+		{name: "invalid signature", csr: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: tampered}), invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certPEM, cert, err := ca.SignNodeCSR(tt.csr, "node-1", time.Hour)
+			if tt.invalid {
+				if !errors.Is(err, ErrInvalidCSR) {
+					t.Fatalf("got %v, want ErrInvalidCSR", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(certPEM) == 0 {
+				t.Error("no PEM returned")
+			}
+			// The subject is always the node the certificate is issued for
+			if cert.Subject.CommonName != "node-1" {
+				t.Errorf("subject %q, want node-1", cert.Subject.CommonName)
+			}
+			if cert.NotAfter.After(time.Now().Add(time.Hour)) {
+				t.Errorf("valid until %s, longer than the validity", cert.NotAfter)
+			}
+			if _, err := cert.Verify(x509.VerifyOptions{
+				Roots:     ca.Pool(),
+				KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			}); err != nil {
+				t.Errorf("not a valid client certificate: %v", err)
+			}
+			if _, err := cert.Verify(x509.VerifyOptions{
+				Roots:     ca.Pool(),
+				KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			}); err == nil {
+				t.Error("node certificate is valid as a server certificate")
+			}
+		})
+	}
+}
+
+func TestSerialsDiffer(t *testing.T) {
+	storage, _ := etcdtest.NewStorage()
+	ca, err := LoadOrCreate(context.Background(), storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		_, cert, err := ca.SignNodeCSR(newCSR(t, "node-1"), "node-1", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[Serial(cert)] {
+			t.Fatalf("serial %s issued twice", Serial(cert))
+		}
+		seen[Serial(cert)] = true
+	}
+}
@@ -0,0 +1,87 @@
+// Package placement decides which nodes may run a deployment, based on its
+// selected clusters and placement constraints. Constraints are parsed by the
+// spec package.
+package placement
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/open-scheduler/centro/spec"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// Matches reports whether a node satisfies a constraint. A node without the
+// attribute only satisfies != and not in.
+func Matches(c *spec.Constraint, node *etcdstorage.NodeInfo) bool {
+	value, ok := nodeAttribute(node, c.Attribute)
+	contains := false
+	if ok {
+		for _, candidate := range c.Values {
+			if candidate == value {
+				contains = true
+				break
+			}
+		}
+	}
+
+	switch c.Operator {
+	case "==", "in":
+		return contains
+	default:
+		return !contains
+	}
+}
+
+func nodeAttribute(node *etcdstorage.NodeInfo, attribute string) (string, bool) {
+	switch attribute {
+	case "node.id":
+		return node.NodeID, true
+	case "node.cluster":
+		return node.ClusterName, true
+	}
+
+	key := strings.TrimPrefix(strings.TrimPrefix(attribute, "node.meta."), "node.label.")
+	// Labels from the join token or the approval win over what the agent reports
+	if value, ok := node.Labels[key]; ok {
+		return value, true
+	}
+	value, ok := node.Metadata[key]
+	return value, ok
+}
+
+// Check returns why a node may not run a deployment, or "" if it may
+func Check(node *etcdstorage.NodeInfo, deployment *pb.Deployment) string {
+	if deployment.TargetNodeId != "" && deployment.TargetNodeId != node.NodeID {
+		return fmt.Sprintf("Deployment must run on node %s", deployment.TargetNodeId)
+	}
+
+	if len(deployment.SelectedClusters) > 0 {
+		clusterMatches := false
+		for _, cluster := range deployment.SelectedClusters {
+			if cluster == node.ClusterName {
+				clusterMatches = true
+				break
+			}
+		}
+		if !clusterMatches {
+			return fmt.Sprintf("Cluster mismatch: deployment requires %v, node is in '%s'",
+				deployment.SelectedClusters, node.ClusterName)
+		}
+	}
+
+	if deployment.Placement != nil {
+		for _, text := range deployment.Placement.Constraints {
+			constraint, err := spec.ParseConstraint(text)
+			if err != nil {
+				return fmt.Sprintf("Invalid constraint %q: %v", text, err)
+			}
+			if !Matches(constraint, node) {
+				return fmt.Sprintf("Constraint not met: %s", text)
+			}
+		}
+	}
+
+	return ""
+}
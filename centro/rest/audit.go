@@ -0,0 +1,292 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/open-scheduler/centro/audit"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// defaultAuditLimit is how many entries GET /audit returns unless the
+// request says otherwise
+const defaultAuditLimit = 100
+
+// RequestIDHeader carries the request ID, clients may set it to correlate
+// their requests with the audit log
+const RequestIDHeader = "X-Request-ID"
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// auditedRoutes names the actions of routes that need no permission
+var auditedRoutes = map[string]string{
+	"/api/v1/auth/login":                "auth:login",
+	"/api/v1/auth/logout":               "auth:logout",
+	"/api/v1/users/{username}/password": "users:password",
+}
+
+// redactedRoutes name the request fields that hold secret values besides the
+// ones audit.Redact recognizes by name
+var redactedRoutes = map[string][]string{
+	"/api/v1/secrets":        secretValueFields,
+	"/api/v1/secrets/{name}": secretValueFields,
+}
+
+// unauditedRoutes change nothing worth recording and are called often: clients
+// refresh their tokens every few minutes and poll during the device flow
+var unauditedRoutes = map[string]bool{
+	"/api/v1/auth/refresh":           true,
+	"/api/v1/auth/oidc/device":       true,
+	"/api/v1/auth/oidc/device/token": true,
+}
+
+// EnableAuditFile writes the audit log to sink as well as to etcd
+func (s *APIServer) EnableAuditFile(sink *audit.FileSink) {
+	s.auditSink = sink
+}
+
+// statusRecorder remembers the status code a handler responded with
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// auditMiddleware gives every request an ID and records requests that change
+// something in the audit log. The entry is put in the request context, where
+// the authentication middleware and authorize fill in who made the request
+// and what it needed permission for.
+func (s *APIServer) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.New().String()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		template := ""
+		if route := mux.CurrentRoute(r); route != nil {
+			template, _ = route.GetPathTemplate()
+		}
+		if !isMutatingMethod(r.Method) || unauditedRoutes[template] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The handler reads the body after it was copied for the log
+		var body []byte
+		if r.Body != nil {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(r.Body, audit.MaxBodySize+1))
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		}
+
+		entry := &etcdstorage.AuditEntry{
+			ID:           uuid.New().String(),
+			Time:         time.Now(),
+			RequestID:    requestID,
+			Action:       auditedRoutes[template],
+			Method:       r.Method,
+			Path:         r.URL.Path,
+			ResourceID:   routeResourceID(mux.Vars(r)),
+			SourceIP:     sourceIP(r),
+			ForwardedFor: r.Header.Get("X-Forwarded-For"),
+		}
+		if entry.Action == "" {
+			entry.Action = r.Method + " " + template
+		} else {
+			entry.Resource, _ = splitPermission(entry.Action)
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), "audit", entry)))
+
+		entry.StatusCode = recorder.status
+		if entry.StatusCode == 0 {
+			entry.StatusCode = http.StatusOK
+		}
+		entry.Outcome = auditOutcome(entry.StatusCode)
+		// Requests without a valid token are not recorded, anyone could fill
+		// the log with them. Failed logins are.
+		if entry.StatusCode == http.StatusUnauthorized && entry.Actor == "" {
+			return
+		}
+		entry.Body = audit.Redact(body, redactedRoutes[template]...)
+
+		s.saveAuditEntry(entry)
+	})
+}
+
+func (s *APIServer) saveAuditEntry(entry *etcdstorage.AuditEntry) {
+	if err := s.storage.SaveAuditEntry(context.Background(), entry); err != nil {
+		log.Printf("[Centro REST] Failed to save audit entry of request %s: %v", entry.RequestID, err)
+	}
+	if s.auditSink != nil {
+		if err := s.auditSink.Write(entry); err != nil {
+			log.Printf("[Centro REST] Failed to write audit entry of request %s to file: %v", entry.RequestID, err)
+		}
+	}
+}
+
+// requestAuditEntry returns the audit entry of a request, or nil if the
+// request is not audited
+func requestAuditEntry(r *http.Request) *etcdstorage.AuditEntry {
+	entry, _ := r.Context().Value("audit").(*etcdstorage.AuditEntry)
+	return entry
+}
+
+// auditClaims records who made an audited request
+func auditClaims(r *http.Request, claims *Claims) {
+	if entry := requestAuditEntry(r); entry != nil {
+		entry.Actor = claims.Username
+		entry.Role = claims.Role
+		entry.TokenID = claims.TokenID
+	}
+}
+
+// auditPermission records the permission an audited request needs
+func auditPermission(r *http.Request, perm string) {
+	if entry := requestAuditEntry(r); entry != nil {
+		entry.Action = perm
+		entry.Resource, _ = splitPermission(perm)
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// routeResourceID returns the ID or name of the object a route refers to
+func routeResourceID(vars map[string]string) string {
+	for _, name := range []string{"id", "name", "username"} {
+		if value := vars[name]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func auditOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return etcdstorage.AuditOutcomeDenied
+	case status < 400:
+		return etcdstorage.AuditOutcomeSuccess
+	default:
+		return etcdstorage.AuditOutcomeFailure
+	}
+}
+
+// handleListAudit godoc
+// @Summary List the audit log
+// @Description List the recorded POST, PUT, PATCH and DELETE requests, oldest first. Secrets in request bodies are redacted. Requests without a valid token are not recorded, failed logins are.
+// @Tags Audit
+// @Produce json
+// @Security BearerAuth
+// @Param actor query string false "Only requests of this user or service account"
+// @Param action query string false "Only this action, a permission like deployments:update or auth:login"
+// @Param resource query string false "Only this resource, like deployments or nodes"
+// @Param resource_id query string false "Only requests for this deployment, node, user or other object"
+// @Param outcome query string false "success, denied or failure"
+// @Param request_id query string false "Only the request with this ID"
+// @Param since query string false "Only requests at or after this time (RFC3339)"
+// @Param until query string false "Only requests at or before this time (RFC3339)"
+// @Param limit query int false "Only return the most recent N entries, 100 if empty, 0 for all"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /audit [get]
+func (s *APIServer) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := etcdstorage.AuditFilter{
+		Actor:      query.Get("actor"),
+		Action:     query.Get("action"),
+		Resource:   query.Get("resource"),
+		ResourceID: query.Get("resource_id"),
+		Outcome:    strings.ToLower(query.Get("outcome")),
+		RequestID:  query.Get("request_id"),
+	}
+	switch filter.Outcome {
+	case "", etcdstorage.AuditOutcomeSuccess, etcdstorage.AuditOutcomeDenied, etcdstorage.AuditOutcomeFailure:
+	default:
+		respondWithError(w, http.StatusBadRequest, "invalid 'outcome' parameter, use success, denied or failure")
+		return
+	}
+
+	// since and until are read like the event filters
+	eventFilter, err := parseEventFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Since = eventFilter.Since
+	filter.Until = eventFilter.Until
+
+	limit := defaultAuditLimit
+	if raw := query.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			respondWithError(w, http.StatusBadRequest, "invalid 'limit' parameter")
+			return
+		}
+	}
+
+	entries, err := s.storage.GetAuditEntries(context.Background(), filter)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get audit entries: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve the audit log")
+		return
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
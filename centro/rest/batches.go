@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// batchProgress summarizes how many replicas of a running batch deployment completed
+func (s *APIServer) batchProgress(ctx context.Context, spec *etcdstorage.DeploymentSpec) (string, int) {
+	state, err := s.storage.GetBatchState(ctx, spec.DeploymentID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get batch state: %v", err)
+	}
+	if state == nil {
+		return "Waiting for the reconciler to start replicas", 0
+	}
+
+	completed := 0
+	for _, unitID := range state.Units {
+		if record, err := s.storage.GetDeploymentHistory(ctx, unitID); err == nil && record != nil && record.Status == "completed" {
+			completed++
+		}
+	}
+	return fmt.Sprintf("%d/%d replicas completed", completed, len(state.Units)), completed
+}
+
+// respondWithBatch writes the details of a batch deployment whose replicas are still running
+func (s *APIServer) respondWithBatch(ctx context.Context, w http.ResponseWriter, spec *etcdstorage.DeploymentSpec, events []*etcdstorage.DeploymentEvent) {
+	replicas, err := s.storage.GetServiceReplicas(ctx, spec.DeploymentID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get batch replicas: %v", err)
+	}
+
+	detail, completed := s.batchProgress(ctx, spec)
+	respondWithJSON(w, http.StatusOK, withAuthors(map[string]interface{}{
+		"deployment_id":      spec.DeploymentID,
+		"status":             "batch",
+		"detail":             detail,
+		"revision":           spec.Revision,
+		"updated_at":         spec.UpdatedAt,
+		"replicas":           replicas,
+		"desired_replicas":   spec.Deployment.Replicas,
+		"completed_replicas": completed,
+		"deployment":         spec.Deployment,
+		"events":             events,
+	}, spec))
+}
@@ -0,0 +1,379 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/open-scheduler/centro/configs"
+	"github.com/open-scheduler/centro/spec"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+var configNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,127}$`)
+
+// ConfigRequest creates or updates a config. Data is the content of the file,
+// a Go template for deployments that reference the config with template.
+type ConfigRequest struct {
+	// Name is only used when creating a config
+	Name        string  `json:"name,omitempty" example:"nginx.conf"`
+	Description string  `json:"description,omitempty" example:"Reverse proxy of the shop"`
+	Data        *string `json:"data,omitempty" example:"server { server_name {{ .Meta.domain }}; }"`
+}
+
+func configResponse(config *etcdstorage.Config, usedBy []string, withData bool) map[string]interface{} {
+	response := map[string]interface{}{
+		"name":        config.Name,
+		"description": config.Description,
+		"version":     config.Version,
+		"size":        len(config.Data),
+		"created_by":  config.CreatedBy,
+		"created_at":  config.CreatedAt,
+		"updated_by":  config.UpdatedBy,
+		"updated_at":  config.UpdatedAt,
+	}
+	if withData {
+		response["data"] = config.Data
+	}
+	if usedBy != nil {
+		response["used_by"] = usedBy
+	}
+	return response
+}
+
+// configUsers returns the deployments whose current spec references a
+// config, keyed by config name, and the configs that are referenced as
+// templates. Finished deployments are left out.
+func (s *APIServer) configUsers(ctx context.Context) (map[string][]string, map[string]bool, error) {
+	specs, err := s.storage.GetAllDeploymentSpecs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	history, err := s.storage.GetAllDeploymentHistory(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users := make(map[string][]string)
+	templates := make(map[string]bool)
+	for deploymentID, stored := range specs {
+		if _, finished := history[deploymentID]; finished || stored.Deployment == nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, ref := range stored.Deployment.Configs {
+			if ref.Template {
+				templates[ref.Config] = true
+			}
+			if !seen[ref.Config] {
+				seen[ref.Config] = true
+				users[ref.Config] = append(users[ref.Config], deploymentID)
+			}
+		}
+	}
+	for _, deploymentIDs := range users {
+		sort.Strings(deploymentIDs)
+	}
+	return users, templates, nil
+}
+
+// checkConfigReferences writes an error response and returns false unless
+// the configs referenced by the deployments exist and those referenced as
+// templates parse. Whether a template renders is only known on the node.
+func (s *APIServer) checkConfigReferences(w http.ResponseWriter, r *http.Request, deployments ...*pb.Deployment) bool {
+	var errs spec.Errors
+	stored := make(map[string]*etcdstorage.Config)
+	for i, deployment := range deployments {
+		for j, ref := range deployment.Configs {
+			config, checked := stored[ref.Config]
+			if !checked {
+				var err error
+				config, err = s.storage.GetConfig(r.Context(), ref.Config)
+				if err != nil {
+					log.Printf("[Centro REST] Failed to get config %s: %v", ref.Config, err)
+					respondWithError(w, http.StatusInternalServerError, "Failed to check configs")
+					return false
+				}
+				stored[ref.Config] = config
+			}
+
+			field := fmt.Sprintf("configs[%d].config", j)
+			if len(deployments) > 1 {
+				field = fmt.Sprintf("deployments[%d].%s", i, field)
+			}
+			if config == nil {
+				errs = append(errs, spec.FieldError{Field: field, Message: fmt.Sprintf("config %s does not exist", ref.Config)})
+				continue
+			}
+			if ref.Template {
+				if _, err := configs.Parse(config); err != nil {
+					errs = append(errs, spec.FieldError{Field: field, Message: fmt.Sprintf("config %s: %v", ref.Config, err)})
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return false
+	}
+	return true
+}
+
+// handleListConfigs godoc
+// @Summary List configs
+// @Description List the configs with their size and the deployments that use them, without their data
+// @Tags Configs
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Router /configs [get]
+func (s *APIServer) handleListConfigs(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	stored, err := s.storage.GetAllConfigs(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get configs: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get configs")
+		return
+	}
+	users, _, err := s.configUsers(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get config users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get configs")
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(stored))
+	for _, config := range stored {
+		list = append(list, configResponse(config, append([]string{}, users[config.Name]...), false))
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"configs": list,
+		"count":   len(list),
+	})
+}
+
+// handleGetConfig godoc
+// @Summary Get a config
+// @Description Get a config with its data and the deployments that use it
+// @Tags Configs
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Config name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /configs/{name} [get]
+func (s *APIServer) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	ctx := context.Background()
+	config, err := s.storage.GetConfig(ctx, name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get config %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get config")
+		return
+	}
+	if config == nil {
+		respondWithError(w, http.StatusNotFound, "Config not found")
+		return
+	}
+	users, _, err := s.configUsers(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get config users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get config")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, configResponse(config, append([]string{}, users[name]...), true))
+}
+
+// handleCreateConfig godoc
+// @Summary Create a config
+// @Description Store a file that deployments mount by referencing the config in their configs, optionally rendered as a Go template with the deployment metadata
+// @Tags Configs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param config body ConfigRequest true "Config"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /configs [post]
+func (s *APIServer) handleCreateConfig(w http.ResponseWriter, r *http.Request) {
+	var req ConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !configNamePattern.MatchString(req.Name) {
+		respondWithError(w, http.StatusBadRequest, "Config name must be at most 128 lowercase letters, digits, '.', '_' or '-' and start with a letter or digit")
+		return
+	}
+	if entry := requestAuditEntry(r); entry != nil {
+		entry.ResourceID = req.Name
+	}
+	if req.Data == nil {
+		respondWithError(w, http.StatusBadRequest, "A config needs data")
+		return
+	}
+	if len(*req.Data) > configs.MaxSize {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Config data is larger than %d bytes", configs.MaxSize))
+		return
+	}
+
+	now := time.Now()
+	author := requestAuthor(r)
+	config := &etcdstorage.Config{
+		Name:        req.Name,
+		Description: req.Description,
+		Data:        *req.Data,
+		Version:     1,
+		CreatedBy:   author,
+		CreatedAt:   now,
+		UpdatedBy:   author,
+		UpdatedAt:   now,
+	}
+
+	created, err := s.storage.CreateConfig(context.Background(), config)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to create config %s: %v", req.Name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create config")
+		return
+	}
+	if !created {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Config %s already exists", req.Name))
+		return
+	}
+
+	log.Printf("[Centro REST] Config %s created by %s", req.Name, author)
+	respondWithJSON(w, http.StatusCreated, configResponse(config, nil, false))
+}
+
+// handleUpdateConfig godoc
+// @Summary Update a config
+// @Description Replace the data of a config, its description, or both. Instances that are running keep the old file until they are replaced, e.g. by updating their deployment. Data of a config that deployments use as a template must parse.
+// @Tags Configs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Config name"
+// @Param config body ConfigRequest true "New data or description"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /configs/{name} [put]
+func (s *APIServer) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	var req ConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Name != "" && req.Name != name {
+		respondWithError(w, http.StatusBadRequest, "Configs cannot be renamed")
+		return
+	}
+	if req.Data == nil && req.Description == "" {
+		respondWithError(w, http.StatusBadRequest, "Set data or description")
+		return
+	}
+	if req.Data != nil && len(*req.Data) > configs.MaxSize {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Config data is larger than %d bytes", configs.MaxSize))
+		return
+	}
+
+	ctx := context.Background()
+	config, err := s.storage.GetConfig(ctx, name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get config %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update config")
+		return
+	}
+	if config == nil {
+		respondWithError(w, http.StatusNotFound, "Config not found")
+		return
+	}
+
+	if req.Description != "" {
+		config.Description = req.Description
+	}
+	if req.Data != nil {
+		config.Data = *req.Data
+		_, templates, err := s.configUsers(ctx)
+		if err != nil {
+			log.Printf("[Centro REST] Failed to get config users: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to update config")
+			return
+		}
+		if templates[name] {
+			if _, err := configs.Parse(config); err != nil {
+				respondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Config %s is used as a template: %v", name, err))
+				return
+			}
+		}
+	}
+	config.Version++
+	config.UpdatedBy = requestAuthor(r)
+	config.UpdatedAt = time.Now()
+	if err := s.storage.SaveConfig(ctx, config); err != nil {
+		log.Printf("[Centro REST] Failed to save config %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update config")
+		return
+	}
+
+	log.Printf("[Centro REST] Config %s updated to version %d by %s", name, config.Version, config.UpdatedBy)
+	respondWithJSON(w, http.StatusOK, configResponse(config, nil, false))
+}
+
+// handleDeleteConfig godoc
+// @Summary Delete a config
+// @Description A config that is referenced by a deployment that has not finished cannot be deleted
+// @Tags Configs
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Config name"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /configs/{name} [delete]
+func (s *APIServer) handleDeleteConfig(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	ctx := context.Background()
+	users, _, err := s.configUsers(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get config users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete config")
+		return
+	}
+	if len(users[name]) > 0 {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Config %s is used by deployments %v", name, users[name]))
+		return
+	}
+
+	deleted, err := s.storage.DeleteConfig(ctx, name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to delete config %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete config")
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Config not found")
+		return
+	}
+
+	log.Printf("[Centro REST] Config %s deleted by %s", name, requestAuthor(r))
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": fmt.Sprintf("Config %s deleted", name),
+	})
+}
@@ -0,0 +1,36 @@
+package rest
+
+import (
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// blockedEntry describes a deployment that waits for its upstream deployments
+func blockedEntry(spec *etcdstorage.DeploymentSpec, state *etcdstorage.DependencyState) map[string]interface{} {
+	detail := "Waiting for the reconciler to check dependencies"
+	if state != nil && state.Message != "" {
+		detail = state.Message
+	}
+	return withAuthors(map[string]interface{}{
+		"deployment_id": spec.DeploymentID,
+		"status":        etcdstorage.DependencyStatusBlocked,
+		"detail":        detail,
+		"revision":      spec.Revision,
+		"updated_at":    spec.UpdatedAt,
+		"depends_on":    spec.Deployment.DependsOn,
+		"deployment":    spec.Deployment,
+	}, spec)
+}
+
+// sameDependencies reports whether two specs wait for the same upstream deployments
+func sameDependencies(a, b []*pb.Dependency) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].DeploymentId != b[i].DeploymentId || a[i].Condition != b[i].Condition {
+			return false
+		}
+	}
+	return true
+}
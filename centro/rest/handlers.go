@@ -3,13 +3,21 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/open-scheduler/centro/audit"
+	"github.com/open-scheduler/centro/oidc"
+	"github.com/open-scheduler/centro/pki"
+	"github.com/open-scheduler/centro/secrets"
+	"github.com/open-scheduler/centro/spec"
 	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
 	pb "github.com/open-scheduler/proto"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -18,6 +26,16 @@ import (
 type APIServer struct {
 	storage *etcdstorage.Storage
 	router  *mux.Router
+	// oidc is nil unless single sign-on is configured
+	oidc *oidc.Provider
+	// ssoSessionTTL is how long an SSO login can be refreshed
+	ssoSessionTTL time.Duration
+	// ca is nil when gRPC is served without TLS
+	ca *pki.CA
+	// auditSink is nil unless the audit log is also written to a file
+	auditSink *audit.FileSink
+	// keyring is nil unless secrets are enabled
+	keyring *secrets.Keyring
 }
 
 func NewAPIServer(storage *etcdstorage.Storage) *APIServer {
@@ -37,26 +55,95 @@ func (s *APIServer) setupRoutes() {
 	api := s.router.PathPrefix("/api/v1").Subrouter()
 
 	api.HandleFunc("/auth/login", s.handleLogin).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/refresh", s.handleRefresh).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/oidc/config", s.handleOIDCConfig).Methods("GET")
+	api.HandleFunc("/auth/oidc/login", s.handleOIDCLogin).Methods("GET")
+	api.HandleFunc("/auth/oidc/callback", s.handleOIDCCallback).Methods("GET")
+	api.HandleFunc("/auth/oidc/device", s.handleOIDCDevice).Methods("POST", "OPTIONS")
+	api.HandleFunc("/auth/oidc/device/token", s.handleOIDCDeviceToken).Methods("POST", "OPTIONS")
 
 	protected := api.PathPrefix("").Subrouter()
-	protected.Use(JWTAuthMiddleware)
-
-	protected.HandleFunc("/deployments", s.handleListDeployments).Methods("GET")
-	protected.HandleFunc("/deployments", s.handleSubmitDeployment).Methods("POST")
-	protected.HandleFunc("/deployments/{id}", s.handleGetDeployment).Methods("GET")
-	protected.HandleFunc("/deployments/{id}/status", s.handleGetDeploymentStatus).Methods("GET")
-	protected.HandleFunc("/deployments/{id}/events", s.handleGetDeploymentEvents).Methods("GET")
-	protected.HandleFunc("/instances", s.handleListInstances).Methods("GET")
-	protected.HandleFunc("/instances/{id}", s.handleGetInstanceData).Methods("GET")
-
-	protected.HandleFunc("/nodes", s.handleListNodes).Methods("GET")
-	protected.HandleFunc("/nodes/{id}", s.handleGetNode).Methods("GET")
-	protected.HandleFunc("/nodes/{id}/health", s.handleNodeHealth).Methods("GET")
-
-	protected.HandleFunc("/stats", s.handleStats).Methods("GET")
+	protected.Use(JWTAuthMiddleware(s.validateAPIToken))
+
+	// Every route needs a permission of the role of the user, see rbac.go
+	protected.HandleFunc("/auth/can-i", s.handleCanI).Methods("GET")
+	protected.HandleFunc("/auth/logout", s.handleLogout).Methods("POST")
+
+	protected.HandleFunc("/deployments", s.authorize("deployments:list", s.handleListDeployments)).Methods("GET")
+	protected.HandleFunc("/deployments", s.authorize("deployments:create", s.handleSubmitDeployment)).Methods("POST")
+	protected.HandleFunc("/deployments/{id}", s.authorize("deployments:get", s.handleGetDeployment)).Methods("GET")
+	protected.HandleFunc("/deployments/{id}", s.authorize("deployments:update", s.handleUpdateDeployment)).Methods("PUT")
+	protected.HandleFunc("/deployments/{id}", s.authorize("deployments:update", s.handlePatchDeployment)).Methods("PATCH")
+	protected.HandleFunc("/deployments/{id}/revisions", s.authorize("deployments:get", s.handleListDeploymentRevisions)).Methods("GET")
+	protected.HandleFunc("/deployments/{id}/revisions/{revision}", s.authorize("deployments:get", s.handleGetDeploymentRevision)).Methods("GET")
+	protected.HandleFunc("/deployments/{id}/rollback", s.authorize("deployments:update", s.handleRollbackDeployment)).Methods("POST")
+	protected.HandleFunc("/deployments/{id}/promote", s.authorize("deployments:update", s.handlePromoteDeployment)).Methods("POST")
+	protected.HandleFunc("/deployments/{id}/abort", s.authorize("deployments:update", s.handleAbortDeployment)).Methods("POST")
+	protected.HandleFunc("/deployments/{id}/status", s.authorize("deployments:get", s.handleGetDeploymentStatus)).Methods("GET")
+	protected.HandleFunc("/deployments/{id}/events", s.authorize("deployments:get", s.handleGetDeploymentEvents)).Methods("GET")
+	protected.HandleFunc("/workflows", s.authorize("deployments:create", s.handleSubmitWorkflow)).Methods("POST")
+	protected.HandleFunc("/instances", s.authorize("instances:list", s.handleListInstances)).Methods("GET")
+	protected.HandleFunc("/instances/{id}", s.authorize("instances:get", s.handleGetInstanceData)).Methods("GET")
+
+	protected.HandleFunc("/nodes", s.authorize("nodes:list", s.handleListNodes)).Methods("GET")
+	protected.HandleFunc("/nodes/{id}", s.authorize("nodes:get", s.handleGetNode)).Methods("GET")
+	protected.HandleFunc("/nodes/{id}/health", s.authorize("nodes:get", s.handleNodeHealth)).Methods("GET")
+	protected.HandleFunc("/nodes/{id}/decommission", s.authorize("nodes:update", s.handleDecommissionNode)).Methods("POST")
+	protected.HandleFunc("/nodes/{id}/recommission", s.authorize("nodes:update", s.handleRecommissionNode)).Methods("POST")
+	protected.HandleFunc("/nodes/{id}/approve", s.authorize("nodes:update", s.handleApproveNode)).Methods("POST")
+	protected.HandleFunc("/nodes/{id}/reject", s.authorize("nodes:update", s.handleRejectNode)).Methods("POST")
+	protected.HandleFunc("/nodes/{id}/credentials", s.authorize("nodes:get", s.handleListNodeCredentials)).Methods("GET")
+	protected.HandleFunc("/nodes/{id}/credentials", s.authorize("nodes:update", s.handleCreateNodeCredential)).Methods("POST")
+	protected.HandleFunc("/nodes/{id}/credentials", s.authorize("nodes:update", s.handleRevokeNodeCredentials)).Methods("DELETE")
+	protected.HandleFunc("/nodes/{id}/credentials/{credential_id}", s.authorize("nodes:update", s.handleRevokeNodeCredential)).Methods("DELETE")
+
+	protected.HandleFunc("/events", s.authorize("events:list", s.handleListEvents)).Methods("GET")
+
+	protected.HandleFunc("/users", s.authorize("users:list", s.handleListUsers)).Methods("GET")
+	protected.HandleFunc("/users", s.authorize("users:create", s.handleCreateUser)).Methods("POST")
+	// Users change their own password without users:update
+	protected.HandleFunc("/users/{username}/password", s.handleSetUserPassword).Methods("PUT")
+	protected.HandleFunc("/users/{username}/role", s.authorize("users:update", s.handleSetUserRole)).Methods("PUT")
+	protected.HandleFunc("/users/{username}", s.authorize("users:delete", s.handleDeleteUser)).Methods("DELETE")
+
+	protected.HandleFunc("/roles", s.authorize("roles:list", s.handleListRoles)).Methods("GET")
+	protected.HandleFunc("/roles/{name}", s.authorize("roles:get", s.handleGetRole)).Methods("GET")
+	protected.HandleFunc("/roles/{name}", s.authorize("roles:update", s.handleSaveRole)).Methods("PUT")
+	protected.HandleFunc("/roles/{name}", s.authorize("roles:delete", s.handleDeleteRole)).Methods("DELETE")
+
+	protected.HandleFunc("/serviceaccounts", s.authorize("serviceaccounts:list", s.handleListServiceAccounts)).Methods("GET")
+	protected.HandleFunc("/serviceaccounts", s.authorize("serviceaccounts:create", s.handleCreateServiceAccount)).Methods("POST")
+	protected.HandleFunc("/serviceaccounts/{name}", s.authorize("serviceaccounts:delete", s.handleDeleteServiceAccount)).Methods("DELETE")
+	protected.HandleFunc("/tokens", s.authorize("tokens:list", s.handleListTokens)).Methods("GET")
+	protected.HandleFunc("/tokens", s.authorize("tokens:create", s.handleCreateToken)).Methods("POST")
+	protected.HandleFunc("/tokens/{id}", s.authorize("tokens:delete", s.handleRevokeToken)).Methods("DELETE")
+
+	protected.HandleFunc("/join-tokens", s.authorize("jointokens:list", s.handleListJoinTokens)).Methods("GET")
+	protected.HandleFunc("/join-tokens", s.authorize("jointokens:create", s.handleCreateJoinToken)).Methods("POST")
+	protected.HandleFunc("/join-tokens/{id}", s.authorize("jointokens:delete", s.handleDeleteJoinToken)).Methods("DELETE")
+	protected.HandleFunc("/pki/ca", s.authorize("nodes:get", s.handleGetCA)).Methods("GET")
+
+	protected.HandleFunc("/stats", s.authorize("stats:get", s.handleStats)).Methods("GET")
+
+	// The watch streams deployments, instances, nodes and events, each checked against its own list permission
+	protected.HandleFunc("/watch", s.handleWatch).Methods("GET")
+
+	protected.HandleFunc("/audit", s.authorize("audit:list", s.handleListAudit)).Methods("GET")
+
+	protected.HandleFunc("/secrets", s.authorize("secrets:list", s.handleListSecrets)).Methods("GET")
+	protected.HandleFunc("/secrets", s.authorize("secrets:create", s.handleCreateSecret)).Methods("POST")
+	protected.HandleFunc("/secrets/{name}", s.authorize("secrets:get", s.handleGetSecret)).Methods("GET")
+	protected.HandleFunc("/secrets/{name}", s.authorize("secrets:update", s.handleUpdateSecret)).Methods("PUT")
+	protected.HandleFunc("/secrets/{name}", s.authorize("secrets:delete", s.handleDeleteSecret)).Methods("DELETE")
+	protected.HandleFunc("/configs", s.authorize("configs:list", s.handleListConfigs)).Methods("GET")
+	protected.HandleFunc("/configs", s.authorize("configs:create", s.handleCreateConfig)).Methods("POST")
+	protected.HandleFunc("/configs/{name}", s.authorize("configs:get", s.handleGetConfig)).Methods("GET")
+	protected.HandleFunc("/configs/{name}", s.authorize("configs:update", s.handleUpdateConfig)).Methods("PUT")
+	protected.HandleFunc("/configs/{name}", s.authorize("configs:delete", s.handleDeleteConfig)).Methods("DELETE")
 
 	s.router.Use(LoggingMiddleware)
 	s.router.Use(CORSMiddleware)
+	s.router.Use(s.auditMiddleware)
 }
 
 func (s *APIServer) GetRouter() *mux.Router {
@@ -72,6 +159,9 @@ type LoginResponse struct {
 	Token     string `json:"token"`
 	ExpiresIn int    `json:"expires_in"`
 	Username  string `json:"username"`
+	// RefreshToken renews the token at /auth/refresh
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	RefreshExpiresIn int    `json:"refresh_expires_in,omitempty"`
 }
 
 // handleLogin godoc
@@ -96,19 +186,27 @@ func (s *APIServer) handleLogin(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "Username and password are required")
 		return
 	}
+	// Failed logins are recorded with the username that was tried
+	if entry := requestAuditEntry(r); entry != nil {
+		entry.Actor = req.Username
+	}
 
-	if req.Username == "admin" && req.Password == "admin123" {
-		token, err := GenerateToken(req.Username, "admin")
+	user, err := s.storage.GetUser(r.Context(), req.Username)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get user %s: %v", req.Username, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
+
+	if user != nil && checkPassword(user, req.Password) {
+		response, err := s.issueTokens(r.Context(), user.Username, user.Role, false, nil)
 		if err != nil {
+			log.Printf("[Centro REST] Failed to log in %s: %v", user.Username, err)
 			respondWithError(w, http.StatusInternalServerError, "Failed to generate token")
 			return
 		}
 
-		respondWithJSON(w, http.StatusOK, LoginResponse{
-			Token:     token,
-			ExpiresIn: 86400,
-			Username:  req.Username,
-		})
+		respondWithJSON(w, http.StatusOK, response)
 		return
 	}
 
@@ -122,16 +220,27 @@ func (s *APIServer) handleLogin(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param status query string false "Filter by status (queued, pending, completed, failed)"
+// @Param status query string false "Filter by status (queued, active, blocked, service, system, batch, periodic, completed, failed)"
+// @Param name query string false "Only deployments with this deployment_name"
 // @Success 200 {object} map[string]interface{}
 // @Failure 500 {object} map[string]string
 // @Router /deployments [get]
 func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 	statusFilter := r.URL.Query().Get("status")
+	nameFilter := r.URL.Query().Get("name")
+	matchesName := func(deployment *pb.Deployment) bool {
+		return nameFilter == "" || (deployment != nil && deployment.DeploymentName == nameFilter)
+	}
 
 	response := make(map[string]interface{})
 
+	// Specs record who created and last updated deployments
+	specs, specsErr := s.storage.GetAllDeploymentSpecs(ctx)
+	if specsErr != nil {
+		log.Printf("[Centro REST] Failed to get deployment specs: %v", specsErr)
+	}
+
 	// Queued deployments - waiting in queue to be picked up
 	if statusFilter == "" || statusFilter == "queued" {
 		queueLength, err := s.storage.GetQueueLength(ctx)
@@ -150,7 +259,10 @@ func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request
 			// Format queued deployments to match expected structure (same format as active deployments)
 			formattedQueue := make([]map[string]interface{}, 0, len(queueDeployments))
 			for _, deployment := range queueDeployments {
-				formattedQueue = append(formattedQueue, map[string]interface{}{
+				if !matchesName(deployment) {
+					continue
+				}
+				formattedQueue = append(formattedQueue, withAuthors(map[string]interface{}{
 					"deployment_id": deployment.DeploymentId,
 					"node_id":       "",
 					"status":        "queued",
@@ -158,7 +270,7 @@ func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request
 					"updated_at":    nil,
 					"claimed_at":    nil,
 					"deployment":    deployment,
-				})
+				}, specs[deployment.DeploymentId]))
 			}
 			response["queued_deployments"] = formattedQueue
 		}
@@ -172,7 +284,10 @@ func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request
 		} else {
 			deployments := make([]map[string]interface{}, 0, len(activeDeployments))
 			for deploymentID, status := range activeDeployments {
-				deployments = append(deployments, map[string]interface{}{
+				if !matchesName(status.Deployment) {
+					continue
+				}
+				deployments = append(deployments, withAuthors(map[string]interface{}{
 					"deployment_id":     deploymentID,
 					"node_id":    status.NodeID,
 					"status":     status.Status,
@@ -180,12 +295,127 @@ func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request
 					"updated_at": status.UpdatedAt,
 					"claimed_at": status.ClaimedAt,
 					"deployment":        status.Deployment,
-				})
+				}, specs[deploymentID]))
 			}
 			response["active_deployments"] = deployments
 		}
 	}
 
+	// Services and system deployments - their replicas are listed as separate deployments
+	if statusFilter == "" || statusFilter == "service" || statusFilter == "system" {
+		if specsErr == nil {
+			services := make([]map[string]interface{}, 0)
+			systems := make([]map[string]interface{}, 0)
+			for deploymentID, stored := range specs {
+				if !matchesName(stored.Deployment) {
+					continue
+				}
+				if !etcdstorage.IsManagedService(stored.Deployment) && !etcdstorage.IsManagedSystem(stored.Deployment) {
+					continue
+				}
+				detail := ""
+				if state, err := s.storage.GetServiceState(ctx, deploymentID); err == nil && state != nil {
+					detail = state.Message
+				}
+				entry := map[string]interface{}{
+					"deployment_id": deploymentID,
+					"status":        "service",
+					"detail":        detail,
+					"revision":      stored.Revision,
+					"updated_at":    stored.UpdatedAt,
+					"deployment":    stored.Deployment,
+				}
+				withAuthors(entry, stored)
+				if etcdstorage.IsManagedSystem(stored.Deployment) {
+					entry["status"] = "system"
+					systems = append(systems, entry)
+				} else {
+					services = append(services, entry)
+				}
+			}
+			if statusFilter != "system" {
+				response["services"] = services
+				response["service_count"] = len(services)
+			}
+			if statusFilter != "service" {
+				response["system_deployments"] = systems
+				response["system_count"] = len(systems)
+			}
+		}
+	}
+
+	// Batches - multi-replica batch deployments that have not finished yet
+	if statusFilter == "" || statusFilter == "batch" {
+		if specsErr == nil {
+			batches := make([]map[string]interface{}, 0)
+			for deploymentID, stored := range specs {
+				if !etcdstorage.IsManagedBatch(stored.Deployment) || !matchesName(stored.Deployment) {
+					continue
+				}
+				if finished, err := s.storage.GetDeploymentHistory(ctx, deploymentID); err != nil || finished != nil {
+					continue
+				}
+				detail, _ := s.batchProgress(ctx, stored)
+				batches = append(batches, withAuthors(map[string]interface{}{
+					"deployment_id": deploymentID,
+					"status":        "batch",
+					"detail":        detail,
+					"revision":      stored.Revision,
+					"updated_at":    stored.UpdatedAt,
+					"deployment":    stored.Deployment,
+				}, stored))
+			}
+			response["batches"] = batches
+			response["batch_count"] = len(batches)
+		}
+	}
+
+	// Blocked deployments - waiting for their upstream deployments
+	if statusFilter == "" || statusFilter == "blocked" {
+		if specsErr == nil {
+			blocked := make([]map[string]interface{}, 0)
+			for deploymentID, stored := range specs {
+				if !etcdstorage.HasDependencies(stored.Deployment) || !matchesName(stored.Deployment) {
+					continue
+				}
+				state, err := s.storage.GetDependencyState(ctx, deploymentID)
+				if err != nil || (state != nil && state.Status != etcdstorage.DependencyStatusBlocked) {
+					continue
+				}
+				blocked = append(blocked, blockedEntry(stored, state))
+			}
+			response["blocked_deployments"] = blocked
+			response["blocked_count"] = len(blocked)
+		}
+	}
+
+	// Periodic deployments - their launches are listed as separate deployments
+	if statusFilter == "" || statusFilter == "periodic" {
+		if specsErr == nil {
+			periodics := make([]map[string]interface{}, 0)
+			for deploymentID, stored := range specs {
+				if !etcdstorage.IsManagedPeriodic(stored.Deployment) || !matchesName(stored.Deployment) {
+					continue
+				}
+				entry := map[string]interface{}{
+					"deployment_id": deploymentID,
+					"status":        "periodic",
+					"detail":        "Cron " + stored.Deployment.Periodic.Cron,
+					"revision":      stored.Revision,
+					"updated_at":    stored.UpdatedAt,
+					"deployment":    stored.Deployment,
+				}
+				withAuthors(entry, stored)
+				if state, err := s.storage.GetPeriodicState(ctx, deploymentID); err == nil && state != nil {
+					entry["next_launch"] = state.NextLaunch
+				}
+				periodics = append(periodics, entry)
+			}
+			response["periodic_deployments"] = periodics
+			response["periodic_count"] = len(periodics)
+		}
+	}
+
 	// Get all history to filter by status
 	allHistory, err := s.storage.GetAllDeploymentHistory(ctx)
 	if err != nil {
@@ -195,8 +425,8 @@ func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request
 		if statusFilter == "" || statusFilter == "completed" {
 			completedDeployments := make([]map[string]interface{}, 0)
 			for deploymentID, status := range allHistory {
-				if status.Status == "completed" {
-					completedDeployments = append(completedDeployments, map[string]interface{}{
+				if status.Status == "completed" && matchesName(status.Deployment) {
+					completedDeployments = append(completedDeployments, withAuthors(map[string]interface{}{
 						"deployment_id":     deploymentID,
 						"node_id":    status.NodeID,
 						"status":     status.Status,
@@ -204,7 +434,7 @@ func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request
 						"updated_at": status.UpdatedAt,
 						"claimed_at": status.ClaimedAt,
 						"deployment":        status.Deployment,
-					})
+					}, specs[deploymentID]))
 				}
 			}
 			response["completed_deployments"] = completedDeployments
@@ -215,16 +445,20 @@ func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request
 		if statusFilter == "" || statusFilter == "failed" {
 			failedDeployments := make([]map[string]interface{}, 0)
 			for deploymentID, status := range allHistory {
-				if status.Status == "failed" {
-					failedDeployments = append(failedDeployments, map[string]interface{}{
+				if (status.Status == "failed" || status.Status == etcdstorage.DependencyStatusUpstreamFailed) && matchesName(status.Deployment) {
+					listedStatus := "failed_permanent"
+					if status.Status == etcdstorage.DependencyStatusUpstreamFailed {
+						listedStatus = status.Status
+					}
+					failedDeployments = append(failedDeployments, withAuthors(map[string]interface{}{
 						"deployment_id":     deploymentID,
 						"node_id":    status.NodeID,
-						"status":     "failed_permanent",
+						"status":     listedStatus,
 						"detail":     status.Detail,
 						"updated_at": status.UpdatedAt,
 						"claimed_at": status.ClaimedAt,
 						"deployment":        status.Deployment,
-					})
+					}, specs[deploymentID]))
 				}
 			}
 
@@ -234,7 +468,10 @@ func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request
 				log.Printf("[Centro REST] Failed to get failed queue deployments: %v", err)
 			} else {
 				for _, deployment := range failedQueueDeployments {
-					failedDeployments = append(failedDeployments, map[string]interface{}{
+					if !matchesName(deployment) {
+						continue
+					}
+					failedDeployments = append(failedDeployments, withAuthors(map[string]interface{}{
 						"deployment_id":     deployment.DeploymentId,
 						"node_id":    "",
 						"status":     "failed_retrying",
@@ -242,7 +479,7 @@ func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request
 						"updated_at": nil,
 						"claimed_at": nil,
 						"deployment":        deployment,
-					})
+					}, specs[deployment.DeploymentId]))
 				}
 			}
 
@@ -254,289 +491,107 @@ func (s *APIServer) handleListDeployments(w http.ResponseWriter, r *http.Request
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-type SubmitDeploymentRequest struct {
-	DeploymentId            string                `json:"deployment_id" example:"123"`
-	DeploymentName          string                `json:"deployment_name" example:"web-server-deployment"`
-	DeploymentType          string                `json:"deployment_type" example:"service"`
-	SelectedClusters []string              `json:"selected_clusters" example:"dc1,dc2"`
-	Meta             map[string]string     `json:"meta"`
-	Driver           string                `json:"driver" example:"podman"`
-	WorkloadType     string                `json:"workload_type" example:"container"`
-	Command          string                `json:"command" example:"echo 'Hello World'"`
-	CommandArray     []string              `json:"command_array,omitempty" example:"nginx,-g,daemon off;"`
-	InstanceConfig   *InstanceSpecRequest  `json:"instance_config,omitempty"`
-	Resources        *ResourcesRequest     `json:"resources,omitempty"`
-	Volumes          []VolumeRequest       `json:"volumes,omitempty"`
-	Replicas         *int32                `json:"replicas,omitempty" example:"2"`
-	Placement        *PlacementRequest     `json:"placement,omitempty"`
-	WorkingDir       string                `json:"working_dir,omitempty" example:"/usr/share/nginx/html"`
-	Ports            []PortMappingRequest  `json:"ports,omitempty"`
-	Security         *SecurityRequest      `json:"security,omitempty"`
-	HealthCheck      *HealthCheckRequest   `json:"health_check,omitempty"`
-	RestartPolicy    *RestartPolicyRequest `json:"restart_policy,omitempty"`
-	Networks         []string              `json:"networks,omitempty" example:"backend-net"`
-	InstanceType     string                `json:"instance_type,omitempty" example:"virtual-machine"`
-}
-
-type ResourcesRequest struct {
-	MemoryMB        int64   `json:"memory_mb" example:"512"`
-	MemoryReserveMB int64   `json:"memory_reserve_mb,omitempty" example:"256"`
-	CPU             float32 `json:"cpu" example:"1.0"`
-	CPUReserve      float32 `json:"cpu_reserve,omitempty" example:"0.5"`
-}
-
-type VolumeRequest struct {
-	HostPath     string `json:"host_path" example:"/data/app"`
-	InstancePath string `json:"instance_path" example:"/usr/share/nginx/html"`
-	ReadOnly     bool   `json:"read_only,omitempty" example:"false"`
-	Type         string `json:"type,omitempty" example:"bind"`
-}
-
-type InstanceSpecRequest struct {
-	Image       string              `json:"image" example:"docker.io/library/alpine:latest"`
-	Command     []string            `json:"command,omitempty" example:""`
-	Args        []string            `json:"args,omitempty" example:""`
-	Options     map[string]string   `json:"options,omitempty"`
-	ImageSource *ImageSourceRequest `json:"image_source,omitempty"`
-	UserData    string              `json:"user_data,omitempty"`
-	Devices     []DeviceRequest     `json:"devices,omitempty"`
-}
-
-type PlacementRequest struct {
-	Constraints []string `json:"constraints,omitempty" example:"node.driver in [podman, containerd]"`
-	Strategy    string   `json:"strategy,omitempty" example:"spread"`
-}
+// idempotencyKeyNamespace derives deployment IDs from Idempotency-Key headers
+var idempotencyKeyNamespace = uuid.MustParse("3b0c9d4e-5f27-4a8e-9c61-2d7f80a1b5e3")
 
-type PortMappingRequest struct {
-	HostPort      int32  `json:"host_port" example:"8080"`
-	ContainerPort int32  `json:"container_port" example:"80"`
-	Protocol      string `json:"protocol" example:"tcp"`
-}
-
-type SecurityRequest struct {
-	Privileged             bool     `json:"privileged,omitempty" example:"false"`
-	CapabilitiesAdd        []string `json:"capabilities_add,omitempty" example:"NET_BIND_SERVICE"`
-	CapabilitiesDrop       []string `json:"capabilities_drop,omitempty" example:"ALL"`
-	ReadOnlyRootFilesystem bool     `json:"read_only_root_filesystem,omitempty" example:"true"`
-}
-
-type HealthCheckRequest struct {
-	Test        []string `json:"test,omitempty" example:"CMD-SHELL,curl -f http://localhost/ || exit 1"`
-	Interval    string   `json:"interval,omitempty" example:"30s"`
-	Timeout     string   `json:"timeout,omitempty" example:"5s"`
-	Retries     int32    `json:"retries,omitempty" example:"3"`
-	StartPeriod string   `json:"start_period,omitempty" example:"5s"`
-}
-
-type RestartPolicyRequest struct {
-	Condition   string `json:"condition" example:"on-failure"`
-	MaxAttempts int32  `json:"max_attempts,omitempty" example:"3"`
-}
-
-type ImageSourceRequest struct {
-	Alias  string `json:"alias,omitempty" example:"ubuntu/22.04"`
-	Server string `json:"server,omitempty" example:"images.linuxcontainers.org"`
-	Mode   string `json:"mode,omitempty" example:"pull"`
-}
-
-type DeviceRequest struct {
-	Name       string            `json:"name" example:"eth0"`
-	Type       string            `json:"type" example:"nic"`
-	Properties map[string]string `json:"properties,omitempty"`
+// idempotencyID derives an ID from an Idempotency-Key header. The key is
+// scoped to the user that sent it, so that users picking the same key do not
+// end up with each other's deployments.
+func idempotencyID(r *http.Request, key string) string {
+	return uuid.NewSHA1(idempotencyKeyNamespace, []byte(requestAuthor(r)+"/"+key)).String()
 }
 
 // handleSubmitDeployment godoc
 // @Summary Submit a new deployment
-// @Description Create and submit a new deployment to the scheduler
+// @Description Create and submit a new deployment to the scheduler. Submissions are idempotent: the deployment_id of the request, or an ID derived from the Idempotency-Key header, is used instead of a random one. Resubmitting the same spec under an existing ID returns the existing deployment with 200, a different spec returns 409.
 // @Tags Deployments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param deployment body SubmitDeploymentRequest true "Deployment details"
+// @Param deployment body spec.SubmitDeploymentRequest true "Deployment details"
+// @Param Idempotency-Key header string false "Key identifying this submission across retries"
+// @Success 200 {object} map[string]interface{}
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
 // @Failure 500 {object} map[string]string
 // @Router /deployments [post]
 func (s *APIServer) handleSubmitDeployment(w http.ResponseWriter, r *http.Request) {
-	var req SubmitDeploymentRequest
+	var req spec.SubmitDeploymentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if req.DeploymentName == "" {
-		respondWithError(w, http.StatusBadRequest, "Deployment name is required")
-		return
-	}
-	deploymentID := uuid.New().String()
-
-	deployment := &pb.Deployment{
-		DeploymentId:            deploymentID,
-		DeploymentName:          req.DeploymentName,
-		DeploymentType:          req.DeploymentType,
-		SelectedClusters: req.SelectedClusters,
-		DriverType:       req.Driver,
-		WorkloadType:     req.WorkloadType,
-		Command:          req.Command,
-		RetryCount:       0,
-		MaxRetries:       3, // Default: retry up to 3 times
-		LastRetryTime:    0,
-	}
-
-	// Support command_array if provided
-	if len(req.CommandArray) > 0 {
-		deployment.CommandArray = req.CommandArray
-	}
-
-	// Replicas
-	if req.Replicas != nil {
-		deployment.Replicas = *req.Replicas
-	} else {
-		deployment.Replicas = 1 // Default to 1 replica
+	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	deploymentID := req.DeploymentId
+	if deploymentID == "" && idempotencyKey != "" {
+		deploymentID = idempotencyID(r, idempotencyKey)
 	}
-
-	// Placement
-	if req.Placement != nil {
-		deployment.Placement = &pb.Placement{
-			Constraints: req.Placement.Constraints,
-			Strategy:    req.Placement.Strategy,
-		}
+	if deploymentID == "" {
+		deploymentID = uuid.New().String()
 	}
+	deployment := req.ToDeployment(deploymentID)
 
-	// Working directory
-	if req.WorkingDir != "" {
-		deployment.WorkingDir = req.WorkingDir
+	errs := spec.ValidateDeployment(deployment)
+	if req.DeploymentId != "" {
+		errs = append(errs, spec.ValidateClientID(req.DeploymentId)...)
 	}
-
-	// Ports
-	if len(req.Ports) > 0 {
-		deployment.Ports = make([]*pb.PortMapping, 0, len(req.Ports))
-		for _, p := range req.Ports {
-			deployment.Ports = append(deployment.Ports, &pb.PortMapping{
-				HostPort:      p.HostPort,
-				ContainerPort: p.ContainerPort,
-				Protocol:      p.Protocol,
-			})
-		}
-	}
-
-	// Security settings
-	if req.Security != nil {
-		deployment.Security = &pb.SecuritySettings{
-			Privileged:             req.Security.Privileged,
-			CapabilitiesAdd:        req.Security.CapabilitiesAdd,
-			CapabilitiesDrop:       req.Security.CapabilitiesDrop,
-			ReadOnlyRootFilesystem: req.Security.ReadOnlyRootFilesystem,
-		}
-	}
-
-	// Health check
-	if req.HealthCheck != nil {
-		deployment.HealthCheck = &pb.HealthCheck{
-			Test:        req.HealthCheck.Test,
-			Interval:    req.HealthCheck.Interval,
-			Timeout:     req.HealthCheck.Timeout,
-			Retries:     req.HealthCheck.Retries,
-			StartPeriod: req.HealthCheck.StartPeriod,
-		}
+	if errs != nil {
+		respondWithValidationErrors(w, errs)
+		return
 	}
-
-	// Restart policy
-	if req.RestartPolicy != nil {
-		deployment.RestartPolicy = &pb.RestartPolicy{
-			Condition:   req.RestartPolicy.Condition,
-			MaxAttempts: req.RestartPolicy.MaxAttempts,
-		}
+	if !s.checkClusterScope(w, r, "deployments:create", deployment.SelectedClusters) {
+		return
 	}
-
-	// Networks
-	if len(req.Networks) > 0 {
-		deployment.Networks = make([]*pb.NetworkReference, 0, len(req.Networks))
-		for _, netName := range req.Networks {
-			deployment.Networks = append(deployment.Networks, &pb.NetworkReference{
-				Name: netName,
-			})
-		}
+	if !s.checkSecretReferences(w, r, deployment) {
+		return
 	}
-
-	// Instance type
-	if req.InstanceType != "" {
-		deployment.InstanceType = req.InstanceType
+	if !s.checkConfigReferences(w, r, deployment) {
+		return
 	}
 
-	if req.InstanceConfig != nil {
-		instSpec := &pb.InstanceSpec{
-			ImageName:     req.InstanceConfig.Image,
-			Entrypoint:    req.InstanceConfig.Command,
-			Arguments:     req.InstanceConfig.Args,
-			DriverOptions: req.InstanceConfig.Options,
-		}
-
-		// Image source
-		if req.InstanceConfig.ImageSource != nil {
-			instSpec.ImageSource = &pb.ImageSource{
-				Alias:  req.InstanceConfig.ImageSource.Alias,
-				Server: req.InstanceConfig.ImageSource.Server,
-				Mode:   req.InstanceConfig.ImageSource.Mode,
-			}
-		}
-
-		// User data
-		if req.InstanceConfig.UserData != "" {
-			instSpec.UserData = req.InstanceConfig.UserData
-		}
-
-		// Devices
-		if len(req.InstanceConfig.Devices) > 0 {
-			instSpec.Devices = make([]*pb.Device, 0, len(req.InstanceConfig.Devices))
-			for _, d := range req.InstanceConfig.Devices {
-				instSpec.Devices = append(instSpec.Devices, &pb.Device{
-					Name:       d.Name,
-					Type:       d.Type,
-					Properties: d.Properties,
-				})
-			}
-		}
-
-		deployment.InstanceConfig = instSpec
-	}
-	if req.Resources != nil {
-		deployment.ResourceRequirements = &pb.Resources{
-			MemoryLimitMb:    req.Resources.MemoryMB,
-			MemoryReservedMb: req.Resources.MemoryReserveMB,
-			CpuLimitCores:    req.Resources.CPU,
-			CpuReservedCores: req.Resources.CPUReserve,
-		}
-	}
-	if len(req.Volumes) > 0 {
-		deployment.VolumeMounts = make([]*pb.Volume, 0, len(req.Volumes))
-		for _, v := range req.Volumes {
-			vol := &pb.Volume{
-				SourcePath: v.HostPath,
-				TargetPath: v.InstancePath,
-				ReadOnly:   v.ReadOnly,
-			}
-			if v.Type != "" {
-				vol.Type = v.Type
-			}
-			deployment.VolumeMounts = append(deployment.VolumeMounts, vol)
-		}
-	}
-	if req.Meta != nil {
-		deployment.DeploymentMetadata = req.Meta
+	// The request is kept so that later merge patches use the same field names
+	req.DeploymentId = deploymentID
+	request, err := json.Marshal(&req)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to submit deployment")
+		return
 	}
 
 	ctx := context.Background()
-	if err := s.storage.EnqueueDeployment(ctx, deployment); err != nil {
+	stored, created, err := s.storage.SubmitDeployment(ctx, &etcdstorage.DeploymentSpec{
+		IdempotencyKey: idempotencyKey,
+		Request:        request,
+		Deployment:     deployment,
+	}, requestAuthor(r))
+	if errors.Is(err, etcdstorage.ErrDeploymentConflict) {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Deployment %s already exists with a different spec", deploymentID))
+		return
+	}
+	if err != nil {
 		log.Printf("[Centro REST] Failed to enqueue deployment: %v", err)
 		respondWithError(w, http.StatusInternalServerError, "Failed to submit deployment")
 		return
 	}
 
+	if !created {
+		log.Printf("[Centro REST] Deployment %s resubmitted with an identical spec", deploymentID)
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"deployment_id": deploymentID,
+			"created":       false,
+			"message":       "Deployment already exists with the same spec",
+			"deployment":    stored.Deployment,
+		})
+		return
+	}
+
 	log.Printf("[Centro REST] Deployment submitted: %s (%s)", deploymentID, req.DeploymentName)
 
 	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
 		"deployment_id":  deploymentID,
+		"created":        true,
 		"message": "Deployment submitted successfully",
 		"deployment":     deployment,
 	})
@@ -544,7 +599,7 @@ func (s *APIServer) handleSubmitDeployment(w http.ResponseWriter, r *http.Reques
 
 // handleGetDeployment godoc
 // @Summary Get deployment details
-// @Description Get detailed information about a specific deployment
+// @Description Get detailed information about a specific deployment. For a service the response has status "service" and lists its rollout state and replicas.
 // @Tags Deployments
 // @Accept json
 // @Produce json
@@ -564,13 +619,18 @@ func (s *APIServer) handleGetDeployment(w http.ResponseWriter, r *http.Request)
 		log.Printf("[Centro REST] Failed to get active deployment: %v", err)
 	}
 
-	events, err := s.storage.GetDeploymentEvents(ctx, deploymentID)
+	events, err := s.storage.GetDeploymentEvents(ctx, deploymentID, etcdstorage.EventFilter{})
 	if err != nil {
 		log.Printf("[Centro REST] Failed to get deployment events: %v", err)
 	}
 
+	stored, err := s.storage.GetDeploymentSpec(ctx, deploymentID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get deployment spec: %v", err)
+	}
+
 	if activeDeployment != nil {
-		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		respondWithJSON(w, http.StatusOK, withAuthors(map[string]interface{}{
 			"deployment_id":     deploymentID,
 			"status":     "active",
 			"node_id":    activeDeployment.NodeID,
@@ -579,7 +639,7 @@ func (s *APIServer) handleGetDeployment(w http.ResponseWriter, r *http.Request)
 			"claimed_at": activeDeployment.ClaimedAt,
 			"deployment":        activeDeployment.Deployment,
 			"events":     events,
-		})
+		}, stored))
 		return
 	}
 
@@ -589,7 +649,7 @@ func (s *APIServer) handleGetDeployment(w http.ResponseWriter, r *http.Request)
 	}
 
 	if queueDeployment != nil {
-		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		respondWithJSON(w, http.StatusOK, withAuthors(map[string]interface{}{
 			"deployment_id":     deploymentID,
 			"status":     "queued",
 			"node_id":    "",
@@ -598,7 +658,7 @@ func (s *APIServer) handleGetDeployment(w http.ResponseWriter, r *http.Request)
 			"claimed_at": nil,
 			"deployment":        queueDeployment,
 			"events":     events,
-		})
+		}, stored))
 		return
 	}
 
@@ -609,7 +669,7 @@ func (s *APIServer) handleGetDeployment(w http.ResponseWriter, r *http.Request)
 	}
 
 	if failedDeployment != nil {
-		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		respondWithJSON(w, http.StatusOK, withAuthors(map[string]interface{}{
 			"deployment_id":     deploymentID,
 			"status":     "failed",
 			"node_id":    "",
@@ -618,7 +678,7 @@ func (s *APIServer) handleGetDeployment(w http.ResponseWriter, r *http.Request)
 			"claimed_at": nil,
 			"deployment":        failedDeployment,
 			"events":     events,
-		})
+		}, stored))
 		return
 	}
 
@@ -628,16 +688,44 @@ func (s *APIServer) handleGetDeployment(w http.ResponseWriter, r *http.Request)
 	}
 
 	if historyDeployment != nil {
-		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		respondWithJSON(w, http.StatusOK, withAuthors(map[string]interface{}{
 			"deployment_id":     deploymentID,
-			"status":     "completed",
+			"status":     historyDeployment.Status,
 			"node_id":    historyDeployment.NodeID,
 			"detail":     historyDeployment.Detail,
 			"updated_at": historyDeployment.UpdatedAt,
 			"claimed_at": historyDeployment.ClaimedAt,
 			"deployment":        historyDeployment.Deployment,
 			"events":     events,
-		})
+		}, stored))
+		return
+	}
+
+	if stored != nil && etcdstorage.HasDependencies(stored.Deployment) {
+		state, err := s.storage.GetDependencyState(ctx, deploymentID)
+		if err != nil {
+			log.Printf("[Centro REST] Failed to get dependency state: %v", err)
+		}
+		if state == nil || state.Status == etcdstorage.DependencyStatusBlocked {
+			entry := blockedEntry(stored, state)
+			entry["events"] = events
+			respondWithJSON(w, http.StatusOK, entry)
+			return
+		}
+	}
+
+	if stored != nil && (etcdstorage.IsManagedService(stored.Deployment) || etcdstorage.IsManagedSystem(stored.Deployment)) {
+		s.respondWithService(ctx, w, stored, events)
+		return
+	}
+
+	if stored != nil && etcdstorage.IsManagedBatch(stored.Deployment) {
+		s.respondWithBatch(ctx, w, stored, events)
+		return
+	}
+
+	if stored != nil && etcdstorage.IsManagedPeriodic(stored.Deployment) {
+		s.respondWithPeriodic(ctx, w, stored, events)
 		return
 	}
 
@@ -722,76 +810,123 @@ func (s *APIServer) handleListInstances(w http.ResponseWriter, r *http.Request)
 
 // handleGetDeploymentEvents godoc
 // @Summary Get deployment events
-// @Description Retrieve events for a specific deployment
+// @Description Retrieve structured events for a specific deployment, optionally filtered by type and time range
 // @Tags Deployments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Deployment ID"
+// @Param type query string false "Filter by event type (normal, warning)"
+// @Param since query string false "Only events at or after this time (RFC3339)"
+// @Param until query string false "Only events at or before this time (RFC3339)"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /deployments/{id}/events [get]
 func (s *APIServer) handleGetDeploymentEvents(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	deploymentID := vars["id"]
 
-	ctx := context.Background()
-	events, err := s.storage.GetDeploymentEvents(ctx, deploymentID)
+	filter, err := parseEventFilter(r)
 	if err != nil {
-		log.Printf("[Centro REST] Failed to get deployment events: %v", err)
-		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve events")
+		respondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if events == nil {
-		respondWithJSON(w, http.StatusOK, map[string]interface{}{
-			"deployment_id": deploymentID,
-			"events": []string{},
-		})
+	ctx := context.Background()
+	events, err := s.storage.GetDeploymentEvents(ctx, deploymentID, filter)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get deployment events: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve events")
 		return
 	}
 
-	// Filter out consecutive duplicate events
-	filteredEvents := filterConsecutiveDuplicates(events)
-
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"deployment_id": deploymentID,
-		"events": filteredEvents,
+		"events":        events,
+		"count":         len(events),
 	})
 }
 
-// filterConsecutiveDuplicates removes consecutive duplicate events from the list
-func filterConsecutiveDuplicates(events []string) []string {
-	if len(events) == 0 {
-		return events
+// handleListEvents godoc
+// @Summary List events
+// @Description Retrieve events of all deployments, oldest first. The returned revision can be passed to /watch?kind=event to follow new events.
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param deployment_id query string false "Only events of this deployment"
+// @Param type query string false "Filter by event type (normal, warning)"
+// @Param since query string false "Only events at or after this time (RFC3339)"
+// @Param until query string false "Only events at or before this time (RFC3339)"
+// @Param limit query int false "Only return the most recent N events"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /events [get]
+func (s *APIServer) handleListEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseEventFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
 	}
+	filter.DeploymentID = r.URL.Query().Get("deployment_id")
 
-	filtered := make([]string, 0, len(events))
-	filtered = append(filtered, events[0])
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 0 {
+			respondWithError(w, http.StatusBadRequest, "invalid 'limit' parameter")
+			return
+		}
+	}
 
-	for i := 1; i < len(events); i++ {
-		// Extract the message part after the timestamp (everything after "] ")
-		currentMsg := extractEventMessage(events[i])
-		previousMsg := extractEventMessage(events[i-1])
+	ctx := context.Background()
+	events, revision, err := s.storage.GetAllEvents(ctx, filter)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get events: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve events")
+		return
+	}
 
-		// Only add if the message is different from the previous one
-		if currentMsg != previousMsg {
-			filtered = append(filtered, events[i])
-		}
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
 	}
 
-	return filtered
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"events":   events,
+		"count":    len(events),
+		"revision": revision,
+	})
 }
 
-// extractEventMessage extracts the message part from an event string
-// Events format: "[timestamp] message"
-func extractEventMessage(event string) string {
-	// Find the position after the timestamp bracket
-	idx := strings.Index(event, "] ")
-	if idx == -1 {
-		return event
+// parseEventFilter reads the type, since and until query parameters
+func parseEventFilter(r *http.Request) (etcdstorage.EventFilter, error) {
+	query := r.URL.Query()
+	filter := etcdstorage.EventFilter{
+		Type: query.Get("type"),
+	}
+	if filter.Type != "" && !etcdstorage.ValidEventType(filter.Type) {
+		return filter, fmt.Errorf("invalid 'type' parameter, expected normal or warning")
+	}
+
+	if since := query.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return filter, fmt.Errorf("invalid 'since' parameter, expected RFC3339 timestamp")
+		}
+		filter.Since = t
+	}
+
+	if until := query.Get("until"); until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return filter, fmt.Errorf("invalid 'until' parameter, expected RFC3339 timestamp")
+		}
+		filter.Until = t
 	}
-	return event[idx+2:]
+
+	return filter, nil
 }
 
 // handleGetInstanceData godoc
@@ -822,7 +957,7 @@ func (s *APIServer) handleGetInstanceData(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	events, err := s.storage.GetDeploymentEvents(ctx, deploymentID)
+	events, err := s.storage.GetDeploymentEvents(ctx, deploymentID, etcdstorage.EventFilter{})
 	if err != nil {
 		log.Printf("[Centro REST] Failed to get deployment events: %v", err)
 		respondWithError(w, http.StatusInternalServerError, "Failed to retrieve events")
@@ -843,6 +978,7 @@ func (s *APIServer) handleGetInstanceData(w http.ResponseWriter, r *http.Request
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param approval query string false "Only nodes with this approval (pending, approved, rejected)"
 // @Success 200 {object} map[string]interface{}
 // @Failure 500 {object} map[string]string
 // @Router /nodes [get]
@@ -855,15 +991,24 @@ func (s *APIServer) handleListNodes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	approval := r.URL.Query().Get("approval")
+
 	nodesList := make([]map[string]interface{}, 0, len(nodes))
 	for _, node := range nodes {
+		if approval != "" && node.ApprovalStatus() != approval {
+			continue
+		}
 		nodesList = append(nodesList, map[string]interface{}{
 			"node_id":        node.NodeID,
+			"cluster_name":   node.ClusterName,
 			"last_heartbeat": node.LastHeartbeat,
 			"ram_mb":         node.RamMB,
 			"cpu_cores":      node.CPUCores,
 			"disk_mb":        node.DiskMB,
 			"metadata":       node.Metadata,
+			"labels":         node.Labels,
+			"decommissioned": node.Decommissioned,
+			"approval":       node.ApprovalStatus(),
 		})
 	}
 
@@ -904,11 +1049,17 @@ func (s *APIServer) handleGetNode(w http.ResponseWriter, r *http.Request) {
 
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"node_id":        node.NodeID,
+		"cluster_name":   node.ClusterName,
 		"last_heartbeat": node.LastHeartbeat,
 		"ram_mb":         node.RamMB,
 		"cpu_cores":      node.CPUCores,
 		"disk_mb":        node.DiskMB,
 		"metadata":       node.Metadata,
+		"labels":         node.Labels,
+		"decommissioned": node.Decommissioned,
+		"approval":       node.ApprovalStatus(),
+		"approved_by":    node.ApprovedBy,
+		"join_token_id":  node.JoinTokenID,
 	})
 }
 
@@ -994,16 +1145,21 @@ func (s *APIServer) handleStats(w http.ResponseWriter, r *http.Request) {
 	}
 
 	healthyNodes := 0
+	pendingNodes := 0
 	for _, node := range nodes {
 		if node.IsHealthy() {
 			healthyNodes++
 		}
+		if node.Approval == etcdstorage.NodeApprovalPending {
+			pendingNodes++
+		}
 	}
 
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"nodes": map[string]interface{}{
 			"total":   len(nodes),
 			"healthy": healthyNodes,
+			"pending": pendingNodes,
 		},
 		"deployments": map[string]interface{}{
 			"queued":    queueLength,
@@ -1029,3 +1185,10 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 func respondWithError(w http.ResponseWriter, code int, message string) {
 	respondWithJSON(w, code, map[string]string{"error": message})
 }
+
+func respondWithValidationErrors(w http.ResponseWriter, errs spec.Errors) {
+	respondWithJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+		"error":  fmt.Sprintf("Deployment spec is invalid (%d problems)", len(errs)),
+		"errors": errs,
+	})
+}
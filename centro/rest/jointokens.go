@@ -0,0 +1,219 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	centrogrpc "github.com/open-scheduler/centro/grpc"
+	"github.com/open-scheduler/centro/pki"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// defaultJoinTokenTTL is how long a join token is valid unless the request
+// says otherwise
+const defaultJoinTokenTTL = 24 * time.Hour
+
+type CreateJoinTokenRequest struct {
+	Description string `json:"description,omitempty" example:"rack 4"`
+	// Cluster the nodes are put in, whatever cluster their agents report
+	Cluster string `json:"cluster,omitempty" example:"production"`
+	// Labels of the nodes, usable in placement constraints as node.label.<key>
+	Labels map[string]string `json:"labels,omitempty"`
+	// MaxUses is how many nodes can join with the token, 1 if empty
+	MaxUses int `json:"max_uses,omitempty" example:"10"`
+	// ExpiresIn is a duration like 1h or 7d, 24h if empty
+	ExpiresIn string `json:"expires_in,omitempty" example:"1h"`
+}
+
+// EnableNodeCertificates lets admins download the CA certificate agents
+// verify Centro with
+func (s *APIServer) EnableNodeCertificates(ca *pki.CA) {
+	s.ca = ca
+}
+
+func joinTokenResponse(token *etcdstorage.JoinToken) map[string]interface{} {
+	status := "active"
+	if token.Exhausted() {
+		status = "used up"
+	}
+	usedBy := token.UsedBy
+	if usedBy == nil {
+		usedBy = []string{}
+	}
+	return map[string]interface{}{
+		"id":           token.ID,
+		"description":  token.Description,
+		"cluster":      token.Cluster,
+		"labels":       token.Labels,
+		"status":       status,
+		"max_uses":     token.MaxUses,
+		"uses":         len(token.UsedBy),
+		"expires_at":   token.ExpiresAt,
+		"created_by":   token.CreatedBy,
+		"created_at":   token.CreatedAt,
+		"used_by":      usedBy,
+		"last_used_at": token.LastUsedAt,
+	}
+}
+
+// handleGetCA godoc
+// @Summary Get the CA certificate
+// @Description The certificate of the internal CA, in PEM. Agents need it to verify Centro before they join.
+// @Tags Nodes
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /pki/ca [get]
+func (s *APIServer) handleGetCA(w http.ResponseWriter, r *http.Request) {
+	if s.ca == nil {
+		respondWithError(w, http.StatusNotFound, "Centro serves gRPC without TLS")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"certificate": string(s.ca.CertificatePEM()),
+	})
+}
+
+// handleListJoinTokens godoc
+// @Summary List join tokens
+// @Description List the join tokens that have not expired yet, newest first. The secrets are never returned.
+// @Tags Nodes
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Router /join-tokens [get]
+func (s *APIServer) handleListJoinTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := s.storage.GetAllJoinTokens(context.Background())
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get join tokens: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get join tokens")
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(tokens))
+	for _, token := range tokens {
+		list = append(list, joinTokenResponse(token))
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"join_tokens": list,
+		"count":       len(list),
+	})
+}
+
+// handleCreateJoinToken godoc
+// @Summary Create a join token
+// @Description Create a token new nodes join with. They are approved at once and put in the cluster and given the labels of the token, and with TLS get their client certificate. The token works for max_uses nodes until it expires. It is only returned in this response, Centro stores its hash.
+// @Tags Nodes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param token body CreateJoinTokenRequest false "Join token"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /join-tokens [post]
+func (s *APIServer) handleCreateJoinToken(w http.ResponseWriter, r *http.Request) {
+	var req CreateJoinTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.MaxUses < 0 {
+		respondWithError(w, http.StatusBadRequest, "max_uses must be positive")
+		return
+	}
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+	for key := range req.Labels {
+		if key == "" {
+			respondWithError(w, http.StatusBadRequest, "Label keys must not be empty")
+			return
+		}
+	}
+	// Roles limited to clusters can only let nodes join those clusters
+	if !s.checkClusterScope(w, r, "jointokens:create", []string{req.Cluster}) {
+		return
+	}
+	ttl := defaultJoinTokenTTL
+	if req.ExpiresIn != "" {
+		duration, err := parseExpiresIn(req.ExpiresIn)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if duration <= 0 {
+			respondWithError(w, http.StatusBadRequest, "expires_in must be positive")
+			return
+		}
+		ttl = duration
+	}
+
+	tokenID, raw, err := newSecretToken(centrogrpc.JoinTokenPrefix)
+	if err != nil {
+		log.Printf("[Centro REST] %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create join token")
+		return
+	}
+	now := time.Now()
+	token := &etcdstorage.JoinToken{
+		ID:          tokenID,
+		SecretHash:  centrogrpc.HashToken(raw),
+		Description: req.Description,
+		Cluster:     req.Cluster,
+		Labels:      req.Labels,
+		MaxUses:     req.MaxUses,
+		ExpiresAt:   now.Add(ttl),
+		CreatedBy:   requestAuthor(r),
+		CreatedAt:   now,
+	}
+	if err := s.storage.SaveJoinToken(context.Background(), token); err != nil {
+		log.Printf("[Centro REST] Failed to save join token: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create join token")
+		return
+	}
+
+	log.Printf("[Centro REST] Join token %s for %d node(s) in cluster %q created by %s, expires %s",
+		token.ID, token.MaxUses, token.Cluster, token.CreatedBy, token.ExpiresAt.Format(time.RFC3339))
+	response := joinTokenResponse(token)
+	response["token"] = raw
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// handleDeleteJoinToken godoc
+// @Summary Delete a join token
+// @Description Nodes can no longer join with the token. Nodes that joined with it stay approved and keep their certificates.
+// @Tags Nodes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Join token ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /join-tokens/{id} [delete]
+func (s *APIServer) handleDeleteJoinToken(w http.ResponseWriter, r *http.Request) {
+	tokenID := mux.Vars(r)["id"]
+	deleted, err := s.storage.DeleteJoinToken(context.Background(), tokenID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to delete join token %s: %v", tokenID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete join token")
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Join token not found")
+		return
+	}
+
+	log.Printf("[Centro REST] Join token %s deleted by %s", tokenID, requestAuthor(r))
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Join token " + tokenID + " deleted",
+	})
+}
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,22 +10,60 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-var jwtSecret = []byte("your-secret-key-change-in-production")
+// DefaultJWTSecret signs tokens when no secret is configured. Centro only
+// accepts it in dev mode.
+const DefaultJWTSecret = "your-secret-key-change-in-production"
+
+var jwtSecret = []byte(DefaultJWTSecret)
+
+// SetJWTSecret sets the secret API tokens are signed and checked with
+func SetJWTSecret(secret []byte) {
+	jwtSecret = secret
+}
 
 type Claims struct {
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// Scopes limit an API token to these permissions of the role
+	Scopes []string `json:"scopes,omitempty"`
+	// TokenID is set for API tokens of service accounts
+	TokenID string `json:"token_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// APITokenValidator checks an API token and returns the claims of its service
+// account. It returns ErrInvalidToken for unknown, revoked or expired tokens.
+type APITokenValidator func(ctx context.Context, token string) (*Claims, error)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// AccessTokenTTL is the lifetime of the tokens GenerateToken issues. Clients
+// renew them with a refresh token.
+const AccessTokenTTL = 15 * time.Minute
+
+// RevocationList tells whether a token was revoked before it expired, keyed
+// by the token ID (jti)
+type RevocationList interface {
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+var revocationList RevocationList
+
+// SetRevocationList sets the list ValidateToken checks tokens against
+func SetRevocationList(list RevocationList) {
+	revocationList = list
+}
+
 func GenerateToken(username, role string) (string, error) {
 	claims := &Claims{
 		Username: username,
 		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "centro-scheduler",
@@ -35,7 +74,10 @@ func GenerateToken(username, role string) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
-func ValidateToken(tokenString string) (*Claims, error) {
+// ValidateToken checks the signature and expiry of a token and that it is
+// not on the revocation list. It returns ErrInvalidToken for tokens that
+// must be rejected.
+func ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -44,19 +86,42 @@ func ValidateToken(tokenString string) (*Claims, error) {
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	if revocationList != nil && claims.ID != "" {
+		revoked, err := revocationList.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, fmt.Errorf("%w: token was revoked", ErrInvalidToken)
+		}
 	}
 
-	return nil, fmt.Errorf("invalid token")
+	return claims, nil
 }
 
-func JWTAuthMiddleware(next http.Handler) http.Handler {
+// JWTAuthMiddleware accepts JWTs from /auth/login and API tokens of service
+// accounts, which are checked by apiTokens
+func JWTAuthMiddleware(apiTokens APITokenValidator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return jwtAuth(apiTokens, next)
+	}
+}
+
+func jwtAuth(apiTokens APITokenValidator, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
+		// EventSource and WebSocket clients in browsers cannot set headers
+		if authHeader == "" && isStreamingRequest(r) && r.URL.Query().Get("access_token") != "" {
+			authHeader = "Bearer " + r.URL.Query().Get("access_token")
+		}
 		if authHeader == "" {
 			http.Error(w, `{"error": "Authorization header required"}`, http.StatusUnauthorized)
 			return
@@ -68,18 +133,35 @@ func JWTAuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		claims, err := ValidateToken(parts[1])
+		var claims *Claims
+		var err error
+		if IsAPIToken(parts[1]) {
+			claims, err = apiTokens(r.Context(), parts[1])
+		} else {
+			claims, err = ValidateToken(r.Context(), parts[1])
+		}
+		if err != nil && !errors.Is(err, ErrInvalidToken) {
+			log.Printf("[Centro REST] Token validation failed: %v", err)
+			http.Error(w, `{"error": "Failed to validate token"}`, http.StatusInternalServerError)
+			return
+		}
 		if err != nil {
 			log.Printf("[Centro REST] Token validation failed: %v", err)
 			http.Error(w, `{"error": "Invalid or expired token"}`, http.StatusUnauthorized)
 			return
 		}
 
+		auditClaims(r, claims)
 		ctx := context.WithValue(r.Context(), "claims", claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+func isStreamingRequest(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream") ||
+		strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -92,8 +174,8 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
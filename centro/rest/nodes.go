@@ -0,0 +1,382 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	centrogrpc "github.com/open-scheduler/centro/grpc"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// handleDecommissionNode godoc
+// @Summary Decommission a node
+// @Description Stop assigning deployments to a node and revoke its client certificates and node credentials, so its agent can no longer call Centro. Deployments on the node are handled like those of a lost node: replicas are replaced and batch deployments retried elsewhere. The node needs a new join token or credential after recommissioning.
+// @Tags Nodes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Node ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /nodes/{id}/decommission [post]
+func (s *APIServer) handleDecommissionNode(w http.ResponseWriter, r *http.Request) {
+	s.setNodeDecommissioned(w, r, true)
+}
+
+// handleRecommissionNode godoc
+// @Summary Recommission a node
+// @Description Put a decommissioned node back into service. System deployments that match the node are started on it again once its agent joined again with a new join token or credential.
+// @Tags Nodes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Node ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /nodes/{id}/recommission [post]
+func (s *APIServer) handleRecommissionNode(w http.ResponseWriter, r *http.Request) {
+	s.setNodeDecommissioned(w, r, false)
+}
+
+func (s *APIServer) setNodeDecommissioned(w http.ResponseWriter, r *http.Request, decommissioned bool) {
+	nodeID := mux.Vars(r)["id"]
+
+	ctx := context.Background()
+	node, err := s.storage.SetNodeDecommissioned(ctx, nodeID, decommissioned)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to update node %s: %v", nodeID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update node")
+		return
+	}
+	if node == nil {
+		respondWithError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	action := "recommissioned"
+	if decommissioned {
+		action = "decommissioned"
+	}
+	log.Printf("[Centro REST] Node %s %s by %s", nodeID, action, requestAuthor(r))
+
+	response := map[string]interface{}{
+		"node_id":           node.NodeID,
+		"decommissioned":    node.Decommissioned,
+		"decommissioned_at": node.DecommissionedAt,
+		"message":           fmt.Sprintf("Node %s %s", nodeID, action),
+	}
+	if decommissioned {
+		certificates, credentials, err := s.revokeNodeIdentity(ctx, nodeID)
+		if err != nil {
+			log.Printf("[Centro REST] Failed to revoke the identity of node %s: %v", nodeID, err)
+			respondWithError(w, http.StatusInternalServerError, "Node decommissioned, but its certificates could not be revoked, try again")
+			return
+		}
+		response["revoked_certificates"] = certificates
+		response["revoked_credentials"] = credentials
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+type ApproveNodeRequest struct {
+	// Cluster the node is put in, whatever cluster its agent reports
+	Cluster string `json:"cluster,omitempty" example:"production"`
+	// Labels replace those of the node, usable in placement constraints as node.label.<key>
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func nodeApprovalResponse(node *etcdstorage.NodeInfo, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"node_id":      node.NodeID,
+		"approval":     node.ApprovalStatus(),
+		"approved_by":  node.ApprovedBy,
+		"approved_at":  node.ApprovedAt,
+		"cluster_name": node.ClusterName,
+		"labels":       node.Labels,
+		"message":      message,
+	}
+}
+
+// handleApproveNode godoc
+// @Summary Approve a node
+// @Description Let a node that registered without a join token get deployments. Optionally put it in a cluster and give it labels. Approving an approved node changes its cluster and labels.
+// @Tags Nodes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Node ID"
+// @Param approval body ApproveNodeRequest false "Cluster and labels"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /nodes/{id}/approve [post]
+func (s *APIServer) handleApproveNode(w http.ResponseWriter, r *http.Request) {
+	nodeID := mux.Vars(r)["id"]
+
+	var req ApproveNodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	for key := range req.Labels {
+		if key == "" {
+			respondWithError(w, http.StatusBadRequest, "Label keys must not be empty")
+			return
+		}
+	}
+	if req.Cluster != "" && !s.checkClusterScope(w, r, "nodes:update", []string{req.Cluster}) {
+		return
+	}
+
+	author := requestAuthor(r)
+	now := time.Now()
+	node, err := s.storage.UpdateNode(context.Background(), nodeID, func(node *etcdstorage.NodeInfo) (*etcdstorage.NodeInfo, bool) {
+		if node == nil {
+			return nil, false
+		}
+		node.Approval = etcdstorage.NodeApprovalApproved
+		node.ApprovedBy = author
+		node.ApprovedAt = now
+		if req.Cluster != "" {
+			node.AssignedCluster = req.Cluster
+			node.ClusterName = req.Cluster
+		}
+		if req.Labels != nil {
+			node.Labels = req.Labels
+		}
+		return node, true
+	})
+	if err != nil {
+		log.Printf("[Centro REST] Failed to approve node %s: %v", nodeID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update node")
+		return
+	}
+	if node == nil {
+		respondWithError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+
+	log.Printf("[Centro REST] Node %s approved by %s (cluster: %s)", nodeID, author, node.ClusterName)
+	respondWithJSON(w, http.StatusOK, nodeApprovalResponse(node, fmt.Sprintf("Node %s approved", nodeID)))
+}
+
+// handleRejectNode godoc
+// @Summary Reject a node
+// @Description Keep a node from ever getting deployments and revoke its client certificates and node credentials. Its heartbeats are refused until it is approved.
+// @Tags Nodes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Node ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /nodes/{id}/reject [post]
+func (s *APIServer) handleRejectNode(w http.ResponseWriter, r *http.Request) {
+	nodeID := mux.Vars(r)["id"]
+
+	ctx := context.Background()
+	node, err := s.storage.UpdateNode(ctx, nodeID, func(node *etcdstorage.NodeInfo) (*etcdstorage.NodeInfo, bool) {
+		if node == nil {
+			return nil, false
+		}
+		node.Approval = etcdstorage.NodeApprovalRejected
+		node.ApprovedBy = ""
+		node.ApprovedAt = time.Time{}
+		return node, true
+	})
+	if err != nil {
+		log.Printf("[Centro REST] Failed to reject node %s: %v", nodeID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update node")
+		return
+	}
+	if node == nil {
+		respondWithError(w, http.StatusNotFound, "Node not found")
+		return
+	}
+	log.Printf("[Centro REST] Node %s rejected by %s", nodeID, requestAuthor(r))
+
+	response := nodeApprovalResponse(node, fmt.Sprintf("Node %s rejected", nodeID))
+	certificates, credentials, err := s.revokeNodeIdentity(ctx, nodeID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to revoke the identity of node %s: %v", nodeID, err)
+		respondWithError(w, http.StatusInternalServerError, "Node rejected, but its certificates could not be revoked, try again")
+		return
+	}
+	response["revoked_certificates"] = certificates
+	response["revoked_credentials"] = credentials
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// revokeNodeIdentity revokes the certificates and credentials of a node, so
+// its agent cannot call Centro anymore
+func (s *APIServer) revokeNodeIdentity(ctx context.Context, nodeID string) (certificates, credentials int, err error) {
+	if certificates, err = s.storage.RevokeNodeCertificates(ctx, nodeID); err != nil {
+		return 0, 0, err
+	}
+	if credentials, err = s.storage.DeleteNodeCredentials(ctx, nodeID, ""); err != nil {
+		return certificates, 0, err
+	}
+	// Lets the node join again with a join token
+	_, err = s.storage.UpdateNode(ctx, nodeID, func(node *etcdstorage.NodeInfo) (*etcdstorage.NodeInfo, bool) {
+		if node == nil {
+			return nil, false
+		}
+		node.IdentityRevokedAt = time.Now()
+		return node, true
+	})
+	if err != nil {
+		return certificates, credentials, err
+	}
+	if certificates+credentials > 0 {
+		log.Printf("[Centro REST] Revoked %d certificate(s) and %d credential(s) of node %s", certificates, credentials, nodeID)
+	}
+	return certificates, credentials, nil
+}
+
+func nodeCredentialResponse(credential *etcdstorage.NodeCredential) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           credential.ID,
+		"node_id":      credential.NodeID,
+		"created_by":   credential.CreatedBy,
+		"created_at":   credential.CreatedAt,
+		"last_used_at": credential.LastUsedAt,
+	}
+}
+
+// handleCreateNodeCredential godoc
+// @Summary Create a node credential
+// @Description Create the credential an agent authenticates with on the gRPC API. Every call made with it is bound to the node, so the agent must run with this node ID. The node does not need to exist yet, but only roles without a cluster scope can create credentials for new nodes. The credential is only returned in this response, Centro stores its hash.
+// @Tags Nodes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Node ID"
+// @Success 201 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Router /nodes/{id}/credentials [post]
+func (s *APIServer) handleCreateNodeCredential(w http.ResponseWriter, r *http.Request) {
+	nodeID := mux.Vars(r)["id"]
+
+	ctx := context.Background()
+	node, err := s.storage.GetNode(ctx, nodeID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get node %s: %v", nodeID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create node credential")
+		return
+	}
+	// A new node may register into any cluster, the existing ones were
+	// checked against the cluster scope by authorize
+	if node == nil && !s.checkClusterScope(w, r, "nodes:update", nil) {
+		return
+	}
+
+	credentialID, raw, err := newSecretToken(centrogrpc.NodeCredentialPrefix)
+	if err != nil {
+		log.Printf("[Centro REST] %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create node credential")
+		return
+	}
+	credential := &etcdstorage.NodeCredential{
+		ID:         credentialID,
+		NodeID:     nodeID,
+		SecretHash: centrogrpc.HashToken(raw),
+		CreatedBy:  requestAuthor(r),
+		CreatedAt:  time.Now(),
+	}
+	if err := s.storage.SaveNodeCredential(ctx, credential); err != nil {
+		log.Printf("[Centro REST] Failed to save node credential: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create node credential")
+		return
+	}
+
+	log.Printf("[Centro REST] Credential %s of node %s created by %s", credential.ID, nodeID, credential.CreatedBy)
+	response := nodeCredentialResponse(credential)
+	response["credential"] = raw
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// handleListNodeCredentials godoc
+// @Summary List node credentials
+// @Description List the credentials of a node, newest first. The secrets are never returned.
+// @Tags Nodes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Node ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Router /nodes/{id}/credentials [get]
+func (s *APIServer) handleListNodeCredentials(w http.ResponseWriter, r *http.Request) {
+	nodeID := mux.Vars(r)["id"]
+
+	credentials, err := s.storage.GetNodeCredentials(context.Background(), nodeID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get credentials of node %s: %v", nodeID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get node credentials")
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(credentials))
+	for _, credential := range credentials {
+		list = append(list, nodeCredentialResponse(credential))
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"credentials": list,
+		"count":       len(list),
+	})
+}
+
+// handleRevokeNodeCredentials godoc
+// @Summary Revoke all credentials of a node
+// @Description The agent of the node cannot call Centro anymore until it gets a new credential
+// @Tags Nodes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Node ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Router /nodes/{id}/credentials [delete]
+func (s *APIServer) handleRevokeNodeCredentials(w http.ResponseWriter, r *http.Request) {
+	s.revokeNodeCredentials(w, r, "")
+}
+
+// handleRevokeNodeCredential godoc
+// @Summary Revoke a node credential
+// @Tags Nodes
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Node ID"
+// @Param credential_id path string true "Credential ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /nodes/{id}/credentials/{credential_id} [delete]
+func (s *APIServer) handleRevokeNodeCredential(w http.ResponseWriter, r *http.Request) {
+	s.revokeNodeCredentials(w, r, mux.Vars(r)["credential_id"])
+}
+
+func (s *APIServer) revokeNodeCredentials(w http.ResponseWriter, r *http.Request, credentialID string) {
+	nodeID := mux.Vars(r)["id"]
+
+	revoked, err := s.storage.DeleteNodeCredentials(context.Background(), nodeID, credentialID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to revoke credentials of node %s: %v", nodeID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to revoke node credentials")
+		return
+	}
+	if credentialID != "" && revoked == 0 {
+		respondWithError(w, http.StatusNotFound, "Node credential not found")
+		return
+	}
+
+	log.Printf("[Centro REST] %d credential(s) of node %s revoked by %s", revoked, nodeID, requestAuthor(r))
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"node_id": nodeID,
+		"revoked": revoked,
+		"message": fmt.Sprintf("%d credential(s) of node %s revoked", revoked, nodeID),
+	})
+}
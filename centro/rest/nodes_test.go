@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+)
+
+func TestCreateNodeCredentialClusterScope(t *testing.T) {
+	storage, _ := etcdtest.NewStorage()
+	s := NewAPIServer(storage)
+	if err := storage.SaveNode(context.Background(), &etcdstorage.NodeInfo{NodeID: "eu-1", ClusterName: "eu"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SaveNode(context.Background(), &etcdstorage.NodeInfo{NodeID: "us-1", ClusterName: "us"}); err != nil {
+		t.Fatal(err)
+	}
+
+	euOperator := &etcdstorage.Role{Name: "eu-operator", Permissions: []string{"nodes:update"}, Clusters: []string{"eu"}}
+	tests := []struct {
+		name   string
+		role   *etcdstorage.Role
+		nodeID string
+		want   int
+	}{
+		{name: "scoped role, node in its cluster", role: euOperator, nodeID: "eu-1", want: http.StatusCreated},
+		{name: "scoped role, node in another cluster", role: euOperator, nodeID: "us-1", want: http.StatusForbidden},
+		{name: "scoped role, new node", role: euOperator, nodeID: "new-1", want: http.StatusForbidden},
+		{name: "unscoped role, new node", role: builtinRoles[RoleAdmin], nodeID: "new-1", want: http.StatusCreated},
+	}
+	handler := s.authorize("nodes:update", s.handleCreateNodeCredential)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/nodes/"+tt.nodeID+"/credentials", nil)
+			r = mux.SetURLVars(r, map[string]string{"id": tt.nodeID})
+			r = r.WithContext(context.WithValue(r.Context(), "role", tt.role))
+			recorder := httptest.NewRecorder()
+			handler(recorder, r)
+			if recorder.Code != tt.want {
+				t.Errorf("got status %d, want %d: %s", recorder.Code, tt.want, recorder.Body)
+			}
+		})
+	}
+}
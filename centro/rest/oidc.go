@@ -0,0 +1,331 @@
+package rest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/open-scheduler/centro/oidc"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"golang.org/x/oauth2"
+)
+
+// oidcLoginTTL is how long a browser may take at the identity provider
+const oidcLoginTTL = 10 * time.Minute
+
+type OIDCConfigResponse struct {
+	Enabled    bool   `json:"enabled"`
+	Issuer     string `json:"issuer,omitempty" example:"https://login.example.com"`
+	DeviceFlow bool   `json:"device_flow"`
+}
+
+type OIDCDeviceTokenRequest struct {
+	DeviceCode string `json:"device_code"`
+}
+
+// DefaultSSOSessionTTL is how long SSO logins can be refreshed by default
+const DefaultSSOSessionTTL = 12 * time.Hour
+
+// EnableOIDC lets users log in through an OpenID Connect identity provider.
+// Their logins can be refreshed for sessionTTL, then the identity provider is
+// asked again, so that users removed from a group lose its role.
+func (s *APIServer) EnableOIDC(provider *oidc.Provider, sessionTTL time.Duration) {
+	s.oidc = provider
+	s.ssoSessionTTL = sessionTTL
+}
+
+func randomURLString(bytes int) (string, error) {
+	buf := make([]byte, bytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// isRelativePath reports whether returnTo stays on this host, so the login
+// cannot be used to send tokens elsewhere
+func isRelativePath(returnTo string) bool {
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.Contains(returnTo, `\`) {
+		return false
+	}
+	parsed, err := url.Parse(returnTo)
+	return err == nil && parsed.Scheme == "" && parsed.Host == ""
+}
+
+func (s *APIServer) requireOIDC(w http.ResponseWriter) bool {
+	if s.oidc == nil {
+		respondWithError(w, http.StatusNotFound, "Single sign-on is not configured")
+		return false
+	}
+	return true
+}
+
+// loginError is why an SSO login was refused
+type loginError struct {
+	status  int
+	message string
+}
+
+// oidcToken issues a Centro token for a verified identity, or returns why
+// the identity may not log in
+func (s *APIServer) oidcToken(r *http.Request, identity *oidc.Identity, err error) (*LoginResponse, *loginError) {
+	if errors.Is(err, oidc.ErrNoRole) {
+		log.Printf("[Centro REST] SSO login of %s refused, no role for groups %v", identity.Username, identity.Groups)
+		return nil, &loginError{http.StatusForbidden, fmt.Sprintf("No role is mapped to the groups of %s", identity.Username)}
+	}
+	if err != nil {
+		log.Printf("[Centro REST] SSO login failed: %v", err)
+		return nil, &loginError{http.StatusUnauthorized, "Single sign-on failed"}
+	}
+
+	// Local users and service accounts are not taken over by IdP accounts
+	// with the same name
+	if strings.HasPrefix(identity.Username, serviceAccountUsername("")) {
+		return nil, &loginError{http.StatusForbidden, fmt.Sprintf("Username %s is reserved", identity.Username)}
+	}
+	user, err := s.storage.GetUser(r.Context(), identity.Username)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get user %s: %v", identity.Username, err)
+		return nil, &loginError{http.StatusInternalServerError, "Failed to log in"}
+	}
+	if user != nil {
+		log.Printf("[Centro REST] SSO login of %s refused, a local user has the name", identity.Username)
+		return nil, &loginError{http.StatusConflict, fmt.Sprintf("%s is a local user, log in with a password", identity.Username)}
+	}
+
+	role, err := s.getRole(r.Context(), identity.Role)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get role %s: %v", identity.Role, err)
+		return nil, &loginError{http.StatusInternalServerError, "Failed to log in"}
+	}
+	if role == nil {
+		log.Printf("[Centro REST] SSO login of %s refused, mapped role %s does not exist", identity.Username, identity.Role)
+		return nil, &loginError{http.StatusForbidden, fmt.Sprintf("Role %s does not exist", identity.Role)}
+	}
+
+	response, err := s.issueTokens(r.Context(), identity.Username, identity.Role, true, nil)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to log in %s: %v", identity.Username, err)
+		return nil, &loginError{http.StatusInternalServerError, "Failed to generate token"}
+	}
+
+	log.Printf("[Centro REST] SSO login of %s (%s) with role %s", identity.Username, identity.Subject, identity.Role)
+	return response, nil
+}
+
+// returnToLogin sends the browser back to where the login started. The
+// result is in the fragment, which is not sent to servers or written to
+// their logs.
+func returnToLogin(w http.ResponseWriter, r *http.Request, returnTo string, fragment url.Values) {
+	http.Redirect(w, r, returnTo+"#"+fragment.Encode(), http.StatusFound)
+}
+
+// handleOIDCConfig godoc
+// @Summary Get single sign-on configuration
+// @Description Tells the panel and osctl whether users can log in through an OpenID Connect provider
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} OIDCConfigResponse
+// @Router /auth/oidc/config [get]
+func (s *APIServer) handleOIDCConfig(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		respondWithJSON(w, http.StatusOK, OIDCConfigResponse{})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, OIDCConfigResponse{
+		Enabled:    true,
+		Issuer:     s.oidc.Issuer(),
+		DeviceFlow: s.oidc.SupportsDeviceFlow(),
+	})
+}
+
+// handleOIDCLogin godoc
+// @Summary Start a browser login
+// @Description Redirects to the identity provider with an authorization code request using PKCE. After the login the callback redirects to return_to with the token in the URL fragment.
+// @Tags Authentication
+// @Param return_to query string false "Path on this host to return to, e.g. /login"
+// @Success 302
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/oidc/login [get]
+func (s *APIServer) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOIDC(w) {
+		return
+	}
+
+	returnTo := r.URL.Query().Get("return_to")
+	if returnTo != "" && !isRelativePath(returnTo) {
+		respondWithError(w, http.StatusBadRequest, "return_to must be a path on this host")
+		return
+	}
+
+	state, err := randomURLString(24)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	nonce, err := randomURLString(24)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	login := &etcdstorage.OIDCLogin{
+		CodeVerifier: oauth2.GenerateVerifier(),
+		Nonce:        nonce,
+		ReturnTo:     returnTo,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.storage.SaveOIDCLogin(r.Context(), state, login, oidcLoginTTL); err != nil {
+		log.Printf("[Centro REST] Failed to save SSO login: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+
+	http.Redirect(w, r, s.oidc.AuthCodeURL(state, login.CodeVerifier, login.Nonce), http.StatusFound)
+}
+
+// handleOIDCCallback godoc
+// @Summary Finish a browser login
+// @Description The identity provider redirects here. The code is exchanged and the ID token verified. Logins started with return_to are redirected there with token, expires_in and username, or error, in the URL fragment. Others get the token as JSON.
+// @Tags Authentication
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State of the login"
+// @Success 200 {object} LoginResponse
+// @Success 302
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/oidc/callback [get]
+func (s *APIServer) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOIDC(w) {
+		return
+	}
+
+	query := r.URL.Query()
+	login, err := s.storage.TakeOIDCLogin(r.Context(), query.Get("state"))
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get SSO login: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
+	if query.Get("state") == "" || login == nil {
+		respondWithError(w, http.StatusBadRequest, "Unknown or expired login, start again")
+		return
+	}
+	fail := func(loginErr *loginError) {
+		if login.ReturnTo != "" {
+			returnToLogin(w, r, login.ReturnTo, url.Values{"error": {loginErr.message}})
+			return
+		}
+		respondWithError(w, loginErr.status, loginErr.message)
+	}
+	if errCode := query.Get("error"); errCode != "" {
+		fail(&loginError{http.StatusUnauthorized, fmt.Sprintf("Identity provider refused the login: %s %s", errCode, query.Get("error_description"))})
+		return
+	}
+	if query.Get("code") == "" {
+		fail(&loginError{http.StatusBadRequest, "code is required"})
+		return
+	}
+
+	identity, err := s.oidc.Exchange(r.Context(), query.Get("code"), login.CodeVerifier, login.Nonce)
+	response, loginErr := s.oidcToken(r, identity, err)
+	if loginErr != nil {
+		fail(loginErr)
+		return
+	}
+
+	if login.ReturnTo == "" {
+		respondWithJSON(w, http.StatusOK, response)
+		return
+	}
+	returnToLogin(w, r, login.ReturnTo, url.Values{
+		"token":              {response.Token},
+		"expires_in":         {strconv.Itoa(response.ExpiresIn)},
+		"username":           {response.Username},
+		"refresh_token":      {response.RefreshToken},
+		"refresh_expires_in": {strconv.Itoa(response.RefreshExpiresIn)},
+	})
+}
+
+// handleOIDCDevice godoc
+// @Summary Start a device login
+// @Description Starts the device authorization flow at the identity provider for osctl. The user opens verification_uri and enters user_code, osctl polls /auth/oidc/device/token with device_code.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} oidc.DeviceLogin
+// @Failure 404 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /auth/oidc/device [post]
+func (s *APIServer) handleOIDCDevice(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOIDC(w) {
+		return
+	}
+	if !s.oidc.SupportsDeviceFlow() {
+		respondWithError(w, http.StatusNotFound, "The identity provider does not support device logins")
+		return
+	}
+
+	login, err := s.oidc.StartDeviceLogin(r.Context())
+	if err != nil {
+		log.Printf("[Centro REST] Failed to start device login: %v", err)
+		respondWithError(w, http.StatusBadGateway, "Failed to start device login at the identity provider")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, login)
+}
+
+// handleOIDCDeviceToken godoc
+// @Summary Poll a device login
+// @Description Returns a token once the user finished the device login. Until then it fails with error authorization_pending, or slow_down if polled too often.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body OIDCDeviceTokenRequest true "Device code"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /auth/oidc/device/token [post]
+func (s *APIServer) handleOIDCDeviceToken(w http.ResponseWriter, r *http.Request) {
+	if !s.requireOIDC(w) {
+		return
+	}
+
+	var req OIDCDeviceTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DeviceCode == "" {
+		respondWithError(w, http.StatusBadRequest, "device_code is required")
+		return
+	}
+
+	identity, err := s.oidc.PollDeviceLogin(r.Context(), req.DeviceCode)
+	var deviceErr *oidc.DeviceError
+	switch {
+	case errors.Is(err, oidc.ErrAuthorizationPending), errors.Is(err, oidc.ErrSlowDown):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.As(err, &deviceErr):
+		respondWithJSON(w, http.StatusBadRequest, map[string]string{
+			"error":             deviceErr.Code,
+			"error_description": deviceErr.Description,
+		})
+		return
+	}
+
+	response, loginErr := s.oidcToken(r, identity, err)
+	if loginErr != nil {
+		respondWithError(w, loginErr.status, loginErr.message)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/open-scheduler/centro/oidc"
+	"github.com/open-scheduler/centro/oidc/oidctest"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+)
+
+func newOIDCTestServer(t *testing.T) *APIServer {
+	t.Helper()
+	idp, err := oidctest.NewServer("centro", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(idp.Close)
+	idp.SetUser(oidctest.User{Subject: "user-1", Username: "alice", Groups: []string{"developers"}})
+
+	provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+		IssuerURL:   idp.Issuer(),
+		ClientID:    idp.ClientID,
+		RedirectURL: "https://centro.example.com/api/v1/auth/oidc/callback",
+		GroupRoles:  []oidc.GroupRole{{Group: "developers", Role: RoleOperator}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage, _ := etcdtest.NewStorage()
+	server := NewAPIServer(storage)
+	server.EnableOIDC(provider, DefaultSSOSessionTTL)
+	return server
+}
+
+// startOIDCLogin starts a login at Centro, lets the identity provider
+// authorize it and returns the query Centro's callback would be called with
+func startOIDCLogin(t *testing.T, s *APIServer) url.Values {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	s.GetRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/login", nil))
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("login returned status %d: %s", recorder.Code, recorder.Body)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(recorder.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("identity provider returned status %d", resp.StatusCode)
+	}
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location.Query()
+}
+
+func oidcCallback(s *APIServer, code, state string) *httptest.ResponseRecorder {
+	query := url.Values{"code": {code}, "state": {state}}
+	recorder := httptest.NewRecorder()
+	s.GetRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/callback?"+query.Encode(), nil))
+	return recorder
+}
+
+func TestOIDCCallback(t *testing.T) {
+	s := newOIDCTestServer(t)
+	callback := startOIDCLogin(t, s)
+
+	recorder := oidcCallback(s, callback.Get("code"), callback.Get("state"))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("callback returned status %d: %s", recorder.Code, recorder.Body)
+	}
+	var response LoginResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Username != "alice" || response.Token == "" {
+		t.Errorf("response = %+v, want a token for alice", response)
+	}
+
+	// The state is used up by the first callback
+	if recorder := oidcCallback(s, callback.Get("code"), callback.Get("state")); recorder.Code != http.StatusBadRequest {
+		t.Errorf("replayed callback returned status %d, want 400", recorder.Code)
+	}
+}
+
+func TestOIDCCallbackRejectsUnknownState(t *testing.T) {
+	s := newOIDCTestServer(t)
+	callback := startOIDCLogin(t, s)
+
+	for _, state := range []string{"", "not-a-login"} {
+		if recorder := oidcCallback(s, callback.Get("code"), state); recorder.Code != http.StatusBadRequest {
+			t.Errorf("callback with state %q returned status %d, want 400", state, recorder.Code)
+		}
+	}
+}
+
+func TestOIDCCallbackRejectsCodeOfAnotherLogin(t *testing.T) {
+	s := newOIDCTestServer(t)
+	first := startOIDCLogin(t, s)
+	second := startOIDCLogin(t, s)
+
+	// The code of the first login was issued for its code challenge, so the
+	// verifier stored with the second login does not match
+	if recorder := oidcCallback(s, first.Get("code"), second.Get("state")); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("callback with the code of another login returned status %d, want 401", recorder.Code)
+	}
+}
+
+func TestSSORefreshKeepsEndOfLogin(t *testing.T) {
+	s := newOIDCTestServer(t)
+	s.ssoSessionTTL = time.Hour
+	callback := startOIDCLogin(t, s)
+
+	recorder := oidcCallback(s, callback.Get("code"), callback.Get("state"))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("callback returned status %d: %s", recorder.Code, recorder.Body)
+	}
+	var login LoginResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&login); err != nil {
+		t.Fatal(err)
+	}
+	if login.RefreshExpiresIn > 3600 || login.RefreshExpiresIn < 3590 {
+		t.Fatalf("SSO refresh token expires in %ds, want the session TTL of 3600s", login.RefreshExpiresIn)
+	}
+
+	stored, err := s.storage.GetRefreshToken(context.Background(), refreshTokenID(login.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(RefreshRequest{RefreshToken: login.RefreshToken})
+	recorder = httptest.NewRecorder()
+	s.GetRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("refresh returned status %d: %s", recorder.Code, recorder.Body)
+	}
+	var refreshed LoginResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&refreshed); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := s.storage.GetRefreshToken(context.Background(), refreshTokenID(refreshed.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rotated.ExpiresAt.Equal(stored.ExpiresAt) {
+		t.Errorf("refreshing moved the end of the SSO login from %s to %s", stored.ExpiresAt, rotated.ExpiresAt)
+	}
+}
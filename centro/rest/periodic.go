@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// periodicLaunches returns the launches of a periodic deployment, newest first,
+// with the current status of each child deployment
+func (s *APIServer) periodicLaunches(ctx context.Context, state *etcdstorage.PeriodicState) []map[string]interface{} {
+	launches := make([]map[string]interface{}, 0, len(state.Launches))
+	for i := len(state.Launches) - 1; i >= 0; i-- {
+		launch := state.Launches[i]
+		entry := map[string]interface{}{
+			"deployment_id": launch.DeploymentID,
+			"spec_revision": launch.SpecRevision,
+			"scheduled_at":  launch.ScheduledAt,
+			"launched_at":   launch.LaunchedAt,
+		}
+		if launch.Skipped != "" {
+			entry["status"] = "skipped"
+			entry["detail"] = launch.Skipped
+		} else {
+			entry["status"], entry["detail"] = s.childStatus(ctx, launch.DeploymentID)
+		}
+		launches = append(launches, entry)
+	}
+	return launches
+}
+
+// childStatus returns the status of a child deployment wherever it currently is
+func (s *APIServer) childStatus(ctx context.Context, deploymentID string) (string, string) {
+	if record, err := s.storage.GetDeploymentHistory(ctx, deploymentID); err == nil && record != nil {
+		return record.Status, record.Detail
+	}
+	if status, err := s.storage.GetDeploymentActive(ctx, deploymentID); err == nil && status != nil {
+		return status.Status, status.Detail
+	}
+	if deployment, err := s.storage.GetFailedDeployment(ctx, deploymentID); err == nil && deployment != nil {
+		return "retrying", ""
+	}
+	if deployment, err := s.storage.GetQueueDeployment(ctx, deploymentID); err == nil && deployment != nil {
+		return "queued", ""
+	}
+	return "unknown", ""
+}
+
+// respondWithPeriodic writes the details of a periodic deployment and its launches
+func (s *APIServer) respondWithPeriodic(ctx context.Context, w http.ResponseWriter, spec *etcdstorage.DeploymentSpec, events []*etcdstorage.DeploymentEvent) {
+	response := map[string]interface{}{
+		"deployment_id": spec.DeploymentID,
+		"status":        "periodic",
+		"detail":        "Waiting for the reconciler to schedule the first launch",
+		"revision":      spec.Revision,
+		"updated_at":    spec.UpdatedAt,
+		"periodic":      spec.Deployment.Periodic,
+		"launches":      []map[string]interface{}{},
+		"deployment":    spec.Deployment,
+		"events":        events,
+	}
+	withAuthors(response, spec)
+
+	state, err := s.storage.GetPeriodicState(ctx, spec.DeploymentID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get periodic state: %v", err)
+	}
+	if state != nil {
+		response["detail"] = "Next launch at " + state.NextLaunch.Format("2006-01-02 15:04:05 MST")
+		response["next_launch"] = state.NextLaunch
+		response["launches"] = s.periodicLaunches(ctx, state)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
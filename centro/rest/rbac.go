@@ -0,0 +1,394 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// Permissions are written as resource:verb, for example deployments:update.
+// Either part may be * to match every resource or verb.
+const (
+	VerbGet    = "get"
+	VerbList   = "list"
+	VerbCreate = "create"
+	VerbUpdate = "update"
+	VerbDelete = "delete"
+)
+
+var (
+	Verbs     = []string{VerbGet, VerbList, VerbCreate, VerbUpdate, VerbDelete}
+	Resources = []string{"deployments", "instances", "nodes", "events", "stats", "users", "roles", "serviceaccounts", "tokens", "jointokens", "audit", "secrets", "configs"}
+)
+
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+var viewerPermissions = []string{
+	"deployments:get", "deployments:list",
+	"instances:get", "instances:list",
+	"nodes:get", "nodes:list",
+	"events:list",
+	"stats:get",
+	"configs:get", "configs:list",
+}
+
+// builtinRoles can be assigned to users but not changed
+var builtinRoles = map[string]*etcdstorage.Role{
+	RoleViewer: {
+		Name:        RoleViewer,
+		Description: "Read deployments, instances, nodes, events and configs",
+		Permissions: viewerPermissions,
+	},
+	RoleOperator: {
+		Name:        RoleOperator,
+		Description: "Viewer, plus submit and manage deployments, secrets and configs and approve and decommission nodes",
+		Permissions: append([]string{
+			"deployments:create", "deployments:update", "deployments:delete",
+			"nodes:update",
+			"secrets:get", "secrets:list", "secrets:create", "secrets:update", "secrets:delete",
+			"configs:create", "configs:update", "configs:delete",
+		}, viewerPermissions...),
+	},
+	RoleAdmin: {
+		Name:        RoleAdmin,
+		Description: "Everything, including users and roles",
+		Permissions: []string{"*:*"},
+	},
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// permission joins a resource and a verb
+func permission(resource, verb string) string {
+	return resource + ":" + verb
+}
+
+func splitPermission(perm string) (resource, verb string) {
+	resource, verb, _ = strings.Cut(perm, ":")
+	return resource, verb
+}
+
+// hasPermission reports whether one of the granted permissions matches perm
+func hasPermission(granted []string, perm string) bool {
+	resource, verb := splitPermission(perm)
+	for _, grant := range granted {
+		grantResource, grantVerb := splitPermission(grant)
+		if (grantResource == "*" || grantResource == resource) && (grantVerb == "*" || grantVerb == verb) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWriteVerb(verb string) bool {
+	return verb == VerbCreate || verb == VerbUpdate || verb == VerbDelete
+}
+
+// validatePermission checks that perm names a known resource and verb
+func validatePermission(perm string) error {
+	resource, verb, ok := strings.Cut(perm, ":")
+	if !ok {
+		return fmt.Errorf("permission %q must be written as resource:verb", perm)
+	}
+	if resource != "*" && !contains(Resources, resource) {
+		return fmt.Errorf("permission %q has unknown resource %s, use one of %v or *", perm, resource, Resources)
+	}
+	if verb != "*" && !contains(Verbs, verb) {
+		return fmt.Errorf("permission %q has unknown verb %s, use one of %v or *", perm, verb, Verbs)
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// getRole returns a built-in or custom role, or nil if there is none with the name
+func (s *APIServer) getRole(ctx context.Context, name string) (*etcdstorage.Role, error) {
+	if role, ok := builtinRoles[name]; ok {
+		return role, nil
+	}
+	return s.storage.GetRole(ctx, name)
+}
+
+// requestRole returns the role of the user that made the request. Users
+// whose role was deleted get no permissions.
+func (s *APIServer) requestRole(r *http.Request) (*etcdstorage.Role, error) {
+	if role, ok := r.Context().Value("role").(*etcdstorage.Role); ok {
+		return role, nil
+	}
+	claims, ok := r.Context().Value("claims").(*Claims)
+	if !ok {
+		return &etcdstorage.Role{}, nil
+	}
+	role, err := s.getRole(r.Context(), claims.Role)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return &etcdstorage.Role{Name: claims.Role}, nil
+	}
+	return role, nil
+}
+
+// requestScopes returns the scopes of the API token the request was made
+// with, or nil for tokens without scopes and logins
+func requestScopes(r *http.Request) []string {
+	if claims, ok := r.Context().Value("claims").(*Claims); ok {
+		return claims.Scopes
+	}
+	return nil
+}
+
+// denial explains why a role and token scopes do not grant perm, or returns
+// "" if they do
+func denial(role *etcdstorage.Role, scopes []string, perm string) string {
+	if !hasPermission(role.Permissions, perm) {
+		return fmt.Sprintf("role %s is missing permission %s", role.Name, perm)
+	}
+	if len(scopes) > 0 && !hasPermission(scopes, perm) {
+		return fmt.Sprintf("the scopes of the token do not include permission %s", perm)
+	}
+	return ""
+}
+
+// can reports whether the user that made the request has a permission
+func (s *APIServer) can(r *http.Request, perm string) (bool, error) {
+	role, err := s.requestRole(r)
+	if err != nil {
+		return false, err
+	}
+	return denial(role, requestScopes(r), perm) == "", nil
+}
+
+func respondForbidden(w http.ResponseWriter, reason, perm string) {
+	respondWithJSON(w, http.StatusForbidden, map[string]string{
+		"error":      "Forbidden: " + reason,
+		"permission": perm,
+	})
+}
+
+// canGrantRole reports whether the user that made the request holds every
+// permission of role, so that users cannot give more access than they have
+// to users, service accounts or tokens
+func (s *APIServer) canGrantRole(r *http.Request, role *etcdstorage.Role) (bool, error) {
+	own, err := s.requestRole(r)
+	if err != nil {
+		return false, err
+	}
+	scopes := requestScopes(r)
+	for _, perm := range role.Permissions {
+		if denial(own, scopes, perm) != "" {
+			return false, nil
+		}
+	}
+	if len(own.Clusters) > 0 && (len(role.Clusters) == 0 || !inScope(own, role.Clusters)) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// checkGrantRole writes 403 and returns false unless the user that made the
+// request can grant role
+func (s *APIServer) checkGrantRole(w http.ResponseWriter, r *http.Request, role *etcdstorage.Role) bool {
+	ok, err := s.canGrantRole(r, role)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get role: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return false
+	}
+	if !ok {
+		respondWithError(w, http.StatusForbidden, fmt.Sprintf("Forbidden: role %s has permissions you do not have", role.Name))
+		return false
+	}
+	return true
+}
+
+// authorize wraps a handler so that it only runs for users whose role has
+// perm. Write permissions of roles limited to clusters are also checked
+// against the deployment or node the route refers to.
+func (s *APIServer) authorize(perm string, handler http.HandlerFunc) http.HandlerFunc {
+	resource, verb := splitPermission(perm)
+	return func(w http.ResponseWriter, r *http.Request) {
+		auditPermission(r, perm)
+		role, err := s.requestRole(r)
+		if err != nil {
+			log.Printf("[Centro REST] Failed to get role: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to check permissions")
+			return
+		}
+		if reason := denial(role, requestScopes(r), perm); reason != "" {
+			respondForbidden(w, reason, perm)
+			return
+		}
+
+		if len(role.Clusters) > 0 && isWriteVerb(verb) {
+			clusters, err := s.routeClusters(r.Context(), resource, mux.Vars(r))
+			if err != nil {
+				log.Printf("[Centro REST] Failed to check cluster scope: %v", err)
+				respondWithError(w, http.StatusInternalServerError, "Failed to check permissions")
+				return
+			}
+			if clusters != nil && !respondIfOutOfScope(w, role, perm, clusters) {
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), "role", role)
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// routeClusters returns the clusters of the deployment or node a route refers
+// to, or nil when the route does not refer to one that exists. A deployment
+// without selected clusters may run on any cluster.
+func (s *APIServer) routeClusters(ctx context.Context, resource string, vars map[string]string) ([]string, error) {
+	id := vars["id"]
+	if id == "" {
+		return nil, nil
+	}
+
+	switch resource {
+	case "deployments":
+		spec, err := s.storage.GetDeploymentSpec(ctx, id)
+		if err != nil || spec == nil {
+			return nil, err
+		}
+		return clustersOf(spec.Deployment.GetSelectedClusters()), nil
+	case "nodes":
+		node, err := s.storage.GetNode(ctx, id)
+		if err != nil || node == nil {
+			return nil, err
+		}
+		return []string{node.ClusterName}, nil
+	}
+	return nil, nil
+}
+
+// clustersOf returns selected clusters, or [""] for "any cluster" so that it
+// is never inside a cluster scope
+func clustersOf(selected []string) []string {
+	if len(selected) == 0 {
+		return []string{""}
+	}
+	return selected
+}
+
+// inScope reports whether every cluster is one of the clusters of the role
+func inScope(role *etcdstorage.Role, clusters []string) bool {
+	if len(role.Clusters) == 0 {
+		return true
+	}
+	for _, cluster := range clusters {
+		if !contains(role.Clusters, cluster) {
+			return false
+		}
+	}
+	return true
+}
+
+// respondIfOutOfScope writes 403 and returns false when clusters are not all
+// in the scope of the role
+func respondIfOutOfScope(w http.ResponseWriter, role *etcdstorage.Role, perm string, clusters []string) bool {
+	if inScope(role, clusters) {
+		return true
+	}
+	respondWithJSON(w, http.StatusForbidden, map[string]interface{}{
+		"error": fmt.Sprintf("Forbidden: role %s has permission %s only for clusters %s, set selected_clusters accordingly",
+			role.Name, perm, strings.Join(role.Clusters, ", ")),
+		"permission": perm,
+		"clusters":   role.Clusters,
+	})
+	return false
+}
+
+// checkClusterScope is used by handlers that create deployments, whose
+// clusters are only known from the request body
+func (s *APIServer) checkClusterScope(w http.ResponseWriter, r *http.Request, perm string, selected []string) bool {
+	role, err := s.requestRole(r)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get role: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return false
+	}
+	return respondIfOutOfScope(w, role, perm, clustersOf(selected))
+}
+
+// handleCanI godoc
+// @Summary Check your permissions
+// @Description Check whether your role allows a verb on a resource, optionally in a cluster. Without verb and resource the permissions of your role are listed.
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Param verb query string false "get, list, create, update or delete"
+// @Param resource query string false "deployments, instances, nodes, events, stats, users, roles, serviceaccounts or tokens"
+// @Param cluster query string false "Cluster the verb is checked for"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /auth/can-i [get]
+func (s *APIServer) handleCanI(w http.ResponseWriter, r *http.Request) {
+	role, err := s.requestRole(r)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get role: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+
+	verb := r.URL.Query().Get("verb")
+	resource := r.URL.Query().Get("resource")
+	scopes := requestScopes(r)
+	if verb == "" && resource == "" {
+		permissions := append([]string(nil), role.Permissions...)
+		sort.Strings(permissions)
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"role":        role.Name,
+			"permissions": permissions,
+			"clusters":    role.Clusters,
+			"scopes":      scopes,
+		})
+		return
+	}
+
+	perm := permission(resource, verb)
+	if err := validatePermission(perm); err != nil || verb == "*" || resource == "*" {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Set verb to one of %v and resource to one of %v", Verbs, Resources))
+		return
+	}
+
+	reason := denial(role, scopes, perm)
+	allowed := reason == ""
+	switch {
+	case !allowed:
+	case len(role.Clusters) > 0 && isWriteVerb(verb):
+		cluster := r.URL.Query().Get("cluster")
+		if cluster == "" {
+			reason = fmt.Sprintf("only in clusters %s", strings.Join(role.Clusters, ", "))
+		} else if !contains(role.Clusters, cluster) {
+			allowed = false
+			reason = fmt.Sprintf("role %s has permission %s only in clusters %s", role.Name, perm, strings.Join(role.Clusters, ", "))
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"allowed":    allowed,
+		"permission": perm,
+		"role":       role.Name,
+		"reason":     reason,
+	})
+}
@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+)
+
+// requestAs returns a request made by a user with the role and token scopes
+// of claims, as the auth middleware passes it on
+func requestAs(claims *Claims, method, target string, vars map[string]string) *http.Request {
+	r := httptest.NewRequest(method, target, nil)
+	if vars != nil {
+		r = mux.SetURLVars(r, vars)
+	}
+	return r.WithContext(context.WithValue(r.Context(), "claims", claims))
+}
+
+func newRBACServer(t *testing.T) (*APIServer, *etcdstorage.Storage) {
+	t.Helper()
+	storage, _ := etcdtest.NewStorage()
+	ctx := context.Background()
+	roles := []*etcdstorage.Role{
+		{Name: "eu-operator", Permissions: []string{"deployments:*", "nodes:update"}, Clusters: []string{"eu"}},
+		{Name: "deployer", Permissions: []string{"deployments:create", "deployments:get"}},
+	}
+	for _, role := range roles {
+		if err := storage.SaveRole(ctx, role); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	eu := batchDeployment("eu-report", "report:1")
+	eu.SelectedClusters = []string{"eu"}
+	anywhere := batchDeployment("report", "report:1")
+	for _, deployment := range []*etcdstorage.DeploymentSpec{{Deployment: eu}, {Deployment: anywhere}} {
+		if _, _, err := storage.SubmitDeployment(ctx, deployment, "alice"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewAPIServer(storage), storage
+}
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		granted []string
+		perm    string
+		want    bool
+	}{
+		{granted: []string{"deployments:get"}, perm: "deployments:get", want: true},
+		{granted: []string{"deployments:get"}, perm: "deployments:update", want: false},
+		{granted: []string{"deployments:*"}, perm: "deployments:delete", want: true},
+		{granted: []string{"*:get"}, perm: "nodes:get", want: true},
+		{granted: []string{"*:get"}, perm: "nodes:update", want: false},
+		{granted: []string{"*:*"}, perm: "roles:delete", want: true},
+		{granted: []string{"deployments"}, perm: "deployments:get", want: false},
+		{granted: nil, perm: "deployments:get", want: false},
+	}
+	for _, tt := range tests {
+		if got := hasPermission(tt.granted, tt.perm); got != tt.want {
+			t.Errorf("hasPermission(%v, %s) = %v, want %v", tt.granted, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	s, _ := newRBACServer(t)
+
+	tests := []struct {
+		name   string
+		claims *Claims
+		perm   string
+		vars   map[string]string
+		want   int
+	}{
+		{name: "built-in role with permission", claims: &Claims{Role: RoleViewer}, perm: "deployments:get", want: http.StatusOK},
+		{name: "built-in role without permission", claims: &Claims{Role: RoleViewer}, perm: "deployments:delete", want: http.StatusForbidden},
+		{name: "custom role", claims: &Claims{Role: "deployer"}, perm: "deployments:create", want: http.StatusOK},
+		{name: "deleted role", claims: &Claims{Role: "removed"}, perm: "deployments:get", want: http.StatusForbidden},
+		{name: "token scopes within the role", claims: &Claims{Role: RoleAdmin, Scopes: []string{"deployments:get"}}, perm: "deployments:get", want: http.StatusOK},
+		{name: "token scopes limit the role", claims: &Claims{Role: RoleAdmin, Scopes: []string{"deployments:get"}}, perm: "deployments:delete", want: http.StatusForbidden},
+		{name: "token scopes do not extend the role", claims: &Claims{Role: RoleViewer, Scopes: []string{"*:*"}}, perm: "deployments:delete", want: http.StatusForbidden},
+		{name: "scoped role, deployment in its cluster", claims: &Claims{Role: "eu-operator"}, perm: "deployments:update", vars: map[string]string{"id": "eu-report"}, want: http.StatusOK},
+		{name: "scoped role, deployment on any cluster", claims: &Claims{Role: "eu-operator"}, perm: "deployments:update", vars: map[string]string{"id": "report"}, want: http.StatusForbidden},
+		{name: "scoped role reads outside its clusters", claims: &Claims{Role: "eu-operator"}, perm: "deployments:get", vars: map[string]string{"id": "report"}, want: http.StatusOK},
+		{name: "scoped role, unknown deployment", claims: &Claims{Role: "eu-operator"}, perm: "deployments:delete", vars: map[string]string{"id": "unknown"}, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := s.authorize(tt.perm, func(w http.ResponseWriter, r *http.Request) {
+				// Handlers get the role authorize resolved
+				if _, ok := r.Context().Value("role").(*etcdstorage.Role); !ok {
+					t.Error("role is not passed to the handler")
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+			recorder := httptest.NewRecorder()
+			handler(recorder, requestAs(tt.claims, http.MethodGet, "/", tt.vars))
+			if recorder.Code != tt.want {
+				t.Errorf("got status %d, want %d: %s", recorder.Code, tt.want, recorder.Body)
+			}
+		})
+	}
+}
+
+func TestCanGrantRole(t *testing.T) {
+	s, _ := newRBACServer(t)
+
+	tests := []struct {
+		name   string
+		claims *Claims
+		role   *etcdstorage.Role
+		want   bool
+	}{
+		{name: "admin grants admin", claims: &Claims{Role: RoleAdmin}, role: builtinRoles[RoleAdmin], want: true},
+		{name: "operator grants viewer", claims: &Claims{Role: RoleOperator}, role: builtinRoles[RoleViewer], want: true},
+		{name: "operator grants admin", claims: &Claims{Role: RoleOperator}, role: builtinRoles[RoleAdmin], want: false},
+		{name: "scoped token grants more than its scopes", claims: &Claims{Role: RoleAdmin, Scopes: []string{"deployments:get"}}, role: builtinRoles[RoleViewer], want: false},
+		{name: "scoped role grants the same clusters", claims: &Claims{Role: "eu-operator"}, role: &etcdstorage.Role{Name: "eu-viewer", Permissions: []string{"deployments:get"}, Clusters: []string{"eu"}}, want: true},
+		{name: "scoped role grants other clusters", claims: &Claims{Role: "eu-operator"}, role: &etcdstorage.Role{Name: "us-viewer", Permissions: []string{"deployments:get"}, Clusters: []string{"us"}}, want: false},
+		{name: "scoped role grants every cluster", claims: &Claims{Role: "eu-operator"}, role: &etcdstorage.Role{Name: "viewer", Permissions: []string{"deployments:get"}}, want: false},
+		{name: "role without permissions", claims: &Claims{Role: RoleViewer}, role: &etcdstorage.Role{Name: "removed"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.canGrantRole(requestAs(tt.claims, http.MethodPost, "/", nil), tt.role)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckClusterScope(t *testing.T) {
+	s, _ := newRBACServer(t)
+
+	tests := []struct {
+		name     string
+		claims   *Claims
+		selected []string
+		want     bool
+	}{
+		{name: "unscoped role, any cluster", claims: &Claims{Role: RoleOperator}, want: true},
+		{name: "scoped role, its cluster", claims: &Claims{Role: "eu-operator"}, selected: []string{"eu"}, want: true},
+		{name: "scoped role, any cluster", claims: &Claims{Role: "eu-operator"}, want: false},
+		{name: "scoped role, other cluster", claims: &Claims{Role: "eu-operator"}, selected: []string{"us"}, want: false},
+		{name: "scoped role, one cluster out of scope", claims: &Claims{Role: "eu-operator"}, selected: []string{"eu", "us"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			got := s.checkClusterScope(recorder, requestAs(tt.claims, http.MethodPost, "/", nil), "deployments:create", tt.selected)
+			if got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			if !got && recorder.Code != http.StatusForbidden {
+				t.Errorf("got status %d, want 403", recorder.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,522 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/open-scheduler/centro/spec"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// handleUpdateDeployment godoc
+// @Summary Replace a deployment spec
+// @Description Replace the spec of a deployment and store it as a new numbered revision. Queued and retrying deployments switch to the new spec immediately, services roll it out to their replicas following their update strategy.
+// @Tags Deployments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Deployment ID"
+// @Param deployment body spec.SubmitDeploymentRequest true "Complete deployment spec"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /deployments/{id} [put]
+func (s *APIServer) handleUpdateDeployment(w http.ResponseWriter, r *http.Request) {
+	deploymentID := mux.Vars(r)["id"]
+
+	var req spec.SubmitDeploymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := context.Background()
+	current, ok := s.loadDeploymentSpec(ctx, w, deploymentID)
+	if !ok {
+		return
+	}
+
+	s.updateDeployment(ctx, w, r, current, &req)
+}
+
+// handlePatchDeployment godoc
+// @Summary Patch a deployment spec
+// @Description Change part of a deployment spec with a JSON merge patch (RFC 7386) using the field names of the submit request. null removes a field. The result is stored as a new numbered revision.
+// @Tags Deployments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Deployment ID"
+// @Param patch body map[string]interface{} true "Merge patch, e.g. {\"instance_config\": {\"image\": \"nginx:1.27\"}}"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /deployments/{id} [patch]
+func (s *APIServer) handlePatchDeployment(w http.ResponseWriter, r *http.Request) {
+	deploymentID := mux.Vars(r)["id"]
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body, expected a JSON merge patch object")
+		return
+	}
+
+	ctx := context.Background()
+	current, ok := s.loadDeploymentSpec(ctx, w, deploymentID)
+	if !ok {
+		return
+	}
+
+	var document map[string]interface{}
+	if len(current.Request) > 0 {
+		if err := json.Unmarshal(current.Request, &document); err != nil {
+			log.Printf("[Centro REST] Failed to decode stored request of deployment %s: %v", deploymentID, err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to patch deployment")
+			return
+		}
+	}
+
+	merged, err := json.Marshal(mergePatch(document, patch))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid merge patch")
+		return
+	}
+
+	var req spec.SubmitDeploymentRequest
+	if err := json.Unmarshal(merged, &req); err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Patched spec does not match the deployment API: %v", err))
+		return
+	}
+
+	s.updateDeployment(ctx, w, r, current, &req)
+}
+
+// loadDeploymentSpec returns the current spec of a deployment, or writes an
+// error response and returns false
+func (s *APIServer) loadDeploymentSpec(ctx context.Context, w http.ResponseWriter, deploymentID string) (*etcdstorage.DeploymentSpec, bool) {
+	current, err := s.storage.GetDeploymentSpec(ctx, deploymentID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get deployment spec: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get deployment")
+		return nil, false
+	}
+	if current == nil {
+		respondWithError(w, http.StatusNotFound, "Deployment not found")
+		return nil, false
+	}
+	return current, true
+}
+
+// updateDeployment validates the new spec of a deployment and stores it as the next revision
+func (s *APIServer) updateDeployment(ctx context.Context, w http.ResponseWriter, r *http.Request,
+	current *etcdstorage.DeploymentSpec, req *spec.SubmitDeploymentRequest) {
+	deploymentID := current.DeploymentID
+	if req.DeploymentId != "" && req.DeploymentId != deploymentID {
+		respondWithError(w, http.StatusBadRequest, "deployment_id cannot be changed")
+		return
+	}
+	req.DeploymentId = deploymentID
+
+	deployment := req.ToDeployment(deploymentID)
+	if errs := spec.ValidateDeployment(deployment); errs != nil {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+	if !s.checkClusterScope(w, r, "deployments:update", deployment.SelectedClusters) {
+		return
+	}
+	if !s.checkSecretReferences(w, r, deployment) {
+		return
+	}
+	if !s.checkConfigReferences(w, r, deployment) {
+		return
+	}
+
+	// The reconciler decides once whether a deployment waits for others
+	if !sameDependencies(current.Deployment.DependsOn, deployment.DependsOn) {
+		respondWithError(w, http.StatusBadRequest, "depends_on cannot be changed after the deployment was submitted")
+		return
+	}
+
+	hash, err := etcdstorage.DeploymentSpecHash(deployment)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to hash deployment spec: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update deployment")
+		return
+	}
+	if hash == current.SpecHash {
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"deployment_id": deploymentID,
+			"revision":      current.Revision,
+			"changed":       false,
+			"message":       "Deployment spec is unchanged",
+			"deployment":    current.Deployment,
+		})
+		return
+	}
+
+	request, err := json.Marshal(req)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to update deployment")
+		return
+	}
+	changes := etcdstorage.DiffRequests(current.Request, request)
+	author := requestAuthor(r)
+
+	updated, err := s.storage.UpdateDeploymentSpec(ctx, current, deployment, request, etcdstorage.RevisionChange{
+		Changes: changes,
+		Author:  author,
+	})
+	if errors.Is(err, etcdstorage.ErrSpecModified) {
+		respondWithError(w, http.StatusConflict, "Deployment was modified concurrently, retry the update")
+		return
+	}
+	if err != nil {
+		log.Printf("[Centro REST] Failed to update deployment %s: %v", deploymentID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update deployment")
+		return
+	}
+
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	event := &etcdstorage.DeploymentEvent{
+		Type:    etcdstorage.EventTypeNormal,
+		Reason:  "Updated",
+		Message: fmt.Sprintf("Spec updated to revision %d by %s (%s)", updated.Revision, author, strings.Join(fields, ", ")),
+		Source:  etcdstorage.EventSourceCentro,
+	}
+	if err := s.storage.SaveDeploymentEvent(ctx, deploymentID, event); err != nil {
+		log.Printf("[Centro REST] Failed to save deployment event: %v", err)
+	}
+
+	log.Printf("[Centro REST] Deployment %s updated to revision %d by %s", deploymentID, updated.Revision, author)
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"deployment_id": deploymentID,
+		"revision":      updated.Revision,
+		"changed":       true,
+		"changes":       changes,
+		"message":       fmt.Sprintf("Deployment updated to revision %d", updated.Revision),
+		"deployment":    deployment,
+	})
+}
+
+// handleListDeploymentRevisions godoc
+// @Summary List deployment revisions
+// @Description Get every stored revision of a deployment spec, oldest first, with the changed fields, author, time and status of each
+// @Tags Deployments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Deployment ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /deployments/{id}/revisions [get]
+func (s *APIServer) handleListDeploymentRevisions(w http.ResponseWriter, r *http.Request) {
+	deploymentID := mux.Vars(r)["id"]
+
+	ctx := context.Background()
+	current, ok := s.loadDeploymentSpec(ctx, w, deploymentID)
+	if !ok {
+		return
+	}
+
+	revisions, err := s.storage.GetDeploymentRevisions(ctx, deploymentID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get deployment revisions: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get deployment revisions")
+		return
+	}
+
+	state := s.rolloutState(ctx, current)
+	entries := make([]*revisionEntry, 0, len(revisions))
+	for _, revision := range revisions {
+		entries = append(entries, &revisionEntry{revision, revisionStatus(revision.Revision, current, state)})
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"deployment_id":    deploymentID,
+		"current_revision": current.Revision,
+		"revisions":        entries,
+		"count":            len(entries),
+	})
+}
+
+// handleGetDeploymentRevision godoc
+// @Summary Get a deployment revision
+// @Description Get a single stored revision of a deployment spec
+// @Tags Deployments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Deployment ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /deployments/{id}/revisions/{revision} [get]
+func (s *APIServer) handleGetDeploymentRevision(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	deploymentID := vars["id"]
+
+	number, err := strconv.ParseInt(vars["revision"], 10, 64)
+	if err != nil || number < 1 {
+		respondWithError(w, http.StatusBadRequest, "Invalid revision number")
+		return
+	}
+
+	ctx := context.Background()
+	current, ok := s.loadDeploymentSpec(ctx, w, deploymentID)
+	if !ok {
+		return
+	}
+
+	revision, err := s.storage.GetDeploymentRevision(ctx, deploymentID, number)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get deployment revision: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get deployment revision")
+		return
+	}
+	if revision == nil {
+		respondWithError(w, http.StatusNotFound, "Revision not found")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, &revisionEntry{revision, revisionStatus(number, current, s.rolloutState(ctx, current))})
+}
+
+// handleRollbackDeployment godoc
+// @Summary Roll back a deployment
+// @Description Restore the spec of an earlier revision as a new revision. The update strategy of the current spec is kept, so services roll the restored spec out like any other change. Without a revision the previous revision is restored. The restored spec is validated like an update and must have the same depends_on.
+// @Tags Deployments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Deployment ID"
+// @Param revision query int false "Revision to restore (default: the previous revision)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /deployments/{id}/rollback [post]
+func (s *APIServer) handleRollbackDeployment(w http.ResponseWriter, r *http.Request) {
+	deploymentID := mux.Vars(r)["id"]
+
+	ctx := context.Background()
+	current, ok := s.loadDeploymentSpec(ctx, w, deploymentID)
+	if !ok {
+		return
+	}
+
+	number := current.Revision - 1
+	if value := r.URL.Query().Get("revision"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid revision number")
+			return
+		}
+		number = parsed
+	}
+	if number < 1 {
+		respondWithError(w, http.StatusBadRequest, "Deployment has no earlier revision to roll back to")
+		return
+	}
+	if number == current.Revision {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Revision %d is the current revision", number))
+		return
+	}
+
+	revision, err := s.storage.GetDeploymentRevision(ctx, deploymentID, number)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get deployment revision: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to roll back deployment")
+		return
+	}
+	if revision == nil {
+		respondWithError(w, http.StatusNotFound, "Revision not found")
+		return
+	}
+
+	deployment, request, err := etcdstorage.RollbackSpec(current, revision)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to restore revision %d of deployment %s: %v", number, deploymentID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to roll back deployment")
+		return
+	}
+	// The restored spec is checked like an update, validation may have
+	// become stricter since the revision was stored
+	if errs := spec.ValidateDeployment(deployment); errs != nil {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+	if !s.checkClusterScope(w, r, "deployments:update", deployment.SelectedClusters) {
+		return
+	}
+	if !s.checkSecretReferences(w, r, deployment) {
+		return
+	}
+	if !s.checkConfigReferences(w, r, deployment) {
+		return
+	}
+	if !sameDependencies(current.Deployment.DependsOn, deployment.DependsOn) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Revision %d has other depends_on than the current spec, depends_on cannot be changed after the deployment was submitted", number))
+		return
+	}
+
+	hash, err := etcdstorage.DeploymentSpecHash(deployment)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to hash deployment spec: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to roll back deployment")
+		return
+	}
+	if hash == current.SpecHash {
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"deployment_id": deploymentID,
+			"revision":      current.Revision,
+			"changed":       false,
+			"message":       fmt.Sprintf("Current spec already matches revision %d", number),
+			"deployment":    current.Deployment,
+		})
+		return
+	}
+
+	author := requestAuthor(r)
+	changes := etcdstorage.DiffRequests(current.Request, request)
+	updated, err := s.storage.UpdateDeploymentSpec(ctx, current, deployment, request, etcdstorage.RevisionChange{
+		Changes:    changes,
+		Author:     author,
+		RollbackOf: number,
+	})
+	if errors.Is(err, etcdstorage.ErrSpecModified) {
+		respondWithError(w, http.StatusConflict, "Deployment was modified concurrently, retry the rollback")
+		return
+	}
+	if err != nil {
+		log.Printf("[Centro REST] Failed to roll back deployment %s: %v", deploymentID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to roll back deployment")
+		return
+	}
+
+	event := &etcdstorage.DeploymentEvent{
+		Type:    etcdstorage.EventTypeNormal,
+		Reason:  "RolledBack",
+		Message: fmt.Sprintf("Rolled back to the spec of revision %d as revision %d by %s", number, updated.Revision, author),
+		Source:  etcdstorage.EventSourceCentro,
+	}
+	if err := s.storage.SaveDeploymentEvent(ctx, deploymentID, event); err != nil {
+		log.Printf("[Centro REST] Failed to save deployment event: %v", err)
+	}
+
+	log.Printf("[Centro REST] Deployment %s rolled back to revision %d as revision %d by %s", deploymentID, number, updated.Revision, author)
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"deployment_id": deploymentID,
+		"revision":      updated.Revision,
+		"rollback_of":   number,
+		"changed":       true,
+		"changes":       changes,
+		"message":       fmt.Sprintf("Rolled back to revision %d as revision %d", number, updated.Revision),
+		"deployment":    deployment,
+	})
+}
+
+// revisionEntry is a stored revision together with its current status
+type revisionEntry struct {
+	*etcdstorage.DeploymentRevision
+	Status string `json:"status"`
+}
+
+// rolloutState returns the rollout state of a service, or nil for other deployments
+func (s *APIServer) rolloutState(ctx context.Context, current *etcdstorage.DeploymentSpec) *etcdstorage.ServiceState {
+	if !etcdstorage.IsManagedService(current.Deployment) {
+		return nil
+	}
+	state, err := s.storage.GetServiceState(ctx, current.DeploymentID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get service state: %v", err)
+	}
+	return state
+}
+
+// revisionStatus describes a revision: "current" or "superseded", and for
+// services also "rolling-out", "canary", "paused", "stable" (still run by the
+// replicas while a newer revision rolls out) or "failed"
+func revisionStatus(revision int64, current *etcdstorage.DeploymentSpec, state *etcdstorage.ServiceState) string {
+	if state != nil {
+		for _, failed := range state.FailedRevisions {
+			if failed == revision {
+				return "failed"
+			}
+		}
+	}
+
+	if revision == current.Revision {
+		if state == nil || state.TargetRevision != revision {
+			return "current"
+		}
+		switch state.Status {
+		case etcdstorage.RolloutStatusProgressing, etcdstorage.RolloutStatusReverting:
+			return "rolling-out"
+		case etcdstorage.RolloutStatusCanary:
+			return "canary"
+		case etcdstorage.RolloutStatusPaused:
+			return "paused"
+		}
+		return "current"
+	}
+
+	if state != nil && revision == state.StableRevision {
+		return "stable"
+	}
+	return "superseded"
+}
+
+// requestAuthor returns the user a request was authenticated as
+func requestAuthor(r *http.Request) string {
+	if claims, ok := r.Context().Value("claims").(*Claims); ok && claims.Username != "" {
+		return claims.Username
+	}
+	return "unknown"
+}
+
+// withAuthors adds who created and last updated a deployment to its entry.
+// Replicas and launches have no spec of their own and are left as they are.
+func withAuthors(entry map[string]interface{}, stored *etcdstorage.DeploymentSpec) map[string]interface{} {
+	if stored != nil {
+		entry["created_by"] = stored.CreatedBy
+		entry["updated_by"] = stored.UpdatedBy
+	}
+	return entry
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to target
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
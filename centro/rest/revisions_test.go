@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+	pb "github.com/open-scheduler/proto"
+)
+
+func batchDeployment(id, image string) *pb.Deployment {
+	return &pb.Deployment{
+		DeploymentId:   id,
+		DeploymentName: id,
+		DeploymentType: "batch",
+		DriverType:     "podman",
+		InstanceConfig: &pb.InstanceSpec{ImageName: image},
+	}
+}
+
+// storeRevisions stores each deployment as the next revision of its ID
+func storeRevisions(t *testing.T, storage *etcdstorage.Storage, deployments ...*pb.Deployment) {
+	t.Helper()
+	ctx := context.Background()
+	if _, _, err := storage.SubmitDeployment(ctx, &etcdstorage.DeploymentSpec{Deployment: deployments[0]}, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	for _, deployment := range deployments[1:] {
+		current, err := storage.GetDeploymentSpec(ctx, deployment.DeploymentId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := storage.UpdateDeploymentSpec(ctx, current, deployment, nil, etcdstorage.RevisionChange{Author: "alice"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func rollback(s *APIServer, deploymentID, revision string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/deployments/"+deploymentID+"/rollback?revision="+revision, nil)
+	r = mux.SetURLVars(r, map[string]string{"id": deploymentID})
+	r = r.WithContext(context.WithValue(r.Context(), "role", builtinRoles[RoleAdmin]))
+	recorder := httptest.NewRecorder()
+	s.handleRollbackDeployment(recorder, r)
+	return recorder
+}
+
+func TestRollbackDeployment(t *testing.T) {
+	storage, _ := etcdtest.NewStorage()
+	s := NewAPIServer(storage)
+
+	// Revision 1 was stored before the driver was checked
+	invalid := batchDeployment("report", "report:1")
+	invalid.DriverType = "docker"
+	withDependency := batchDeployment("report", "report:2")
+	withDependency.DependsOn = []*pb.Dependency{{DeploymentId: "import"}}
+	storeRevisions(t, storage,
+		invalid,
+		withDependency,
+		batchDeployment("report", "report:3"),
+		batchDeployment("report", "report:4"),
+	)
+
+	tests := []struct {
+		name     string
+		revision string
+		want     int
+	}{
+		{name: "invalid spec", revision: "1", want: http.StatusUnprocessableEntity},
+		{name: "other depends_on", revision: "2", want: http.StatusBadRequest},
+		{name: "current revision", revision: "4", want: http.StatusBadRequest},
+		{name: "missing revision", revision: "9", want: http.StatusNotFound},
+		{name: "valid revision", revision: "3", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if recorder := rollback(s, "report", tt.revision); recorder.Code != tt.want {
+				t.Errorf("rollback to revision %s returned status %d, want %d: %s", tt.revision, recorder.Code, tt.want, recorder.Body)
+			}
+		})
+	}
+
+	current, err := storage.GetDeploymentSpec(context.Background(), "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Revision != 5 || current.Deployment.InstanceConfig.ImageName != "report:3" {
+		t.Errorf("current spec is revision %d with image %s, want revision 5 with report:3", current.Revision, current.Deployment.InstanceConfig.ImageName)
+	}
+}
@@ -0,0 +1,243 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+type RoleRequest struct {
+	Description string   `json:"description,omitempty" example:"Deploy to staging"`
+	Permissions []string `json:"permissions" example:"deployments:*,nodes:get,nodes:list"`
+	// Clusters limits the write permissions to deployments and nodes of these clusters
+	Clusters []string `json:"clusters,omitempty" example:"staging"`
+}
+
+func roleResponse(role *etcdstorage.Role) map[string]interface{} {
+	_, builtin := builtinRoles[role.Name]
+	response := map[string]interface{}{
+		"name":        role.Name,
+		"description": role.Description,
+		"permissions": role.Permissions,
+		"clusters":    role.Clusters,
+		"builtin":     builtin,
+	}
+	if !builtin {
+		response["created_at"] = role.CreatedAt
+		response["updated_at"] = role.UpdatedAt
+	}
+	return response
+}
+
+// handleListRoles godoc
+// @Summary List roles
+// @Description List the built-in roles (viewer, operator, admin) and the custom roles
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Router /roles [get]
+func (s *APIServer) handleListRoles(w http.ResponseWriter, r *http.Request) {
+	custom, err := s.storage.GetAllRoles(context.Background())
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get roles: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get roles")
+		return
+	}
+
+	roles := make([]map[string]interface{}, 0, len(builtinRoles)+len(custom))
+	for _, name := range []string{RoleViewer, RoleOperator, RoleAdmin} {
+		roles = append(roles, roleResponse(builtinRoles[name]))
+	}
+	for _, role := range custom {
+		roles = append(roles, roleResponse(role))
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"roles": roles,
+		"count": len(roles),
+	})
+}
+
+// handleGetRole godoc
+// @Summary Get a role
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /roles/{name} [get]
+func (s *APIServer) handleGetRole(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	role, err := s.getRole(context.Background(), name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get role %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get role")
+		return
+	}
+	if role == nil {
+		respondWithError(w, http.StatusNotFound, "Role not found")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, roleResponse(role))
+}
+
+// handleSaveRole godoc
+// @Summary Create or replace a custom role
+// @Description Permissions are written as resource:verb, e.g. deployments:update, and either part may be *. Resources are deployments, instances, nodes, events, stats, users, roles, serviceaccounts and tokens; verbs are get, list, create, update and delete. With clusters the create, update and delete permissions only apply to deployments whose selected_clusters are all in the list and to nodes of these clusters. Users get changes to their role when they log in again. You can only grant permissions you have.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Param role body RoleRequest true "Role"
+// @Success 200 {object} map[string]interface{}
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /roles/{name} [put]
+func (s *APIServer) handleSaveRole(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if _, ok := builtinRoles[name]; ok {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Built-in role %s cannot be changed", name))
+		return
+	}
+	if !roleNamePattern.MatchString(name) {
+		respondWithError(w, http.StatusBadRequest, "Role name must be at most 64 lowercase letters, digits, '_' or '-'")
+		return
+	}
+
+	var req RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Permissions) == 0 {
+		respondWithError(w, http.StatusBadRequest, "A role needs at least one permission")
+		return
+	}
+	for _, perm := range req.Permissions {
+		if err := validatePermission(perm); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	for _, cluster := range req.Clusters {
+		if strings.TrimSpace(cluster) == "" {
+			respondWithError(w, http.StatusBadRequest, "Cluster names cannot be empty")
+			return
+		}
+	}
+	sort.Strings(req.Permissions)
+	sort.Strings(req.Clusters)
+
+	ctx := context.Background()
+	existing, err := s.storage.GetRole(ctx, name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get role %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to save role")
+		return
+	}
+
+	now := time.Now()
+	role := &etcdstorage.Role{
+		Name:        name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+		Clusters:    req.Clusters,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if !s.checkGrantRole(w, r, role) {
+		return
+	}
+	status := http.StatusCreated
+	if existing != nil {
+		role.CreatedAt = existing.CreatedAt
+		status = http.StatusOK
+	}
+	if err := s.storage.SaveRole(ctx, role); err != nil {
+		log.Printf("[Centro REST] Failed to save role %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to save role")
+		return
+	}
+
+	log.Printf("[Centro REST] Role %s saved by %s: %v", name, requestAuthor(r), role.Permissions)
+	respondWithJSON(w, status, roleResponse(role))
+}
+
+// handleDeleteRole godoc
+// @Summary Delete a custom role
+// @Description A role that is still assigned to users or service accounts cannot be deleted
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /roles/{name} [delete]
+func (s *APIServer) handleDeleteRole(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if _, ok := builtinRoles[name]; ok {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Built-in role %s cannot be deleted", name))
+		return
+	}
+
+	ctx := context.Background()
+	users, err := s.storage.GetAllUsers(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete role")
+		return
+	}
+	var assigned []string
+	for _, user := range users {
+		if user.Role == name {
+			assigned = append(assigned, user.Username)
+		}
+	}
+	accounts, err := s.storage.GetAllServiceAccounts(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get service accounts: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete role")
+		return
+	}
+	for _, account := range accounts {
+		if account.Role == name {
+			assigned = append(assigned, serviceAccountUsername(account.Name))
+		}
+	}
+	if len(assigned) > 0 {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Role %s is assigned to %s", name, strings.Join(assigned, ", ")))
+		return
+	}
+
+	deleted, err := s.storage.DeleteRole(ctx, name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to delete role %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete role")
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Role not found")
+		return
+	}
+
+	log.Printf("[Centro REST] Role %s deleted by %s", name, requestAuthor(r))
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": fmt.Sprintf("Role %s deleted", name),
+	})
+}
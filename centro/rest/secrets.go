@@ -0,0 +1,435 @@
+package rest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/open-scheduler/centro/secrets"
+	"github.com/open-scheduler/centro/spec"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+var secretNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,127}$`)
+
+// secretValueFields are the request fields holding secret values, they are
+// redacted from the audit log
+var secretValueFields = []string{"value", "value_base64"}
+
+// SecretRequest creates or updates a secret. Set value for text, or
+// value_base64 for binary values.
+type SecretRequest struct {
+	// Name is only used when creating a secret
+	Name        string `json:"name,omitempty" example:"db-password"`
+	Description string `json:"description,omitempty" example:"Password of the orders database"`
+	Value       string `json:"value,omitempty" example:"s3cr3t"`
+	ValueBase64 string `json:"value_base64,omitempty"`
+}
+
+// EnableSecrets lets users store secrets encrypted with keyring and reference
+// them from deployments
+func (s *APIServer) EnableSecrets(keyring *secrets.Keyring) {
+	s.keyring = keyring
+}
+
+func secretResponse(secret *etcdstorage.Secret, usedBy []string) map[string]interface{} {
+	response := map[string]interface{}{
+		"name":        secret.Name,
+		"description": secret.Description,
+		"version":     secret.Version,
+		"key_id":      secret.KeyID,
+		"created_by":  secret.CreatedBy,
+		"created_at":  secret.CreatedAt,
+		"updated_by":  secret.UpdatedBy,
+		"updated_at":  secret.UpdatedAt,
+	}
+	if usedBy != nil {
+		response["used_by"] = usedBy
+	}
+	return response
+}
+
+// secretValue returns the value of a request, or an error message
+func (req *SecretRequest) secretValue() ([]byte, string) {
+	switch {
+	case req.Value != "" && req.ValueBase64 != "":
+		return nil, "Set either value or value_base64"
+	case req.ValueBase64 != "":
+		value, err := base64.StdEncoding.DecodeString(req.ValueBase64)
+		if err != nil {
+			return nil, "value_base64 is not valid base64"
+		}
+		return value, ""
+	case req.Value != "":
+		return []byte(req.Value), ""
+	}
+	return nil, "A secret needs a value"
+}
+
+// respondIfSecretsDisabled writes an error and returns true when Centro was
+// started without a secrets key
+func (s *APIServer) respondIfSecretsDisabled(w http.ResponseWriter) bool {
+	if s.keyring == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Secrets are not enabled, start Centro with -secrets-key-file")
+		return true
+	}
+	return false
+}
+
+// secretUsers returns the deployments whose current spec references a
+// secret, keyed by secret name. Finished deployments are left out.
+func (s *APIServer) secretUsers(ctx context.Context) (map[string][]string, error) {
+	specs, err := s.storage.GetAllDeploymentSpecs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.storage.GetAllDeploymentHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string][]string)
+	for deploymentID, stored := range specs {
+		if _, finished := history[deploymentID]; finished || stored.Deployment == nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, ref := range stored.Deployment.Secrets {
+			if !seen[ref.Secret] {
+				seen[ref.Secret] = true
+				users[ref.Secret] = append(users[ref.Secret], deploymentID)
+			}
+		}
+	}
+	for _, deploymentIDs := range users {
+		sort.Strings(deploymentIDs)
+	}
+	return users, nil
+}
+
+// checkSecretReferences writes an error response and returns false unless
+// the secrets referenced by the deployments exist and the user may use them.
+// Referencing a secret gives the deployment its value, so it needs
+// secrets:get like reading the secret would.
+func (s *APIServer) checkSecretReferences(w http.ResponseWriter, r *http.Request, deployments ...*pb.Deployment) bool {
+	referenced := false
+	for _, deployment := range deployments {
+		referenced = referenced || len(deployment.Secrets) > 0
+	}
+	if !referenced {
+		return true
+	}
+	if s.respondIfSecretsDisabled(w) {
+		return false
+	}
+	allowed, err := s.can(r, "secrets:get")
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get role: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return false
+	}
+	if !allowed {
+		respondForbidden(w, "deployments that reference secrets need permission secrets:get", "secrets:get")
+		return false
+	}
+
+	var errs spec.Errors
+	exists := make(map[string]bool)
+	for i, deployment := range deployments {
+		for j, ref := range deployment.Secrets {
+			found, checked := exists[ref.Secret]
+			if !checked {
+				secret, err := s.storage.GetSecret(r.Context(), ref.Secret)
+				if err != nil {
+					log.Printf("[Centro REST] Failed to get secret %s: %v", ref.Secret, err)
+					respondWithError(w, http.StatusInternalServerError, "Failed to check secrets")
+					return false
+				}
+				found = secret != nil
+				exists[ref.Secret] = found
+			}
+			if !found {
+				field := fmt.Sprintf("secrets[%d].secret", j)
+				if len(deployments) > 1 {
+					field = fmt.Sprintf("deployments[%d].%s", i, field)
+				}
+				errs = append(errs, spec.FieldError{Field: field, Message: fmt.Sprintf("secret %s does not exist", ref.Secret)})
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		respondWithValidationErrors(w, errs)
+		return false
+	}
+	return true
+}
+
+// handleListSecrets godoc
+// @Summary List secrets
+// @Description List the secrets with the deployments that use them. Values are never returned.
+// @Tags Secrets
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /secrets [get]
+func (s *APIServer) handleListSecrets(w http.ResponseWriter, r *http.Request) {
+	if s.respondIfSecretsDisabled(w) {
+		return
+	}
+
+	ctx := context.Background()
+	stored, err := s.storage.GetAllSecrets(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get secrets: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get secrets")
+		return
+	}
+	users, err := s.secretUsers(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get secret users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get secrets")
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(stored))
+	for _, secret := range stored {
+		list = append(list, secretResponse(secret, append([]string{}, users[secret.Name]...)))
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"secrets": list,
+		"count":   len(list),
+	})
+}
+
+// handleGetSecret godoc
+// @Summary Get a secret
+// @Description Get the metadata of a secret and the deployments that use it. The value is never returned.
+// @Tags Secrets
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Secret name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /secrets/{name} [get]
+func (s *APIServer) handleGetSecret(w http.ResponseWriter, r *http.Request) {
+	if s.respondIfSecretsDisabled(w) {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	ctx := context.Background()
+	secret, err := s.storage.GetSecret(ctx, name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get secret %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get secret")
+		return
+	}
+	if secret == nil {
+		respondWithError(w, http.StatusNotFound, "Secret not found")
+		return
+	}
+	users, err := s.secretUsers(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get secret users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get secret")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, secretResponse(secret, append([]string{}, users[name]...)))
+}
+
+// handleCreateSecret godoc
+// @Summary Create a secret
+// @Description Store a secret encrypted with a data key of its own, which is encrypted with the key file of Centro. Deployments reference it by name in their secrets. The value is redacted from the audit log and never returned.
+// @Tags Secrets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param secret body SecretRequest true "Secret"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /secrets [post]
+func (s *APIServer) handleCreateSecret(w http.ResponseWriter, r *http.Request) {
+	if s.respondIfSecretsDisabled(w) {
+		return
+	}
+
+	var req SecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !secretNamePattern.MatchString(req.Name) {
+		respondWithError(w, http.StatusBadRequest, "Secret name must be at most 128 lowercase letters, digits, '.', '_' or '-' and start with a letter or digit")
+		return
+	}
+	if entry := requestAuditEntry(r); entry != nil {
+		entry.ResourceID = req.Name
+	}
+	value, message := req.secretValue()
+	if message != "" {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	now := time.Now()
+	author := requestAuthor(r)
+	secret := &etcdstorage.Secret{
+		Name:        req.Name,
+		Description: req.Description,
+		Version:     1,
+		CreatedBy:   author,
+		CreatedAt:   now,
+		UpdatedBy:   author,
+		UpdatedAt:   now,
+	}
+	if err := s.keyring.Seal(secret, value); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	created, err := s.storage.CreateSecret(context.Background(), secret)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to create secret %s: %v", req.Name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create secret")
+		return
+	}
+	if !created {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Secret %s already exists", req.Name))
+		return
+	}
+
+	log.Printf("[Centro REST] Secret %s created by %s", req.Name, author)
+	respondWithJSON(w, http.StatusCreated, secretResponse(secret, nil))
+}
+
+// handleUpdateSecret godoc
+// @Summary Update a secret
+// @Description Replace the value of a secret, and its description if one is given. Instances that are running keep the old value until they are replaced, e.g. by updating their deployment.
+// @Tags Secrets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Secret name"
+// @Param secret body SecretRequest true "New value"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /secrets/{name} [put]
+func (s *APIServer) handleUpdateSecret(w http.ResponseWriter, r *http.Request) {
+	if s.respondIfSecretsDisabled(w) {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	var req SecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Name != "" && req.Name != name {
+		respondWithError(w, http.StatusBadRequest, "Secrets cannot be renamed")
+		return
+	}
+	value, message := req.secretValue()
+	if message != "" {
+		respondWithError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	ctx := context.Background()
+	secret, err := s.storage.GetSecret(ctx, name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get secret %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update secret")
+		return
+	}
+	if secret == nil {
+		respondWithError(w, http.StatusNotFound, "Secret not found")
+		return
+	}
+
+	if req.Description != "" {
+		secret.Description = req.Description
+	}
+	secret.Version++
+	secret.UpdatedBy = requestAuthor(r)
+	secret.UpdatedAt = time.Now()
+	if err := s.keyring.Seal(secret, value); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.storage.SaveSecret(ctx, secret); err != nil {
+		log.Printf("[Centro REST] Failed to save secret %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to update secret")
+		return
+	}
+
+	log.Printf("[Centro REST] Secret %s updated to version %d by %s", name, secret.Version, secret.UpdatedBy)
+	respondWithJSON(w, http.StatusOK, secretResponse(secret, nil))
+}
+
+// handleDeleteSecret godoc
+// @Summary Delete a secret
+// @Description A secret that is referenced by a deployment that has not finished cannot be deleted
+// @Tags Secrets
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Secret name"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /secrets/{name} [delete]
+func (s *APIServer) handleDeleteSecret(w http.ResponseWriter, r *http.Request) {
+	if s.respondIfSecretsDisabled(w) {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	ctx := context.Background()
+	users, err := s.secretUsers(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get secret users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete secret")
+		return
+	}
+	if len(users[name]) > 0 {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Secret %s is used by deployments %v", name, users[name]))
+		return
+	}
+
+	deleted, err := s.storage.DeleteSecret(ctx, name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to delete secret %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete secret")
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Secret not found")
+		return
+	}
+
+	log.Printf("[Centro REST] Secret %s deleted by %s", name, requestAuthor(r))
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": fmt.Sprintf("Secret %s deleted", name),
+	})
+}
@@ -0,0 +1,148 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// respondWithService writes the details of a service or system deployment: its
+// current spec, the progress of its rollout and its replica units
+func (s *APIServer) respondWithService(ctx context.Context, w http.ResponseWriter, spec *etcdstorage.DeploymentSpec, events []*etcdstorage.DeploymentEvent) {
+	state, err := s.storage.GetServiceState(ctx, spec.DeploymentID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get service state: %v", err)
+	}
+
+	replicas, err := s.storage.GetServiceReplicas(ctx, spec.DeploymentID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get service replicas: %v", err)
+	}
+
+	healthy := 0
+	for _, replica := range replicas {
+		if replica.Status == "running" && !replica.Stopping && (replica.Health == "" || replica.Health == "healthy") {
+			healthy++
+		}
+	}
+
+	detail := "Waiting for the reconciler to start replicas"
+	if state != nil {
+		detail = state.Message
+	}
+
+	response := map[string]interface{}{
+		"deployment_id":    spec.DeploymentID,
+		"status":           "service",
+		"detail":           detail,
+		"revision":         spec.Revision,
+		"updated_at":       spec.UpdatedAt,
+		"rollout":          state,
+		"replicas":         replicas,
+		"desired_replicas": spec.Deployment.Replicas,
+		"healthy_replicas": healthy,
+		"deployment":       spec.Deployment,
+		"events":           events,
+	}
+	withAuthors(response, spec)
+	// System deployments run one replica per matching node and have no rollouts
+	if etcdstorage.IsManagedSystem(spec.Deployment) {
+		response["status"] = "system"
+		delete(response, "rollout")
+		delete(response, "desired_replicas")
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlePromoteDeployment godoc
+// @Summary Promote the canaries of a service
+// @Description Let a canary or blue/green rollout replace the old replicas of a service. The reconciler applies the request on its next pass.
+// @Tags Deployments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Deployment ID"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /deployments/{id}/promote [post]
+func (s *APIServer) handlePromoteDeployment(w http.ResponseWriter, r *http.Request) {
+	s.requestServiceCommand(w, r, etcdstorage.ServiceCommandPromote)
+}
+
+// handleAbortDeployment godoc
+// @Summary Abort the rollout of a service
+// @Description Stop the rollout of a service and restore the spec of its last stable revision as a new revision. The reconciler applies the request on its next pass.
+// @Tags Deployments
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Deployment ID"
+// @Success 202 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /deployments/{id}/abort [post]
+func (s *APIServer) handleAbortDeployment(w http.ResponseWriter, r *http.Request) {
+	s.requestServiceCommand(w, r, etcdstorage.ServiceCommandAbort)
+}
+
+// requestServiceCommand checks that a promote or abort applies to the current
+// rollout of a service and stores it for the reconciler
+func (s *APIServer) requestServiceCommand(w http.ResponseWriter, r *http.Request, action string) {
+	deploymentID := mux.Vars(r)["id"]
+
+	ctx := context.Background()
+	spec, ok := s.loadDeploymentSpec(ctx, w, deploymentID)
+	if !ok {
+		return
+	}
+	if !etcdstorage.IsManagedService(spec.Deployment) {
+		respondWithError(w, http.StatusBadRequest, "Only service deployments have rollouts")
+		return
+	}
+
+	state, err := s.storage.GetServiceState(ctx, deploymentID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get service state: %v", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to %s deployment", action))
+		return
+	}
+
+	var message string
+	switch action {
+	case etcdstorage.ServiceCommandPromote:
+		if state == nil || state.Status != etcdstorage.RolloutStatusCanary {
+			respondWithError(w, http.StatusConflict, "No canaries are waiting to be promoted")
+			return
+		}
+		message = fmt.Sprintf("Promotion of revision %d requested", state.TargetRevision)
+	case etcdstorage.ServiceCommandAbort:
+		if !state.Abortable() {
+			respondWithError(w, http.StatusConflict, "No rollout to abort")
+			return
+		}
+		message = fmt.Sprintf("Abort of revision %d requested, revision %d will be restored", state.TargetRevision, state.StableRevision)
+	}
+
+	author := requestAuthor(r)
+	command := &etcdstorage.ServiceCommand{Action: action, RequestedBy: author, RequestedAt: time.Now()}
+	if err := s.storage.SaveServiceCommand(ctx, deploymentID, command); err != nil {
+		log.Printf("[Centro REST] Failed to save service command: %v", err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to %s deployment", action))
+		return
+	}
+
+	log.Printf("[Centro REST] Service command %s for deployment %s requested by %s", action, deploymentID, author)
+
+	respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+		"deployment_id": deploymentID,
+		"action":        action,
+		"revision":      state.TargetRevision,
+		"message":       message,
+	})
+}
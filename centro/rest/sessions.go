@@ -0,0 +1,264 @@
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// Refresh tokens look like osr_<id>_<secret>, like API tokens, but are only
+// accepted by /auth/refresh and /auth/logout
+const refreshTokenPrefix = "osr_"
+
+// RefreshTokenTTL is how long a login can be renewed without a password. SSO
+// logins end earlier, see EnableOIDC.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// refreshReuseGrace is how long after its use a refresh token is taken for a
+// concurrent refresh of the same client rather than a stolen copy
+const refreshReuseGrace = 10 * time.Second
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutRequest struct {
+	// RefreshToken of the login to end, it cannot be used afterwards
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// All ends every login of the user
+	All bool `json:"all,omitempty"`
+}
+
+// issueTokens returns an access token and a new refresh token. used is nil
+// for a new login and the refresh token that was used on a refresh.
+func (s *APIServer) issueTokens(ctx context.Context, username, role string, sso bool, used *etcdstorage.RefreshToken) (*LoginResponse, error) {
+	accessToken, err := GenerateToken(username, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	tokenID, refreshToken, err := newSecretToken(refreshTokenPrefix)
+	if err != nil {
+		return nil, err
+	}
+	family := tokenID
+	if used != nil {
+		family = used.Family
+	}
+	now := time.Now()
+	expiresAt := now.Add(RefreshTokenTTL)
+	// SSO logins end a fixed time after the login however often they are
+	// refreshed, the role is only mapped again at the next login
+	if sso {
+		end := now.Add(s.ssoSessionTTL)
+		if used != nil {
+			end = used.ExpiresAt
+		}
+		if end.Before(expiresAt) {
+			expiresAt = end
+		}
+	}
+	err = s.storage.SaveRefreshToken(ctx, &etcdstorage.RefreshToken{
+		ID:         tokenID,
+		SecretHash: hashTokenSecret(refreshToken),
+		Family:     family,
+		Username:   username,
+		Role:       role,
+		SSO:        sso,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		Token:            accessToken,
+		ExpiresIn:        int(AccessTokenTTL.Seconds()),
+		Username:         username,
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(expiresAt.Sub(now).Seconds()),
+	}, nil
+}
+
+// refreshTokenID returns the ID of a refresh token, or "" if it is malformed
+func refreshTokenID(raw string) string {
+	if !strings.HasPrefix(raw, refreshTokenPrefix) {
+		return ""
+	}
+	tokenID, secret, ok := strings.Cut(strings.TrimPrefix(raw, refreshTokenPrefix), "_")
+	if !ok || secret == "" {
+		return ""
+	}
+	return tokenID
+}
+
+func refreshTokenMatches(token *etcdstorage.RefreshToken, raw string) bool {
+	return token != nil && subtle.ConstantTimeCompare([]byte(hashTokenSecret(raw)), []byte(token.SecretHash)) == 1
+}
+
+// handleRefresh godoc
+// @Summary Renew an access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Every refresh token works once. Using one again more than 10 seconds later ends the login, because it was copied.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /auth/refresh [post]
+func (s *APIServer) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		respondWithError(w, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	ctx := r.Context()
+	tokenID := refreshTokenID(req.RefreshToken)
+	if tokenID == "" {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+	// Check the secret before the token is marked as used, so guessing IDs
+	// cannot revoke the logins of others
+	stored, err := s.storage.GetRefreshToken(ctx, tokenID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get refresh token: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+	now := time.Now()
+	if !refreshTokenMatches(stored, req.RefreshToken) || !now.Before(stored.ExpiresAt) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+
+	token, reused, err := s.storage.UseRefreshToken(ctx, tokenID, now)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to use refresh token: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+	if token == nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		return
+	}
+	if reused && now.Sub(*token.UsedAt) < refreshReuseGrace {
+		respondWithError(w, http.StatusUnauthorized, "Refresh token was just used, use the new one")
+		return
+	}
+	if reused {
+		log.Printf("[Centro REST] WARNING: refresh token %s of %s was used twice, ending the login", token.ID, token.Username)
+		if _, err := s.storage.RevokeRefreshTokenFamily(ctx, token.Family); err != nil {
+			log.Printf("[Centro REST] Failed to revoke refresh tokens of %s: %v", token.Username, err)
+		}
+		respondWithError(w, http.StatusUnauthorized, "Refresh token was already used, log in again")
+		return
+	}
+
+	// Local users get their current role. SSO users keep the role of the
+	// login until the login ends after the SSO session TTL, the identity
+	// provider is asked again at the next login.
+	role := token.Role
+	if !token.SSO {
+		user, err := s.storage.GetUser(ctx, token.Username)
+		if err != nil {
+			log.Printf("[Centro REST] Failed to get user %s: %v", token.Username, err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to refresh token")
+			return
+		}
+		if user == nil {
+			respondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+			return
+		}
+		role = user.Role
+	}
+
+	response, err := s.issueTokens(ctx, token.Username, role, token.SSO, token)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to refresh token of %s: %v", token.Username, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handleLogout godoc
+// @Summary Log out
+// @Description Revoke the access token of the request, and the refresh token of the login if it is given. With all set, every login of the user ends.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body LogoutRequest false "Refresh token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Router /auth/logout [post]
+func (s *APIServer) handleLogout(w http.ResponseWriter, r *http.Request) {
+	claims, _ := r.Context().Value("claims").(*Claims)
+	if claims == nil || claims.TokenID != "" {
+		respondWithError(w, http.StatusBadRequest, "API tokens are revoked with DELETE /tokens/{id}")
+		return
+	}
+
+	var req LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := s.storage.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			log.Printf("[Centro REST] Failed to revoke token of %s: %v", claims.Username, err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to log out")
+			return
+		}
+	}
+
+	switch {
+	case req.All:
+		if _, err := s.storage.RevokeUserRefreshTokens(ctx, claims.Username); err != nil {
+			log.Printf("[Centro REST] Failed to revoke refresh tokens of %s: %v", claims.Username, err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to log out")
+			return
+		}
+	case req.RefreshToken != "":
+		token, err := s.storage.GetRefreshToken(ctx, refreshTokenID(req.RefreshToken))
+		if err != nil {
+			log.Printf("[Centro REST] Failed to get refresh token: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to log out")
+			return
+		}
+		// Unknown tokens have expired or were revoked already
+		if refreshTokenMatches(token, req.RefreshToken) && token.Username == claims.Username {
+			if _, err := s.storage.RevokeRefreshTokenFamily(ctx, token.Family); err != nil {
+				log.Printf("[Centro REST] Failed to revoke refresh tokens of %s: %v", claims.Username, err)
+				respondWithError(w, http.StatusInternalServerError, "Failed to log out")
+				return
+			}
+		}
+	}
+
+	log.Printf("[Centro REST] User %s logged out", claims.Username)
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Logged out"})
+}
+
+// endLogins revokes the refresh tokens of a user, after a password change or
+// when the user is deleted. Access tokens stay valid until they expire.
+func (s *APIServer) endLogins(ctx context.Context, username string) {
+	if _, err := s.storage.RevokeUserRefreshTokens(ctx, username); err != nil {
+		log.Printf("[Centro REST] Failed to revoke refresh tokens of %s: %v", username, err)
+	}
+}
@@ -0,0 +1,188 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+)
+
+func refresh(s *APIServer, raw string) (*httptest.ResponseRecorder, *LoginResponse) {
+	body, _ := json.Marshal(RefreshRequest{RefreshToken: raw})
+	recorder := httptest.NewRecorder()
+	s.handleRefresh(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewReader(body)))
+	var response LoginResponse
+	if recorder.Code == http.StatusOK {
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+	}
+	return recorder, &response
+}
+
+// newSessionServer returns a server with the local user alice and a login of hers
+func newSessionServer(t *testing.T) (*APIServer, *etcdstorage.Storage, *LoginResponse) {
+	t.Helper()
+	storage, _ := etcdtest.NewStorage()
+	ctx := context.Background()
+	if err := storage.CreateUser(ctx, &etcdstorage.User{Username: "alice", Role: RoleOperator}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewAPIServer(storage)
+	login, err := s.issueTokens(ctx, "alice", RoleOperator, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, storage, login
+}
+
+// markUsed records that a refresh token was used at usedAt
+func markUsed(t *testing.T, storage *etcdstorage.Storage, raw string, usedAt time.Time) {
+	t.Helper()
+	token, err := storage.GetRefreshToken(context.Background(), refreshTokenID(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.UsedAt = &usedAt
+	if err := storage.SaveRefreshToken(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshRotates(t *testing.T) {
+	s, storage, login := newSessionServer(t)
+
+	recorder, refreshed := refresh(s, login.RefreshToken)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("refresh returned status %d: %s", recorder.Code, recorder.Body)
+	}
+	if refreshed.RefreshToken == login.RefreshToken || refreshed.Token == "" {
+		t.Fatal("refresh did not issue new tokens")
+	}
+
+	used, err := storage.GetRefreshToken(context.Background(), refreshTokenID(login.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := storage.GetRefreshToken(context.Background(), refreshTokenID(refreshed.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used.UsedAt == nil {
+		t.Error("the used refresh token was not marked as used")
+	}
+	if rotated.Family != used.Family {
+		t.Errorf("rotated token is in family %s, want %s", rotated.Family, used.Family)
+	}
+	if rotated.SecretHash != hashTokenSecret(refreshed.RefreshToken) {
+		t.Error("the refresh token is not stored as its hash")
+	}
+
+	if recorder, _ := refresh(s, refreshed.RefreshToken); recorder.Code != http.StatusOK {
+		t.Errorf("rotated token returned status %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestRefreshReuse(t *testing.T) {
+	tests := []struct {
+		name       string
+		usedAgo    time.Duration
+		endsLogin  bool
+		wantStatus int
+	}{
+		{name: "within the grace period", usedAgo: time.Second, wantStatus: http.StatusUnauthorized},
+		{name: "after the grace period", usedAgo: time.Minute, endsLogin: true, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, storage, login := newSessionServer(t)
+			recorder, refreshed := refresh(s, login.RefreshToken)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("refresh returned status %d: %s", recorder.Code, recorder.Body)
+			}
+			markUsed(t, storage, login.RefreshToken, time.Now().Add(-tt.usedAgo))
+
+			if recorder, _ := refresh(s, login.RefreshToken); recorder.Code != tt.wantStatus {
+				t.Fatalf("reuse returned status %d: %s", recorder.Code, recorder.Body)
+			}
+
+			// A copied token ends the login, the client that refreshed first
+			// has to log in again as well
+			recorder, _ = refresh(s, refreshed.RefreshToken)
+			if ended := recorder.Code == http.StatusUnauthorized; ended != tt.endsLogin {
+				t.Errorf("login ended: %v, want %v (status %d)", ended, tt.endsLogin, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestRefreshRejects(t *testing.T) {
+	s, storage, login := newSessionServer(t)
+	expired, err := s.issueTokens(context.Background(), "alice", RoleOperator, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := storage.GetRefreshToken(context.Background(), refreshTokenID(expired.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.ExpiresAt = time.Now().Add(-time.Second)
+	if err := storage.SaveRefreshToken(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want int
+	}{
+		{name: "empty", raw: "", want: http.StatusBadRequest},
+		{name: "malformed", raw: "not-a-token", want: http.StatusUnauthorized},
+		{name: "API token", raw: apiTokenPrefix + refreshTokenID(login.RefreshToken) + "_secret", want: http.StatusUnauthorized},
+		{name: "wrong secret", raw: refreshTokenPrefix + refreshTokenID(login.RefreshToken) + "_other", want: http.StatusUnauthorized},
+		{name: "expired", raw: expired.RefreshToken, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if recorder, _ := refresh(s, tt.raw); recorder.Code != tt.want {
+				t.Errorf("got status %d, want %d: %s", recorder.Code, tt.want, recorder.Body)
+			}
+		})
+	}
+
+	// Guessing the secret must not use up or revoke the login
+	if recorder, _ := refresh(s, login.RefreshToken); recorder.Code != http.StatusOK {
+		t.Errorf("login was ended by invalid refreshes: status %d", recorder.Code)
+	}
+}
+
+func TestRefreshUsesCurrentRole(t *testing.T) {
+	s, storage, login := newSessionServer(t)
+	ctx := context.Background()
+	if _, err := storage.SetUserRole(ctx, "alice", RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+
+	recorder, refreshed := refresh(s, login.RefreshToken)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("refresh returned status %d: %s", recorder.Code, recorder.Body)
+	}
+	token, err := storage.GetRefreshToken(ctx, refreshTokenID(refreshed.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Role != RoleViewer {
+		t.Errorf("refreshed with role %s, want %s", token.Role, RoleViewer)
+	}
+
+	if _, err := storage.DeleteUser(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if recorder, _ := refresh(s, refreshed.RefreshToken); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("deleted user refreshed with status %d", recorder.Code)
+	}
+}
@@ -0,0 +1,468 @@
+package rest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// API tokens look like ost_<id>_<secret>. The ID finds the stored token, the
+// secret is compared with its hash.
+const apiTokenPrefix = "ost_"
+
+// tokenUsedInterval limits how often last_used_at is written
+const tokenUsedInterval = time.Minute
+
+type CreateServiceAccountRequest struct {
+	Name        string `json:"name" example:"ci"`
+	Description string `json:"description,omitempty" example:"Deploys from the CI pipeline"`
+	Role        string `json:"role" example:"operator"`
+}
+
+type CreateTokenRequest struct {
+	ServiceAccount string `json:"service_account" example:"ci"`
+	Name           string `json:"name" example:"github-actions"`
+	// ExpiresIn is a duration like 720h or 90d, empty for a token that does not expire
+	ExpiresIn string `json:"expires_in,omitempty" example:"90d"`
+	// Scopes limit the token to these permissions of the role of the service account
+	Scopes []string `json:"scopes,omitempty" example:"deployments:create,deployments:get"`
+}
+
+// IsAPIToken reports whether a bearer token is an API token rather than a JWT
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, apiTokenPrefix)
+}
+
+// serviceAccountUsername is the name requests with API tokens are made as
+func serviceAccountUsername(name string) string {
+	return "serviceaccount:" + name
+}
+
+func hashTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSecretToken returns a new token ID and the full token <prefix><id>_<secret>
+func newSecretToken(prefix string) (string, string, error) {
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	tokenID := hex.EncodeToString(id)
+	return tokenID, prefix + tokenID + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// parseExpiresIn accepts Go durations and whole days like 90d
+func parseExpiresIn(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid expires_in %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expires_in %q, use a duration like 720h or 90d", value)
+	}
+	return duration, nil
+}
+
+// validateAPIToken is the APITokenValidator of the REST API. Requests with an
+// API token are made as the service account with its current role.
+func (s *APIServer) validateAPIToken(ctx context.Context, raw string) (*Claims, error) {
+	tokenID, secret, ok := strings.Cut(strings.TrimPrefix(raw, apiTokenPrefix), "_")
+	if !ok || tokenID == "" || secret == "" {
+		return nil, ErrInvalidToken
+	}
+
+	token, err := s.storage.GetAPIToken(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || subtle.ConstantTimeCompare([]byte(hashTokenSecret(raw)), []byte(token.SecretHash)) != 1 {
+		return nil, ErrInvalidToken
+	}
+	now := time.Now()
+	if !token.Active(now) {
+		return nil, ErrInvalidToken
+	}
+
+	account, err := s.storage.GetServiceAccount(ctx, token.ServiceAccount)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrInvalidToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > tokenUsedInterval {
+		if err := s.storage.TouchAPIToken(ctx, token.ID, now); err != nil {
+			log.Printf("[Centro REST] Failed to record use of API token %s: %v", token.ID, err)
+		}
+	}
+
+	return &Claims{
+		Username: serviceAccountUsername(account.Name),
+		Role:     account.Role,
+		Scopes:   token.Scopes,
+		TokenID:  token.ID,
+	}, nil
+}
+
+func serviceAccountResponse(account *etcdstorage.ServiceAccount) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        account.Name,
+		"description": account.Description,
+		"role":        account.Role,
+		"created_by":  account.CreatedBy,
+		"created_at":  account.CreatedAt,
+	}
+}
+
+func tokenResponse(token *etcdstorage.APIToken, now time.Time) map[string]interface{} {
+	status := "active"
+	switch {
+	case token.RevokedAt != nil:
+		status = "revoked"
+	case !token.Active(now):
+		status = "expired"
+	}
+	return map[string]interface{}{
+		"id":              token.ID,
+		"name":            token.Name,
+		"service_account": token.ServiceAccount,
+		"scopes":          token.Scopes,
+		"status":          status,
+		"expires_at":      token.ExpiresAt,
+		"created_by":      token.CreatedBy,
+		"created_at":      token.CreatedAt,
+		"last_used_at":    token.LastUsedAt,
+		"revoked_at":      token.RevokedAt,
+	}
+}
+
+// handleListServiceAccounts godoc
+// @Summary List service accounts
+// @Tags Service Accounts
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Router /serviceaccounts [get]
+func (s *APIServer) handleListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := s.storage.GetAllServiceAccounts(context.Background())
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get service accounts: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get service accounts")
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(accounts))
+	for _, account := range accounts {
+		list = append(list, serviceAccountResponse(account))
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"service_accounts": list,
+		"count":            len(list),
+	})
+}
+
+// handleCreateServiceAccount godoc
+// @Summary Create a service account
+// @Description Create an identity for automation with a built-in or custom role. Service accounts authenticate with API tokens. You can only assign roles whose permissions you have.
+// @Tags Service Accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param account body CreateServiceAccountRequest true "Service account"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /serviceaccounts [post]
+func (s *APIServer) handleCreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	var req CreateServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !roleNamePattern.MatchString(req.Name) {
+		respondWithError(w, http.StatusBadRequest, "Name must be at most 64 lowercase letters, digits, '_' or '-'")
+		return
+	}
+	if req.Role == "" {
+		respondWithError(w, http.StatusBadRequest, "Role is required")
+		return
+	}
+	if s.assignableRole(w, r, req.Role) == nil {
+		return
+	}
+
+	account := &etcdstorage.ServiceAccount{
+		Name:        req.Name,
+		Description: req.Description,
+		Role:        req.Role,
+		CreatedBy:   requestAuthor(r),
+		CreatedAt:   time.Now(),
+	}
+	err := s.storage.CreateServiceAccount(context.Background(), account)
+	if errors.Is(err, etcdstorage.ErrServiceAccountExists) {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Service account %s already exists", req.Name))
+		return
+	}
+	if err != nil {
+		log.Printf("[Centro REST] Failed to create service account %s: %v", req.Name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create service account")
+		return
+	}
+
+	log.Printf("[Centro REST] Service account %s (%s) created by %s", account.Name, account.Role, account.CreatedBy)
+	respondWithJSON(w, http.StatusCreated, serviceAccountResponse(account))
+}
+
+// handleDeleteServiceAccount godoc
+// @Summary Delete a service account
+// @Description Delete a service account together with its API tokens. The caller must be able to grant the role of the service account.
+// @Tags Service Accounts
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Service account name"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /serviceaccounts/{name} [delete]
+func (s *APIServer) handleDeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	ctx := context.Background()
+	account, err := s.storage.GetServiceAccount(ctx, name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get service account %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete service account")
+		return
+	}
+	if account == nil {
+		respondWithError(w, http.StatusNotFound, "Service account not found")
+		return
+	}
+	if !s.checkManageRole(w, r, account.Role) {
+		return
+	}
+
+	deleted, err := s.storage.DeleteServiceAccount(ctx, name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to delete service account %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete service account")
+		return
+	}
+	if !deleted {
+		respondWithError(w, http.StatusNotFound, "Service account not found")
+		return
+	}
+
+	log.Printf("[Centro REST] Service account %s deleted by %s", name, requestAuthor(r))
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": fmt.Sprintf("Service account %s and its tokens deleted", name),
+	})
+}
+
+// handleListTokens godoc
+// @Summary List API tokens
+// @Description List the API tokens of service accounts, newest first. The secrets are never returned.
+// @Tags Service Accounts
+// @Produce json
+// @Security BearerAuth
+// @Param service_account query string false "Only tokens of this service account"
+// @Param status query string false "active, expired or revoked"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Router /tokens [get]
+func (s *APIServer) handleListTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := s.storage.GetAllAPITokens(context.Background())
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get API tokens: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get tokens")
+		return
+	}
+
+	account := r.URL.Query().Get("service_account")
+	status := r.URL.Query().Get("status")
+	now := time.Now()
+	list := make([]map[string]interface{}, 0, len(tokens))
+	for _, token := range tokens {
+		if account != "" && token.ServiceAccount != account {
+			continue
+		}
+		item := tokenResponse(token, now)
+		if status != "" && item["status"] != status {
+			continue
+		}
+		list = append(list, item)
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"tokens": list,
+		"count":  len(list),
+	})
+}
+
+// handleCreateToken godoc
+// @Summary Create an API token
+// @Description Create a named token for a service account. The token is only returned in this response, Centro stores its hash. Send it as "Authorization: Bearer <token>". Scopes limit the token to some permissions of the role of the service account.
+// @Tags Service Accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param token body CreateTokenRequest true "Token"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tokens [post]
+func (s *APIServer) handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		respondWithError(w, http.StatusBadRequest, "Token name is required")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if err := validatePermission(scope); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	now := time.Now()
+	var expiresAt *time.Time
+	if req.ExpiresIn != "" {
+		duration, err := parseExpiresIn(req.ExpiresIn)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if duration <= 0 {
+			respondWithError(w, http.StatusBadRequest, "expires_in must be positive")
+			return
+		}
+		expiry := now.Add(duration)
+		expiresAt = &expiry
+	}
+
+	ctx := context.Background()
+	account, err := s.storage.GetServiceAccount(ctx, req.ServiceAccount)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get service account %s: %v", req.ServiceAccount, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create token")
+		return
+	}
+	if account == nil {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Service account %s not found", req.ServiceAccount))
+		return
+	}
+	// A token acts with the role of its service account
+	if s.assignableRole(w, r, account.Role) == nil {
+		return
+	}
+
+	tokenID, raw, err := newSecretToken(apiTokenPrefix)
+	if err != nil {
+		log.Printf("[Centro REST] %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create token")
+		return
+	}
+	token := &etcdstorage.APIToken{
+		ID:             tokenID,
+		Name:           req.Name,
+		ServiceAccount: account.Name,
+		SecretHash:     hashTokenSecret(raw),
+		Scopes:         req.Scopes,
+		ExpiresAt:      expiresAt,
+		CreatedBy:      requestAuthor(r),
+		CreatedAt:      now,
+	}
+	if err := s.storage.SaveAPIToken(ctx, token); err != nil {
+		log.Printf("[Centro REST] Failed to save API token: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create token")
+		return
+	}
+
+	log.Printf("[Centro REST] API token %s (%s) of service account %s created by %s", token.ID, token.Name, token.ServiceAccount, token.CreatedBy)
+	response := tokenResponse(token, now)
+	response["token"] = raw
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// handleRevokeToken godoc
+// @Summary Revoke an API token
+// @Description Revoked tokens are rejected right away and stay listed with status revoked. The caller must be able to grant the role of the service account of the token.
+// @Tags Service Accounts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Token ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tokens/{id} [delete]
+func (s *APIServer) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	tokenID := mux.Vars(r)["id"]
+	ctx := context.Background()
+	token, err := s.storage.GetAPIToken(ctx, tokenID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get API token %s: %v", tokenID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to revoke token")
+		return
+	}
+	if token == nil {
+		respondWithError(w, http.StatusNotFound, "Token not found")
+		return
+	}
+	account, err := s.storage.GetServiceAccount(ctx, token.ServiceAccount)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get service account %s: %v", token.ServiceAccount, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to revoke token")
+		return
+	}
+	// Tokens of deleted service accounts no longer act as anyone
+	if account != nil && !s.checkManageRole(w, r, account.Role) {
+		return
+	}
+
+	token, err = s.storage.RevokeAPIToken(ctx, tokenID)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to revoke API token %s: %v", tokenID, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to revoke token")
+		return
+	}
+	if token == nil {
+		respondWithError(w, http.StatusNotFound, "Token not found")
+		return
+	}
+
+	log.Printf("[Centro REST] API token %s of service account %s revoked by %s", token.ID, token.ServiceAccount, requestAuthor(r))
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message": fmt.Sprintf("Token %s revoked", token.ID),
+		"token":   tokenResponse(token, time.Now()),
+	})
+}
@@ -0,0 +1,192 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+)
+
+// createToken creates an API token with the handler and returns the response
+func createToken(s *APIServer, role *etcdstorage.Role, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/tokens", strings.NewReader(body))
+	r = r.WithContext(context.WithValue(r.Context(), "role", role))
+	recorder := httptest.NewRecorder()
+	s.handleCreateToken(recorder, r)
+	return recorder
+}
+
+// saveAPIToken stores a token of a service account and returns the raw token
+func saveAPIToken(t *testing.T, storage *etcdstorage.Storage, token *etcdstorage.APIToken) string {
+	t.Helper()
+	raw := apiTokenPrefix + token.ID + "_secret-" + token.ID
+	token.SecretHash = hashTokenSecret(raw)
+	if err := storage.SaveAPIToken(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func newTokenServer(t *testing.T) (*APIServer, *etcdstorage.Storage) {
+	t.Helper()
+	storage, _ := etcdtest.NewStorage()
+	for _, account := range []*etcdstorage.ServiceAccount{
+		{Name: "ci", Role: RoleOperator},
+		{Name: "root", Role: RoleAdmin},
+	} {
+		if err := storage.CreateServiceAccount(context.Background(), account); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewAPIServer(storage), storage
+}
+
+func TestCreateTokenStoresHash(t *testing.T) {
+	s, storage := newTokenServer(t)
+
+	recorder := createToken(s, builtinRoles[RoleAdmin], `{"name": "deploy", "service_account": "ci", "expires_in": "30d"}`)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("got status %d: %s", recorder.Code, recorder.Body)
+	}
+	var response struct {
+		ID    string `json:"id"`
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if !IsAPIToken(response.Token) || !strings.HasPrefix(response.Token, apiTokenPrefix+response.ID+"_") {
+		t.Fatalf("token %q does not look like %s<id>_<secret>", response.Token, apiTokenPrefix)
+	}
+
+	stored, err := storage.GetAPIToken(context.Background(), response.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.SecretHash != hashTokenSecret(response.Token) || strings.Contains(stored.SecretHash, response.Token) {
+		t.Error("the token is not stored as its hash")
+	}
+	if stored.ExpiresAt == nil || stored.ExpiresAt.Before(time.Now().Add(29*24*time.Hour)) {
+		t.Errorf("expires at %v, want in 30 days", stored.ExpiresAt)
+	}
+
+	claims, err := s.validateAPIToken(context.Background(), response.Token)
+	if err != nil {
+		t.Fatalf("created token is not valid: %v", err)
+	}
+	if claims.Username != "serviceaccount:ci" || claims.Role != RoleOperator {
+		t.Errorf("claims %+v", claims)
+	}
+}
+
+func TestCreateTokenRequests(t *testing.T) {
+	s, _ := newTokenServer(t)
+
+	tests := []struct {
+		name string
+		role *etcdstorage.Role
+		body string
+		want int
+	}{
+		{name: "operator for operator account", role: builtinRoles[RoleOperator], body: `{"name": "t", "service_account": "ci"}`, want: http.StatusCreated},
+		{name: "operator for admin account", role: builtinRoles[RoleOperator], body: `{"name": "t", "service_account": "root"}`, want: http.StatusForbidden},
+		{name: "unknown service account", role: builtinRoles[RoleAdmin], body: `{"name": "t", "service_account": "missing"}`, want: http.StatusNotFound},
+		{name: "unknown scope", role: builtinRoles[RoleAdmin], body: `{"name": "t", "service_account": "ci", "scopes": ["deployments:run"]}`, want: http.StatusBadRequest},
+		{name: "negative expiry", role: builtinRoles[RoleAdmin], body: `{"name": "t", "service_account": "ci", "expires_in": "-1h"}`, want: http.StatusBadRequest},
+		{name: "invalid expiry", role: builtinRoles[RoleAdmin], body: `{"name": "t", "service_account": "ci", "expires_in": "soon"}`, want: http.StatusBadRequest},
+		{name: "without name", role: builtinRoles[RoleAdmin], body: `{"service_account": "ci"}`, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if recorder := createToken(s, tt.role, tt.body); recorder.Code != tt.want {
+				t.Errorf("got status %d, want %d: %s", recorder.Code, tt.want, recorder.Body)
+			}
+		})
+	}
+}
+
+func TestValidateAPIToken(t *testing.T) {
+	s, storage := newTokenServer(t)
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	valid := saveAPIToken(t, storage, &etcdstorage.APIToken{ID: "valid", ServiceAccount: "ci", Scopes: []string{"deployments:get"}, ExpiresAt: &future})
+	expired := saveAPIToken(t, storage, &etcdstorage.APIToken{ID: "expired", ServiceAccount: "ci", ExpiresAt: &past})
+	revoked := saveAPIToken(t, storage, &etcdstorage.APIToken{ID: "revoked", ServiceAccount: "ci", RevokedAt: &past})
+	orphaned := saveAPIToken(t, storage, &etcdstorage.APIToken{ID: "orphaned", ServiceAccount: "deleted"})
+
+	tests := []struct {
+		name  string
+		raw   string
+		valid bool
+	}{
+		{name: "valid token", raw: valid, valid: true},
+		{name: "expired token", raw: expired},
+		{name: "revoked token", raw: revoked},
+		{name: "token of a deleted service account", raw: orphaned},
+		{name: "wrong secret", raw: apiTokenPrefix + "valid_other"},
+		{name: "unknown token", raw: apiTokenPrefix + "unknown_secret-unknown"},
+		{name: "without secret", raw: apiTokenPrefix + "valid"},
+		{name: "hash instead of secret", raw: apiTokenPrefix + "valid_" + hashTokenSecret(valid)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := s.validateAPIToken(context.Background(), tt.raw)
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("got %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The token acts with the current role of its service account
+			want := &Claims{Username: "serviceaccount:ci", Role: RoleOperator, Scopes: []string{"deployments:get"}, TokenID: "valid"}
+			if !reflect.DeepEqual(claims, want) {
+				t.Errorf("got claims %+v, want %+v", claims, want)
+			}
+		})
+	}
+
+	token, err := storage.GetAPIToken(context.Background(), "valid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.LastUsedAt == nil {
+		t.Error("last_used_at was not recorded")
+	}
+}
+
+func TestParseExpiresIn(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		invalid bool
+	}{
+		{value: "90d", want: 90 * 24 * time.Hour},
+		{value: "720h", want: 720 * time.Hour},
+		{value: "30m", want: 30 * time.Minute},
+		{value: "1.5d", invalid: true},
+		{value: "d", invalid: true},
+		{value: "soon", invalid: true},
+	}
+	for _, tt := range tests {
+		got, err := parseExpiresIn(tt.value)
+		if (err != nil) != tt.invalid {
+			t.Errorf("parseExpiresIn(%q) error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseExpiresIn(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
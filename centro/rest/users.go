@@ -0,0 +1,487 @@
+package rest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/mux"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+// DevAdminPassword is the password of the admin user created in dev mode
+const DevAdminPassword = "admin123"
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._@-]{0,63}$`)
+
+type CreateUserRequest struct {
+	Username string `json:"username" example:"alice"`
+	Password string `json:"password" example:"correct-horse-battery"`
+	Role     string `json:"role,omitempty" example:"viewer"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" example:"operator"`
+}
+
+type SetPasswordRequest struct {
+	Password string `json:"password" example:"correct-horse-battery"`
+	// CurrentPassword is required when users change their own password
+	CurrentPassword string `json:"current_password,omitempty"`
+}
+
+// HashPassword returns the bcrypt hash of a password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func checkPassword(user *etcdstorage.User, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) == nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	// bcrypt ignores everything after 72 bytes
+	if len(password) > 72 {
+		return fmt.Errorf("password must be at most 72 bytes")
+	}
+	return nil
+}
+
+// BootstrapAdmin creates the admin user when no user exists yet. Without a
+// password a random one is generated and logged once, in dev mode the admin
+// gets DevAdminPassword.
+func (s *APIServer) BootstrapAdmin(ctx context.Context, password string, devMode bool) error {
+	users, err := s.storage.GetAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return nil
+	}
+
+	generated := false
+	switch {
+	case password != "":
+	case devMode:
+		password = DevAdminPassword
+	default:
+		buf := make([]byte, 12)
+		if _, err := rand.Read(buf); err != nil {
+			return fmt.Errorf("failed to generate admin password: %w", err)
+		}
+		password = hex.EncodeToString(buf)
+		generated = true
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = s.storage.CreateUser(ctx, &etcdstorage.User{
+		Username:          "admin",
+		PasswordHash:      hash,
+		Role:              RoleAdmin,
+		CreatedAt:         now,
+		PasswordChangedAt: now,
+	})
+	if errors.Is(err, etcdstorage.ErrUserExists) {
+		// Another centro instance created it first
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if generated {
+		log.Printf("[Centro REST] Created user admin with the generated password %s, change it with 'osctl user passwd admin'", password)
+	} else {
+		log.Printf("[Centro REST] Created user admin")
+	}
+	return nil
+}
+
+// assignableRole returns a built-in or custom role that the user making the
+// request may assign. Otherwise it writes an error and returns nil.
+func (s *APIServer) assignableRole(w http.ResponseWriter, r *http.Request, name string) *etcdstorage.Role {
+	role, err := s.getRole(r.Context(), name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get role %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to check role")
+		return nil
+	}
+	if role == nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Role %s does not exist, see GET /roles", name))
+		return nil
+	}
+	if !s.checkGrantRole(w, r, role) {
+		return nil
+	}
+	return role
+}
+
+// checkManageRole writes 403 and returns false unless the user that made the
+// request could have granted the role a user or service account has, so that
+// for example an operator with users:delete cannot remove an admin
+func (s *APIServer) checkManageRole(w http.ResponseWriter, r *http.Request, name string) bool {
+	role, err := s.getRole(r.Context(), name)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get role %s: %v", name, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to check role")
+		return false
+	}
+	if role == nil {
+		// The role was deleted, the account has no permissions left
+		role = &etcdstorage.Role{Name: name}
+	}
+	return s.checkGrantRole(w, r, role)
+}
+
+// manageableUser returns the user a password or role change refers to. Users
+// can only manage users whose current role they could have granted, so that
+// for example an operator with users:update cannot take over an admin. The
+// error response is written and nil returned otherwise.
+func (s *APIServer) manageableUser(w http.ResponseWriter, r *http.Request, username string) *etcdstorage.User {
+	user, err := s.storage.GetUser(r.Context(), username)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get user %s: %v", username, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get user")
+		return nil
+	}
+	if user == nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return nil
+	}
+	if !s.checkManageRole(w, r, user.Role) {
+		return nil
+	}
+	return user
+}
+
+// isLastAdmin reports whether username is the only user with the admin role
+func isLastAdmin(users []*etcdstorage.User, username string) bool {
+	admins := 0
+	last := false
+	for _, user := range users {
+		if user.Role == RoleAdmin {
+			admins++
+			last = user.Username == username
+		}
+	}
+	return admins == 1 && last
+}
+
+func userResponse(user *etcdstorage.User) map[string]interface{} {
+	return map[string]interface{}{
+		"username":            user.Username,
+		"role":                user.Role,
+		"created_at":          user.CreatedAt,
+		"password_changed_at": user.PasswordChangedAt,
+	}
+}
+
+// handleListUsers godoc
+// @Summary List users
+// @Description List the users that can log in
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]string
+// @Router /users [get]
+func (s *APIServer) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.storage.GetAllUsers(context.Background())
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to get users")
+		return
+	}
+
+	list := make([]map[string]interface{}, 0, len(users))
+	for _, user := range users {
+		list = append(list, userResponse(user))
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"users": list,
+		"count": len(list),
+	})
+}
+
+// handleCreateUser godoc
+// @Summary Create a user
+// @Description Create a user with a password and a built-in or custom role, viewer by default
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user body CreateUserRequest true "User"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /users [post]
+func (s *APIServer) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !usernamePattern.MatchString(req.Username) {
+		respondWithError(w, http.StatusBadRequest, "Username must be at most 64 lowercase letters, digits, '.', '_', '@' or '-'")
+		return
+	}
+	if req.Role == "" {
+		req.Role = RoleViewer
+	}
+	if err := validatePassword(req.Password); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if s.assignableRole(w, r, req.Role) == nil {
+		return
+	}
+
+	hash, err := HashPassword(req.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to create user")
+		return
+	}
+	now := time.Now()
+	user := &etcdstorage.User{
+		Username:          req.Username,
+		PasswordHash:      hash,
+		Role:              req.Role,
+		CreatedAt:         now,
+		PasswordChangedAt: now,
+	}
+	err = s.storage.CreateUser(context.Background(), user)
+	if errors.Is(err, etcdstorage.ErrUserExists) {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("User %s already exists", req.Username))
+		return
+	}
+	if err != nil {
+		log.Printf("[Centro REST] Failed to create user %s: %v", req.Username, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to create user")
+		return
+	}
+
+	log.Printf("[Centro REST] User %s (%s) created by %s", user.Username, user.Role, requestAuthor(r))
+	respondWithJSON(w, http.StatusCreated, userResponse(user))
+}
+
+// handleSetUserPassword godoc
+// @Summary Change the password of a user
+// @Description Users with the users:update permission can set the password of any user whose role they could grant. Other users can only change their own password and must send their current password.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "Username"
+// @Param password body SetPasswordRequest true "New password"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{username}/password [put]
+func (s *APIServer) handleSetUserPassword(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+	claims, _ := r.Context().Value("claims").(*Claims)
+	if claims == nil {
+		respondWithError(w, http.StatusForbidden, "You can only change your own password")
+		return
+	}
+	manager, err := s.can(r, permission("users", VerbUpdate))
+	if err != nil {
+		log.Printf("[Centro REST] Failed to check permissions: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+	if !manager && claims.Username != username {
+		respondWithError(w, http.StatusForbidden, "You can only change your own password")
+		return
+	}
+	if claims.Username != username && s.manageableUser(w, r, username) == nil {
+		return
+	}
+
+	var req SetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := validatePassword(req.Password); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	if !manager {
+		user, err := s.storage.GetUser(ctx, username)
+		if err != nil {
+			log.Printf("[Centro REST] Failed to get user %s: %v", username, err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to change password")
+			return
+		}
+		if user == nil || !checkPassword(user, req.CurrentPassword) {
+			respondWithError(w, http.StatusForbidden, "Current password is wrong")
+			return
+		}
+	}
+
+	hash, err := HashPassword(req.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+	user, err := s.storage.SetUserPassword(ctx, username, hash)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to change the password of %s: %v", username, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+	if user == nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	s.endLogins(ctx, username)
+
+	log.Printf("[Centro REST] Password of user %s changed by %s", username, claims.Username)
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message": fmt.Sprintf("Password of %s changed", username),
+		"user":    userResponse(user),
+	})
+}
+
+// handleDeleteUser godoc
+// @Summary Delete a user
+// @Description Delete a user whose role the caller could grant. The last admin cannot be deleted.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "Username"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /users/{username} [delete]
+func (s *APIServer) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	ctx := context.Background()
+	users, err := s.storage.GetAllUsers(ctx)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get users: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
+	var target *etcdstorage.User
+	for _, user := range users {
+		if user.Username == username {
+			target = user
+		}
+	}
+	if target == nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if !s.checkManageRole(w, r, target.Role) {
+		return
+	}
+	if isLastAdmin(users, username) {
+		respondWithError(w, http.StatusConflict, "The last admin cannot be deleted")
+		return
+	}
+
+	if _, err := s.storage.DeleteUser(ctx, username); err != nil {
+		log.Printf("[Centro REST] Failed to delete user %s: %v", username, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
+
+	s.endLogins(ctx, username)
+
+	log.Printf("[Centro REST] User %s deleted by %s", username, requestAuthor(r))
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": fmt.Sprintf("User %s deleted", username),
+	})
+}
+
+// handleSetUserRole godoc
+// @Summary Change the role of a user
+// @Description Assign a built-in or custom role to a user. Both the current and the new role must be one the caller could grant. The last admin keeps the admin role. The user gets the new role when their token is next refreshed.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param username path string true "Username"
+// @Param role body SetRoleRequest true "Role"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /users/{username}/role [put]
+func (s *APIServer) handleSetUserRole(w http.ResponseWriter, r *http.Request) {
+	username := mux.Vars(r)["username"]
+
+	var req SetRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	ctx := context.Background()
+	if s.manageableUser(w, r, username) == nil {
+		return
+	}
+	if s.assignableRole(w, r, req.Role) == nil {
+		return
+	}
+
+	if req.Role != RoleAdmin {
+		users, err := s.storage.GetAllUsers(ctx)
+		if err != nil {
+			log.Printf("[Centro REST] Failed to get users: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to change role")
+			return
+		}
+		if isLastAdmin(users, username) {
+			respondWithError(w, http.StatusConflict, "The last admin must keep the admin role")
+			return
+		}
+	}
+
+	user, err := s.storage.SetUserRole(ctx, username, req.Role)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to change the role of %s: %v", username, err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to change role")
+		return
+	}
+	if user == nil {
+		respondWithError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	log.Printf("[Centro REST] Role of user %s set to %s by %s", username, req.Role, requestAuthor(r))
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message": fmt.Sprintf("Role of %s set to %s", username, req.Role),
+		"user":    userResponse(user),
+	})
+}
@@ -0,0 +1,424 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+const watchKeepaliveInterval = 15 * time.Second
+
+var watchUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// CORS already allows every origin for the REST API
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// watchKindPermissions are the permissions needed to watch each kind of change
+var watchKindPermissions = map[string]string{
+	etcdstorage.ChangeKindDeployment: "deployments:list",
+	etcdstorage.ChangeKindInstance:   "instances:list",
+	etcdstorage.ChangeKindNode:       "nodes:list",
+	etcdstorage.ChangeKindEvent:      "events:list",
+}
+
+// watchFilter selects which changes are sent to a watcher. Empty fields match everything.
+type watchFilter struct {
+	kinds        map[string]bool
+	deploymentID string
+	nodeID       string
+	status       string
+	labels       map[string]string
+	// scope limits changes to the clusters of a role, nil for every cluster
+	scope *etcdstorage.Role
+}
+
+func parseWatchFilter(r *http.Request) (watchFilter, error) {
+	query := r.URL.Query()
+	filter := watchFilter{
+		deploymentID: query.Get("deployment_id"),
+		nodeID:       query.Get("node_id"),
+		status:       query.Get("status"),
+	}
+
+	if kinds := query.Get("kind"); kinds != "" {
+		filter.kinds = make(map[string]bool)
+		for _, kind := range strings.Split(kinds, ",") {
+			kind = strings.TrimSpace(kind)
+			switch kind {
+			case etcdstorage.ChangeKindDeployment, etcdstorage.ChangeKindInstance, etcdstorage.ChangeKindNode, etcdstorage.ChangeKindEvent:
+				filter.kinds[kind] = true
+			default:
+				return filter, fmt.Errorf("invalid kind %q, expected deployment, instance, node or event", kind)
+			}
+		}
+	}
+
+	if labels := query.Get("labels"); labels != "" {
+		filter.labels = make(map[string]string)
+		for _, label := range strings.Split(labels, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(label), "=")
+			if !ok || key == "" {
+				return filter, fmt.Errorf("invalid label selector %q, expected key=value", label)
+			}
+			filter.labels[key] = value
+		}
+	}
+
+	return filter, nil
+}
+
+// authorizeKinds limits the filter to the kinds the role may list and to the
+// clusters of the role. Kinds that were asked for must all be allowed; without
+// any, at least one kind must be. The denial and the missing permission are
+// returned otherwise.
+func (f *watchFilter) authorizeKinds(role *etcdstorage.Role, scopes []string) (reason, perm string) {
+	allowed := make(map[string]bool)
+	for _, kind := range []string{etcdstorage.ChangeKindDeployment, etcdstorage.ChangeKindInstance, etcdstorage.ChangeKindNode, etcdstorage.ChangeKindEvent} {
+		if f.kinds != nil && !f.kinds[kind] {
+			continue
+		}
+		kindPerm := watchKindPermissions[kind]
+		denied := denial(role, scopes, kindPerm)
+		switch {
+		case denied == "":
+			allowed[kind] = true
+		case f.kinds != nil:
+			return denied, kindPerm
+		case reason == "":
+			reason, perm = denied, kindPerm
+		}
+	}
+	if len(allowed) == 0 {
+		return reason, perm
+	}
+
+	f.kinds = allowed
+	if len(role.Clusters) > 0 {
+		f.scope = role
+	}
+	return "", ""
+}
+
+// inScope reports whether a change belongs to the clusters of the filter's
+// scope: nodes by their cluster, everything else by the selected clusters of
+// the deployment it belongs to
+func (f watchFilter) inScope(change *etcdstorage.Change, deploymentFor func(deploymentID string) *pb.Deployment) bool {
+	if f.scope == nil {
+		return true
+	}
+
+	if change.Kind == etcdstorage.ChangeKindNode {
+		node, ok := change.Object.(*etcdstorage.NodeInfo)
+		return ok && contains(f.scope.Clusters, node.ClusterName)
+	}
+
+	var deployment *pb.Deployment
+	switch object := change.Object.(type) {
+	case *pb.Deployment:
+		deployment = object
+	case *etcdstorage.DeploymentStatus:
+		deployment = object.Deployment
+	}
+	if deployment == nil {
+		deployment = deploymentFor(change.DeploymentID)
+	}
+	return deployment != nil && inScope(f.scope, clustersOf(deployment.SelectedClusters))
+}
+
+// matches reports whether a change passes the filter. Events and instances
+// carry no clusters and events no labels of their own, so deploymentFor is
+// used to look up the deployment they belong to.
+func (f watchFilter) matches(change *etcdstorage.Change, deploymentFor func(deploymentID string) *pb.Deployment) bool {
+	if f.kinds != nil && !f.kinds[change.Kind] {
+		return false
+	}
+	if f.deploymentID != "" && change.DeploymentID != f.deploymentID {
+		return false
+	}
+	if f.nodeID != "" && change.NodeID != f.nodeID {
+		return false
+	}
+	if f.status != "" && change.Kind != etcdstorage.ChangeKindEvent && !strings.EqualFold(change.Status, f.status) {
+		return false
+	}
+	if len(f.labels) > 0 {
+		labels := change.Labels
+		if change.Kind == etcdstorage.ChangeKindEvent {
+			if deployment := deploymentFor(change.DeploymentID); deployment != nil {
+				labels = deployment.DeploymentMetadata
+			}
+		}
+		for key, value := range f.labels {
+			if labels[key] != value {
+				return false
+			}
+		}
+	}
+	return f.inScope(change, deploymentFor)
+}
+
+// watchStartRevision returns the revision to resume from, taken from the
+// revision query parameter or the Last-Event-ID header sent by SSE clients
+func watchStartRevision(r *http.Request) (int64, error) {
+	raw := r.URL.Query().Get("revision")
+	if raw == "" {
+		raw = r.Header.Get("Last-Event-ID")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	revision, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || revision < 0 {
+		return 0, fmt.Errorf("invalid revision %q", raw)
+	}
+	return revision, nil
+}
+
+// handleWatch godoc
+// @Summary Watch cluster changes
+// @Description Stream typed change notifications for deployments, instances, nodes and events as Server-Sent Events. Each kind needs its list permission; without kind every kind the caller may list is watched. Roles limited to clusters only see changes of nodes in, and deployments selecting only, those clusters. Send "Upgrade: websocket" to receive the same changes as WebSocket JSON messages instead. Every change carries the etcd revision it was made at; pass it back as revision (or Last-Event-ID) to resume after a reconnect. Streaming clients that cannot set headers may pass the token as access_token.
+// @Tags Watch
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param kind query string false "Comma-separated kinds to watch (deployment, instance, node, event)"
+// @Param deployment_id query string false "Only changes of this deployment"
+// @Param node_id query string false "Only changes of this node"
+// @Param status query string false "Only deployments, instances and nodes with this status"
+// @Param labels query string false "Label selector, e.g. app=web,tier=frontend"
+// @Param revision query int false "Resume after this revision"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Router /watch [get]
+func (s *APIServer) handleWatch(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseWatchFilter(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	role, err := s.requestRole(r)
+	if err != nil {
+		log.Printf("[Centro REST] Failed to get role: %v", err)
+		respondWithError(w, http.StatusInternalServerError, "Failed to check permissions")
+		return
+	}
+	if reason, perm := filter.authorizeKinds(role, requestScopes(r)); reason != "" {
+		respondForbidden(w, reason, perm)
+		return
+	}
+
+	revision, err := watchStartRevision(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// Pin the start revision so that even the first message can be resumed from
+	if revision == 0 {
+		revision, err = s.storage.CurrentRevision(ctx)
+		if err != nil {
+			log.Printf("[Centro REST] Failed to get current revision: %v", err)
+			respondWithError(w, http.StatusInternalServerError, "Failed to start watch")
+			return
+		}
+	}
+
+	deploymentFor := s.deploymentLookup(ctx)
+	results := s.storage.WatchChanges(ctx, revision)
+
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWatchWebSocket(ctx, cancel, w, r, revision, filter, deploymentFor, results)
+		return
+	}
+	s.serveWatchSSE(ctx, w, revision, filter, deploymentFor, results)
+}
+
+func (s *APIServer) serveWatchSSE(ctx context.Context, w http.ResponseWriter, revision int64, filter watchFilter,
+	deploymentFor func(string) *pb.Deployment, results <-chan etcdstorage.WatchResult) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "id: %d\nevent: ready\ndata: {\"revision\": %d}\n\n", revision, revision)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(watchKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case result, ok := <-results:
+			if !ok {
+				return
+			}
+			if result.Err != nil {
+				writeSSEError(w, result.Err)
+				flusher.Flush()
+				return
+			}
+			for _, change := range result.Changes {
+				if !filter.matches(change, deploymentFor) {
+					continue
+				}
+				data, err := json.Marshal(change)
+				if err != nil {
+					log.Printf("[Centro REST] Failed to marshal change: %v", err)
+					continue
+				}
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Revision, change.Kind, data)
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSEError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if errors.Is(err, etcdstorage.ErrRevisionCompacted) {
+		code = http.StatusGone
+	}
+	data, _ := json.Marshal(map[string]interface{}{"error": err.Error(), "code": code})
+	fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+}
+
+func (s *APIServer) serveWatchWebSocket(ctx context.Context, cancel context.CancelFunc, w http.ResponseWriter, r *http.Request,
+	revision int64, filter watchFilter, deploymentFor func(string) *pb.Deployment, results <-chan etcdstorage.WatchResult) {
+	conn, err := watchUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("[Centro REST] WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// The client never sends anything; reading only detects when it goes away
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := conn.WriteJSON(map[string]interface{}{"kind": "ready", "revision": revision}); err != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(watchKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepalive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+		case result, ok := <-results:
+			if !ok {
+				return
+			}
+			if result.Err != nil {
+				code := http.StatusInternalServerError
+				if errors.Is(result.Err, etcdstorage.ErrRevisionCompacted) {
+					code = http.StatusGone
+				}
+				conn.WriteJSON(map[string]interface{}{"kind": "error", "error": result.Err.Error(), "code": code})
+				return
+			}
+			for _, change := range result.Changes {
+				if !filter.matches(change, deploymentFor) {
+					continue
+				}
+				if err := conn.WriteJSON(change); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// A watch stream caches the deployments it looked up for at most
+// watchCacheTTL, so label and cluster changes are seen, and at most
+// watchCacheSize of them
+const (
+	watchCacheTTL  = 30 * time.Second
+	watchCacheSize = 1024
+)
+
+type cachedDeployment struct {
+	deployment *pb.Deployment
+	fetchedAt  time.Time
+}
+
+// deploymentLookup returns a cached lookup of deployments, used to apply label
+// selectors and cluster scopes to changes that only carry a deployment ID.
+// Deployments that are not found are not cached, they may be created later.
+func (s *APIServer) deploymentLookup(ctx context.Context) func(deploymentID string) *pb.Deployment {
+	cache := make(map[string]cachedDeployment)
+	return func(deploymentID string) *pb.Deployment {
+		now := time.Now()
+		if cached, ok := cache[deploymentID]; ok && now.Sub(cached.fetchedAt) < watchCacheTTL {
+			return cached.deployment
+		}
+
+		var deployment *pb.Deployment
+		if status, err := s.storage.GetDeploymentActive(ctx, deploymentID); err == nil && status != nil && status.Deployment != nil {
+			deployment = status.Deployment
+		} else if status, err := s.storage.GetDeploymentHistory(ctx, deploymentID); err == nil && status != nil && status.Deployment != nil {
+			deployment = status.Deployment
+		} else if queued, err := s.storage.GetQueueDeployment(ctx, deploymentID); err == nil && queued != nil {
+			deployment = queued
+		} else if failed, err := s.storage.GetFailedDeployment(ctx, deploymentID); err == nil && failed != nil {
+			deployment = failed
+		}
+
+		if deployment == nil {
+			delete(cache, deploymentID)
+			return nil
+		}
+		if len(cache) >= watchCacheSize {
+			for id, cached := range cache {
+				if now.Sub(cached.fetchedAt) >= watchCacheTTL {
+					delete(cache, id)
+				}
+			}
+			if len(cache) >= watchCacheSize {
+				clear(cache)
+			}
+		}
+		cache[deploymentID] = cachedDeployment{deployment: deployment, fetchedAt: now}
+		return deployment
+	}
+}
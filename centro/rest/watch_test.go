@@ -0,0 +1,29 @@
+package rest
+
+import (
+	"context"
+	"testing"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+)
+
+func TestDeploymentLookupDoesNotCacheMisses(t *testing.T) {
+	ctx := context.Background()
+	storage, _ := etcdtest.NewStorage()
+	s := NewAPIServer(storage)
+	lookup := s.deploymentLookup(ctx)
+
+	if deployment := lookup("report"); deployment != nil {
+		t.Fatalf("lookup of a missing deployment returned %v", deployment)
+	}
+
+	// A deployment submitted while the watch runs is found afterwards
+	if _, _, err := storage.SubmitDeployment(ctx, &etcdstorage.DeploymentSpec{Deployment: batchDeployment("report", "report:1")}, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	deployment := lookup("report")
+	if deployment == nil || deployment.DeploymentId != "report" {
+		t.Fatalf("lookup after submit returned %v, want deployment report", deployment)
+	}
+}
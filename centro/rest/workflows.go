@@ -0,0 +1,256 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/open-scheduler/centro/spec"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// WorkflowRequest submits a DAG of deployments at once. Deployments of the
+// workflow refer to each other in depends_on by deployment_name.
+type WorkflowRequest struct {
+	WorkflowName string                         `json:"workflow_name" example:"nightly-etl"`
+	Deployments  []spec.SubmitDeploymentRequest `json:"deployments"`
+}
+
+// handleSubmitWorkflow godoc
+// @Summary Submit a workflow
+// @Description Submit several deployments that depend on each other, for example extract, transform and load steps. A depends_on entry with a name refers to the deployment of the workflow with that deployment_name. Deployments without a deployment_id get an ID derived from the workflow ID and their name, so a submission with the same Idempotency-Key is not repeated. Every deployment is labeled with the workflow_id and workflow_name meta keys.
+// @Tags Deployments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workflow body WorkflowRequest true "Workflow deployments"
+// @Param Idempotency-Key header string false "Key identifying this submission across retries"
+// @Success 200 {object} map[string]interface{}
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /workflows [post]
+func (s *APIServer) handleSubmitWorkflow(w http.ResponseWriter, r *http.Request) {
+	var req WorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Deployments) == 0 {
+		respondWithError(w, http.StatusBadRequest, "A workflow needs at least one deployment")
+		return
+	}
+
+	idempotencyKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	workflowID := uuid.New().String()
+	if idempotencyKey != "" {
+		workflowID = idempotencyID(r, "workflow/"+idempotencyKey)
+	}
+
+	deployments, order, errs := buildWorkflow(workflowID, &req)
+	if errs != nil {
+		respondWithValidationErrors(w, errs)
+		return
+	}
+	for _, deployment := range deployments {
+		if !s.checkClusterScope(w, r, "deployments:create", deployment.SelectedClusters) {
+			return
+		}
+	}
+	if !s.checkSecretReferences(w, r, deployments...) {
+		return
+	}
+	if !s.checkConfigReferences(w, r, deployments...) {
+		return
+	}
+
+	ctx := context.Background()
+	author := requestAuthor(r)
+	submitted := make([]map[string]interface{}, 0, len(order))
+	anyCreated := false
+	for _, i := range order {
+		deployment := deployments[i]
+		request, err := json.Marshal(&req.Deployments[i])
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to submit workflow")
+			return
+		}
+
+		_, created, err := s.storage.SubmitDeployment(ctx, &etcdstorage.DeploymentSpec{
+			Request:    request,
+			Deployment: deployment,
+		}, author)
+		if errors.Is(err, etcdstorage.ErrDeploymentConflict) {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("Deployment %s already exists with a different spec (%d of %d deployments submitted)",
+				deployment.DeploymentId, len(submitted), len(order)))
+			return
+		}
+		if err != nil {
+			log.Printf("[Centro REST] Failed to submit deployment %s of workflow %s: %v", deployment.DeploymentId, workflowID, err)
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to submit workflow (%d of %d deployments submitted)",
+				len(submitted), len(order)))
+			return
+		}
+		anyCreated = anyCreated || created
+
+		dependsOn := make([]string, 0, len(deployment.DependsOn))
+		for _, dependency := range deployment.DependsOn {
+			dependsOn = append(dependsOn, fmt.Sprintf("%s (%s)", dependency.DeploymentId, dependency.Condition))
+		}
+		submitted = append(submitted, map[string]interface{}{
+			"deployment_id":   deployment.DeploymentId,
+			"deployment_name": deployment.DeploymentName,
+			"depends_on":      dependsOn,
+			"created":         created,
+		})
+	}
+
+	status, message := http.StatusCreated, "Workflow submitted successfully"
+	if !anyCreated {
+		status, message = http.StatusOK, "Workflow already exists with the same spec"
+	}
+	log.Printf("[Centro REST] Workflow submitted: %s (%s, %d deployments)", workflowID, req.WorkflowName, len(submitted))
+
+	respondWithJSON(w, status, map[string]interface{}{
+		"workflow_id":   workflowID,
+		"workflow_name": req.WorkflowName,
+		"created":       anyCreated,
+		"message":       message,
+		"deployments":   submitted,
+	})
+}
+
+// buildWorkflow assigns deployment IDs, resolves depends_on names and
+// validates every deployment of a workflow. It returns the deployments and the
+// order to submit them in, upstream deployments first.
+func buildWorkflow(workflowID string, req *WorkflowRequest) ([]*pb.Deployment, []int, spec.Errors) {
+	var errs spec.Errors
+	addError := func(field, format string, args ...interface{}) {
+		errs = append(errs, spec.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	ids := make([]string, len(req.Deployments))
+	byName := make(map[string]int)
+	for i := range req.Deployments {
+		item := &req.Deployments[i]
+		if first, ok := byName[item.DeploymentName]; ok && item.DeploymentName != "" {
+			addError(fmt.Sprintf("deployments[%d].deployment_name", i), "%s is already used by deployments[%d]", item.DeploymentName, first)
+		} else {
+			byName[item.DeploymentName] = i
+		}
+
+		for _, fieldErr := range spec.ValidateClientID(item.DeploymentId) {
+			addError(fmt.Sprintf("deployments[%d].%s", i, fieldErr.Field), "%s", fieldErr.Message)
+		}
+		ids[i] = item.DeploymentId
+		if ids[i] == "" {
+			ids[i] = uuid.NewSHA1(idempotencyKeyNamespace, []byte(workflowID+"/"+item.DeploymentName)).String()
+		}
+		item.DeploymentId = ids[i]
+	}
+
+	byID := make(map[string]int, len(ids))
+	for i, id := range ids {
+		byID[id] = i
+	}
+
+	upstreams := make([][]int, len(req.Deployments))
+	deployments := make([]*pb.Deployment, len(req.Deployments))
+	for i := range req.Deployments {
+		item := &req.Deployments[i]
+		for j := range item.DependsOn {
+			dependency := &item.DependsOn[j]
+			field := fmt.Sprintf("deployments[%d].depends_on[%d]", i, j)
+			if dependency.Name != "" {
+				upstream, ok := byName[dependency.Name]
+				switch {
+				case !ok:
+					addError(field+".name", "no deployment of the workflow is named %s", dependency.Name)
+				case dependency.DeploymentID != "" && dependency.DeploymentID != ids[upstream]:
+					addError(field, "set either name or deployment_id")
+				default:
+					dependency.DeploymentID = ids[upstream]
+				}
+			}
+			if upstream, ok := byID[dependency.DeploymentID]; ok {
+				upstreams[i] = append(upstreams[i], upstream)
+			}
+		}
+
+		if item.Meta == nil {
+			item.Meta = make(map[string]string)
+		}
+		item.Meta["workflow_id"] = workflowID
+		if req.WorkflowName != "" {
+			item.Meta["workflow_name"] = req.WorkflowName
+		}
+
+		deployments[i] = item.ToDeployment(ids[i])
+		for _, fieldErr := range spec.ValidateDeployment(deployments[i]) {
+			addError(fmt.Sprintf("deployments[%d].%s", i, fieldErr.Field), "%s", fieldErr.Message)
+		}
+	}
+
+	order, cycle := workflowOrder(upstreams)
+	if len(cycle) > 0 {
+		names := make([]string, 0, len(cycle))
+		for _, i := range cycle {
+			names = append(names, req.Deployments[i].DeploymentName)
+		}
+		sort.Strings(names)
+		addError("deployments", "dependency cycle between %s", strings.Join(names, ", "))
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+	return deployments, order, nil
+}
+
+// workflowOrder sorts the deployments of a workflow so that every deployment
+// comes after its upstream deployments. The deployments left over when the
+// dependencies form a cycle are returned as cycle.
+func workflowOrder(upstreams [][]int) (order, cycle []int) {
+	pending := make([]int, len(upstreams))
+	downstreams := make([][]int, len(upstreams))
+	for i, list := range upstreams {
+		pending[i] = len(list)
+		for _, upstream := range list {
+			downstreams[upstream] = append(downstreams[upstream], i)
+		}
+	}
+
+	var ready []int
+	for i, count := range pending {
+		if count == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		next := ready[0]
+		ready = ready[1:]
+		order = append(order, next)
+		for _, downstream := range downstreams[next] {
+			pending[downstream]--
+			if pending[downstream] == 0 {
+				ready = append(ready, downstream)
+			}
+		}
+	}
+
+	for i, count := range pending {
+		if count > 0 {
+			cycle = append(cycle, i)
+		}
+	}
+	return order, cycle
+}
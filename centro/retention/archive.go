@@ -0,0 +1,78 @@
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	RecordKindHistory      = "history"
+	RecordKindEvent        = "event"
+	RecordKindInstanceData = "instance_data"
+)
+
+// ArchivedRecord is a single line of an archive file
+type ArchivedRecord struct {
+	Kind         string      `json:"kind"`
+	DeploymentID string      `json:"deployment_id"`
+	ArchivedAt   time.Time   `json:"archived_at"`
+	Record       interface{} `json:"record"`
+}
+
+// Archiver appends pruned records to daily JSONL files in a directory
+// (centro-archive-YYYY-MM-DD.jsonl) before they are deleted from etcd.
+type Archiver struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewArchiver(dir string) (*Archiver, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	return &Archiver{dir: dir}, nil
+}
+
+// Write appends the records to today's archive file. Either all records are
+// written or an error is returned, so callers only delete what was archived.
+func (a *Archiver) Write(records []ArchivedRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	var buf []byte
+	for _, record := range records {
+		if record.ArchivedAt.IsZero() {
+			record.ArchivedAt = now
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal archived record: %w", err)
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	path := filepath.Join(a.dir, fmt.Sprintf("centro-archive-%s.jsonl", now.Format("2006-01-02")))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open archive file: %w", err)
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,387 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// Policy limits how long records of a deployment are kept. Zero values mean no limit.
+type Policy struct {
+	// MaxAge removes finished deployments (history, events and instance data)
+	// and individual events older than this
+	MaxAge time.Duration
+	// MaxCount keeps at most this many finished deployments of a deployment type
+	MaxCount int
+	// MaxEvents keeps at most this many events per deployment
+	MaxEvents int
+}
+
+func (p Policy) IsZero() bool {
+	return p.MaxAge == 0 && p.MaxCount == 0 && p.MaxEvents == 0
+}
+
+// merge returns p with every limit that is set in override replaced
+func (p Policy) merge(override Policy) Policy {
+	if override.MaxAge > 0 {
+		p.MaxAge = override.MaxAge
+	}
+	if override.MaxCount > 0 {
+		p.MaxCount = override.MaxCount
+	}
+	if override.MaxEvents > 0 {
+		p.MaxEvents = override.MaxEvents
+	}
+	return p
+}
+
+type Config struct {
+	// Default applies to every deployment type
+	Default Policy
+	// PerType overrides limits of the default policy for a deployment type ("service", "batch", ...)
+	PerType map[string]Policy
+	// ArchiveDir is where pruned records are written as JSONL before deletion (empty = no archive)
+	ArchiveDir string
+	// Interval between garbage collection runs
+	Interval time.Duration
+}
+
+// PolicyFor returns the effective policy of a deployment type
+func (c Config) PolicyFor(deploymentType string) Policy {
+	policy := c.Default
+	if override, ok := c.PerType[deploymentType]; ok {
+		policy = policy.merge(override)
+	}
+	return policy
+}
+
+func (c Config) enabled() bool {
+	if !c.Default.IsZero() {
+		return true
+	}
+	for _, policy := range c.PerType {
+		if !policy.IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTypePolicies parses per deployment type policies in the form
+// "batch:max-age=24h,max-count=500;service:max-events=200"
+func ParseTypePolicies(value string) (map[string]Policy, error) {
+	policies := make(map[string]Policy)
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return policies, nil
+	}
+
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		deploymentType, limits, ok := strings.Cut(entry, ":")
+		deploymentType = strings.TrimSpace(deploymentType)
+		if !ok || deploymentType == "" {
+			return nil, fmt.Errorf("invalid retention policy %q, expected <type>:<limit>=<value>,...", entry)
+		}
+
+		var policy Policy
+		for _, limit := range strings.Split(limits, ",") {
+			name, raw, ok := strings.Cut(strings.TrimSpace(limit), "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid retention limit %q for type %s", limit, deploymentType)
+			}
+			switch strings.TrimSpace(name) {
+			case "max-age":
+				d, err := time.ParseDuration(strings.TrimSpace(raw))
+				if err != nil || d < 0 {
+					return nil, fmt.Errorf("invalid max-age %q for type %s", raw, deploymentType)
+				}
+				policy.MaxAge = d
+			case "max-count":
+				n, err := strconv.Atoi(strings.TrimSpace(raw))
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid max-count %q for type %s", raw, deploymentType)
+				}
+				policy.MaxCount = n
+			case "max-events":
+				n, err := strconv.Atoi(strings.TrimSpace(raw))
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid max-events %q for type %s", raw, deploymentType)
+				}
+				policy.MaxEvents = n
+			default:
+				return nil, fmt.Errorf("unknown retention limit %q for type %s", name, deploymentType)
+			}
+		}
+		policies[deploymentType] = policy
+	}
+
+	return policies, nil
+}
+
+// Collector periodically prunes deployment history, events and instance data
+// according to the configured policies. It must only run on the leader.
+type Collector struct {
+	storage  *etcdstorage.Storage
+	config   Config
+	archiver *Archiver
+}
+
+func NewCollector(storage *etcdstorage.Storage, config Config) (*Collector, error) {
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Minute
+	}
+
+	collector := &Collector{storage: storage, config: config}
+	if config.ArchiveDir != "" {
+		archiver, err := NewArchiver(config.ArchiveDir)
+		if err != nil {
+			return nil, err
+		}
+		collector.archiver = archiver
+	}
+	return collector, nil
+}
+
+func (c *Collector) Run(ctx context.Context) {
+	if !c.config.enabled() {
+		log.Printf("[Retention] No retention policy configured, garbage collection disabled")
+		return
+	}
+
+	log.Printf("[Retention] Starting garbage collection loop (interval: %s)", c.config.Interval)
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+
+	c.collect(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			c.collect(ctx)
+		case <-ctx.Done():
+			log.Printf("[Retention] Stopping garbage collection loop")
+			return
+		}
+	}
+}
+
+type historyEntry struct {
+	deploymentID string
+	status       *etcdstorage.DeploymentStatus
+}
+
+func (c *Collector) collect(ctx context.Context) {
+	now := time.Now()
+
+	// Instance data is read first so that every record it belongs to is
+	// already stored somewhere by the time the other keys are listed.
+	instances, err := c.storage.GetAllInstanceData(ctx)
+	if err != nil {
+		log.Printf("[Retention] Failed to get instance data: %v", err)
+		return
+	}
+
+	deploymentTypes, err := c.liveDeploymentTypes(ctx)
+	if err != nil {
+		log.Printf("[Retention] Failed to get deployments: %v", err)
+		return
+	}
+
+	history, err := c.storage.GetAllDeploymentHistory(ctx)
+	if err != nil {
+		log.Printf("[Retention] Failed to get deployment history: %v", err)
+		return
+	}
+
+	byType := make(map[string][]historyEntry)
+	for deploymentID, status := range history {
+		deploymentType := ""
+		if status.Deployment != nil {
+			deploymentType = status.Deployment.DeploymentType
+		}
+		deploymentTypes[deploymentID] = deploymentType
+		byType[deploymentType] = append(byType[deploymentType], historyEntry{deploymentID: deploymentID, status: status})
+	}
+
+	removed := make(map[string]bool)
+	for deploymentType, entries := range byType {
+		policy := c.config.PolicyFor(deploymentType)
+		if policy.MaxAge == 0 && policy.MaxCount == 0 {
+			continue
+		}
+
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].status.UpdatedAt.After(entries[j].status.UpdatedAt)
+		})
+
+		for i, entry := range entries {
+			expired := policy.MaxAge > 0 && now.Sub(entry.status.UpdatedAt) > policy.MaxAge
+			overLimit := policy.MaxCount > 0 && i >= policy.MaxCount
+			if !expired && !overLimit {
+				continue
+			}
+			if err := c.pruneDeployment(ctx, entry.deploymentID, entry.status, instances[entry.deploymentID]); err != nil {
+				log.Printf("[Retention] Failed to prune deployment %s: %v", entry.deploymentID, err)
+				continue
+			}
+			removed[entry.deploymentID] = true
+		}
+	}
+
+	prunedEvents := 0
+	eventDeploymentIDs, err := c.storage.GetDeploymentIDsWithEvents(ctx)
+	if err != nil {
+		log.Printf("[Retention] Failed to list deployment events: %v", err)
+	} else {
+		for _, deploymentID := range eventDeploymentIDs {
+			if removed[deploymentID] {
+				continue
+			}
+			n, err := c.pruneEvents(ctx, deploymentID, c.config.PolicyFor(deploymentTypes[deploymentID]), now)
+			if err != nil {
+				log.Printf("[Retention] Failed to prune events of deployment %s: %v", deploymentID, err)
+				continue
+			}
+			prunedEvents += n
+		}
+	}
+
+	// Instance data without any deployment record left can never be shown again
+	prunedInstances := 0
+	for deploymentID, instanceData := range instances {
+		if _, known := deploymentTypes[deploymentID]; known || removed[deploymentID] {
+			continue
+		}
+		if err := c.archive([]ArchivedRecord{{Kind: RecordKindInstanceData, DeploymentID: deploymentID, Record: instanceData}}); err != nil {
+			log.Printf("[Retention] Failed to archive instance data of deployment %s: %v", deploymentID, err)
+			continue
+		}
+		if err := c.storage.DeleteInstanceData(ctx, deploymentID); err != nil {
+			log.Printf("[Retention] %v", err)
+			continue
+		}
+		prunedInstances++
+	}
+
+	if len(removed) > 0 || prunedEvents > 0 || prunedInstances > 0 {
+		log.Printf("[Retention] Pruned %d finished deployments, %d events and %d orphaned instance data records",
+			len(removed), prunedEvents, prunedInstances)
+	}
+}
+
+// liveDeploymentTypes returns the deployment type of every queued, failed or active deployment
+func (c *Collector) liveDeploymentTypes(ctx context.Context) (map[string]string, error) {
+	deploymentTypes := make(map[string]string)
+
+	queued, err := c.storage.GetQueueDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range queued {
+		deploymentTypes[deployment.DeploymentId] = deployment.DeploymentType
+	}
+
+	failed, err := c.storage.GetAllFailedDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for deploymentID, deployment := range failed {
+		deploymentTypes[deploymentID] = deployment.DeploymentType
+	}
+
+	active, err := c.storage.GetAllActiveDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for deploymentID, status := range active {
+		deploymentType := ""
+		if status.Deployment != nil {
+			deploymentType = status.Deployment.DeploymentType
+		}
+		deploymentTypes[deploymentID] = deploymentType
+	}
+
+	return deploymentTypes, nil
+}
+
+func (c *Collector) pruneDeployment(ctx context.Context, deploymentID string, status *etcdstorage.DeploymentStatus, instanceData *pb.InstanceData) error {
+	if c.archiver != nil {
+		records := []ArchivedRecord{{Kind: RecordKindHistory, DeploymentID: deploymentID, Record: status}}
+		events, err := c.storage.GetDeploymentEvents(ctx, deploymentID, etcdstorage.EventFilter{})
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			records = append(records, ArchivedRecord{Kind: RecordKindEvent, DeploymentID: deploymentID, Record: event})
+		}
+		if instanceData != nil {
+			records = append(records, ArchivedRecord{Kind: RecordKindInstanceData, DeploymentID: deploymentID, Record: instanceData})
+		}
+		if err := c.archive(records); err != nil {
+			return err
+		}
+	}
+
+	return c.storage.DeleteDeploymentRecords(ctx, deploymentID)
+}
+
+func (c *Collector) pruneEvents(ctx context.Context, deploymentID string, policy Policy, now time.Time) (int, error) {
+	if policy.MaxAge == 0 && policy.MaxEvents == 0 {
+		return 0, nil
+	}
+
+	events, err := c.storage.GetDeploymentEvents(ctx, deploymentID, etcdstorage.EventFilter{})
+	if err != nil {
+		return 0, err
+	}
+
+	var cutoff time.Time
+	if policy.MaxAge > 0 {
+		cutoff = now.Add(-policy.MaxAge)
+	}
+	if policy.MaxEvents > 0 && len(events) > policy.MaxEvents {
+		if keepFrom := events[len(events)-policy.MaxEvents].Time; keepFrom.After(cutoff) {
+			cutoff = keepFrom
+		}
+	}
+	if cutoff.IsZero() {
+		return 0, nil
+	}
+
+	// Legacy events only have a time in seconds and can be out of order with
+	// the events around them, so every event is checked
+	var records []ArchivedRecord
+	for _, event := range events {
+		if !event.Time.Before(cutoff) {
+			continue
+		}
+		records = append(records, ArchivedRecord{Kind: RecordKindEvent, DeploymentID: deploymentID, Record: event})
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	if err := c.archive(records); err != nil {
+		return 0, err
+	}
+
+	deleted, err := c.storage.DeleteDeploymentEventsBefore(ctx, deploymentID, cutoff)
+	return int(deleted), err
+}
+
+func (c *Collector) archive(records []ArchivedRecord) error {
+	if c.archiver == nil {
+		return nil
+	}
+	return c.archiver.Write(records)
+}
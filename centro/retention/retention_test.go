@@ -0,0 +1,159 @@
+package retention
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+)
+
+func readArchive(t *testing.T, dir string) []ArchivedRecord {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "centro-archive-*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []ArchivedRecord
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var record ArchivedRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("invalid archive line %q: %v", scanner.Text(), err)
+			}
+			records = append(records, record)
+		}
+		f.Close()
+	}
+	return records
+}
+
+func TestPruneEventsWithLegacyEvents(t *testing.T) {
+	ctx := context.Background()
+	storage, client := etcdtest.NewStorage()
+	dir := t.TempDir()
+	collector, err := NewCollector(storage, Config{ArchiveDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const deploymentID = "deployment-1"
+	cutoff := time.Date(2024, 3, 1, 12, 0, 0, 500_000_000, time.UTC)
+
+	// Legacy events carry their time in seconds in the value, while the key
+	// holds the nanosecond time of the write
+	putLegacy := func(key time.Time, message string) {
+		t.Helper()
+		value := fmt.Sprintf("[%s] %s", key.Format(time.RFC3339), message)
+		if _, err := client.Put(ctx, fmt.Sprintf("/centro/deployments/events/%s/%d", deploymentID, key.UnixNano()), value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save := func(at time.Time, reason string) {
+		t.Helper()
+		event := &etcdstorage.DeploymentEvent{Time: at, Type: etcdstorage.EventTypeNormal, Reason: reason, Message: reason}
+		if err := storage.SaveDeploymentEvent(ctx, deploymentID, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	putLegacy(cutoff.Add(-1200*time.Millisecond), "legacy before")
+	save(cutoff.Add(-400*time.Millisecond), "structured before")
+	putLegacy(cutoff.Add(-300*time.Millisecond), "legacy same second")
+	save(cutoff.Add(100*time.Millisecond), "structured after")
+	// Written after the cutoff, but its time is truncated to before it
+	putLegacy(cutoff.Add(200*time.Millisecond), "legacy truncated")
+	putLegacy(cutoff.Add(700*time.Millisecond), "legacy after")
+
+	policy := Policy{MaxAge: time.Hour}
+	now := cutoff.Add(time.Hour)
+
+	pruned, err := collector.pruneEvents(ctx, deploymentID, policy, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 4 {
+		t.Errorf("pruned %d events, want 4", pruned)
+	}
+
+	archived := readArchive(t, dir)
+	if len(archived) != pruned {
+		t.Errorf("archived %d events but deleted %d", len(archived), pruned)
+	}
+
+	remaining, err := storage.GetDeploymentEvents(ctx, deploymentID, etcdstorage.EventFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, event := range remaining {
+		messages = append(messages, event.Message)
+		if event.Time.Before(cutoff) {
+			t.Errorf("event %q from %s was kept", event.Message, event.Time)
+		}
+	}
+	if len(messages) != 2 || messages[0] != "structured after" || messages[1] != "legacy after" {
+		t.Errorf("remaining events = %q, want [structured after, legacy after]", messages)
+	}
+
+	// A second run must neither delete nor archive anything again
+	pruned, err = collector.pruneEvents(ctx, deploymentID, policy, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 0 {
+		t.Errorf("second run pruned %d events, want 0", pruned)
+	}
+	if archived := readArchive(t, dir); len(archived) != 4 {
+		t.Errorf("second run archived events again, %d records in archive", len(archived))
+	}
+}
+
+func TestPruneEventsMaxEvents(t *testing.T) {
+	ctx := context.Background()
+	storage, _ := etcdtest.NewStorage()
+	collector, err := NewCollector(storage, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const deploymentID = "deployment-2"
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		event := &etcdstorage.DeploymentEvent{
+			Time:    start.Add(time.Duration(i) * time.Minute),
+			Type:    etcdstorage.EventTypeNormal,
+			Message: fmt.Sprintf("event %d", i),
+		}
+		if err := storage.SaveDeploymentEvent(ctx, deploymentID, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruned, err := collector.pruneEvents(ctx, deploymentID, Policy{MaxEvents: 2}, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 3 {
+		t.Errorf("pruned %d events, want 3", pruned)
+	}
+
+	remaining, err := storage.GetDeploymentEvents(ctx, deploymentID, etcdstorage.EventFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 2 || remaining[0].Message != "event 3" || remaining[1].Message != "event 4" {
+		t.Errorf("remaining events = %+v, want event 3 and event 4", remaining)
+	}
+}
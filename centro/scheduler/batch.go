@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// reconcileBatch starts the replica units of a batch deployment once and
+// records the outcome of the batch in the history of the deployment: completed
+// when every unit completed, failed as soon as one unit failed for good. Units
+// are retried by their retry policy like any other deployment.
+func (r *Reconciler) reconcileBatch(ctx context.Context, spec *etcdstorage.DeploymentSpec, units []*replicaUnit) error {
+	deploymentID := spec.DeploymentID
+	finished, err := r.storage.GetDeploymentHistory(ctx, deploymentID)
+	if err != nil {
+		return err
+	}
+	if finished != nil {
+		// Units left over from a failed batch
+		for _, unit := range units {
+			if err := r.stopUnit(ctx, unit); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	state, err := r.storage.GetBatchState(ctx, deploymentID)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return r.startBatch(ctx, spec)
+	}
+
+	live := make(map[string]bool, len(units))
+	for _, unit := range units {
+		live[unit.id()] = true
+	}
+
+	completed := 0
+	for _, unitID := range state.Units {
+		if live[unitID] {
+			continue
+		}
+		record, err := r.storage.GetDeploymentHistory(ctx, unitID)
+		if err != nil {
+			return err
+		}
+		switch {
+		case record == nil:
+			// Claimed by an agent that has not reported yet
+		case record.Status == "completed":
+			completed++
+		default:
+			return r.finishBatch(ctx, spec, state, units, "failed",
+				fmt.Sprintf("Replica %s %s: %s", unitID, record.Status, record.Detail))
+		}
+	}
+
+	if completed == len(state.Units) {
+		return r.finishBatch(ctx, spec, state, nil, "completed", fmt.Sprintf("All %d replicas completed", completed))
+	}
+	return nil
+}
+
+// startBatch queues every replica unit of a batch deployment
+func (r *Reconciler) startBatch(ctx context.Context, spec *etcdstorage.DeploymentSpec) error {
+	state := &etcdstorage.BatchState{
+		DeploymentID: spec.DeploymentID,
+		SpecRevision: spec.Revision,
+		StartedAt:    time.Now(),
+	}
+	units := make([]*pb.Deployment, 0, spec.Deployment.Replicas)
+	for n := int64(1); n <= int64(spec.Deployment.Replicas); n++ {
+		unit := newUnit(spec, n)
+		units = append(units, unit)
+		state.Units = append(state.Units, unit.DeploymentId)
+	}
+
+	// Save the units first so that a batch is never started twice
+	if err := r.storage.SaveBatchState(ctx, state); err != nil {
+		return err
+	}
+
+	for _, unit := range units {
+		if err := r.storage.EnqueueDeployment(ctx, unit); err != nil {
+			log.Printf("[Reconciler] Failed to queue replica %s: %v", unit.DeploymentId, err)
+		}
+	}
+
+	r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeNormal, "ReplicasStarted",
+		fmt.Sprintf("Started %d replica(s) of revision %d: %v", len(units), spec.Revision, state.Units))
+	log.Printf("[Reconciler] Batch %s: started replicas %v", spec.DeploymentID, state.Units)
+	return nil
+}
+
+// finishBatch stops the remaining units of a batch deployment and records its final status
+func (r *Reconciler) finishBatch(ctx context.Context, spec *etcdstorage.DeploymentSpec, state *etcdstorage.BatchState,
+	units []*replicaUnit, status, detail string) error {
+	for _, unit := range units {
+		if err := r.stopUnit(ctx, unit); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	if err := r.storage.SaveDeploymentHistory(ctx, spec.DeploymentID, &etcdstorage.DeploymentStatus{
+		Deployment: spec.Deployment,
+		Status:     status,
+		Detail:     detail,
+		UpdatedAt:  now,
+		ClaimedAt:  state.StartedAt,
+		StartedAt:  state.StartedAt,
+	}); err != nil {
+		return err
+	}
+
+	eventType, reason := etcdstorage.EventTypeNormal, "Completed"
+	if status == "failed" {
+		eventType, reason = etcdstorage.EventTypeWarning, "Failed"
+	}
+	r.saveEvent(ctx, spec.DeploymentID, eventType, reason, detail)
+	log.Printf("[Reconciler] Batch %s %s: %s", spec.DeploymentID, status, detail)
+	return nil
+}
@@ -0,0 +1,208 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// reconcileDependencies keeps a deployment with depends_on blocked until the
+// condition of every upstream deployment is met and reports whether it may
+// run. A deployment that is not managed otherwise is queued when it is
+// released. A deployment whose conditions can no longer be met is recorded in
+// its history as upstream_failed, which in turn fails the deployments that
+// wait for it to succeed.
+func (r *Reconciler) reconcileDependencies(ctx context.Context, spec *etcdstorage.DeploymentSpec,
+	specs map[string]*etcdstorage.DeploymentSpec) (bool, error) {
+	state, err := r.storage.GetDependencyState(ctx, spec.DeploymentID)
+	if err != nil {
+		return false, err
+	}
+	if state != nil && state.Status == etcdstorage.DependencyStatusReleased {
+		return true, nil
+	}
+	if state != nil && state.Status == etcdstorage.DependencyStatusUpstreamFailed {
+		return false, nil
+	}
+
+	var waiting, failed []string
+	for _, dependency := range spec.Deployment.DependsOn {
+		status, unmet, err := r.upstreamStatus(ctx, dependency.DeploymentId, specs)
+		if err != nil {
+			return false, err
+		}
+		if unmet == "" {
+			var met bool
+			met, unmet = dependencyMet(dependency, status)
+			if met {
+				continue
+			}
+		}
+		if unmet != "" {
+			failed = append(failed, unmet)
+		} else {
+			waiting = append(waiting, waitingFor(dependency))
+		}
+	}
+
+	if state == nil {
+		state = &etcdstorage.DependencyState{DeploymentID: spec.DeploymentID, Status: etcdstorage.DependencyStatusBlocked}
+	}
+
+	switch {
+	case len(failed) > 0:
+		return false, r.failUpstream(ctx, spec, state, strings.Join(failed, "; "))
+	case len(waiting) > 0:
+		message := "Waiting for " + strings.Join(waiting, ", ")
+		if state.Message == message {
+			return false, nil
+		}
+		if state.Message == "" {
+			r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeNormal, "Blocked", message)
+		}
+		state.Message = message
+		return false, r.storage.SaveDependencyState(ctx, state)
+	}
+
+	if err := r.release(ctx, spec, state); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// upstreamStatus returns the final status of an upstream deployment, or ""
+// while it has not finished. unmet explains why it will never finish.
+func (r *Reconciler) upstreamStatus(ctx context.Context, deploymentID string,
+	specs map[string]*etcdstorage.DeploymentSpec) (status, unmet string, err error) {
+	record, err := r.storage.GetDeploymentHistory(ctx, deploymentID)
+	if err != nil {
+		return "", "", err
+	}
+	if record != nil {
+		return record.Status, "", nil
+	}
+
+	if spec, ok := specs[deploymentID]; ok {
+		deployment := spec.Deployment
+		if etcdstorage.IsManagedService(deployment) || etcdstorage.IsManagedSystem(deployment) || etcdstorage.IsManagedPeriodic(deployment) {
+			return "", fmt.Sprintf("%s is a %s deployment and never finishes", deploymentID, upstreamKind(deployment)), nil
+		}
+		return "", "", nil
+	}
+
+	// Deployments submitted before specs were stored only exist in the queues
+	if deployment, err := r.storage.GetQueueDeployment(ctx, deploymentID); err != nil || deployment != nil {
+		return "", "", err
+	}
+	if deployment, err := r.storage.GetFailedDeployment(ctx, deploymentID); err != nil || deployment != nil {
+		return "", "", err
+	}
+	if active, err := r.storage.GetDeploymentActive(ctx, deploymentID); err != nil || active != nil {
+		return "", "", err
+	}
+	return "", fmt.Sprintf("%s does not exist", deploymentID), nil
+}
+
+func upstreamKind(deployment *pb.Deployment) string {
+	if etcdstorage.IsManagedPeriodic(deployment) {
+		return "periodic"
+	}
+	return deployment.DeploymentType
+}
+
+func dependencyCondition(dependency *pb.Dependency) string {
+	if dependency.Condition == "" {
+		return etcdstorage.DependencySucceeded
+	}
+	return dependency.Condition
+}
+
+// waitingFor describes what a blocked deployment waits for, e.g. "extract to succeed"
+func waitingFor(dependency *pb.Dependency) string {
+	switch dependencyCondition(dependency) {
+	case etcdstorage.DependencyFailed:
+		return dependency.DeploymentId + " to fail"
+	case etcdstorage.DependencyCompleted:
+		return dependency.DeploymentId + " to finish"
+	default:
+		return dependency.DeploymentId + " to succeed"
+	}
+}
+
+// dependencyMet reports whether an upstream deployment with the given final
+// status meets the condition of a dependency. A reason is returned when the
+// condition can no longer be met.
+func dependencyMet(dependency *pb.Dependency, status string) (bool, string) {
+	if status == "" {
+		return false, ""
+	}
+
+	upstream := dependency.DeploymentId
+	switch dependencyCondition(dependency) {
+	case etcdstorage.DependencyCompleted:
+		return true, ""
+	case etcdstorage.DependencyFailed:
+		if status == "completed" {
+			return false, fmt.Sprintf("%s succeeded, expected it to fail", upstream)
+		}
+		return true, ""
+	default:
+		if status != "completed" {
+			return false, fmt.Sprintf("%s finished with status %s", upstream, status)
+		}
+		return true, ""
+	}
+}
+
+// release lets a deployment run once its dependencies are met. Deployments
+// that are not managed otherwise are queued here.
+func (r *Reconciler) release(ctx context.Context, spec *etcdstorage.DeploymentSpec, state *etcdstorage.DependencyState) error {
+	state.Status = etcdstorage.DependencyStatusReleased
+	state.Message = "All dependencies are met"
+	state.ReleasedAt = time.Now()
+
+	// Save the state first so that a deployment is never queued twice
+	if err := r.storage.SaveDependencyState(ctx, state); err != nil {
+		return err
+	}
+
+	deployment := spec.Deployment
+	if !etcdstorage.IsManagedService(deployment) && !etcdstorage.IsManagedBatch(deployment) && !etcdstorage.IsManagedSystem(deployment) {
+		if err := r.storage.EnqueueDeployment(ctx, deployment); err != nil {
+			return fmt.Errorf("failed to queue released deployment: %w", err)
+		}
+	}
+
+	r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeNormal, "Unblocked", "All dependencies are met, starting the deployment")
+	log.Printf("[Reconciler] Deployment %s released, all dependencies are met", spec.DeploymentID)
+	return nil
+}
+
+// failUpstream records that a deployment will never run because the condition
+// of an upstream deployment can no longer be met
+func (r *Reconciler) failUpstream(ctx context.Context, spec *etcdstorage.DeploymentSpec, state *etcdstorage.DependencyState, reason string) error {
+	state.Status = etcdstorage.DependencyStatusUpstreamFailed
+	state.Message = reason
+
+	now := time.Now()
+	if err := r.storage.SaveDeploymentHistory(ctx, spec.DeploymentID, &etcdstorage.DeploymentStatus{
+		Deployment: spec.Deployment,
+		Status:     etcdstorage.DependencyStatusUpstreamFailed,
+		Detail:     "Upstream dependency failed: " + reason,
+		UpdatedAt:  now,
+	}); err != nil {
+		return err
+	}
+	if err := r.storage.SaveDependencyState(ctx, state); err != nil {
+		return err
+	}
+
+	r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeWarning, "UpstreamFailed", reason)
+	log.Printf("[Reconciler] Deployment %s failed, upstream dependency failed: %s", spec.DeploymentID, reason)
+	return nil
+}
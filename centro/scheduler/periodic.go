@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/open-scheduler/centro/periodic"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// reconcilePeriodic launches a child deployment of a periodic deployment each
+// time its cron schedule fires and records the launch in its history. Launches
+// missed while no leader was running are collapsed into a single launch. With
+// prohibit_overlap a launch is skipped while a child of an earlier launch is
+// still queued, retrying or running.
+func (r *Reconciler) reconcilePeriodic(ctx context.Context, spec *etcdstorage.DeploymentSpec, units []*replicaUnit) error {
+	schedule, err := periodic.ParseSchedule(spec.Deployment.Periodic)
+	if err != nil {
+		return err
+	}
+
+	state, err := r.storage.GetPeriodicState(ctx, spec.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if state == nil || state.SpecRevision != spec.Revision {
+		// A new or changed schedule starts counting from now
+		if state == nil {
+			state = &etcdstorage.PeriodicState{DeploymentID: spec.DeploymentID}
+		}
+		state.SpecRevision = spec.Revision
+		state.NextLaunch = schedule.Next(now)
+		log.Printf("[Reconciler] Periodic deployment %s: next launch at %s", spec.DeploymentID, state.NextLaunch.Format(time.RFC3339))
+		return r.storage.SavePeriodicState(ctx, state)
+	}
+
+	if now.Before(state.NextLaunch) {
+		return nil
+	}
+
+	launch := &etcdstorage.PeriodicLaunch{
+		SpecRevision: spec.Revision,
+		ScheduledAt:  state.NextLaunch,
+		LaunchedAt:   now,
+	}
+	state.NextLaunch = schedule.Next(now)
+
+	if spec.Deployment.Periodic.ProhibitOverlap && len(units) > 0 {
+		launch.Skipped = fmt.Sprintf("previous launch %s is still %s", units[0].id(), units[0].state)
+		state.AddLaunch(launch)
+		if err := r.storage.SavePeriodicState(ctx, state); err != nil {
+			return err
+		}
+		r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeWarning, "LaunchSkipped",
+			fmt.Sprintf("Skipped the launch scheduled at %s: %s", launch.ScheduledAt.Format(time.RFC3339), launch.Skipped))
+		log.Printf("[Reconciler] Periodic deployment %s: skipped launch, %s", spec.DeploymentID, launch.Skipped)
+		return nil
+	}
+
+	child := newPeriodicChild(spec, launch.ScheduledAt)
+	launch.DeploymentID = child.DeploymentId
+	state.AddLaunch(launch)
+
+	// Save the launch first so that a scheduled launch is never started twice
+	if err := r.storage.SavePeriodicState(ctx, state); err != nil {
+		return err
+	}
+	if err := r.storage.EnqueueDeployment(ctx, child); err != nil {
+		return fmt.Errorf("failed to queue launch %s: %w", child.DeploymentId, err)
+	}
+
+	r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeNormal, "Launched",
+		fmt.Sprintf("Launched %s of revision %d, scheduled at %s", child.DeploymentId, spec.Revision, launch.ScheduledAt.Format(time.RFC3339)))
+	log.Printf("[Reconciler] Periodic deployment %s: launched %s", spec.DeploymentID, child.DeploymentId)
+	return nil
+}
+
+// newPeriodicChild returns the child deployment of a launch of a periodic
+// deployment. A launch runs a single replica, spec.ValidateDeployment rejects
+// periodic deployments with more.
+func newPeriodicChild(spec *etcdstorage.DeploymentSpec, scheduledAt time.Time) *pb.Deployment {
+	child := newUnit(spec, 0)
+	child.DeploymentId = etcdstorage.PeriodicChildID(spec.DeploymentID, scheduledAt)
+	child.DeploymentType = "batch"
+	child.Periodic = nil
+	return child
+}
@@ -59,9 +59,13 @@ func (q *Queue) moveFailedJobsToQueue(ctx context.Context) {
 			}
 
 			// Save event
-			if err := q.storage.SaveDeploymentEvent(ctx, deployment.DeploymentId,
-				fmt.Sprintf("[%s] Deployment permanently failed after %d retries (max: %d)",
-					time.Now().Format(time.RFC3339), deployment.RetryCount, deployment.MaxRetries)); err != nil {
+			if err := q.storage.SaveDeploymentEvent(ctx, deployment.DeploymentId, &etcdstorage.DeploymentEvent{
+				Type:    etcdstorage.EventTypeWarning,
+				Reason:  "PermanentlyFailed",
+				Message: fmt.Sprintf("Deployment permanently failed after %d retries (max: %d)", deployment.RetryCount, deployment.MaxRetries),
+				Source:  etcdstorage.EventSourceScheduler,
+				Attempt: deployment.RetryCount,
+			}); err != nil {
 				log.Printf("[Scheduler] Failed to save deployment event: %v", err)
 			}
 
@@ -82,9 +86,13 @@ func (q *Queue) moveFailedJobsToQueue(ctx context.Context) {
 		}
 
 		// Save retry event
-		if err := q.storage.SaveDeploymentEvent(ctx, deployment.DeploymentId,
-			fmt.Sprintf("[%s] Retrying deployment (attempt %d)",
-				time.Now().Format(time.RFC3339), deployment.RetryCount)); err != nil {
+		if err := q.storage.SaveDeploymentEvent(ctx, deployment.DeploymentId, &etcdstorage.DeploymentEvent{
+			Type:    etcdstorage.EventTypeNormal,
+			Reason:  "Retrying",
+			Message: fmt.Sprintf("Retrying deployment (attempt %d)", deployment.RetryCount),
+			Source:  etcdstorage.EventSourceScheduler,
+			Attempt: deployment.RetryCount,
+		}); err != nil {
 			log.Printf("[Scheduler] Failed to save retry event: %v", err)
 		}
 
@@ -96,7 +104,8 @@ func (q *Queue) moveFailedJobsToQueue(ctx context.Context) {
 }
 
 // checkStaleJobs detects deployments that are stuck in "assigned" or "running" state
-// without updates for too long and moves them back to the queue or failed queue
+// without updates for too long, or whose node was lost, and moves them to the failed
+// queue. Lost service and system replicas are moved to history for the reconciler to replace.
 func (q *Queue) checkStaleJobs(ctx context.Context) {
 	activeDeployments, err := q.storage.GetAllActiveDeployments(ctx)
 	if err != nil {
@@ -104,10 +113,17 @@ func (q *Queue) checkStaleJobs(ctx context.Context) {
 		return
 	}
 
+	nodes, err := q.storage.GetAllNodes(ctx)
+	if err != nil {
+		log.Printf("[Scheduler] Failed to get nodes: %v", err)
+		return
+	}
+
 	now := time.Now()
 	// Timeout thresholds
 	assignedTimeout := 5 * time.Minute // Deployment assigned but never started running
 	runningTimeout := 30 * time.Minute // Deployment running but no status updates
+	nodeLostTimeout := 2 * time.Minute // Node of the deployment stopped sending heartbeats
 
 	for deploymentID, deploymentStatus := range activeDeployments {
 		timeSinceUpdate := now.Sub(deploymentStatus.UpdatedAt)
@@ -116,7 +132,14 @@ func (q *Queue) checkStaleJobs(ctx context.Context) {
 		isStale := false
 		reason := ""
 
-		if deploymentStatus.Status == "assigned" && timeSinceUpdate > assignedTimeout {
+		node, known := nodes[deploymentStatus.NodeID]
+		if deploymentStatus.NodeID != "" && !known {
+			isStale = true
+			reason = fmt.Sprintf("Node %s is no longer registered", deploymentStatus.NodeID)
+		} else if known && now.Sub(node.LastHeartbeat) > nodeLostTimeout {
+			isStale = true
+			reason = fmt.Sprintf("Node %s was lost (last heartbeat: %v)", deploymentStatus.NodeID, node.LastHeartbeat)
+		} else if deploymentStatus.Status == "assigned" && timeSinceUpdate > assignedTimeout {
 			isStale = true
 			reason = fmt.Sprintf("Deployment assigned to node %s but never started running (timeout: %v)",
 				deploymentStatus.NodeID, assignedTimeout)
@@ -126,13 +149,65 @@ func (q *Queue) checkStaleJobs(ctx context.Context) {
 				deploymentStatus.NodeID, runningTimeout)
 		}
 
+		// An instance that was asked to stop is not retried, its node most likely went away
+		if isStale && deploymentStatus.DesiredStatus == etcdstorage.DesiredStatusStopped {
+			log.Printf("[Scheduler] Stale deployment %s was being stopped, moving to history", deploymentID)
+			deploymentStatus.Status = "stopped"
+			deploymentStatus.Detail = reason
+			deploymentStatus.UpdatedAt = now
+			if err := q.storage.SaveDeploymentHistory(ctx, deploymentID, deploymentStatus); err != nil {
+				log.Printf("[Scheduler] Failed to save stopped deployment to history: %v", err)
+				continue
+			}
+			if err := q.storage.DeleteDeploymentActive(ctx, deploymentID); err != nil {
+				log.Printf("[Scheduler] Failed to delete stale active deployment: %v", err)
+			}
+			continue
+		}
+
+		// Service and system replicas are not retried, the reconciler starts a replacement
+		if isStale && etcdstorage.IsLongRunningUnit(deploymentStatus.Deployment) {
+			log.Printf("[Scheduler] Replica %s is lost: %s", deploymentID, reason)
+			lostEvent := &etcdstorage.DeploymentEvent{
+				Type:    etcdstorage.EventTypeWarning,
+				Reason:  "Lost",
+				Message: fmt.Sprintf("Replica lost, a replacement will be started: %s", reason),
+				NodeID:  deploymentStatus.NodeID,
+				Source:  etcdstorage.EventSourceScheduler,
+			}
+			for _, eventDeploymentID := range []string{deploymentID, deploymentStatus.Deployment.ParentDeploymentId} {
+				if err := q.storage.SaveDeploymentEvent(ctx, eventDeploymentID, lostEvent); err != nil {
+					log.Printf("[Scheduler] Failed to save lost deployment event: %v", err)
+				}
+			}
+			deploymentStatus.Status = "lost"
+			deploymentStatus.Detail = reason
+			deploymentStatus.UpdatedAt = now
+			if err := q.storage.SaveDeploymentHistory(ctx, deploymentID, deploymentStatus); err != nil {
+				log.Printf("[Scheduler] Failed to save lost deployment to history: %v", err)
+				continue
+			}
+			if err := q.storage.DeleteDeploymentActive(ctx, deploymentID); err != nil {
+				log.Printf("[Scheduler] Failed to delete stale active deployment: %v", err)
+			}
+			continue
+		}
+
 		if isStale {
 			log.Printf("[Scheduler] Detected stale deployment %s: %s", deploymentID, reason)
 
 			// Save event
-			if err := q.storage.SaveDeploymentEvent(ctx, deploymentID,
-				fmt.Sprintf("[%s] Deployment detected as stale: %s",
-					time.Now().Format(time.RFC3339), reason)); err != nil {
+			staleEvent := &etcdstorage.DeploymentEvent{
+				Type:    etcdstorage.EventTypeWarning,
+				Reason:  "Stale",
+				Message: fmt.Sprintf("Deployment detected as stale: %s", reason),
+				NodeID:  deploymentStatus.NodeID,
+				Source:  etcdstorage.EventSourceScheduler,
+			}
+			if deploymentStatus.Deployment != nil {
+				staleEvent.Attempt = deploymentStatus.Deployment.RetryCount
+			}
+			if err := q.storage.SaveDeploymentEvent(ctx, deploymentID, staleEvent); err != nil {
 				log.Printf("[Scheduler] Failed to save stale deployment event: %v", err)
 			}
 
@@ -0,0 +1,676 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultMaxParallel     = 1
+	defaultMinHealthyTime  = 10 * time.Second
+	defaultHealthyDeadline = 5 * time.Minute
+	defaultCanaries        = 1
+)
+
+const (
+	strategyRolling   = "rolling"
+	strategyCanary    = "canary"
+	strategyBlueGreen = "blue-green"
+)
+
+// Reconciler runs the replicas of service deployments as individual replica
+// units. It replaces replicas that exit and rolls out new spec revisions in
+// batches, following the update strategy of the service. Canary and blue/green
+// rollouts start the new replicas next to the old ones and wait to be promoted
+// before replacing them. Batch deployments with several replicas are run as
+// units too, see reconcileBatch, and system deployments run one unit on every
+// matching node, see reconcileSystem. Periodic deployments launch a child
+// deployment each time their cron schedule fires, see reconcilePeriodic.
+// Deployments with depends_on wait for their upstream deployments first, see
+// reconcileDependencies. It must only run on the leader.
+type Reconciler struct {
+	storage  *etcdstorage.Storage
+	interval time.Duration
+}
+
+func NewReconciler(storage *etcdstorage.Storage) *Reconciler {
+	return &Reconciler{storage: storage, interval: 5 * time.Second}
+}
+
+func (r *Reconciler) Run(ctx context.Context) {
+	log.Printf("[Reconciler] Starting service reconciler.")
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.reconcile(ctx)
+		case <-ctx.Done():
+			log.Printf("[Reconciler] Stopping service reconciler.")
+			return
+		}
+	}
+}
+
+// replicaUnit is a single replica of a service as found in the queue, the
+// failed queue or the active deployments
+type replicaUnit struct {
+	deployment *pb.Deployment
+	// state is "queued", "retrying" or the status last reported by the agent
+	state  string
+	active *etcdstorage.DeploymentStatus
+	health string
+}
+
+func (u *replicaUnit) id() string {
+	return u.deployment.DeploymentId
+}
+
+// healthy reports whether the unit has been running, and passing its health
+// check if it has one, for at least minHealthy
+func (u *replicaUnit) healthy(minHealthy time.Duration) bool {
+	if u.active == nil || u.state != "running" {
+		return false
+	}
+	if u.health != "" && u.health != "healthy" {
+		return false
+	}
+	startedAt := u.active.StartedAt
+	if startedAt.IsZero() {
+		startedAt = u.active.ClaimedAt
+	}
+	return time.Since(startedAt) >= minHealthy
+}
+
+func (r *Reconciler) reconcile(ctx context.Context) {
+	specs, err := r.storage.GetAllDeploymentSpecs(ctx)
+	if err != nil {
+		log.Printf("[Reconciler] Failed to get deployment specs: %v", err)
+		return
+	}
+
+	units, err := r.listUnits(ctx)
+	if err != nil {
+		log.Printf("[Reconciler] Failed to list replica units: %v", err)
+		return
+	}
+
+	nodes, err := r.storage.GetAllNodes(ctx)
+	if err != nil {
+		log.Printf("[Reconciler] Failed to get nodes: %v", err)
+		return
+	}
+
+	for parentID, parentUnits := range units {
+		spec, ok := specs[parentID]
+		if ok && etcdstorage.IsManaged(spec.Deployment) {
+			continue
+		}
+		// The deployment was deleted, its replicas go with it
+		for _, unit := range parentUnits {
+			if err := r.stopUnit(ctx, unit); err != nil {
+				log.Printf("[Reconciler] Failed to stop orphaned replica %s: %v", unit.id(), err)
+			}
+		}
+	}
+
+	for deploymentID, spec := range specs {
+		if etcdstorage.HasDependencies(spec.Deployment) {
+			released, err := r.reconcileDependencies(ctx, spec, specs)
+			if err != nil {
+				log.Printf("[Reconciler] Failed to check dependencies of %s: %v", deploymentID, err)
+			}
+			if !released {
+				continue
+			}
+		}
+
+		switch {
+		case etcdstorage.IsManagedPeriodic(spec.Deployment):
+			if err := r.reconcilePeriodic(ctx, spec, units[deploymentID]); err != nil {
+				log.Printf("[Reconciler] Failed to reconcile periodic deployment %s: %v", deploymentID, err)
+			}
+		case etcdstorage.IsManagedService(spec.Deployment):
+			if err := r.reconcileService(ctx, spec, units[deploymentID]); err != nil {
+				log.Printf("[Reconciler] Failed to reconcile service %s: %v", deploymentID, err)
+			}
+		case etcdstorage.IsManagedBatch(spec.Deployment):
+			if err := r.reconcileBatch(ctx, spec, units[deploymentID]); err != nil {
+				log.Printf("[Reconciler] Failed to reconcile batch %s: %v", deploymentID, err)
+			}
+		case etcdstorage.IsManagedSystem(spec.Deployment):
+			if err := r.reconcileSystem(ctx, spec, units[deploymentID], nodes); err != nil {
+				log.Printf("[Reconciler] Failed to reconcile system deployment %s: %v", deploymentID, err)
+			}
+		}
+	}
+}
+
+// listUnits returns every replica unit that is not stopping, grouped by service
+func (r *Reconciler) listUnits(ctx context.Context) (map[string][]*replicaUnit, error) {
+	units := make(map[string][]*replicaUnit)
+	add := func(unit *replicaUnit) {
+		if unit.deployment == nil || unit.deployment.ParentDeploymentId == "" {
+			return
+		}
+		parentID := unit.deployment.ParentDeploymentId
+		units[parentID] = append(units[parentID], unit)
+	}
+
+	queued, err := r.storage.GetQueueDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range queued {
+		add(&replicaUnit{deployment: deployment, state: "queued"})
+	}
+
+	failed, err := r.storage.GetAllFailedDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range failed {
+		add(&replicaUnit{deployment: deployment, state: "retrying"})
+	}
+
+	active, err := r.storage.GetAllActiveDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := r.storage.GetAllInstanceData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for deploymentID, status := range active {
+		if status.DesiredStatus == etcdstorage.DesiredStatusStopped {
+			continue
+		}
+		unit := &replicaUnit{deployment: status.Deployment, state: status.Status, active: status}
+		if instance, ok := instances[deploymentID]; ok {
+			unit.health = instance.Health
+		}
+		add(unit)
+	}
+
+	for _, parentUnits := range units {
+		sort.Slice(parentUnits, func(i, j int) bool {
+			return parentUnits[i].id() < parentUnits[j].id()
+		})
+	}
+	return units, nil
+}
+
+// reconcileService takes the next step of a service towards its desired replicas
+func (r *Reconciler) reconcileService(ctx context.Context, spec *etcdstorage.DeploymentSpec, units []*replicaUnit) error {
+	return r.updateState(ctx, spec, func(state *etcdstorage.ServiceState) error {
+		return r.step(ctx, spec, state, units)
+	})
+}
+
+// updateState runs fn with the service state of a deployment and saves the
+// state if fn changed it
+func (r *Reconciler) updateState(ctx context.Context, spec *etcdstorage.DeploymentSpec, fn func(*etcdstorage.ServiceState) error) error {
+	state, err := r.storage.GetServiceState(ctx, spec.DeploymentID)
+	if err != nil {
+		return err
+	}
+	var before etcdstorage.ServiceState
+	if state == nil {
+		state = &etcdstorage.ServiceState{DeploymentID: spec.DeploymentID, Status: etcdstorage.RolloutStatusRunning, NextUnit: 1}
+	} else {
+		before = *state
+		before.BatchUnits = append([]string(nil), state.BatchUnits...)
+	}
+
+	stepErr := fn(state)
+
+	before.UpdatedAt = state.UpdatedAt
+	if !reflect.DeepEqual(&before, state) {
+		if err := r.storage.SaveServiceState(ctx, state); err != nil {
+			return err
+		}
+	}
+	return stepErr
+}
+
+func (r *Reconciler) step(ctx context.Context, spec *etcdstorage.DeploymentSpec, state *etcdstorage.ServiceState, units []*replicaUnit) error {
+	deploymentID := spec.DeploymentID
+	target := spec.Deployment
+	strategy := updateStrategy(target.Update)
+	if state.TargetRevision != spec.Revision {
+		r.startRollout(ctx, spec, state, strategy)
+	}
+
+	command, err := r.storage.TakeServiceCommand(ctx, deploymentID)
+	if err != nil {
+		return err
+	}
+	if command != nil {
+		r.handleCommand(ctx, spec, state, command)
+	}
+	if state.Status == etcdstorage.RolloutStatusPaused {
+		return nil
+	}
+
+	targetHash, err := workloadHash(target)
+	if err != nil {
+		return err
+	}
+
+	var current, old []*replicaUnit
+	byID := make(map[string]*replicaUnit, len(units))
+	for _, unit := range units {
+		byID[unit.id()] = unit
+		if hash, err := workloadHash(unit.deployment); err == nil && hash == targetHash {
+			current = append(current, unit)
+		} else {
+			old = append(old, unit)
+		}
+	}
+
+	// Replicas that are not running yet are the first to go
+	sort.SliceStable(old, func(i, j int) bool {
+		return old[i].state != "running" && old[j].state == "running"
+	})
+
+	// Wait for the running batch to become healthy
+	if len(state.BatchUnits) > 0 {
+		pending := 0
+		for _, unitID := range state.BatchUnits {
+			unit, ok := byID[unitID]
+			if !ok || unit.state == "retrying" {
+				return r.failRollout(ctx, spec, state, fmt.Sprintf("replica %s of batch %d failed", unitID, state.Batch))
+			}
+			if !unit.healthy(strategy.minHealthyTime) {
+				pending++
+			}
+		}
+		if pending > 0 {
+			if time.Since(state.BatchStartedAt) > strategy.healthyDeadline {
+				return r.failRollout(ctx, spec, state, fmt.Sprintf("batch %d did not become healthy within %s", state.Batch, strategy.healthyDeadline))
+			}
+			return nil
+		}
+
+		r.saveEvent(ctx, deploymentID, etcdstorage.EventTypeNormal, "BatchHealthy",
+			fmt.Sprintf("Batch %d of revision %d is healthy", state.Batch, state.TargetRevision))
+		state.BatchUnits = nil
+	}
+
+	desired := int(target.Replicas)
+	if state.Status == etcdstorage.RolloutStatusCanary {
+		// Old replicas keep serving until the canaries are promoted
+		canaries := min(strategy.canaries, desired)
+		if strategy.mode == strategyBlueGreen {
+			canaries = desired
+		}
+		if need := canaries - len(current); need > 0 {
+			return r.startUnits(ctx, spec, state, need)
+		}
+		for _, unit := range current {
+			if !unit.healthy(strategy.minHealthyTime) {
+				state.CanaryHealthyAt = time.Time{}
+				return nil
+			}
+		}
+		if state.CanaryHealthyAt.IsZero() {
+			state.CanaryHealthyAt = time.Now()
+			state.Message = fmt.Sprintf("%d canary replica(s) of revision %d are healthy, waiting to be promoted", len(current), state.TargetRevision)
+			r.saveEvent(ctx, deploymentID, etcdstorage.EventTypeNormal, "CanaryHealthy", state.Message)
+		}
+		if strategy.autoPromoteAfter <= 0 || time.Since(state.CanaryHealthyAt) < strategy.autoPromoteAfter {
+			return nil
+		}
+		r.promote(ctx, state, fmt.Sprintf("auto-promote after %s", strategy.autoPromoteAfter))
+	}
+
+	if need := desired - len(current); need > 0 {
+		count := need
+		if rollingOut(state) && count > strategy.maxParallel {
+			count = strategy.maxParallel
+		}
+
+		// Make room for the new replicas by stopping old ones beyond the surge allowance
+		room := desired + strategy.maxSurge - len(current) - len(old)
+		if !rollingOut(state) {
+			room = need
+		}
+		for room < count && len(old) > 0 {
+			var unit *replicaUnit
+			unit, old = old[0], old[1:]
+			if err := r.stopUnit(ctx, unit); err != nil {
+				return err
+			}
+			room++
+		}
+		if count > room {
+			count = room
+		}
+		if count <= 0 {
+			return nil
+		}
+
+		return r.startUnits(ctx, spec, state, count)
+	}
+
+	// Every replica runs the target spec, stop what is left over
+	for _, unit := range old {
+		if err := r.stopUnit(ctx, unit); err != nil {
+			return err
+		}
+	}
+	for i := len(current) - 1; i >= desired; i-- {
+		if err := r.stopUnit(ctx, current[i]); err != nil {
+			return err
+		}
+	}
+	current = current[:min(desired, len(current))]
+
+	if rollingOut(state) {
+		for _, unit := range current {
+			if !unit.healthy(strategy.minHealthyTime) {
+				return nil
+			}
+		}
+		r.completeRollout(ctx, state)
+	}
+
+	return nil
+}
+
+// startRollout begins replacing replicas with the latest revision of a service
+func (r *Reconciler) startRollout(ctx context.Context, spec *etcdstorage.DeploymentSpec, state *etcdstorage.ServiceState, strategy strategy) {
+	from := state.TargetRevision
+	state.TargetRevision = spec.Revision
+	state.Status = etcdstorage.RolloutStatusProgressing
+	state.Batch = 0
+	state.BatchUnits = nil
+	state.CanaryHealthyAt = time.Time{}
+
+	// A service without a stable revision has nothing to protect with canaries
+	staged := state.StableRevision > 0 && strategy.mode != strategyRolling
+	switch {
+	case from == 0:
+		state.Message = fmt.Sprintf("Starting replicas of revision %d", spec.Revision)
+	case staged && strategy.mode == strategyBlueGreen:
+		state.Status = etcdstorage.RolloutStatusCanary
+		state.Message = fmt.Sprintf("Starting a full set of revision %d next to revision %d", spec.Revision, from)
+	case staged:
+		state.Status = etcdstorage.RolloutStatusCanary
+		state.Message = fmt.Sprintf("Starting %d canary replica(s) of revision %d next to revision %d", strategy.canaries, spec.Revision, from)
+	default:
+		state.Message = fmt.Sprintf("Rolling out revision %d, replacing revision %d", spec.Revision, from)
+	}
+	r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeNormal, "RolloutStarted", state.Message)
+	log.Printf("[Reconciler] Service %s: %s", spec.DeploymentID, state.Message)
+}
+
+// handleCommand applies a promote or abort request to the rollout of a service.
+// Requests that no longer apply, e.g. because the rollout moved on, are dropped.
+func (r *Reconciler) handleCommand(ctx context.Context, spec *etcdstorage.DeploymentSpec, state *etcdstorage.ServiceState, command *etcdstorage.ServiceCommand) {
+	switch command.Action {
+	case etcdstorage.ServiceCommandPromote:
+		if state.Status != etcdstorage.RolloutStatusCanary {
+			log.Printf("[Reconciler] Ignoring promote of service %s: rollout is %s", spec.DeploymentID, state.Status)
+			return
+		}
+		r.promote(ctx, state, command.RequestedBy)
+	case etcdstorage.ServiceCommandAbort:
+		if !state.Abortable() {
+			log.Printf("[Reconciler] Ignoring abort of service %s: nothing to abort", spec.DeploymentID)
+			return
+		}
+		r.abort(ctx, spec, state, command.RequestedBy)
+	default:
+		log.Printf("[Reconciler] Ignoring unknown command %q for service %s", command.Action, spec.DeploymentID)
+	}
+}
+
+// promote lets a canary rollout replace the old replicas
+func (r *Reconciler) promote(ctx context.Context, state *etcdstorage.ServiceState, by string) {
+	state.Status = etcdstorage.RolloutStatusProgressing
+	state.CanaryHealthyAt = time.Time{}
+	state.Message = fmt.Sprintf("Revision %d promoted by %s", state.TargetRevision, by)
+	r.saveEvent(ctx, state.DeploymentID, etcdstorage.EventTypeNormal, "Promoted", state.Message)
+	log.Printf("[Reconciler] Service %s: %s", state.DeploymentID, state.Message)
+}
+
+// abort stops the rollout of a service and restores its last stable revision
+func (r *Reconciler) abort(ctx context.Context, spec *etcdstorage.DeploymentSpec, state *etcdstorage.ServiceState, by string) {
+	aborted := state.TargetRevision
+	reverted, err := r.revert(ctx, spec, state.StableRevision, by)
+	if err != nil {
+		log.Printf("[Reconciler] Failed to abort rollout of service %s: %v", spec.DeploymentID, err)
+		r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeWarning, "AbortFailed",
+			fmt.Sprintf("Failed to abort rollout of revision %d: %v", aborted, err))
+		return
+	}
+
+	state.TargetRevision = reverted.Revision
+	state.Status = etcdstorage.RolloutStatusReverting
+	state.Batch = 0
+	state.BatchUnits = nil
+	state.CanaryHealthyAt = time.Time{}
+	state.Message = fmt.Sprintf("Rollout of revision %d aborted by %s, restoring the spec of revision %d as revision %d",
+		aborted, by, state.StableRevision, reverted.Revision)
+	r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeWarning, "RolloutAborted", state.Message)
+	log.Printf("[Reconciler] Service %s: %s", spec.DeploymentID, state.Message)
+}
+
+func (r *Reconciler) completeRollout(ctx context.Context, state *etcdstorage.ServiceState) {
+	if state.Status == etcdstorage.RolloutStatusReverting {
+		state.Message = fmt.Sprintf("Reverted to the spec of revision %d", state.StableRevision)
+	} else {
+		state.Message = fmt.Sprintf("Revision %d rolled out", state.TargetRevision)
+	}
+	state.StableRevision = state.TargetRevision
+	state.Status = etcdstorage.RolloutStatusRunning
+	state.Batch = 0
+	state.CanaryHealthyAt = time.Time{}
+
+	r.saveEvent(ctx, state.DeploymentID, etcdstorage.EventTypeNormal, "RolloutComplete", state.Message)
+	log.Printf("[Reconciler] Service %s: %s", state.DeploymentID, state.Message)
+}
+
+// failRollout pauses a failed rollout, or rolls back to the last stable
+// revision if the update strategy asks for it
+func (r *Reconciler) failRollout(ctx context.Context, spec *etcdstorage.DeploymentSpec, state *etcdstorage.ServiceState, reason string) error {
+	deploymentID := spec.DeploymentID
+	log.Printf("[Reconciler] Rollout of revision %d of service %s failed: %s", state.TargetRevision, deploymentID, reason)
+	r.saveEvent(ctx, deploymentID, etcdstorage.EventTypeWarning, "RolloutFailed",
+		fmt.Sprintf("Rollout of revision %d failed: %s", state.TargetRevision, reason))
+	state.BatchUnits = nil
+	state.FailedRevisions = append(state.FailedRevisions, state.TargetRevision)
+
+	revert := spec.Deployment.Update != nil && spec.Deployment.Update.AutoRevert &&
+		state.StableRevision > 0 && state.Status != etcdstorage.RolloutStatusReverting
+	if revert {
+		reverted, err := r.revert(ctx, spec, state.StableRevision, "auto-revert")
+		if err == nil {
+			state.TargetRevision = reverted.Revision
+			state.Status = etcdstorage.RolloutStatusReverting
+			state.Batch = 0
+			state.CanaryHealthyAt = time.Time{}
+			state.Message = fmt.Sprintf("Reverting to the spec of revision %d as revision %d: %s", state.StableRevision, reverted.Revision, reason)
+			r.saveEvent(ctx, deploymentID, etcdstorage.EventTypeWarning, "AutoReverted", state.Message)
+			return nil
+		}
+		log.Printf("[Reconciler] Failed to revert service %s: %v", deploymentID, err)
+		reason = fmt.Sprintf("%s (auto-revert failed: %v)", reason, err)
+	}
+
+	state.Status = etcdstorage.RolloutStatusPaused
+	state.Message = fmt.Sprintf("Rollout of revision %d paused: %s", state.TargetRevision, reason)
+	r.saveEvent(ctx, deploymentID, etcdstorage.EventTypeWarning, "RolloutPaused", state.Message)
+	return nil
+}
+
+// revert stores the spec of a previous revision as the next revision of a service
+func (r *Reconciler) revert(ctx context.Context, spec *etcdstorage.DeploymentSpec, revision int64, author string) (*etcdstorage.DeploymentSpec, error) {
+	stable, err := r.storage.GetDeploymentRevision(ctx, spec.DeploymentID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if stable == nil {
+		return nil, fmt.Errorf("revision %d not found", revision)
+	}
+
+	deployment, request, err := etcdstorage.RollbackSpec(spec, stable)
+	if err != nil {
+		return nil, err
+	}
+	return r.storage.UpdateDeploymentSpec(ctx, spec, deployment, request, etcdstorage.RevisionChange{
+		Changes:    etcdstorage.DiffRequests(spec.Request, request),
+		Author:     author,
+		RollbackOf: revision,
+	})
+}
+
+// startUnits queues count new replica units running the target revision
+func (r *Reconciler) startUnits(ctx context.Context, spec *etcdstorage.DeploymentSpec, state *etcdstorage.ServiceState, count int) error {
+	units := make([]*pb.Deployment, 0, count)
+	for i := 0; i < count; i++ {
+		units = append(units, newUnit(spec, state.NextUnit))
+		state.NextUnit++
+	}
+
+	// Save the unit counter first so that unit IDs are never handed out twice
+	if err := r.storage.SaveServiceState(ctx, state); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, count)
+	for _, unit := range units {
+		if err := r.storage.EnqueueDeployment(ctx, unit); err != nil {
+			log.Printf("[Reconciler] Failed to queue replica %s: %v", unit.DeploymentId, err)
+			continue
+		}
+		ids = append(ids, unit.DeploymentId)
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("failed to queue replicas of service %s", spec.DeploymentID)
+	}
+
+	if rollingOut(state) {
+		state.Batch++
+		state.BatchUnits = ids
+		state.BatchStartedAt = time.Now()
+		r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeNormal, "BatchStarted",
+			fmt.Sprintf("Batch %d of revision %d started: %v", state.Batch, state.TargetRevision, ids))
+	} else {
+		r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeNormal, "ReplicasStarted",
+			fmt.Sprintf("Started %d replica(s) of revision %d: %v", len(ids), state.TargetRevision, ids))
+	}
+	log.Printf("[Reconciler] Service %s: started replicas %v", spec.DeploymentID, ids)
+
+	return nil
+}
+
+// newUnit returns the n-th replica unit of a deployment, running its current spec
+func newUnit(spec *etcdstorage.DeploymentSpec, n int64) *pb.Deployment {
+	unit := proto.Clone(spec.Deployment).(*pb.Deployment)
+	unit.DeploymentId = etcdstorage.ReplicaUnitID(spec.DeploymentID, n)
+	unit.ParentDeploymentId = spec.DeploymentID
+	unit.SpecRevision = spec.Revision
+	unit.Replicas = 1
+	unit.Update = nil
+	unit.DependsOn = nil
+	return unit
+}
+
+// stopUnit removes a replica that has not started yet or asks its agent to stop it
+func (r *Reconciler) stopUnit(ctx context.Context, unit *replicaUnit) error {
+	log.Printf("[Reconciler] Stopping replica %s (%s)", unit.id(), unit.state)
+	switch unit.state {
+	case "queued":
+		return r.storage.DeleteQueueDeployment(ctx, unit.id())
+	case "retrying":
+		return r.storage.DeleteFailedDeployment(ctx, unit.id())
+	default:
+		return r.storage.MarkDeploymentStopping(ctx, unit.id())
+	}
+}
+
+func (r *Reconciler) saveEvent(ctx context.Context, deploymentID, eventType, reason, message string) {
+	if err := r.storage.SaveDeploymentEvent(ctx, deploymentID, &etcdstorage.DeploymentEvent{
+		Type:    eventType,
+		Reason:  reason,
+		Message: message,
+		Source:  etcdstorage.EventSourceScheduler,
+	}); err != nil {
+		log.Printf("[Reconciler] Failed to save deployment event: %v", err)
+	}
+}
+
+func rollingOut(state *etcdstorage.ServiceState) bool {
+	return state.Status == etcdstorage.RolloutStatusProgressing || state.Status == etcdstorage.RolloutStatusReverting ||
+		state.Status == etcdstorage.RolloutStatusCanary
+}
+
+type strategy struct {
+	mode             string
+	maxParallel      int
+	maxSurge         int
+	minHealthyTime   time.Duration
+	healthyDeadline  time.Duration
+	canaries         int
+	autoPromoteAfter time.Duration
+}
+
+// updateStrategy fills in the defaults of an update block. Durations were
+// validated on submit, so parse errors fall back to the defaults.
+func updateStrategy(update *pb.UpdateStrategy) strategy {
+	result := strategy{
+		mode:            strategyRolling,
+		maxParallel:     defaultMaxParallel,
+		minHealthyTime:  defaultMinHealthyTime,
+		healthyDeadline: defaultHealthyDeadline,
+		canaries:        defaultCanaries,
+	}
+	if update == nil {
+		return result
+	}
+
+	if update.Strategy != "" {
+		result.mode = update.Strategy
+	}
+	if update.Canary > 0 {
+		result.canaries = int(update.Canary)
+	}
+	if d, err := time.ParseDuration(update.AutoPromoteAfter); err == nil {
+		result.autoPromoteAfter = d
+	}
+
+	if update.MaxParallel > 0 {
+		result.maxParallel = int(update.MaxParallel)
+	}
+	result.maxSurge = int(update.MaxSurge)
+	if d, err := time.ParseDuration(update.MinHealthyTime); err == nil {
+		result.minHealthyTime = d
+	}
+	if d, err := time.ParseDuration(update.HealthyDeadline); err == nil {
+		result.healthyDeadline = d
+	}
+	return result
+}
+
+// workloadHash hashes what a replica runs. Scaling or changing the update
+// strategy does not change it, so such updates don't replace running replicas.
+func workloadHash(deployment *pb.Deployment) (string, error) {
+	workload := proto.Clone(deployment).(*pb.Deployment)
+	workload.ParentDeploymentId = ""
+	workload.TargetNodeId = ""
+	workload.SpecRevision = 0
+	workload.Replicas = 0
+	workload.Update = nil
+	workload.DependsOn = nil
+	return etcdstorage.DeploymentSpecHash(workload)
+}
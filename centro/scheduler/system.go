@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/open-scheduler/centro/placement"
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	pb "github.com/open-scheduler/proto"
+)
+
+// reconcileSystem keeps one replica unit of a system deployment on every
+// healthy node that matches its clusters and constraints. Units pinned to a
+// node that was decommissioned, removed or no longer matches are stopped, and
+// units running an older spec are replaced node by node. Nodes that missed
+// heartbeats keep their unit until it is detected as lost.
+func (r *Reconciler) reconcileSystem(ctx context.Context, spec *etcdstorage.DeploymentSpec, units []*replicaUnit,
+	nodes map[string]*etcdstorage.NodeInfo) error {
+	return r.updateState(ctx, spec, func(state *etcdstorage.ServiceState) error {
+		state.TargetRevision = spec.Revision
+		targetHash, err := workloadHash(spec.Deployment)
+		if err != nil {
+			return err
+		}
+
+		byNode := make(map[string][]*replicaUnit)
+		for _, unit := range units {
+			nodeID := unit.deployment.TargetNodeId
+			byNode[nodeID] = append(byNode[nodeID], unit)
+		}
+
+		running := 0
+		for nodeID, nodeUnits := range byNode {
+			node, known := nodes[nodeID]
+			reason := ""
+			switch {
+			case !known:
+				reason = fmt.Sprintf("node %s is no longer registered", nodeID)
+			case node.Decommissioned:
+				reason = fmt.Sprintf("node %s was decommissioned", nodeID)
+			case !node.IsApproved():
+				reason = fmt.Sprintf("node %s is not approved", nodeID)
+			default:
+				if mismatch := placement.Check(node, spec.Deployment); mismatch != "" {
+					reason = fmt.Sprintf("node %s no longer matches: %s", nodeID, mismatch)
+				}
+			}
+
+			kept := false
+			for _, unit := range nodeUnits {
+				if reason == "" && !kept {
+					if hash, err := workloadHash(unit.deployment); err == nil && hash == targetHash {
+						kept = true
+						if unit.state == "running" {
+							running++
+						}
+						continue
+					}
+				}
+				if err := r.stopUnit(ctx, unit); err != nil {
+					return err
+				}
+				if reason != "" {
+					r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeNormal, "ReplicaStopped",
+						fmt.Sprintf("Stopped replica %s: %s", unit.id(), reason))
+				}
+			}
+		}
+
+		var eligible []string
+		for nodeID, node := range nodes {
+			if !node.Decommissioned && node.IsApproved() && node.IsHealthy() && placement.Check(node, spec.Deployment) == "" {
+				eligible = append(eligible, nodeID)
+			}
+		}
+		sort.Strings(eligible)
+
+		// A node whose old unit is being stopped gets its new unit on the next pass
+		var missing []string
+		for _, nodeID := range eligible {
+			if len(byNode[nodeID]) == 0 {
+				missing = append(missing, nodeID)
+			}
+		}
+
+		state.Message = fmt.Sprintf("Running on %d of %d eligible node(s)", running, len(eligible))
+		if len(missing) == 0 {
+			return nil
+		}
+		return r.startSystemUnits(ctx, spec, state, missing)
+	})
+}
+
+// startSystemUnits queues a replica unit of a system deployment for each of the given nodes
+func (r *Reconciler) startSystemUnits(ctx context.Context, spec *etcdstorage.DeploymentSpec, state *etcdstorage.ServiceState, nodeIDs []string) error {
+	units := make([]*pb.Deployment, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		unit := newUnit(spec, state.NextUnit)
+		unit.TargetNodeId = nodeID
+		units = append(units, unit)
+		state.NextUnit++
+	}
+
+	// Save the unit counter first so that unit IDs are never handed out twice
+	if err := r.storage.SaveServiceState(ctx, state); err != nil {
+		return err
+	}
+
+	for _, unit := range units {
+		if err := r.storage.EnqueueDeployment(ctx, unit); err != nil {
+			log.Printf("[Reconciler] Failed to queue replica %s: %v", unit.DeploymentId, err)
+			continue
+		}
+		r.saveEvent(ctx, spec.DeploymentID, etcdstorage.EventTypeNormal, "ReplicaStarted",
+			fmt.Sprintf("Started replica %s of revision %d on node %s", unit.DeploymentId, spec.Revision, unit.TargetNodeId))
+		log.Printf("[Reconciler] System deployment %s: started replica %s on node %s", spec.DeploymentID, unit.DeploymentId, unit.TargetNodeId)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+// Package secrets encrypts the values of the secrets store with envelope
+// encryption. Every value is sealed with its own random data key, and the
+// data key is sealed with the key encryption key read from a local file. Only
+// the sealed data key and value are stored in etcd, so a copy of etcd or of
+// its backups does not reveal secrets without the key file.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// KeySize is the size of the key encryption key and of the data keys (AES-256)
+const KeySize = 32
+
+// MaxValueSize is the largest secret value, etcd is not made for large values
+const MaxValueSize = 64 << 10
+
+// ErrWrongKey is returned for secrets that were sealed with another key
+// encryption key than the one Centro was started with
+var ErrWrongKey = errors.New("secret was encrypted with another key")
+
+// Keyring seals and opens secret values with the key encryption key
+type Keyring struct {
+	kek   cipher.AEAD
+	keyID string
+}
+
+// LoadKeyFile reads the key encryption key from a file. The file holds 32
+// bytes, either raw or encoded as hex or base64, for example the output of
+// `openssl rand -hex 32`.
+func LoadKeyFile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets key file: %w", err)
+	}
+	key, err := decodeKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets key file %s: %w", path, err)
+	}
+	return NewKeyring(key)
+}
+
+func decodeKey(data []byte) ([]byte, error) {
+	if len(data) == KeySize {
+		return data, nil
+	}
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("expected %d bytes, raw or encoded as hex or base64", KeySize)
+}
+
+// NewKeyring returns a keyring for a 32 byte key encryption key
+func NewKeyring(key []byte) (*Keyring, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	kek, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	// The ID tells which key sealed a secret without revealing the key
+	sum := sha256.Sum256(key)
+	return &Keyring{kek: kek, keyID: hex.EncodeToString(sum[:8])}, nil
+}
+
+// KeyID identifies the key encryption key
+func (k *Keyring) KeyID() string {
+	return k.keyID
+}
+
+// Seal encrypts value with a new data key and stores the sealed data key and
+// value in secret. The name of the secret is authenticated with both, so a
+// sealed value cannot be moved to another secret.
+func (k *Keyring) Seal(secret *etcdstorage.Secret, value []byte) error {
+	if len(value) > MaxValueSize {
+		return fmt.Errorf("secret value must be at most %d bytes", MaxValueSize)
+	}
+
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return err
+	}
+
+	ciphertext, err := seal(aead, value, []byte(secret.Name))
+	if err != nil {
+		return err
+	}
+	wrappedKey, err := seal(k.kek, dataKey, []byte(secret.Name))
+	if err != nil {
+		return err
+	}
+
+	secret.KeyID = k.keyID
+	secret.WrappedKey = wrappedKey
+	secret.Ciphertext = ciphertext
+	return nil
+}
+
+// Open decrypts the value of a secret
+func (k *Keyring) Open(secret *etcdstorage.Secret) ([]byte, error) {
+	if secret.KeyID != k.keyID {
+		return nil, fmt.Errorf("%w %s, Centro has key %s", ErrWrongKey, secret.KeyID, k.keyID)
+	}
+	dataKey, err := open(k.kek, secret.WrappedKey, []byte(secret.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data key of secret %s: %w", secret.Name, err)
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	value, err := open(aead, secret.Ciphertext, []byte(secret.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret %s: %w", secret.Name, err)
+	}
+	return value, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// seal encrypts plaintext and prepends the random nonce
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed data is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,172 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+)
+
+func newKeyring(t *testing.T, fill byte) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyring(bytes.Repeat([]byte{fill}, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+func TestSealOpen(t *testing.T) {
+	keyring := newKeyring(t, 1)
+	storage, _ := etcdtest.NewStorage()
+	ctx := context.Background()
+
+	values := map[string][]byte{
+		"empty":  {},
+		"db":     []byte("s3cr3t-password"),
+		"binary": {0, 1, 2, 255},
+		"large":  bytes.Repeat([]byte("x"), MaxValueSize),
+	}
+	for name, value := range values {
+		t.Run(name, func(t *testing.T) {
+			secret := &etcdstorage.Secret{Name: name}
+			if err := keyring.Seal(secret, value); err != nil {
+				t.Fatal(err)
+			}
+			if len(value) > 0 && bytes.Contains(secret.Ciphertext, value) {
+				t.Error("the value is stored in plain text")
+			}
+			if err := storage.SaveSecret(ctx, secret); err != nil {
+				t.Fatal(err)
+			}
+
+			stored, err := storage.GetSecret(ctx, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opened, err := keyring.Open(stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(opened, value) {
+				t.Errorf("opened %q, want %q", opened, value)
+			}
+		})
+	}
+}
+
+func TestSealUsesNewDataKeys(t *testing.T) {
+	keyring := newKeyring(t, 1)
+	first := &etcdstorage.Secret{Name: "db"}
+	second := &etcdstorage.Secret{Name: "db"}
+	if err := keyring.Seal(first, []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.Seal(second, []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first.WrappedKey, second.WrappedKey) || bytes.Equal(first.Ciphertext, second.Ciphertext) {
+		t.Error("sealing the same value twice gave the same result")
+	}
+}
+
+func TestSealTooLarge(t *testing.T) {
+	if err := newKeyring(t, 1).Seal(&etcdstorage.Secret{Name: "large"}, make([]byte, MaxValueSize+1)); err == nil {
+		t.Error("sealed a value larger than MaxValueSize")
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	keyring := newKeyring(t, 1)
+	sealed := func() *etcdstorage.Secret {
+		secret := &etcdstorage.Secret{Name: "db"}
+		if err := keyring.Seal(secret, []byte("s3cr3t-password")); err != nil {
+			t.Fatal(err)
+		}
+		return secret
+	}
+	other := &etcdstorage.Secret{Name: "other"}
+	if err := keyring.Seal(other, []byte("other-password")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		keyring  *Keyring
+		modify   func(*etcdstorage.Secret)
+		wrongKey bool
+	}{
+		{name: "another key", keyring: newKeyring(t, 2), wrongKey: true},
+		{name: "another key with the same ID", keyring: &Keyring{kek: newKeyring(t, 2).kek, keyID: keyring.keyID}},
+		{name: "renamed secret", modify: func(s *etcdstorage.Secret) { s.Name = "renamed" }},
+		{name: "data key of another secret", modify: func(s *etcdstorage.Secret) { s.WrappedKey = other.WrappedKey }},
+		{name: "value of another secret", modify: func(s *etcdstorage.Secret) { s.Ciphertext = other.Ciphertext }},
+		{name: "tampered value", modify: func(s *etcdstorage.Secret) { s.Ciphertext[len(s.Ciphertext)-1] ^= 1 }},
+		{name: "truncated data key", modify: func(s *etcdstorage.Secret) { s.WrappedKey = s.WrappedKey[:4] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := sealed()
+			if tt.modify != nil {
+				tt.modify(secret)
+			}
+			opener := keyring
+			if tt.keyring != nil {
+				opener = tt.keyring
+			}
+			value, err := opener.Open(secret)
+			if err == nil {
+				t.Fatalf("opened %q", value)
+			}
+			if errors.Is(err, ErrWrongKey) != tt.wrongKey {
+				t.Errorf("got %v, wrong key: %v", err, tt.wrongKey)
+			}
+		})
+	}
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+	want := newKeyring(t, 7).KeyID()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		invalid bool
+	}{
+		{name: "raw", data: key},
+		{name: "hex", data: []byte(hex.EncodeToString(key) + "\n")},
+		{name: "base64", data: []byte(base64.StdEncoding.EncodeToString(key) + "\n")},
+		{name: "too short", data: key[:16], invalid: true},
+		{name: "short hex", data: []byte(hex.EncodeToString(key[:16]) + "\n"), invalid: true},
+		{name: "text", data: []byte("not a key"), invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "key")
+			if err := os.WriteFile(path, tt.data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			keyring, err := LoadKeyFile(path)
+			if tt.invalid {
+				if err == nil {
+					t.Error("loaded an invalid key")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keyring.KeyID() != want {
+				t.Errorf("key ID %s, want %s", keyring.KeyID(), want)
+			}
+		})
+	}
+}
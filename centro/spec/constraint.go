@@ -0,0 +1,66 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Operators are checked in this order so that "not in" is not read as "in"
+var operators = []string{" not in ", " in ", "==", "!="}
+
+// Constraint is a parsed placement constraint. Constraints have the form
+// "<attribute> <operator> <value>". Attributes are node.id, node.cluster and
+// node.meta.<key> (or node.label.<key>) for the metadata a node reports with
+// its heartbeat. Operators are ==, != and in / not in followed by a list such
+// as [podman, containerd].
+type Constraint struct {
+	Attribute string
+	Operator  string
+	Values    []string
+}
+
+// ParseConstraint parses a placement constraint such as "node.meta.zone == us-east"
+func ParseConstraint(constraint string) (*Constraint, error) {
+	for _, operator := range operators {
+		index := strings.Index(constraint, operator)
+		if index < 0 {
+			continue
+		}
+
+		attribute := strings.TrimSpace(constraint[:index])
+		value := strings.TrimSpace(constraint[index+len(operator):])
+		if !validAttribute(attribute) {
+			return nil, fmt.Errorf("unknown attribute %q, expected node.id, node.cluster or node.meta.<key>", attribute)
+		}
+
+		parsed := &Constraint{Attribute: attribute, Operator: strings.TrimSpace(operator)}
+		if parsed.Operator == "in" || parsed.Operator == "not in" {
+			if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+				return nil, fmt.Errorf("%s expects a list such as [a, b]", parsed.Operator)
+			}
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					parsed.Values = append(parsed.Values, item)
+				}
+			}
+		} else {
+			parsed.Values = []string{value}
+		}
+		if len(parsed.Values) == 0 || parsed.Values[0] == "" {
+			return nil, fmt.Errorf("missing value")
+		}
+		return parsed, nil
+	}
+
+	return nil, fmt.Errorf("expected an operator: ==, !=, in or not in")
+}
+
+func validAttribute(attribute string) bool {
+	switch {
+	case attribute == "node.id", attribute == "node.cluster":
+		return true
+	case strings.HasPrefix(attribute, "node.meta."), strings.HasPrefix(attribute, "node.label."):
+		return !strings.HasSuffix(attribute, ".")
+	}
+	return false
+}
@@ -0,0 +1,384 @@
+// Package spec holds the deployment spec accepted by the REST API, its
+// conversion into the deployment sent to agents and its validation. osctl
+// validates specs with it before submitting them, so it must not import the
+// storage or any other server package.
+//
+// Field paths in validation errors use the names of the request fields (for
+// example "instance_config.image" or "ports[0].host_port") so that users can
+// find the offending value in what they submitted.
+package spec
+
+import (
+	pb "github.com/open-scheduler/proto"
+)
+
+// DependencySucceeded is the condition of dependencies that do not set one
+const DependencySucceeded = "succeeded"
+
+// SubmitDeploymentRequest is the deployment spec accepted by the REST API and
+// written by osctl from YAML files
+type SubmitDeploymentRequest struct {
+	DeploymentId     string                   `json:"deployment_id" example:"123"`
+	DeploymentName   string                   `json:"deployment_name" example:"web-server-deployment"`
+	DeploymentType   string                   `json:"deployment_type" example:"service"`
+	SelectedClusters []string                 `json:"selected_clusters" example:"dc1,dc2"`
+	Meta             map[string]string        `json:"meta"`
+	Driver           string                   `json:"driver" example:"podman"`
+	WorkloadType     string                   `json:"workload_type" example:"container"`
+	Command          string                   `json:"command" example:"echo 'Hello World'"`
+	CommandArray     []string                 `json:"command_array,omitempty" example:"nginx,-g,daemon off;"`
+	InstanceConfig   *InstanceSpecRequest     `json:"instance_config,omitempty"`
+	Resources        *ResourcesRequest        `json:"resources,omitempty"`
+	Volumes          []VolumeRequest          `json:"volumes,omitempty"`
+	Env              map[string]string        `json:"env,omitempty"`
+	Secrets          []SecretReferenceRequest `json:"secrets,omitempty"`
+	Configs          []ConfigReferenceRequest `json:"configs,omitempty"`
+	Replicas         *int32                   `json:"replicas,omitempty" example:"2"`
+	Placement        *PlacementRequest        `json:"placement,omitempty"`
+	WorkingDir       string                   `json:"working_dir,omitempty" example:"/usr/share/nginx/html"`
+	Ports            []PortMappingRequest     `json:"ports,omitempty"`
+	Security         *SecurityRequest         `json:"security,omitempty"`
+	HealthCheck      *HealthCheckRequest      `json:"health_check,omitempty"`
+	RestartPolicy    *RestartPolicyRequest    `json:"restart_policy,omitempty"`
+	Update           *UpdateStrategyRequest   `json:"update,omitempty"`
+	Periodic         *PeriodicRequest         `json:"periodic,omitempty"`
+	DependsOn        []DependencyRequest      `json:"depends_on,omitempty"`
+	Networks         []string                 `json:"networks,omitempty" example:"backend-net"`
+	InstanceType     string                   `json:"instance_type,omitempty" example:"virtual-machine"`
+}
+
+type ResourcesRequest struct {
+	MemoryMB        int64   `json:"memory_mb" example:"512"`
+	MemoryReserveMB int64   `json:"memory_reserve_mb,omitempty" example:"256"`
+	CPU             float32 `json:"cpu" example:"1.0"`
+	CPUReserve      float32 `json:"cpu_reserve,omitempty" example:"0.5"`
+}
+
+type VolumeRequest struct {
+	HostPath     string `json:"host_path" example:"/data/app"`
+	InstancePath string `json:"instance_path" example:"/usr/share/nginx/html"`
+	ReadOnly     bool   `json:"read_only,omitempty" example:"false"`
+	Type         string `json:"type,omitempty" example:"bind"`
+}
+
+// SecretReferenceRequest puts the value of a secret in an environment variable
+// or in a file inside the instance. Set either env or file.
+type SecretReferenceRequest struct {
+	Secret string `json:"secret" example:"db-password"`
+	Env    string `json:"env,omitempty" example:"DB_PASSWORD"`
+	File   string `json:"file,omitempty" example:"/run/secrets/db-password"`
+}
+
+// ConfigReferenceRequest mounts a config object as a read-only file inside
+// the instance. With template the config is rendered as a Go template with
+// the deployment metadata first.
+type ConfigReferenceRequest struct {
+	Config   string `json:"config" example:"nginx.conf"`
+	File     string `json:"file" example:"/etc/nginx/nginx.conf"`
+	Template bool   `json:"template,omitempty" example:"true"`
+}
+
+type InstanceSpecRequest struct {
+	Image       string              `json:"image" example:"docker.io/library/alpine:latest"`
+	Command     []string            `json:"command,omitempty" example:""`
+	Args        []string            `json:"args,omitempty" example:""`
+	Options     map[string]string   `json:"options,omitempty"`
+	ImageSource *ImageSourceRequest `json:"image_source,omitempty"`
+	UserData    string              `json:"user_data,omitempty"`
+	Devices     []DeviceRequest     `json:"devices,omitempty"`
+}
+
+type PlacementRequest struct {
+	Constraints []string `json:"constraints,omitempty" example:"node.driver in [podman, containerd]"`
+	Strategy    string   `json:"strategy,omitempty" example:"spread"`
+}
+
+type PortMappingRequest struct {
+	HostPort      int32  `json:"host_port" example:"8080"`
+	ContainerPort int32  `json:"container_port" example:"80"`
+	Protocol      string `json:"protocol" example:"tcp"`
+}
+
+type SecurityRequest struct {
+	Privileged             bool     `json:"privileged,omitempty" example:"false"`
+	CapabilitiesAdd        []string `json:"capabilities_add,omitempty" example:"NET_BIND_SERVICE"`
+	CapabilitiesDrop       []string `json:"capabilities_drop,omitempty" example:"ALL"`
+	ReadOnlyRootFilesystem bool     `json:"read_only_root_filesystem,omitempty" example:"true"`
+}
+
+type HealthCheckRequest struct {
+	Test        []string `json:"test,omitempty" example:"CMD-SHELL,curl -f http://localhost/ || exit 1"`
+	Interval    string   `json:"interval,omitempty" example:"30s"`
+	Timeout     string   `json:"timeout,omitempty" example:"5s"`
+	Retries     int32    `json:"retries,omitempty" example:"3"`
+	StartPeriod string   `json:"start_period,omitempty" example:"5s"`
+}
+
+type RestartPolicyRequest struct {
+	Condition   string `json:"condition" example:"on-failure"`
+	MaxAttempts int32  `json:"max_attempts,omitempty" example:"3"`
+}
+
+// UpdateStrategyRequest controls how the replicas of a service are replaced when its spec changes
+type UpdateStrategyRequest struct {
+	MaxParallel     int32  `json:"max_parallel,omitempty" example:"1"`
+	MaxSurge        int32  `json:"max_surge,omitempty" example:"1"`
+	MinHealthyTime  string `json:"min_healthy_time,omitempty" example:"10s"`
+	HealthyDeadline string `json:"healthy_deadline,omitempty" example:"5m"`
+	AutoRevert      bool   `json:"auto_revert,omitempty" example:"true"`
+	// Strategy is rolling (default), canary or blue-green
+	Strategy         string `json:"strategy,omitempty" example:"canary"`
+	Canary           int32  `json:"canary,omitempty" example:"1"`
+	AutoPromoteAfter string `json:"auto_promote_after,omitempty" example:"10m"`
+}
+
+// PeriodicRequest launches a batch deployment on a cron schedule
+type PeriodicRequest struct {
+	Cron            string `json:"cron" example:"0 2 * * *"`
+	TimeZone        string `json:"time_zone,omitempty" example:"Europe/Berlin"`
+	ProhibitOverlap bool   `json:"prohibit_overlap,omitempty" example:"true"`
+}
+
+// DependencyRequest names an upstream deployment that must finish first. In a
+// workflow the upstream can be given by the deployment_name of another
+// deployment of the same workflow instead of its ID.
+type DependencyRequest struct {
+	DeploymentID string `json:"deployment_id,omitempty" example:"nightly-extract"`
+	Name         string `json:"name,omitempty" example:"extract"`
+	// Condition is succeeded (default), failed or completed (finished with any status)
+	Condition string `json:"condition,omitempty" example:"succeeded"`
+}
+
+type ImageSourceRequest struct {
+	Alias  string `json:"alias,omitempty" example:"ubuntu/22.04"`
+	Server string `json:"server,omitempty" example:"images.linuxcontainers.org"`
+	Mode   string `json:"mode,omitempty" example:"pull"`
+}
+
+type DeviceRequest struct {
+	Name       string            `json:"name" example:"eth0"`
+	Type       string            `json:"type" example:"nic"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// ToDeployment converts the request into the deployment spec sent to agents
+func (req *SubmitDeploymentRequest) ToDeployment(deploymentID string) *pb.Deployment {
+	deployment := &pb.Deployment{
+		DeploymentId:     deploymentID,
+		DeploymentName:   req.DeploymentName,
+		DeploymentType:   req.DeploymentType,
+		SelectedClusters: req.SelectedClusters,
+		DriverType:       req.Driver,
+		WorkloadType:     req.WorkloadType,
+		Command:          req.Command,
+		RetryCount:       0,
+		MaxRetries:       3, // Default: retry up to 3 times
+		LastRetryTime:    0,
+	}
+
+	// Support command_array if provided
+	if len(req.CommandArray) > 0 {
+		deployment.CommandArray = req.CommandArray
+	}
+
+	// Replicas
+	if req.Replicas != nil {
+		deployment.Replicas = *req.Replicas
+	} else {
+		deployment.Replicas = 1 // Default to 1 replica
+	}
+
+	// Placement
+	if req.Placement != nil {
+		deployment.Placement = &pb.Placement{
+			Constraints: req.Placement.Constraints,
+			Strategy:    req.Placement.Strategy,
+		}
+	}
+
+	// Working directory
+	if req.WorkingDir != "" {
+		deployment.WorkingDir = req.WorkingDir
+	}
+
+	// Ports
+	if len(req.Ports) > 0 {
+		deployment.Ports = make([]*pb.PortMapping, 0, len(req.Ports))
+		for _, p := range req.Ports {
+			deployment.Ports = append(deployment.Ports, &pb.PortMapping{
+				HostPort:      p.HostPort,
+				ContainerPort: p.ContainerPort,
+				Protocol:      p.Protocol,
+			})
+		}
+	}
+
+	// Security settings
+	if req.Security != nil {
+		deployment.Security = &pb.SecuritySettings{
+			Privileged:             req.Security.Privileged,
+			CapabilitiesAdd:        req.Security.CapabilitiesAdd,
+			CapabilitiesDrop:       req.Security.CapabilitiesDrop,
+			ReadOnlyRootFilesystem: req.Security.ReadOnlyRootFilesystem,
+		}
+	}
+
+	// Health check
+	if req.HealthCheck != nil {
+		deployment.HealthCheck = &pb.HealthCheck{
+			Test:        req.HealthCheck.Test,
+			Interval:    req.HealthCheck.Interval,
+			Timeout:     req.HealthCheck.Timeout,
+			Retries:     req.HealthCheck.Retries,
+			StartPeriod: req.HealthCheck.StartPeriod,
+		}
+	}
+
+	// Restart policy
+	if req.RestartPolicy != nil {
+		deployment.RestartPolicy = &pb.RestartPolicy{
+			Condition:   req.RestartPolicy.Condition,
+			MaxAttempts: req.RestartPolicy.MaxAttempts,
+		}
+	}
+
+	// Update strategy
+	if req.Update != nil {
+		deployment.Update = &pb.UpdateStrategy{
+			MaxParallel:      req.Update.MaxParallel,
+			MaxSurge:         req.Update.MaxSurge,
+			MinHealthyTime:   req.Update.MinHealthyTime,
+			HealthyDeadline:  req.Update.HealthyDeadline,
+			AutoRevert:       req.Update.AutoRevert,
+			Strategy:         req.Update.Strategy,
+			Canary:           req.Update.Canary,
+			AutoPromoteAfter: req.Update.AutoPromoteAfter,
+		}
+	}
+
+	// Dependencies
+	if len(req.DependsOn) > 0 {
+		deployment.DependsOn = make([]*pb.Dependency, 0, len(req.DependsOn))
+		for _, d := range req.DependsOn {
+			condition := d.Condition
+			if condition == "" {
+				condition = DependencySucceeded
+			}
+			deployment.DependsOn = append(deployment.DependsOn, &pb.Dependency{
+				DeploymentId: d.DeploymentID,
+				Condition:    condition,
+			})
+		}
+	}
+
+	// Cron schedule
+	if req.Periodic != nil {
+		deployment.Periodic = &pb.Periodic{
+			Cron:            req.Periodic.Cron,
+			TimeZone:        req.Periodic.TimeZone,
+			ProhibitOverlap: req.Periodic.ProhibitOverlap,
+		}
+	}
+
+	// Networks
+	if len(req.Networks) > 0 {
+		deployment.Networks = make([]*pb.NetworkReference, 0, len(req.Networks))
+		for _, netName := range req.Networks {
+			deployment.Networks = append(deployment.Networks, &pb.NetworkReference{
+				Name: netName,
+			})
+		}
+	}
+
+	// Instance type
+	if req.InstanceType != "" {
+		deployment.InstanceType = req.InstanceType
+	}
+
+	if req.InstanceConfig != nil {
+		instSpec := &pb.InstanceSpec{
+			ImageName:     req.InstanceConfig.Image,
+			Entrypoint:    req.InstanceConfig.Command,
+			Arguments:     req.InstanceConfig.Args,
+			DriverOptions: req.InstanceConfig.Options,
+		}
+
+		// Image source
+		if req.InstanceConfig.ImageSource != nil {
+			instSpec.ImageSource = &pb.ImageSource{
+				Alias:  req.InstanceConfig.ImageSource.Alias,
+				Server: req.InstanceConfig.ImageSource.Server,
+				Mode:   req.InstanceConfig.ImageSource.Mode,
+			}
+		}
+
+		// User data
+		if req.InstanceConfig.UserData != "" {
+			instSpec.UserData = req.InstanceConfig.UserData
+		}
+
+		// Devices
+		if len(req.InstanceConfig.Devices) > 0 {
+			instSpec.Devices = make([]*pb.Device, 0, len(req.InstanceConfig.Devices))
+			for _, d := range req.InstanceConfig.Devices {
+				instSpec.Devices = append(instSpec.Devices, &pb.Device{
+					Name:       d.Name,
+					Type:       d.Type,
+					Properties: d.Properties,
+				})
+			}
+		}
+
+		deployment.InstanceConfig = instSpec
+	}
+	if req.Resources != nil {
+		deployment.ResourceRequirements = &pb.Resources{
+			MemoryLimitMb:    req.Resources.MemoryMB,
+			MemoryReservedMb: req.Resources.MemoryReserveMB,
+			CpuLimitCores:    req.Resources.CPU,
+			CpuReservedCores: req.Resources.CPUReserve,
+		}
+	}
+	if len(req.Volumes) > 0 {
+		deployment.VolumeMounts = make([]*pb.Volume, 0, len(req.Volumes))
+		for _, v := range req.Volumes {
+			vol := &pb.Volume{
+				SourcePath: v.HostPath,
+				TargetPath: v.InstancePath,
+				ReadOnly:   v.ReadOnly,
+			}
+			if v.Type != "" {
+				vol.Type = v.Type
+			}
+			deployment.VolumeMounts = append(deployment.VolumeMounts, vol)
+		}
+	}
+	if len(req.Env) > 0 {
+		deployment.EnvironmentVariables = req.Env
+	}
+	if len(req.Secrets) > 0 {
+		deployment.Secrets = make([]*pb.SecretReference, 0, len(req.Secrets))
+		for _, ref := range req.Secrets {
+			deployment.Secrets = append(deployment.Secrets, &pb.SecretReference{
+				Secret: ref.Secret,
+				Env:    ref.Env,
+				File:   ref.File,
+			})
+		}
+	}
+	if len(req.Configs) > 0 {
+		deployment.Configs = make([]*pb.ConfigReference, 0, len(req.Configs))
+		for _, ref := range req.Configs {
+			deployment.Configs = append(deployment.Configs, &pb.ConfigReference{
+				Config:   ref.Config,
+				File:     ref.File,
+				Template: ref.Template,
+			})
+		}
+	}
+	if req.Meta != nil {
+		deployment.DeploymentMetadata = req.Meta
+	}
+
+	return deployment
+}
@@ -0,0 +1,539 @@
+package spec
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/open-scheduler/centro/periodic"
+	pb "github.com/open-scheduler/proto"
+)
+
+var (
+	DeploymentTypes   = []string{"service", "batch", "system"}
+	Drivers           = []string{"podman", "containerd", "incus", "process"}
+	WorkloadTypes     = []string{"container", "vm", "process"}
+	InstanceTypes     = []string{"container", "virtual-machine"}
+	PlacementStrategy = []string{"spread", "pack", "random"}
+	PortProtocols     = []string{"tcp", "udp"}
+	RestartConditions = []string{"no", "always", "on-failure", "unless-stopped"}
+	ImagePullModes    = []string{"pull", "local"}
+	VolumeTypes       = []string{"bind", "volume", "tmpfs"}
+	UpdateStrategies  = []string{"rolling", "canary", "blue-green"}
+	DependencyConds   = []string{"succeeded", "failed", "completed"}
+)
+
+// deploymentIDPattern keeps client-supplied IDs safe to use in storage keys and URLs
+var deploymentIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// reservedIDPattern matches the IDs the reconciler gives to the replicas
+// (<id>-<n>) and the periodic launches (<id>-periodic-<unix time>) of a deployment
+var reservedIDPattern = regexp.MustCompile(`-[0-9]+$|-periodic-`)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateClientID checks a deployment ID chosen by the client for a new
+// deployment. The format is checked by ValidateDeployment, this rejects the IDs
+// reserved for replicas and periodic launches so that they cannot be taken.
+func ValidateClientID(id string) Errors {
+	var errs Errors
+	if reservedIDPattern.MatchString(id) {
+		errs.add("deployment_id", "must not end in '-' followed by digits or contain '-periodic-', these IDs are reserved for replicas and periodic launches")
+	}
+	return errs
+}
+
+// FieldError describes a single problem with a field of a deployment spec
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Errors is the list of all problems found in a spec
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *Errors) add(field, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateDeployment checks a deployment spec and returns every problem found,
+// or nil if the spec is valid
+func ValidateDeployment(deployment *pb.Deployment) Errors {
+	var errs Errors
+	if deployment == nil {
+		errs.add("deployment", "is required")
+		return errs
+	}
+
+	if deployment.DeploymentId != "" && !deploymentIDPattern.MatchString(deployment.DeploymentId) {
+		errs.add("deployment_id", "must be at most 128 letters, digits, '.', '_' or '-' and start with a letter or digit")
+	}
+
+	if strings.TrimSpace(deployment.DeploymentName) == "" {
+		errs.add("deployment_name", "is required")
+	} else if len(deployment.DeploymentName) > 253 {
+		errs.add("deployment_name", "must be at most 253 characters")
+	}
+
+	if deployment.DeploymentType != "" && !oneOf(deployment.DeploymentType, DeploymentTypes) {
+		errs.add("deployment_type", "must be one of %s", strings.Join(DeploymentTypes, ", "))
+	}
+
+	if deployment.DriverType == "" {
+		errs.add("driver", "is required")
+	} else if !oneOf(deployment.DriverType, Drivers) {
+		errs.add("driver", "unknown driver %q, must be one of %s", deployment.DriverType, strings.Join(Drivers, ", "))
+	}
+
+	if deployment.WorkloadType != "" && !oneOf(deployment.WorkloadType, WorkloadTypes) {
+		errs.add("workload_type", "must be one of %s", strings.Join(WorkloadTypes, ", "))
+	}
+
+	if deployment.InstanceType != "" && !oneOf(deployment.InstanceType, InstanceTypes) {
+		errs.add("instance_type", "must be one of %s", strings.Join(InstanceTypes, ", "))
+	}
+
+	if deployment.Replicas < 0 {
+		errs.add("replicas", "must not be negative")
+	} else if deployment.DeploymentType == "system" && deployment.Replicas > 1 {
+		errs.add("replicas", "is not used by system deployments, they run one replica on every matching node")
+	}
+	if deployment.MaxRetries < 0 {
+		errs.add("max_retries", "must not be negative")
+	}
+
+	validateWorkload(deployment, &errs)
+	validateResources(deployment.ResourceRequirements, &errs)
+	validateVolumes(deployment.VolumeMounts, &errs)
+	validatePorts(deployment.Ports, &errs)
+	validatePlacement(deployment.Placement, &errs)
+	validateHealthCheck(deployment.HealthCheck, &errs)
+	validateRestartPolicy(deployment.RestartPolicy, &errs)
+	validateUpdateStrategy(deployment, &errs)
+	validatePeriodic(deployment, &errs)
+	validateDependencies(deployment, &errs)
+	files := validateSecrets(deployment, &errs)
+	validateConfigs(deployment, files, &errs)
+
+	if deployment.WorkingDir != "" && !path.IsAbs(deployment.WorkingDir) {
+		errs.add("working_dir", "must be an absolute path")
+	}
+
+	for i, network := range deployment.Networks {
+		if network == nil || strings.TrimSpace(network.Name) == "" {
+			errs.add(fmt.Sprintf("networks[%d]", i), "network name must not be empty")
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateWorkload checks that the driver has everything it needs to start an instance
+func validateWorkload(deployment *pb.Deployment, errs *Errors) {
+	config := deployment.InstanceConfig
+
+	switch deployment.DriverType {
+	case "podman", "containerd":
+		if config == nil || strings.TrimSpace(config.ImageName) == "" {
+			errs.add("instance_config.image", "is required for the %s driver", deployment.DriverType)
+		}
+	case "incus":
+		if config == nil || (strings.TrimSpace(config.ImageName) == "" && (config.ImageSource == nil || config.ImageSource.Alias == "")) {
+			errs.add("instance_config.image", "an image or instance_config.image_source.alias is required for the incus driver")
+		}
+	case "process":
+		if strings.TrimSpace(deployment.Command) == "" && len(deployment.CommandArray) == 0 {
+			errs.add("command", "command or command_array is required for the process driver")
+		}
+	}
+
+	if config == nil {
+		return
+	}
+
+	if source := config.ImageSource; source != nil && source.Mode != "" && !oneOf(source.Mode, ImagePullModes) {
+		errs.add("instance_config.image_source.mode", "must be one of %s", strings.Join(ImagePullModes, ", "))
+	}
+
+	for i, device := range config.Devices {
+		field := fmt.Sprintf("instance_config.devices[%d]", i)
+		if device == nil {
+			errs.add(field, "must not be empty")
+			continue
+		}
+		if device.Name == "" {
+			errs.add(field+".name", "is required")
+		}
+		if device.Type == "" {
+			errs.add(field+".type", "is required")
+		}
+	}
+}
+
+func validateResources(resources *pb.Resources, errs *Errors) {
+	if resources == nil {
+		return
+	}
+
+	if resources.MemoryLimitMb < 0 {
+		errs.add("resources.memory_mb", "must not be negative")
+	}
+	if resources.MemoryReservedMb < 0 {
+		errs.add("resources.memory_reserve_mb", "must not be negative")
+	} else if resources.MemoryLimitMb > 0 && resources.MemoryReservedMb > resources.MemoryLimitMb {
+		errs.add("resources.memory_reserve_mb", "must not exceed resources.memory_mb (%d)", resources.MemoryLimitMb)
+	}
+	if resources.CpuLimitCores < 0 {
+		errs.add("resources.cpu", "must not be negative")
+	}
+	if resources.CpuReservedCores < 0 {
+		errs.add("resources.cpu_reserve", "must not be negative")
+	} else if resources.CpuLimitCores > 0 && resources.CpuReservedCores > resources.CpuLimitCores {
+		errs.add("resources.cpu_reserve", "must not exceed resources.cpu (%g)", resources.CpuLimitCores)
+	}
+}
+
+func validateVolumes(volumes []*pb.Volume, errs *Errors) {
+	targets := make(map[string]int)
+	for i, volume := range volumes {
+		field := fmt.Sprintf("volumes[%d]", i)
+		if volume == nil {
+			errs.add(field, "must not be empty")
+			continue
+		}
+		if volume.Type != "" && !oneOf(volume.Type, VolumeTypes) {
+			errs.add(field+".type", "must be one of %s", strings.Join(VolumeTypes, ", "))
+		}
+		if volume.SourcePath == "" && volume.Type != "tmpfs" {
+			errs.add(field+".host_path", "is required")
+		}
+		if volume.TargetPath == "" {
+			errs.add(field+".instance_path", "is required")
+			continue
+		}
+		if !path.IsAbs(volume.TargetPath) {
+			errs.add(field+".instance_path", "must be an absolute path")
+		}
+		if first, ok := targets[volume.TargetPath]; ok {
+			errs.add(field+".instance_path", "%s is already mounted by volumes[%d]", volume.TargetPath, first)
+		} else {
+			targets[volume.TargetPath] = i
+		}
+	}
+}
+
+func validatePorts(ports []*pb.PortMapping, errs *Errors) {
+	hostPorts := make(map[string]int)
+	for i, port := range ports {
+		field := fmt.Sprintf("ports[%d]", i)
+		if port == nil {
+			errs.add(field, "must not be empty")
+			continue
+		}
+		if port.ContainerPort < 1 || port.ContainerPort > 65535 {
+			errs.add(field+".container_port", "must be between 1 and 65535")
+		}
+		// A host port of 0 lets the runtime pick a free port
+		if port.HostPort < 0 || port.HostPort > 65535 {
+			errs.add(field+".host_port", "must be between 0 and 65535")
+		}
+
+		protocol := strings.ToLower(port.Protocol)
+		if protocol != "" && !oneOf(protocol, PortProtocols) {
+			errs.add(field+".protocol", "must be one of %s", strings.Join(PortProtocols, ", "))
+		}
+		if protocol == "" {
+			protocol = "tcp"
+		}
+
+		if port.HostPort > 0 {
+			key := fmt.Sprintf("%d/%s", port.HostPort, protocol)
+			if first, ok := hostPorts[key]; ok {
+				errs.add(field+".host_port", "%s is already used by ports[%d]", key, first)
+			} else {
+				hostPorts[key] = i
+			}
+		}
+	}
+}
+
+func validatePlacement(placement *pb.Placement, errs *Errors) {
+	if placement == nil {
+		return
+	}
+
+	if placement.Strategy != "" && !oneOf(placement.Strategy, PlacementStrategy) {
+		errs.add("placement.strategy", "must be one of %s", strings.Join(PlacementStrategy, ", "))
+	}
+	for i, constraint := range placement.Constraints {
+		if strings.TrimSpace(constraint) == "" {
+			errs.add(fmt.Sprintf("placement.constraints[%d]", i), "must not be empty")
+		} else if _, err := ParseConstraint(constraint); err != nil {
+			errs.add(fmt.Sprintf("placement.constraints[%d]", i), "%v", err)
+		}
+	}
+}
+
+func validateHealthCheck(healthCheck *pb.HealthCheck, errs *Errors) {
+	if healthCheck == nil {
+		return
+	}
+
+	if len(healthCheck.Test) == 0 {
+		errs.add("health_check.test", "is required when a health check is configured")
+	} else if first := healthCheck.Test[0]; first != "NONE" && first != "CMD" && first != "CMD-SHELL" {
+		errs.add("health_check.test", "must start with CMD, CMD-SHELL or NONE")
+	} else if first != "NONE" && len(healthCheck.Test) < 2 {
+		errs.add("health_check.test", "%s needs a command", first)
+	}
+
+	validateDuration("health_check.interval", healthCheck.Interval, errs)
+	validateDuration("health_check.timeout", healthCheck.Timeout, errs)
+	validateDuration("health_check.start_period", healthCheck.StartPeriod, errs)
+
+	if healthCheck.Retries < 0 {
+		errs.add("health_check.retries", "must not be negative")
+	}
+}
+
+func validateRestartPolicy(policy *pb.RestartPolicy, errs *Errors) {
+	if policy == nil {
+		return
+	}
+
+	if policy.Condition != "" && !oneOf(policy.Condition, RestartConditions) {
+		errs.add("restart_policy.condition", "must be one of %s", strings.Join(RestartConditions, ", "))
+	}
+	if policy.MaxAttempts < 0 {
+		errs.add("restart_policy.max_attempts", "must not be negative")
+	}
+}
+
+func validateUpdateStrategy(deployment *pb.Deployment, errs *Errors) {
+	update := deployment.Update
+	if update == nil {
+		return
+	}
+
+	if deployment.DeploymentType != "service" {
+		errs.add("update", "is only supported for service deployments")
+	}
+	if update.MaxParallel < 0 {
+		errs.add("update.max_parallel", "must not be negative")
+	}
+	if update.MaxSurge < 0 {
+		errs.add("update.max_surge", "must not be negative")
+	}
+	validateDuration("update.min_healthy_time", update.MinHealthyTime, errs)
+	validateDuration("update.healthy_deadline", update.HealthyDeadline, errs)
+
+	if update.Strategy != "" && !oneOf(update.Strategy, UpdateStrategies) {
+		errs.add("update.strategy", "must be one of %s", strings.Join(UpdateStrategies, ", "))
+	}
+	if update.Canary < 0 {
+		errs.add("update.canary", "must not be negative")
+	} else if update.Canary > 0 && update.Strategy != "canary" {
+		errs.add("update.canary", "is only used by the canary strategy")
+	} else if update.Canary > deployment.Replicas {
+		errs.add("update.canary", "must not exceed replicas (%d)", deployment.Replicas)
+	}
+	if update.AutoPromoteAfter != "" && update.Strategy != "canary" && update.Strategy != "blue-green" {
+		errs.add("update.auto_promote_after", "is only used by the canary and blue-green strategies")
+	}
+	validateDuration("update.auto_promote_after", update.AutoPromoteAfter, errs)
+}
+
+func validatePeriodic(deployment *pb.Deployment, errs *Errors) {
+	if deployment.Periodic == nil {
+		return
+	}
+
+	if deployment.DeploymentType != "" && deployment.DeploymentType != "batch" {
+		errs.add("periodic", "is only supported for batch deployments")
+	}
+	if deployment.Replicas > 1 {
+		errs.add("replicas", "must be 1 for periodic deployments, each launch runs a single child deployment")
+	}
+	if _, err := periodic.Location(deployment.Periodic); err != nil {
+		errs.add("periodic.time_zone", "%v", err)
+	} else if _, err := periodic.ParseSchedule(deployment.Periodic); err != nil {
+		errs.add("periodic.cron", "%v", err)
+	}
+}
+
+func validateDependencies(deployment *pb.Deployment, errs *Errors) {
+	if len(deployment.DependsOn) > 0 && deployment.Periodic != nil {
+		errs.add("depends_on", "is not supported for periodic deployments")
+	}
+
+	upstreams := make(map[string]int)
+	for i, dependency := range deployment.DependsOn {
+		field := fmt.Sprintf("depends_on[%d]", i)
+		if dependency == nil {
+			errs.add(field, "must not be empty")
+			continue
+		}
+		switch {
+		case dependency.DeploymentId == "":
+			errs.add(field+".deployment_id", "is required")
+		case !deploymentIDPattern.MatchString(dependency.DeploymentId):
+			errs.add(field+".deployment_id", "is not a valid deployment ID")
+		case dependency.DeploymentId == deployment.DeploymentId:
+			errs.add(field+".deployment_id", "a deployment cannot depend on itself")
+		default:
+			if first, ok := upstreams[dependency.DeploymentId]; ok {
+				errs.add(field+".deployment_id", "%s is already listed in depends_on[%d]", dependency.DeploymentId, first)
+			} else {
+				upstreams[dependency.DeploymentId] = i
+			}
+		}
+		if dependency.Condition != "" && !oneOf(dependency.Condition, DependencyConds) {
+			errs.add(field+".condition", "must be one of %s", strings.Join(DependencyConds, ", "))
+		}
+	}
+}
+
+// validateSecrets checks the secret references. Whether the secrets exist is
+// checked by the API, which has the secrets store. It returns the files the
+// secrets are written to, with the field of the reference.
+func validateSecrets(deployment *pb.Deployment, errs *Errors) map[string]string {
+	envs := make(map[string]int)
+	files := make(map[string]string)
+	mounts := volumeTargets(deployment)
+
+	for i, ref := range deployment.Secrets {
+		field := fmt.Sprintf("secrets[%d]", i)
+		if ref == nil {
+			errs.add(field, "must not be empty")
+			continue
+		}
+		if strings.TrimSpace(ref.Secret) == "" {
+			errs.add(field+".secret", "is required")
+		}
+		if (ref.Env == "") == (ref.File == "") {
+			errs.add(field, "set either env or file")
+			continue
+		}
+
+		if ref.Env != "" {
+			switch {
+			case !envNamePattern.MatchString(ref.Env):
+				errs.add(field+".env", "must be letters, digits or '_' and not start with a digit")
+			case hasKey(deployment.EnvironmentVariables, ref.Env):
+				errs.add(field+".env", "%s is already set in env", ref.Env)
+			default:
+				if first, ok := envs[ref.Env]; ok {
+					errs.add(field+".env", "%s is already set by secrets[%d]", ref.Env, first)
+				} else {
+					envs[ref.Env] = i
+				}
+			}
+			continue
+		}
+
+		switch {
+		case deployment.DriverType == "process":
+			errs.add(field+".file", "the process driver cannot mount files, use env")
+		case !path.IsAbs(ref.File) || path.Clean(ref.File) != ref.File:
+			errs.add(field+".file", "must be a clean absolute path")
+		case mounts[ref.File]:
+			errs.add(field+".file", "%s is already mounted by volumes", ref.File)
+		default:
+			if first, ok := files[ref.File]; ok {
+				errs.add(field+".file", "%s is already written by %s", ref.File, first)
+			} else {
+				files[ref.File] = field
+			}
+		}
+	}
+	return files
+}
+
+// validateConfigs checks the config references. Their files must not be
+// written by secrets or by another reference. Whether the configs exist and
+// are valid templates is checked by the API.
+func validateConfigs(deployment *pb.Deployment, files map[string]string, errs *Errors) {
+	mounts := volumeTargets(deployment)
+	for i, ref := range deployment.Configs {
+		field := fmt.Sprintf("configs[%d]", i)
+		if ref == nil {
+			errs.add(field, "must not be empty")
+			continue
+		}
+		if strings.TrimSpace(ref.Config) == "" {
+			errs.add(field+".config", "is required")
+		}
+
+		switch {
+		case ref.File == "":
+			errs.add(field+".file", "is required")
+		case deployment.DriverType == "process":
+			errs.add(field+".file", "the process driver cannot mount files")
+		case !path.IsAbs(ref.File) || path.Clean(ref.File) != ref.File:
+			errs.add(field+".file", "must be a clean absolute path")
+		case mounts[ref.File]:
+			errs.add(field+".file", "%s is already mounted by volumes", ref.File)
+		default:
+			if first, ok := files[ref.File]; ok {
+				errs.add(field+".file", "%s is already written by %s", ref.File, first)
+			} else {
+				files[ref.File] = field
+			}
+		}
+	}
+}
+
+func volumeTargets(deployment *pb.Deployment) map[string]bool {
+	mounts := make(map[string]bool)
+	for _, volume := range deployment.VolumeMounts {
+		if volume != nil {
+			mounts[volume.TargetPath] = true
+		}
+	}
+	return mounts
+}
+
+func validateDuration(field, value string, errs *Errors) {
+	if value == "" {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		errs.add(field, "invalid duration %q, expected a value such as 30s or 1m", value)
+		return
+	}
+	if d <= 0 {
+		errs.add(field, "must be positive")
+	}
+}
+
+func hasKey(values map[string]string, key string) bool {
+	_, ok := values[key]
+	return ok
+}
+
+func oneOf(value string, allowed []string) bool {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package spec
+
+import (
+	"testing"
+
+	pb "github.com/open-scheduler/proto"
+)
+
+func validDeployment() *pb.Deployment {
+	return &pb.Deployment{
+		DeploymentId:   "web",
+		DeploymentName: "web",
+		DeploymentType: "service",
+		DriverType:     "process",
+		Command:        "/bin/true",
+		Replicas:       1,
+	}
+}
+
+func hasFieldError(errs Errors, field string) bool {
+	for _, fieldErr := range errs {
+		if fieldErr.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidatePeriodic(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(*pb.Deployment)
+		badField string
+	}{
+		{
+			name: "single replica",
+			modify: func(d *pb.Deployment) {
+				d.DeploymentType = "batch"
+				d.Periodic = &pb.Periodic{Cron: "@hourly"}
+			},
+		},
+		{
+			name: "more than one replica",
+			modify: func(d *pb.Deployment) {
+				d.DeploymentType = "batch"
+				d.Replicas = 3
+				d.Periodic = &pb.Periodic{Cron: "@hourly"}
+			},
+			badField: "replicas",
+		},
+		{
+			name: "service deployment",
+			modify: func(d *pb.Deployment) {
+				d.Periodic = &pb.Periodic{Cron: "@hourly"}
+			},
+			badField: "periodic",
+		},
+		{
+			name: "invalid cron expression",
+			modify: func(d *pb.Deployment) {
+				d.DeploymentType = "batch"
+				d.Periodic = &pb.Periodic{Cron: "every day"}
+			},
+			badField: "periodic.cron",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := validDeployment()
+			tt.modify(deployment)
+			errs := ValidateDeployment(deployment)
+			if tt.badField == "" && errs != nil {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if tt.badField != "" && !hasFieldError(errs, tt.badField) {
+				t.Fatalf("expected an error for %s, got %v", tt.badField, errs)
+			}
+		})
+	}
+}
+
+func TestValidateClientID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{id: "web", valid: true},
+		{id: "web-v2", valid: true},
+		{id: "2024-report.v1", valid: true},
+		{id: "web-3", valid: false},
+		{id: "report-2024", valid: false},
+		{id: "nightly-periodic-1717200000", valid: false},
+		{id: "nightly-periodic-x", valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			errs := ValidateClientID(tt.id)
+			if tt.valid && errs != nil {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			if !tt.valid && !hasFieldError(errs, "deployment_id") {
+				t.Fatalf("expected %q to be rejected", tt.id)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const auditPrefix = "/centro/audit/"
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeDenied  = "denied"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEntry records one change made through the REST API. Entries are never
+// updated or deleted by Centro.
+type AuditEntry struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+	// Actor is the user or service account, empty if the request was not authenticated
+	Actor   string `json:"actor,omitempty"`
+	Role    string `json:"role,omitempty"`
+	TokenID string `json:"token_id,omitempty"`
+	// Action is the permission the route requires, like deployments:update,
+	// or the route for routes that need none
+	Action     string `json:"action"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Resource   string `json:"resource,omitempty"`
+	ResourceID string `json:"resource_id,omitempty"`
+	SourceIP   string `json:"source_ip"`
+	// ForwardedFor is the X-Forwarded-For header, which clients can set to anything
+	ForwardedFor string `json:"forwarded_for,omitempty"`
+	StatusCode   int    `json:"status_code"`
+	Outcome      string `json:"outcome"`
+	// Body is the request body with secrets replaced
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+type AuditFilter struct {
+	Actor      string
+	Action     string
+	Resource   string
+	ResourceID string
+	Outcome    string
+	RequestID  string
+	Since      time.Time
+	Until      time.Time
+}
+
+func (f AuditFilter) Matches(entry *AuditEntry) bool {
+	if f.Actor != "" && f.Actor != entry.Actor {
+		return false
+	}
+	if f.Action != "" && f.Action != entry.Action {
+		return false
+	}
+	if f.Resource != "" && f.Resource != entry.Resource {
+		return false
+	}
+	if f.ResourceID != "" && f.ResourceID != entry.ResourceID {
+		return false
+	}
+	if f.Outcome != "" && f.Outcome != entry.Outcome {
+		return false
+	}
+	if f.RequestID != "" && f.RequestID != entry.RequestID {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// SaveAuditEntry appends an entry to the audit log. The key starts with the
+// time of the entry, so the log reads in order; an existing key is never
+// overwritten.
+func (s *Storage) SaveAuditEntry(ctx context.Context, entry *AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	key := auditPrefix + eventKeyTime(entry.Time) + "-" + entry.ID
+	resp, err := s.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.CreateRevision(key), "=", 0),
+	).Then(
+		clientv3.OpPut(key, string(data)),
+	).Commit()
+	if err != nil {
+		return fmt.Errorf("failed to save audit entry: %w", err)
+	}
+	if !resp.Succeeded {
+		return fmt.Errorf("audit entry %s exists already", key)
+	}
+	return nil
+}
+
+// GetAuditEntries returns the audit entries matching the filter, oldest first
+func (s *Storage) GetAuditEntries(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error) {
+	// As with events the time range is applied to the key range
+	startKey := auditPrefix
+	if !filter.Since.IsZero() {
+		startKey = auditPrefix + eventKeyTime(filter.Since)
+	}
+	endKey := clientv3.GetPrefixRangeEnd(auditPrefix)
+	if !filter.Until.IsZero() {
+		endKey = auditPrefix + eventKeyTime(filter.Until.Add(time.Nanosecond))
+	}
+
+	resp, err := s.client.Get(ctx, startKey, clientv3.WithRange(endKey), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+
+	entries := make([]*AuditEntry, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var entry AuditEntry
+		if err := json.Unmarshal(kv.Value, &entry); err != nil {
+			continue
+		}
+		if filter.Matches(&entry) {
+			entries = append(entries, &entry)
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,60 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	pb "github.com/open-scheduler/proto"
+)
+
+const batchStatePrefix = "/centro/deployments/batches/"
+
+// BatchState records the replica units started for a batch deployment. Batch
+// units are started once and retried by their retry policy, never replaced.
+type BatchState struct {
+	DeploymentID string    `json:"deployment_id"`
+	SpecRevision int64     `json:"spec_revision"`
+	Units        []string  `json:"units"`
+	StartedAt    time.Time `json:"started_at"`
+}
+
+// IsManagedBatch reports whether a batch deployment runs as several replica
+// units that must all complete. Single-replica batches are queued directly.
+func IsManagedBatch(deployment *pb.Deployment) bool {
+	return deployment != nil && deployment.DeploymentType == "batch" && deployment.ParentDeploymentId == "" &&
+		deployment.Periodic == nil && deployment.Replicas > 1
+}
+
+func (s *Storage) GetBatchState(ctx context.Context, deploymentID string) (*BatchState, error) {
+	resp, err := s.client.Get(ctx, batchStatePrefix+deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch state: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var state BatchState
+	if err := json.Unmarshal(resp.Kvs[0].Value, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch state: %w", err)
+	}
+
+	return &state, nil
+}
+
+func (s *Storage) SaveBatchState(ctx context.Context, state *BatchState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch state: %w", err)
+	}
+
+	_, err = s.client.Put(ctx, batchStatePrefix+state.DeploymentID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save batch state: %w", err)
+	}
+
+	return nil
+}
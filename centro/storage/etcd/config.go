@@ -0,0 +1,107 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const configsPrefix = "/centro/configs/"
+
+// Config is a named file content that deployments mount into their
+// instances, optionally rendered as a template
+type Config struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Data        string `json:"data"`
+	// Version counts the updates of the data, starting at 1
+	Version   int64     `json:"version"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *Storage) SaveConfig(ctx context.Context, config *Config) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if _, err := s.client.Put(ctx, configsPrefix+config.Name, string(data)); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+// CreateConfig saves a new config and reports false if one with the same name exists
+func (s *Storage) CreateConfig(ctx context.Context, config *Config) (bool, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	key := configsPrefix + config.Name
+	resp, err := s.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.CreateRevision(key), "=", 0),
+	).Then(
+		clientv3.OpPut(key, string(data)),
+	).Commit()
+	if err != nil {
+		return false, fmt.Errorf("failed to create config: %w", err)
+	}
+	return resp.Succeeded, nil
+}
+
+func (s *Storage) GetConfig(ctx context.Context, name string) (*Config, error) {
+	resp, err := s.client.Get(ctx, configsPrefix+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var config Config
+	if err := json.Unmarshal(resp.Kvs[0].Value, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// GetAllConfigs returns every config sorted by name
+func (s *Storage) GetAllConfigs(ctx context.Context) ([]*Config, error) {
+	resp, err := s.client.Get(ctx, configsPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configs: %w", err)
+	}
+
+	configs := make([]*Config, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var config Config
+		if err := json.Unmarshal(kv.Value, &config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+		configs = append(configs, &config)
+	}
+
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Name < configs[j].Name
+	})
+	return configs, nil
+}
+
+// DeleteConfig removes a config and reports whether it existed
+func (s *Storage) DeleteConfig(ctx context.Context, name string) (bool, error) {
+	resp, err := s.client.Delete(ctx, configsPrefix+name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete config: %w", err)
+	}
+	return resp.Deleted > 0, nil
+}
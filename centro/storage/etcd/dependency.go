@@ -0,0 +1,76 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	pb "github.com/open-scheduler/proto"
+)
+
+const dependencyStatePrefix = "/centro/deployments/dependencies/"
+
+// Status of a deployment with dependencies
+const (
+	DependencyStatusBlocked        = "blocked"
+	DependencyStatusReleased       = "released"
+	DependencyStatusUpstreamFailed = "upstream_failed"
+)
+
+// Conditions an upstream deployment must meet
+const (
+	DependencySucceeded = "succeeded"
+	DependencyFailed    = "failed"
+	DependencyCompleted = "completed"
+)
+
+// DependencyState records whether a deployment with dependencies is still
+// blocked by its upstream deployments. Once released it is scheduled like any
+// other deployment.
+type DependencyState struct {
+	DeploymentID string    `json:"deployment_id"`
+	Status       string    `json:"status"`
+	Message      string    `json:"message"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	ReleasedAt   time.Time `json:"released_at,omitempty"`
+}
+
+// HasDependencies reports whether a deployment waits for upstream deployments
+// before it is queued or its replicas are started
+func HasDependencies(deployment *pb.Deployment) bool {
+	return deployment != nil && len(deployment.DependsOn) > 0 && deployment.ParentDeploymentId == ""
+}
+
+func (s *Storage) GetDependencyState(ctx context.Context, deploymentID string) (*DependencyState, error) {
+	resp, err := s.client.Get(ctx, dependencyStatePrefix+deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dependency state: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var state DependencyState
+	if err := json.Unmarshal(resp.Kvs[0].Value, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal dependency state: %w", err)
+	}
+
+	return &state, nil
+}
+
+func (s *Storage) SaveDependencyState(ctx context.Context, state *DependencyState) error {
+	state.UpdatedAt = time.Now()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dependency state: %w", err)
+	}
+
+	_, err = s.client.Put(ctx, dependencyStatePrefix+state.DeploymentID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save dependency state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package etcd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const (
+	leaderElectionPrefix = "/centro/leader/"
+	leaderSessionTTL     = 15
+)
+
+// RunAsLeader campaigns for cluster leadership and calls run once this instance
+// has been elected. The context passed to run is cancelled as soon as leadership
+// is lost, after which the instance campaigns again. RunAsLeader blocks until ctx
+// is cancelled.
+func (s *Storage) RunAsLeader(ctx context.Context, candidateID string, run func(ctx context.Context)) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		session, err := concurrency.NewSession(s.client, concurrency.WithTTL(leaderSessionTTL), concurrency.WithContext(ctx))
+		if err != nil {
+			log.Printf("[Centro] Failed to create leader election session: %v", err)
+			if !sleepContext(ctx, 5*time.Second) {
+				return
+			}
+			continue
+		}
+
+		election := concurrency.NewElection(session, leaderElectionPrefix)
+		if err := election.Campaign(ctx, candidateID); err != nil {
+			session.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("[Centro] Leader election campaign failed: %v", err)
+			continue
+		}
+
+		log.Printf("[Centro] Elected as leader (%s)", candidateID)
+		leaderCtx, cancel := context.WithCancel(ctx)
+		go run(leaderCtx)
+
+		select {
+		case <-session.Done():
+			log.Printf("[Centro] Lost leadership (%s)", candidateID)
+		case <-ctx.Done():
+		}
+		cancel()
+
+		resignCtx, resignCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := election.Resign(resignCtx); err != nil {
+			log.Printf("[Centro] Failed to resign leadership: %v", err)
+		}
+		resignCancel()
+		session.Close()
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
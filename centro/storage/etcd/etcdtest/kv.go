@@ -0,0 +1,322 @@
+// Package etcdtest provides an in-memory etcd key-value store for tests of
+// code that uses the storage package, so that they run without an etcd
+// server. The KV API is implemented, and leases as far as granting and
+// revoking them; leases never expire and watches are not supported.
+package etcdtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+)
+
+// KV is an in-memory implementation of the etcd KV service
+type KV struct {
+	mu       sync.Mutex
+	revision int64
+	kvs      map[string]*mvccpb.KeyValue
+}
+
+func NewKV() *KV {
+	return &KV{revision: 1, kvs: make(map[string]*mvccpb.KeyValue)}
+}
+
+// NewClient returns an etcd client backed by a new in-memory store. Its KV
+// methods work, and Grant and Revoke of its leases.
+func NewClient() *clientv3.Client {
+	kv := NewKV()
+	client := &clientv3.Client{}
+	client.KV = clientv3.NewKVFromKVClient(kv, client)
+	client.Lease = clientv3.NewLeaseFromLeaseClient(&leases{kv: kv}, client, time.Minute)
+	return client
+}
+
+// NewStorage returns a storage backed by a new in-memory store, and the
+// client to read and write raw keys with
+func NewStorage() (*etcdstorage.Storage, *clientv3.Client) {
+	client := NewClient()
+	return etcdstorage.NewStorageFromClient(client), client
+}
+
+func (kv *KV) header() *pb.ResponseHeader {
+	return &pb.ResponseHeader{Revision: kv.revision}
+}
+
+// inRange reports whether key is in [start, end) with etcd's meaning of end:
+// empty for the single key start, "\x00" for every key from start on
+func inRange(key, start, end []byte) bool {
+	switch {
+	case len(end) == 0:
+		return bytes.Equal(key, start)
+	case len(end) == 1 && end[0] == 0:
+		return bytes.Compare(key, start) >= 0
+	default:
+		return bytes.Compare(key, start) >= 0 && bytes.Compare(key, end) < 0
+	}
+}
+
+func (kv *KV) matching(start, end []byte) []*mvccpb.KeyValue {
+	var matches []*mvccpb.KeyValue
+	for key, item := range kv.kvs {
+		if inRange([]byte(key), start, end) {
+			matches = append(matches, item)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return bytes.Compare(matches[i].Key, matches[j].Key) < 0
+	})
+	return matches
+}
+
+func copyKV(item *mvccpb.KeyValue, keysOnly bool) *mvccpb.KeyValue {
+	c := *item
+	if keysOnly {
+		c.Value = nil
+	}
+	return &c
+}
+
+func (kv *KV) Range(ctx context.Context, in *pb.RangeRequest, opts ...grpc.CallOption) (*pb.RangeResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	return kv.rangeLocked(in)
+}
+
+func (kv *KV) rangeLocked(in *pb.RangeRequest) (*pb.RangeResponse, error) {
+	matches := kv.matching(in.Key, in.RangeEnd)
+
+	less := func(a, b *mvccpb.KeyValue) bool { return bytes.Compare(a.Key, b.Key) < 0 }
+	switch in.SortTarget {
+	case pb.RangeRequest_VERSION:
+		less = func(a, b *mvccpb.KeyValue) bool { return a.Version < b.Version }
+	case pb.RangeRequest_CREATE:
+		less = func(a, b *mvccpb.KeyValue) bool { return a.CreateRevision < b.CreateRevision }
+	case pb.RangeRequest_MOD:
+		less = func(a, b *mvccpb.KeyValue) bool { return a.ModRevision < b.ModRevision }
+	case pb.RangeRequest_VALUE:
+		less = func(a, b *mvccpb.KeyValue) bool { return bytes.Compare(a.Value, b.Value) < 0 }
+	}
+	if in.SortOrder == pb.RangeRequest_DESCEND {
+		sort.SliceStable(matches, func(i, j int) bool { return less(matches[j], matches[i]) })
+	} else {
+		sort.SliceStable(matches, func(i, j int) bool { return less(matches[i], matches[j]) })
+	}
+
+	resp := &pb.RangeResponse{Header: kv.header(), Count: int64(len(matches))}
+	if in.CountOnly {
+		return resp, nil
+	}
+	if in.Limit > 0 && int64(len(matches)) > in.Limit {
+		matches = matches[:in.Limit]
+		resp.More = true
+	}
+	for _, item := range matches {
+		resp.Kvs = append(resp.Kvs, copyKV(item, in.KeysOnly))
+	}
+	return resp, nil
+}
+
+func (kv *KV) Put(ctx context.Context, in *pb.PutRequest, opts ...grpc.CallOption) (*pb.PutResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.revision++
+	return kv.putLocked(in), nil
+}
+
+func (kv *KV) putLocked(in *pb.PutRequest) *pb.PutResponse {
+	resp := &pb.PutResponse{Header: kv.header()}
+	prev, ok := kv.kvs[string(in.Key)]
+	item := &mvccpb.KeyValue{
+		Key:            in.Key,
+		Value:          in.Value,
+		CreateRevision: kv.revision,
+		ModRevision:    kv.revision,
+		Version:        1,
+		Lease:          in.Lease,
+	}
+	if ok {
+		if in.PrevKv {
+			resp.PrevKv = copyKV(prev, false)
+		}
+		item.CreateRevision = prev.CreateRevision
+		item.Version = prev.Version + 1
+		if in.IgnoreValue {
+			item.Value = prev.Value
+		}
+		if in.IgnoreLease {
+			item.Lease = prev.Lease
+		}
+	}
+	kv.kvs[string(in.Key)] = item
+	return resp
+}
+
+func (kv *KV) DeleteRange(ctx context.Context, in *pb.DeleteRangeRequest, opts ...grpc.CallOption) (*pb.DeleteRangeResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.revision++
+	return kv.deleteLocked(in), nil
+}
+
+func (kv *KV) deleteLocked(in *pb.DeleteRangeRequest) *pb.DeleteRangeResponse {
+	resp := &pb.DeleteRangeResponse{Header: kv.header()}
+	for _, item := range kv.matching(in.Key, in.RangeEnd) {
+		delete(kv.kvs, string(item.Key))
+		resp.Deleted++
+		if in.PrevKv {
+			resp.PrevKvs = append(resp.PrevKvs, copyKV(item, false))
+		}
+	}
+	return resp
+}
+
+func (kv *KV) compare(cmp *pb.Compare) (bool, error) {
+	matches := kv.matching(cmp.Key, cmp.RangeEnd)
+	if len(matches) == 0 {
+		// A missing key has version, create and mod revision 0
+		matches = []*mvccpb.KeyValue{{Key: cmp.Key}}
+	}
+	for _, item := range matches {
+		var result int
+		switch cmp.Target {
+		case pb.Compare_VERSION:
+			result = compareInt(item.Version, cmp.GetVersion())
+		case pb.Compare_CREATE:
+			result = compareInt(item.CreateRevision, cmp.GetCreateRevision())
+		case pb.Compare_MOD:
+			result = compareInt(item.ModRevision, cmp.GetModRevision())
+		case pb.Compare_VALUE:
+			if item.CreateRevision == 0 {
+				return false, nil
+			}
+			result = bytes.Compare(item.Value, cmp.GetValue())
+		default:
+			return false, fmt.Errorf("etcdtest: unsupported compare target %v", cmp.Target)
+		}
+
+		var ok bool
+		switch cmp.Result {
+		case pb.Compare_EQUAL:
+			ok = result == 0
+		case pb.Compare_NOT_EQUAL:
+			ok = result != 0
+		case pb.Compare_GREATER:
+			ok = result > 0
+		case pb.Compare_LESS:
+			ok = result < 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (kv *KV) Txn(ctx context.Context, in *pb.TxnRequest, opts ...grpc.CallOption) (*pb.TxnResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.revision++
+	return kv.txnLocked(in)
+}
+
+func (kv *KV) txnLocked(in *pb.TxnRequest) (*pb.TxnResponse, error) {
+	succeeded := true
+	for _, cmp := range in.Compare {
+		ok, err := kv.compare(cmp)
+		if err != nil {
+			return nil, err
+		}
+		succeeded = succeeded && ok
+	}
+
+	ops := in.Success
+	if !succeeded {
+		ops = in.Failure
+	}
+	resp := &pb.TxnResponse{Header: kv.header(), Succeeded: succeeded}
+	for _, op := range ops {
+		switch request := op.Request.(type) {
+		case *pb.RequestOp_RequestRange:
+			rangeResp, err := kv.rangeLocked(request.RequestRange)
+			if err != nil {
+				return nil, err
+			}
+			resp.Responses = append(resp.Responses, &pb.ResponseOp{Response: &pb.ResponseOp_ResponseRange{ResponseRange: rangeResp}})
+		case *pb.RequestOp_RequestPut:
+			resp.Responses = append(resp.Responses, &pb.ResponseOp{Response: &pb.ResponseOp_ResponsePut{ResponsePut: kv.putLocked(request.RequestPut)}})
+		case *pb.RequestOp_RequestDeleteRange:
+			resp.Responses = append(resp.Responses, &pb.ResponseOp{Response: &pb.ResponseOp_ResponseDeleteRange{ResponseDeleteRange: kv.deleteLocked(request.RequestDeleteRange)}})
+		case *pb.RequestOp_RequestTxn:
+			txnResp, err := kv.txnLocked(request.RequestTxn)
+			if err != nil {
+				return nil, err
+			}
+			resp.Responses = append(resp.Responses, &pb.ResponseOp{Response: &pb.ResponseOp_ResponseTxn{ResponseTxn: txnResp}})
+		}
+	}
+	return resp, nil
+}
+
+// Compact does nothing, the store keeps no history
+func (kv *KV) Compact(ctx context.Context, in *pb.CompactionRequest, opts ...grpc.CallOption) (*pb.CompactionResponse, error) {
+	return &pb.CompactionResponse{Header: kv.header()}, nil
+}
+
+// leases hands out lease IDs for the keys of a KV. Keys attached to a lease
+// are deleted when it is revoked.
+type leases struct {
+	kv     *KV
+	mu     sync.Mutex
+	nextID int64
+}
+
+func (l *leases) LeaseGrant(ctx context.Context, in *pb.LeaseGrantRequest, opts ...grpc.CallOption) (*pb.LeaseGrantResponse, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	return &pb.LeaseGrantResponse{Header: l.kv.header(), ID: l.nextID, TTL: in.TTL}, nil
+}
+
+func (l *leases) LeaseRevoke(ctx context.Context, in *pb.LeaseRevokeRequest, opts ...grpc.CallOption) (*pb.LeaseRevokeResponse, error) {
+	l.kv.mu.Lock()
+	defer l.kv.mu.Unlock()
+	l.kv.revision++
+	for key, item := range l.kv.kvs {
+		if item.Lease == in.ID {
+			delete(l.kv.kvs, key)
+		}
+	}
+	return &pb.LeaseRevokeResponse{Header: l.kv.header()}, nil
+}
+
+func (l *leases) LeaseKeepAlive(ctx context.Context, opts ...grpc.CallOption) (pb.Lease_LeaseKeepAliveClient, error) {
+	return nil, fmt.Errorf("etcdtest: lease keep alive is not supported")
+}
+
+func (l *leases) LeaseTimeToLive(ctx context.Context, in *pb.LeaseTimeToLiveRequest, opts ...grpc.CallOption) (*pb.LeaseTimeToLiveResponse, error) {
+	return nil, fmt.Errorf("etcdtest: lease time to live is not supported")
+}
+
+func (l *leases) LeaseLeases(ctx context.Context, in *pb.LeaseLeasesRequest, opts ...grpc.CallOption) (*pb.LeaseLeasesResponse, error) {
+	return nil, fmt.Errorf("etcdtest: listing leases is not supported")
+}
@@ -0,0 +1,114 @@
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	EventTypeNormal  = "normal"
+	EventTypeWarning = "warning"
+)
+
+const (
+	EventSourceCentro    = "centro"
+	EventSourceScheduler = "scheduler"
+	EventSourceAgent     = "agent"
+)
+
+// DeploymentEvent is a single entry in a deployment's event timeline
+type DeploymentEvent struct {
+	DeploymentID string    `json:"deployment_id"`
+	Time         time.Time `json:"time"`
+	Type         string    `json:"type"`
+	Reason       string    `json:"reason"`
+	Message      string    `json:"message"`
+	NodeID       string    `json:"node_id,omitempty"`
+	Source       string    `json:"source,omitempty"`
+	Attempt      int32     `json:"attempt"`
+}
+
+// sameAs reports whether two events carry the same information, ignoring when they happened
+func (e *DeploymentEvent) sameAs(other *DeploymentEvent) bool {
+	return e.Type == other.Type &&
+		e.Reason == other.Reason &&
+		e.Message == other.Message &&
+		e.NodeID == other.NodeID
+}
+
+// eventKeyTime formats the time in event keys: unix nanoseconds, zero padded
+// so that keys sort by time. Times outside of what fits are clamped, which
+// keeps range bounds built from them in order.
+func eventKeyTime(t time.Time) string {
+	switch {
+	case t.Before(time.Unix(0, 0)):
+		t = time.Unix(0, 0)
+	case t.After(time.Unix(0, math.MaxInt64)):
+		t = time.Unix(0, math.MaxInt64)
+	}
+	return fmt.Sprintf("%019d", t.UnixNano())
+}
+
+// ValidEventType reports whether events can have the type, in any case
+func ValidEventType(eventType string) bool {
+	return strings.EqualFold(eventType, EventTypeNormal) || strings.EqualFold(eventType, EventTypeWarning)
+}
+
+// EventFilter narrows down the events returned by GetDeploymentEvents.
+// Zero values match everything.
+type EventFilter struct {
+	DeploymentID string
+	Type         string
+	Since        time.Time
+	Until        time.Time
+}
+
+func (f EventFilter) Matches(event *DeploymentEvent) bool {
+	if f.DeploymentID != "" && f.DeploymentID != event.DeploymentID {
+		return false
+	}
+	if f.Type != "" && !strings.EqualFold(f.Type, event.Type) {
+		return false
+	}
+	if !f.Since.IsZero() && event.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && event.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// decodeDeploymentEvent reads a stored event. Events written before events were
+// structured are plain strings in the form "[RFC3339] message"; those are
+// converted so callers only ever deal with DeploymentEvent.
+func decodeDeploymentEvent(deploymentID string, value []byte) *DeploymentEvent {
+	var event DeploymentEvent
+	if len(value) > 0 && value[0] == '{' {
+		if err := json.Unmarshal(value, &event); err == nil {
+			if event.DeploymentID == "" {
+				event.DeploymentID = deploymentID
+			}
+			return &event
+		}
+	}
+
+	event = DeploymentEvent{
+		DeploymentID: deploymentID,
+		Type:         EventTypeNormal,
+		Message:      string(value),
+	}
+	raw := string(value)
+	if strings.HasPrefix(raw, "[") {
+		if idx := strings.Index(raw, "] "); idx != -1 {
+			if t, err := time.Parse(time.RFC3339, raw[1:idx]); err == nil {
+				event.Time = t
+			}
+			event.Message = raw[idx+2:]
+		}
+	}
+	return &event
+}
@@ -0,0 +1,77 @@
+package etcd_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	etcdstorage "github.com/open-scheduler/centro/storage/etcd"
+	"github.com/open-scheduler/centro/storage/etcd/etcdtest"
+)
+
+func TestGetDeploymentEventsTimeRange(t *testing.T) {
+	ctx := context.Background()
+	storage, _ := etcdtest.NewStorage()
+
+	const deploymentID = "web"
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, message := range []string{"scheduled", "started", "stopped"} {
+		event := &etcdstorage.DeploymentEvent{
+			Time:    base.Add(time.Duration(i) * time.Hour),
+			Type:    etcdstorage.EventTypeNormal,
+			Reason:  message,
+			Message: message,
+		}
+		if err := storage.SaveDeploymentEvent(ctx, deploymentID, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		until time.Time
+		want  int
+	}{
+		{name: "everything", want: 3},
+		{name: "since before 2001", since: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), want: 3},
+		{name: "since before 1970", since: time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), want: 3},
+		{name: "until before 2001", until: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), want: 0},
+		{name: "until before 1970", until: time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), want: 0},
+		{name: "until far future", until: time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC), want: 3},
+		{name: "window", since: base.Add(30 * time.Minute), until: base.Add(time.Hour), want: 1},
+		{name: "until is inclusive", until: base, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := storage.GetDeploymentEvents(ctx, deploymentID, etcdstorage.EventFilter{Since: tt.since, Until: tt.until})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != tt.want {
+				t.Errorf("got %d events, want %d", len(events), tt.want)
+			}
+		})
+	}
+
+	deleted, err := storage.DeleteDeploymentEventsBefore(ctx, deploymentID, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 0 {
+		t.Errorf("deleting events before 2000 deleted %d events", deleted)
+	}
+}
+
+func TestValidEventType(t *testing.T) {
+	for eventType, want := range map[string]bool{
+		"normal":  true,
+		"Warning": true,
+		"error":   false,
+		"":        false,
+	} {
+		if got := etcdstorage.ValidEventType(eventType); got != want {
+			t.Errorf("ValidEventType(%q) = %v, want %v", eventType, got, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const joinTokensPrefix = "/centro/jointokens/"
+
+// JoinToken admits new nodes, which are approved at once and get the cluster
+// and labels of the token. With TLS they exchange it for their first client
+// certificate. Only the SHA-256 hash of the secret is stored.
+type JoinToken struct {
+	ID          string            `json:"id"`
+	SecretHash  string            `json:"secret_hash"`
+	Description string            `json:"description,omitempty"`
+	Cluster     string            `json:"cluster,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	// MaxUses is how many nodes can join with the token
+	MaxUses   int       `json:"max_uses"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	// UsedBy are the nodes that joined with the token
+	UsedBy     []string   `json:"used_by,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// Exhausted reports whether as many nodes joined with the token as it allows
+func (t *JoinToken) Exhausted() bool {
+	return len(t.UsedBy) >= t.MaxUses
+}
+
+// SaveJoinToken stores a join token that etcd removes when it expires
+func (s *Storage) SaveJoinToken(ctx context.Context, token *JoinToken) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal join token: %w", err)
+	}
+
+	lease, err := s.client.Grant(ctx, leaseSeconds(token.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("failed to grant join token lease: %w", err)
+	}
+	if _, err := s.client.Put(ctx, joinTokensPrefix+token.ID, string(data), clientv3.WithLease(lease.ID)); err != nil {
+		return fmt.Errorf("failed to save join token: %w", err)
+	}
+	return nil
+}
+
+// GetJoinToken returns a join token, or nil if there is none with the ID
+func (s *Storage) GetJoinToken(ctx context.Context, id string) (*JoinToken, error) {
+	resp, err := s.client.Get(ctx, joinTokensPrefix+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join token: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var token JoinToken
+	if err := json.Unmarshal(resp.Kvs[0].Value, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal join token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// GetAllJoinTokens returns every unexpired join token, newest first
+func (s *Storage) GetAllJoinTokens(ctx context.Context) ([]*JoinToken, error) {
+	resp, err := s.client.Get(ctx, joinTokensPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join tokens: %w", err)
+	}
+
+	tokens := make([]*JoinToken, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var token JoinToken
+		if err := json.Unmarshal(kv.Value, &token); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal join token: %w", err)
+		}
+		tokens = append(tokens, &token)
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+	return tokens, nil
+}
+
+// UseJoinToken records that a node joined with a join token and returns it,
+// or nil if there is none with the ID. ok is false if the token was used up or
+// expired, then it is returned unchanged.
+func (s *Storage) UseJoinToken(ctx context.Context, id, nodeID string, usedAt time.Time) (token *JoinToken, ok bool, err error) {
+	key := joinTokensPrefix + id
+	for {
+		resp, err := s.client.Get(ctx, key)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get join token: %w", err)
+		}
+		if len(resp.Kvs) == 0 {
+			return nil, false, nil
+		}
+
+		var token JoinToken
+		if err := json.Unmarshal(resp.Kvs[0].Value, &token); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal join token: %w", err)
+		}
+		if token.Exhausted() || !usedAt.Before(token.ExpiresAt) {
+			return &token, false, nil
+		}
+
+		token.UsedBy = append(token.UsedBy, nodeID)
+		token.LastUsedAt = &usedAt
+		data, err := json.Marshal(&token)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to marshal join token: %w", err)
+		}
+		txn, err := s.client.Txn(ctx).If(
+			clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision),
+		).Then(
+			clientv3.OpPut(key, string(data), clientv3.WithIgnoreLease()),
+		).Commit()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to save join token: %w", err)
+		}
+		if txn.Succeeded {
+			return &token, true, nil
+		}
+	}
+}
+
+// DeleteJoinToken removes a join token and reports whether it existed
+func (s *Storage) DeleteJoinToken(ctx context.Context, id string) (bool, error) {
+	resp, err := s.client.Delete(ctx, joinTokensPrefix+id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete join token: %w", err)
+	}
+	return resp.Deleted > 0, nil
+}
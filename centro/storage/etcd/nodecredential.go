@@ -0,0 +1,139 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const nodeCredentialsPrefix = "/centro/nodecredentials/"
+
+// NodeCredential lets an agent call the gRPC API as one node. Only the
+// SHA-256 hash of the secret is stored.
+type NodeCredential struct {
+	ID         string     `json:"id"`
+	NodeID     string     `json:"node_id"`
+	SecretHash string     `json:"secret_hash"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func (s *Storage) SaveNodeCredential(ctx context.Context, credential *NodeCredential) error {
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return fmt.Errorf("failed to marshal node credential: %w", err)
+	}
+
+	if _, err := s.client.Put(ctx, nodeCredentialsPrefix+credential.ID, string(data)); err != nil {
+		return fmt.Errorf("failed to save node credential: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetNodeCredential(ctx context.Context, id string) (*NodeCredential, error) {
+	resp, err := s.client.Get(ctx, nodeCredentialsPrefix+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node credential: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var credential NodeCredential
+	if err := json.Unmarshal(resp.Kvs[0].Value, &credential); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal node credential: %w", err)
+	}
+
+	return &credential, nil
+}
+
+// GetNodeCredentials returns the credentials of a node, newest first
+func (s *Storage) GetNodeCredentials(ctx context.Context, nodeID string) ([]*NodeCredential, error) {
+	resp, err := s.client.Get(ctx, nodeCredentialsPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node credentials: %w", err)
+	}
+
+	credentials := make([]*NodeCredential, 0)
+	for _, kv := range resp.Kvs {
+		var credential NodeCredential
+		if err := json.Unmarshal(kv.Value, &credential); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal node credential: %w", err)
+		}
+		if credential.NodeID == nodeID {
+			credentials = append(credentials, &credential)
+		}
+	}
+
+	sort.Slice(credentials, func(i, j int) bool {
+		return credentials[i].CreatedAt.After(credentials[j].CreatedAt)
+	})
+	return credentials, nil
+}
+
+// DeleteNodeCredentials revokes credentials of a node, all of them if id is
+// empty, and returns how many were deleted
+func (s *Storage) DeleteNodeCredentials(ctx context.Context, nodeID, id string) (int, error) {
+	credentials, err := s.GetNodeCredentials(ctx, nodeID)
+	if err != nil {
+		return 0, err
+	}
+
+	var ops []clientv3.Op
+	for _, credential := range credentials {
+		if id == "" || credential.ID == id {
+			ops = append(ops, clientv3.OpDelete(nodeCredentialsPrefix+credential.ID))
+		}
+	}
+	if len(ops) == 0 {
+		return 0, nil
+	}
+
+	if _, err := s.client.Txn(ctx).Then(ops...).Commit(); err != nil {
+		return 0, fmt.Errorf("failed to delete node credentials: %w", err)
+	}
+	return len(ops), nil
+}
+
+// TouchNodeCredential records that a credential was used. A credential that
+// was deleted in the meantime is not written again.
+func (s *Storage) TouchNodeCredential(ctx context.Context, id string, usedAt time.Time) error {
+	key := nodeCredentialsPrefix + id
+	for {
+		resp, err := s.client.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to get node credential: %w", err)
+		}
+		if len(resp.Kvs) == 0 {
+			return nil
+		}
+
+		var credential NodeCredential
+		if err := json.Unmarshal(resp.Kvs[0].Value, &credential); err != nil {
+			return fmt.Errorf("failed to unmarshal node credential: %w", err)
+		}
+		credential.LastUsedAt = &usedAt
+
+		data, err := json.Marshal(&credential)
+		if err != nil {
+			return fmt.Errorf("failed to marshal node credential: %w", err)
+		}
+		txn, err := s.client.Txn(ctx).If(
+			clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision),
+		).Then(
+			clientv3.OpPut(key, string(data)),
+		).Commit()
+		if err != nil {
+			return fmt.Errorf("failed to save node credential: %w", err)
+		}
+		if txn.Succeeded {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const oidcLoginsPrefix = "/centro/oidc/logins/"
+
+// OIDCLogin is a browser login that was sent to the identity provider and
+// has not come back yet, keyed by its state parameter
+type OIDCLogin struct {
+	CodeVerifier string    `json:"code_verifier"`
+	Nonce        string    `json:"nonce"`
+	ReturnTo     string    `json:"return_to,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SaveOIDCLogin stores a pending login that etcd removes after ttl
+func (s *Storage) SaveOIDCLogin(ctx context.Context, state string, login *OIDCLogin, ttl time.Duration) error {
+	data, err := json.Marshal(login)
+	if err != nil {
+		return fmt.Errorf("failed to marshal OIDC login: %w", err)
+	}
+
+	lease, err := s.client.Grant(ctx, int64(ttl.Seconds()))
+	if err != nil {
+		return fmt.Errorf("failed to grant OIDC login lease: %w", err)
+	}
+	if _, err := s.client.Put(ctx, oidcLoginsPrefix+state, string(data), clientv3.WithLease(lease.ID)); err != nil {
+		return fmt.Errorf("failed to save OIDC login: %w", err)
+	}
+	return nil
+}
+
+// TakeOIDCLogin removes a pending login and returns it, or nil if there is
+// none with the state, so that every state is only used once
+func (s *Storage) TakeOIDCLogin(ctx context.Context, state string) (*OIDCLogin, error) {
+	resp, err := s.client.Delete(ctx, oidcLoginsPrefix+state, clientv3.WithPrevKV())
+	if err != nil {
+		return nil, fmt.Errorf("failed to take OIDC login: %w", err)
+	}
+
+	if len(resp.PrevKvs) == 0 {
+		return nil, nil
+	}
+
+	var login OIDCLogin
+	if err := json.Unmarshal(resp.PrevKvs[0].Value, &login); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal OIDC login: %w", err)
+	}
+
+	return &login, nil
+}
@@ -0,0 +1,85 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	pb "github.com/open-scheduler/proto"
+)
+
+const periodicStatePrefix = "/centro/deployments/periodic/"
+
+// MaxPeriodicLaunches is how many launches are kept in the history of a periodic deployment
+const MaxPeriodicLaunches = 100
+
+// PeriodicLaunch is a single launch of a periodic deployment. Skipped launches
+// have no child deployment.
+type PeriodicLaunch struct {
+	DeploymentID string    `json:"deployment_id,omitempty"`
+	SpecRevision int64     `json:"spec_revision"`
+	ScheduledAt  time.Time `json:"scheduled_at"`
+	LaunchedAt   time.Time `json:"launched_at"`
+	Skipped      string    `json:"skipped,omitempty"`
+}
+
+// PeriodicState records when a periodic deployment launches next and the
+// child deployments it launched, newest last
+type PeriodicState struct {
+	DeploymentID string            `json:"deployment_id"`
+	SpecRevision int64             `json:"spec_revision"`
+	NextLaunch   time.Time         `json:"next_launch"`
+	Launches     []*PeriodicLaunch `json:"launches"`
+}
+
+// IsManagedPeriodic reports whether a deployment is launched on a cron
+// schedule as a child deployment per launch instead of being queued itself
+func IsManagedPeriodic(deployment *pb.Deployment) bool {
+	return deployment != nil && deployment.Periodic != nil && deployment.ParentDeploymentId == ""
+}
+
+// PeriodicChildID returns the deployment ID of the launch of a periodic deployment scheduled at the given time
+func PeriodicChildID(deploymentID string, scheduledAt time.Time) string {
+	return fmt.Sprintf("%s-periodic-%d", deploymentID, scheduledAt.Unix())
+}
+
+// AddLaunch appends a launch to the history, dropping the oldest launches beyond MaxPeriodicLaunches
+func (state *PeriodicState) AddLaunch(launch *PeriodicLaunch) {
+	state.Launches = append(state.Launches, launch)
+	if len(state.Launches) > MaxPeriodicLaunches {
+		state.Launches = state.Launches[len(state.Launches)-MaxPeriodicLaunches:]
+	}
+}
+
+func (s *Storage) GetPeriodicState(ctx context.Context, deploymentID string) (*PeriodicState, error) {
+	resp, err := s.client.Get(ctx, periodicStatePrefix+deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get periodic state: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var state PeriodicState
+	if err := json.Unmarshal(resp.Kvs[0].Value, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal periodic state: %w", err)
+	}
+
+	return &state, nil
+}
+
+func (s *Storage) SavePeriodicState(ctx context.Context, state *PeriodicState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal periodic state: %w", err)
+	}
+
+	_, err = s.client.Put(ctx, periodicStatePrefix+state.DeploymentID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save periodic state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	caKey                  = "/centro/pki/ca"
+	nodeCertificatesPrefix = "/centro/certificates/"
+)
+
+// CAKeyPair is the internal CA that signs the certificates of Centro and of
+// the nodes. It is shared by all Centro instances.
+type CAKeyPair struct {
+	CertificatePEM string    `json:"certificate_pem"`
+	KeyPEM         string    `json:"key_pem"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// NodeCertificate records a client certificate issued to a node, so it can be
+// revoked before it expires
+type NodeCertificate struct {
+	// Serial is the hex encoded serial number of the certificate
+	Serial    string     `json:"serial"`
+	NodeID    string     `json:"node_id"`
+	IssuedAt  time.Time  `json:"issued_at"`
+	NotAfter  time.Time  `json:"not_after"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// GetCA returns the CA, or nil if none was created yet
+func (s *Storage) GetCA(ctx context.Context) (*CAKeyPair, error) {
+	resp, err := s.client.Get(ctx, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get CA: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var ca CAKeyPair
+	if err := json.Unmarshal(resp.Kvs[0].Value, &ca); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal CA: %w", err)
+	}
+
+	return &ca, nil
+}
+
+// CreateCA stores a CA unless another Centro instance created one first, and
+// returns the CA in use
+func (s *Storage) CreateCA(ctx context.Context, ca *CAKeyPair) (*CAKeyPair, error) {
+	data, err := json.Marshal(ca)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CA: %w", err)
+	}
+
+	resp, err := s.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.CreateRevision(caKey), "=", 0),
+	).Then(
+		clientv3.OpPut(caKey, string(data)),
+	).Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA: %w", err)
+	}
+	if !resp.Succeeded {
+		return s.GetCA(ctx)
+	}
+	return ca, nil
+}
+
+// SaveNodeCertificate records an issued certificate until it expires
+func (s *Storage) SaveNodeCertificate(ctx context.Context, cert *NodeCertificate) error {
+	data, err := json.Marshal(cert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal node certificate: %w", err)
+	}
+
+	lease, err := s.client.Grant(ctx, leaseSeconds(cert.NotAfter))
+	if err != nil {
+		return fmt.Errorf("failed to grant node certificate lease: %w", err)
+	}
+	if _, err := s.client.Put(ctx, nodeCertificatesPrefix+cert.Serial, string(data), clientv3.WithLease(lease.ID)); err != nil {
+		return fmt.Errorf("failed to save node certificate: %w", err)
+	}
+	return nil
+}
+
+// GetNodeCertificate returns an issued certificate, or nil if it is unknown
+// or expired
+func (s *Storage) GetNodeCertificate(ctx context.Context, serial string) (*NodeCertificate, error) {
+	resp, err := s.client.Get(ctx, nodeCertificatesPrefix+serial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node certificate: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var cert NodeCertificate
+	if err := json.Unmarshal(resp.Kvs[0].Value, &cert); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal node certificate: %w", err)
+	}
+
+	return &cert, nil
+}
+
+// GetNodeCertificates returns the unexpired certificates issued to a node
+func (s *Storage) GetNodeCertificates(ctx context.Context, nodeID string) ([]*NodeCertificate, error) {
+	resp, err := s.client.Get(ctx, nodeCertificatesPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node certificates: %w", err)
+	}
+
+	certs := make([]*NodeCertificate, 0)
+	for _, kv := range resp.Kvs {
+		var cert NodeCertificate
+		if err := json.Unmarshal(kv.Value, &cert); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal node certificate: %w", err)
+		}
+		if cert.NodeID == nodeID {
+			certs = append(certs, &cert)
+		}
+	}
+	return certs, nil
+}
+
+// RevokeNodeCertificates revokes every unexpired certificate of a node and
+// returns how many were revoked
+func (s *Storage) RevokeNodeCertificates(ctx context.Context, nodeID string) (int, error) {
+	certs, err := s.GetNodeCertificates(ctx, nodeID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var ops []clientv3.Op
+	for _, cert := range certs {
+		if cert.RevokedAt != nil {
+			continue
+		}
+		cert.RevokedAt = &now
+		data, err := json.Marshal(cert)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal node certificate: %w", err)
+		}
+		// The record keeps its lease and disappears when the certificate expires
+		ops = append(ops, clientv3.OpPut(nodeCertificatesPrefix+cert.Serial, string(data), clientv3.WithIgnoreLease()))
+	}
+	if len(ops) == 0 {
+		return 0, nil
+	}
+
+	if _, err := s.client.Txn(ctx).Then(ops...).Commit(); err != nil {
+		return 0, fmt.Errorf("failed to revoke node certificates: %w", err)
+	}
+	return len(ops), nil
+}
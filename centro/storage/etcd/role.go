@@ -0,0 +1,87 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const rolesPrefix = "/centro/roles/"
+
+// Role is a custom role. Users get the permissions of their role, built-in
+// roles are not stored.
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+	// Clusters limits the write permissions of the role to deployments and
+	// nodes of these clusters, empty means every cluster
+	Clusters  []string  `json:"clusters,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *Storage) SaveRole(ctx context.Context, role *Role) error {
+	data, err := json.Marshal(role)
+	if err != nil {
+		return fmt.Errorf("failed to marshal role: %w", err)
+	}
+
+	if _, err := s.client.Put(ctx, rolesPrefix+role.Name, string(data)); err != nil {
+		return fmt.Errorf("failed to save role: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetRole(ctx context.Context, name string) (*Role, error) {
+	resp, err := s.client.Get(ctx, rolesPrefix+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var role Role
+	if err := json.Unmarshal(resp.Kvs[0].Value, &role); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal role: %w", err)
+	}
+
+	return &role, nil
+}
+
+// GetAllRoles returns every custom role sorted by name
+func (s *Storage) GetAllRoles(ctx context.Context) ([]*Role, error) {
+	resp, err := s.client.Get(ctx, rolesPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	roles := make([]*Role, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var role Role
+		if err := json.Unmarshal(kv.Value, &role); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal role: %w", err)
+		}
+		roles = append(roles, &role)
+	}
+
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles, nil
+}
+
+// DeleteRole removes a custom role and reports whether it existed
+func (s *Storage) DeleteRole(ctx context.Context, name string) (bool, error) {
+	resp, err := s.client.Delete(ctx, rolesPrefix+name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete role: %w", err)
+	}
+	return resp.Deleted > 0, nil
+}
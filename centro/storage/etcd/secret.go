@@ -0,0 +1,112 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const secretsPrefix = "/centro/secrets/"
+
+// Secret is a value of the secrets store. Only the sealed value is stored,
+// see the secrets package.
+type Secret struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// KeyID identifies the key encryption key WrappedKey is sealed with
+	KeyID string `json:"key_id"`
+	// WrappedKey is the data key of the secret, sealed with the key encryption key
+	WrappedKey []byte `json:"wrapped_key"`
+	// Ciphertext is the value, sealed with the data key
+	Ciphertext []byte `json:"ciphertext"`
+	// Version counts the updates of the value, starting at 1
+	Version   int64     `json:"version"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *Storage) SaveSecret(ctx context.Context, secret *Secret) error {
+	data, err := json.Marshal(secret)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secret: %w", err)
+	}
+
+	if _, err := s.client.Put(ctx, secretsPrefix+secret.Name, string(data)); err != nil {
+		return fmt.Errorf("failed to save secret: %w", err)
+	}
+	return nil
+}
+
+// CreateSecret saves a new secret and reports false if one with the same name exists
+func (s *Storage) CreateSecret(ctx context.Context, secret *Secret) (bool, error) {
+	data, err := json.Marshal(secret)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal secret: %w", err)
+	}
+
+	key := secretsPrefix + secret.Name
+	resp, err := s.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.CreateRevision(key), "=", 0),
+	).Then(
+		clientv3.OpPut(key, string(data)),
+	).Commit()
+	if err != nil {
+		return false, fmt.Errorf("failed to create secret: %w", err)
+	}
+	return resp.Succeeded, nil
+}
+
+func (s *Storage) GetSecret(ctx context.Context, name string) (*Secret, error) {
+	resp, err := s.client.Get(ctx, secretsPrefix+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var secret Secret
+	if err := json.Unmarshal(resp.Kvs[0].Value, &secret); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal secret: %w", err)
+	}
+
+	return &secret, nil
+}
+
+// GetAllSecrets returns every secret sorted by name
+func (s *Storage) GetAllSecrets(ctx context.Context) ([]*Secret, error) {
+	resp, err := s.client.Get(ctx, secretsPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secrets: %w", err)
+	}
+
+	secrets := make([]*Secret, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var secret Secret
+		if err := json.Unmarshal(kv.Value, &secret); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal secret: %w", err)
+		}
+		secrets = append(secrets, &secret)
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+	return secrets, nil
+}
+
+// DeleteSecret removes a secret and reports whether it existed
+func (s *Storage) DeleteSecret(ctx context.Context, name string) (bool, error) {
+	resp, err := s.client.Delete(ctx, secretsPrefix+name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete secret: %w", err)
+	}
+	return resp.Deleted > 0, nil
+}
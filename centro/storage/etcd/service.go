@@ -0,0 +1,307 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	pb "github.com/open-scheduler/proto"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	serviceStatePrefix   = "/centro/deployments/services/"
+	serviceCommandPrefix = "/centro/deployments/service-commands/"
+)
+
+const (
+	RolloutStatusRunning     = "running"
+	RolloutStatusProgressing = "progressing"
+	RolloutStatusCanary      = "canary"
+	RolloutStatusPaused      = "paused"
+	RolloutStatusReverting   = "reverting"
+)
+
+const (
+	ServiceCommandPromote = "promote"
+	ServiceCommandAbort   = "abort"
+)
+
+// ServiceState tracks how far the replicas of a service deployment have been
+// rolled out to its current spec revision. System deployments use it to
+// number their replica units.
+type ServiceState struct {
+	DeploymentID string `json:"deployment_id"`
+	// StableRevision is the last revision that all replicas ran healthy (0 = none yet)
+	StableRevision int64 `json:"stable_revision"`
+	// TargetRevision is the revision being rolled out
+	TargetRevision int64  `json:"target_revision"`
+	Status         string `json:"status"`
+	Message        string `json:"message,omitempty"`
+	// Batch counts the batches of the current rollout, BatchUnits are the
+	// replicas started by the current batch
+	Batch          int       `json:"batch"`
+	BatchUnits     []string  `json:"batch_units,omitempty"`
+	BatchStartedAt time.Time `json:"batch_started_at,omitempty"`
+	// CanaryHealthyAt is when all canaries of the rollout were first seen healthy
+	CanaryHealthyAt time.Time `json:"canary_healthy_at,omitempty"`
+	// FailedRevisions are the revisions whose rollout failed
+	FailedRevisions []int64 `json:"failed_revisions,omitempty"`
+	// NextUnit numbers replica units so that their IDs are never reused
+	NextUnit  int64     `json:"next_unit"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Abortable reports whether a service has a rollout that can be aborted in
+// favour of its last stable revision
+func (state *ServiceState) Abortable() bool {
+	if state == nil || state.StableRevision == 0 || state.TargetRevision == state.StableRevision {
+		return false
+	}
+	switch state.Status {
+	case RolloutStatusProgressing, RolloutStatusCanary, RolloutStatusPaused:
+		return true
+	}
+	return false
+}
+
+// IsManagedService reports whether a deployment runs as replica units that are
+// created and replaced by the reconciler instead of being queued directly
+func IsManagedService(deployment *pb.Deployment) bool {
+	return deployment != nil && deployment.DeploymentType == "service" && deployment.ParentDeploymentId == ""
+}
+
+// IsManagedSystem reports whether a deployment runs one replica unit on every
+// node that matches its clusters and constraints
+func IsManagedSystem(deployment *pb.Deployment) bool {
+	return deployment != nil && deployment.DeploymentType == "system" && deployment.ParentDeploymentId == ""
+}
+
+// IsManaged reports whether the reconciler creates the replica units of a
+// deployment, or queues it once its dependencies are met, instead of the
+// deployment being queued itself
+func IsManaged(deployment *pb.Deployment) bool {
+	return IsManagedService(deployment) || IsManagedBatch(deployment) || IsManagedSystem(deployment) ||
+		IsManagedPeriodic(deployment) || HasDependencies(deployment)
+}
+
+// IsLongRunningUnit reports whether a deployment is a replica unit of a service
+// or system deployment, which the reconciler replaces instead of retrying
+func IsLongRunningUnit(deployment *pb.Deployment) bool {
+	return deployment != nil && deployment.ParentDeploymentId != "" &&
+		(deployment.DeploymentType == "service" || deployment.DeploymentType == "system")
+}
+
+// ReplicaUnitID returns the deployment ID of the n-th replica unit of a service
+func ReplicaUnitID(deploymentID string, n int64) string {
+	return fmt.Sprintf("%s-%d", deploymentID, n)
+}
+
+func (s *Storage) GetServiceState(ctx context.Context, deploymentID string) (*ServiceState, error) {
+	resp, err := s.client.Get(ctx, serviceStatePrefix+deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service state: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var state ServiceState
+	if err := json.Unmarshal(resp.Kvs[0].Value, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal service state: %w", err)
+	}
+
+	return &state, nil
+}
+
+func (s *Storage) SaveServiceState(ctx context.Context, state *ServiceState) error {
+	state.UpdatedAt = time.Now()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal service state: %w", err)
+	}
+
+	_, err = s.client.Put(ctx, serviceStatePrefix+state.DeploymentID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save service state: %w", err)
+	}
+
+	return nil
+}
+
+// ServiceCommand is a request to promote or abort the rollout of a service.
+// Commands are stored apart from the service state, which only the reconciler writes.
+type ServiceCommand struct {
+	Action      string    `json:"action"`
+	RequestedBy string    `json:"requested_by"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+func (s *Storage) SaveServiceCommand(ctx context.Context, deploymentID string, command *ServiceCommand) error {
+	data, err := json.Marshal(command)
+	if err != nil {
+		return fmt.Errorf("failed to marshal service command: %w", err)
+	}
+
+	_, err = s.client.Put(ctx, serviceCommandPrefix+deploymentID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save service command: %w", err)
+	}
+
+	return nil
+}
+
+// TakeServiceCommand removes and returns the pending command of a service, if any
+func (s *Storage) TakeServiceCommand(ctx context.Context, deploymentID string) (*ServiceCommand, error) {
+	resp, err := s.client.Delete(ctx, serviceCommandPrefix+deploymentID, clientv3.WithPrevKV())
+	if err != nil {
+		return nil, fmt.Errorf("failed to take service command: %w", err)
+	}
+
+	if len(resp.PrevKvs) == 0 {
+		return nil, nil
+	}
+
+	var command ServiceCommand
+	if err := json.Unmarshal(resp.PrevKvs[0].Value, &command); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal service command: %w", err)
+	}
+
+	return &command, nil
+}
+
+// GetAllDeploymentSpecs returns the current spec of every deployment, keyed by deployment ID
+func (s *Storage) GetAllDeploymentSpecs(ctx context.Context) (map[string]*DeploymentSpec, error) {
+	resp, err := s.client.Get(ctx, deploymentSpecPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment specs: %w", err)
+	}
+
+	specs := make(map[string]*DeploymentSpec)
+	for _, kv := range resp.Kvs {
+		var spec DeploymentSpec
+		if err := json.Unmarshal(kv.Value, &spec); err != nil {
+			continue
+		}
+		spec.modRevision = kv.ModRevision
+		specs[strings.TrimPrefix(string(kv.Key), deploymentSpecPrefix)] = &spec
+	}
+
+	return specs, nil
+}
+
+func (s *Storage) DeleteQueueDeployment(ctx context.Context, deploymentID string) error {
+	_, err := s.client.Delete(ctx, deploymentQueuePrefix+deploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete queued deployment: %w", err)
+	}
+
+	return nil
+}
+
+// MarkDeploymentStopping asks the agent running a deployment to stop its
+// instance. The agent is told on its next status update.
+func (s *Storage) MarkDeploymentStopping(ctx context.Context, deploymentID string) error {
+	key := deploymentActivePrefix + deploymentID
+	resp, err := s.client.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to get active deployment: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil
+	}
+
+	var status DeploymentStatus
+	if err := json.Unmarshal(resp.Kvs[0].Value, &status); err != nil {
+		return fmt.Errorf("failed to unmarshal deployment status: %w", err)
+	}
+	if status.DesiredStatus == DesiredStatusStopped {
+		return nil
+	}
+	status.DesiredStatus = DesiredStatusStopped
+
+	data, err := json.Marshal(&status)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deployment status: %w", err)
+	}
+
+	// Only write if no status update came in meanwhile; the next reconcile retries otherwise
+	_, err = s.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision),
+	).Then(
+		clientv3.OpPut(key, string(data)),
+	).Commit()
+	if err != nil {
+		return fmt.Errorf("failed to mark deployment stopping: %w", err)
+	}
+
+	return nil
+}
+
+// ServiceReplica is the current state of a single replica unit of a service
+type ServiceReplica struct {
+	DeploymentID string    `json:"deployment_id"`
+	SpecRevision int64     `json:"spec_revision"`
+	Status       string    `json:"status"`
+	NodeID       string    `json:"node_id,omitempty"`
+	Health       string    `json:"health,omitempty"`
+	Stopping     bool      `json:"stopping,omitempty"`
+	StartedAt    time.Time `json:"started_at,omitempty"`
+}
+
+// GetServiceReplicas returns the replica units of a service that are queued,
+// waiting for a retry or running, ordered by unit ID
+func (s *Storage) GetServiceReplicas(ctx context.Context, deploymentID string) ([]*ServiceReplica, error) {
+	var replicas []*ServiceReplica
+
+	queued, err := s.GetQueueDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range queued {
+		if deployment.ParentDeploymentId == deploymentID {
+			replicas = append(replicas, &ServiceReplica{DeploymentID: deployment.DeploymentId, SpecRevision: deployment.SpecRevision, Status: "queued"})
+		}
+	}
+
+	failed, err := s.GetAllFailedDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range failed {
+		if deployment.ParentDeploymentId == deploymentID {
+			replicas = append(replicas, &ServiceReplica{DeploymentID: deployment.DeploymentId, SpecRevision: deployment.SpecRevision, Status: "retrying"})
+		}
+	}
+
+	active, err := s.GetAllActiveDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for unitID, status := range active {
+		if status.Deployment == nil || status.Deployment.ParentDeploymentId != deploymentID {
+			continue
+		}
+		replica := &ServiceReplica{
+			DeploymentID: unitID,
+			SpecRevision: status.Deployment.SpecRevision,
+			Status:       status.Status,
+			NodeID:       status.NodeID,
+			Stopping:     status.DesiredStatus == DesiredStatusStopped,
+			StartedAt:    status.StartedAt,
+		}
+		if instance, err := s.GetInstanceData(ctx, unitID); err == nil && instance != nil {
+			replica.Health = instance.Health
+		}
+		replicas = append(replicas, replica)
+	}
+
+	sort.Slice(replicas, func(i, j int) bool {
+		return replicas[i].DeploymentID < replicas[j].DeploymentID
+	})
+	return replicas, nil
+}
@@ -0,0 +1,245 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	serviceAccountsPrefix = "/centro/serviceaccounts/"
+	apiTokensPrefix       = "/centro/tokens/"
+)
+
+// ErrServiceAccountExists is returned by CreateServiceAccount when the name is taken
+var ErrServiceAccountExists = errors.New("service account already exists")
+
+// ServiceAccount is an identity for automation. It cannot log in with a
+// password and authenticates with API tokens instead.
+type ServiceAccount struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Role        string    `json:"role"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// APIToken is a long-lived token of a service account. Only the SHA-256 hash
+// of the secret is stored.
+type APIToken struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	ServiceAccount string `json:"service_account"`
+	SecretHash     string `json:"secret_hash"`
+	// Scopes limit the token to these permissions of the role, empty means all of them
+	Scopes     []string   `json:"scopes,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the token is neither revoked nor expired
+func (t *APIToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+func (s *Storage) CreateServiceAccount(ctx context.Context, account *ServiceAccount) error {
+	data, err := json.Marshal(account)
+	if err != nil {
+		return fmt.Errorf("failed to marshal service account: %w", err)
+	}
+
+	key := serviceAccountsPrefix + account.Name
+	resp, err := s.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.CreateRevision(key), "=", 0),
+	).Then(
+		clientv3.OpPut(key, string(data)),
+	).Commit()
+	if err != nil {
+		return fmt.Errorf("failed to create service account: %w", err)
+	}
+	if !resp.Succeeded {
+		return ErrServiceAccountExists
+	}
+	return nil
+}
+
+func (s *Storage) GetServiceAccount(ctx context.Context, name string) (*ServiceAccount, error) {
+	resp, err := s.client.Get(ctx, serviceAccountsPrefix+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service account: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var account ServiceAccount
+	if err := json.Unmarshal(resp.Kvs[0].Value, &account); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal service account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// GetAllServiceAccounts returns every service account sorted by name
+func (s *Storage) GetAllServiceAccounts(ctx context.Context) ([]*ServiceAccount, error) {
+	resp, err := s.client.Get(ctx, serviceAccountsPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service accounts: %w", err)
+	}
+
+	accounts := make([]*ServiceAccount, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var account ServiceAccount
+		if err := json.Unmarshal(kv.Value, &account); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal service account: %w", err)
+		}
+		accounts = append(accounts, &account)
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+	return accounts, nil
+}
+
+// DeleteServiceAccount removes a service account with its tokens and reports
+// whether it existed
+func (s *Storage) DeleteServiceAccount(ctx context.Context, name string) (bool, error) {
+	tokens, err := s.GetAllAPITokens(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	ops := []clientv3.Op{clientv3.OpDelete(serviceAccountsPrefix + name)}
+	for _, token := range tokens {
+		if token.ServiceAccount == name {
+			ops = append(ops, clientv3.OpDelete(apiTokensPrefix+token.ID))
+		}
+	}
+
+	resp, err := s.client.Txn(ctx).Then(ops...).Commit()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete service account: %w", err)
+	}
+	return resp.Responses[0].GetResponseDeleteRange().Deleted > 0, nil
+}
+
+func (s *Storage) SaveAPIToken(ctx context.Context, token *APIToken) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API token: %w", err)
+	}
+
+	if _, err := s.client.Put(ctx, apiTokensPrefix+token.ID, string(data)); err != nil {
+		return fmt.Errorf("failed to save API token: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetAPIToken(ctx context.Context, id string) (*APIToken, error) {
+	resp, err := s.client.Get(ctx, apiTokensPrefix+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var token APIToken
+	if err := json.Unmarshal(resp.Kvs[0].Value, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal API token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// GetAllAPITokens returns every API token, newest first
+func (s *Storage) GetAllAPITokens(ctx context.Context) ([]*APIToken, error) {
+	resp, err := s.client.Get(ctx, apiTokensPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API tokens: %w", err)
+	}
+
+	tokens := make([]*APIToken, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var token APIToken
+		if err := json.Unmarshal(kv.Value, &token); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal API token: %w", err)
+		}
+		tokens = append(tokens, &token)
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+	return tokens, nil
+}
+
+// RevokeAPIToken marks a token as revoked and returns it, or nil if the token
+// does not exist. Revoking a revoked token keeps the first revocation time.
+func (s *Storage) RevokeAPIToken(ctx context.Context, id string) (*APIToken, error) {
+	return s.updateAPIToken(ctx, id, func(token *APIToken) bool {
+		if token.RevokedAt != nil {
+			return false
+		}
+		now := time.Now()
+		token.RevokedAt = &now
+		return true
+	})
+}
+
+// TouchAPIToken records that a token was used
+func (s *Storage) TouchAPIToken(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := s.updateAPIToken(ctx, id, func(token *APIToken) bool {
+		token.LastUsedAt = &usedAt
+		return true
+	})
+	return err
+}
+
+func (s *Storage) updateAPIToken(ctx context.Context, id string, update func(*APIToken) bool) (*APIToken, error) {
+	key := apiTokensPrefix + id
+	for {
+		resp, err := s.client.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get API token: %w", err)
+		}
+		if len(resp.Kvs) == 0 {
+			return nil, nil
+		}
+
+		var token APIToken
+		if err := json.Unmarshal(resp.Kvs[0].Value, &token); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal API token: %w", err)
+		}
+		if !update(&token) {
+			return &token, nil
+		}
+
+		data, err := json.Marshal(&token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal API token: %w", err)
+		}
+		txn, err := s.client.Txn(ctx).If(
+			clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision),
+		).Then(
+			clientv3.OpPut(key, string(data)),
+		).Commit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to save API token: %w", err)
+		}
+		if txn.Succeeded {
+			return &token, nil
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	refreshTokensPrefix = "/centro/refreshtokens/"
+	revokedTokensPrefix = "/centro/revoked/"
+)
+
+// RefreshToken renews the access token of a login. Every refresh replaces it
+// with a new token of the same family, so a token that is used twice was
+// stolen and the family is revoked. Only the SHA-256 hash of the secret is
+// stored.
+type RefreshToken struct {
+	ID         string `json:"id"`
+	SecretHash string `json:"secret_hash"`
+	// Family is shared by all tokens rotated from the same login
+	Family   string `json:"family"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	// SSO is set for logins through the identity provider, whose users are
+	// not stored
+	SSO       bool       `json:"sso,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// leaseSeconds is the TTL of a lease that ends at expiresAt, at least a second
+func leaseSeconds(expiresAt time.Time) int64 {
+	ttl := int64(time.Until(expiresAt).Seconds()) + 1
+	if ttl < 1 {
+		ttl = 1
+	}
+	return ttl
+}
+
+// SaveRefreshToken stores a refresh token that etcd removes when it expires
+func (s *Storage) SaveRefreshToken(ctx context.Context, token *RefreshToken) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal refresh token: %w", err)
+	}
+
+	lease, err := s.client.Grant(ctx, leaseSeconds(token.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("failed to grant refresh token lease: %w", err)
+	}
+	if _, err := s.client.Put(ctx, refreshTokensPrefix+token.ID, string(data), clientv3.WithLease(lease.ID)); err != nil {
+		return fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	return nil
+}
+
+// UseRefreshToken marks a refresh token as used and returns it, or nil if
+// there is none with the ID. reused is true if it had been used before.
+func (s *Storage) UseRefreshToken(ctx context.Context, id string, usedAt time.Time) (token *RefreshToken, reused bool, err error) {
+	key := refreshTokensPrefix + id
+	for {
+		resp, err := s.client.Get(ctx, key)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get refresh token: %w", err)
+		}
+		if len(resp.Kvs) == 0 {
+			return nil, false, nil
+		}
+
+		var token RefreshToken
+		if err := json.Unmarshal(resp.Kvs[0].Value, &token); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal refresh token: %w", err)
+		}
+		if token.UsedAt != nil {
+			return &token, true, nil
+		}
+
+		token.UsedAt = &usedAt
+		data, err := json.Marshal(&token)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to marshal refresh token: %w", err)
+		}
+		txn, err := s.client.Txn(ctx).If(
+			clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision),
+		).Then(
+			clientv3.OpPut(key, string(data), clientv3.WithIgnoreLease()),
+		).Commit()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to save refresh token: %w", err)
+		}
+		if txn.Succeeded {
+			return &token, false, nil
+		}
+	}
+}
+
+// GetRefreshToken returns a refresh token, or nil if there is none with the ID
+func (s *Storage) GetRefreshToken(ctx context.Context, id string) (*RefreshToken, error) {
+	resp, err := s.client.Get(ctx, refreshTokensPrefix+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var token RefreshToken
+	if err := json.Unmarshal(resp.Kvs[0].Value, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// RevokeRefreshTokenFamily deletes all refresh tokens rotated from one login
+func (s *Storage) RevokeRefreshTokenFamily(ctx context.Context, family string) (int, error) {
+	return s.deleteRefreshTokens(ctx, func(token *RefreshToken) bool {
+		return token.Family == family
+	})
+}
+
+// RevokeUserRefreshTokens deletes the refresh tokens of all logins of a user
+func (s *Storage) RevokeUserRefreshTokens(ctx context.Context, username string) (int, error) {
+	return s.deleteRefreshTokens(ctx, func(token *RefreshToken) bool {
+		return token.Username == username
+	})
+}
+
+func (s *Storage) deleteRefreshTokens(ctx context.Context, match func(*RefreshToken) bool) (int, error) {
+	resp, err := s.client.Get(ctx, refreshTokensPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get refresh tokens: %w", err)
+	}
+
+	var ops []clientv3.Op
+	for _, kv := range resp.Kvs {
+		var token RefreshToken
+		if err := json.Unmarshal(kv.Value, &token); err != nil {
+			continue
+		}
+		if match(&token) {
+			ops = append(ops, clientv3.OpDelete(string(kv.Key)))
+		}
+	}
+	if len(ops) == 0 {
+		return 0, nil
+	}
+
+	if _, err := s.client.Txn(ctx).Then(ops...).Commit(); err != nil {
+		return 0, fmt.Errorf("failed to delete refresh tokens: %w", err)
+	}
+	return len(ops), nil
+}
+
+// RevokeToken puts the ID (jti) of an access token on the revocation list
+// until the token expires anyway
+func (s *Storage) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	lease, err := s.client.Grant(ctx, leaseSeconds(expiresAt))
+	if err != nil {
+		return fmt.Errorf("failed to grant revocation lease: %w", err)
+	}
+	if _, err := s.client.Put(ctx, revokedTokensPrefix+jti, expiresAt.Format(time.RFC3339), clientv3.WithLease(lease.ID)); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether an access token ID is on the revocation list
+func (s *Storage) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	resp, err := s.client.Get(ctx, revokedTokensPrefix+jti, clientv3.WithCountOnly())
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return resp.Count > 0, nil
+}
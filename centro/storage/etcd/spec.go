@@ -0,0 +1,434 @@
+package etcd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	pb "github.com/open-scheduler/proto"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	deploymentSpecPrefix     = "/centro/deployments/specs/"
+	deploymentRevisionPrefix = "/centro/deployments/revisions/"
+)
+
+// ErrDeploymentConflict is returned when a deployment ID is submitted again with a different spec
+var ErrDeploymentConflict = errors.New("deployment already exists with a different spec")
+
+// ErrSpecModified is returned when a deployment spec was changed by someone
+// else between reading and updating it
+var ErrSpecModified = errors.New("deployment spec was modified concurrently")
+
+// DeploymentSpec is the current spec of a deployment. It outlives the queue
+// entry so that resubmissions and updates under the same ID can be recognized.
+type DeploymentSpec struct {
+	DeploymentID   string `json:"deployment_id"`
+	Revision       int64  `json:"revision"`
+	SpecHash       string `json:"spec_hash"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// Request is the REST request the spec was built from, used to apply patches
+	Request    json.RawMessage `json:"request,omitempty"`
+	Deployment *pb.Deployment  `json:"deployment"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	// CreatedBy submitted the deployment, UpdatedBy made the latest revision
+	CreatedBy string `json:"created_by,omitempty"`
+	UpdatedBy string `json:"updated_by,omitempty"`
+
+	modRevision int64
+}
+
+// FieldChange is a single changed field between two revisions, using REST request field names
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+}
+
+// DeploymentRevision is a numbered version of a deployment spec
+type DeploymentRevision struct {
+	DeploymentID string          `json:"deployment_id"`
+	Revision     int64           `json:"revision"`
+	SpecHash     string          `json:"spec_hash"`
+	Request      json.RawMessage `json:"request,omitempty"`
+	Deployment   *pb.Deployment  `json:"deployment"`
+	Changes      []FieldChange   `json:"changes,omitempty"`
+	Author       string          `json:"author,omitempty"`
+	// RollbackOf is the revision whose spec was restored by a rollback
+	RollbackOf int64     `json:"rollback_of,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RevisionChange describes why a new revision is stored
+type RevisionChange struct {
+	Changes    []FieldChange
+	Author     string
+	RollbackOf int64
+}
+
+// DeploymentSpecHash returns a hash of everything the user specified for a
+// deployment. The ID and the retry bookkeeping of the scheduler are left out.
+func DeploymentSpecHash(deployment *pb.Deployment) (string, error) {
+	spec := proto.Clone(deployment).(*pb.Deployment)
+	spec.DeploymentId = ""
+	spec.RetryCount = 0
+	spec.LastRetryTime = 0
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal deployment spec: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func deploymentRevisionKey(deploymentID string, revision int64) string {
+	// Zero padded so that revisions sort numerically
+	return fmt.Sprintf("%s%s/%010d", deploymentRevisionPrefix, deploymentID, revision)
+}
+
+// SubmitDeployment stores spec as revision 1 of a new deployment and enqueues
+// it in one transaction; services are left to the reconciler instead. If the
+// deployment ID is already taken nothing is written: the stored spec is
+// returned with created=false when it matches, otherwise ErrDeploymentConflict
+// is returned.
+func (s *Storage) SubmitDeployment(ctx context.Context, spec *DeploymentSpec, author string) (*DeploymentSpec, bool, error) {
+	deployment := spec.Deployment
+	deploymentID := deployment.DeploymentId
+	hash, err := DeploymentSpecHash(deployment)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	spec.DeploymentID = deploymentID
+	spec.Revision = 1
+	spec.SpecHash = hash
+	spec.CreatedAt = now
+	spec.UpdatedAt = now
+	spec.CreatedBy = author
+	spec.UpdatedBy = author
+
+	specData, err := json.Marshal(spec)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal deployment spec: %w", err)
+	}
+	revisionData, err := json.Marshal(&DeploymentRevision{
+		DeploymentID: deploymentID,
+		Revision:     spec.Revision,
+		SpecHash:     hash,
+		Request:      spec.Request,
+		Deployment:   deployment,
+		Author:       author,
+		CreatedAt:    now,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal deployment revision: %w", err)
+	}
+	deploymentData, err := json.Marshal(deployment)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal deployment: %w", err)
+	}
+
+	specKey := deploymentSpecPrefix + deploymentID
+	ops := []clientv3.Op{
+		clientv3.OpPut(specKey, string(specData)),
+		clientv3.OpPut(deploymentRevisionKey(deploymentID, spec.Revision), string(revisionData)),
+	}
+	// Services, system, periodic and multi-replica batch deployments are not
+	// queued themselves, the reconciler creates their replicas. Deployments
+	// with dependencies are queued by the reconciler once they are met.
+	if !IsManaged(deployment) {
+		ops = append(ops, clientv3.OpPut(deploymentQueuePrefix+deploymentID, string(deploymentData)))
+	}
+
+	// Deployments submitted before specs were stored only exist under one of these keys
+	resp, err := s.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.CreateRevision(specKey), "=", 0),
+		clientv3.Compare(clientv3.CreateRevision(deploymentQueuePrefix+deploymentID), "=", 0),
+		clientv3.Compare(clientv3.CreateRevision(failDeploymentQueuePrefix+deploymentID), "=", 0),
+		clientv3.Compare(clientv3.CreateRevision(deploymentActivePrefix+deploymentID), "=", 0),
+		clientv3.Compare(clientv3.CreateRevision(deploymentHistoryPrefix+deploymentID), "=", 0),
+	).Then(ops...).Else(
+		clientv3.OpGet(specKey),
+	).Commit()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to submit deployment: %w", err)
+	}
+	if resp.Succeeded {
+		return spec, true, nil
+	}
+
+	kvs := resp.Responses[0].GetResponseRange().Kvs
+	if len(kvs) == 0 {
+		return nil, false, ErrDeploymentConflict
+	}
+	var existing DeploymentSpec
+	if err := json.Unmarshal(kvs[0].Value, &existing); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal deployment spec: %w", err)
+	}
+	if existing.SpecHash != hash {
+		return &existing, false, ErrDeploymentConflict
+	}
+	return &existing, false, nil
+}
+
+func (s *Storage) GetDeploymentSpec(ctx context.Context, deploymentID string) (*DeploymentSpec, error) {
+	resp, err := s.client.Get(ctx, deploymentSpecPrefix+deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment spec: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var spec DeploymentSpec
+	if err := json.Unmarshal(resp.Kvs[0].Value, &spec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal deployment spec: %w", err)
+	}
+	spec.modRevision = resp.Kvs[0].ModRevision
+
+	return &spec, nil
+}
+
+// UpdateDeploymentSpec stores a new revision of a deployment read with
+// GetDeploymentSpec. A queued or retrying deployment is switched to the new
+// spec right away; running instances keep the spec they were started with.
+// ErrSpecModified is returned if the spec changed since current was read.
+func (s *Storage) UpdateDeploymentSpec(ctx context.Context, current *DeploymentSpec, deployment *pb.Deployment,
+	request json.RawMessage, change RevisionChange) (*DeploymentSpec, error) {
+	deploymentID := current.DeploymentID
+	hash, err := DeploymentSpecHash(deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updated := *current
+	updated.Revision = current.Revision + 1
+	updated.SpecHash = hash
+	updated.Request = request
+	updated.Deployment = deployment
+	updated.UpdatedAt = now
+	updated.UpdatedBy = change.Author
+
+	specData, err := json.Marshal(&updated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal deployment spec: %w", err)
+	}
+	revisionData, err := json.Marshal(&DeploymentRevision{
+		DeploymentID: deploymentID,
+		Revision:     updated.Revision,
+		SpecHash:     hash,
+		Request:      request,
+		Deployment:   deployment,
+		Changes:      change.Changes,
+		Author:       change.Author,
+		RollbackOf:   change.RollbackOf,
+		CreatedAt:    now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal deployment revision: %w", err)
+	}
+
+	ops := []clientv3.Op{
+		clientv3.OpPut(deploymentSpecPrefix+deploymentID, string(specData)),
+		clientv3.OpPut(deploymentRevisionKey(deploymentID, updated.Revision), string(revisionData)),
+	}
+	for _, prefix := range []string{deploymentQueuePrefix, failDeploymentQueuePrefix} {
+		op, err := s.replacePendingDeployment(ctx, prefix+deploymentID, deployment)
+		if err != nil {
+			return nil, err
+		}
+		if op != nil {
+			ops = append(ops, *op)
+		}
+	}
+
+	resp, err := s.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.ModRevision(deploymentSpecPrefix+deploymentID), "=", current.modRevision),
+	).Then(ops...).Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update deployment spec: %w", err)
+	}
+	if !resp.Succeeded {
+		return nil, ErrSpecModified
+	}
+
+	updated.modRevision = resp.Header.Revision
+	return &updated, nil
+}
+
+// replacePendingDeployment returns an operation that replaces the deployment
+// stored under key with the new spec, keeping its retry bookkeeping. The
+// replacement only happens if the entry is still unchanged when the operation runs.
+func (s *Storage) replacePendingDeployment(ctx context.Context, key string, deployment *pb.Deployment) (*clientv3.Op, error) {
+	resp, err := s.client.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending deployment: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var pending pb.Deployment
+	if err := json.Unmarshal(resp.Kvs[0].Value, &pending); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pending deployment: %w", err)
+	}
+
+	replacement := proto.Clone(deployment).(*pb.Deployment)
+	replacement.RetryCount = pending.RetryCount
+	replacement.LastRetryTime = pending.LastRetryTime
+
+	data, err := json.Marshal(replacement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal deployment: %w", err)
+	}
+
+	op := clientv3.OpTxn(
+		[]clientv3.Cmp{clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision)},
+		[]clientv3.Op{clientv3.OpPut(key, string(data))},
+		nil,
+	)
+	return &op, nil
+}
+
+// RollbackSpec returns the spec and request of an earlier revision to be
+// stored as the next revision. The update strategy of the current spec is
+// kept, so a rollback is rolled out the same way as any other change.
+func RollbackSpec(current *DeploymentSpec, revision *DeploymentRevision) (*pb.Deployment, json.RawMessage, error) {
+	deployment := proto.Clone(revision.Deployment).(*pb.Deployment)
+	deployment.DeploymentId = current.DeploymentID
+	deployment.Update = nil
+	if current.Deployment.Update != nil {
+		deployment.Update = proto.Clone(current.Deployment.Update).(*pb.UpdateStrategy)
+	}
+
+	if len(revision.Request) == 0 {
+		return deployment, revision.Request, nil
+	}
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(revision.Request, &request); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal revision request: %w", err)
+	}
+	var currentRequest map[string]json.RawMessage
+	if len(current.Request) > 0 {
+		if err := json.Unmarshal(current.Request, &currentRequest); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal current request: %w", err)
+		}
+	}
+	if update, ok := currentRequest["update"]; ok {
+		request["update"] = update
+	} else {
+		delete(request, "update")
+	}
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal revision request: %w", err)
+	}
+	return deployment, data, nil
+}
+
+// GetDeploymentRevisions returns every stored revision of a deployment, oldest first
+func (s *Storage) GetDeploymentRevisions(ctx context.Context, deploymentID string) ([]*DeploymentRevision, error) {
+	prefix := deploymentRevisionPrefix + deploymentID + "/"
+	resp, err := s.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment revisions: %w", err)
+	}
+
+	revisions := make([]*DeploymentRevision, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var revision DeploymentRevision
+		if err := json.Unmarshal(kv.Value, &revision); err != nil {
+			continue
+		}
+		revisions = append(revisions, &revision)
+	}
+
+	return revisions, nil
+}
+
+func (s *Storage) GetDeploymentRevision(ctx context.Context, deploymentID string, revision int64) (*DeploymentRevision, error) {
+	resp, err := s.client.Get(ctx, deploymentRevisionKey(deploymentID, revision))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment revision: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var deploymentRevision DeploymentRevision
+	if err := json.Unmarshal(resp.Kvs[0].Value, &deploymentRevision); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal deployment revision: %w", err)
+	}
+
+	return &deploymentRevision, nil
+}
+
+// DiffRequests lists the fields that differ between two submit requests.
+// Nested objects are compared field by field, lists as a whole.
+func DiffRequests(oldRequest, newRequest json.RawMessage) []FieldChange {
+	oldFields := make(map[string]interface{})
+	newFields := make(map[string]interface{})
+
+	var oldDocument, newDocument interface{}
+	if len(oldRequest) > 0 {
+		json.Unmarshal(oldRequest, &oldDocument)
+	}
+	json.Unmarshal(newRequest, &newDocument)
+	flattenJSON("", oldDocument, oldFields)
+	flattenJSON("", newDocument, newFields)
+
+	var changes []FieldChange
+	for field, oldValue := range oldFields {
+		if newValue, ok := newFields[field]; !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newFields[field]})
+		}
+	}
+	for field, newValue := range newFields {
+		if _, ok := oldFields[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, New: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func flattenJSON(prefix string, value interface{}, fields map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			field := key
+			if prefix != "" {
+				field = prefix + "." + key
+			}
+			flattenJSON(field, child, fields)
+		}
+	case nil:
+		// Absent and null fields are the same thing in a request
+	case string:
+		if v != "" {
+			fields[prefix] = v
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			fields[prefix] = v
+		}
+	default:
+		fields[prefix] = v
+	}
+}
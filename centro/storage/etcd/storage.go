@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -34,12 +35,50 @@ type NodeInfo struct {
 	CPUCores      float32           `json:"cpu_cores"`
 	DiskMB        float32           `json:"disk_mb"`
 	Metadata      map[string]string `json:"metadata"`
+	// A decommissioned node gets no new deployments and its system deployment replicas are stopped
+	Decommissioned   bool      `json:"decommissioned,omitempty"`
+	DecommissionedAt time.Time `json:"decommissioned_at,omitempty"`
+	// Approval is pending for nodes that registered without a join token until
+	// an admin approves or rejects them. Nodes registered before approvals
+	// existed have none and count as approved.
+	Approval   string    `json:"approval,omitempty"`
+	ApprovedBy string    `json:"approved_by,omitempty"`
+	ApprovedAt time.Time `json:"approved_at,omitempty"`
+	// JoinTokenID is the join token the node registered with
+	JoinTokenID string `json:"join_token_id,omitempty"`
+	// AssignedCluster and Labels come from the join token or the approval.
+	// AssignedCluster overrides the cluster the agent reports.
+	AssignedCluster string            `json:"assigned_cluster,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	// IdentityRevokedAt is when decommissioning revoked the certificates and
+	// credentials of the node. Only then may a join token take over its ID.
+	IdentityRevokedAt time.Time `json:"identity_revoked_at,omitempty"`
 }
 
+const (
+	NodeApprovalPending  = "pending"
+	NodeApprovalApproved = "approved"
+	NodeApprovalRejected = "rejected"
+)
+
 func (n *NodeInfo) IsHealthy() bool {
 	return time.Since(n.LastHeartbeat) < 60*time.Second
 }
 
+// IsApproved reports whether the node may get deployments
+func (n *NodeInfo) IsApproved() bool {
+	return n.Approval == "" || n.Approval == NodeApprovalApproved
+}
+
+// ApprovalStatus returns the approval of the node, approved for nodes that
+// registered before approvals existed
+func (n *NodeInfo) ApprovalStatus() string {
+	if n.Approval == "" {
+		return NodeApprovalApproved
+	}
+	return n.Approval
+}
+
 type DeploymentStatus struct {
 	Deployment *pb.Deployment `json:"deployment"`
 	NodeID     string         `json:"node_id"`
@@ -47,8 +86,14 @@ type DeploymentStatus struct {
 	Detail     string         `json:"detail"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	ClaimedAt  time.Time      `json:"claimed_at"`
+	// StartedAt is when the agent first reported the deployment as running
+	StartedAt time.Time `json:"started_at,omitempty"`
+	// DesiredStatus is set when centro wants the instance stopped
+	DesiredStatus string `json:"desired_status,omitempty"`
 }
 
+const DesiredStatusStopped = "stopped"
+
 func NewStorage(endpoints []string) (*Storage, error) {
 	cli, err := clientv3.New(clientv3.Config{
 		Endpoints:   endpoints,
@@ -61,6 +106,11 @@ func NewStorage(endpoints []string) (*Storage, error) {
 	return &Storage{client: cli}, nil
 }
 
+// NewStorageFromClient returns a storage using an existing etcd client
+func NewStorageFromClient(client *clientv3.Client) *Storage {
+	return &Storage{client: client}
+}
+
 func (s *Storage) Close() error {
 	return s.client.Close()
 }
@@ -118,6 +168,67 @@ func (s *Storage) GetAllNodes(ctx context.Context) (map[string]*NodeInfo, error)
 	return nodes, nil
 }
 
+// SetNodeDecommissioned marks a node as decommissioned or puts it back into
+// service. The update is retried if the node is saved concurrently.
+func (s *Storage) SetNodeDecommissioned(ctx context.Context, nodeID string, decommissioned bool) (*NodeInfo, error) {
+	return s.UpdateNode(ctx, nodeID, func(node *NodeInfo) (*NodeInfo, bool) {
+		if node == nil || node.Decommissioned == decommissioned {
+			return node, false
+		}
+		node.Decommissioned = decommissioned
+		node.DecommissionedAt = time.Time{}
+		if decommissioned {
+			node.DecommissionedAt = time.Now()
+		}
+		return node, true
+	})
+}
+
+// UpdateNode saves the node update returns for the stored node, which is nil
+// if the node is not registered. Nothing is saved if update returns false.
+// update is called again if the node is saved concurrently, and the node it
+// returned last is returned.
+func (s *Storage) UpdateNode(ctx context.Context, nodeID string, update func(node *NodeInfo) (*NodeInfo, bool)) (*NodeInfo, error) {
+	key := nodesPrefix + nodeID
+	for {
+		resp, err := s.client.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node from etcd: %w", err)
+		}
+
+		var current *NodeInfo
+		var modRevision int64
+		if len(resp.Kvs) > 0 {
+			current = &NodeInfo{}
+			if err := json.Unmarshal(resp.Kvs[0].Value, current); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal node: %w", err)
+			}
+			modRevision = resp.Kvs[0].ModRevision
+		}
+
+		node, save := update(current)
+		if !save || node == nil {
+			return node, nil
+		}
+
+		data, err := json.Marshal(node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal node: %w", err)
+		}
+		txn, err := s.client.Txn(ctx).If(
+			clientv3.Compare(clientv3.ModRevision(key), "=", modRevision),
+		).Then(
+			clientv3.OpPut(key, string(data)),
+		).Commit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to save node to etcd: %w", err)
+		}
+		if txn.Succeeded {
+			return node, nil
+		}
+	}
+}
+
 func (s *Storage) EnqueueFailedDeployment(ctx context.Context, deployment *pb.Deployment) error {
 	data, err := json.Marshal(deployment)
 	if err != nil {
@@ -200,6 +311,50 @@ func (s *Storage) DequeueDeployment(ctx context.Context) (*pb.Deployment, error)
 	return &deployment, nil
 }
 
+// DequeueDeploymentForNode takes the next queued deployment a node may run.
+// Replica units pinned to the node come first, units pinned to other nodes are
+// skipped. nil is returned if there is none, or another node took it first.
+func (s *Storage) DequeueDeploymentForNode(ctx context.Context, nodeID string) (*pb.Deployment, error) {
+	resp, err := s.client.Get(ctx, deploymentQueuePrefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment from queue: %w", err)
+	}
+
+	var next *pb.Deployment
+	var nextKey string
+	var nextRevision int64
+	for _, kv := range resp.Kvs {
+		var deployment pb.Deployment
+		if err := json.Unmarshal(kv.Value, &deployment); err != nil {
+			continue
+		}
+		pinned := deployment.TargetNodeId == nodeID
+		if pinned || (deployment.TargetNodeId == "" && next == nil) {
+			next, nextKey, nextRevision = &deployment, string(kv.Key), kv.ModRevision
+		}
+		if pinned {
+			break
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	txn, err := s.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.ModRevision(nextKey), "=", nextRevision),
+	).Then(
+		clientv3.OpDelete(nextKey),
+	).Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete deployment from queue: %w", err)
+	}
+	if !txn.Succeeded {
+		return nil, nil
+	}
+
+	return next, nil
+}
+
 func (s *Storage) GetQueueLength(ctx context.Context) (int, error) {
 	resp, err := s.client.Get(ctx, deploymentQueuePrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
 	if err != nil {
@@ -370,50 +525,98 @@ func (s *Storage) GetDeploymentHistory(ctx context.Context, deploymentID string)
 	return &status, nil
 }
 
-func (s *Storage) SaveDeploymentEvent(ctx context.Context, deploymentID string, event string) error {
-	// Remove timestamp (everything up to and including "] "), use the rest as the event message
-	getMessage := func(ev string) string {
-		if idx := strings.Index(ev, "] "); idx != -1 {
-			return ev[idx+2:]
-		}
-		return ev
+func (s *Storage) SaveDeploymentEvent(ctx context.Context, deploymentID string, event *DeploymentEvent) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
 	}
-	newMsg := getMessage(event)
+	event.DeploymentID = deploymentID
 
 	// Get the previous (most recent) event for this deployment, if any
 	prefix := deploymentEventsPrefix + deploymentID + "/"
 	resp, err := s.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend), clientv3.WithLimit(1))
 	if err == nil && len(resp.Kvs) > 0 {
-		prevMsg := getMessage(string(resp.Kvs[0].Value))
-		if prevMsg == newMsg {
+		if prev := decodeDeploymentEvent(deploymentID, resp.Kvs[0].Value); prev.sameAs(event) {
 			return nil // skip duplicate event regardless of timestamp
 		}
 	}
 	// if error above, fail open: allow event
 
-	key := fmt.Sprintf("%s%s/%d", deploymentEventsPrefix, deploymentID, time.Now().UnixNano())
-	_, err = s.client.Put(ctx, key, event)
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deployment event: %w", err)
+	}
+
+	key := deploymentEventsPrefix + deploymentID + "/" + eventKeyTime(event.Time)
+	_, err = s.client.Put(ctx, key, string(data))
 	if err != nil {
 		return fmt.Errorf("failed to save deployment event: %w", err)
 	}
 	return nil
 }
 
-func (s *Storage) GetDeploymentEvents(ctx context.Context, deploymentID string) ([]string, error) {
+func (s *Storage) GetDeploymentEvents(ctx context.Context, deploymentID string, filter EventFilter) ([]*DeploymentEvent, error) {
 	prefix := deploymentEventsPrefix + deploymentID + "/"
-	resp, err := s.client.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+
+	// Event keys end with the event time in unix nanoseconds, so the time
+	// range can be applied to the key range instead of scanning everything.
+	startKey := prefix
+	if !filter.Since.IsZero() {
+		startKey = prefix + eventKeyTime(filter.Since)
+	}
+	endKey := clientv3.GetPrefixRangeEnd(prefix)
+	if !filter.Until.IsZero() {
+		endKey = prefix + eventKeyTime(filter.Until.Add(time.Nanosecond))
+	}
+
+	resp, err := s.client.Get(ctx, startKey, clientv3.WithRange(endKey), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get deployment events: %w", err)
 	}
 
-	events := make([]string, 0, len(resp.Kvs))
+	events := make([]*DeploymentEvent, 0, len(resp.Kvs))
 	for _, kv := range resp.Kvs {
-		events = append(events, string(kv.Value))
+		event := decodeDeploymentEvent(deploymentID, kv.Value)
+		if filter.Matches(event) {
+			events = append(events, event)
+		}
 	}
 
 	return events, nil
 }
 
+// GetAllEvents returns the events of all deployments matching the filter, oldest
+// first, together with the etcd revision they were read at so that callers can
+// continue with a watch without missing or repeating events.
+func (s *Storage) GetAllEvents(ctx context.Context, filter EventFilter) ([]*DeploymentEvent, int64, error) {
+	prefix := deploymentEventsPrefix
+	if filter.DeploymentID != "" {
+		prefix = deploymentEventsPrefix + filter.DeploymentID + "/"
+	}
+	resp, err := s.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	events := make([]*DeploymentEvent, 0)
+	for _, kv := range resp.Kvs {
+		rest := strings.TrimPrefix(string(kv.Key), deploymentEventsPrefix)
+		idx := strings.LastIndex(rest, "/")
+		if idx <= 0 {
+			continue
+		}
+		event := decodeDeploymentEvent(rest[:idx], kv.Value)
+		if filter.Matches(event) {
+			events = append(events, event)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	return events, resp.Header.Revision, nil
+}
+
 func (s *Storage) GetAllFailedDeployments(ctx context.Context) (map[string]*pb.Deployment, error) {
 	resp, err := s.client.Get(ctx, failDeploymentQueuePrefix, clientv3.WithPrefix())
 	if err != nil {
@@ -503,4 +706,123 @@ func (s *Storage) GetFailedDeployment(ctx context.Context, deploymentID string)
 	}
 
 	return &deployment, nil
-}
\ No newline at end of file
+}
+
+// GetDeploymentIDsWithEvents returns the IDs of all deployments that have at least one stored event
+func (s *Storage) GetDeploymentIDsWithEvents(ctx context.Context) ([]string, error) {
+	resp, err := s.client.Get(ctx, deploymentEventsPrefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment event keys: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	deploymentIDs := make([]string, 0)
+	for _, kv := range resp.Kvs {
+		rest := strings.TrimPrefix(string(kv.Key), deploymentEventsPrefix)
+		idx := strings.LastIndex(rest, "/")
+		if idx <= 0 {
+			continue
+		}
+		deploymentID := rest[:idx]
+		if !seen[deploymentID] {
+			seen[deploymentID] = true
+			deploymentIDs = append(deploymentIDs, deploymentID)
+		}
+	}
+
+	return deploymentIDs, nil
+}
+
+// GetAllInstanceData returns the stored instance data of every deployment, keyed by deployment ID
+func (s *Storage) GetAllInstanceData(ctx context.Context) (map[string]*pb.InstanceData, error) {
+	resp, err := s.client.Get(ctx, instanceDataPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance data: %w", err)
+	}
+
+	instances := make(map[string]*pb.InstanceData)
+	for _, kv := range resp.Kvs {
+		var instanceData pb.InstanceData
+		if err := json.Unmarshal(kv.Value, &instanceData); err != nil {
+			log.Printf("Failed to unmarshal instance data: %v", err)
+			continue
+		}
+		deploymentID := strings.TrimPrefix(string(kv.Key), instanceDataPrefix)
+		instances[deploymentID] = &instanceData
+	}
+
+	return instances, nil
+}
+
+// DeleteDeploymentEventsBefore removes all events of a deployment recorded before the given time.
+// Events are matched on their decoded time, not their key: events stored before
+// events were structured keep their time in the value with second precision,
+// behind a key in nanoseconds, so they can be older than before while their key
+// is not.
+func (s *Storage) DeleteDeploymentEventsBefore(ctx context.Context, deploymentID string, before time.Time) (int64, error) {
+	prefix := deploymentEventsPrefix + deploymentID + "/"
+	endKey := prefix + eventKeyTime(before)
+
+	// The time of an event is never later than its key, so everything below
+	// endKey goes; the rest has to be decoded.
+	resp, err := s.client.Get(ctx, endKey, clientv3.WithRange(clientv3.GetPrefixRangeEnd(prefix)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get deployment events: %w", err)
+	}
+
+	ops := []clientv3.Op{clientv3.OpDelete(prefix, clientv3.WithRange(endKey))}
+	for _, kv := range resp.Kvs {
+		if decodeDeploymentEvent(deploymentID, kv.Value).Time.Before(before) {
+			ops = append(ops, clientv3.OpDelete(string(kv.Key)))
+		}
+	}
+
+	var deleted int64
+	for len(ops) > 0 {
+		// etcd limits the number of operations in a transaction to 128 by default
+		batch := ops
+		if len(batch) > 128 {
+			batch = batch[:128]
+		}
+		ops = ops[len(batch):]
+
+		txnResp, err := s.client.Txn(ctx).Then(batch...).Commit()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete deployment events: %w", err)
+		}
+		for _, r := range txnResp.Responses {
+			deleted += r.GetResponseDeleteRange().Deleted
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteInstanceData removes the stored instance data of a deployment
+func (s *Storage) DeleteInstanceData(ctx context.Context, deploymentID string) error {
+	_, err := s.client.Delete(ctx, instanceDataPrefix+deploymentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete instance data: %w", err)
+	}
+	return nil
+}
+
+// DeleteDeploymentRecords removes the history entry of a finished deployment together
+// with its events and instance data in a single transaction
+func (s *Storage) DeleteDeploymentRecords(ctx context.Context, deploymentID string) error {
+	_, err := s.client.Txn(ctx).Then(
+		clientv3.OpDelete(deploymentHistoryPrefix+deploymentID),
+		clientv3.OpDelete(deploymentEventsPrefix+deploymentID+"/", clientv3.WithPrefix()),
+		clientv3.OpDelete(instanceDataPrefix+deploymentID),
+		clientv3.OpDelete(deploymentSpecPrefix+deploymentID),
+		clientv3.OpDelete(deploymentRevisionPrefix+deploymentID+"/", clientv3.WithPrefix()),
+		clientv3.OpDelete(serviceStatePrefix+deploymentID),
+		clientv3.OpDelete(serviceCommandPrefix+deploymentID),
+		clientv3.OpDelete(batchStatePrefix+deploymentID),
+		clientv3.OpDelete(periodicStatePrefix+deploymentID),
+		clientv3.OpDelete(dependencyStatePrefix+deploymentID),
+	).Commit()
+	if err != nil {
+		return fmt.Errorf("failed to delete deployment records: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const usersPrefix = "/centro/users/"
+
+// ErrUserExists is returned by CreateUser when the username is taken
+var ErrUserExists = errors.New("user already exists")
+
+// User is an account that can log in to the REST API. Only the hash of the
+// password is stored.
+type User struct {
+	Username          string    `json:"username"`
+	PasswordHash      string    `json:"password_hash"`
+	Role              string    `json:"role"`
+	CreatedAt         time.Time `json:"created_at"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+func (s *Storage) CreateUser(ctx context.Context, user *User) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user: %w", err)
+	}
+
+	key := usersPrefix + user.Username
+	resp, err := s.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.CreateRevision(key), "=", 0),
+	).Then(
+		clientv3.OpPut(key, string(data)),
+	).Commit()
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	if !resp.Succeeded {
+		return ErrUserExists
+	}
+	return nil
+}
+
+func (s *Storage) GetUser(ctx context.Context, username string) (*User, error) {
+	resp, err := s.client.Get(ctx, usersPrefix+username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	var user User
+	if err := json.Unmarshal(resp.Kvs[0].Value, &user); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetAllUsers returns every user sorted by username
+func (s *Storage) GetAllUsers(ctx context.Context) ([]*User, error) {
+	resp, err := s.client.Get(ctx, usersPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	users := make([]*User, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		var user User
+		if err := json.Unmarshal(kv.Value, &user); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal user: %w", err)
+		}
+		users = append(users, &user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+	return users, nil
+}
+
+// SetUserPassword replaces the password hash of a user and returns the
+// updated user, or nil if the user does not exist
+func (s *Storage) SetUserPassword(ctx context.Context, username, passwordHash string) (*User, error) {
+	return s.updateUser(ctx, username, func(user *User) {
+		user.PasswordHash = passwordHash
+		user.PasswordChangedAt = time.Now()
+	})
+}
+
+// SetUserRole changes the role of a user and returns the updated user, or nil
+// if the user does not exist
+func (s *Storage) SetUserRole(ctx context.Context, username, role string) (*User, error) {
+	return s.updateUser(ctx, username, func(user *User) {
+		user.Role = role
+	})
+}
+
+func (s *Storage) updateUser(ctx context.Context, username string, update func(*User)) (*User, error) {
+	key := usersPrefix + username
+	for {
+		resp, err := s.client.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if len(resp.Kvs) == 0 {
+			return nil, nil
+		}
+
+		var user User
+		if err := json.Unmarshal(resp.Kvs[0].Value, &user); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal user: %w", err)
+		}
+		update(&user)
+
+		data, err := json.Marshal(&user)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal user: %w", err)
+		}
+		txn, err := s.client.Txn(ctx).If(
+			clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision),
+		).Then(
+			clientv3.OpPut(key, string(data)),
+		).Commit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to save user: %w", err)
+		}
+		if txn.Succeeded {
+			return &user, nil
+		}
+	}
+}
+
+// DeleteUser removes a user and reports whether it existed
+func (s *Storage) DeleteUser(ctx context.Context, username string) (bool, error) {
+	resp, err := s.client.Delete(ctx, usersPrefix+username)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete user: %w", err)
+	}
+	return resp.Deleted > 0, nil
+}
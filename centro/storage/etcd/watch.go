@@ -0,0 +1,205 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "github.com/open-scheduler/proto"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const watchPrefix = "/centro/"
+
+const (
+	ChangeKindDeployment = "deployment"
+	ChangeKindInstance   = "instance"
+	ChangeKindNode       = "node"
+	ChangeKindEvent      = "event"
+)
+
+const (
+	ChangeTypePut    = "put"
+	ChangeTypeDelete = "delete"
+)
+
+// ErrRevisionCompacted is returned when a watch is resumed from a revision
+// that etcd no longer has. Callers have to re-list and watch from the start.
+var ErrRevisionCompacted = errors.New("requested revision has been compacted")
+
+// Change is a typed notification about a single stored object
+type Change struct {
+	Revision     int64             `json:"revision"`
+	Kind         string            `json:"kind"`
+	Type         string            `json:"type"`
+	DeploymentID string            `json:"deployment_id,omitempty"`
+	NodeID       string            `json:"node_id,omitempty"`
+	Status       string            `json:"status,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Object       interface{}       `json:"object,omitempty"`
+}
+
+// WatchResult carries either a batch of changes or the error that ended the watch
+type WatchResult struct {
+	Changes []*Change
+	Err     error
+}
+
+// WatchChanges streams changes to deployments, instances, nodes and events.
+// A revision > 0 resumes the watch right after that revision, otherwise only
+// changes from now on are sent. The channel is closed when ctx is cancelled or
+// after a result carrying an error.
+func (s *Storage) WatchChanges(ctx context.Context, revision int64) <-chan WatchResult {
+	results := make(chan WatchResult)
+
+	opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithPrevKV()}
+	if revision > 0 {
+		opts = append(opts, clientv3.WithRev(revision+1))
+	}
+
+	go func() {
+		defer close(results)
+
+		watchCtx := clientv3.WithRequireLeader(ctx)
+		for resp := range s.client.Watch(watchCtx, watchPrefix, opts...) {
+			if resp.CompactRevision != 0 {
+				select {
+				case results <- WatchResult{Err: fmt.Errorf("%w (compacted at %d)", ErrRevisionCompacted, resp.CompactRevision)}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			if err := resp.Err(); err != nil {
+				select {
+				case results <- WatchResult{Err: fmt.Errorf("watch failed: %w", err)}:
+				case <-ctx.Done():
+				}
+				return
+			}
+
+			changes := make([]*Change, 0, len(resp.Events))
+			for _, ev := range resp.Events {
+				if change := decodeChange(ev); change != nil {
+					changes = append(changes, change)
+				}
+			}
+			if len(changes) == 0 {
+				continue
+			}
+
+			select {
+			case results <- WatchResult{Changes: changes}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+// decodeChange turns an etcd watch event into a Change, or nil for keys that
+// are not exposed to watchers
+func decodeChange(ev *clientv3.Event) *Change {
+	key := string(ev.Kv.Key)
+	value := ev.Kv.Value
+	change := &Change{
+		Revision: ev.Kv.ModRevision,
+		Type:     ChangeTypePut,
+	}
+	if ev.Type == clientv3.EventTypeDelete {
+		change.Type = ChangeTypeDelete
+		value = nil
+		if ev.PrevKv != nil {
+			value = ev.PrevKv.Value
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(key, deploymentQueuePrefix):
+		change.Kind = ChangeKindDeployment
+		change.DeploymentID = strings.TrimPrefix(key, deploymentQueuePrefix)
+		change.Status = "queued"
+		decodeDeploymentChange(change, value)
+
+	case strings.HasPrefix(key, failDeploymentQueuePrefix):
+		change.Kind = ChangeKindDeployment
+		change.DeploymentID = strings.TrimPrefix(key, failDeploymentQueuePrefix)
+		change.Status = "retrying"
+		decodeDeploymentChange(change, value)
+
+	case strings.HasPrefix(key, deploymentActivePrefix), strings.HasPrefix(key, deploymentHistoryPrefix):
+		change.Kind = ChangeKindDeployment
+		change.DeploymentID = strings.TrimPrefix(strings.TrimPrefix(key, deploymentActivePrefix), deploymentHistoryPrefix)
+		var status DeploymentStatus
+		if len(value) > 0 && json.Unmarshal(value, &status) == nil {
+			change.Status = status.Status
+			change.NodeID = status.NodeID
+			if status.Deployment != nil {
+				change.Labels = status.Deployment.DeploymentMetadata
+			}
+			change.Object = &status
+		}
+
+	case strings.HasPrefix(key, instanceDataPrefix):
+		change.Kind = ChangeKindInstance
+		change.DeploymentID = strings.TrimPrefix(key, instanceDataPrefix)
+		var instanceData pb.InstanceData
+		if len(value) > 0 && json.Unmarshal(value, &instanceData) == nil {
+			change.Status = instanceData.Status
+			change.Labels = instanceData.Labels
+			change.Object = &instanceData
+		}
+
+	case strings.HasPrefix(key, deploymentEventsPrefix):
+		change.Kind = ChangeKindEvent
+		rest := strings.TrimPrefix(key, deploymentEventsPrefix)
+		idx := strings.LastIndex(rest, "/")
+		if idx <= 0 {
+			return nil
+		}
+		change.DeploymentID = rest[:idx]
+		if len(value) > 0 {
+			event := decodeDeploymentEvent(change.DeploymentID, value)
+			change.NodeID = event.NodeID
+			change.Object = event
+		}
+
+	case strings.HasPrefix(key, nodesPrefix):
+		change.Kind = ChangeKindNode
+		change.NodeID = strings.TrimPrefix(key, nodesPrefix)
+		var node NodeInfo
+		if len(value) > 0 && json.Unmarshal(value, &node) == nil {
+			change.Status = "unhealthy"
+			if node.IsHealthy() {
+				change.Status = "healthy"
+			}
+			change.Labels = node.Metadata
+			change.Object = &node
+		}
+
+	default:
+		return nil
+	}
+
+	return change
+}
+
+func decodeDeploymentChange(change *Change, value []byte) {
+	var deployment pb.Deployment
+	if len(value) > 0 && json.Unmarshal(value, &deployment) == nil {
+		change.Labels = deployment.DeploymentMetadata
+		change.Object = &deployment
+	}
+}
+
+// CurrentRevision returns the latest etcd revision, used as a starting point for watches
+func (s *Storage) CurrentRevision(ctx context.Context) (int64, error) {
+	resp, err := s.client.Get(ctx, watchPrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current revision: %w", err)
+	}
+	return resp.Header.Revision, nil
+}
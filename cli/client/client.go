@@ -2,24 +2,33 @@ package client
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
 )
 
 const (
 	DefaultBaseURL = "http://localhost:8080/api/v1"
 	TokenFile      = ".osctl_token"
+	// TokenEnv holds a token to use instead of the saved one, e.g. an API
+	// token of a service account in CI
+	TokenEnv = "OSCTL_TOKEN"
 )
 
 type Client struct {
 	BaseURL string
 	Token   string
+	// Headers are added to every request
+	Headers map[string]string
 	client  *http.Client
+	// refreshToken renews Token when it expires at expiresAt
+	refreshToken string
+	expiresAt    time.Time
 }
 
 type LoginRequest struct {
@@ -28,9 +37,33 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token     string `json:"token"`
-	ExpiresIn int    `json:"expires_in"`
-	Username  string `json:"username"`
+	Token            string `json:"token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Username         string `json:"username"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	RefreshExpiresIn int    `json:"refresh_expires_in,omitempty"`
+}
+
+// StatusError is returned for responses with an error status
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API error: %s (status: %d)", e.Message, e.StatusCode)
+}
+
+// newStatusError uses the error field of a JSON error response as the
+// message, or the whole body if there is none
+func newStatusError(statusCode int, body []byte) *StatusError {
+	var errorResp map[string]string
+	if err := json.Unmarshal(body, &errorResp); err == nil {
+		if msg, ok := errorResp["error"]; ok {
+			return &StatusError{StatusCode: statusCode, Message: msg}
+		}
+	}
+	return &StatusError{StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
 }
 
 func NewClient(baseURL string) *Client {
@@ -44,13 +77,17 @@ func NewClient(baseURL string) *Client {
 }
 
 func (c *Client) LoadToken() error {
-	homeDir, err := os.UserHomeDir()
+	if token := strings.TrimSpace(os.Getenv(TokenEnv)); token != "" {
+		c.Token = token
+		return nil
+	}
+
+	path, err := tokenPath()
 	if err != nil {
 		return err
 	}
-	tokenPath := filepath.Join(homeDir, TokenFile)
 	
-	data, err := os.ReadFile(tokenPath)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil // Token file doesn't exist, will need to login
@@ -58,19 +95,16 @@ func (c *Client) LoadToken() error {
 		return err
 	}
 	
-	c.Token = string(bytes.TrimSpace(data))
+	saved := parseSession(data)
+	c.Token = saved.Token
+	c.refreshToken = saved.RefreshToken
+	c.expiresAt = saved.ExpiresAt
 	return nil
 }
 
+// SaveToken saves a token that cannot be refreshed
 func (c *Client) SaveToken(token string) error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return err
-	}
-	tokenPath := filepath.Join(homeDir, TokenFile)
-	
-	c.Token = token
-	return os.WriteFile(tokenPath, []byte(token), 0600)
+	return c.saveSession(session{Token: token})
 }
 
 func (c *Client) Login(username, password string) error {
@@ -108,7 +142,7 @@ func (c *Client) Login(username, password string) error {
 		return fmt.Errorf("failed to decode response: %w", err)
 	}
 	
-	return c.SaveToken(loginResp.Token)
+	return c.SaveLogin(&loginResp)
 }
 
 func (c *Client) ensureAuthenticated() error {
@@ -121,10 +155,26 @@ func (c *Client) ensureAuthenticated() error {
 			return fmt.Errorf("not authenticated. Please login first")
 		}
 	}
+	c.refreshIfExpiring()
 	return nil
 }
 
+// DoRequest sends a request with the token. When Centro rejects an expired
+// token, it is refreshed and the request sent again.
 func (c *Client) DoRequest(method, endpoint string, body interface{}) (*http.Response, error) {
+	resp, err := c.doRequest(method, endpoint, body)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || c.refreshToken == "" {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	if err := c.Refresh(); err != nil {
+		return nil, err
+	}
+	return c.doRequest(method, endpoint, body)
+}
+
+func (c *Client) doRequest(method, endpoint string, body interface{}) (*http.Response, error) {
 	if err := c.ensureAuthenticated(); err != nil {
 		return nil, err
 	}
@@ -146,6 +196,9 @@ func (c *Client) DoRequest(method, endpoint string, body interface{}) (*http.Res
 	}
 	
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	for key, value := range c.Headers {
+		req.Header.Set(key, value)
+	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -171,13 +224,7 @@ func (c *Client) Get(endpoint string) (map[string]interface{}, error) {
 	}
 	
 	if resp.StatusCode >= 400 {
-		var errorResp map[string]string
-		if err := json.Unmarshal(body, &errorResp); err == nil {
-			if msg, ok := errorResp["error"]; ok {
-				return nil, fmt.Errorf("API error: %s (status: %d)", msg, resp.StatusCode)
-			}
-		}
-		return nil, fmt.Errorf("API error: %s (status: %d)", string(body), resp.StatusCode)
+		return nil, newStatusError(resp.StatusCode, body)
 	}
 	
 	var result map[string]interface{}
@@ -189,7 +236,44 @@ func (c *Client) Get(endpoint string) (map[string]interface{}, error) {
 }
 
 func (c *Client) Post(endpoint string, body interface{}) (map[string]interface{}, error) {
-	resp, err := c.DoRequest("POST", endpoint, body)
+	return c.send("POST", endpoint, body)
+}
+
+func (c *Client) Put(endpoint string, body interface{}) (map[string]interface{}, error) {
+	return c.send("PUT", endpoint, body)
+}
+
+func (c *Client) Patch(endpoint string, body interface{}) (map[string]interface{}, error) {
+	return c.send("PATCH", endpoint, body)
+}
+
+func (c *Client) Delete(endpoint string) (map[string]interface{}, error) {
+	return c.send("DELETE", endpoint, nil)
+}
+
+// Username returns the user the saved token was issued to, or "" when there
+// is no token or it is an API token. The token is not verified, Centro does that.
+func (c *Client) Username() string {
+	parts := strings.Split(c.Token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.Username
+}
+
+// send makes a request with a JSON body and decodes the JSON response
+func (c *Client) send(method, endpoint string, body interface{}) (map[string]interface{}, error) {
+	resp, err := c.DoRequest(method, endpoint, body)
 	if err != nil {
 		return nil, err
 	}
@@ -201,13 +285,7 @@ func (c *Client) Post(endpoint string, body interface{}) (map[string]interface{}
 	}
 	
 	if resp.StatusCode >= 400 {
-		var errorResp map[string]string
-		if err := json.Unmarshal(respBody, &errorResp); err == nil {
-			if msg, ok := errorResp["error"]; ok {
-				return nil, fmt.Errorf("API error: %s (status: %d)", msg, resp.StatusCode)
-			}
-		}
-		return nil, fmt.Errorf("API error: %s (status: %d)", string(respBody), resp.StatusCode)
+		return nil, newStatusError(resp.StatusCode, respBody)
 	}
 	
 	var result map[string]interface{}
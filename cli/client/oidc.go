@@ -0,0 +1,107 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrAuthorizationPending means the user has not finished the SSO login yet
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	// ErrSlowDown means the login is polled too often
+	ErrSlowDown = errors.New("slow_down")
+)
+
+// DeviceLogin is an SSO login waiting for the user to enter UserCode at
+// VerificationURI
+type DeviceLogin struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// StartSSOLogin starts a device login at the identity provider of Centro
+func (c *Client) StartSSOLogin() (*DeviceLogin, error) {
+	var login DeviceLogin
+	if err := c.postAuth("/auth/oidc/device", nil, &login); err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+// PollSSOLogin checks once whether the user finished a device login and
+// saves the token if so. It returns ErrAuthorizationPending or ErrSlowDown
+// while the login should be polled again.
+func (c *Client) PollSSOLogin(deviceCode string) (*LoginResponse, error) {
+	var loginResp LoginResponse
+	err := c.postAuth("/auth/oidc/device/token", map[string]string{"device_code": deviceCode}, &loginResp)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.SaveLogin(&loginResp); err != nil {
+		return nil, err
+	}
+	return &loginResp, nil
+}
+
+// postAuth posts to an authentication endpoint that needs no token
+func (c *Client) postAuth(endpoint string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest("POST", c.BaseURL+endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		json.Unmarshal(data, &apiErr)
+		switch apiErr.Error {
+		case ErrAuthorizationPending.Error():
+			return ErrAuthorizationPending
+		case ErrSlowDown.Error():
+			return ErrSlowDown
+		case "":
+			return newStatusError(resp.StatusCode, data)
+		}
+		msg := apiErr.Error
+		if apiErr.ErrorDescription != "" {
+			msg += ": " + apiErr.ErrorDescription
+		}
+		return &StatusError{StatusCode: resp.StatusCode, Message: msg}
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// refreshBefore is how long before it expires an access token is renewed
+const refreshBefore = 30 * time.Second
+
+// session is what the token file holds. Files written by older versions of
+// osctl only hold the token.
+type session struct {
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+}
+
+func tokenPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, TokenFile), nil
+}
+
+func parseSession(data []byte) session {
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return session{Token: strings.TrimSpace(string(data))}
+	}
+	return s
+}
+
+func (c *Client) saveSession(s session) error {
+	path, err := tokenPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+
+	c.Token = s.Token
+	c.refreshToken = s.RefreshToken
+	c.expiresAt = s.ExpiresAt
+	return os.WriteFile(path, data, 0600)
+}
+
+// SaveLogin saves the tokens of a login or a refresh
+func (c *Client) SaveLogin(resp *LoginResponse) error {
+	s := session{Token: resp.Token, RefreshToken: resp.RefreshToken}
+	if resp.ExpiresIn > 0 {
+		s.ExpiresAt = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return c.saveSession(s)
+}
+
+// Refresh renews the access token with the refresh token of the login
+func (c *Client) Refresh() error {
+	if c.refreshToken == "" {
+		return fmt.Errorf("not authenticated. Please login first")
+	}
+
+	used := c.refreshToken
+	var resp LoginResponse
+	if err := c.postAuth("/auth/refresh", map[string]string{"refresh_token": used}, &resp); err != nil {
+		// Another osctl may have refreshed at the same time and saved the
+		// new tokens
+		if c.LoadToken() == nil && c.Token != "" && c.refreshToken != used {
+			return nil
+		}
+		return fmt.Errorf("session expired, please login again: %w", err)
+	}
+	return c.SaveLogin(&resp)
+}
+
+// refreshIfExpiring renews the access token shortly before it expires. If
+// that fails the request is sent anyway and Centro decides.
+func (c *Client) refreshIfExpiring() {
+	if c.refreshToken == "" || c.expiresAt.IsZero() || time.Until(c.expiresAt) > refreshBefore {
+		return
+	}
+	c.Refresh()
+}
+
+// Logout revokes the token and the login at Centro and removes the token
+// file. With all, every login of the user ends.
+func (c *Client) Logout(all bool) error {
+	if os.Getenv(TokenEnv) != "" {
+		return fmt.Errorf("%s is set, revoke API tokens with osctl token revoke", TokenEnv)
+	}
+	if err := c.ensureAuthenticated(); err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{"refresh_token": c.refreshToken, "all": all}
+	resp, err := c.DoRequest("POST", "/auth/logout", body)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+			err = fmt.Errorf("API error: status %d", resp.StatusCode)
+		}
+	}
+
+	path, pathErr := tokenPath()
+	if pathErr != nil {
+		return pathErr
+	}
+	if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) {
+		return fmt.Errorf("failed to remove %s: %w", path, removeErr)
+	}
+	c.Token, c.refreshToken, c.expiresAt = "", "", time.Time{}
+
+	if err != nil {
+		return fmt.Errorf("removed the saved token, but Centro could not revoke it: %w", err)
+	}
+	return nil
+}
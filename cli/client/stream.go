@@ -0,0 +1,185 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// StreamEvent is a single Server-Sent Event
+type StreamEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// Change is a change notification from the /watch endpoint
+type Change struct {
+	Revision     int64                  `json:"revision"`
+	Kind         string                 `json:"kind"`
+	Type         string                 `json:"type"`
+	DeploymentID string                 `json:"deployment_id"`
+	NodeID       string                 `json:"node_id"`
+	Status       string                 `json:"status"`
+	Labels       map[string]string      `json:"labels"`
+	Object       map[string]interface{} `json:"object"`
+}
+
+// ErrRevisionGone is returned when the server can no longer resume from the requested revision
+var ErrRevisionGone = errors.New("watch revision is no longer available")
+
+const (
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// Stream opens an SSE endpoint and calls handle for every event until the
+// stream ends, ctx is cancelled or handle returns an error.
+func (c *Client) Stream(ctx context.Context, endpoint string, lastEventID string, handle func(StreamEvent) error) error {
+	if err := c.ensureAuthenticated(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s", c.BaseURL, endpoint), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	// Streams stay open indefinitely, so the regular client timeout cannot be used
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to open stream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return newStatusError(resp.StatusCode, body)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var event StreamEvent
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			// A blank line dispatches the event
+			if len(data) > 0 || event.Event != "" {
+				event.Data = strings.Join(data, "\n")
+				if err := handle(event); err != nil {
+					return err
+				}
+			}
+			event = StreamEvent{}
+			data = nil
+		case strings.HasPrefix(line, ":"):
+			// Comment, used by the server as keepalive
+		case strings.HasPrefix(line, "id:"):
+			event.ID = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "event:"):
+			event.Event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+}
+
+// Watch follows /watch with the given filters and calls handle for every change.
+// Dropped connections are re-established with backoff and resumed from the last
+// seen revision. If that revision is gone, onReset is called (so callers can
+// re-list) and watching continues from the current state.
+func (c *Client) Watch(ctx context.Context, params url.Values, revision int64, handle func(*Change), onReset func()) error {
+	delay := minReconnectDelay
+	for {
+		query := url.Values{}
+		for key, values := range params {
+			query[key] = values
+		}
+		if revision > 0 {
+			query.Set("revision", fmt.Sprintf("%d", revision))
+		}
+
+		err := c.Stream(ctx, "/watch?"+query.Encode(), "", func(event StreamEvent) error {
+			switch event.Event {
+			case "ready":
+				delay = minReconnectDelay
+				var ready struct {
+					Revision int64 `json:"revision"`
+				}
+				if json.Unmarshal([]byte(event.Data), &ready) == nil && ready.Revision > revision {
+					revision = ready.Revision
+				}
+			case "error":
+				var streamErr struct {
+					Error string `json:"error"`
+					Code  int    `json:"code"`
+				}
+				json.Unmarshal([]byte(event.Data), &streamErr)
+				if streamErr.Code == http.StatusGone {
+					return ErrRevisionGone
+				}
+				return fmt.Errorf("watch error: %s", streamErr.Error)
+			default:
+				var change Change
+				if err := json.Unmarshal([]byte(event.Data), &change); err != nil {
+					return nil
+				}
+				if change.Revision > revision {
+					revision = change.Revision
+				}
+				handle(&change)
+			}
+			return nil
+		})
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, ErrRevisionGone) {
+			revision = 0
+			if onReset != nil {
+				onReset()
+			}
+			continue
+		}
+		// Client errors such as an expired token or a bad filter won't go away by retrying
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
@@ -2,8 +2,13 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/open-scheduler/cli/client"
 	"github.com/spf13/cobra"
@@ -13,53 +18,85 @@ import (
 var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Apply a job specification from a file",
-	Long:  "Apply a job specification from a YAML file",
+	Long: `Apply a job specification from a YAML file.
+
+If the spec has a job_id, or a queued, running or retrying job with the same
+name exists, that job is updated in place and gets a new revision. Otherwise
+the spec is submitted as a new job.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		filePath, _ := cmd.Flags().GetString("f")
 		if filePath == "" {
 			return fmt.Errorf("file path is required. Use -f flag")
 		}
 
-		// Read YAML file
-		data, err := os.ReadFile(filePath)
+		apiReq, err := loadDeploymentRequest(filePath)
 		if err != nil {
-			return fmt.Errorf("failed to read file: %w", err)
+			return err
 		}
 
-		// Parse YAML
-		var yamlSpec map[string]interface{}
-		if err := yaml.Unmarshal(data, &yamlSpec); err != nil {
-			return fmt.Errorf("failed to parse YAML: %w", err)
+		// Catch spec mistakes before anything is sent to the server
+		if errs, err := validateDeploymentRequest(apiReq); err != nil {
+			return err
+		} else if errs != nil {
+			printValidationErrors(filePath, errs)
+			return fmt.Errorf("%s is invalid", filePath)
 		}
 
-		// Convert YAML spec to API request format
-		apiReq := convertYAMLToAPIRequest(yamlSpec)
-
 		// Submit job
 		c := client.NewClient(getBaseURL())
 		if err := c.LoadToken(); err != nil {
 			return fmt.Errorf("failed to load token: %w", err)
 		}
+		// Lets the server recognize a retried apply instead of creating a second deployment
+		if key, _ := cmd.Flags().GetString("idempotency-key"); key != "" {
+			c.Headers = map[string]string{"Idempotency-Key": key}
+		}
+
+		createNew, _ := cmd.Flags().GetBool("new")
+		existingID := ""
+		if !createNew {
+			existingID, err = findExistingDeployment(c, apiReq)
+			if err != nil {
+				return err
+			}
+		}
+
+		if existingID != "" {
+			result, err := c.Put("/deployments/"+url.PathEscape(existingID), apiReq)
+			if err == nil {
+				printUpdateResult(existingID, result)
+				return nil
+			}
+			// A job_id from the spec that does not exist yet is a first submission
+			var statusErr *client.StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || apiReq["deployment_id"] != existingID {
+				return err
+			}
+		}
 
-		result, err := c.Post("/jobs", apiReq)
+		result, err := c.Post("/deployments", apiReq)
 		if err != nil {
 			return err
 		}
 
 		// Format response nicely
-		if jobID, ok := result["job_id"].(string); ok {
-			fmt.Printf("✓ Job submitted successfully!\n\n")
+		if jobID, ok := result["deployment_id"].(string); ok {
+			if created, ok := result["created"].(bool); ok && !created {
+				fmt.Printf("✓ Job already submitted with the same spec, nothing changed.\n\n")
+			} else {
+				fmt.Printf("✓ Job submitted successfully!\n\n")
+			}
 			fmt.Println("Job ID:       ", jobID)
 			if message, ok := result["message"].(string); ok {
 				fmt.Println("Message:      ", message)
 			}
 
 			// Show job details if available
-			if job, ok := result["job"].(map[string]interface{}); ok {
-				if jobName, ok := job["job_name"].(string); ok && jobName != "" {
+			if job, ok := result["deployment"].(map[string]interface{}); ok {
+				if jobName, ok := job["deployment_name"].(string); ok && jobName != "" {
 					fmt.Println("Job Name:     ", jobName)
 				}
-				if jobType, ok := job["job_type"].(string); ok && jobType != "" {
+				if jobType, ok := job["deployment_type"].(string); ok && jobType != "" {
 					fmt.Println("Type:         ", jobType)
 				}
 				if driverType, ok := job["driver_type"].(string); ok && driverType != "" {
@@ -82,6 +119,110 @@ var applyCmd = &cobra.Command{
 	},
 }
 
+// findExistingDeployment returns the ID of the deployment a spec should update:
+// the deployment_id of the spec if it has one, otherwise the single queued,
+// running or retrying deployment with the same name. An empty ID means the
+// spec should be submitted as a new deployment.
+func findExistingDeployment(c *client.Client, apiReq map[string]interface{}) (string, error) {
+	if id, ok := apiReq["deployment_id"].(string); ok && id != "" {
+		return id, nil
+	}
+	name, ok := apiReq["deployment_name"].(string)
+	if !ok || name == "" {
+		return "", nil
+	}
+
+	result, err := c.Get("/deployments?name=" + url.QueryEscape(name))
+	if err != nil {
+		return "", fmt.Errorf("failed to look up deployments named %s: %w", name, err)
+	}
+
+	var ids []string
+	for _, section := range []string{"services", "system_deployments", "batches", "periodic_deployments", "blocked_deployments", "queued_deployments", "active_deployments", "failed_deployments"} {
+		entries, _ := result[section].([]interface{})
+		for _, entry := range entries {
+			deployment, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// Permanently failed deployments are finished and are not updated
+			if status, _ := deployment["status"].(string); status == "failed_permanent" {
+				continue
+			}
+			// Replicas of a service are updated through the service
+			if spec, ok := deployment["deployment"].(map[string]interface{}); ok {
+				if parentID, _ := spec["parent_deployment_id"].(string); parentID != "" {
+					continue
+				}
+			}
+			if id, ok := deployment["deployment_id"].(string); ok && id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", nil
+	case 1:
+		return ids[0], nil
+	default:
+		sort.Strings(ids)
+		return "", fmt.Errorf("%d deployments are named %s (%s), set deployment_id in the spec to choose one or use --new",
+			len(ids), name, strings.Join(ids, ", "))
+	}
+}
+
+func printUpdateResult(jobID string, result map[string]interface{}) {
+	revision, _ := result["revision"].(float64)
+	if changed, ok := result["changed"].(bool); ok && !changed {
+		fmt.Printf("✓ Job %s is up to date (revision %d), nothing changed.\n", jobID, int64(revision))
+		return
+	}
+
+	fmt.Printf("✓ Job %s updated to revision %d\n\n", jobID, int64(revision))
+	if changes, ok := result["changes"].([]interface{}); ok && len(changes) > 0 {
+		fmt.Println("Changes:")
+		for _, entry := range changes {
+			change, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fmt.Printf("  %s: %s -> %s\n", change["field"], formatChangeValue(change["old"]), formatChangeValue(change["new"]))
+		}
+	}
+	fmt.Printf("\nUse 'osctl describe job %s' to view job details.\n", jobID)
+}
+
+func formatChangeValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	if text, ok := value.(string); ok {
+		return text
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// loadDeploymentRequest reads a YAML spec file and converts it to the submit API request format
+func loadDeploymentRequest(filePath string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	var yamlSpec map[string]interface{}
+	if err := yaml.Unmarshal(data, &yamlSpec); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	return convertYAMLToAPIRequest(yamlSpec), nil
+}
+
 func convertYAMLToAPIRequest(yamlSpec map[string]interface{}) map[string]interface{} {
 	req := make(map[string]interface{})
 
@@ -95,13 +236,13 @@ func convertYAMLToAPIRequest(yamlSpec map[string]interface{}) map[string]interfa
 	// Legacy format - direct job specification
 	// Basic fields
 	if jobID, ok := yamlSpec["job_id"].(string); ok {
-		req["job_id"] = jobID
+		req["deployment_id"] = jobID
 	}
 	if jobName, ok := yamlSpec["job_name"].(string); ok {
-		req["job_name"] = jobName
+		req["deployment_name"] = jobName
 	}
 	if jobType, ok := yamlSpec["job_type"].(string); ok {
-		req["job_type"] = jobType
+		req["deployment_type"] = jobType
 	}
 	if selectedClusters, ok := yamlSpec["selected_clusters"].([]interface{}); ok {
 		clusters := make([]string, 0, len(selectedClusters))
@@ -115,6 +256,9 @@ func convertYAMLToAPIRequest(yamlSpec map[string]interface{}) map[string]interfa
 	if driverType, ok := yamlSpec["driver_type"].(string); ok {
 		req["driver"] = driverType
 	}
+	if replicas, ok := yamlSpec["replicas"].(int); ok {
+		req["replicas"] = int32(replicas)
+	}
 	if workloadType, ok := yamlSpec["workload_type"].(string); ok {
 		req["workload_type"] = workloadType
 	}
@@ -221,6 +365,31 @@ func convertYAMLToAPIRequest(yamlSpec map[string]interface{}) map[string]interfa
 		req["env"] = envMap
 	}
 
+	// Secrets
+	if secrets, ok := yamlSpec["secrets"].([]interface{}); ok {
+		req["secrets"] = convertSecrets(secrets)
+	}
+
+	// Configs
+	if configs, ok := yamlSpec["configs"].([]interface{}); ok {
+		req["configs"] = convertConfigs(configs)
+	}
+
+	// Update strategy
+	if update, ok := yamlSpec["update"].(map[string]interface{}); ok {
+		req["update"] = convertUpdateStrategy(update)
+	}
+
+	// Cron schedule
+	if periodic, ok := yamlSpec["periodic"].(map[string]interface{}); ok {
+		req["periodic"] = convertPeriodic(periodic)
+	}
+
+	// Dependencies
+	if dependsOn, ok := yamlSpec["depends_on"].([]interface{}); ok {
+		req["depends_on"] = convertDependsOn(dependsOn)
+	}
+
 	// Job metadata
 	if jobMetadata, ok := yamlSpec["job_metadata"].(map[string]interface{}); ok {
 		metaMap := make(map[string]string)
@@ -235,14 +404,116 @@ func convertYAMLToAPIRequest(yamlSpec map[string]interface{}) map[string]interfa
 	return req
 }
 
+// convertSecrets converts secret references, which use the API field names in both spec formats
+func convertSecrets(secrets []interface{}) []map[string]interface{} {
+	refs := make([]map[string]interface{}, 0, len(secrets))
+	for _, s := range secrets {
+		secretMap, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ref := make(map[string]interface{})
+		for _, key := range []string{"secret", "env", "file"} {
+			if value, ok := secretMap[key].(string); ok {
+				ref[key] = value
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// convertConfigs converts config references, which use the API field names in both spec formats
+func convertConfigs(configs []interface{}) []map[string]interface{} {
+	refs := make([]map[string]interface{}, 0, len(configs))
+	for _, c := range configs {
+		configMap, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ref := make(map[string]interface{})
+		for _, key := range []string{"config", "file"} {
+			if value, ok := configMap[key].(string); ok {
+				ref[key] = value
+			}
+		}
+		if template, ok := configMap["template"].(bool); ok {
+			ref["template"] = template
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// convertUpdateStrategy converts an update block, which uses the API field names in both spec formats
+func convertUpdateStrategy(update map[string]interface{}) map[string]interface{} {
+	updateReq := make(map[string]interface{})
+	for _, field := range []string{"max_parallel", "max_surge", "canary"} {
+		if value, ok := update[field].(int); ok {
+			updateReq[field] = int32(value)
+		}
+	}
+	for _, field := range []string{"strategy", "min_healthy_time", "healthy_deadline", "auto_promote_after"} {
+		if value, ok := update[field].(string); ok {
+			updateReq[field] = value
+		}
+	}
+	if autoRevert, ok := update["auto_revert"].(bool); ok {
+		updateReq["auto_revert"] = autoRevert
+	}
+	return updateReq
+}
+
+// convertPeriodic converts a periodic block, which uses the API field names in both spec formats
+func convertPeriodic(periodic map[string]interface{}) map[string]interface{} {
+	periodicReq := make(map[string]interface{})
+	for _, field := range []string{"cron", "time_zone"} {
+		if value, ok := periodic[field].(string); ok {
+			periodicReq[field] = value
+		}
+	}
+	if prohibitOverlap, ok := periodic["prohibit_overlap"].(bool); ok {
+		periodicReq["prohibit_overlap"] = prohibitOverlap
+	}
+	return periodicReq
+}
+
+// convertDependsOn converts a depends_on list. An entry is either the name of
+// another service of the same template, which must succeed, or a map with
+// name (or service) or deployment_id and an optional condition.
+func convertDependsOn(dependsOn []interface{}) []map[string]interface{} {
+	dependencies := make([]map[string]interface{}, 0, len(dependsOn))
+	for _, entry := range dependsOn {
+		switch value := entry.(type) {
+		case string:
+			dependencies = append(dependencies, map[string]interface{}{"name": value})
+		case map[string]interface{}:
+			dependency := make(map[string]interface{})
+			if name, ok := value["service"].(string); ok {
+				dependency["name"] = name
+			}
+			for _, field := range []string{"name", "deployment_id", "condition"} {
+				if text, ok := value[field].(string); ok {
+					dependency[field] = text
+				}
+			}
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	return dependencies
+}
+
 // convertTemplateServiceToAPIRequest converts a template.yaml service to API request format
 func convertTemplateServiceToAPIRequest(service map[string]interface{}) map[string]interface{} {
 	req := make(map[string]interface{})
 
 	// Service name becomes job name
 	if name, ok := service["name"].(string); ok {
-		req["job_name"] = name
-		req["job_type"] = "service" // Default for template.yaml services
+		req["deployment_name"] = name
+		req["deployment_type"] = "service" // Default for template.yaml services
+	}
+	if deploymentType, ok := service["deployment_type"].(string); ok {
+		req["deployment_type"] = deploymentType
 	}
 
 	// Service type maps to workload_type
@@ -263,6 +534,31 @@ func convertTemplateServiceToAPIRequest(service map[string]interface{}) map[stri
 		req["replicas"] = replicasInt32
 	}
 
+	// Metadata, also available to config templates as .Meta
+	if meta, ok := service["meta"].(map[string]interface{}); ok {
+		metaMap := make(map[string]string)
+		for k, v := range meta {
+			metaMap[k] = fmt.Sprintf("%v", v)
+		}
+		req["meta"] = metaMap
+	}
+
+	// Update strategy
+	if update, ok := service["update"].(map[string]interface{}); ok {
+		req["update"] = convertUpdateStrategy(update)
+	}
+
+	// Cron schedule, a periodic service runs to completion on every launch
+	if periodic, ok := service["periodic"].(map[string]interface{}); ok {
+		req["periodic"] = convertPeriodic(periodic)
+		req["deployment_type"] = "batch"
+	}
+
+	// Dependencies on other services of the template
+	if dependsOn, ok := service["depends_on"].([]interface{}); ok {
+		req["depends_on"] = convertDependsOn(dependsOn)
+	}
+
 	// Placement constraints
 	if placement, ok := service["placement"].(map[string]interface{}); ok {
 		placementReq := make(map[string]interface{})
@@ -350,6 +646,16 @@ func convertTemplateServiceToAPIRequest(service map[string]interface{}) map[stri
 			}
 		}
 
+		// Secrets
+		if secrets, ok := spec["secrets"].([]interface{}); ok && len(secrets) > 0 {
+			req["secrets"] = convertSecrets(secrets)
+		}
+
+		// Configs
+		if configs, ok := spec["configs"].([]interface{}); ok && len(configs) > 0 {
+			req["configs"] = convertConfigs(configs)
+		}
+
 		// Mounts
 		if mounts, ok := spec["mounts"].([]interface{}); ok {
 			volumes := make([]map[string]interface{}, 0, len(mounts))
@@ -568,4 +874,6 @@ func init() {
 	rootCmd.AddCommand(applyCmd)
 	applyCmd.Flags().StringP("f", "f", "", "Path to YAML file")
 	applyCmd.MarkFlagRequired("f")
+	applyCmd.Flags().Bool("new", false, "Always submit a new deployment instead of updating the one with the same name")
+	applyCmd.Flags().String("idempotency-key", "", "Key identifying this submission, so that retries return the deployment created by the first attempt")
 }
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log",
+	Long: `Show who changed what through the API, oldest first. Every POST, PUT, PATCH
+and DELETE request is recorded with its user, role, source IP and outcome.
+Secrets in request bodies are redacted.`,
+	Example: `  osctl audit
+  osctl audit --resource deployments --id abc-123
+  osctl audit --actor alice --outcome denied --since 24h
+  osctl audit --request-id 9b1c6c1e-2f4d-4a8e-bb1a-3c1d2e4f5a6b --body`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		query := url.Values{}
+		for flag, param := range map[string]string{
+			"actor":      "actor",
+			"action":     "action",
+			"resource":   "resource",
+			"id":         "resource_id",
+			"outcome":    "outcome",
+			"request-id": "request_id",
+		} {
+			if value, _ := cmd.Flags().GetString(flag); value != "" {
+				query.Set(param, value)
+			}
+		}
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			sinceTime, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+			query.Set("since", sinceTime.Format(time.RFC3339))
+		}
+		limit, _ := cmd.Flags().GetInt("limit")
+		query.Set("limit", strconv.Itoa(limit))
+
+		result, err := c.Get("/audit?" + query.Encode())
+		if err != nil {
+			return err
+		}
+
+		entries, _ := result["entries"].([]interface{})
+		if len(entries) == 0 {
+			fmt.Println("No audit entries found")
+			return nil
+		}
+
+		showBody, _ := cmd.Flags().GetBool("body")
+		fmt.Printf("%-20s %-16s %-24s %-28s %-8s %-16s %s\n", "TIME", "ACTOR", "ACTION", "RESOURCE", "OUTCOME", "SOURCE", "REQUEST ID")
+		fmt.Println(strings.Repeat("-", 150))
+		for _, item := range entries {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			actor := fmt.Sprintf("%v", entry["actor"])
+			if entry["actor"] == nil {
+				actor = "-"
+			}
+			resource := fmt.Sprintf("%v", entry["resource"])
+			if id, ok := entry["resource_id"].(string); ok && id != "" {
+				resource += "/" + id
+			}
+			if entry["resource"] == nil {
+				resource = fmt.Sprintf("%v", entry["path"])
+			}
+			outcome := fmt.Sprintf("%v (%.0f)", entry["outcome"], getFloat64(entry["status_code"]))
+			fmt.Printf("%-20s %-16s %-24s %-28s %-8s %-16s %s\n",
+				formatUserTime(entry["time"]), actor, entry["action"], resource, outcome, entry["source_ip"], entry["request_id"])
+			if showBody && entry["body"] != nil {
+				body, err := json.Marshal(entry["body"])
+				if err == nil {
+					fmt.Printf("  %s\n", body)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().String("actor", "", "Only requests of this user or service account")
+	auditCmd.Flags().String("action", "", "Only this action, e.g. deployments:update or auth:login")
+	auditCmd.Flags().String("resource", "", "Only this resource, e.g. deployments or nodes")
+	auditCmd.Flags().String("id", "", "Only requests for this deployment, node, user or other object")
+	auditCmd.Flags().String("outcome", "", "Only requests with this outcome (success, denied, failure)")
+	auditCmd.Flags().String("request-id", "", "Only the request with this ID")
+	auditCmd.Flags().String("since", "", "Only requests newer than a duration (e.g. 24h) or RFC3339 timestamp")
+	auditCmd.Flags().Int("limit", 100, "Show only the most recent N entries, 0 for all")
+	auditCmd.Flags().Bool("body", false, "Show the redacted request bodies")
+}
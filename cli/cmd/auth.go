@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Inspect your access to Centro",
+}
+
+var canICmd = &cobra.Command{
+	Use:   "can-i VERB RESOURCE",
+	Short: "Check whether your role allows an action",
+	Long: `Check whether your role allows VERB (get, list, create, update or delete) on
+RESOURCE (deployments, instances, nodes, events, stats, users, roles,
+serviceaccounts, tokens or jointokens).
+
+Exits with a non-zero status when the action is not allowed.`,
+	Example: `  osctl auth can-i create deployments
+  osctl auth can-i update deployments --cluster prod
+  osctl auth can-i --list`,
+	// A denied action is an expected outcome, not a usage mistake
+	SilenceUsage: true,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if list, _ := cmd.Flags().GetBool("list"); list {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		cluster, _ := cmd.Flags().GetString("cluster")
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		if list {
+			result, err := c.Get("/auth/can-i")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Role: %s\n", result["role"])
+			if clusters, ok := result["clusters"].([]interface{}); ok && len(clusters) > 0 {
+				fmt.Printf("Clusters: %s (create, update and delete)\n", joinValues(clusters))
+			}
+			fmt.Println("Permissions:")
+			permissions, _ := result["permissions"].([]interface{})
+			for _, perm := range permissions {
+				fmt.Printf("  %s\n", perm)
+			}
+			return nil
+		}
+
+		query := url.Values{}
+		query.Set("verb", args[0])
+		query.Set("resource", args[1])
+		if cluster != "" {
+			query.Set("cluster", cluster)
+		}
+		result, err := c.Get("/auth/can-i?" + query.Encode())
+		if err != nil {
+			return err
+		}
+
+		allowed, _ := result["allowed"].(bool)
+		reason, _ := result["reason"].(string)
+		answer := "no"
+		if allowed {
+			answer = "yes"
+		}
+		if reason != "" {
+			answer += " - " + reason
+		}
+		fmt.Println(answer)
+
+		if !allowed {
+			return fmt.Errorf("%s %s is not allowed", args[0], args[1])
+		}
+		return nil
+	},
+}
+
+func joinValues(values []interface{}) string {
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		parts = append(parts, fmt.Sprint(value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(canICmd)
+
+	canICmd.Flags().Bool("list", false, "List the permissions of your role")
+	canICmd.Flags().String("cluster", "", "Check the action for deployments and nodes of this cluster")
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage config files used by deployments",
+	Long: `Manage config files used by deployments.
+
+Deployments reference configs by name in their configs list. The agent writes
+them to a directory of the instance and mounts them read-only at file. With
+template the config is a Go template, rendered with the deployment metadata:
+
+  configs:
+    - config: nginx.conf
+      file: /etc/nginx/nginx.conf
+      template: true
+
+Templates can use {{ .Deployment.ID }}, {{ .Deployment.Name }},
+{{ .Deployment.Type }}, {{ .Deployment.ParentID }}, {{ .Deployment.Clusters }},
+{{ .Meta.key }}, {{ .Env.NAME }}, {{ .Node.ID }}, {{ .Node.Cluster }} and
+{{ .Node.Labels.key }}. A missing key fails the start of the instance.`,
+}
+
+// readConfigData reads the data of a config from a file, - for stdin
+func readConfigData(file string) (string, error) {
+	if file == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read config from stdin: %w", err)
+		}
+		return string(data), nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	return string(data), nil
+}
+
+var configCreateCmd = &cobra.Command{
+	Use:   "create NAME --from-file FILE",
+	Short: "Create a config from a file",
+	Example: `  osctl config create nginx.conf --from-file ./nginx.conf.tmpl --description "Reverse proxy of the shop"
+  helm template ... | osctl config create app.yaml --from-file -`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("from-file")
+		if file == "" {
+			return fmt.Errorf("--from-file is required")
+		}
+		data, err := readConfigData(file)
+		if err != nil {
+			return err
+		}
+		description, _ := cmd.Flags().GetString("description")
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Post("/configs", map[string]interface{}{
+			"name":        args[0],
+			"description": description,
+			"data":        data,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Config %s created (%.0f bytes)\n", result["name"], getFloat64(result["size"]))
+		return nil
+	},
+}
+
+var configUpdateCmd = &cobra.Command{
+	Use:   "update NAME",
+	Short: "Replace the data or description of a config",
+	Long: `Replace the data or description of a config. Running instances keep the old
+file until they are replaced, for example by updating their deployment.`,
+	Example: `  osctl config update nginx.conf --from-file ./nginx.conf.tmpl`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		body := make(map[string]interface{})
+		if file, _ := cmd.Flags().GetString("from-file"); file != "" {
+			data, err := readConfigData(file)
+			if err != nil {
+				return err
+			}
+			body["data"] = data
+		}
+		if description, _ := cmd.Flags().GetString("description"); description != "" {
+			body["description"] = description
+		}
+		if len(body) == 0 {
+			return fmt.Errorf("set --from-file or --description")
+		}
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Put(fmt.Sprintf("/configs/%s", url.PathEscape(args[0])), body)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Config %s updated to version %.0f\n", result["name"], getFloat64(result["version"]))
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List configs and the deployments using them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Get("/configs")
+		if err != nil {
+			return err
+		}
+
+		configs, _ := result["configs"].([]interface{})
+		if len(configs) == 0 {
+			fmt.Println("No configs found")
+			return nil
+		}
+
+		fmt.Printf("%-28s %-8s %-8s %-16s %-20s %-24s %s\n", "NAME", "VERSION", "SIZE", "UPDATED BY", "UPDATED", "USED BY", "DESCRIPTION")
+		fmt.Println(strings.Repeat("-", 130))
+		for _, item := range configs {
+			config, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			usedBy := "-"
+			if list, ok := config["used_by"].([]interface{}); ok && len(list) > 0 {
+				usedBy = joinValues(list)
+			}
+			fmt.Printf("%-28s %-8.0f %-8.0f %-16s %-20s %-24s %s\n",
+				config["name"], getFloat64(config["version"]), getFloat64(config["size"]), config["updated_by"],
+				formatUserTime(config["updated_at"]), usedBy, config["description"])
+		}
+		return nil
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:     "show NAME",
+	Short:   "Print the data of a config",
+	Example: `  osctl config show nginx.conf > nginx.conf.tmpl`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Get(fmt.Sprintf("/configs/%s", url.PathEscape(args[0])))
+		if err != nil {
+			return err
+		}
+
+		data, _ := result["data"].(string)
+		fmt.Print(data)
+		return nil
+	},
+}
+
+var configDeleteCmd = &cobra.Command{
+	Use:     "delete NAME",
+	Aliases: []string{"rm"},
+	Short:   "Delete a config that no deployment uses",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Delete(fmt.Sprintf("/configs/%s", url.PathEscape(args[0])))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configCreateCmd)
+	configCmd.AddCommand(configUpdateCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configDeleteCmd)
+
+	for _, command := range []*cobra.Command{configCreateCmd, configUpdateCmd} {
+		command.Flags().String("from-file", "", "File with the data of the config, - for stdin")
+		command.Flags().String("description", "", "What the config is used for")
+	}
+}
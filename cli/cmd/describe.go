@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/open-scheduler/cli/client"
 	"github.com/spf13/cobra"
@@ -35,6 +34,17 @@ var describeNodeCmd = &cobra.Command{
 		// Format node information
 		fmt.Println("Name:         ", result["node_id"])
 		fmt.Println("Last Heartbeat:", formatTimestamp(result["last_heartbeat"]))
+		if cluster, _ := result["cluster_name"].(string); cluster != "" {
+			fmt.Println("Cluster:      ", cluster)
+		}
+		if approval, _ := result["approval"].(string); approval != "" && approval != "approved" {
+			fmt.Println("Approval:     ", approval)
+		} else if approvedBy, _ := result["approved_by"].(string); approvedBy != "" {
+			fmt.Println("Approved By:  ", approvedBy)
+		}
+		if decommissioned, _ := result["decommissioned"].(bool); decommissioned {
+			fmt.Println("Status:        decommissioned")
+		}
 		
 		ramMB := getFloat64(result["ram_mb"])
 		cpuCores := getFloat64(result["cpu_cores"])
@@ -45,6 +55,13 @@ var describeNodeCmd = &cobra.Command{
 		fmt.Println("  CPU Cores:  ", formatCPU(cpuCores))
 		fmt.Println("  Disk:       ", formatDisk(diskMB))
 		
+		if labels, ok := result["labels"].(map[string]interface{}); ok && len(labels) > 0 {
+			fmt.Println("\nLabels:")
+			for k, v := range labels {
+				fmt.Printf("  %s: %v\n", k, v)
+			}
+		}
+		
 		if metadata, ok := result["metadata"].(map[string]interface{}); ok && len(metadata) > 0 {
 			fmt.Println("\nMetadata:")
 			for k, v := range metadata {
@@ -68,13 +85,13 @@ var describeJobCmd = &cobra.Command{
 			return fmt.Errorf("failed to load token: %w", err)
 		}
 		
-		result, err := c.Get(fmt.Sprintf("/jobs/%s", jobID))
+		result, err := c.Get(fmt.Sprintf("/deployments/%s", jobID))
 		if err != nil {
 			return err
 		}
 		
 		// Basic Information
-		fmt.Println("Name:         ", result["job_id"])
+		fmt.Println("Name:         ", result["deployment_id"])
 		fmt.Println("Status:       ", result["status"])
 		if nodeID, ok := result["node_id"].(string); ok && nodeID != "" {
 			fmt.Println("Node:         ", nodeID)
@@ -88,14 +105,42 @@ var describeJobCmd = &cobra.Command{
 		if updatedAt := result["updated_at"]; updatedAt != nil {
 			fmt.Println("Updated At:   ", formatTimestamp(updatedAt))
 		}
+		if createdBy, ok := result["created_by"].(string); ok && createdBy != "" {
+			fmt.Println("Created By:   ", createdBy)
+		}
+		if updatedBy, ok := result["updated_by"].(string); ok && updatedBy != "" {
+			fmt.Println("Updated By:   ", updatedBy)
+		}
+		
+		if result["status"] == "service" {
+			printServiceRollout(result)
+		}
+		if result["status"] == "system" {
+			fmt.Printf("Replicas:      %.0f healthy\n", getFloat64(result["healthy_replicas"]))
+			printReplicas(result)
+		}
+		if result["status"] == "batch" {
+			fmt.Printf("Replicas:      %.0f desired, %.0f completed\n", getFloat64(result["desired_replicas"]), getFloat64(result["completed_replicas"]))
+			printReplicas(result)
+		}
+		if result["status"] == "periodic" {
+			if nextLaunch := result["next_launch"]; nextLaunch != nil {
+				fmt.Println("Next Launch:  ", formatTimestamp(nextLaunch))
+			}
+			printLaunches(result)
+		}
 		
 		// Job Specification
-		if job, ok := result["job"].(map[string]interface{}); ok {
+		if job, ok := result["deployment"].(map[string]interface{}); ok {
+			if parentID, ok := job["parent_deployment_id"].(string); ok && parentID != "" {
+				fmt.Printf("Parent:        %s (revision %.0f)\n", parentID, getFloat64(job["spec_revision"]))
+			}
+			
 			fmt.Println("\nJob Specification:")
-			if jobName, ok := job["job_name"].(string); ok && jobName != "" {
+			if jobName, ok := job["deployment_name"].(string); ok && jobName != "" {
 				fmt.Println("  Name:            ", jobName)
 			}
-			if jobType, ok := job["job_type"].(string); ok && jobType != "" {
+			if jobType, ok := job["deployment_type"].(string); ok && jobType != "" {
 				fmt.Println("  Type:            ", jobType)
 			}
 			if driverType, ok := job["driver_type"].(string); ok && driverType != "" {
@@ -180,8 +225,85 @@ var describeJobCmd = &cobra.Command{
 				}
 			}
 			
+			// Secrets
+			if secrets, ok := job["secrets"].([]interface{}); ok && len(secrets) > 0 {
+				fmt.Println("\n  Secrets:")
+				for _, entry := range secrets {
+					if ref, ok := entry.(map[string]interface{}); ok {
+						if env, ok := ref["env"].(string); ok && env != "" {
+							fmt.Printf("    %v -> env %s\n", ref["secret"], env)
+						} else {
+							fmt.Printf("    %v -> file %v\n", ref["secret"], ref["file"])
+						}
+					}
+				}
+			}
+			
+			// Configs
+			if configs, ok := job["configs"].([]interface{}); ok && len(configs) > 0 {
+				fmt.Println("\n  Configs:")
+				for _, entry := range configs {
+					if ref, ok := entry.(map[string]interface{}); ok {
+						template := ""
+						if isTemplate, ok := ref["template"].(bool); ok && isTemplate {
+							template = " (template)"
+						}
+						fmt.Printf("    %v -> %v%s\n", ref["config"], ref["file"], template)
+					}
+				}
+			}
+			
+			// Dependencies
+			if dependsOn, ok := job["depends_on"].([]interface{}); ok && len(dependsOn) > 0 {
+				fmt.Println("\n  Depends On:")
+				for _, entry := range dependsOn {
+					if dependency, ok := entry.(map[string]interface{}); ok {
+						fmt.Printf("    %v (%v)\n", dependency["deployment_id"], dependency["condition"])
+					}
+				}
+			}
+			
+			// Cron schedule
+			if periodic, ok := job["periodic"].(map[string]interface{}); ok {
+				fmt.Println("\n  Periodic:")
+				fmt.Println("    Cron:          ", periodic["cron"])
+				timeZone, _ := periodic["time_zone"].(string)
+				if timeZone == "" {
+					timeZone = "UTC"
+				}
+				fmt.Println("    Time Zone:     ", timeZone)
+				prohibitOverlap, _ := periodic["prohibit_overlap"].(bool)
+				fmt.Println("    No Overlap:    ", prohibitOverlap)
+			}
+			
+			// Update strategy
+			if update, ok := job["update"].(map[string]interface{}); ok {
+				fmt.Println("\n  Update Strategy:")
+				strategy, _ := update["strategy"].(string)
+				if strategy == "" {
+					strategy = "rolling"
+				}
+				fmt.Println("    Strategy:      ", strategy)
+				if canary := getFloat64(update["canary"]); canary > 0 {
+					fmt.Printf("    Canary:        %.0f\n", canary)
+				}
+				if autoPromote, ok := update["auto_promote_after"].(string); ok && autoPromote != "" {
+					fmt.Println("    Auto Promote:  ", autoPromote)
+				}
+				fmt.Printf("    Max Parallel:  %.0f\n", getFloat64(update["max_parallel"]))
+				fmt.Printf("    Max Surge:     %.0f\n", getFloat64(update["max_surge"]))
+				if minHealthy, ok := update["min_healthy_time"].(string); ok && minHealthy != "" {
+					fmt.Println("    Min Healthy:   ", minHealthy)
+				}
+				if deadline, ok := update["healthy_deadline"].(string); ok && deadline != "" {
+					fmt.Println("    Deadline:      ", deadline)
+				}
+				autoRevert, _ := update["auto_revert"].(bool)
+				fmt.Println("    Auto Revert:   ", autoRevert)
+			}
+			
 			// Metadata
-			if metadata, ok := job["job_metadata"].(map[string]interface{}); ok && len(metadata) > 0 {
+			if metadata, ok := job["deployment_metadata"].(map[string]interface{}); ok && len(metadata) > 0 {
 				fmt.Println("\n  Metadata:")
 				for k, v := range metadata {
 					fmt.Printf("    %s: %v\n", k, v)
@@ -200,27 +322,7 @@ var describeJobCmd = &cobra.Command{
 		
 		// Events
 		if events, ok := result["events"].([]interface{}); ok && len(events) > 0 {
-			fmt.Println("\nEvents:")
-			for i, event := range events {
-				if i >= 10 { // Limit to last 10 events
-					fmt.Printf("  ... and %d more events\n", len(events)-10)
-					break
-				}
-				eventStr := fmt.Sprintf("%v", event)
-				// Format event timestamp if present
-				if idx := strings.Index(eventStr, "] "); idx > 0 {
-					tsPart := eventStr[:idx+1]
-					msgPart := eventStr[idx+2:]
-					// Try to parse and reformat timestamp
-					tsPart = strings.Trim(tsPart, "[]")
-					if t, err := time.Parse(time.RFC3339Nano, tsPart); err == nil {
-						tsPart = t.Format("2006-01-02 15:04:05")
-					}
-					fmt.Printf("  [%s] %s\n", tsPart, msgPart)
-				} else {
-					fmt.Printf("  %s\n", eventStr)
-				}
-			}
+			printEvents(events)
 		}
 		
 		return nil
@@ -326,33 +428,126 @@ var describeInstanceCmd = &cobra.Command{
 		
 		// Events
 		if events, ok := result["events"].([]interface{}); ok && len(events) > 0 {
-			fmt.Println("\nEvents:")
-			for i, event := range events {
-				if i >= 10 { // Limit to last 10 events
-					fmt.Printf("  ... and %d more events\n", len(events)-10)
-					break
-				}
-				eventStr := fmt.Sprintf("%v", event)
-				// Format event timestamp if present
-				if idx := strings.Index(eventStr, "] "); idx > 0 {
-					tsPart := eventStr[:idx+1]
-					msgPart := eventStr[idx+2:]
-					// Try to parse and reformat timestamp
-					tsPart = strings.Trim(tsPart, "[]")
-					if t, err := time.Parse(time.RFC3339Nano, tsPart); err == nil {
-						tsPart = t.Format("2006-01-02 15:04:05")
-					}
-					fmt.Printf("  [%s] %s\n", tsPart, msgPart)
-				} else {
-					fmt.Printf("  %s\n", eventStr)
-				}
-			}
+			printEvents(events)
 		}
 		
 		return nil
 	},
 }
 
+// printServiceRollout prints the rollout progress and replicas of a service
+func printServiceRollout(result map[string]interface{}) {
+	fmt.Printf("Revision:      %.0f\n", getFloat64(result["revision"]))
+	fmt.Printf("Replicas:      %.0f desired, %.0f healthy\n", getFloat64(result["desired_replicas"]), getFloat64(result["healthy_replicas"]))
+
+	if rollout, ok := result["rollout"].(map[string]interface{}); ok {
+		fmt.Println("\nRollout:")
+		fmt.Println("  Status:        ", rollout["status"])
+		fmt.Printf("  Stable:         revision %.0f\n", getFloat64(rollout["stable_revision"]))
+		fmt.Printf("  Target:         revision %.0f\n", getFloat64(rollout["target_revision"]))
+		if batch := getFloat64(rollout["batch"]); batch > 0 {
+			fmt.Printf("  Batch:          %.0f\n", batch)
+		}
+		if healthyAt, ok := rollout["canary_healthy_at"].(string); ok && rollout["status"] == "canary" && !strings.HasPrefix(healthyAt, "0001-") {
+			fmt.Println("  Canary Healthy:", formatTimestamp(healthyAt))
+		}
+		if message, ok := rollout["message"].(string); ok && message != "" {
+			fmt.Println("  Message:       ", message)
+		}
+		if rollout["status"] == "canary" {
+			fmt.Printf("\nUse 'osctl promote %s' to replace the old replicas or 'osctl abort %s' to roll back.\n",
+				result["deployment_id"], result["deployment_id"])
+		}
+	}
+
+	printReplicas(result)
+}
+
+// printReplicas prints the replica units of a service or batch
+func printReplicas(result map[string]interface{}) {
+	replicas, _ := result["replicas"].([]interface{})
+	if len(replicas) == 0 {
+		return
+	}
+	fmt.Println("\nReplicas:")
+	fmt.Printf("  %-40s %-9s %-12s %-10s %-25s\n", "ID", "REVISION", "STATUS", "HEALTH", "NODE_ID")
+	for _, entry := range replicas {
+		replica, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		status := fmt.Sprintf("%v", replica["status"])
+		if stopping, _ := replica["stopping"].(bool); stopping {
+			status = "stopping"
+		}
+		health, _ := replica["health"].(string)
+		if health == "" {
+			health = "-"
+		}
+		nodeID, _ := replica["node_id"].(string)
+		fmt.Printf("  %-40s %-9.0f %-12s %-10s %-25s\n", replica["deployment_id"], getFloat64(replica["spec_revision"]), status, health, nodeID)
+	}
+}
+
+// printLaunches prints the most recent launches of a periodic deployment
+func printLaunches(result map[string]interface{}) {
+	launches, _ := result["launches"].([]interface{})
+	if len(launches) == 0 {
+		return
+	}
+	fmt.Println("\nLaunches:")
+	fmt.Printf("  %-45s %-22s %-12s %s\n", "ID", "SCHEDULED", "STATUS", "DETAIL")
+	for i, entry := range launches {
+		if i >= 10 {
+			fmt.Printf("  ... and %d more launches\n", len(launches)-10)
+			break
+		}
+		launch, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		id, _ := launch["deployment_id"].(string)
+		if id == "" {
+			id = "-"
+		}
+		detail, _ := launch["detail"].(string)
+		fmt.Printf("  %-45s %-22s %-12v %s\n", id, formatTimestamp(launch["scheduled_at"]), launch["status"], detail)
+	}
+}
+
+// printEvents prints up to the last 10 events of a describe response
+func printEvents(events []interface{}) {
+	fmt.Println("\nEvents:")
+	for i, event := range events {
+		if i >= 10 { // Limit to last 10 events
+			fmt.Printf("  ... and %d more events\n", len(events)-10)
+			break
+		}
+		fmt.Printf("  %s\n", formatEvent(event))
+	}
+}
+
+// formatEvent renders a structured event as "[time] type reason: message".
+// Plain string events from older servers are printed as they are.
+func formatEvent(event interface{}) string {
+	e, ok := event.(map[string]interface{})
+	if !ok {
+		return fmt.Sprintf("%v", event)
+	}
+
+	line := fmt.Sprintf("[%s] %-7s", formatTimestamp(e["time"]), e["type"])
+	if reason, ok := e["reason"].(string); ok && reason != "" {
+		line += " " + reason + ":"
+	}
+	if message, ok := e["message"].(string); ok && message != "" {
+		line += " " + message
+	}
+	if nodeID, ok := e["node_id"].(string); ok && nodeID != "" {
+		line += fmt.Sprintf(" (node: %s)", nodeID)
+	}
+	return line
+}
+
 func init() {
 	rootCmd.AddCommand(describeCmd)
 	describeCmd.AddCommand(describeNodeCmd)
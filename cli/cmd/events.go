@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Show deployment events",
+	Long: `Show events of all deployments, or of a single deployment with --deployment.
+Use -f to keep following new events as they happen.`,
+	Example: `  osctl events
+  osctl events --deployment abc-123 -f
+  osctl events --since 30m --type warning`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		deploymentID, _ := cmd.Flags().GetString("deployment")
+		eventType, _ := cmd.Flags().GetString("type")
+		since, _ := cmd.Flags().GetString("since")
+		follow, _ := cmd.Flags().GetBool("follow")
+
+		query := url.Values{}
+		if deploymentID != "" {
+			query.Set("deployment_id", deploymentID)
+		}
+		if eventType != "" {
+			query.Set("type", eventType)
+		}
+		if since != "" {
+			sinceTime, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+			query.Set("since", sinceTime.Format(time.RFC3339))
+		}
+
+		endpoint := "/events"
+		if len(query) > 0 {
+			endpoint += "?" + query.Encode()
+		}
+		result, err := c.Get(endpoint)
+		if err != nil {
+			return err
+		}
+
+		events, _ := result["events"].([]interface{})
+		if len(events) == 0 && !follow {
+			fmt.Println("No events found")
+			return nil
+		}
+		for _, event := range events {
+			printEventLine(event, deploymentID == "")
+		}
+
+		if !follow {
+			return nil
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		watchQuery := url.Values{"kind": {"event"}}
+		if deploymentID != "" {
+			watchQuery.Set("deployment_id", deploymentID)
+		}
+		revision := int64(getFloat64(result["revision"]))
+
+		err = c.Watch(ctx, watchQuery, revision, func(change *client.Change) {
+			if change.Type != "put" || change.Object == nil {
+				return
+			}
+			if eventType != "" && !strings.EqualFold(fmt.Sprintf("%v", change.Object["type"]), eventType) {
+				return
+			}
+			printEventLine(change.Object, deploymentID == "")
+		}, func() {
+			fmt.Fprintln(os.Stderr, "Event history was compacted while disconnected, some events may have been missed")
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	},
+}
+
+// printEventLine prints an event, prefixed with its deployment ID when events of several deployments are shown
+func printEventLine(event interface{}, withDeployment bool) {
+	if !withDeployment {
+		fmt.Println(formatEvent(event))
+		return
+	}
+	deploymentID := ""
+	if e, ok := event.(map[string]interface{}); ok {
+		deploymentID = fmt.Sprintf("%v", e["deployment_id"])
+	}
+	fmt.Printf("%-36s %s\n", deploymentID, formatEvent(event))
+}
+
+// parseSince accepts either a duration relative to now (e.g. "30m") or an RFC3339 timestamp
+func parseSince(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q, expected a duration (e.g. 30m) or RFC3339 timestamp", value)
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+
+	eventsCmd.Flags().BoolP("follow", "f", false, "Follow new events as they happen")
+	eventsCmd.Flags().String("since", "", "Only show events newer than a duration (e.g. 30m) or RFC3339 timestamp")
+	eventsCmd.Flags().String("deployment", "", "Only show events of this deployment")
+	eventsCmd.Flags().String("type", "", "Only show events of this type (normal, warning)")
+}
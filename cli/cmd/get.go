@@ -25,52 +25,14 @@ var getNodesCmd = &cobra.Command{
 			return fmt.Errorf("failed to load token: %w", err)
 		}
 
-		result, err := c.Get("/nodes")
-		if err != nil {
-			return err
+		endpoint := "/nodes"
+		if pending, _ := cmd.Flags().GetBool("pending"); pending {
+			endpoint = "/nodes?approval=pending"
 		}
 
-		nodes, ok := result["nodes"].([]interface{})
-		if !ok {
-			return fmt.Errorf("invalid response format")
-		}
-
-		if len(nodes) == 0 {
-			fmt.Println("No nodes found")
-			return nil
-		}
-
-		// Print header
-		fmt.Printf("%-35s %-19s %-12s %-10s %-12s\n", "NODE_ID", "LAST_HEARTBEAT", "RAM", "CPU", "DISK")
-		fmt.Println(strings.Repeat("-", 95))
-
-		for _, node := range nodes {
-			nodeMap := node.(map[string]interface{})
-			nodeID := fmt.Sprintf("%v", nodeMap["node_id"])
-			// Truncate long node IDs
-			if len(nodeID) > 33 {
-				nodeID = nodeID[:30] + "..."
-			}
-
-			// Format timestamp
-			lastHeartbeat := formatTimestamp(nodeMap["last_heartbeat"])
-
-			// Format memory
-			ramMB := getFloat64(nodeMap["ram_mb"])
-			ramStr := formatMemory(ramMB)
-
-			// Format CPU
-			cpuCores := getFloat64(nodeMap["cpu_cores"])
-			cpuStr := formatCPU(cpuCores)
-
-			// Format disk
-			diskMB := getFloat64(nodeMap["disk_mb"])
-			diskStr := formatDisk(diskMB)
-
-			fmt.Printf("%-35s %-19s %-12s %-10s %-12s\n", nodeID, lastHeartbeat, ramStr, cpuStr, diskStr)
-		}
-
-		return nil
+		return runWatchable(cmd, c, "node", func() error {
+			return printNodes(c, endpoint)
+		})
 	},
 }
 
@@ -95,116 +57,9 @@ var getJobsCmd = &cobra.Command{
 			endpoint = "/jobs"
 		}
 
-		result, err := c.Get(endpoint)
-		if err != nil {
-			return err
-		}
-
-		// Print summary
-		if queuedCount, ok := result["queued_count"].(float64); ok {
-			fmt.Printf("Queued: %.0f\n", queuedCount)
-		}
-		if activeCount, ok := result["active_count"].(float64); ok {
-			fmt.Printf("Active: %.0f\n", activeCount)
-		}
-		if completedCount, ok := result["completed_count"].(float64); ok {
-			fmt.Printf("Completed: %.0f\n", completedCount)
-		}
-		if failedCount, ok := result["failed_count"].(float64); ok {
-			fmt.Printf("Failed: %.0f\n", failedCount)
-		}
-		fmt.Println()
-
-		// Print jobs
-		if activeJobs, ok := result["active_jobs"].([]interface{}); ok && len(activeJobs) > 0 {
-			fmt.Println("\nACTIVE JOBS:")
-			fmt.Printf("%-35s %-25s %-15s %-19s\n", "JOB_ID", "NODE_ID", "STATUS", "UPDATED_AT")
-			fmt.Println(strings.Repeat("-", 95))
-			for _, job := range activeJobs {
-				jobMap := job.(map[string]interface{})
-				jobID := fmt.Sprintf("%v", jobMap["job_id"])
-				if len(jobID) > 33 {
-					jobID = jobID[:30] + "..."
-				}
-				nodeID := fmt.Sprintf("%v", jobMap["node_id"])
-				if len(nodeID) > 23 {
-					nodeID = nodeID[:20] + "..."
-				}
-				status := fmt.Sprintf("%v", jobMap["status"])
-				updatedAt := formatTimestamp(jobMap["updated_at"])
-				fmt.Printf("%-35s %-25s %-15s %-19s\n", jobID, nodeID, status, updatedAt)
-			}
-			fmt.Println()
-		}
-
-		if queuedJobs, ok := result["queued_jobs"].([]interface{}); ok && len(queuedJobs) > 0 {
-			fmt.Println("\nQUEUED JOBS:")
-			fmt.Printf("%-35s %-50s\n", "JOB_ID", "JOB_NAME")
-			fmt.Println(strings.Repeat("-", 90))
-			for _, job := range queuedJobs {
-				jobMap := job.(map[string]interface{})
-				jobID := fmt.Sprintf("%v", jobMap["job_id"])
-				if len(jobID) > 33 {
-					jobID = jobID[:30] + "..."
-				}
-				jobName := ""
-				if jobData, ok := jobMap["job"].(map[string]interface{}); ok {
-					jobName = fmt.Sprintf("%v", jobData["job_name"])
-				}
-				if len(jobName) > 48 {
-					jobName = jobName[:45] + "..."
-				}
-				fmt.Printf("%-35s %-50s\n", jobID, jobName)
-			}
-			fmt.Println()
-		}
-
-		if completedJobs, ok := result["completed_jobs"].([]interface{}); ok && len(completedJobs) > 0 {
-			fmt.Println("\nCOMPLETED JOBS:")
-			fmt.Printf("%-35s %-25s %-15s %-19s\n", "JOB_ID", "NODE_ID", "STATUS", "UPDATED_AT")
-			fmt.Println(strings.Repeat("-", 95))
-			for _, job := range completedJobs {
-				jobMap := job.(map[string]interface{})
-				jobID := fmt.Sprintf("%v", jobMap["job_id"])
-				if len(jobID) > 33 {
-					jobID = jobID[:30] + "..."
-				}
-				nodeID := fmt.Sprintf("%v", jobMap["node_id"])
-				if len(nodeID) > 23 {
-					nodeID = nodeID[:20] + "..."
-				}
-				status := fmt.Sprintf("%v", jobMap["status"])
-				updatedAt := formatTimestamp(jobMap["updated_at"])
-				fmt.Printf("%-35s %-25s %-15s %-19s\n", jobID, nodeID, status, updatedAt)
-			}
-			fmt.Println()
-		}
-
-		if failedJobs, ok := result["failed_jobs"].([]interface{}); ok && len(failedJobs) > 0 {
-			fmt.Println("\nFAILED JOBS:")
-			fmt.Printf("%-35s %-25s %-15s %-40s\n", "JOB_ID", "NODE_ID", "STATUS", "DETAIL")
-			fmt.Println(strings.Repeat("-", 120))
-			for _, job := range failedJobs {
-				jobMap := job.(map[string]interface{})
-				jobID := fmt.Sprintf("%v", jobMap["job_id"])
-				if len(jobID) > 33 {
-					jobID = jobID[:30] + "..."
-				}
-				nodeID := fmt.Sprintf("%v", jobMap["node_id"])
-				if len(nodeID) > 23 {
-					nodeID = nodeID[:20] + "..."
-				}
-				status := fmt.Sprintf("%v", jobMap["status"])
-				detail := fmt.Sprintf("%v", jobMap["detail"])
-				if len(detail) > 38 {
-					detail = detail[:35] + "..."
-				}
-				fmt.Printf("%-35s %-25s %-15s %-40s\n", jobID, nodeID, status, detail)
-			}
-			fmt.Println()
-		}
-
-		return nil
+		return runWatchable(cmd, c, "deployment", func() error {
+			return printJobs(c, endpoint)
+		})
 	},
 }
 
@@ -218,45 +73,219 @@ var getInstancesCmd = &cobra.Command{
 			return fmt.Errorf("failed to load token: %w", err)
 		}
 
-		result, err := c.Get("/instances")
-		if err != nil {
-			return err
-		}
+		return runWatchable(cmd, c, "instance", func() error {
+			return printInstances(c)
+		})
+	},
+}
+
+func printNodes(c *client.Client, endpoint string) error {
+	result, err := c.Get(endpoint)
+	if err != nil {
+		return err
+	}
 
-		instances, ok := result["instances"].([]interface{})
-		if !ok {
-			return fmt.Errorf("invalid response format")
+	nodes, ok := result["nodes"].([]interface{})
+	if !ok {
+		return fmt.Errorf("invalid response format")
+	}
+
+	if len(nodes) == 0 {
+		fmt.Println("No nodes found")
+		return nil
+	}
+
+	// Print header
+	fmt.Printf("%-35s %-15s %-19s %-12s %-10s %-12s\n", "NODE_ID", "STATUS", "LAST_HEARTBEAT", "RAM", "CPU", "DISK")
+	fmt.Println(strings.Repeat("-", 111))
+
+	for _, node := range nodes {
+		nodeMap := node.(map[string]interface{})
+		nodeID := fmt.Sprintf("%v", nodeMap["node_id"])
+		// Truncate long node IDs
+		if len(nodeID) > 33 {
+			nodeID = nodeID[:30] + "..."
 		}
 
-		if len(instances) == 0 {
-			fmt.Println("No instances found")
-			return nil
+		// Format timestamp
+		lastHeartbeat := formatTimestamp(nodeMap["last_heartbeat"])
+
+		// Format memory
+		ramMB := getFloat64(nodeMap["ram_mb"])
+		ramStr := formatMemory(ramMB)
+
+		// Format CPU
+		cpuCores := getFloat64(nodeMap["cpu_cores"])
+		cpuStr := formatCPU(cpuCores)
+
+		// Format disk
+		diskMB := getFloat64(nodeMap["disk_mb"])
+		diskStr := formatDisk(diskMB)
+
+		// Approval comes first, a pending node gets nothing either way
+		status, _ := nodeMap["approval"].(string)
+		if decommissioned, _ := nodeMap["decommissioned"].(bool); decommissioned && status == "approved" {
+			status = "decommissioned"
 		}
 
-		fmt.Printf("%-35s %-25s %-15s %-40s\n", "JOB_ID", "INSTANCE_ID", "STATUS", "IMAGE")
-		fmt.Println(strings.Repeat("-", 120))
+		fmt.Printf("%-35s %-15s %-19s %-12s %-10s %-12s\n", nodeID, status, lastHeartbeat, ramStr, cpuStr, diskStr)
+	}
+
+	return nil
+}
+
+func printJobs(c *client.Client, endpoint string) error {
+	result, err := c.Get(endpoint)
+	if err != nil {
+		return err
+	}
+
+	// Print summary
+	if queuedCount, ok := result["queued_count"].(float64); ok {
+		fmt.Printf("Queued: %.0f\n", queuedCount)
+	}
+	if activeCount, ok := result["active_count"].(float64); ok {
+		fmt.Printf("Active: %.0f\n", activeCount)
+	}
+	if completedCount, ok := result["completed_count"].(float64); ok {
+		fmt.Printf("Completed: %.0f\n", completedCount)
+	}
+	if failedCount, ok := result["failed_count"].(float64); ok {
+		fmt.Printf("Failed: %.0f\n", failedCount)
+	}
+	fmt.Println()
 
-		for _, inst := range instances {
-			instMap := inst.(map[string]interface{})
-			jobID := fmt.Sprintf("%v", instMap["job_id"])
+	// Print jobs
+	if activeJobs, ok := result["active_jobs"].([]interface{}); ok && len(activeJobs) > 0 {
+		fmt.Println("\nACTIVE JOBS:")
+		fmt.Printf("%-35s %-25s %-15s %-19s\n", "JOB_ID", "NODE_ID", "STATUS", "UPDATED_AT")
+		fmt.Println(strings.Repeat("-", 95))
+		for _, job := range activeJobs {
+			jobMap := job.(map[string]interface{})
+			jobID := fmt.Sprintf("%v", jobMap["job_id"])
 			if len(jobID) > 33 {
 				jobID = jobID[:30] + "..."
 			}
-			instanceID := fmt.Sprintf("%v", instMap["instance_id"])
-			if len(instanceID) > 23 {
-				instanceID = instanceID[:20] + "..."
+			nodeID := fmt.Sprintf("%v", jobMap["node_id"])
+			if len(nodeID) > 23 {
+				nodeID = nodeID[:20] + "..."
 			}
-			status := fmt.Sprintf("%v", instMap["status"])
-			image := fmt.Sprintf("%v", instMap["image_name"])
-			if len(image) > 38 {
-				image = image[:35] + "..."
+			status := fmt.Sprintf("%v", jobMap["status"])
+			updatedAt := formatTimestamp(jobMap["updated_at"])
+			fmt.Printf("%-35s %-25s %-15s %-19s\n", jobID, nodeID, status, updatedAt)
+		}
+		fmt.Println()
+	}
+
+	if queuedJobs, ok := result["queued_jobs"].([]interface{}); ok && len(queuedJobs) > 0 {
+		fmt.Println("\nQUEUED JOBS:")
+		fmt.Printf("%-35s %-50s\n", "JOB_ID", "JOB_NAME")
+		fmt.Println(strings.Repeat("-", 90))
+		for _, job := range queuedJobs {
+			jobMap := job.(map[string]interface{})
+			jobID := fmt.Sprintf("%v", jobMap["job_id"])
+			if len(jobID) > 33 {
+				jobID = jobID[:30] + "..."
+			}
+			jobName := ""
+			if jobData, ok := jobMap["job"].(map[string]interface{}); ok {
+				jobName = fmt.Sprintf("%v", jobData["job_name"])
 			}
+			if len(jobName) > 48 {
+				jobName = jobName[:45] + "..."
+			}
+			fmt.Printf("%-35s %-50s\n", jobID, jobName)
+		}
+		fmt.Println()
+	}
+
+	if completedJobs, ok := result["completed_jobs"].([]interface{}); ok && len(completedJobs) > 0 {
+		fmt.Println("\nCOMPLETED JOBS:")
+		fmt.Printf("%-35s %-25s %-15s %-19s\n", "JOB_ID", "NODE_ID", "STATUS", "UPDATED_AT")
+		fmt.Println(strings.Repeat("-", 95))
+		for _, job := range completedJobs {
+			jobMap := job.(map[string]interface{})
+			jobID := fmt.Sprintf("%v", jobMap["job_id"])
+			if len(jobID) > 33 {
+				jobID = jobID[:30] + "..."
+			}
+			nodeID := fmt.Sprintf("%v", jobMap["node_id"])
+			if len(nodeID) > 23 {
+				nodeID = nodeID[:20] + "..."
+			}
+			status := fmt.Sprintf("%v", jobMap["status"])
+			updatedAt := formatTimestamp(jobMap["updated_at"])
+			fmt.Printf("%-35s %-25s %-15s %-19s\n", jobID, nodeID, status, updatedAt)
+		}
+		fmt.Println()
+	}
 
-			fmt.Printf("%-35s %-25s %-15s %-40s\n", jobID, instanceID, status, image)
+	if failedJobs, ok := result["failed_jobs"].([]interface{}); ok && len(failedJobs) > 0 {
+		fmt.Println("\nFAILED JOBS:")
+		fmt.Printf("%-35s %-25s %-15s %-40s\n", "JOB_ID", "NODE_ID", "STATUS", "DETAIL")
+		fmt.Println(strings.Repeat("-", 120))
+		for _, job := range failedJobs {
+			jobMap := job.(map[string]interface{})
+			jobID := fmt.Sprintf("%v", jobMap["job_id"])
+			if len(jobID) > 33 {
+				jobID = jobID[:30] + "..."
+			}
+			nodeID := fmt.Sprintf("%v", jobMap["node_id"])
+			if len(nodeID) > 23 {
+				nodeID = nodeID[:20] + "..."
+			}
+			status := fmt.Sprintf("%v", jobMap["status"])
+			detail := fmt.Sprintf("%v", jobMap["detail"])
+			if len(detail) > 38 {
+				detail = detail[:35] + "..."
+			}
+			fmt.Printf("%-35s %-25s %-15s %-40s\n", jobID, nodeID, status, detail)
 		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func printInstances(c *client.Client) error {
+	result, err := c.Get("/instances")
+	if err != nil {
+		return err
+	}
 
+	instances, ok := result["instances"].([]interface{})
+	if !ok {
+		return fmt.Errorf("invalid response format")
+	}
+
+	if len(instances) == 0 {
+		fmt.Println("No instances found")
 		return nil
-	},
+	}
+
+	fmt.Printf("%-35s %-25s %-15s %-40s\n", "JOB_ID", "INSTANCE_ID", "STATUS", "IMAGE")
+	fmt.Println(strings.Repeat("-", 120))
+
+	for _, inst := range instances {
+		instMap := inst.(map[string]interface{})
+		jobID := fmt.Sprintf("%v", instMap["job_id"])
+		if len(jobID) > 33 {
+			jobID = jobID[:30] + "..."
+		}
+		instanceID := fmt.Sprintf("%v", instMap["instance_id"])
+		if len(instanceID) > 23 {
+			instanceID = instanceID[:20] + "..."
+		}
+		status := fmt.Sprintf("%v", instMap["status"])
+		image := fmt.Sprintf("%v", instMap["image_name"])
+		if len(image) > 38 {
+			image = image[:35] + "..."
+		}
+
+		fmt.Printf("%-35s %-25s %-15s %-40s\n", jobID, instanceID, status, image)
+	}
+
+	return nil
 }
 
 func init() {
@@ -265,6 +294,9 @@ func init() {
 	getCmd.AddCommand(getJobsCmd)
 	getCmd.AddCommand(getInstancesCmd)
 
+	getCmd.PersistentFlags().BoolP("watch", "w", false, "Watch for changes and update the output")
+
+	getNodesCmd.Flags().Bool("pending", false, "Show only nodes pending approval")
 	getJobsCmd.Flags().Bool("active", false, "Show only active jobs")
 	getJobsCmd.Flags().Bool("failed", false, "Show only failed jobs")
 }
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/open-scheduler/cli/client"
 	"github.com/spf13/cobra"
@@ -13,7 +15,13 @@ var loginCmd = &cobra.Command{
 	Use:   "login",
 	Short: "Login to Centro server",
 	Long:  "Authenticate with Centro server and save JWT token",
+	Example: `  osctl login -u admin
+  osctl login --sso`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if sso, _ := cmd.Flags().GetBool("sso"); sso {
+			return loginSSO()
+		}
+
 		username, _ := cmd.Flags().GetString("username")
 		password, _ := cmd.Flags().GetString("password")
 		
@@ -46,9 +54,74 @@ var loginCmd = &cobra.Command{
 	},
 }
 
+// loginSSO logs in through the identity provider of Centro with the device
+// flow, so it works without a browser on this machine
+func loginSSO() error {
+	c := client.NewClient(getBaseURL())
+	login, err := c.StartSSOLogin()
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+
+	fmt.Printf("Open %s and enter the code %s\n", login.VerificationURI, login.UserCode)
+	if login.VerificationURIComplete != "" {
+		fmt.Printf("or open %s\n", login.VerificationURIComplete)
+	}
+	fmt.Println("Waiting for the login to finish...")
+
+	interval := time.Duration(login.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(login.ExpiresIn) * time.Second)
+	for login.ExpiresIn <= 0 || time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		resp, err := c.PollSSOLogin(login.DeviceCode)
+		switch {
+		case errors.Is(err, client.ErrAuthorizationPending):
+			continue
+		case errors.Is(err, client.ErrSlowDown):
+			interval += 5 * time.Second
+			continue
+		case err != nil:
+			return fmt.Errorf("login failed: %w", err)
+		}
+
+		fmt.Printf("Login successful as %s! Token saved.\n", resp.Username)
+		return nil
+	}
+	return fmt.Errorf("login failed: the code expired, run osctl login --sso again")
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Log out of Centro server",
+	Long:  "Revoke the saved token at Centro and remove it",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+
+		c := client.NewClient(getBaseURL())
+		if err := c.Logout(all); err != nil {
+			return err
+		}
+
+		if all {
+			fmt.Println("✓ Logged out everywhere")
+		} else {
+			fmt.Println("✓ Logged out")
+		}
+		return nil
+	},
+}
+
 func init() {
+	rootCmd.AddCommand(logoutCmd)
+	logoutCmd.Flags().Bool("all", false, "End every login of your user, not just this one")
+
 	rootCmd.AddCommand(loginCmd)
 	loginCmd.Flags().StringP("username", "u", "", "Username")
 	loginCmd.Flags().StringP("password", "p", "", "Password")
+	loginCmd.Flags().Bool("sso", false, "Log in through the single sign-on provider of Centro")
 }
 
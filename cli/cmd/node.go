@@ -0,0 +1,387 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+)
+
+var decommissionCmd = &cobra.Command{
+	Use:   "decommission NODE_ID",
+	Short: "Stop assigning deployments to a node",
+	Long: `Decommission a node before taking it out of the cluster.
+
+The node gets no new deployments and the replicas of system deployments on it
+are stopped. Other deployments keep running until they finish.
+
+The certificates and credentials of the node are revoked, so its agent can no
+longer reach Centro. To bring the node back, recommission it and give its agent
+a new join token or credential.`,
+	Example: `  osctl decommission node-1`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setNodeDecommissioned(args[0], "decommission")
+	},
+}
+
+var recommissionCmd = &cobra.Command{
+	Use:     "recommission NODE_ID",
+	Short:   "Put a decommissioned node back into service",
+	Example: `  osctl recommission node-1`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setNodeDecommissioned(args[0], "recommission")
+	},
+}
+
+func setNodeDecommissioned(nodeID, action string) error {
+	c := client.NewClient(getBaseURL())
+	if err := c.LoadToken(); err != nil {
+		return fmt.Errorf("failed to load token: %w", err)
+	}
+
+	result, err := c.Post(fmt.Sprintf("/nodes/%s/%s", url.PathEscape(nodeID), action), nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ %s\n", result["message"])
+	return nil
+}
+
+var nodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "Manage nodes",
+}
+
+var nodeCredentialCmd = &cobra.Command{
+	Use:     "credential",
+	Aliases: []string{"credentials"},
+	Short:   "Manage the credentials agents authenticate with",
+	Long: `Manage the credentials agents authenticate with.
+
+Centro only accepts gRPC calls of agents with a client certificate (see
+'osctl node join-token') or a node credential. A credential belongs to one
+node, the agent must run with that node ID and pass the credential with
+--token or the TOKEN environment variable.`,
+}
+
+var nodeCredentialCreateCmd = &cobra.Command{
+	Use:   "create NODE_ID",
+	Short: "Create a credential for a node",
+	Long: `Create a credential for a node. The node does not need to exist yet. The
+credential is printed once and cannot be shown again.`,
+	Example: `  osctl node credential create node-1`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Post(fmt.Sprintf("/nodes/%s/credentials", url.PathEscape(args[0])), nil)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Credential %s created for node %s\n", result["id"], result["node_id"])
+		fmt.Println("Save the credential now, it cannot be shown again:")
+		fmt.Println(result["credential"])
+		return nil
+	},
+}
+
+var nodeCredentialListCmd = &cobra.Command{
+	Use:     "list NODE_ID",
+	Aliases: []string{"ls"},
+	Short:   "List the credentials of a node",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Get(fmt.Sprintf("/nodes/%s/credentials", url.PathEscape(args[0])))
+		if err != nil {
+			return err
+		}
+
+		credentials, _ := result["credentials"].([]interface{})
+		if len(credentials) == 0 {
+			fmt.Println("No credentials found")
+			return nil
+		}
+
+		fmt.Printf("%-16s %-20s %-20s %s\n", "ID", "CREATED", "LAST USED", "CREATED BY")
+		fmt.Println(strings.Repeat("-", 80))
+		for _, item := range credentials {
+			credential, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fmt.Printf("%-16s %-20s %-20s %s\n",
+				credential["id"], formatUserTime(credential["created_at"]), formatUserTime(credential["last_used_at"]), credential["created_by"])
+		}
+		return nil
+	},
+}
+
+var nodeCredentialRevokeCmd = &cobra.Command{
+	Use:   "revoke NODE_ID [CREDENTIAL_ID]",
+	Short: "Revoke one or all credentials of a node",
+	Example: `  osctl node credential revoke node-1 3f9c2a71d04b8e65
+  osctl node credential revoke node-1 --all`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		if all == (len(args) == 2) {
+			return fmt.Errorf("give either a CREDENTIAL_ID or --all")
+		}
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		endpoint := fmt.Sprintf("/nodes/%s/credentials", url.PathEscape(args[0]))
+		if !all {
+			endpoint += "/" + url.PathEscape(args[1])
+		}
+		result, err := c.Delete(endpoint)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		return nil
+	},
+}
+
+var nodeJoinTokenCmd = &cobra.Command{
+	Use:     "join-token",
+	Aliases: []string{"join-tokens"},
+	Short:   "Manage the tokens new nodes join with",
+	Long: `Manage the tokens new nodes join with.
+
+An agent started with --join-token is approved at once, and put in the cluster
+and given the labels of the token. With --ca-file it also exchanges the token
+for a client certificate signed by the CA of Centro, and renews the certificate
+on its own from then on. Nodes that register without a join token wait for
+'osctl node approve'.`,
+}
+
+var nodeJoinTokenCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a join token",
+	Long: `Create a join token. The token is printed once and cannot be shown again.
+It works for --max-uses nodes and expires after 24 hours unless --expires-in
+says otherwise.`,
+	Example: `  osctl node join-token create --description "rack 4" --expires-in 1h
+  osctl node join-token create --cluster production --label zone=eu-1 --label gpu=true --max-uses 10`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		description, _ := cmd.Flags().GetString("description")
+		expiresIn, _ := cmd.Flags().GetString("expires-in")
+		cluster, _ := cmd.Flags().GetString("cluster")
+		labels, _ := cmd.Flags().GetStringToString("label")
+		maxUses, _ := cmd.Flags().GetInt("max-uses")
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Post("/join-tokens", map[string]interface{}{
+			"description": description,
+			"cluster":     cluster,
+			"labels":      labels,
+			"max_uses":    maxUses,
+			"expires_in":  expiresIn,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Join token %s created for %.0f node(s)\n", result["id"], getFloat64(result["max_uses"]))
+		fmt.Printf("Expires: %s\n", formatUserTime(result["expires_at"]))
+		fmt.Println("Save the join token now, it cannot be shown again:")
+		fmt.Println(result["token"])
+		return nil
+	},
+}
+
+var nodeJoinTokenListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List join tokens that have not expired",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Get("/join-tokens")
+		if err != nil {
+			return err
+		}
+
+		tokens, _ := result["join_tokens"].([]interface{})
+		if len(tokens) == 0 {
+			fmt.Println("No join tokens found")
+			return nil
+		}
+
+		fmt.Printf("%-16s %-8s %-6s %-20s %-16s %s\n", "ID", "STATUS", "USES", "EXPIRES", "CLUSTER", "DESCRIPTION")
+		fmt.Println(strings.Repeat("-", 90))
+		for _, item := range tokens {
+			token, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			cluster, _ := token["cluster"].(string)
+			if cluster == "" {
+				cluster = "-"
+			}
+			uses := fmt.Sprintf("%.0f/%.0f", getFloat64(token["uses"]), getFloat64(token["max_uses"]))
+			fmt.Printf("%-16s %-8s %-6s %-20s %-16s %s\n",
+				token["id"], token["status"], uses, formatUserTime(token["expires_at"]), cluster, token["description"])
+		}
+		return nil
+	},
+}
+
+var nodeJoinTokenDeleteCmd = &cobra.Command{
+	Use:     "delete ID",
+	Aliases: []string{"rm"},
+	Short:   "Delete a join token",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Delete("/join-tokens/" + url.PathEscape(args[0]))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		return nil
+	},
+}
+
+var nodeApproveCmd = &cobra.Command{
+	Use:   "approve NODE_ID",
+	Short: "Let a node that registered without a join token get deployments",
+	Long: `Approve a node. Nodes that register without a join token are pending until
+they are approved and get no deployments. --cluster and --label assign the
+node to a cluster and replace its labels, whatever its agent reports.`,
+	Example: `  osctl node approve node-1
+  osctl node approve node-1 --cluster production --label zone=eu-1`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cluster, _ := cmd.Flags().GetString("cluster")
+		body := map[string]interface{}{
+			"cluster": cluster,
+		}
+		if cmd.Flags().Changed("label") {
+			labels, _ := cmd.Flags().GetStringToString("label")
+			body["labels"] = labels
+		}
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Post(fmt.Sprintf("/nodes/%s/approve", url.PathEscape(args[0])), body)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		return nil
+	},
+}
+
+var nodeRejectCmd = &cobra.Command{
+	Use:   "reject NODE_ID",
+	Short: "Keep a node from getting deployments and revoke its credentials",
+	Long: `Reject a node. It never gets deployments, its heartbeats are refused and its
+certificates and credentials are revoked. Approving it later undoes this, but
+its agent needs a new join token or credential.`,
+	Example: `  osctl node reject node-1`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Post(fmt.Sprintf("/nodes/%s/reject", url.PathEscape(args[0])), nil)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		return nil
+	},
+}
+
+var nodeCACmd = &cobra.Command{
+	Use:   "ca",
+	Short: "Print the CA certificate agents verify Centro with",
+	Long: `Print the CA certificate of Centro in PEM. Save it on new nodes and pass it
+to the agent with --ca-file.`,
+	Example: `  osctl node ca > centro-ca.crt`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Get("/pki/ca")
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(result["certificate"])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(decommissionCmd)
+	rootCmd.AddCommand(recommissionCmd)
+
+	rootCmd.AddCommand(nodeCmd)
+	nodeCmd.AddCommand(nodeCredentialCmd)
+	nodeCredentialCmd.AddCommand(nodeCredentialCreateCmd)
+	nodeCredentialCmd.AddCommand(nodeCredentialListCmd)
+	nodeCredentialCmd.AddCommand(nodeCredentialRevokeCmd)
+
+	nodeCmd.AddCommand(nodeJoinTokenCmd)
+	nodeJoinTokenCmd.AddCommand(nodeJoinTokenCreateCmd)
+	nodeJoinTokenCmd.AddCommand(nodeJoinTokenListCmd)
+	nodeJoinTokenCmd.AddCommand(nodeJoinTokenDeleteCmd)
+	nodeCmd.AddCommand(nodeCACmd)
+	nodeCmd.AddCommand(nodeApproveCmd)
+	nodeCmd.AddCommand(nodeRejectCmd)
+
+	nodeCredentialRevokeCmd.Flags().Bool("all", false, "Revoke every credential of the node")
+	nodeJoinTokenCreateCmd.Flags().String("description", "", "What the join token is for")
+	nodeJoinTokenCreateCmd.Flags().String("expires-in", "", "Lifetime like 1h or 7d (default: 24h)")
+	nodeJoinTokenCreateCmd.Flags().String("cluster", "", "Cluster the nodes are put in")
+	nodeJoinTokenCreateCmd.Flags().StringToString("label", nil, "Label of the nodes like zone=eu-1 (repeatable)")
+	nodeJoinTokenCreateCmd.Flags().Int("max-uses", 1, "How many nodes can join with the token")
+
+	nodeApproveCmd.Flags().String("cluster", "", "Cluster the node is put in")
+	nodeApproveCmd.Flags().StringToString("label", nil, "Label of the node like zone=eu-1 (repeatable), replaces its labels")
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote JOB_ID",
+	Short: "Promote the canaries of a service",
+	Long: `Let a canary or blue/green rollout of a service replace its old replicas.
+
+The canaries must be waiting to be promoted, see 'osctl describe job JOB_ID'.`,
+	Example: `  osctl promote web`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return requestServiceCommand(args[0], "promote")
+	},
+}
+
+var abortCmd = &cobra.Command{
+	Use:   "abort JOB_ID",
+	Short: "Abort the rollout of a service",
+	Long: `Stop the rollout of a service and restore the spec of its last stable
+revision. The restored spec is stored as a new revision.`,
+	Example: `  osctl abort web`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return requestServiceCommand(args[0], "abort")
+	},
+}
+
+// requestServiceCommand asks centro to promote or abort the rollout of a service
+func requestServiceCommand(jobID, action string) error {
+	c := client.NewClient(getBaseURL())
+	if err := c.LoadToken(); err != nil {
+		return fmt.Errorf("failed to load token: %w", err)
+	}
+
+	result, err := c.Post(fmt.Sprintf("/deployments/%s/%s", url.PathEscape(jobID), action), nil)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ %s\n", result["message"])
+	fmt.Printf("\nUse 'osctl describe job %s' to follow the rollout.\n", jobID)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+	rootCmd.AddCommand(abortCmd)
+}
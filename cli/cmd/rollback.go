@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback JOB_ID",
+	Short: "Restore an earlier revision of a job",
+	Long: `Restore the spec of an earlier revision of a job as a new revision.
+
+Without --revision the previous revision is restored. Services roll the
+restored spec out with their current update strategy. Use
+'osctl get revisions JOB_ID' to list the revisions of a job.`,
+	Example: `  osctl rollback abc-123
+  osctl rollback abc-123 --revision 2`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID := args[0]
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		endpoint := fmt.Sprintf("/deployments/%s/rollback", url.PathEscape(jobID))
+		if revision, _ := cmd.Flags().GetInt64("revision"); revision > 0 {
+			endpoint += fmt.Sprintf("?revision=%d", revision)
+		}
+
+		result, err := c.Post(endpoint, nil)
+		if err != nil {
+			return err
+		}
+
+		if changed, ok := result["changed"].(bool); ok && !changed {
+			fmt.Printf("✓ %s, nothing changed.\n", result["message"])
+			return nil
+		}
+
+		fmt.Printf("✓ Job %s rolled back to revision %.0f as revision %.0f\n", jobID,
+			getFloat64(result["rollback_of"]), getFloat64(result["revision"]))
+		if changes, ok := result["changes"].([]interface{}); ok && len(changes) > 0 {
+			fmt.Println("\nChanges:")
+			for _, entry := range changes {
+				change, ok := entry.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				fmt.Printf("  %s: %s -> %s\n", change["field"], formatChangeValue(change["old"]), formatChangeValue(change["new"]))
+			}
+		}
+		fmt.Printf("\nUse 'osctl describe job %s' to follow the rollout.\n", jobID)
+		return nil
+	},
+}
+
+var getRevisionsCmd = &cobra.Command{
+	Use:     "revisions JOB_ID",
+	Aliases: []string{"revision", "rev"},
+	Short:   "List the revisions of a job",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Get(fmt.Sprintf("/deployments/%s/revisions", url.PathEscape(args[0])))
+		if err != nil {
+			return err
+		}
+
+		revisions, _ := result["revisions"].([]interface{})
+		if len(revisions) == 0 {
+			fmt.Println("No revisions found")
+			return nil
+		}
+
+		fmt.Printf("%-9s %-12s %-15s %-19s %s\n", "REVISION", "STATUS", "AUTHOR", "CREATED_AT", "CHANGES")
+		fmt.Println(strings.Repeat("-", 95))
+		for _, entry := range revisions {
+			revision, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			author, _ := revision["author"].(string)
+			if author == "" {
+				author = "-"
+			}
+			fmt.Printf("%-9.0f %-12s %-15s %-19s %s\n", getFloat64(revision["revision"]), revision["status"],
+				author, formatTimestamp(revision["created_at"]), revisionSummary(revision))
+		}
+		return nil
+	},
+}
+
+// revisionSummary names the fields a revision changed, or the revision it restored
+func revisionSummary(revision map[string]interface{}) string {
+	var summary []string
+	if rollbackOf := getFloat64(revision["rollback_of"]); rollbackOf > 0 {
+		summary = append(summary, fmt.Sprintf("rollback to %.0f", rollbackOf))
+	}
+	changes, _ := revision["changes"].([]interface{})
+	fields := make([]string, 0, len(changes))
+	for _, entry := range changes {
+		if change, ok := entry.(map[string]interface{}); ok {
+			fields = append(fields, fmt.Sprintf("%v", change["field"]))
+		}
+	}
+	if len(fields) > 0 {
+		summary = append(summary, strings.Join(fields, ", "))
+	}
+	if len(summary) == 0 {
+		if getFloat64(revision["revision"]) == 1 {
+			return "initial spec"
+		}
+		return "-"
+	}
+	return strings.Join(summary, ": ")
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+	getCmd.AddCommand(getRevisionsCmd)
+	rollbackCmd.Flags().Int64("revision", 0, "Revision to restore (default: the previous revision)")
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+)
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage secrets used by deployments",
+	Long: `Manage secrets used by deployments.
+
+Secrets are stored encrypted by Centro and are never shown again. Deployments
+reference them by name in their secrets list, as an environment variable or as
+a file mounted into the instance:
+
+  secrets:
+    - secret: db-password
+      env: DB_PASSWORD
+    - secret: tls-key
+      file: /etc/nginx/tls/key.pem`,
+}
+
+// secretValue reads the value of a secret from the flags, or asks for it
+func secretValue(cmd *cobra.Command) (map[string]interface{}, error) {
+	literal, _ := cmd.Flags().GetString("from-literal")
+	file, _ := cmd.Flags().GetString("from-file")
+
+	switch {
+	case literal != "" && file != "":
+		return nil, fmt.Errorf("use either --from-literal or --from-file")
+	case literal != "":
+		return map[string]interface{}{"value": literal}, nil
+	case file == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret from stdin: %w", err)
+		}
+		return map[string]interface{}{"value_base64": base64.StdEncoding.EncodeToString(data)}, nil
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secret file: %w", err)
+		}
+		return map[string]interface{}{"value_base64": base64.StdEncoding.EncodeToString(data)}, nil
+	}
+
+	value, err := readPassword("Secret value: ")
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, fmt.Errorf("secret value cannot be empty")
+	}
+	return map[string]interface{}{"value": value}, nil
+}
+
+var secretCreateCmd = &cobra.Command{
+	Use:   "create NAME",
+	Short: "Create a secret",
+	Long: `Create a secret. The value is taken from --from-literal or --from-file,
+or asked for when neither is given. Prefer --from-file or the prompt, literals
+end up in the shell history.`,
+	Example: `  osctl secret create db-password
+  osctl secret create tls-key --from-file ./key.pem --description "Key of www.example.com"
+  vault read -field=token secret/ci | osctl secret create ci-token --from-file -`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		body, err := secretValue(cmd)
+		if err != nil {
+			return err
+		}
+		body["name"] = args[0]
+		body["description"], _ = cmd.Flags().GetString("description")
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Post("/secrets", body)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Secret %s created\n", result["name"])
+		return nil
+	},
+}
+
+var secretUpdateCmd = &cobra.Command{
+	Use:   "update NAME",
+	Short: "Replace the value of a secret",
+	Long: `Replace the value of a secret. Running instances keep the old value until
+they are replaced, for example by updating their deployment.`,
+	Example: `  osctl secret update db-password
+  osctl secret update tls-key --from-file ./key.pem`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		body, err := secretValue(cmd)
+		if err != nil {
+			return err
+		}
+		if description, _ := cmd.Flags().GetString("description"); description != "" {
+			body["description"] = description
+		}
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Put(fmt.Sprintf("/secrets/%s", url.PathEscape(args[0])), body)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Secret %s updated to version %.0f\n", result["name"], getFloat64(result["version"]))
+		return nil
+	},
+}
+
+var secretListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List secrets and the deployments using them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Get("/secrets")
+		if err != nil {
+			return err
+		}
+
+		secrets, _ := result["secrets"].([]interface{})
+		if len(secrets) == 0 {
+			fmt.Println("No secrets found")
+			return nil
+		}
+
+		fmt.Printf("%-28s %-8s %-16s %-20s %-24s %s\n", "NAME", "VERSION", "UPDATED BY", "UPDATED", "USED BY", "DESCRIPTION")
+		fmt.Println(strings.Repeat("-", 120))
+		for _, item := range secrets {
+			secret, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			usedBy := "-"
+			if list, ok := secret["used_by"].([]interface{}); ok && len(list) > 0 {
+				usedBy = joinValues(list)
+			}
+			fmt.Printf("%-28s %-8.0f %-16s %-20s %-24s %s\n",
+				secret["name"], getFloat64(secret["version"]), secret["updated_by"],
+				formatUserTime(secret["updated_at"]), usedBy, secret["description"])
+		}
+		return nil
+	},
+}
+
+var secretDeleteCmd = &cobra.Command{
+	Use:     "delete NAME",
+	Aliases: []string{"rm"},
+	Short:   "Delete a secret that no deployment uses",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Delete(fmt.Sprintf("/secrets/%s", url.PathEscape(args[0])))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(secretCmd)
+	secretCmd.AddCommand(secretCreateCmd)
+	secretCmd.AddCommand(secretUpdateCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretDeleteCmd)
+
+	for _, command := range []*cobra.Command{secretCreateCmd, secretUpdateCmd} {
+		command.Flags().String("from-literal", "", "Value of the secret")
+		command.Flags().String("from-file", "", "File with the value of the secret, - for stdin")
+		command.Flags().String("description", "", "What the secret is used for")
+	}
+}
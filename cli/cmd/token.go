@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+)
+
+var serviceAccountCmd = &cobra.Command{
+	Use:     "serviceaccount",
+	Aliases: []string{"sa"},
+	Short:   "Manage service accounts for automation",
+	Long: `Manage service accounts for automation.
+
+Service accounts cannot log in with a password. They authenticate with API
+tokens created by 'osctl token create'.`,
+}
+
+var serviceAccountCreateCmd = &cobra.Command{
+	Use:     "create NAME",
+	Short:   "Create a service account",
+	Example: `  osctl serviceaccount create ci --role operator --description "GitHub Actions"`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		role, _ := cmd.Flags().GetString("role")
+		description, _ := cmd.Flags().GetString("description")
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Post("/serviceaccounts", map[string]interface{}{
+			"name":        args[0],
+			"description": description,
+			"role":        role,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Service account %s created with role %s\n", result["name"], result["role"])
+		return nil
+	},
+}
+
+var serviceAccountListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List service accounts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Get("/serviceaccounts")
+		if err != nil {
+			return err
+		}
+
+		accounts, _ := result["service_accounts"].([]interface{})
+		if len(accounts) == 0 {
+			fmt.Println("No service accounts found")
+			return nil
+		}
+
+		fmt.Printf("%-24s %-12s %-20s %s\n", "NAME", "ROLE", "CREATED", "DESCRIPTION")
+		fmt.Println(strings.Repeat("-", 80))
+		for _, item := range accounts {
+			account, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fmt.Printf("%-24s %-12s %-20s %s\n",
+				account["name"], account["role"], formatUserTime(account["created_at"]), account["description"])
+		}
+		return nil
+	},
+}
+
+var serviceAccountDeleteCmd = &cobra.Command{
+	Use:     "delete NAME",
+	Aliases: []string{"rm"},
+	Short:   "Delete a service account and its tokens",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Delete(fmt.Sprintf("/serviceaccounts/%s", url.PathEscape(args[0])))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		return nil
+	},
+}
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage API tokens of service accounts",
+	Long: `Manage API tokens of service accounts.
+
+Pass a token to osctl in the OSCTL_TOKEN environment variable, or to the REST
+API as "Authorization: Bearer <token>".`,
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create NAME",
+	Short: "Create an API token for a service account",
+	Long: `Create an API token for a service account. The token is printed once and
+cannot be shown again.`,
+	Example: `  osctl token create github-actions --service-account ci --expires-in 90d
+  osctl token create deploy-only --service-account ci --scope deployments:create --scope deployments:get`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		account, _ := cmd.Flags().GetString("service-account")
+		expiresIn, _ := cmd.Flags().GetString("expires-in")
+		scopes, _ := cmd.Flags().GetStringSlice("scope")
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Post("/tokens", map[string]interface{}{
+			"service_account": account,
+			"name":            args[0],
+			"expires_in":      expiresIn,
+			"scopes":          scopes,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Token %s (%s) created for service account %s\n", result["id"], result["name"], result["service_account"])
+		if expiresAt, ok := result["expires_at"].(string); ok {
+			fmt.Printf("Expires: %s\n", formatUserTime(expiresAt))
+		}
+		fmt.Println("Save the token now, it cannot be shown again:")
+		fmt.Println(result["token"])
+		return nil
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List API tokens",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		account, _ := cmd.Flags().GetString("service-account")
+		status, _ := cmd.Flags().GetString("status")
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		query := url.Values{}
+		if account != "" {
+			query.Set("service_account", account)
+		}
+		if status != "" {
+			query.Set("status", status)
+		}
+		endpoint := "/tokens"
+		if len(query) > 0 {
+			endpoint += "?" + query.Encode()
+		}
+		result, err := c.Get(endpoint)
+		if err != nil {
+			return err
+		}
+
+		tokens, _ := result["tokens"].([]interface{})
+		if len(tokens) == 0 {
+			fmt.Println("No tokens found")
+			return nil
+		}
+
+		fmt.Printf("%-16s %-20s %-16s %-8s %-20s %-20s %s\n", "ID", "NAME", "SERVICE ACCOUNT", "STATUS", "EXPIRES", "LAST USED", "SCOPES")
+		fmt.Println(strings.Repeat("-", 120))
+		for _, item := range tokens {
+			token, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			scopes := "all"
+			if list, ok := token["scopes"].([]interface{}); ok && len(list) > 0 {
+				scopes = joinValues(list)
+			}
+			expires := "never"
+			if token["expires_at"] != nil {
+				expires = formatUserTime(token["expires_at"])
+			}
+			fmt.Printf("%-16s %-20s %-16s %-8s %-20s %-20s %s\n",
+				token["id"], token["name"], token["service_account"], token["status"],
+				expires, formatUserTime(token["last_used_at"]), scopes)
+		}
+		return nil
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:     "revoke TOKEN_ID",
+	Short:   "Revoke an API token",
+	Example: `  osctl token revoke 3f9c2a71d04b8e65`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Delete(fmt.Sprintf("/tokens/%s", url.PathEscape(args[0])))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serviceAccountCmd)
+	serviceAccountCmd.AddCommand(serviceAccountCreateCmd)
+	serviceAccountCmd.AddCommand(serviceAccountListCmd)
+	serviceAccountCmd.AddCommand(serviceAccountDeleteCmd)
+
+	serviceAccountCreateCmd.Flags().String("role", "", "Role of the service account: viewer, operator, admin or a custom role")
+	serviceAccountCreateCmd.Flags().String("description", "", "What the service account is used for")
+	serviceAccountCreateCmd.MarkFlagRequired("role")
+
+	rootCmd.AddCommand(tokenCmd)
+	tokenCmd.AddCommand(tokenCreateCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+
+	tokenCreateCmd.Flags().String("service-account", "", "Service account the token belongs to")
+	tokenCreateCmd.Flags().String("expires-in", "", "Lifetime like 720h or 90d (default: no expiry)")
+	tokenCreateCmd.Flags().StringSlice("scope", nil, "Limit the token to a permission like deployments:create (repeatable)")
+	tokenCreateCmd.MarkFlagRequired("service-account")
+	tokenListCmd.Flags().String("service-account", "", "Only tokens of this service account")
+	tokenListCmd.Flags().String("status", "", "Only tokens with this status (active, expired, revoked)")
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage the users that can log in to Centro",
+	Long: `Manage the users that can log in to Centro.
+
+Managing users needs the users permissions of the admin role. Every user can
+change their own password with 'osctl user passwd'.`,
+}
+
+var userCreateCmd = &cobra.Command{
+	Use:   "create USERNAME",
+	Short: "Create a user",
+	Example: `  osctl user create alice
+  osctl user create bob --role operator`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		role, _ := cmd.Flags().GetString("role")
+		password, _ := cmd.Flags().GetString("password")
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		if password == "" {
+			var err error
+			password, err = readNewPassword()
+			if err != nil {
+				return err
+			}
+		}
+
+		result, err := c.Post("/users", map[string]interface{}{
+			"username": args[0],
+			"password": password,
+			"role":     role,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ User %s created with role %s\n", result["username"], result["role"])
+		return nil
+	},
+}
+
+var userPasswdCmd = &cobra.Command{
+	Use:   "passwd [USERNAME]",
+	Short: "Change the password of a user",
+	Long: `Change the password of a user. Without USERNAME your own password is changed
+and the current password is asked for. Admins can set the password of any user.`,
+	Example: `  osctl user passwd
+  osctl user passwd alice`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, _ := cmd.Flags().GetString("password")
+		currentPassword, _ := cmd.Flags().GetString("current-password")
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		self := c.Username()
+		username := self
+		if len(args) > 0 {
+			username = args[0]
+		}
+		if username == "" {
+			return fmt.Errorf("not authenticated. Please login first")
+		}
+
+		if username == self && currentPassword == "" {
+			var err error
+			currentPassword, err = readPassword("Current password: ")
+			if err != nil {
+				return err
+			}
+		}
+		if password == "" {
+			var err error
+			password, err = readNewPassword()
+			if err != nil {
+				return err
+			}
+		}
+
+		result, err := c.Put(fmt.Sprintf("/users/%s/password", url.PathEscape(username)), map[string]interface{}{
+			"password":         password,
+			"current_password": currentPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		if username == self {
+			fmt.Println("Other sessions keep working until their token expires.")
+		}
+		return nil
+	},
+}
+
+var userDeleteCmd = &cobra.Command{
+	Use:     "delete USERNAME",
+	Aliases: []string{"rm"},
+	Short:   "Delete a user",
+	Example: `  osctl user delete alice`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Delete(fmt.Sprintf("/users/%s", url.PathEscape(args[0])))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		return nil
+	},
+}
+
+var userSetRoleCmd = &cobra.Command{
+	Use:   "set-role USERNAME ROLE",
+	Short: "Change the role of a user",
+	Long: `Assign a built-in (viewer, operator, admin) or custom role to a user. The user
+gets the new role when their token is next refreshed, within 15 minutes.`,
+	Example: `  osctl user set-role alice operator`,
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Put(fmt.Sprintf("/users/%s/role", url.PathEscape(args[0])), map[string]interface{}{
+			"role": args[1],
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n", result["message"])
+		return nil
+	},
+}
+
+var userListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List users",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+
+		result, err := c.Get("/users")
+		if err != nil {
+			return err
+		}
+
+		users, _ := result["users"].([]interface{})
+		if len(users) == 0 {
+			fmt.Println("No users found")
+			return nil
+		}
+
+		fmt.Printf("%-24s %-12s %-22s %s\n", "USERNAME", "ROLE", "CREATED", "PASSWORD CHANGED")
+		fmt.Println(strings.Repeat("-", 80))
+		for _, item := range users {
+			user, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fmt.Printf("%-24s %-12s %-22s %s\n",
+				user["username"], user["role"], formatUserTime(user["created_at"]), formatUserTime(user["password_changed_at"]))
+		}
+		return nil
+	},
+}
+
+func formatUserTime(value interface{}) string {
+	s, _ := value.(string)
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func readPassword(prompt string) (string, error) {
+	fmt.Print(prompt)
+	passwordBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return string(passwordBytes), nil
+}
+
+// readNewPassword asks for a password twice
+func readNewPassword() (string, error) {
+	password, err := readPassword("New password: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := readPassword("Repeat password: ")
+	if err != nil {
+		return "", err
+	}
+	if password != confirm {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return password, nil
+}
+
+func init() {
+	rootCmd.AddCommand(userCmd)
+	userCmd.AddCommand(userCreateCmd)
+	userCmd.AddCommand(userPasswdCmd)
+	userCmd.AddCommand(userSetRoleCmd)
+	userCmd.AddCommand(userDeleteCmd)
+	userCmd.AddCommand(userListCmd)
+
+	userCreateCmd.Flags().String("role", "viewer", "Role of the user: viewer, operator, admin or a custom role")
+	userCreateCmd.Flags().String("password", "", "Password (asked for when not set)")
+	userPasswdCmd.Flags().String("password", "", "New password (asked for when not set)")
+	userPasswdCmd.Flags().String("current-password", "", "Current password, needed to change your own password")
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/open-scheduler/centro/spec"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate a job specification without submitting it",
+	Long:  "Check a job specification YAML file with the same rules the server applies on submit",
+	// A spec with problems is an expected outcome, not a usage mistake
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filePath, _ := cmd.Flags().GetString("f")
+		if filePath == "" {
+			return fmt.Errorf("file path is required. Use -f flag")
+		}
+
+		apiReq, err := loadDeploymentRequest(filePath)
+		if err != nil {
+			return err
+		}
+
+		errs, err := validateDeploymentRequest(apiReq)
+		if err != nil {
+			return err
+		}
+		if errs != nil {
+			printValidationErrors(filePath, errs)
+			return fmt.Errorf("%s is invalid", filePath)
+		}
+
+		fmt.Printf("✓ %s is valid\n", filePath)
+		return nil
+	},
+}
+
+// validateDeploymentRequest runs the server-side validation rules on a submit request
+func validateDeploymentRequest(apiReq map[string]interface{}) (spec.Errors, error) {
+	data, err := json.Marshal(apiReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode spec: %w", err)
+	}
+
+	var req spec.SubmitDeploymentRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("spec does not match the deployment API: %w", err)
+	}
+
+	return spec.ValidateDeployment(req.ToDeployment(req.DeploymentId)), nil
+}
+
+func printValidationErrors(filePath string, errs spec.Errors) {
+	fmt.Printf("✗ %s has %d problem(s):\n", filePath, len(errs))
+	for _, fieldErr := range errs {
+		fmt.Printf("  - %s: %s\n", fieldErr.Field, fieldErr.Message)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringP("f", "f", "", "Path to YAML file")
+	validateCmd.MarkFlagRequired("f")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+)
+
+// redrawDelay collapses bursts of changes (e.g. a deployment moving from the
+// queue to active) into a single redraw
+const redrawDelay = 300 * time.Millisecond
+
+// runWatchable prints a resource once, or with --watch keeps redrawing it
+// whenever the server reports a change of the given kind
+func runWatchable(cmd *cobra.Command, c *client.Client, kind string, render func() error) error {
+	watch, _ := cmd.Flags().GetBool("watch")
+	if !watch {
+		return render()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	redraw := func() {
+		fmt.Print("\033[H\033[2J")
+		if err := render(); err != nil {
+			printError(err)
+		}
+		fmt.Printf("\nLast updated %s. Watching for changes (Ctrl+C to stop)...\n", time.Now().Format("15:04:05"))
+	}
+	redraw()
+
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+				time.Sleep(redrawDelay)
+				select {
+				case <-changed:
+				default:
+				}
+				redraw()
+			}
+		}
+	}()
+
+	err := c.Watch(ctx, url.Values{"kind": {kind}}, 0, func(*client.Change) { notify() }, notify)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/open-scheduler/cli/client"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var workflowCmd = &cobra.Command{
+	Use:   "workflow",
+	Short: "Submit every service of a template.yaml as one workflow",
+	Long: `Submit all services of a template.yaml together. A service can list other
+services of the template in depends_on; it is blocked until they finished
+and fails as upstream_failed if they cannot meet the condition:
+
+  services:
+    - name: "transform"
+      deployment_type: "batch"
+      depends_on:
+        - "extract"                 # must succeed
+        - service: "cleanup-check"
+          condition: "completed"    # succeeded, failed or completed
+
+The project name of the template's metadata becomes the workflow name.`,
+	Example: `  osctl workflow -f etl.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filePath, _ := cmd.Flags().GetString("f")
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+
+		var template map[string]interface{}
+		if err := yaml.Unmarshal(data, &template); err != nil {
+			return fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		services, _ := template["services"].([]interface{})
+		if len(services) == 0 {
+			return fmt.Errorf("%s has no services", filePath)
+		}
+
+		deployments := make([]map[string]interface{}, 0, len(services))
+		for _, entry := range services {
+			service, ok := entry.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: every service must be a map", filePath)
+			}
+			deployments = append(deployments, convertTemplateServiceToAPIRequest(service))
+		}
+		workflowReq := map[string]interface{}{"deployments": deployments}
+		if metadata, ok := template["metadata"].(map[string]interface{}); ok {
+			if project, ok := metadata["project"].(string); ok {
+				workflowReq["workflow_name"] = project
+			}
+		}
+
+		c := client.NewClient(getBaseURL())
+		if err := c.LoadToken(); err != nil {
+			return fmt.Errorf("failed to load token: %w", err)
+		}
+		if key, _ := cmd.Flags().GetString("idempotency-key"); key != "" {
+			c.Headers = map[string]string{"Idempotency-Key": key}
+		}
+
+		result, err := c.Post("/workflows", workflowReq)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ %s\n\n", result["message"])
+		fmt.Println("Workflow ID:  ", result["workflow_id"])
+		if name, ok := result["workflow_name"].(string); ok && name != "" {
+			fmt.Println("Workflow Name:", name)
+		}
+		fmt.Printf("\n  %-38s %-25s %s\n", "JOB ID", "NAME", "DEPENDS ON")
+		submitted, _ := result["deployments"].([]interface{})
+		for _, entry := range submitted {
+			deployment, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			dependsOn := "-"
+			if list, ok := deployment["depends_on"].([]interface{}); ok && len(list) > 0 {
+				dependsOn = fmt.Sprintf("%v", list[0])
+				for _, upstream := range list[1:] {
+					dependsOn += fmt.Sprintf(", %v", upstream)
+				}
+			}
+			fmt.Printf("  %-38v %-25v %s\n", deployment["deployment_id"], deployment["deployment_name"], dependsOn)
+		}
+		fmt.Println("\nUse 'osctl describe job JOB_ID' to follow a step of the workflow.")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workflowCmd)
+	workflowCmd.Flags().StringP("f", "f", "", "Path to template.yaml")
+	workflowCmd.Flags().String("idempotency-key", "", "Key identifying this submission, so that retries return the workflow created by the first attempt")
+	workflowCmd.MarkFlagRequired("f")
+}
@@ -54,7 +54,7 @@ cd ..
 
 
 echo "==> Starting Centro server in background..."
-./centro_server --etcd-endpoints=localhost:2379 > centro_server.log 2>&1 &
+./centro_server --etcd-endpoints=localhost:2379 --dev --grpc-insecure > centro_server.log 2>&1 &
 CENTRO_PID=$!
 echo "Centro server PID: $CENTRO_PID"
 
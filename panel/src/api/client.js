@@ -1,9 +1,50 @@
 import { get } from 'svelte/store';
-import { authStore, logout } from '../stores/authStore';
+import { authStore, logout, setLogin } from '../stores/authStore';
 
 const API_BASE = '/api/v1';
 
-async function request(endpoint, options = {}) {
+// Access tokens are renewed this long before they expire
+const REFRESH_BEFORE_MS = 30 * 1000;
+
+let refreshing = null;
+
+// refreshSession renews the access token with the refresh token. Concurrent
+// callers share one refresh, because every refresh token works only once.
+export function refreshSession() {
+  if (!refreshing) {
+    const { refreshToken } = get(authStore);
+    refreshing = (async () => {
+      if (!refreshToken) {
+        throw new Error('Session expired');
+      }
+      const response = await fetch(`${API_BASE}/auth/refresh`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      });
+      if (!response.ok) {
+        throw new Error('Session expired');
+      }
+      setLogin(await response.json());
+    })().finally(() => {
+      refreshing = null;
+    });
+  }
+  return refreshing;
+}
+
+function tokenExpiring() {
+  const auth = get(authStore);
+  return auth.token && auth.refreshToken && auth.expiresAt - Date.now() < REFRESH_BEFORE_MS;
+}
+
+async function request(endpoint, options = {}, retried = false) {
+  const isAuthEndpoint = endpoint.startsWith('/auth/login') || endpoint.startsWith('/auth/oidc');
+  if (!isAuthEndpoint && tokenExpiring()) {
+    // If this fails the request is sent anyway and a 401 logs out
+    await refreshSession().catch(() => {});
+  }
+
   const auth = get(authStore);
   const headers = {
     'Content-Type': 'application/json',
@@ -23,6 +64,12 @@ async function request(endpoint, options = {}) {
     const response = await fetch(`${API_BASE}${endpoint}`, config);
     
     if (response.status === 401) {
+      if (!retried && !isAuthEndpoint && auth.refreshToken) {
+        const refreshed = await refreshSession().then(() => true, () => false);
+        if (refreshed) {
+          return request(endpoint, options, true);
+        }
+      }
       logout();
       throw new Error('Unauthorized');
     }
@@ -46,6 +93,16 @@ export const auth = {
       method: 'POST',
       body: JSON.stringify({ username, password }),
     }),
+  logout: () =>
+    request('/auth/logout', {
+      method: 'POST',
+      body: JSON.stringify({ refresh_token: get(authStore).refreshToken }),
+    }),
+  oidcConfig: () => request('/auth/oidc/config'),
+  // The browser leaves the panel and comes back to returnTo with the token
+  // in the URL fragment
+  ssoLoginURL: (returnTo) =>
+    `${API_BASE}/auth/oidc/login?return_to=${encodeURIComponent(returnTo)}`,
 };
 
 // Deployments
@@ -85,3 +142,55 @@ export const stats = {
   get: () => request('/stats'),
 };
 
+
+// Watch streams change notifications from /watch (Server-Sent Events).
+// EventSource cannot send headers, so the token is passed as access_token.
+// The browser reconnects on its own and resumes from the last event ID.
+// When the token expired the stream is reopened with a refreshed one.
+// Returns a function that stops watching.
+export function watch(params, onChange) {
+  const kinds = ['deployment', 'instance', 'node', 'event'];
+  let source;
+  let revision = '';
+  let stopped = false;
+
+  const handler = (message) => {
+    revision = message.lastEventId || revision;
+    try {
+      onChange(JSON.parse(message.data));
+    } catch (error) {
+      console.error('Failed to parse watch event:', error);
+    }
+  };
+
+  const open = () => {
+    const auth = get(authStore);
+    const query = new URLSearchParams({ ...params, access_token: auth.token || '' });
+    if (revision) {
+      query.set('revision', revision);
+    }
+    source = new EventSource(`${API_BASE}/watch?${query}`);
+    kinds.forEach((kind) => source.addEventListener(kind, handler));
+    source.onerror = () => {
+      // The browser gives up on responses like 401, it retries the others
+      if (source.readyState === EventSource.CLOSED && !stopped) {
+        refreshSession().then(open, logout);
+      }
+    };
+  };
+  open();
+
+  return () => {
+    stopped = true;
+    source.close();
+  };
+}
+
+// debounce collapses bursts of watch notifications into a single reload
+export function debounce(fn, wait = 500) {
+  let timer;
+  return (...args) => {
+    clearTimeout(timer);
+    timer = setTimeout(() => fn(...args), wait);
+  };
+}
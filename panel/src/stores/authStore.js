@@ -1,7 +1,7 @@
 import { writable } from 'svelte/store';
 
 const storedAuth = localStorage.getItem('auth');
-const initialAuth = storedAuth ? JSON.parse(storedAuth) : { token: null, username: null, expiresAt: null };
+const initialAuth = storedAuth ? JSON.parse(storedAuth) : { token: null, username: null, expiresAt: null, refreshToken: null };
 
 export const authStore = writable(initialAuth);
 
@@ -15,7 +15,17 @@ authStore.subscribe(value => {
   }
 });
 
+// setLogin saves the response of a login or a token refresh
+export function setLogin(response) {
+  authStore.set({
+    token: response.token,
+    username: response.username,
+    expiresAt: Date.now() + (Number(response.expires_in) * 1000),
+    refreshToken: response.refresh_token || null,
+  });
+}
+
 export function logout() {
-  authStore.set({ token: null, username: null, expiresAt: null });
+  authStore.set({ token: null, username: null, expiresAt: null, refreshToken: null });
 }
 
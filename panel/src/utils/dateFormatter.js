@@ -53,12 +53,25 @@ export function formatEventTimestamp(timestamp) {
 }
 
 /**
- * Parse an event string in the format "[timestamp] message"
- * @param {string} event - Event string with timestamp
- * @returns {object} Object with timestamp, message, and formattedTime
+ * Parse a deployment event. Events are structured objects
+ * ({ time, type, reason, message, ... }); older servers return
+ * strings in the format "[timestamp] message".
+ * @param {object|string} event - Event object or legacy event string
+ * @returns {object} Object with timestamp, message, type, reason, and formattedTime
  */
 export function parseEvent(event) {
-  // Parse event format: [2025-11-12T16:31:48+07:00] Message
+  if (event && typeof event === 'object') {
+    const message = event.reason ? `${event.reason}: ${event.message}` : event.message;
+    return {
+      timestamp: event.time,
+      message,
+      type: event.type || 'normal',
+      reason: event.reason || '',
+      formattedTime: event.time ? formatEventTimestamp(event.time) : ''
+    };
+  }
+
+  // Parse legacy event format: [2025-11-12T16:31:48+07:00] Message
   const match = event.match(/\[(.*?)\]\s*(.*)/);
   if (match) {
     const timestamp = match[1];
@@ -66,10 +79,12 @@ export function parseEvent(event) {
     return {
       timestamp,
       message,
+      type: 'normal',
+      reason: '',
       formattedTime: formatEventTimestamp(timestamp)
     };
   }
-  return { timestamp: null, message: event, formattedTime: '' };
+  return { timestamp: null, message: event, type: 'normal', reason: '', formattedTime: '' };
 }
 
 /**
@@ -216,7 +216,7 @@ type Deployment struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	DeploymentId     string                 `protobuf:"bytes,1,opt,name=deployment_id,json=deploymentId,proto3" json:"deployment_id,omitempty"`
 	DeploymentName   string                 `protobuf:"bytes,2,opt,name=deployment_name,json=deploymentName,proto3" json:"deployment_name,omitempty"`
-	DeploymentType   string                 `protobuf:"bytes,3,opt,name=deployment_type,json=deploymentType,proto3" json:"deployment_type,omitempty"`       // Deployment type: "service", "batch", etc.
+	DeploymentType   string                 `protobuf:"bytes,3,opt,name=deployment_type,json=deploymentType,proto3" json:"deployment_type,omitempty"`       // Deployment type: "service", "batch" or "system"
 	SelectedClusters []string               `protobuf:"bytes,4,rep,name=selected_clusters,json=selectedClusters,proto3" json:"selected_clusters,omitempty"` // Clusters where this deployment can run (empty = any cluster)
 	// Deployment execution configuration (merged from Task)
 	DriverType           string            `protobuf:"bytes,5,opt,name=driver_type,json=driverType,proto3" json:"driver_type,omitempty"`             // Driver type: "podman", "incus", "exec"
@@ -242,8 +242,17 @@ type Deployment struct {
 	RestartPolicy *RestartPolicy      `protobuf:"bytes,22,opt,name=restart_policy,json=restartPolicy,proto3" json:"restart_policy,omitempty"` // Restart policy for failed instances
 	Networks      []*NetworkReference `protobuf:"bytes,23,rep,name=networks,proto3" json:"networks,omitempty"`                                // Network assignments
 	InstanceType  string              `protobuf:"bytes,24,opt,name=instance_type,json=instanceType,proto3" json:"instance_type,omitempty"`    // Instance type: "virtual-machine", "container" (for Incus)
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	// Rolling updates of service deployments
+	Update             *UpdateStrategy    `protobuf:"bytes,26,opt,name=update,proto3" json:"update,omitempty"`                                                     // How running replicas are replaced when the spec changes
+	ParentDeploymentId string             `protobuf:"bytes,27,opt,name=parent_deployment_id,json=parentDeploymentId,proto3" json:"parent_deployment_id,omitempty"` // Deployment this replica unit belongs to (empty for deployments submitted directly)
+	SpecRevision       int64              `protobuf:"varint,28,opt,name=spec_revision,json=specRevision,proto3" json:"spec_revision,omitempty"`                    // Spec revision of the parent a replica unit was created from
+	TargetNodeId       string             `protobuf:"bytes,29,opt,name=target_node_id,json=targetNodeId,proto3" json:"target_node_id,omitempty"`                   // Node a replica unit of a system deployment must run on
+	Periodic           *Periodic          `protobuf:"bytes,30,opt,name=periodic,proto3" json:"periodic,omitempty"`                                                 // Launch the deployment on a cron schedule instead of once
+	DependsOn          []*Dependency      `protobuf:"bytes,31,rep,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`                              // Deployments that must finish before this one is started
+	Secrets            []*SecretReference `protobuf:"bytes,32,rep,name=secrets,proto3" json:"secrets,omitempty"`                                                   // Secrets the agent resolves right before it starts the instance
+	Configs            []*ConfigReference `protobuf:"bytes,33,rep,name=configs,proto3" json:"configs,omitempty"`                                                   // Config objects the agent writes to files in the instance
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
 func (x *Deployment) Reset() {
@@ -451,31 +460,87 @@ func (x *Deployment) GetInstanceType() string {
 	return ""
 }
 
-// Resource requirements and limits for deployment execution
-type Resources struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	MemoryLimitMb    int64                  `protobuf:"varint,1,opt,name=memory_limit_mb,json=memoryLimitMb,proto3" json:"memory_limit_mb,omitempty"`           // Maximum memory in MB
-	MemoryReservedMb int64                  `protobuf:"varint,2,opt,name=memory_reserved_mb,json=memoryReservedMb,proto3" json:"memory_reserved_mb,omitempty"`  // Reserved/guaranteed memory in MB
-	CpuLimitCores    float32                `protobuf:"fixed32,3,opt,name=cpu_limit_cores,json=cpuLimitCores,proto3" json:"cpu_limit_cores,omitempty"`          // CPU limit in cores (e.g., 1.0 = 1 core, 0.5 = half core)
-	CpuReservedCores float32                `protobuf:"fixed32,4,opt,name=cpu_reserved_cores,json=cpuReservedCores,proto3" json:"cpu_reserved_cores,omitempty"` // Reserved/guaranteed CPU in cores
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *Deployment) GetUpdate() *UpdateStrategy {
+	if x != nil {
+		return x.Update
+	}
+	return nil
 }
 
-func (x *Resources) Reset() {
-	*x = Resources{}
+func (x *Deployment) GetParentDeploymentId() string {
+	if x != nil {
+		return x.ParentDeploymentId
+	}
+	return ""
+}
+
+func (x *Deployment) GetSpecRevision() int64 {
+	if x != nil {
+		return x.SpecRevision
+	}
+	return 0
+}
+
+func (x *Deployment) GetTargetNodeId() string {
+	if x != nil {
+		return x.TargetNodeId
+	}
+	return ""
+}
+
+func (x *Deployment) GetPeriodic() *Periodic {
+	if x != nil {
+		return x.Periodic
+	}
+	return nil
+}
+
+func (x *Deployment) GetDependsOn() []*Dependency {
+	if x != nil {
+		return x.DependsOn
+	}
+	return nil
+}
+
+func (x *Deployment) GetSecrets() []*SecretReference {
+	if x != nil {
+		return x.Secrets
+	}
+	return nil
+}
+
+func (x *Deployment) GetConfigs() []*ConfigReference {
+	if x != nil {
+		return x.Configs
+	}
+	return nil
+}
+
+// Secret of the secrets store a deployment uses, either as an environment
+// variable or as a file. The value is never part of the deployment.
+type SecretReference struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Secret        string                 `protobuf:"bytes,1,opt,name=secret,proto3" json:"secret,omitempty"` // Name of the secret
+	Env           string                 `protobuf:"bytes,2,opt,name=env,proto3" json:"env,omitempty"`       // Environment variable the value is put in
+	File          string                 `protobuf:"bytes,3,opt,name=file,proto3" json:"file,omitempty"`     // Absolute path inside the instance the value is written to
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SecretReference) Reset() {
+	*x = SecretReference{}
 	mi := &file_proto_agent_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Resources) String() string {
+func (x *SecretReference) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Resources) ProtoMessage() {}
+func (*SecretReference) ProtoMessage() {}
 
-func (x *Resources) ProtoReflect() protoreflect.Message {
+func (x *SecretReference) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -487,64 +552,57 @@ func (x *Resources) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Resources.ProtoReflect.Descriptor instead.
-func (*Resources) Descriptor() ([]byte, []int) {
+// Deprecated: Use SecretReference.ProtoReflect.Descriptor instead.
+func (*SecretReference) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *Resources) GetMemoryLimitMb() int64 {
-	if x != nil {
-		return x.MemoryLimitMb
-	}
-	return 0
-}
-
-func (x *Resources) GetMemoryReservedMb() int64 {
+func (x *SecretReference) GetSecret() string {
 	if x != nil {
-		return x.MemoryReservedMb
+		return x.Secret
 	}
-	return 0
+	return ""
 }
 
-func (x *Resources) GetCpuLimitCores() float32 {
+func (x *SecretReference) GetEnv() string {
 	if x != nil {
-		return x.CpuLimitCores
+		return x.Env
 	}
-	return 0
+	return ""
 }
 
-func (x *Resources) GetCpuReservedCores() float32 {
+func (x *SecretReference) GetFile() string {
 	if x != nil {
-		return x.CpuReservedCores
+		return x.File
 	}
-	return 0
+	return ""
 }
 
-// Volume mount specification for binding host paths to instance/VM paths
-type Volume struct {
+// Config object a deployment uses as a read-only file. Templates are rendered
+// by Centro with the metadata of the deployment.
+type ConfigReference struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	SourcePath    string                 `protobuf:"bytes,1,opt,name=source_path,json=sourcePath,proto3" json:"source_path,omitempty"` // Path on the host system
-	TargetPath    string                 `protobuf:"bytes,2,opt,name=target_path,json=targetPath,proto3" json:"target_path,omitempty"` // Path inside the instance/VM
-	ReadOnly      bool                   `protobuf:"varint,3,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`      // Whether the mount is read-only
-	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`                               // Mount type: "bind", "volume", etc.
+	Config        string                 `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`      // Name of the config object
+	File          string                 `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"`          // Absolute path inside the instance the content is written to
+	Template      bool                   `protobuf:"varint,3,opt,name=template,proto3" json:"template,omitempty"` // Render the content as a Go template
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Volume) Reset() {
-	*x = Volume{}
+func (x *ConfigReference) Reset() {
+	*x = ConfigReference{}
 	mi := &file_proto_agent_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Volume) String() string {
+func (x *ConfigReference) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Volume) ProtoMessage() {}
+func (*ConfigReference) ProtoMessage() {}
 
-func (x *Volume) ProtoReflect() protoreflect.Message {
+func (x *ConfigReference) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -556,62 +614,55 @@ func (x *Volume) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Volume.ProtoReflect.Descriptor instead.
-func (*Volume) Descriptor() ([]byte, []int) {
+// Deprecated: Use ConfigReference.ProtoReflect.Descriptor instead.
+func (*ConfigReference) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *Volume) GetSourcePath() string {
+func (x *ConfigReference) GetConfig() string {
 	if x != nil {
-		return x.SourcePath
+		return x.Config
 	}
 	return ""
 }
 
-func (x *Volume) GetTargetPath() string {
+func (x *ConfigReference) GetFile() string {
 	if x != nil {
-		return x.TargetPath
+		return x.File
 	}
 	return ""
 }
 
-func (x *Volume) GetReadOnly() bool {
+func (x *ConfigReference) GetTemplate() bool {
 	if x != nil {
-		return x.ReadOnly
+		return x.Template
 	}
 	return false
 }
 
-func (x *Volume) GetType() string {
-	if x != nil {
-		return x.Type
-	}
-	return ""
-}
-
-// Placement constraints and strategy for deployment scheduling
-type Placement struct {
+// Upstream deployment a deployment waits for
+type Dependency struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Constraints   []string               `protobuf:"bytes,1,rep,name=constraints,proto3" json:"constraints,omitempty"` // Placement constraints (e.g., "node.driver in [podman, containerd]", "node.label.zone == us-east")
-	Strategy      string                 `protobuf:"bytes,2,opt,name=strategy,proto3" json:"strategy,omitempty"`       // Placement strategy: "spread", "pack", "random"
+	DeploymentId  string                 `protobuf:"bytes,1,opt,name=deployment_id,json=deploymentId,proto3" json:"deployment_id,omitempty"` // Upstream deployment
+	Condition     string                 `protobuf:"bytes,2,opt,name=condition,proto3" json:"condition,omitempty"`                           // "succeeded" (default), "failed" or "completed" (finished with any status)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Placement) Reset() {
-	*x = Placement{}
+func (x *Dependency) Reset() {
+	*x = Dependency{}
 	mi := &file_proto_agent_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Placement) String() string {
+func (x *Dependency) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Placement) ProtoMessage() {}
+func (*Dependency) ProtoMessage() {}
 
-func (x *Placement) ProtoReflect() protoreflect.Message {
+func (x *Dependency) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -623,49 +674,49 @@ func (x *Placement) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Placement.ProtoReflect.Descriptor instead.
-func (*Placement) Descriptor() ([]byte, []int) {
+// Deprecated: Use Dependency.ProtoReflect.Descriptor instead.
+func (*Dependency) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *Placement) GetConstraints() []string {
+func (x *Dependency) GetDeploymentId() string {
 	if x != nil {
-		return x.Constraints
+		return x.DeploymentId
 	}
-	return nil
+	return ""
 }
 
-func (x *Placement) GetStrategy() string {
+func (x *Dependency) GetCondition() string {
 	if x != nil {
-		return x.Strategy
+		return x.Condition
 	}
 	return ""
 }
 
-// Port mapping configuration
-type PortMapping struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	HostPort      int32                  `protobuf:"varint,1,opt,name=host_port,json=hostPort,proto3" json:"host_port,omitempty"`                // Port on the host
-	ContainerPort int32                  `protobuf:"varint,2,opt,name=container_port,json=containerPort,proto3" json:"container_port,omitempty"` // Port inside the container/instance
-	Protocol      string                 `protobuf:"bytes,3,opt,name=protocol,proto3" json:"protocol,omitempty"`                                 // Protocol: "tcp", "udp"
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// Cron schedule of a periodic deployment. Each launch runs as a child deployment.
+type Periodic struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Cron            string                 `protobuf:"bytes,1,opt,name=cron,proto3" json:"cron,omitempty"`                                               // Cron expression in the agent's robfig/cron syntax (e.g. "0 2 * * *" or "@hourly")
+	TimeZone        string                 `protobuf:"bytes,2,opt,name=time_zone,json=timeZone,proto3" json:"time_zone,omitempty"`                       // IANA time zone the expression is evaluated in (default: UTC)
+	ProhibitOverlap bool                   `protobuf:"varint,3,opt,name=prohibit_overlap,json=prohibitOverlap,proto3" json:"prohibit_overlap,omitempty"` // Skip a launch while the previous launch is still running
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *PortMapping) Reset() {
-	*x = PortMapping{}
+func (x *Periodic) Reset() {
+	*x = Periodic{}
 	mi := &file_proto_agent_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PortMapping) String() string {
+func (x *Periodic) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PortMapping) ProtoMessage() {}
+func (*Periodic) ProtoMessage() {}
 
-func (x *PortMapping) ProtoReflect() protoreflect.Message {
+func (x *Periodic) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -677,57 +728,61 @@ func (x *PortMapping) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PortMapping.ProtoReflect.Descriptor instead.
-func (*PortMapping) Descriptor() ([]byte, []int) {
+// Deprecated: Use Periodic.ProtoReflect.Descriptor instead.
+func (*Periodic) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *PortMapping) GetHostPort() int32 {
+func (x *Periodic) GetCron() string {
 	if x != nil {
-		return x.HostPort
+		return x.Cron
 	}
-	return 0
+	return ""
 }
 
-func (x *PortMapping) GetContainerPort() int32 {
+func (x *Periodic) GetTimeZone() string {
 	if x != nil {
-		return x.ContainerPort
+		return x.TimeZone
 	}
-	return 0
+	return ""
 }
 
-func (x *PortMapping) GetProtocol() string {
+func (x *Periodic) GetProhibitOverlap() bool {
 	if x != nil {
-		return x.Protocol
+		return x.ProhibitOverlap
 	}
-	return ""
+	return false
 }
 
-// Security settings for containers/instances
-type SecuritySettings struct {
-	state                  protoimpl.MessageState `protogen:"open.v1"`
-	Privileged             bool                   `protobuf:"varint,1,opt,name=privileged,proto3" json:"privileged,omitempty"`                                                           // Whether to run in privileged mode
-	CapabilitiesAdd        []string               `protobuf:"bytes,2,rep,name=capabilities_add,json=capabilitiesAdd,proto3" json:"capabilities_add,omitempty"`                           // Capabilities to add (e.g., ["NET_BIND_SERVICE"])
-	CapabilitiesDrop       []string               `protobuf:"bytes,3,rep,name=capabilities_drop,json=capabilitiesDrop,proto3" json:"capabilities_drop,omitempty"`                        // Capabilities to drop (e.g., ["ALL"])
-	ReadOnlyRootFilesystem bool                   `protobuf:"varint,4,opt,name=read_only_root_filesystem,json=readOnlyRootFilesystem,proto3" json:"read_only_root_filesystem,omitempty"` // Whether root filesystem is read-only
-	unknownFields          protoimpl.UnknownFields
-	sizeCache              protoimpl.SizeCache
+// Update strategy for replacing the replicas of a service deployment
+type UpdateStrategy struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	MaxParallel      int32                  `protobuf:"varint,1,opt,name=max_parallel,json=maxParallel,proto3" json:"max_parallel,omitempty"`                 // Replicas replaced per batch (default: 1)
+	MaxSurge         int32                  `protobuf:"varint,2,opt,name=max_surge,json=maxSurge,proto3" json:"max_surge,omitempty"`                          // Extra replicas started before old ones are stopped (default: 0)
+	MinHealthyTime   string                 `protobuf:"bytes,3,opt,name=min_healthy_time,json=minHealthyTime,proto3" json:"min_healthy_time,omitempty"`       // How long a new replica must stay healthy before the batch counts as healthy (e.g. "10s")
+	HealthyDeadline  string                 `protobuf:"bytes,4,opt,name=healthy_deadline,json=healthyDeadline,proto3" json:"healthy_deadline,omitempty"`      // How long a batch may take to become healthy before it fails (e.g. "5m")
+	AutoRevert       bool                   `protobuf:"varint,5,opt,name=auto_revert,json=autoRevert,proto3" json:"auto_revert,omitempty"`                    // Roll back to the last stable revision when a batch fails
+	Strategy         string                 `protobuf:"bytes,6,opt,name=strategy,proto3" json:"strategy,omitempty"`                                           // "rolling" (default), "canary" or "blue-green"
+	Canary           int32                  `protobuf:"varint,7,opt,name=canary,proto3" json:"canary,omitempty"`                                              // Canary replicas started next to the old ones (canary strategy, default: 1)
+	AutoPromoteAfter string                 `protobuf:"bytes,8,opt,name=auto_promote_after,json=autoPromoteAfter,proto3" json:"auto_promote_after,omitempty"` // Promote once the canaries were healthy this long (e.g. "10m"), empty waits for a manual promote
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *SecuritySettings) Reset() {
-	*x = SecuritySettings{}
+func (x *UpdateStrategy) Reset() {
+	*x = UpdateStrategy{}
 	mi := &file_proto_agent_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SecuritySettings) String() string {
+func (x *UpdateStrategy) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SecuritySettings) ProtoMessage() {}
+func (*UpdateStrategy) ProtoMessage() {}
 
-func (x *SecuritySettings) ProtoReflect() protoreflect.Message {
+func (x *UpdateStrategy) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -739,65 +794,92 @@ func (x *SecuritySettings) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SecuritySettings.ProtoReflect.Descriptor instead.
-func (*SecuritySettings) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateStrategy.ProtoReflect.Descriptor instead.
+func (*UpdateStrategy) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *SecuritySettings) GetPrivileged() bool {
+func (x *UpdateStrategy) GetMaxParallel() int32 {
 	if x != nil {
-		return x.Privileged
+		return x.MaxParallel
 	}
-	return false
+	return 0
 }
 
-func (x *SecuritySettings) GetCapabilitiesAdd() []string {
+func (x *UpdateStrategy) GetMaxSurge() int32 {
 	if x != nil {
-		return x.CapabilitiesAdd
+		return x.MaxSurge
 	}
-	return nil
+	return 0
 }
 
-func (x *SecuritySettings) GetCapabilitiesDrop() []string {
+func (x *UpdateStrategy) GetMinHealthyTime() string {
 	if x != nil {
-		return x.CapabilitiesDrop
+		return x.MinHealthyTime
 	}
-	return nil
+	return ""
 }
 
-func (x *SecuritySettings) GetReadOnlyRootFilesystem() bool {
+func (x *UpdateStrategy) GetHealthyDeadline() string {
 	if x != nil {
-		return x.ReadOnlyRootFilesystem
+		return x.HealthyDeadline
+	}
+	return ""
+}
+
+func (x *UpdateStrategy) GetAutoRevert() bool {
+	if x != nil {
+		return x.AutoRevert
 	}
 	return false
 }
 
-// Health check configuration
-type HealthCheck struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Test          []string               `protobuf:"bytes,1,rep,name=test,proto3" json:"test,omitempty"`                                  // Health check test command (e.g., ["CMD-SHELL", "curl -f http://localhost/ || exit 1"])
-	Interval      string                 `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`                          // Interval between health checks (e.g., "30s")
-	Timeout       string                 `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`                            // Timeout for health check (e.g., "5s")
-	Retries       int32                  `protobuf:"varint,4,opt,name=retries,proto3" json:"retries,omitempty"`                           // Number of consecutive failures before marking unhealthy
-	StartPeriod   string                 `protobuf:"bytes,5,opt,name=start_period,json=startPeriod,proto3" json:"start_period,omitempty"` // Grace period before health checks start (e.g., "5s")
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *UpdateStrategy) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
 }
 
-func (x *HealthCheck) Reset() {
-	*x = HealthCheck{}
+func (x *UpdateStrategy) GetCanary() int32 {
+	if x != nil {
+		return x.Canary
+	}
+	return 0
+}
+
+func (x *UpdateStrategy) GetAutoPromoteAfter() string {
+	if x != nil {
+		return x.AutoPromoteAfter
+	}
+	return ""
+}
+
+// Resource requirements and limits for deployment execution
+type Resources struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	MemoryLimitMb    int64                  `protobuf:"varint,1,opt,name=memory_limit_mb,json=memoryLimitMb,proto3" json:"memory_limit_mb,omitempty"`           // Maximum memory in MB
+	MemoryReservedMb int64                  `protobuf:"varint,2,opt,name=memory_reserved_mb,json=memoryReservedMb,proto3" json:"memory_reserved_mb,omitempty"`  // Reserved/guaranteed memory in MB
+	CpuLimitCores    float32                `protobuf:"fixed32,3,opt,name=cpu_limit_cores,json=cpuLimitCores,proto3" json:"cpu_limit_cores,omitempty"`          // CPU limit in cores (e.g., 1.0 = 1 core, 0.5 = half core)
+	CpuReservedCores float32                `protobuf:"fixed32,4,opt,name=cpu_reserved_cores,json=cpuReservedCores,proto3" json:"cpu_reserved_cores,omitempty"` // Reserved/guaranteed CPU in cores
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Resources) Reset() {
+	*x = Resources{}
 	mi := &file_proto_agent_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheck) String() string {
+func (x *Resources) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheck) ProtoMessage() {}
+func (*Resources) ProtoMessage() {}
 
-func (x *HealthCheck) ProtoReflect() protoreflect.Message {
+func (x *Resources) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -809,69 +891,64 @@ func (x *HealthCheck) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheck.ProtoReflect.Descriptor instead.
-func (*HealthCheck) Descriptor() ([]byte, []int) {
+// Deprecated: Use Resources.ProtoReflect.Descriptor instead.
+func (*Resources) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *HealthCheck) GetTest() []string {
+func (x *Resources) GetMemoryLimitMb() int64 {
 	if x != nil {
-		return x.Test
+		return x.MemoryLimitMb
 	}
-	return nil
+	return 0
 }
 
-func (x *HealthCheck) GetInterval() string {
+func (x *Resources) GetMemoryReservedMb() int64 {
 	if x != nil {
-		return x.Interval
+		return x.MemoryReservedMb
 	}
-	return ""
+	return 0
 }
 
-func (x *HealthCheck) GetTimeout() string {
+func (x *Resources) GetCpuLimitCores() float32 {
 	if x != nil {
-		return x.Timeout
-	}
-	return ""
-}
-
-func (x *HealthCheck) GetRetries() int32 {
-	if x != nil {
-		return x.Retries
+		return x.CpuLimitCores
 	}
 	return 0
 }
 
-func (x *HealthCheck) GetStartPeriod() string {
+func (x *Resources) GetCpuReservedCores() float32 {
 	if x != nil {
-		return x.StartPeriod
+		return x.CpuReservedCores
 	}
-	return ""
+	return 0
 }
 
-// Restart policy for instances
-type RestartPolicy struct {
+// Volume mount specification for binding host paths to instance/VM paths
+type Volume struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Condition     string                 `protobuf:"bytes,1,opt,name=condition,proto3" json:"condition,omitempty"`                         // Restart condition: "no", "always", "on-failure", "unless-stopped"
-	MaxAttempts   int32                  `protobuf:"varint,2,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"` // Maximum restart attempts (0 = unlimited)
+	SourcePath    string                 `protobuf:"bytes,1,opt,name=source_path,json=sourcePath,proto3" json:"source_path,omitempty"` // Path on the host system
+	TargetPath    string                 `protobuf:"bytes,2,opt,name=target_path,json=targetPath,proto3" json:"target_path,omitempty"` // Path inside the instance/VM
+	ReadOnly      bool                   `protobuf:"varint,3,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`      // Whether the mount is read-only
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`                               // Mount type: "bind", "volume", etc.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RestartPolicy) Reset() {
-	*x = RestartPolicy{}
+func (x *Volume) Reset() {
+	*x = Volume{}
 	mi := &file_proto_agent_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RestartPolicy) String() string {
+func (x *Volume) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RestartPolicy) ProtoMessage() {}
+func (*Volume) ProtoMessage() {}
 
-func (x *RestartPolicy) ProtoReflect() protoreflect.Message {
+func (x *Volume) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -883,47 +960,62 @@ func (x *RestartPolicy) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RestartPolicy.ProtoReflect.Descriptor instead.
-func (*RestartPolicy) Descriptor() ([]byte, []int) {
+// Deprecated: Use Volume.ProtoReflect.Descriptor instead.
+func (*Volume) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *RestartPolicy) GetCondition() string {
+func (x *Volume) GetSourcePath() string {
 	if x != nil {
-		return x.Condition
+		return x.SourcePath
 	}
 	return ""
 }
 
-func (x *RestartPolicy) GetMaxAttempts() int32 {
+func (x *Volume) GetTargetPath() string {
 	if x != nil {
-		return x.MaxAttempts
+		return x.TargetPath
 	}
-	return 0
+	return ""
 }
 
-// Network reference for deployment network assignment
-type NetworkReference struct {
+func (x *Volume) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *Volume) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+// Placement constraints and strategy for deployment scheduling
+type Placement struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // Network name (e.g., "backend-net")
+	Constraints   []string               `protobuf:"bytes,1,rep,name=constraints,proto3" json:"constraints,omitempty"` // Placement constraints (e.g., "node.driver in [podman, containerd]", "node.label.zone == us-east")
+	Strategy      string                 `protobuf:"bytes,2,opt,name=strategy,proto3" json:"strategy,omitempty"`       // Placement strategy: "spread", "pack", "random"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NetworkReference) Reset() {
-	*x = NetworkReference{}
+func (x *Placement) Reset() {
+	*x = Placement{}
 	mi := &file_proto_agent_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NetworkReference) String() string {
+func (x *Placement) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NetworkReference) ProtoMessage() {}
+func (*Placement) ProtoMessage() {}
 
-func (x *NetworkReference) ProtoReflect() protoreflect.Message {
+func (x *Placement) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -935,42 +1027,49 @@ func (x *NetworkReference) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NetworkReference.ProtoReflect.Descriptor instead.
-func (*NetworkReference) Descriptor() ([]byte, []int) {
+// Deprecated: Use Placement.ProtoReflect.Descriptor instead.
+func (*Placement) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *NetworkReference) GetName() string {
+func (x *Placement) GetConstraints() []string {
 	if x != nil {
-		return x.Name
+		return x.Constraints
+	}
+	return nil
+}
+
+func (x *Placement) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
 	}
 	return ""
 }
 
-// Image source configuration for pulling images
-type ImageSource struct {
+// Port mapping configuration
+type PortMapping struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`   // Image alias (e.g., "ubuntu/22.04")
-	Server        string                 `protobuf:"bytes,2,opt,name=server,proto3" json:"server,omitempty"` // Image server URL (e.g., "images.linuxcontainers.org")
-	Mode          string                 `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`     // Pull mode: "pull", "local"
+	HostPort      int32                  `protobuf:"varint,1,opt,name=host_port,json=hostPort,proto3" json:"host_port,omitempty"`                // Port on the host
+	ContainerPort int32                  `protobuf:"varint,2,opt,name=container_port,json=containerPort,proto3" json:"container_port,omitempty"` // Port inside the container/instance
+	Protocol      string                 `protobuf:"bytes,3,opt,name=protocol,proto3" json:"protocol,omitempty"`                                 // Protocol: "tcp", "udp"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ImageSource) Reset() {
-	*x = ImageSource{}
+func (x *PortMapping) Reset() {
+	*x = PortMapping{}
 	mi := &file_proto_agent_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ImageSource) String() string {
+func (x *PortMapping) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ImageSource) ProtoMessage() {}
+func (*PortMapping) ProtoMessage() {}
 
-func (x *ImageSource) ProtoReflect() protoreflect.Message {
+func (x *PortMapping) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -982,56 +1081,57 @@ func (x *ImageSource) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ImageSource.ProtoReflect.Descriptor instead.
-func (*ImageSource) Descriptor() ([]byte, []int) {
+// Deprecated: Use PortMapping.ProtoReflect.Descriptor instead.
+func (*PortMapping) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *ImageSource) GetAlias() string {
+func (x *PortMapping) GetHostPort() int32 {
 	if x != nil {
-		return x.Alias
+		return x.HostPort
 	}
-	return ""
+	return 0
 }
 
-func (x *ImageSource) GetServer() string {
+func (x *PortMapping) GetContainerPort() int32 {
 	if x != nil {
-		return x.Server
+		return x.ContainerPort
 	}
-	return ""
+	return 0
 }
 
-func (x *ImageSource) GetMode() string {
+func (x *PortMapping) GetProtocol() string {
 	if x != nil {
-		return x.Mode
+		return x.Protocol
 	}
 	return ""
 }
 
-// Device configuration for instances (network interfaces, etc.)
-type Device struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                                                                       // Device name (e.g., "eth0")
-	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`                                                                                       // Device type (e.g., "nic")
-	Properties    map[string]string      `protobuf:"bytes,3,rep,name=properties,proto3" json:"properties,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Device-specific properties (e.g., network: "incusbr0")
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// Security settings for containers/instances
+type SecuritySettings struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Privileged             bool                   `protobuf:"varint,1,opt,name=privileged,proto3" json:"privileged,omitempty"`                                                           // Whether to run in privileged mode
+	CapabilitiesAdd        []string               `protobuf:"bytes,2,rep,name=capabilities_add,json=capabilitiesAdd,proto3" json:"capabilities_add,omitempty"`                           // Capabilities to add (e.g., ["NET_BIND_SERVICE"])
+	CapabilitiesDrop       []string               `protobuf:"bytes,3,rep,name=capabilities_drop,json=capabilitiesDrop,proto3" json:"capabilities_drop,omitempty"`                        // Capabilities to drop (e.g., ["ALL"])
+	ReadOnlyRootFilesystem bool                   `protobuf:"varint,4,opt,name=read_only_root_filesystem,json=readOnlyRootFilesystem,proto3" json:"read_only_root_filesystem,omitempty"` // Whether root filesystem is read-only
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
 }
 
-func (x *Device) Reset() {
-	*x = Device{}
+func (x *SecuritySettings) Reset() {
+	*x = SecuritySettings{}
 	mi := &file_proto_agent_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Device) String() string {
+func (x *SecuritySettings) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Device) ProtoMessage() {}
+func (*SecuritySettings) ProtoMessage() {}
 
-func (x *Device) ProtoReflect() protoreflect.Message {
+func (x *SecuritySettings) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1043,60 +1143,65 @@ func (x *Device) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Device.ProtoReflect.Descriptor instead.
-func (*Device) Descriptor() ([]byte, []int) {
+// Deprecated: Use SecuritySettings.ProtoReflect.Descriptor instead.
+func (*SecuritySettings) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *Device) GetName() string {
+func (x *SecuritySettings) GetPrivileged() bool {
 	if x != nil {
-		return x.Name
+		return x.Privileged
 	}
-	return ""
+	return false
 }
 
-func (x *Device) GetType() string {
+func (x *SecuritySettings) GetCapabilitiesAdd() []string {
 	if x != nil {
-		return x.Type
+		return x.CapabilitiesAdd
 	}
-	return ""
+	return nil
 }
 
-func (x *Device) GetProperties() map[string]string {
+func (x *SecuritySettings) GetCapabilitiesDrop() []string {
 	if x != nil {
-		return x.Properties
+		return x.CapabilitiesDrop
 	}
 	return nil
 }
 
-// Instance specification for containerized or VM workloads
-type InstanceSpec struct {
+func (x *SecuritySettings) GetReadOnlyRootFilesystem() bool {
+	if x != nil {
+		return x.ReadOnlyRootFilesystem
+	}
+	return false
+}
+
+// Health check configuration
+type HealthCheck struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ImageName     string                 `protobuf:"bytes,1,opt,name=image_name,json=imageName,proto3" json:"image_name,omitempty"`                                                                                       // Image name (e.g., "nginx:latest" for containers, VM image for VMs)
-	Entrypoint    []string               `protobuf:"bytes,2,rep,name=entrypoint,proto3" json:"entrypoint,omitempty"`                                                                                                      // Entrypoint command to run in the instance
-	Arguments     []string               `protobuf:"bytes,3,rep,name=arguments,proto3" json:"arguments,omitempty"`                                                                                                        // Arguments for the entrypoint command
-	DriverOptions map[string]string      `protobuf:"bytes,4,rep,name=driver_options,json=driverOptions,proto3" json:"driver_options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Driver-specific configuration options
-	ImageSource   *ImageSource           `protobuf:"bytes,5,opt,name=image_source,json=imageSource,proto3" json:"image_source,omitempty"`                                                                                 // Image source configuration (server, alias, mode)
-	UserData      string                 `protobuf:"bytes,6,opt,name=user_data,json=userData,proto3" json:"user_data,omitempty"`                                                                                          // User data/cloud-init configuration (for VMs)
-	Devices       []*Device              `protobuf:"bytes,7,rep,name=devices,proto3" json:"devices,omitempty"`                                                                                                            // Device configurations (network interfaces, etc.)
+	Test          []string               `protobuf:"bytes,1,rep,name=test,proto3" json:"test,omitempty"`                                  // Health check test command (e.g., ["CMD-SHELL", "curl -f http://localhost/ || exit 1"])
+	Interval      string                 `protobuf:"bytes,2,opt,name=interval,proto3" json:"interval,omitempty"`                          // Interval between health checks (e.g., "30s")
+	Timeout       string                 `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`                            // Timeout for health check (e.g., "5s")
+	Retries       int32                  `protobuf:"varint,4,opt,name=retries,proto3" json:"retries,omitempty"`                           // Number of consecutive failures before marking unhealthy
+	StartPeriod   string                 `protobuf:"bytes,5,opt,name=start_period,json=startPeriod,proto3" json:"start_period,omitempty"` // Grace period before health checks start (e.g., "5s")
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *InstanceSpec) Reset() {
-	*x = InstanceSpec{}
+func (x *HealthCheck) Reset() {
+	*x = HealthCheck{}
 	mi := &file_proto_agent_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *InstanceSpec) String() string {
+func (x *HealthCheck) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*InstanceSpec) ProtoMessage() {}
+func (*HealthCheck) ProtoMessage() {}
 
-func (x *InstanceSpec) ProtoReflect() protoreflect.Message {
+func (x *HealthCheck) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_agent_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1108,84 +1213,746 @@ func (x *InstanceSpec) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use InstanceSpec.ProtoReflect.Descriptor instead.
-func (*InstanceSpec) Descriptor() ([]byte, []int) {
+// Deprecated: Use HealthCheck.ProtoReflect.Descriptor instead.
+func (*HealthCheck) Descriptor() ([]byte, []int) {
 	return file_proto_agent_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *InstanceSpec) GetImageName() string {
-	if x != nil {
-		return x.ImageName
-	}
-	return ""
-}
-
-func (x *InstanceSpec) GetEntrypoint() []string {
+func (x *HealthCheck) GetTest() []string {
 	if x != nil {
-		return x.Entrypoint
+		return x.Test
 	}
 	return nil
 }
 
-func (x *InstanceSpec) GetArguments() []string {
+func (x *HealthCheck) GetInterval() string {
 	if x != nil {
-		return x.Arguments
+		return x.Interval
 	}
-	return nil
+	return ""
 }
 
-func (x *InstanceSpec) GetDriverOptions() map[string]string {
+func (x *HealthCheck) GetTimeout() string {
 	if x != nil {
-		return x.DriverOptions
+		return x.Timeout
 	}
-	return nil
+	return ""
 }
 
-func (x *InstanceSpec) GetImageSource() *ImageSource {
+func (x *HealthCheck) GetRetries() int32 {
 	if x != nil {
-		return x.ImageSource
+		return x.Retries
 	}
-	return nil
+	return 0
 }
 
-func (x *InstanceSpec) GetUserData() string {
+func (x *HealthCheck) GetStartPeriod() string {
 	if x != nil {
-		return x.UserData
+		return x.StartPeriod
 	}
 	return ""
 }
 
-func (x *InstanceSpec) GetDevices() []*Device {
-	if x != nil {
-		return x.Devices
-	}
+// Restart policy for instances
+type RestartPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Condition     string                 `protobuf:"bytes,1,opt,name=condition,proto3" json:"condition,omitempty"`                         // Restart condition: "no", "always", "on-failure", "unless-stopped"
+	MaxAttempts   int32                  `protobuf:"varint,2,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"` // Maximum restart attempts (0 = unlimited)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestartPolicy) Reset() {
+	*x = RestartPolicy{}
+	mi := &file_proto_agent_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestartPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestartPolicy) ProtoMessage() {}
+
+func (x *RestartPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestartPolicy.ProtoReflect.Descriptor instead.
+func (*RestartPolicy) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RestartPolicy) GetCondition() string {
+	if x != nil {
+		return x.Condition
+	}
+	return ""
+}
+
+func (x *RestartPolicy) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+// Network reference for deployment network assignment
+type NetworkReference struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // Network name (e.g., "backend-net")
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NetworkReference) Reset() {
+	*x = NetworkReference{}
+	mi := &file_proto_agent_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NetworkReference) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NetworkReference) ProtoMessage() {}
+
+func (x *NetworkReference) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NetworkReference.ProtoReflect.Descriptor instead.
+func (*NetworkReference) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *NetworkReference) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// Image source configuration for pulling images
+type ImageSource struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Alias         string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`   // Image alias (e.g., "ubuntu/22.04")
+	Server        string                 `protobuf:"bytes,2,opt,name=server,proto3" json:"server,omitempty"` // Image server URL (e.g., "images.linuxcontainers.org")
+	Mode          string                 `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`     // Pull mode: "pull", "local"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImageSource) Reset() {
+	*x = ImageSource{}
+	mi := &file_proto_agent_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImageSource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageSource) ProtoMessage() {}
+
+func (x *ImageSource) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageSource.ProtoReflect.Descriptor instead.
+func (*ImageSource) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ImageSource) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *ImageSource) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *ImageSource) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+// Device configuration for instances (network interfaces, etc.)
+type Device struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                                                                       // Device name (e.g., "eth0")
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`                                                                                       // Device type (e.g., "nic")
+	Properties    map[string]string      `protobuf:"bytes,3,rep,name=properties,proto3" json:"properties,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Device-specific properties (e.g., network: "incusbr0")
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Device) Reset() {
+	*x = Device{}
+	mi := &file_proto_agent_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Device) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Device) ProtoMessage() {}
+
+func (x *Device) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Device.ProtoReflect.Descriptor instead.
+func (*Device) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Device) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Device) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Device) GetProperties() map[string]string {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+// Instance specification for containerized or VM workloads
+type InstanceSpec struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ImageName     string                 `protobuf:"bytes,1,opt,name=image_name,json=imageName,proto3" json:"image_name,omitempty"`                                                                                       // Image name (e.g., "nginx:latest" for containers, VM image for VMs)
+	Entrypoint    []string               `protobuf:"bytes,2,rep,name=entrypoint,proto3" json:"entrypoint,omitempty"`                                                                                                      // Entrypoint command to run in the instance
+	Arguments     []string               `protobuf:"bytes,3,rep,name=arguments,proto3" json:"arguments,omitempty"`                                                                                                        // Arguments for the entrypoint command
+	DriverOptions map[string]string      `protobuf:"bytes,4,rep,name=driver_options,json=driverOptions,proto3" json:"driver_options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Driver-specific configuration options
+	ImageSource   *ImageSource           `protobuf:"bytes,5,opt,name=image_source,json=imageSource,proto3" json:"image_source,omitempty"`                                                                                 // Image source configuration (server, alias, mode)
+	UserData      string                 `protobuf:"bytes,6,opt,name=user_data,json=userData,proto3" json:"user_data,omitempty"`                                                                                          // User data/cloud-init configuration (for VMs)
+	Devices       []*Device              `protobuf:"bytes,7,rep,name=devices,proto3" json:"devices,omitempty"`                                                                                                            // Device configurations (network interfaces, etc.)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstanceSpec) Reset() {
+	*x = InstanceSpec{}
+	mi := &file_proto_agent_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstanceSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstanceSpec) ProtoMessage() {}
+
+func (x *InstanceSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstanceSpec.ProtoReflect.Descriptor instead.
+func (*InstanceSpec) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *InstanceSpec) GetImageName() string {
+	if x != nil {
+		return x.ImageName
+	}
+	return ""
+}
+
+func (x *InstanceSpec) GetEntrypoint() []string {
+	if x != nil {
+		return x.Entrypoint
+	}
+	return nil
+}
+
+func (x *InstanceSpec) GetArguments() []string {
+	if x != nil {
+		return x.Arguments
+	}
+	return nil
+}
+
+func (x *InstanceSpec) GetDriverOptions() map[string]string {
+	if x != nil {
+		return x.DriverOptions
+	}
+	return nil
+}
+
+func (x *InstanceSpec) GetImageSource() *ImageSource {
+	if x != nil {
+		return x.ImageSource
+	}
+	return nil
+}
+
+func (x *InstanceSpec) GetUserData() string {
+	if x != nil {
+		return x.UserData
+	}
+	return ""
+}
+
+func (x *InstanceSpec) GetDevices() []*Device {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+type GetDeploymentResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	DeploymentAvailable bool                   `protobuf:"varint,1,opt,name=deployment_available,json=deploymentAvailable,proto3" json:"deployment_available,omitempty"` // Whether a deployment is available for execution
+	Deployment          *Deployment            `protobuf:"bytes,2,opt,name=deployment,proto3" json:"deployment,omitempty"`
+	ResponseMessage     string                 `protobuf:"bytes,3,opt,name=response_message,json=responseMessage,proto3" json:"response_message,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *GetDeploymentResponse) Reset() {
+	*x = GetDeploymentResponse{}
+	mi := &file_proto_agent_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeploymentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeploymentResponse) ProtoMessage() {}
+
+func (x *GetDeploymentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeploymentResponse.ProtoReflect.Descriptor instead.
+func (*GetDeploymentResponse) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetDeploymentResponse) GetDeploymentAvailable() bool {
+	if x != nil {
+		return x.DeploymentAvailable
+	}
+	return false
+}
+
+func (x *GetDeploymentResponse) GetDeployment() *Deployment {
+	if x != nil {
+		return x.Deployment
+	}
+	return nil
+}
+
+func (x *GetDeploymentResponse) GetResponseMessage() string {
+	if x != nil {
+		return x.ResponseMessage
+	}
+	return ""
+}
+
+// Status update from Agent to Centro about deployment execution
+type UpdateStatusRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	NodeId           string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	DeploymentId     string                 `protobuf:"bytes,2,opt,name=deployment_id,json=deploymentId,proto3" json:"deployment_id,omitempty"`
+	DeploymentStatus string                 `protobuf:"bytes,3,opt,name=deployment_status,json=deploymentStatus,proto3" json:"deployment_status,omitempty"` // Deployment status: "pending", "running", "completed", "failed"
+	StatusMessage    string                 `protobuf:"bytes,4,opt,name=status_message,json=statusMessage,proto3" json:"status_message,omitempty"`          // Detailed status message or error description
+	Timestamp        int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UpdateStatusRequest) Reset() {
+	*x = UpdateStatusRequest{}
+	mi := &file_proto_agent_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStatusRequest) ProtoMessage() {}
+
+func (x *UpdateStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStatusRequest.ProtoReflect.Descriptor instead.
+func (*UpdateStatusRequest) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UpdateStatusRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *UpdateStatusRequest) GetDeploymentId() string {
+	if x != nil {
+		return x.DeploymentId
+	}
+	return ""
+}
+
+func (x *UpdateStatusRequest) GetDeploymentStatus() string {
+	if x != nil {
+		return x.DeploymentStatus
+	}
+	return ""
+}
+
+func (x *UpdateStatusRequest) GetStatusMessage() string {
+	if x != nil {
+		return x.StatusMessage
+	}
+	return ""
+}
+
+func (x *UpdateStatusRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type UpdateStatusResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Acknowledged    bool                   `protobuf:"varint,1,opt,name=acknowledged,proto3" json:"acknowledged,omitempty"`
+	ResponseMessage string                 `protobuf:"bytes,2,opt,name=response_message,json=responseMessage,proto3" json:"response_message,omitempty"`
+	StopInstance    bool                   `protobuf:"varint,3,opt,name=stop_instance,json=stopInstance,proto3" json:"stop_instance,omitempty"` // The instance of this deployment should be stopped (e.g. replaced by a rolling update)
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *UpdateStatusResponse) Reset() {
+	*x = UpdateStatusResponse{}
+	mi := &file_proto_agent_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStatusResponse) ProtoMessage() {}
+
+func (x *UpdateStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStatusResponse.ProtoReflect.Descriptor instead.
+func (*UpdateStatusResponse) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *UpdateStatusResponse) GetAcknowledged() bool {
+	if x != nil {
+		return x.Acknowledged
+	}
+	return false
+}
+
+func (x *UpdateStatusResponse) GetResponseMessage() string {
+	if x != nil {
+		return x.ResponseMessage
+	}
+	return ""
+}
+
+func (x *UpdateStatusResponse) GetStopInstance() bool {
+	if x != nil {
+		return x.StopInstance
+	}
+	return false
+}
+
+// Instance inspection data sent from Agent to Centro after instance is running
+type InstanceData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`                                                  // Instance ID
+	InstanceName  string                 `protobuf:"bytes,2,opt,name=instance_name,json=instanceName,proto3" json:"instance_name,omitempty"`                                            // Instance name
+	Image         string                 `protobuf:"bytes,3,opt,name=image,proto3" json:"image,omitempty"`                                                                              // Full image identifier
+	ImageName     string                 `protobuf:"bytes,4,opt,name=image_name,json=imageName,proto3" json:"image_name,omitempty"`                                                     // Image name (e.g., "nginx:latest")
+	Command       []string               `protobuf:"bytes,5,rep,name=command,proto3" json:"command,omitempty"`                                                                          // Command executed in instance
+	Args          []string               `protobuf:"bytes,6,rep,name=args,proto3" json:"args,omitempty"`                                                                                // Arguments passed to command
+	Created       string                 `protobuf:"bytes,7,opt,name=created,proto3" json:"created,omitempty"`                                                                          // Creation timestamp
+	StartedAt     string                 `protobuf:"bytes,8,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`                                                     // Instance start timestamp
+	FinishedAt    string                 `protobuf:"bytes,9,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`                                                  // Instance finish timestamp (if stopped)
+	Status        string                 `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`                                                                           // Instance status: "running", "stopped", "exited", "failed"
+	ExitCode      int32                  `protobuf:"varint,11,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`                                                      // Exit code (if stopped)
+	Pid           int32                  `protobuf:"varint,12,opt,name=pid,proto3" json:"pid,omitempty"`                                                                                // Process ID
+	Labels        map[string]string      `protobuf:"bytes,13,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Instance labels
+	Ports         []string               `protobuf:"bytes,14,rep,name=ports,proto3" json:"ports,omitempty"`                                                                             // Exposed ports
+	Volumes       []string               `protobuf:"bytes,15,rep,name=volumes,proto3" json:"volumes,omitempty"`                                                                         // Mounted volumes
+	Health        string                 `protobuf:"bytes,16,opt,name=health,proto3" json:"health,omitempty"`                                                                           // Health check result: "healthy", "unhealthy", "starting" (empty = no health check)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstanceData) Reset() {
+	*x = InstanceData{}
+	mi := &file_proto_agent_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstanceData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstanceData) ProtoMessage() {}
+
+func (x *InstanceData) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstanceData.ProtoReflect.Descriptor instead.
+func (*InstanceData) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *InstanceData) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
+func (x *InstanceData) GetInstanceName() string {
+	if x != nil {
+		return x.InstanceName
+	}
+	return ""
+}
+
+func (x *InstanceData) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *InstanceData) GetImageName() string {
+	if x != nil {
+		return x.ImageName
+	}
+	return ""
+}
+
+func (x *InstanceData) GetCommand() []string {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+func (x *InstanceData) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *InstanceData) GetCreated() string {
+	if x != nil {
+		return x.Created
+	}
+	return ""
+}
+
+func (x *InstanceData) GetStartedAt() string {
+	if x != nil {
+		return x.StartedAt
+	}
+	return ""
+}
+
+func (x *InstanceData) GetFinishedAt() string {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return ""
+}
+
+func (x *InstanceData) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *InstanceData) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *InstanceData) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *InstanceData) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
 	return nil
 }
 
-type GetDeploymentResponse struct {
-	state               protoimpl.MessageState `protogen:"open.v1"`
-	DeploymentAvailable bool                   `protobuf:"varint,1,opt,name=deployment_available,json=deploymentAvailable,proto3" json:"deployment_available,omitempty"` // Whether a deployment is available for execution
-	Deployment          *Deployment            `protobuf:"bytes,2,opt,name=deployment,proto3" json:"deployment,omitempty"`
-	ResponseMessage     string                 `protobuf:"bytes,3,opt,name=response_message,json=responseMessage,proto3" json:"response_message,omitempty"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+func (x *InstanceData) GetPorts() []string {
+	if x != nil {
+		return x.Ports
+	}
+	return nil
 }
 
-func (x *GetDeploymentResponse) Reset() {
-	*x = GetDeploymentResponse{}
-	mi := &file_proto_agent_proto_msgTypes[15]
+func (x *InstanceData) GetVolumes() []string {
+	if x != nil {
+		return x.Volumes
+	}
+	return nil
+}
+
+func (x *InstanceData) GetHealth() string {
+	if x != nil {
+		return x.Health
+	}
+	return ""
+}
+
+type SetInstanceDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`                   // Node ID where instance is running
+	DeploymentId  string                 `protobuf:"bytes,2,opt,name=deployment_id,json=deploymentId,proto3" json:"deployment_id,omitempty"` // Deployment ID associated with the instance
+	InstanceData  *InstanceData          `protobuf:"bytes,3,opt,name=instance_data,json=instanceData,proto3" json:"instance_data,omitempty"` // Instance inspection data
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                          // Timestamp when data was collected
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetInstanceDataRequest) Reset() {
+	*x = SetInstanceDataRequest{}
+	mi := &file_proto_agent_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetDeploymentResponse) String() string {
+func (x *SetInstanceDataRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetDeploymentResponse) ProtoMessage() {}
+func (*SetInstanceDataRequest) ProtoMessage() {}
 
-func (x *GetDeploymentResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_agent_proto_msgTypes[15]
+func (x *SetInstanceDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1196,59 +1963,298 @@ func (x *GetDeploymentResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetDeploymentResponse.ProtoReflect.Descriptor instead.
-func (*GetDeploymentResponse) Descriptor() ([]byte, []int) {
-	return file_proto_agent_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use SetInstanceDataRequest.ProtoReflect.Descriptor instead.
+func (*SetInstanceDataRequest) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SetInstanceDataRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *SetInstanceDataRequest) GetDeploymentId() string {
+	if x != nil {
+		return x.DeploymentId
+	}
+	return ""
+}
+
+func (x *SetInstanceDataRequest) GetInstanceData() *InstanceData {
+	if x != nil {
+		return x.InstanceData
+	}
+	return nil
+}
+
+func (x *SetInstanceDataRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type SetInstanceDataResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Acknowledged    bool                   `protobuf:"varint,1,opt,name=acknowledged,proto3" json:"acknowledged,omitempty"`
+	ResponseMessage string                 `protobuf:"bytes,2,opt,name=response_message,json=responseMessage,proto3" json:"response_message,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SetInstanceDataResponse) Reset() {
+	*x = SetInstanceDataResponse{}
+	mi := &file_proto_agent_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetInstanceDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetInstanceDataResponse) ProtoMessage() {}
+
+func (x *SetInstanceDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetInstanceDataResponse.ProtoReflect.Descriptor instead.
+func (*SetInstanceDataResponse) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SetInstanceDataResponse) GetAcknowledged() bool {
+	if x != nil {
+		return x.Acknowledged
+	}
+	return false
+}
+
+func (x *SetInstanceDataResponse) GetResponseMessage() string {
+	if x != nil {
+		return x.ResponseMessage
+	}
+	return ""
+}
+
+// A new node asks for a client certificate with a one-time join token
+type JoinNodeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	JoinToken     string                 `protobuf:"bytes,2,opt,name=join_token,json=joinToken,proto3" json:"join_token,omitempty"`
+	CsrPem        []byte                 `protobuf:"bytes,3,opt,name=csr_pem,json=csrPem,proto3" json:"csr_pem,omitempty"` // PEM encoded certificate signing request for the key of the node
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JoinNodeRequest) Reset() {
+	*x = JoinNodeRequest{}
+	mi := &file_proto_agent_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinNodeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinNodeRequest) ProtoMessage() {}
+
+func (x *JoinNodeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinNodeRequest.ProtoReflect.Descriptor instead.
+func (*JoinNodeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *JoinNodeRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *JoinNodeRequest) GetJoinToken() string {
+	if x != nil {
+		return x.JoinToken
+	}
+	return ""
+}
+
+func (x *JoinNodeRequest) GetCsrPem() []byte {
+	if x != nil {
+		return x.CsrPem
+	}
+	return nil
+}
+
+type JoinNodeResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Accepted         bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	ResponseMessage  string                 `protobuf:"bytes,2,opt,name=response_message,json=responseMessage,proto3" json:"response_message,omitempty"`
+	CertificatePem   []byte                 `protobuf:"bytes,3,opt,name=certificate_pem,json=certificatePem,proto3" json:"certificate_pem,omitempty"` // Client certificate of the node, signed by the CA of Centro
+	CaCertificatePem []byte                 `protobuf:"bytes,4,opt,name=ca_certificate_pem,json=caCertificatePem,proto3" json:"ca_certificate_pem,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *JoinNodeResponse) Reset() {
+	*x = JoinNodeResponse{}
+	mi := &file_proto_agent_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JoinNodeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JoinNodeResponse) ProtoMessage() {}
+
+func (x *JoinNodeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JoinNodeResponse.ProtoReflect.Descriptor instead.
+func (*JoinNodeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *JoinNodeResponse) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *JoinNodeResponse) GetResponseMessage() string {
+	if x != nil {
+		return x.ResponseMessage
+	}
+	return ""
+}
+
+func (x *JoinNodeResponse) GetCertificatePem() []byte {
+	if x != nil {
+		return x.CertificatePem
+	}
+	return nil
+}
+
+func (x *JoinNodeResponse) GetCaCertificatePem() []byte {
+	if x != nil {
+		return x.CaCertificatePem
+	}
+	return nil
+}
+
+// A node renews its client certificate before it expires
+type RenewCertificateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	CsrPem        []byte                 `protobuf:"bytes,2,opt,name=csr_pem,json=csrPem,proto3" json:"csr_pem,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenewCertificateRequest) Reset() {
+	*x = RenewCertificateRequest{}
+	mi := &file_proto_agent_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenewCertificateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewCertificateRequest) ProtoMessage() {}
+
+func (x *RenewCertificateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-func (x *GetDeploymentResponse) GetDeploymentAvailable() bool {
-	if x != nil {
-		return x.DeploymentAvailable
-	}
-	return false
+// Deprecated: Use RenewCertificateRequest.ProtoReflect.Descriptor instead.
+func (*RenewCertificateRequest) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{28}
 }
 
-func (x *GetDeploymentResponse) GetDeployment() *Deployment {
+func (x *RenewCertificateRequest) GetNodeId() string {
 	if x != nil {
-		return x.Deployment
+		return x.NodeId
 	}
-	return nil
+	return ""
 }
 
-func (x *GetDeploymentResponse) GetResponseMessage() string {
+func (x *RenewCertificateRequest) GetCsrPem() []byte {
 	if x != nil {
-		return x.ResponseMessage
+		return x.CsrPem
 	}
-	return ""
+	return nil
 }
 
-// Status update from Agent to Centro about deployment execution
-type UpdateStatusRequest struct {
+type RenewCertificateResponse struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
-	NodeId           string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
-	DeploymentId     string                 `protobuf:"bytes,2,opt,name=deployment_id,json=deploymentId,proto3" json:"deployment_id,omitempty"`
-	DeploymentStatus string                 `protobuf:"bytes,3,opt,name=deployment_status,json=deploymentStatus,proto3" json:"deployment_status,omitempty"` // Deployment status: "pending", "running", "completed", "failed"
-	StatusMessage    string                 `protobuf:"bytes,4,opt,name=status_message,json=statusMessage,proto3" json:"status_message,omitempty"`          // Detailed status message or error description
-	Timestamp        int64                  `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Accepted         bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	ResponseMessage  string                 `protobuf:"bytes,2,opt,name=response_message,json=responseMessage,proto3" json:"response_message,omitempty"`
+	CertificatePem   []byte                 `protobuf:"bytes,3,opt,name=certificate_pem,json=certificatePem,proto3" json:"certificate_pem,omitempty"`
+	CaCertificatePem []byte                 `protobuf:"bytes,4,opt,name=ca_certificate_pem,json=caCertificatePem,proto3" json:"ca_certificate_pem,omitempty"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
 
-func (x *UpdateStatusRequest) Reset() {
-	*x = UpdateStatusRequest{}
-	mi := &file_proto_agent_proto_msgTypes[16]
+func (x *RenewCertificateResponse) Reset() {
+	*x = RenewCertificateResponse{}
+	mi := &file_proto_agent_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateStatusRequest) String() string {
+func (x *RenewCertificateResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateStatusRequest) ProtoMessage() {}
+func (*RenewCertificateResponse) ProtoMessage() {}
 
-func (x *UpdateStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_agent_proto_msgTypes[16]
+func (x *RenewCertificateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1259,69 +2265,63 @@ func (x *UpdateStatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateStatusRequest.ProtoReflect.Descriptor instead.
-func (*UpdateStatusRequest) Descriptor() ([]byte, []int) {
-	return file_proto_agent_proto_rawDescGZIP(), []int{16}
-}
-
-func (x *UpdateStatusRequest) GetNodeId() string {
-	if x != nil {
-		return x.NodeId
-	}
-	return ""
+// Deprecated: Use RenewCertificateResponse.ProtoReflect.Descriptor instead.
+func (*RenewCertificateResponse) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{29}
 }
 
-func (x *UpdateStatusRequest) GetDeploymentId() string {
+func (x *RenewCertificateResponse) GetAccepted() bool {
 	if x != nil {
-		return x.DeploymentId
+		return x.Accepted
 	}
-	return ""
+	return false
 }
 
-func (x *UpdateStatusRequest) GetDeploymentStatus() string {
+func (x *RenewCertificateResponse) GetResponseMessage() string {
 	if x != nil {
-		return x.DeploymentStatus
+		return x.ResponseMessage
 	}
 	return ""
 }
 
-func (x *UpdateStatusRequest) GetStatusMessage() string {
+func (x *RenewCertificateResponse) GetCertificatePem() []byte {
 	if x != nil {
-		return x.StatusMessage
+		return x.CertificatePem
 	}
-	return ""
+	return nil
 }
 
-func (x *UpdateStatusRequest) GetTimestamp() int64 {
+func (x *RenewCertificateResponse) GetCaCertificatePem() []byte {
 	if x != nil {
-		return x.Timestamp
+		return x.CaCertificatePem
 	}
-	return 0
+	return nil
 }
 
-type UpdateStatusResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Acknowledged    bool                   `protobuf:"varint,1,opt,name=acknowledged,proto3" json:"acknowledged,omitempty"`
-	ResponseMessage string                 `protobuf:"bytes,2,opt,name=response_message,json=responseMessage,proto3" json:"response_message,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+// The agent of the node a deployment is assigned to asks for the values of its secrets
+type GetDeploymentSecretsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	DeploymentId  string                 `protobuf:"bytes,2,opt,name=deployment_id,json=deploymentId,proto3" json:"deployment_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateStatusResponse) Reset() {
-	*x = UpdateStatusResponse{}
-	mi := &file_proto_agent_proto_msgTypes[17]
+func (x *GetDeploymentSecretsRequest) Reset() {
+	*x = GetDeploymentSecretsRequest{}
+	mi := &file_proto_agent_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateStatusResponse) String() string {
+func (x *GetDeploymentSecretsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateStatusResponse) ProtoMessage() {}
+func (*GetDeploymentSecretsRequest) ProtoMessage() {}
 
-func (x *UpdateStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_agent_proto_msgTypes[17]
+func (x *GetDeploymentSecretsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1332,62 +2332,50 @@ func (x *UpdateStatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateStatusResponse.ProtoReflect.Descriptor instead.
-func (*UpdateStatusResponse) Descriptor() ([]byte, []int) {
-	return file_proto_agent_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use GetDeploymentSecretsRequest.ProtoReflect.Descriptor instead.
+func (*GetDeploymentSecretsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{30}
 }
 
-func (x *UpdateStatusResponse) GetAcknowledged() bool {
+func (x *GetDeploymentSecretsRequest) GetNodeId() string {
 	if x != nil {
-		return x.Acknowledged
+		return x.NodeId
 	}
-	return false
+	return ""
 }
 
-func (x *UpdateStatusResponse) GetResponseMessage() string {
+func (x *GetDeploymentSecretsRequest) GetDeploymentId() string {
 	if x != nil {
-		return x.ResponseMessage
+		return x.DeploymentId
 	}
 	return ""
 }
 
-// Instance inspection data sent from Agent to Centro after instance is running
-type InstanceData struct {
+type ResolvedSecret struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	InstanceId    string                 `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`                                                  // Instance ID
-	InstanceName  string                 `protobuf:"bytes,2,opt,name=instance_name,json=instanceName,proto3" json:"instance_name,omitempty"`                                            // Instance name
-	Image         string                 `protobuf:"bytes,3,opt,name=image,proto3" json:"image,omitempty"`                                                                              // Full image identifier
-	ImageName     string                 `protobuf:"bytes,4,opt,name=image_name,json=imageName,proto3" json:"image_name,omitempty"`                                                     // Image name (e.g., "nginx:latest")
-	Command       []string               `protobuf:"bytes,5,rep,name=command,proto3" json:"command,omitempty"`                                                                          // Command executed in instance
-	Args          []string               `protobuf:"bytes,6,rep,name=args,proto3" json:"args,omitempty"`                                                                                // Arguments passed to command
-	Created       string                 `protobuf:"bytes,7,opt,name=created,proto3" json:"created,omitempty"`                                                                          // Creation timestamp
-	StartedAt     string                 `protobuf:"bytes,8,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`                                                     // Instance start timestamp
-	FinishedAt    string                 `protobuf:"bytes,9,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`                                                  // Instance finish timestamp (if stopped)
-	Status        string                 `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`                                                                           // Instance status: "running", "stopped", "exited", "failed"
-	ExitCode      int32                  `protobuf:"varint,11,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`                                                      // Exit code (if stopped)
-	Pid           int32                  `protobuf:"varint,12,opt,name=pid,proto3" json:"pid,omitempty"`                                                                                // Process ID
-	Labels        map[string]string      `protobuf:"bytes,13,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Instance labels
-	Ports         []string               `protobuf:"bytes,14,rep,name=ports,proto3" json:"ports,omitempty"`                                                                             // Exposed ports
-	Volumes       []string               `protobuf:"bytes,15,rep,name=volumes,proto3" json:"volumes,omitempty"`                                                                         // Mounted volumes
+	Secret        string                 `protobuf:"bytes,1,opt,name=secret,proto3" json:"secret,omitempty"`
+	Env           string                 `protobuf:"bytes,2,opt,name=env,proto3" json:"env,omitempty"`
+	File          string                 `protobuf:"bytes,3,opt,name=file,proto3" json:"file,omitempty"`
+	Value         []byte                 `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *InstanceData) Reset() {
-	*x = InstanceData{}
-	mi := &file_proto_agent_proto_msgTypes[18]
+func (x *ResolvedSecret) Reset() {
+	*x = ResolvedSecret{}
+	mi := &file_proto_agent_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *InstanceData) String() string {
+func (x *ResolvedSecret) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*InstanceData) ProtoMessage() {}
+func (*ResolvedSecret) ProtoMessage() {}
 
-func (x *InstanceData) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_agent_proto_msgTypes[18]
+func (x *ResolvedSecret) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1398,141 +2386,176 @@ func (x *InstanceData) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use InstanceData.ProtoReflect.Descriptor instead.
-func (*InstanceData) Descriptor() ([]byte, []int) {
-	return file_proto_agent_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use ResolvedSecret.ProtoReflect.Descriptor instead.
+func (*ResolvedSecret) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *InstanceData) GetInstanceId() string {
+func (x *ResolvedSecret) GetSecret() string {
 	if x != nil {
-		return x.InstanceId
+		return x.Secret
 	}
 	return ""
 }
 
-func (x *InstanceData) GetInstanceName() string {
+func (x *ResolvedSecret) GetEnv() string {
 	if x != nil {
-		return x.InstanceName
+		return x.Env
 	}
 	return ""
 }
 
-func (x *InstanceData) GetImage() string {
+func (x *ResolvedSecret) GetFile() string {
 	if x != nil {
-		return x.Image
+		return x.File
 	}
 	return ""
 }
 
-func (x *InstanceData) GetImageName() string {
+func (x *ResolvedSecret) GetValue() []byte {
 	if x != nil {
-		return x.ImageName
+		return x.Value
 	}
-	return ""
+	return nil
 }
 
-func (x *InstanceData) GetCommand() []string {
-	if x != nil {
-		return x.Command
-	}
-	return nil
+type GetDeploymentSecretsResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Accepted        bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	ResponseMessage string                 `protobuf:"bytes,2,opt,name=response_message,json=responseMessage,proto3" json:"response_message,omitempty"`
+	Secrets         []*ResolvedSecret      `protobuf:"bytes,3,rep,name=secrets,proto3" json:"secrets,omitempty"` // Values in the order of the references of the deployment
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
-func (x *InstanceData) GetArgs() []string {
-	if x != nil {
-		return x.Args
-	}
-	return nil
+func (x *GetDeploymentSecretsResponse) Reset() {
+	*x = GetDeploymentSecretsResponse{}
+	mi := &file_proto_agent_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *InstanceData) GetCreated() string {
-	if x != nil {
-		return x.Created
-	}
-	return ""
+func (x *GetDeploymentSecretsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *InstanceData) GetStartedAt() string {
+func (*GetDeploymentSecretsResponse) ProtoMessage() {}
+
+func (x *GetDeploymentSecretsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[32]
 	if x != nil {
-		return x.StartedAt
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *InstanceData) GetFinishedAt() string {
+// Deprecated: Use GetDeploymentSecretsResponse.ProtoReflect.Descriptor instead.
+func (*GetDeploymentSecretsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GetDeploymentSecretsResponse) GetAccepted() bool {
 	if x != nil {
-		return x.FinishedAt
+		return x.Accepted
 	}
-	return ""
+	return false
 }
 
-func (x *InstanceData) GetStatus() string {
+func (x *GetDeploymentSecretsResponse) GetResponseMessage() string {
 	if x != nil {
-		return x.Status
+		return x.ResponseMessage
 	}
 	return ""
 }
 
-func (x *InstanceData) GetExitCode() int32 {
+func (x *GetDeploymentSecretsResponse) GetSecrets() []*ResolvedSecret {
 	if x != nil {
-		return x.ExitCode
+		return x.Secrets
 	}
-	return 0
+	return nil
 }
 
-func (x *InstanceData) GetPid() int32 {
-	if x != nil {
-		return x.Pid
-	}
-	return 0
+// The agent of the node a deployment is assigned to asks for the content of its config files
+type GetDeploymentConfigsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	DeploymentId  string                 `protobuf:"bytes,2,opt,name=deployment_id,json=deploymentId,proto3" json:"deployment_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *InstanceData) GetLabels() map[string]string {
+func (x *GetDeploymentConfigsRequest) Reset() {
+	*x = GetDeploymentConfigsRequest{}
+	mi := &file_proto_agent_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeploymentConfigsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeploymentConfigsRequest) ProtoMessage() {}
+
+func (x *GetDeploymentConfigsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[33]
 	if x != nil {
-		return x.Labels
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *InstanceData) GetPorts() []string {
+// Deprecated: Use GetDeploymentConfigsRequest.ProtoReflect.Descriptor instead.
+func (*GetDeploymentConfigsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *GetDeploymentConfigsRequest) GetNodeId() string {
 	if x != nil {
-		return x.Ports
+		return x.NodeId
 	}
-	return nil
+	return ""
 }
 
-func (x *InstanceData) GetVolumes() []string {
+func (x *GetDeploymentConfigsRequest) GetDeploymentId() string {
 	if x != nil {
-		return x.Volumes
+		return x.DeploymentId
 	}
-	return nil
+	return ""
 }
 
-type SetInstanceDataRequest struct {
+type RenderedConfig struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`                   // Node ID where instance is running
-	DeploymentId  string                 `protobuf:"bytes,2,opt,name=deployment_id,json=deploymentId,proto3" json:"deployment_id,omitempty"` // Deployment ID associated with the instance
-	InstanceData  *InstanceData          `protobuf:"bytes,3,opt,name=instance_data,json=instanceData,proto3" json:"instance_data,omitempty"` // Instance inspection data
-	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                          // Timestamp when data was collected
+	Config        string                 `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	File          string                 `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"`
+	Content       []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SetInstanceDataRequest) Reset() {
-	*x = SetInstanceDataRequest{}
-	mi := &file_proto_agent_proto_msgTypes[19]
+func (x *RenderedConfig) Reset() {
+	*x = RenderedConfig{}
+	mi := &file_proto_agent_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetInstanceDataRequest) String() string {
+func (x *RenderedConfig) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetInstanceDataRequest) ProtoMessage() {}
+func (*RenderedConfig) ProtoMessage() {}
 
-func (x *SetInstanceDataRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_agent_proto_msgTypes[19]
+func (x *RenderedConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1543,62 +2566,56 @@ func (x *SetInstanceDataRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetInstanceDataRequest.ProtoReflect.Descriptor instead.
-func (*SetInstanceDataRequest) Descriptor() ([]byte, []int) {
-	return file_proto_agent_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use RenderedConfig.ProtoReflect.Descriptor instead.
+func (*RenderedConfig) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{34}
 }
 
-func (x *SetInstanceDataRequest) GetNodeId() string {
+func (x *RenderedConfig) GetConfig() string {
 	if x != nil {
-		return x.NodeId
+		return x.Config
 	}
 	return ""
 }
 
-func (x *SetInstanceDataRequest) GetDeploymentId() string {
+func (x *RenderedConfig) GetFile() string {
 	if x != nil {
-		return x.DeploymentId
+		return x.File
 	}
 	return ""
 }
 
-func (x *SetInstanceDataRequest) GetInstanceData() *InstanceData {
+func (x *RenderedConfig) GetContent() []byte {
 	if x != nil {
-		return x.InstanceData
+		return x.Content
 	}
 	return nil
 }
 
-func (x *SetInstanceDataRequest) GetTimestamp() int64 {
-	if x != nil {
-		return x.Timestamp
-	}
-	return 0
-}
-
-type SetInstanceDataResponse struct {
+type GetDeploymentConfigsResponse struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
-	Acknowledged    bool                   `protobuf:"varint,1,opt,name=acknowledged,proto3" json:"acknowledged,omitempty"`
+	Accepted        bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
 	ResponseMessage string                 `protobuf:"bytes,2,opt,name=response_message,json=responseMessage,proto3" json:"response_message,omitempty"`
+	Configs         []*RenderedConfig      `protobuf:"bytes,3,rep,name=configs,proto3" json:"configs,omitempty"` // Files in the order of the references of the deployment
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
 
-func (x *SetInstanceDataResponse) Reset() {
-	*x = SetInstanceDataResponse{}
-	mi := &file_proto_agent_proto_msgTypes[20]
+func (x *GetDeploymentConfigsResponse) Reset() {
+	*x = GetDeploymentConfigsResponse{}
+	mi := &file_proto_agent_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SetInstanceDataResponse) String() string {
+func (x *GetDeploymentConfigsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SetInstanceDataResponse) ProtoMessage() {}
+func (*GetDeploymentConfigsResponse) ProtoMessage() {}
 
-func (x *SetInstanceDataResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_agent_proto_msgTypes[20]
+func (x *GetDeploymentConfigsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_agent_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1609,25 +2626,32 @@ func (x *SetInstanceDataResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SetInstanceDataResponse.ProtoReflect.Descriptor instead.
-func (*SetInstanceDataResponse) Descriptor() ([]byte, []int) {
-	return file_proto_agent_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use GetDeploymentConfigsResponse.ProtoReflect.Descriptor instead.
+func (*GetDeploymentConfigsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_agent_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *SetInstanceDataResponse) GetAcknowledged() bool {
+func (x *GetDeploymentConfigsResponse) GetAccepted() bool {
 	if x != nil {
-		return x.Acknowledged
+		return x.Accepted
 	}
 	return false
 }
 
-func (x *SetInstanceDataResponse) GetResponseMessage() string {
+func (x *GetDeploymentConfigsResponse) GetResponseMessage() string {
 	if x != nil {
 		return x.ResponseMessage
 	}
 	return ""
 }
 
+func (x *GetDeploymentConfigsResponse) GetConfigs() []*RenderedConfig {
+	if x != nil {
+		return x.Configs
+	}
+	return nil
+}
+
 var File_proto_agent_proto protoreflect.FileDescriptor
 
 const file_proto_agent_proto_rawDesc = "" +
@@ -1648,8 +2672,7 @@ const file_proto_agent_proto_rawDesc = "" +
 	"\facknowledged\x18\x01 \x01(\bR\facknowledged\x12)\n" +
 	"\x10response_message\x18\x02 \x01(\tR\x0fresponseMessage\"/\n" +
 	"\x14GetDeploymentRequest\x12\x17\n" +
-	"\anode_id\x18\x01 \x01(\tR\x06nodeId\"\xec\n" +
-	"\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\"\xef\r\n" +
 	"\n" +
 	"Deployment\x12#\n" +
 	"\rdeployment_id\x18\x01 \x01(\tR\fdeploymentId\x12'\n" +
@@ -1681,13 +2704,48 @@ const file_proto_agent_proto_rawDesc = "" +
 	"\fhealth_check\x18\x15 \x01(\v2\x16.scheduler.HealthCheckR\vhealthCheck\x12?\n" +
 	"\x0erestart_policy\x18\x16 \x01(\v2\x18.scheduler.RestartPolicyR\rrestartPolicy\x127\n" +
 	"\bnetworks\x18\x17 \x03(\v2\x1b.scheduler.NetworkReferenceR\bnetworks\x12#\n" +
-	"\rinstance_type\x18\x18 \x01(\tR\finstanceType\x1aG\n" +
+	"\rinstance_type\x18\x18 \x01(\tR\finstanceType\x121\n" +
+	"\x06update\x18\x1a \x01(\v2\x19.scheduler.UpdateStrategyR\x06update\x120\n" +
+	"\x14parent_deployment_id\x18\x1b \x01(\tR\x12parentDeploymentId\x12#\n" +
+	"\rspec_revision\x18\x1c \x01(\x03R\fspecRevision\x12$\n" +
+	"\x0etarget_node_id\x18\x1d \x01(\tR\ftargetNodeId\x12/\n" +
+	"\bperiodic\x18\x1e \x01(\v2\x13.scheduler.PeriodicR\bperiodic\x124\n" +
+	"\n" +
+	"depends_on\x18\x1f \x03(\v2\x15.scheduler.DependencyR\tdependsOn\x124\n" +
+	"\asecrets\x18  \x03(\v2\x1a.scheduler.SecretReferenceR\asecrets\x124\n" +
+	"\aconfigs\x18! \x03(\v2\x1a.scheduler.ConfigReferenceR\aconfigs\x1aG\n" +
 	"\x19EnvironmentVariablesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aE\n" +
 	"\x17DeploymentMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb7\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"O\n" +
+	"\x0fSecretReference\x12\x16\n" +
+	"\x06secret\x18\x01 \x01(\tR\x06secret\x12\x10\n" +
+	"\x03env\x18\x02 \x01(\tR\x03env\x12\x12\n" +
+	"\x04file\x18\x03 \x01(\tR\x04file\"Y\n" +
+	"\x0fConfigReference\x12\x16\n" +
+	"\x06config\x18\x01 \x01(\tR\x06config\x12\x12\n" +
+	"\x04file\x18\x02 \x01(\tR\x04file\x12\x1a\n" +
+	"\btemplate\x18\x03 \x01(\bR\btemplate\"O\n" +
+	"\n" +
+	"Dependency\x12#\n" +
+	"\rdeployment_id\x18\x01 \x01(\tR\fdeploymentId\x12\x1c\n" +
+	"\tcondition\x18\x02 \x01(\tR\tcondition\"f\n" +
+	"\bPeriodic\x12\x12\n" +
+	"\x04cron\x18\x01 \x01(\tR\x04cron\x12\x1b\n" +
+	"\ttime_zone\x18\x02 \x01(\tR\btimeZone\x12)\n" +
+	"\x10prohibit_overlap\x18\x03 \x01(\bR\x0fprohibitOverlap\"\xa8\x02\n" +
+	"\x0eUpdateStrategy\x12!\n" +
+	"\fmax_parallel\x18\x01 \x01(\x05R\vmaxParallel\x12\x1b\n" +
+	"\tmax_surge\x18\x02 \x01(\x05R\bmaxSurge\x12(\n" +
+	"\x10min_healthy_time\x18\x03 \x01(\tR\x0eminHealthyTime\x12)\n" +
+	"\x10healthy_deadline\x18\x04 \x01(\tR\x0fhealthyDeadline\x12\x1f\n" +
+	"\vauto_revert\x18\x05 \x01(\bR\n" +
+	"autoRevert\x12\x1a\n" +
+	"\bstrategy\x18\x06 \x01(\tR\bstrategy\x12\x16\n" +
+	"\x06canary\x18\a \x01(\x05R\x06canary\x12,\n" +
+	"\x12auto_promote_after\x18\b \x01(\tR\x10autoPromoteAfter\"\xb7\x01\n" +
 	"\tResources\x12&\n" +
 	"\x0fmemory_limit_mb\x18\x01 \x01(\x03R\rmemoryLimitMb\x12,\n" +
 	"\x12memory_reserved_mb\x18\x02 \x01(\x03R\x10memoryReservedMb\x12&\n" +
@@ -1763,10 +2821,11 @@ const file_proto_agent_proto_rawDesc = "" +
 	"\rdeployment_id\x18\x02 \x01(\tR\fdeploymentId\x12+\n" +
 	"\x11deployment_status\x18\x03 \x01(\tR\x10deploymentStatus\x12%\n" +
 	"\x0estatus_message\x18\x04 \x01(\tR\rstatusMessage\x12\x1c\n" +
-	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\"e\n" +
+	"\ttimestamp\x18\x05 \x01(\x03R\ttimestamp\"\x8a\x01\n" +
 	"\x14UpdateStatusResponse\x12\"\n" +
 	"\facknowledged\x18\x01 \x01(\bR\facknowledged\x12)\n" +
-	"\x10response_message\x18\x02 \x01(\tR\x0fresponseMessage\"\x80\x04\n" +
+	"\x10response_message\x18\x02 \x01(\tR\x0fresponseMessage\x12#\n" +
+	"\rstop_instance\x18\x03 \x01(\bR\fstopInstance\"\x98\x04\n" +
 	"\fInstanceData\x12\x1f\n" +
 	"\vinstance_id\x18\x01 \x01(\tR\n" +
 	"instanceId\x12#\n" +
@@ -1787,7 +2846,8 @@ const file_proto_agent_proto_rawDesc = "" +
 	"\x03pid\x18\f \x01(\x05R\x03pid\x12;\n" +
 	"\x06labels\x18\r \x03(\v2#.scheduler.InstanceData.LabelsEntryR\x06labels\x12\x14\n" +
 	"\x05ports\x18\x0e \x03(\tR\x05ports\x12\x18\n" +
-	"\avolumes\x18\x0f \x03(\tR\avolumes\x1a9\n" +
+	"\avolumes\x18\x0f \x03(\tR\avolumes\x12\x16\n" +
+	"\x06health\x18\x10 \x01(\tR\x06health\x1a9\n" +
 	"\vLabelsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb2\x01\n" +
@@ -1798,12 +2858,57 @@ const file_proto_agent_proto_rawDesc = "" +
 	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"h\n" +
 	"\x17SetInstanceDataResponse\x12\"\n" +
 	"\facknowledged\x18\x01 \x01(\bR\facknowledged\x12)\n" +
-	"\x10response_message\x18\x02 \x01(\tR\x0fresponseMessage2\xdf\x02\n" +
+	"\x10response_message\x18\x02 \x01(\tR\x0fresponseMessage\"b\n" +
+	"\x0fJoinNodeRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x1d\n" +
+	"\n" +
+	"join_token\x18\x02 \x01(\tR\tjoinToken\x12\x17\n" +
+	"\acsr_pem\x18\x03 \x01(\fR\x06csrPem\"\xb0\x01\n" +
+	"\x10JoinNodeResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\x12)\n" +
+	"\x10response_message\x18\x02 \x01(\tR\x0fresponseMessage\x12'\n" +
+	"\x0fcertificate_pem\x18\x03 \x01(\fR\x0ecertificatePem\x12,\n" +
+	"\x12ca_certificate_pem\x18\x04 \x01(\fR\x10caCertificatePem\"K\n" +
+	"\x17RenewCertificateRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x17\n" +
+	"\acsr_pem\x18\x02 \x01(\fR\x06csrPem\"\xb8\x01\n" +
+	"\x18RenewCertificateResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\x12)\n" +
+	"\x10response_message\x18\x02 \x01(\tR\x0fresponseMessage\x12'\n" +
+	"\x0fcertificate_pem\x18\x03 \x01(\fR\x0ecertificatePem\x12,\n" +
+	"\x12ca_certificate_pem\x18\x04 \x01(\fR\x10caCertificatePem\"[\n" +
+	"\x1bGetDeploymentSecretsRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12#\n" +
+	"\rdeployment_id\x18\x02 \x01(\tR\fdeploymentId\"d\n" +
+	"\x0eResolvedSecret\x12\x16\n" +
+	"\x06secret\x18\x01 \x01(\tR\x06secret\x12\x10\n" +
+	"\x03env\x18\x02 \x01(\tR\x03env\x12\x12\n" +
+	"\x04file\x18\x03 \x01(\tR\x04file\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\fR\x05value\"\x9a\x01\n" +
+	"\x1cGetDeploymentSecretsResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\x12)\n" +
+	"\x10response_message\x18\x02 \x01(\tR\x0fresponseMessage\x123\n" +
+	"\asecrets\x18\x03 \x03(\v2\x19.scheduler.ResolvedSecretR\asecrets\"[\n" +
+	"\x1bGetDeploymentConfigsRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12#\n" +
+	"\rdeployment_id\x18\x02 \x01(\tR\fdeploymentId\"V\n" +
+	"\x0eRenderedConfig\x12\x16\n" +
+	"\x06config\x18\x01 \x01(\tR\x06config\x12\x12\n" +
+	"\x04file\x18\x02 \x01(\tR\x04file\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\"\x9a\x01\n" +
+	"\x1cGetDeploymentConfigsResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\x12)\n" +
+	"\x10response_message\x18\x02 \x01(\tR\x0fresponseMessage\x123\n" +
+	"\aconfigs\x18\x03 \x03(\v2\x19.scheduler.RenderedConfigR\aconfigs2\xd3\x05\n" +
 	"\x16CentroSchedulerService\x12F\n" +
 	"\tHeartbeat\x12\x1b.scheduler.HeartbeatRequest\x1a\x1c.scheduler.HeartbeatResponse\x12R\n" +
 	"\rGetDeployment\x12\x1f.scheduler.GetDeploymentRequest\x1a .scheduler.GetDeploymentResponse\x12O\n" +
 	"\fUpdateStatus\x12\x1e.scheduler.UpdateStatusRequest\x1a\x1f.scheduler.UpdateStatusResponse\x12X\n" +
-	"\x0fSetInstanceData\x12!.scheduler.SetInstanceDataRequest\x1a\".scheduler.SetInstanceDataResponseB!Z\x1fgithub.com/open-scheduler/protob\x06proto3"
+	"\x0fSetInstanceData\x12!.scheduler.SetInstanceDataRequest\x1a\".scheduler.SetInstanceDataResponse\x12C\n" +
+	"\bJoinNode\x12\x1a.scheduler.JoinNodeRequest\x1a\x1b.scheduler.JoinNodeResponse\x12[\n" +
+	"\x10RenewCertificate\x12\".scheduler.RenewCertificateRequest\x1a#.scheduler.RenewCertificateResponse\x12g\n" +
+	"\x14GetDeploymentSecrets\x12&.scheduler.GetDeploymentSecretsRequest\x1a'.scheduler.GetDeploymentSecretsResponse\x12g\n" +
+	"\x14GetDeploymentConfigs\x12&.scheduler.GetDeploymentConfigsRequest\x1a'.scheduler.GetDeploymentConfigsResponseB!Z\x1fgithub.com/open-scheduler/protob\x06proto3"
 
 var (
 	file_proto_agent_proto_rawDescOnce sync.Once
@@ -1817,69 +2922,99 @@ func file_proto_agent_proto_rawDescGZIP() []byte {
 	return file_proto_agent_proto_rawDescData
 }
 
-var file_proto_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
+var file_proto_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 42)
 var file_proto_agent_proto_goTypes = []any{
-	(*HeartbeatRequest)(nil),        // 0: scheduler.HeartbeatRequest
-	(*HeartbeatResponse)(nil),       // 1: scheduler.HeartbeatResponse
-	(*GetDeploymentRequest)(nil),    // 2: scheduler.GetDeploymentRequest
-	(*Deployment)(nil),              // 3: scheduler.Deployment
-	(*Resources)(nil),               // 4: scheduler.Resources
-	(*Volume)(nil),                  // 5: scheduler.Volume
-	(*Placement)(nil),               // 6: scheduler.Placement
-	(*PortMapping)(nil),             // 7: scheduler.PortMapping
-	(*SecuritySettings)(nil),        // 8: scheduler.SecuritySettings
-	(*HealthCheck)(nil),             // 9: scheduler.HealthCheck
-	(*RestartPolicy)(nil),           // 10: scheduler.RestartPolicy
-	(*NetworkReference)(nil),        // 11: scheduler.NetworkReference
-	(*ImageSource)(nil),             // 12: scheduler.ImageSource
-	(*Device)(nil),                  // 13: scheduler.Device
-	(*InstanceSpec)(nil),            // 14: scheduler.InstanceSpec
-	(*GetDeploymentResponse)(nil),   // 15: scheduler.GetDeploymentResponse
-	(*UpdateStatusRequest)(nil),     // 16: scheduler.UpdateStatusRequest
-	(*UpdateStatusResponse)(nil),    // 17: scheduler.UpdateStatusResponse
-	(*InstanceData)(nil),            // 18: scheduler.InstanceData
-	(*SetInstanceDataRequest)(nil),  // 19: scheduler.SetInstanceDataRequest
-	(*SetInstanceDataResponse)(nil), // 20: scheduler.SetInstanceDataResponse
-	nil,                             // 21: scheduler.HeartbeatRequest.NodeMetadataEntry
-	nil,                             // 22: scheduler.Deployment.EnvironmentVariablesEntry
-	nil,                             // 23: scheduler.Deployment.DeploymentMetadataEntry
-	nil,                             // 24: scheduler.Device.PropertiesEntry
-	nil,                             // 25: scheduler.InstanceSpec.DriverOptionsEntry
-	nil,                             // 26: scheduler.InstanceData.LabelsEntry
+	(*HeartbeatRequest)(nil),             // 0: scheduler.HeartbeatRequest
+	(*HeartbeatResponse)(nil),            // 1: scheduler.HeartbeatResponse
+	(*GetDeploymentRequest)(nil),         // 2: scheduler.GetDeploymentRequest
+	(*Deployment)(nil),                   // 3: scheduler.Deployment
+	(*SecretReference)(nil),              // 4: scheduler.SecretReference
+	(*ConfigReference)(nil),              // 5: scheduler.ConfigReference
+	(*Dependency)(nil),                   // 6: scheduler.Dependency
+	(*Periodic)(nil),                     // 7: scheduler.Periodic
+	(*UpdateStrategy)(nil),               // 8: scheduler.UpdateStrategy
+	(*Resources)(nil),                    // 9: scheduler.Resources
+	(*Volume)(nil),                       // 10: scheduler.Volume
+	(*Placement)(nil),                    // 11: scheduler.Placement
+	(*PortMapping)(nil),                  // 12: scheduler.PortMapping
+	(*SecuritySettings)(nil),             // 13: scheduler.SecuritySettings
+	(*HealthCheck)(nil),                  // 14: scheduler.HealthCheck
+	(*RestartPolicy)(nil),                // 15: scheduler.RestartPolicy
+	(*NetworkReference)(nil),             // 16: scheduler.NetworkReference
+	(*ImageSource)(nil),                  // 17: scheduler.ImageSource
+	(*Device)(nil),                       // 18: scheduler.Device
+	(*InstanceSpec)(nil),                 // 19: scheduler.InstanceSpec
+	(*GetDeploymentResponse)(nil),        // 20: scheduler.GetDeploymentResponse
+	(*UpdateStatusRequest)(nil),          // 21: scheduler.UpdateStatusRequest
+	(*UpdateStatusResponse)(nil),         // 22: scheduler.UpdateStatusResponse
+	(*InstanceData)(nil),                 // 23: scheduler.InstanceData
+	(*SetInstanceDataRequest)(nil),       // 24: scheduler.SetInstanceDataRequest
+	(*SetInstanceDataResponse)(nil),      // 25: scheduler.SetInstanceDataResponse
+	(*JoinNodeRequest)(nil),              // 26: scheduler.JoinNodeRequest
+	(*JoinNodeResponse)(nil),             // 27: scheduler.JoinNodeResponse
+	(*RenewCertificateRequest)(nil),      // 28: scheduler.RenewCertificateRequest
+	(*RenewCertificateResponse)(nil),     // 29: scheduler.RenewCertificateResponse
+	(*GetDeploymentSecretsRequest)(nil),  // 30: scheduler.GetDeploymentSecretsRequest
+	(*ResolvedSecret)(nil),               // 31: scheduler.ResolvedSecret
+	(*GetDeploymentSecretsResponse)(nil), // 32: scheduler.GetDeploymentSecretsResponse
+	(*GetDeploymentConfigsRequest)(nil),  // 33: scheduler.GetDeploymentConfigsRequest
+	(*RenderedConfig)(nil),               // 34: scheduler.RenderedConfig
+	(*GetDeploymentConfigsResponse)(nil), // 35: scheduler.GetDeploymentConfigsResponse
+	nil,                                  // 36: scheduler.HeartbeatRequest.NodeMetadataEntry
+	nil,                                  // 37: scheduler.Deployment.EnvironmentVariablesEntry
+	nil,                                  // 38: scheduler.Deployment.DeploymentMetadataEntry
+	nil,                                  // 39: scheduler.Device.PropertiesEntry
+	nil,                                  // 40: scheduler.InstanceSpec.DriverOptionsEntry
+	nil,                                  // 41: scheduler.InstanceData.LabelsEntry
 }
 var file_proto_agent_proto_depIdxs = []int32{
-	21, // 0: scheduler.HeartbeatRequest.node_metadata:type_name -> scheduler.HeartbeatRequest.NodeMetadataEntry
-	14, // 1: scheduler.Deployment.instance_config:type_name -> scheduler.InstanceSpec
-	22, // 2: scheduler.Deployment.environment_variables:type_name -> scheduler.Deployment.EnvironmentVariablesEntry
-	4,  // 3: scheduler.Deployment.resource_requirements:type_name -> scheduler.Resources
-	5,  // 4: scheduler.Deployment.volume_mounts:type_name -> scheduler.Volume
-	23, // 5: scheduler.Deployment.deployment_metadata:type_name -> scheduler.Deployment.DeploymentMetadataEntry
-	6,  // 6: scheduler.Deployment.placement:type_name -> scheduler.Placement
-	7,  // 7: scheduler.Deployment.ports:type_name -> scheduler.PortMapping
-	8,  // 8: scheduler.Deployment.security:type_name -> scheduler.SecuritySettings
-	9,  // 9: scheduler.Deployment.health_check:type_name -> scheduler.HealthCheck
-	10, // 10: scheduler.Deployment.restart_policy:type_name -> scheduler.RestartPolicy
-	11, // 11: scheduler.Deployment.networks:type_name -> scheduler.NetworkReference
-	24, // 12: scheduler.Device.properties:type_name -> scheduler.Device.PropertiesEntry
-	25, // 13: scheduler.InstanceSpec.driver_options:type_name -> scheduler.InstanceSpec.DriverOptionsEntry
-	12, // 14: scheduler.InstanceSpec.image_source:type_name -> scheduler.ImageSource
-	13, // 15: scheduler.InstanceSpec.devices:type_name -> scheduler.Device
-	3,  // 16: scheduler.GetDeploymentResponse.deployment:type_name -> scheduler.Deployment
-	26, // 17: scheduler.InstanceData.labels:type_name -> scheduler.InstanceData.LabelsEntry
-	18, // 18: scheduler.SetInstanceDataRequest.instance_data:type_name -> scheduler.InstanceData
-	0,  // 19: scheduler.CentroSchedulerService.Heartbeat:input_type -> scheduler.HeartbeatRequest
-	2,  // 20: scheduler.CentroSchedulerService.GetDeployment:input_type -> scheduler.GetDeploymentRequest
-	16, // 21: scheduler.CentroSchedulerService.UpdateStatus:input_type -> scheduler.UpdateStatusRequest
-	19, // 22: scheduler.CentroSchedulerService.SetInstanceData:input_type -> scheduler.SetInstanceDataRequest
-	1,  // 23: scheduler.CentroSchedulerService.Heartbeat:output_type -> scheduler.HeartbeatResponse
-	15, // 24: scheduler.CentroSchedulerService.GetDeployment:output_type -> scheduler.GetDeploymentResponse
-	17, // 25: scheduler.CentroSchedulerService.UpdateStatus:output_type -> scheduler.UpdateStatusResponse
-	20, // 26: scheduler.CentroSchedulerService.SetInstanceData:output_type -> scheduler.SetInstanceDataResponse
-	23, // [23:27] is the sub-list for method output_type
-	19, // [19:23] is the sub-list for method input_type
-	19, // [19:19] is the sub-list for extension type_name
-	19, // [19:19] is the sub-list for extension extendee
-	0,  // [0:19] is the sub-list for field type_name
+	36, // 0: scheduler.HeartbeatRequest.node_metadata:type_name -> scheduler.HeartbeatRequest.NodeMetadataEntry
+	19, // 1: scheduler.Deployment.instance_config:type_name -> scheduler.InstanceSpec
+	37, // 2: scheduler.Deployment.environment_variables:type_name -> scheduler.Deployment.EnvironmentVariablesEntry
+	9,  // 3: scheduler.Deployment.resource_requirements:type_name -> scheduler.Resources
+	10, // 4: scheduler.Deployment.volume_mounts:type_name -> scheduler.Volume
+	38, // 5: scheduler.Deployment.deployment_metadata:type_name -> scheduler.Deployment.DeploymentMetadataEntry
+	11, // 6: scheduler.Deployment.placement:type_name -> scheduler.Placement
+	12, // 7: scheduler.Deployment.ports:type_name -> scheduler.PortMapping
+	13, // 8: scheduler.Deployment.security:type_name -> scheduler.SecuritySettings
+	14, // 9: scheduler.Deployment.health_check:type_name -> scheduler.HealthCheck
+	15, // 10: scheduler.Deployment.restart_policy:type_name -> scheduler.RestartPolicy
+	16, // 11: scheduler.Deployment.networks:type_name -> scheduler.NetworkReference
+	8,  // 12: scheduler.Deployment.update:type_name -> scheduler.UpdateStrategy
+	7,  // 13: scheduler.Deployment.periodic:type_name -> scheduler.Periodic
+	6,  // 14: scheduler.Deployment.depends_on:type_name -> scheduler.Dependency
+	4,  // 15: scheduler.Deployment.secrets:type_name -> scheduler.SecretReference
+	5,  // 16: scheduler.Deployment.configs:type_name -> scheduler.ConfigReference
+	39, // 17: scheduler.Device.properties:type_name -> scheduler.Device.PropertiesEntry
+	40, // 18: scheduler.InstanceSpec.driver_options:type_name -> scheduler.InstanceSpec.DriverOptionsEntry
+	17, // 19: scheduler.InstanceSpec.image_source:type_name -> scheduler.ImageSource
+	18, // 20: scheduler.InstanceSpec.devices:type_name -> scheduler.Device
+	3,  // 21: scheduler.GetDeploymentResponse.deployment:type_name -> scheduler.Deployment
+	41, // 22: scheduler.InstanceData.labels:type_name -> scheduler.InstanceData.LabelsEntry
+	23, // 23: scheduler.SetInstanceDataRequest.instance_data:type_name -> scheduler.InstanceData
+	31, // 24: scheduler.GetDeploymentSecretsResponse.secrets:type_name -> scheduler.ResolvedSecret
+	34, // 25: scheduler.GetDeploymentConfigsResponse.configs:type_name -> scheduler.RenderedConfig
+	0,  // 26: scheduler.CentroSchedulerService.Heartbeat:input_type -> scheduler.HeartbeatRequest
+	2,  // 27: scheduler.CentroSchedulerService.GetDeployment:input_type -> scheduler.GetDeploymentRequest
+	21, // 28: scheduler.CentroSchedulerService.UpdateStatus:input_type -> scheduler.UpdateStatusRequest
+	24, // 29: scheduler.CentroSchedulerService.SetInstanceData:input_type -> scheduler.SetInstanceDataRequest
+	26, // 30: scheduler.CentroSchedulerService.JoinNode:input_type -> scheduler.JoinNodeRequest
+	28, // 31: scheduler.CentroSchedulerService.RenewCertificate:input_type -> scheduler.RenewCertificateRequest
+	30, // 32: scheduler.CentroSchedulerService.GetDeploymentSecrets:input_type -> scheduler.GetDeploymentSecretsRequest
+	33, // 33: scheduler.CentroSchedulerService.GetDeploymentConfigs:input_type -> scheduler.GetDeploymentConfigsRequest
+	1,  // 34: scheduler.CentroSchedulerService.Heartbeat:output_type -> scheduler.HeartbeatResponse
+	20, // 35: scheduler.CentroSchedulerService.GetDeployment:output_type -> scheduler.GetDeploymentResponse
+	22, // 36: scheduler.CentroSchedulerService.UpdateStatus:output_type -> scheduler.UpdateStatusResponse
+	25, // 37: scheduler.CentroSchedulerService.SetInstanceData:output_type -> scheduler.SetInstanceDataResponse
+	27, // 38: scheduler.CentroSchedulerService.JoinNode:output_type -> scheduler.JoinNodeResponse
+	29, // 39: scheduler.CentroSchedulerService.RenewCertificate:output_type -> scheduler.RenewCertificateResponse
+	32, // 40: scheduler.CentroSchedulerService.GetDeploymentSecrets:output_type -> scheduler.GetDeploymentSecretsResponse
+	35, // 41: scheduler.CentroSchedulerService.GetDeploymentConfigs:output_type -> scheduler.GetDeploymentConfigsResponse
+	34, // [34:42] is the sub-list for method output_type
+	26, // [26:34] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_proto_agent_proto_init() }
@@ -1893,7 +3028,7 @@ func file_proto_agent_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_agent_proto_rawDesc), len(file_proto_agent_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   27,
+			NumMessages:   42,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
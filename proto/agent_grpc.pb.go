@@ -19,10 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CentroSchedulerService_Heartbeat_FullMethodName       = "/scheduler.CentroSchedulerService/Heartbeat"
-	CentroSchedulerService_GetDeployment_FullMethodName   = "/scheduler.CentroSchedulerService/GetDeployment"
-	CentroSchedulerService_UpdateStatus_FullMethodName    = "/scheduler.CentroSchedulerService/UpdateStatus"
-	CentroSchedulerService_SetInstanceData_FullMethodName = "/scheduler.CentroSchedulerService/SetInstanceData"
+	CentroSchedulerService_Heartbeat_FullMethodName            = "/scheduler.CentroSchedulerService/Heartbeat"
+	CentroSchedulerService_GetDeployment_FullMethodName        = "/scheduler.CentroSchedulerService/GetDeployment"
+	CentroSchedulerService_UpdateStatus_FullMethodName         = "/scheduler.CentroSchedulerService/UpdateStatus"
+	CentroSchedulerService_SetInstanceData_FullMethodName      = "/scheduler.CentroSchedulerService/SetInstanceData"
+	CentroSchedulerService_JoinNode_FullMethodName             = "/scheduler.CentroSchedulerService/JoinNode"
+	CentroSchedulerService_RenewCertificate_FullMethodName     = "/scheduler.CentroSchedulerService/RenewCertificate"
+	CentroSchedulerService_GetDeploymentSecrets_FullMethodName = "/scheduler.CentroSchedulerService/GetDeploymentSecrets"
+	CentroSchedulerService_GetDeploymentConfigs_FullMethodName = "/scheduler.CentroSchedulerService/GetDeploymentConfigs"
 )
 
 // CentroSchedulerServiceClient is the client API for CentroSchedulerService service.
@@ -39,6 +43,14 @@ type CentroSchedulerServiceClient interface {
 	UpdateStatus(ctx context.Context, in *UpdateStatusRequest, opts ...grpc.CallOption) (*UpdateStatusResponse, error)
 	// Agent sends instance inspection data after instance is running
 	SetInstanceData(ctx context.Context, in *SetInstanceDataRequest, opts ...grpc.CallOption) (*SetInstanceDataResponse, error)
+	// New node exchanges a join token and a CSR for a client certificate, the only call allowed without one
+	JoinNode(ctx context.Context, in *JoinNodeRequest, opts ...grpc.CallOption) (*JoinNodeResponse, error)
+	// Node exchanges a CSR for a new client certificate before its current one expires
+	RenewCertificate(ctx context.Context, in *RenewCertificateRequest, opts ...grpc.CallOption) (*RenewCertificateResponse, error)
+	// Agent gets the decrypted secrets of a deployment assigned to its node, right before starting it
+	GetDeploymentSecrets(ctx context.Context, in *GetDeploymentSecretsRequest, opts ...grpc.CallOption) (*GetDeploymentSecretsResponse, error)
+	// Agent gets the rendered config files of a deployment assigned to its node, right before starting it
+	GetDeploymentConfigs(ctx context.Context, in *GetDeploymentConfigsRequest, opts ...grpc.CallOption) (*GetDeploymentConfigsResponse, error)
 }
 
 type centroSchedulerServiceClient struct {
@@ -89,6 +101,46 @@ func (c *centroSchedulerServiceClient) SetInstanceData(ctx context.Context, in *
 	return out, nil
 }
 
+func (c *centroSchedulerServiceClient) JoinNode(ctx context.Context, in *JoinNodeRequest, opts ...grpc.CallOption) (*JoinNodeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(JoinNodeResponse)
+	err := c.cc.Invoke(ctx, CentroSchedulerService_JoinNode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *centroSchedulerServiceClient) RenewCertificate(ctx context.Context, in *RenewCertificateRequest, opts ...grpc.CallOption) (*RenewCertificateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenewCertificateResponse)
+	err := c.cc.Invoke(ctx, CentroSchedulerService_RenewCertificate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *centroSchedulerServiceClient) GetDeploymentSecrets(ctx context.Context, in *GetDeploymentSecretsRequest, opts ...grpc.CallOption) (*GetDeploymentSecretsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDeploymentSecretsResponse)
+	err := c.cc.Invoke(ctx, CentroSchedulerService_GetDeploymentSecrets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *centroSchedulerServiceClient) GetDeploymentConfigs(ctx context.Context, in *GetDeploymentConfigsRequest, opts ...grpc.CallOption) (*GetDeploymentConfigsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDeploymentConfigsResponse)
+	err := c.cc.Invoke(ctx, CentroSchedulerService_GetDeploymentConfigs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CentroSchedulerServiceServer is the server API for CentroSchedulerService service.
 // All implementations must embed UnimplementedCentroSchedulerServiceServer
 // for forward compatibility.
@@ -103,6 +155,14 @@ type CentroSchedulerServiceServer interface {
 	UpdateStatus(context.Context, *UpdateStatusRequest) (*UpdateStatusResponse, error)
 	// Agent sends instance inspection data after instance is running
 	SetInstanceData(context.Context, *SetInstanceDataRequest) (*SetInstanceDataResponse, error)
+	// New node exchanges a join token and a CSR for a client certificate, the only call allowed without one
+	JoinNode(context.Context, *JoinNodeRequest) (*JoinNodeResponse, error)
+	// Node exchanges a CSR for a new client certificate before its current one expires
+	RenewCertificate(context.Context, *RenewCertificateRequest) (*RenewCertificateResponse, error)
+	// Agent gets the decrypted secrets of a deployment assigned to its node, right before starting it
+	GetDeploymentSecrets(context.Context, *GetDeploymentSecretsRequest) (*GetDeploymentSecretsResponse, error)
+	// Agent gets the rendered config files of a deployment assigned to its node, right before starting it
+	GetDeploymentConfigs(context.Context, *GetDeploymentConfigsRequest) (*GetDeploymentConfigsResponse, error)
 	mustEmbedUnimplementedCentroSchedulerServiceServer()
 }
 
@@ -125,6 +185,18 @@ func (UnimplementedCentroSchedulerServiceServer) UpdateStatus(context.Context, *
 func (UnimplementedCentroSchedulerServiceServer) SetInstanceData(context.Context, *SetInstanceDataRequest) (*SetInstanceDataResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetInstanceData not implemented")
 }
+func (UnimplementedCentroSchedulerServiceServer) JoinNode(context.Context, *JoinNodeRequest) (*JoinNodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinNode not implemented")
+}
+func (UnimplementedCentroSchedulerServiceServer) RenewCertificate(context.Context, *RenewCertificateRequest) (*RenewCertificateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewCertificate not implemented")
+}
+func (UnimplementedCentroSchedulerServiceServer) GetDeploymentSecrets(context.Context, *GetDeploymentSecretsRequest) (*GetDeploymentSecretsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeploymentSecrets not implemented")
+}
+func (UnimplementedCentroSchedulerServiceServer) GetDeploymentConfigs(context.Context, *GetDeploymentConfigsRequest) (*GetDeploymentConfigsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeploymentConfigs not implemented")
+}
 func (UnimplementedCentroSchedulerServiceServer) mustEmbedUnimplementedCentroSchedulerServiceServer() {
 }
 func (UnimplementedCentroSchedulerServiceServer) testEmbeddedByValue() {}
@@ -219,6 +291,78 @@ func _CentroSchedulerService_SetInstanceData_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CentroSchedulerService_JoinNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinNodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CentroSchedulerServiceServer).JoinNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CentroSchedulerService_JoinNode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CentroSchedulerServiceServer).JoinNode(ctx, req.(*JoinNodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CentroSchedulerService_RenewCertificate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewCertificateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CentroSchedulerServiceServer).RenewCertificate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CentroSchedulerService_RenewCertificate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CentroSchedulerServiceServer).RenewCertificate(ctx, req.(*RenewCertificateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CentroSchedulerService_GetDeploymentSecrets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeploymentSecretsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CentroSchedulerServiceServer).GetDeploymentSecrets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CentroSchedulerService_GetDeploymentSecrets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CentroSchedulerServiceServer).GetDeploymentSecrets(ctx, req.(*GetDeploymentSecretsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CentroSchedulerService_GetDeploymentConfigs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeploymentConfigsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CentroSchedulerServiceServer).GetDeploymentConfigs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CentroSchedulerService_GetDeploymentConfigs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CentroSchedulerServiceServer).GetDeploymentConfigs(ctx, req.(*GetDeploymentConfigsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CentroSchedulerService_ServiceDesc is the grpc.ServiceDesc for CentroSchedulerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -242,6 +386,22 @@ var CentroSchedulerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SetInstanceData",
 			Handler:    _CentroSchedulerService_SetInstanceData_Handler,
 		},
+		{
+			MethodName: "JoinNode",
+			Handler:    _CentroSchedulerService_JoinNode_Handler,
+		},
+		{
+			MethodName: "RenewCertificate",
+			Handler:    _CentroSchedulerService_RenewCertificate_Handler,
+		},
+		{
+			MethodName: "GetDeploymentSecrets",
+			Handler:    _CentroSchedulerService_GetDeploymentSecrets_Handler,
+		},
+		{
+			MethodName: "GetDeploymentConfigs",
+			Handler:    _CentroSchedulerService_GetDeploymentConfigs_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/agent.proto",